@@ -3,6 +3,7 @@ import cors from "cors";
 import { openapiSpec } from "../src/openapi";
 import { swaggerHtml } from "../src/swagger";
 import {
+    auditActor,
     errorHandler,
     requestLogger,
     notFoundHandler,
@@ -18,9 +19,33 @@ import {
     loansRouter,
     borrowingsRouter,
     aiRouter,
+    projectsRouter,
+    registryRouter,
+    mobileRouter,
+    fixedIncomeRouter,
+    optionsRouter,
+    vestingRouter,
+    addressBookRouter,
+    allocationRouter,
+    activityRouter,
+    reportSubscriptionsRouter,
+    metaRouter,
+    recurringRouter,
+    budgetsRouter,
+    jobsRouter,
+    webhooksRouter,
+    sheetsExportRouter,
+    yearCloseRouter,
+    monthReviewRouter,
+    accountsRouter,
 } from "../src/handlers";
 import { settingsRepository } from "../src/repositories";
-import { vaultService, borrowingService } from "../src/services";
+import {
+    vaultService,
+    borrowingService,
+    webhookService,
+    accountBalanceService,
+} from "../src/services";
 import { initializeDatabase } from "../src/database/connection";
 import { setupMonitoring, setMetrics } from "../src/monitoring";
 import { logger } from "../src/utils/logger";
@@ -37,6 +62,8 @@ app.use(cors());
 // Increase body size limits for large JSON imports
 app.use(express.json({ limit: "4mb" }));
 app.use(express.urlencoded({ limit: "4mb", extended: true }));
+// Attribute each request's writes in the audit log
+app.use(auditActor());
 
 app.get("/health", (_req, res) =>
     res.json({
@@ -57,6 +84,25 @@ app.use("/api", [
     loansRouter,
     borrowingsRouter,
     aiRouter,
+    projectsRouter,
+    registryRouter,
+    mobileRouter,
+    fixedIncomeRouter,
+    optionsRouter,
+    vestingRouter,
+    addressBookRouter,
+    allocationRouter,
+    activityRouter,
+    reportSubscriptionsRouter,
+    metaRouter,
+    recurringRouter,
+    budgetsRouter,
+    jobsRouter,
+    webhooksRouter,
+    sheetsExportRouter,
+    yearCloseRouter,
+    monthReviewRouter,
+    accountsRouter,
 ]);
 
 // Metrics endpoint for Prometheus scraping
@@ -101,9 +147,15 @@ function ensureInitialized() {
         // Initialize borrowing settings
         settingsRepository.getBorrowingSettings();
 
+        // Catch account balances up with writes that bypassed them
+        accountBalanceService.rebuild();
+
         // Start auto-deduction scheduler for borrowings
         borrowingService.startAutoDeductionScheduler();
 
+        // Push transaction and vault events to registered webhooks
+        webhookService.start();
+
         logger.info("Initialization complete.");
         initialized = true;
     } catch (e) {
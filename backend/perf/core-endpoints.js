@@ -0,0 +1,77 @@
+/*
+  k6 load test for the core endpoints, enforcing the HTTP budgets in
+  budgets.json. k6 exits non-zero when a threshold is crossed.
+  - Requests arrive at a fixed rate; the run fails if the server cannot
+    keep up (dropped iterations) or errors exceed the budget
+  - Every request is tagged with its endpoint so each one has its own
+    p95 budget
+
+  Usage (against a running backend):
+    k6 run perf/core-endpoints.js
+    BASE_URL=http://localhost:8001 k6 run perf/core-endpoints.js
+*/
+
+import http from "k6/http";
+import { check } from "k6";
+
+const budgets = JSON.parse(open("./budgets.json")).http;
+const BASE_URL = __ENV.BASE_URL || "http://localhost:8080";
+const JSON_HEADERS = { headers: { "Content-Type": "application/json" } };
+
+const thresholds = {
+  http_req_failed: [`rate<${budgets.max_error_rate}`],
+  dropped_iterations: ["count==0"],
+};
+for (const [endpoint, b] of Object.entries(budgets.endpoints)) {
+  thresholds[`http_req_duration{endpoint:${endpoint}}`] = [
+    `p(95)<${b.p95_ms}`,
+  ];
+}
+
+export const options = {
+  scenarios: {
+    core: {
+      executor: "constant-arrival-rate",
+      rate: budgets.rate_per_sec,
+      timeUnit: "1s",
+      duration: budgets.duration,
+      preAllocatedVUs: 20,
+      maxVUs: 100,
+    },
+  },
+  thresholds,
+};
+
+// Reads outnumber writes roughly 4:1, as they do from the dashboard
+export default function () {
+  const n = __ITER % 5;
+  let res;
+  if (n === 0) {
+    res = http.post(
+      `${BASE_URL}/api/transactions/expense`,
+      JSON.stringify({
+        asset: { type: "FIAT", symbol: "USD" },
+        amount: 10 + (__ITER % 90),
+        category: "load-test",
+        note: `k6 iteration ${__ITER}`,
+      }),
+      { ...JSON_HEADERS, tags: { endpoint: "create_expense" } },
+    );
+    check(res, { "expense created": (r) => r.status === 201 });
+    return;
+  }
+  if (n === 1 || n === 2) {
+    res = http.get(`${BASE_URL}/api/transactions`, {
+      tags: { endpoint: "list_transactions" },
+    });
+  } else if (n === 3) {
+    res = http.get(`${BASE_URL}/api/reports/holdings`, {
+      tags: { endpoint: "holdings" },
+    });
+  } else {
+    res = http.get(`${BASE_URL}/api/accounts/balances`, {
+      tags: { endpoint: "account_balances" },
+    });
+  }
+  check(res, { "status is 200": (r) => r.status === 200 });
+}
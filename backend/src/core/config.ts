@@ -13,12 +13,20 @@ interface Config {
 
     // AI/Security
     backendSigningSecret?: string;
+    settingsEncryptionKey?: string;
 
     // Feature flags
     noExternalRates: boolean;
+    enableFixtures: boolean;
 
     // External API keys
     exchangeRateApiKey?: string;
+    alphaVantageApiKey?: string;
+    evmExplorerUrl: string; // Etherscan-compatible API for wallet syncs
+    evmExplorerApiKey?: string;
+
+    // Notifications
+    notifyWebhookUrl?: string;
 }
 
 /**
@@ -66,8 +74,17 @@ function loadConfig(): Config {
                 ? "database"
                 : "json",
         backendSigningSecret: process.env.BACKEND_SIGNING_SECRET,
+        settingsEncryptionKey: process.env.SETTINGS_ENCRYPTION_KEY,
         noExternalRates: getBool("NO_EXTERNAL_RATES", false),
+        enableFixtures: getBool("ENABLE_FIXTURES", false),
         exchangeRateApiKey: process.env.EXCHANGE_RATE_API_KEY,
+        alphaVantageApiKey: process.env.ALPHA_VANTAGE_API_KEY,
+        evmExplorerUrl: getEnv(
+            "EVM_EXPLORER_URL",
+            "https://api.etherscan.io/v2/api"
+        ),
+        evmExplorerApiKey: process.env.EVM_EXPLORER_API_KEY,
+        notifyWebhookUrl: process.env.NOTIFY_WEBHOOK_URL,
     };
 }
 
@@ -105,12 +122,30 @@ export const config = {
     get backendSigningSecret(): string | undefined {
         return getConfig().backendSigningSecret;
     },
+    get settingsEncryptionKey(): string | undefined {
+        return getConfig().settingsEncryptionKey;
+    },
     get noExternalRates(): boolean {
         return getConfig().noExternalRates;
     },
+    get enableFixtures(): boolean {
+        return getConfig().enableFixtures;
+    },
     get exchangeRateApiKey(): string | undefined {
         return getConfig().exchangeRateApiKey;
     },
+    get alphaVantageApiKey(): string | undefined {
+        return getConfig().alphaVantageApiKey;
+    },
+    get evmExplorerUrl(): string {
+        return getConfig().evmExplorerUrl;
+    },
+    get evmExplorerApiKey(): string | undefined {
+        return getConfig().evmExplorerApiKey;
+    },
+    get notifyWebhookUrl(): string | undefined {
+        return getConfig().notifyWebhookUrl;
+    },
     get isDevelopment(): boolean {
         return getConfig().nodeEnv !== "production";
     },
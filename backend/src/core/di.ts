@@ -11,6 +11,27 @@ import {
   IAdminRepository,
   IPendingActionsRepository,
   ISettingsRepository,
+  IProjectRepository,
+  IRegistryRepository,
+  IFixedIncomeRepository,
+  IOptionRepository,
+  IVestingRepository,
+  IAddressBookRepository,
+  IGlidepathRepository,
+  IDepositRateRepository,
+  ITrashRepository,
+  IReportSubscriptionRepository,
+  IMergeRepository,
+  IRecurringRepository,
+  IBudgetRepository,
+  IGoalRepository,
+  IReportingRepository,
+  IWebhookRepository,
+  IYearCloseRepository,
+  IMonthReviewRepository,
+  IAuditRepository,
+  IAccountBalanceRepository,
+  IActionIntentRepository,
 } from "../repositories/repository.interface";
 import {
   TransactionRepositoryDb,
@@ -38,7 +59,111 @@ import {
   SettingsRepositoryDb,
   SettingsRepositoryJson,
 } from "../repositories/settings.repository";
+import {
+  ProjectRepositoryDb,
+  ProjectRepositoryJson,
+} from "../repositories/project.repository";
+import {
+  RegistryRepositoryDb,
+  RegistryRepositoryJson,
+} from "../repositories/registry.repository";
+import {
+  FixedIncomeRepositoryDb,
+  FixedIncomeRepositoryJson,
+} from "../repositories/fixed-income.repository";
+import {
+  OptionRepositoryDb,
+  OptionRepositoryJson,
+} from "../repositories/option.repository";
+import {
+  VestingRepositoryDb,
+  VestingRepositoryJson,
+} from "../repositories/vesting.repository";
+import {
+  AddressBookRepositoryDb,
+  AddressBookRepositoryJson,
+} from "../repositories/address-book.repository";
+import {
+  GlidepathRepositoryDb,
+  GlidepathRepositoryJson,
+} from "../repositories/glidepath.repository";
+import {
+  DepositRateRepositoryDb,
+  DepositRateRepositoryJson,
+} from "../repositories/deposit-rate.repository";
+import {
+  TrashRepositoryDb,
+  TrashRepositoryJson,
+} from "../repositories/trash.repository";
+import {
+  ReportSubscriptionRepositoryDb,
+  ReportSubscriptionRepositoryJson,
+} from "../repositories/report-subscription.repository";
+import {
+  MergeRepositoryDb,
+  MergeRepositoryJson,
+} from "../repositories/merge.repository";
+import {
+  RecurringRepositoryDb,
+  RecurringRepositoryJson,
+} from "../repositories/recurring.repository";
+import {
+  BudgetRepositoryDb,
+  BudgetRepositoryJson,
+} from "../repositories/budget.repository";
+import {
+  GoalRepositoryDb,
+  GoalRepositoryJson,
+} from "../repositories/goal.repository";
+import {
+  ReportingRepositoryDb,
+  ReportingRepositoryJson,
+} from "../repositories/reporting.repository";
+import {
+  WebhookRepositoryDb,
+  WebhookRepositoryJson,
+} from "../repositories/webhook.repository";
+import {
+  YearCloseRepositoryDb,
+  YearCloseRepositoryJson,
+} from "../repositories/year-close.repository";
+import {
+  MonthReviewRepositoryDb,
+  MonthReviewRepositoryJson,
+} from "../repositories/month-review.repository";
+import {
+  AuditRepositoryDb,
+  AuditRepositoryJson,
+  AuditSpec,
+  withAudit,
+} from "../repositories/audit.repository";
+import {
+  AccountBalanceRepositoryDb,
+  AccountBalanceRepositoryJson,
+  withAccountBalances,
+} from "../repositories/account-balance.repository";
+import {
+  ActionIntentRepositoryDb,
+  ActionIntentRepositoryJson,
+} from "../repositories/action-intent.repository";
+import {
+  withStableLedgerNames,
+  withStableNames,
+} from "../repositories/stable-names.repository";
+import {
+  withTransactionRules,
+} from "../repositories/transaction-rules.repository";
+import { withFxSnapshots } from "../repositories/fx-snapshot.repository";
+import { priceCacheRepository } from "../repositories/price-cache.repository";
+import { DEFAULT_FX_CURRENCIES } from "../utils/fx.util";
+import { invalidateAssetKinds } from "../utils/asset.util";
+import {
+  withTransactionEvents,
+  withVaultEntryEvents,
+} from "../services/event-bus.service";
+import { accountDefaultsService } from "../services/account-defaults.service";
 import { config } from "./config";
+import { Asset } from "../types";
 
 /**
  * Repository factory interface
@@ -73,6 +198,43 @@ class DIContainer {
     typeof createPendingActionsRepository
   >;
   private _settingsRepository?: ReturnType<typeof createSettingsRepository>;
+  private _projectRepository?: ReturnType<typeof createProjectRepository>;
+  private _registryRepository?: ReturnType<typeof createRegistryRepository>;
+  private _fixedIncomeRepository?: ReturnType<
+    typeof createFixedIncomeRepository
+  >;
+  private _optionRepository?: ReturnType<typeof createOptionRepository>;
+  private _vestingRepository?: ReturnType<typeof createVestingRepository>;
+  private _addressBookRepository?: ReturnType<
+    typeof createAddressBookRepository
+  >;
+  private _glidepathRepository?: ReturnType<typeof createGlidepathRepository>;
+  private _depositRateRepository?: ReturnType<
+    typeof createDepositRateRepository
+  >;
+  private _trashRepository?: ReturnType<typeof createTrashRepository>;
+  private _reportSubscriptionRepository?: ReturnType<
+    typeof createReportSubscriptionRepository
+  >;
+  private _mergeRepository?: ReturnType<typeof createMergeRepository>;
+  private _recurringRepository?: ReturnType<typeof createRecurringRepository>;
+  private _budgetRepository?: ReturnType<typeof createBudgetRepository>;
+  private _goalRepository?: ReturnType<typeof createGoalRepository>;
+  private _reportingRepository?: ReturnType<typeof createReportingRepository>;
+  private _webhookRepository?: ReturnType<typeof createWebhookRepository>;
+  private _yearCloseRepository?: ReturnType<
+    typeof createYearCloseRepository
+  >;
+  private _monthReviewRepository?: ReturnType<
+    typeof createMonthReviewRepository
+  >;
+  private _auditRepository?: ReturnType<typeof createAuditRepository>;
+  private _accountBalanceRepository?: ReturnType<
+    typeof createAccountBalanceRepository
+  >;
+  private _actionIntentRepository?: ReturnType<
+    typeof createActionIntentRepository
+  >;
 
   // Transaction repository
   get transactionRepository() {
@@ -130,6 +292,174 @@ class DIContainer {
     return this._settingsRepository;
   }
 
+  // Project repository
+  get projectRepository() {
+    if (!this._projectRepository) {
+      this._projectRepository = createProjectRepository();
+    }
+    return this._projectRepository;
+  }
+
+  // Subscription / warranty registry repository
+  get registryRepository() {
+    if (!this._registryRepository) {
+      this._registryRepository = createRegistryRepository();
+    }
+    return this._registryRepository;
+  }
+
+  // Fixed income (term deposit / bond) repository
+  get fixedIncomeRepository() {
+    if (!this._fixedIncomeRepository) {
+      this._fixedIncomeRepository = createFixedIncomeRepository();
+    }
+    return this._fixedIncomeRepository;
+  }
+
+  // Options position repository
+  get optionRepository() {
+    if (!this._optionRepository) {
+      this._optionRepository = createOptionRepository();
+    }
+    return this._optionRepository;
+  }
+
+  // Vesting grant repository
+  get vestingRepository() {
+    if (!this._vestingRepository) {
+      this._vestingRepository = createVestingRepository();
+    }
+    return this._vestingRepository;
+  }
+
+  // Address book repository
+  get addressBookRepository() {
+    if (!this._addressBookRepository) {
+      this._addressBookRepository = createAddressBookRepository();
+    }
+    return this._addressBookRepository;
+  }
+
+  // Allocation glidepaths
+  get glidepathRepository() {
+    if (!this._glidepathRepository) {
+      this._glidepathRepository = createGlidepathRepository();
+    }
+    return this._glidepathRepository;
+  }
+
+  // Reference VND deposit rates
+  get depositRateRepository() {
+    if (!this._depositRateRepository) {
+      this._depositRateRepository = createDepositRateRepository();
+    }
+    return this._depositRateRepository;
+  }
+
+  // Trash (restorable deletions)
+  get trashRepository() {
+    if (!this._trashRepository) {
+      this._trashRepository = createTrashRepository();
+    }
+    return this._trashRepository;
+  }
+
+  // Report subscriptions (scheduled reports)
+  get reportSubscriptionRepository() {
+    if (!this._reportSubscriptionRepository) {
+      this._reportSubscriptionRepository = createReportSubscriptionRepository();
+    }
+    return this._reportSubscriptionRepository;
+  }
+
+  // Merges of duplicate admin entries
+  get mergeRepository() {
+    if (!this._mergeRepository) {
+      this._mergeRepository = createMergeRepository();
+    }
+    return this._mergeRepository;
+  }
+
+  // Recurring transactions (scheduled income and expenses)
+  get recurringRepository() {
+    if (!this._recurringRepository) {
+      this._recurringRepository = createRecurringRepository();
+    }
+    return this._recurringRepository;
+  }
+
+  // Monthly spending limits per tag
+  get budgetRepository() {
+    if (!this._budgetRepository) {
+      this._budgetRepository = createBudgetRepository();
+    }
+    return this._budgetRepository;
+  }
+
+  // Savings goals and what funds them
+  get goalRepository() {
+    if (!this._goalRepository) {
+      this._goalRepository = createGoalRepository();
+    }
+    return this._goalRepository;
+  }
+
+  // Read-only queries behind the history reports
+  get reportingRepository() {
+    if (!this._reportingRepository) {
+      this._reportingRepository = createReportingRepository();
+    }
+    return this._reportingRepository;
+  }
+
+  // Webhook repository
+  get webhookRepository() {
+    if (!this._webhookRepository) {
+      this._webhookRepository = createWebhookRepository();
+    }
+    return this._webhookRepository;
+  }
+
+  // Year close repository
+  get yearCloseRepository() {
+    if (!this._yearCloseRepository) {
+      this._yearCloseRepository = createYearCloseRepository();
+    }
+    return this._yearCloseRepository;
+  }
+
+  // Month review repository
+  get monthReviewRepository() {
+    if (!this._monthReviewRepository) {
+      this._monthReviewRepository = createMonthReviewRepository();
+    }
+    return this._monthReviewRepository;
+  }
+
+  // Audit log repository
+  get auditRepository() {
+    if (!this._auditRepository) {
+      this._auditRepository = createAuditRepository();
+    }
+    return this._auditRepository;
+  }
+
+  // Materialized account balances
+  get accountBalanceRepository() {
+    if (!this._accountBalanceRepository) {
+      this._accountBalanceRepository = createAccountBalanceRepository();
+    }
+    return this._accountBalanceRepository;
+  }
+
+  // Two-phase action intents
+  get actionIntentRepository() {
+    if (!this._actionIntentRepository) {
+      this._actionIntentRepository = createActionIntentRepository();
+    }
+    return this._actionIntentRepository;
+  }
+
   /**
    * Reset all repositories (useful for testing)
    */
@@ -141,22 +471,152 @@ class DIContainer {
     this._adminRepository = undefined;
     this._pendingActionsRepository = undefined;
     this._settingsRepository = undefined;
+    this._projectRepository = undefined;
+    this._registryRepository = undefined;
+    this._fixedIncomeRepository = undefined;
+    this._optionRepository = undefined;
+    this._vestingRepository = undefined;
+    this._addressBookRepository = undefined;
+    this._glidepathRepository = undefined;
+    this._depositRateRepository = undefined;
+    this._trashRepository = undefined;
+    this._reportSubscriptionRepository = undefined;
+    this._mergeRepository = undefined;
+    this._recurringRepository = undefined;
+    this._budgetRepository = undefined;
+    this._goalRepository = undefined;
+    this._reportingRepository = undefined;
+    this._webhookRepository = undefined;
+    this._yearCloseRepository = undefined;
+    this._monthReviewRepository = undefined;
+    this._auditRepository = undefined;
+    this._accountBalanceRepository = undefined;
+    this._actionIntentRepository = undefined;
+    invalidateAssetKinds();
   }
 }
 
+// Writes of audited repositories go to the audit log
+function audited<R extends object>(repo: R, specs: AuditSpec<R>[]): R {
+  return withAudit(repo, specs, (entries) =>
+    container.auditRepository.appendMany(entries),
+  );
+}
+
+// Stable names resolve admin accounts and assets through the container
+const stableNameDeps = () => ({
+  admin: container.adminRepository,
+  settings: container.settingsRepository,
+});
+
+// Snapshots use a cached rate no older than this, else wait for backfill
+const FX_SNAPSHOT_MAX_AGE_MS = 7 * 24 * 60 * 60 * 1000;
+
+function cachedUSDPerUnit(
+  currency: Asset,
+  atISO: string,
+): number | undefined {
+  const at = new Date(atISO);
+  const rate = priceCacheRepository.getLatestRate(currency, at);
+  if (!rate) return undefined;
+  const age = at.getTime() - new Date(rate.timestamp).getTime();
+  return age <= FX_SNAPSHOT_MAX_AGE_MS ? rate.rateUSD : undefined;
+}
+
 // Factory functions
+// New transactions go through the transaction rules and get an FX
+// snapshot and their account's FX margin (see
+// repositories/fx-snapshot.repository); writes record stable
+// account and asset ids (see repositories/stable-names.repository),
+// update account balances, then publish events (see
+// services/event-bus.service)
 function createTransactionRepository(): ITransactionRepository {
-  return createRepository<ITransactionRepository>({
-    createDb: () => new TransactionRepositoryDb(),
-    createJson: () => new TransactionRepositoryJson(),
-  });
+  return withTransactionEvents(
+    withAccountBalances(
+      withFxSnapshots(
+        withTransactionRules(
+          withStableNames(
+            audited(
+              createRepository<ITransactionRepository>({
+                createDb: () => new TransactionRepositoryDb(),
+                createJson: () => new TransactionRepositoryJson(),
+              }),
+              [
+                {
+                  entity: "transaction",
+                  find: (repo, id) => repo.findById(id),
+                  idOf: (t) => t.id,
+                  // A restored transaction is back as it was before its
+                  // deletion
+                  create: ["create", "createMany", "restore"],
+                  update: ["update"],
+                  remove: ["delete", "softDelete"],
+                  removeMany: [
+                    {
+                      method: "purgeDeleted",
+                      find: (repo, before: string) =>
+                        repo
+                          .findDeleted()
+                          .filter((t) => t.deletedAt! <= before),
+                    },
+                  ],
+                },
+              ],
+            ),
+            stableNameDeps,
+          ),
+          () => container.settingsRepository.getTransactionRules(),
+        ),
+        () => [
+          ...DEFAULT_FX_CURRENCIES,
+          container.settingsRepository.getReportingCurrency(),
+        ],
+        cachedUSDPerUnit,
+        () => accountDefaultsService.fxMargins(),
+      ),
+      () => container.accountBalanceRepository,
+    ),
+  );
 }
 
 function createVaultRepository(): IVaultRepository {
-  return createRepository<IVaultRepository>({
-    createDb: () => new VaultRepositoryDb(),
-    createJson: () => new VaultRepositoryJson(),
-  });
+  const repo = audited(
+    createRepository<IVaultRepository>({
+      createDb: () => new VaultRepositoryDb(),
+      createJson: () => new VaultRepositoryJson(),
+    }),
+    [
+      {
+        entity: "vault",
+        find: (repo, name) => repo.findByName(name),
+        idOf: (v) => v.name,
+        create: ["create"],
+        update: ["update"],
+        remove: ["delete"],
+      },
+      {
+        // Entries have no id of their own; vault and time name them
+        entity: "vault_entry",
+        find: () => undefined,
+        idOf: (e) => `${e.vault}@${e.at}`,
+        create: ["createEntry"],
+        removeMany: [
+          {
+            method: "deleteEntries",
+            find: (repo, params: { vault?: string; before?: string }) =>
+              (params.vault
+                ? repo.findAllEntries(params.vault)
+                : params.before
+                  ? repo.findAllEntriesUntil(params.before)
+                  : []
+              ).filter((e) => !params.before || e.at < params.before),
+          },
+          { method: "deleteEntry", find: (_repo, entry) => [entry] },
+        ],
+      },
+    ],
+  );
+  return withVaultEntryEvents(repo);
 }
 
 function createLoanRepository(): ILoanRepository {
@@ -173,11 +633,65 @@ function createBorrowingRepository(): IBorrowingRepository {
   });
 }
 
+// Asset writes drop the cached asset kinds (see utils/asset.util)
+function withAssetKinds(repo: IAdminRepository): IAdminRepository {
+  const wrapped: IAdminRepository = Object.create(repo);
+  for (const method of ["createAsset", "updateAsset", "deleteAsset"] as const) {
+    const write = (repo[method] as (...args: any[]) => any).bind(repo);
+    (wrapped as any)[method] = (...args: any[]) => {
+      try {
+        return write(...args);
+      } finally {
+        invalidateAssetKinds();
+      }
+    };
+  }
+  return wrapped;
+}
+
 function createAdminRepository(): IAdminRepository {
-  return createRepository<IAdminRepository>({
-    createDb: () => new AdminRepositoryDb(),
-    createJson: () => new AdminRepositoryJson(),
-  });
+  return audited(
+    withAssetKinds(
+      createRepository<IAdminRepository>({
+        createDb: () => new AdminRepositoryDb(),
+        createJson: () => new AdminRepositoryJson(),
+      }),
+    ),
+    [
+      {
+        entity: "admin_type",
+        find: (repo, id) => repo.findTypeById(id),
+        idOf: (t) => t.id,
+        create: ["createType"],
+        update: ["updateType"],
+        remove: ["deleteType"],
+      },
+      {
+        entity: "admin_account",
+        find: (repo, id) => repo.findAccountById(id),
+        idOf: (a) => a.id,
+        create: ["createAccount"],
+        update: ["updateAccount"],
+        remove: ["deleteAccount"],
+      },
+      {
+        entity: "admin_asset",
+        find: (repo, id) => repo.findAssetById(id),
+        idOf: (a) => a.id,
+        create: ["createAsset"],
+        update: ["updateAsset"],
+        remove: ["deleteAsset"],
+      },
+      {
+        entity: "admin_tag",
+        find: (repo, id) => repo.findTagById(id),
+        idOf: (t) => t.id,
+        create: ["createTag"],
+        update: ["updateTag"],
+        remove: ["deleteTag"],
+      },
+    ],
+  );
 }
 
 function createPendingActionsRepository(): IPendingActionsRepository {
@@ -194,6 +708,179 @@ function createSettingsRepository(): ISettingsRepository {
   });
 }
 
+function createProjectRepository(): IProjectRepository {
+  return createRepository<IProjectRepository>({
+    createDb: () => new ProjectRepositoryDb(),
+    createJson: () => new ProjectRepositoryJson(),
+  });
+}
+
+function createRegistryRepository(): IRegistryRepository {
+  return createRepository<IRegistryRepository>({
+    createDb: () => new RegistryRepositoryDb(),
+    createJson: () => new RegistryRepositoryJson(),
+  });
+}
+
+function createFixedIncomeRepository(): IFixedIncomeRepository {
+  return audited(
+    createRepository<IFixedIncomeRepository>({
+      createDb: () => new FixedIncomeRepositoryDb(),
+      createJson: () => new FixedIncomeRepositoryJson(),
+    }),
+    [
+      {
+        entity: "fixed_income",
+        find: (repo, id) => repo.findById(id),
+        idOf: (f) => f.id,
+        create: ["create"],
+        update: ["update"],
+        remove: ["delete"],
+      },
+    ],
+  );
+}
+
+function createOptionRepository(): IOptionRepository {
+  return audited(
+    createRepository<IOptionRepository>({
+      createDb: () => new OptionRepositoryDb(),
+      createJson: () => new OptionRepositoryJson(),
+    }),
+    [
+      {
+        entity: "option",
+        find: (repo, id) => repo.findById(id),
+        idOf: (o) => o.id,
+        create: ["create"],
+        update: ["update"],
+      },
+    ],
+  );
+}
+
+function createVestingRepository(): IVestingRepository {
+  return createRepository<IVestingRepository>({
+    createDb: () => new VestingRepositoryDb(),
+    createJson: () => new VestingRepositoryJson(),
+  });
+}
+
+function createAddressBookRepository(): IAddressBookRepository {
+  return createRepository<IAddressBookRepository>({
+    createDb: () => new AddressBookRepositoryDb(),
+    createJson: () => new AddressBookRepositoryJson(),
+  });
+}
+
+function createGlidepathRepository(): IGlidepathRepository {
+  return createRepository<IGlidepathRepository>({
+    createDb: () => new GlidepathRepositoryDb(),
+    createJson: () => new GlidepathRepositoryJson(),
+  });
+}
+
+function createDepositRateRepository(): IDepositRateRepository {
+  return createRepository<IDepositRateRepository>({
+    createDb: () => new DepositRateRepositoryDb(),
+    createJson: () => new DepositRateRepositoryJson(),
+  });
+}
+
+function createTrashRepository(): ITrashRepository {
+  return createRepository<ITrashRepository>({
+    createDb: () => new TrashRepositoryDb(),
+    createJson: () => new TrashRepositoryJson(),
+  });
+}
+
+function createReportSubscriptionRepository(): IReportSubscriptionRepository {
+  return createRepository<IReportSubscriptionRepository>({
+    createDb: () => new ReportSubscriptionRepositoryDb(),
+    createJson: () => new ReportSubscriptionRepositoryJson(),
+  });
+}
+
+function createMergeRepository(): IMergeRepository {
+  return createRepository<IMergeRepository>({
+    createDb: () => new MergeRepositoryDb(),
+    createJson: () => new MergeRepositoryJson(),
+  });
+}
+
+function createRecurringRepository(): IRecurringRepository {
+  return createRepository<IRecurringRepository>({
+    createDb: () => new RecurringRepositoryDb(),
+    createJson: () => new RecurringRepositoryJson(),
+  });
+}
+
+function createBudgetRepository(): IBudgetRepository {
+  return createRepository<IBudgetRepository>({
+    createDb: () => new BudgetRepositoryDb(),
+    createJson: () => new BudgetRepositoryJson(),
+  });
+}
+
+function createGoalRepository(): IGoalRepository {
+  return createRepository<IGoalRepository>({
+    createDb: () => new GoalRepositoryDb(),
+    createJson: () => new GoalRepositoryJson(),
+  });
+}
+
+function createReportingRepository(): IReportingRepository {
+  return withStableLedgerNames(
+    createRepository<IReportingRepository>({
+      createDb: () => new ReportingRepositoryDb(),
+      createJson: () => new ReportingRepositoryJson(),
+    }),
+    stableNameDeps,
+  );
+}
+
+function createWebhookRepository(): IWebhookRepository {
+  return createRepository<IWebhookRepository>({
+    createDb: () => new WebhookRepositoryDb(),
+    createJson: () => new WebhookRepositoryJson(),
+  });
+}
+
+function createYearCloseRepository(): IYearCloseRepository {
+  return createRepository<IYearCloseRepository>({
+    createDb: () => new YearCloseRepositoryDb(),
+    createJson: () => new YearCloseRepositoryJson(),
+  });
+}
+
+function createAuditRepository(): IAuditRepository {
+  return createRepository<IAuditRepository>({
+    createDb: () => new AuditRepositoryDb(),
+    createJson: () => new AuditRepositoryJson(),
+  });
+}
+
+function createAccountBalanceRepository(): IAccountBalanceRepository {
+  return createRepository<IAccountBalanceRepository>({
+    createDb: () => new AccountBalanceRepositoryDb(),
+    createJson: () => new AccountBalanceRepositoryJson(),
+  });
+}
+
+function createActionIntentRepository(): IActionIntentRepository {
+  return createRepository<IActionIntentRepository>({
+    createDb: () => new ActionIntentRepositoryDb(),
+    createJson: () => new ActionIntentRepositoryJson(),
+  });
+}
+
+function createMonthReviewRepository(): IMonthReviewRepository {
+  return createRepository<IMonthReviewRepository>({
+    createDb: () => new MonthReviewRepositoryDb(),
+    createJson: () => new MonthReviewRepositoryJson(),
+  });
+}
+
 // Singleton instance
 export const container = new DIContainer();
 
@@ -220,6 +907,69 @@ export const repositories = {
   get settings() {
     return container.settingsRepository;
   },
+  get project() {
+    return container.projectRepository;
+  },
+  get registry() {
+    return container.registryRepository;
+  },
+  get fixedIncome() {
+    return container.fixedIncomeRepository;
+  },
+  get option() {
+    return container.optionRepository;
+  },
+  get vesting() {
+    return container.vestingRepository;
+  },
+  get addressBook() {
+    return container.addressBookRepository;
+  },
+  get glidepath() {
+    return container.glidepathRepository;
+  },
+  get depositRate() {
+    return container.depositRateRepository;
+  },
+  get trash() {
+    return container.trashRepository;
+  },
+  get reportSubscription() {
+    return container.reportSubscriptionRepository;
+  },
+  get merge() {
+    return container.mergeRepository;
+  },
+  get recurring() {
+    return container.recurringRepository;
+  },
+  get budget() {
+    return container.budgetRepository;
+  },
+  get goal() {
+    return container.goalRepository;
+  },
+  get reporting() {
+    return container.reportingRepository;
+  },
+  get webhook() {
+    return container.webhookRepository;
+  },
+  get yearClose() {
+    return container.yearCloseRepository;
+  },
+  get monthReview() {
+    return container.monthReviewRepository;
+  },
+  get audit() {
+    return container.auditRepository;
+  },
+  get accountBalance() {
+    return container.accountBalanceRepository;
+  },
+  get actionIntent() {
+    return container.actionIntentRepository;
+  },
 };
 
 // Export for backward compatibility (will be deprecated)
@@ -230,6 +980,27 @@ export const borrowingRepository = repositories.borrowing;
 export const adminRepository = repositories.admin;
 export const pendingActionsRepository = repositories.pendingActions;
 export const settingsRepository = repositories.settings;
+export const projectRepository = repositories.project;
+export const registryRepository = repositories.registry;
+export const fixedIncomeRepository = repositories.fixedIncome;
+export const optionRepository = repositories.option;
+export const vestingRepository = repositories.vesting;
+export const addressBookRepository = repositories.addressBook;
+export const glidepathRepository = repositories.glidepath;
+export const depositRateRepository = repositories.depositRate;
+export const trashRepository = repositories.trash;
+export const reportSubscriptionRepository = repositories.reportSubscription;
+export const mergeRepository = repositories.merge;
+export const recurringRepository = repositories.recurring;
+export const budgetRepository = repositories.budget;
+export const goalRepository = repositories.goal;
+export const reportingRepository = repositories.reporting;
+export const webhookRepository = repositories.webhook;
+export const yearCloseRepository = repositories.yearClose;
+export const monthReviewRepository = repositories.monthReview;
+export const auditRepository = repositories.audit;
+export const accountBalanceRepository = repositories.accountBalance;
+export const actionIntentRepository = repositories.actionIntent;
 
 // Export repository classes for type imports and testing
 export {
@@ -258,3 +1029,87 @@ export {
   SettingsRepositoryJson,
   SettingsRepositoryDb,
 } from "../repositories/settings.repository";
+export {
+  ProjectRepositoryJson,
+  ProjectRepositoryDb,
+} from "../repositories/project.repository";
+export {
+  RegistryRepositoryJson,
+  RegistryRepositoryDb,
+} from "../repositories/registry.repository";
+export {
+  FixedIncomeRepositoryJson,
+  FixedIncomeRepositoryDb,
+} from "../repositories/fixed-income.repository";
+export {
+  OptionRepositoryJson,
+  OptionRepositoryDb,
+} from "../repositories/option.repository";
+export {
+  VestingRepositoryJson,
+  VestingRepositoryDb,
+} from "../repositories/vesting.repository";
+export {
+  AddressBookRepositoryJson,
+  AddressBookRepositoryDb,
+} from "../repositories/address-book.repository";
+export {
+  GlidepathRepositoryJson,
+  GlidepathRepositoryDb,
+} from "../repositories/glidepath.repository";
+export {
+  DepositRateRepositoryJson,
+  DepositRateRepositoryDb,
+} from "../repositories/deposit-rate.repository";
+export {
+  TrashRepositoryJson,
+  TrashRepositoryDb,
+} from "../repositories/trash.repository";
+export {
+  ReportSubscriptionRepositoryJson,
+  ReportSubscriptionRepositoryDb,
+} from "../repositories/report-subscription.repository";
+export {
+  MergeRepositoryJson,
+  MergeRepositoryDb,
+} from "../repositories/merge.repository";
+export {
+  RecurringRepositoryJson,
+  RecurringRepositoryDb,
+} from "../repositories/recurring.repository";
+export {
+  BudgetRepositoryJson,
+  BudgetRepositoryDb,
+} from "../repositories/budget.repository";
+export {
+  GoalRepositoryJson,
+  GoalRepositoryDb,
+} from "../repositories/goal.repository";
+export {
+  ReportingRepositoryJson,
+  ReportingRepositoryDb,
+} from "../repositories/reporting.repository";
+export {
+  WebhookRepositoryJson,
+  WebhookRepositoryDb,
+} from "../repositories/webhook.repository";
+export {
+  YearCloseRepositoryJson,
+  YearCloseRepositoryDb,
+} from "../repositories/year-close.repository";
+export {
+  MonthReviewRepositoryJson,
+  MonthReviewRepositoryDb,
+} from "../repositories/month-review.repository";
+export {
+  AuditRepositoryJson,
+  AuditRepositoryDb,
+} from "../repositories/audit.repository";
+export {
+  AccountBalanceRepositoryJson,
+  AccountBalanceRepositoryDb,
+} from "../repositories/account-balance.repository";
+export {
+  ActionIntentRepositoryJson,
+  ActionIntentRepositoryDb,
+} from "../repositories/action-intent.repository";
@@ -2,11 +2,29 @@
  * Express middleware for error handling, validation, and logging
  */
 
+import { createHash } from "crypto";
 import { Request, Response, NextFunction } from "express";
 import { ZodError } from "zod";
 import { AppError, isAppError, ValidationError } from "./errors";
 import { logger } from "./logger";
 import { config } from "./config";
+import {
+    applyDisplayPrecision,
+    DEFAULT_DISPLAY_PRECISION,
+} from "../utils/number.util";
+import {
+    PriceOverrides,
+    parsePriceOverrides,
+    withPriceOverrides,
+} from "../utils/price-override.util";
+import { withActor } from "../utils/audit-context.util";
+import {
+    BUILTIN_REPORT_CURRENCIES,
+    ISO_CURRENCY,
+    addCurrencyFigures,
+    ratesOnDays,
+    reportDays,
+} from "../utils/currency.util";
 
 /**
  * Standard error response format
@@ -122,6 +140,198 @@ export function validateRequest<T>(schema: { parse: (data: unknown) => T }) {
     };
 }
 
+/**
+ * Display precision middleware factory
+ * Rounds numeric JSON response fields per currency; ?raw=true skips rounding
+ */
+export function displayPrecision(getPrecision: () => Record<string, number>) {
+    return (req: Request, res: Response, next: NextFunction): void => {
+        if (String(req.query.raw || "").toLowerCase() === "true") {
+            next();
+            return;
+        }
+
+        const json = res.json.bind(res);
+        res.json = (body?: unknown) => {
+            let precision = DEFAULT_DISPLAY_PRECISION;
+            try {
+                precision = getPrecision();
+            } catch {
+                // Settings unavailable - fall back to defaults
+            }
+            return json(
+                applyDisplayPrecision(
+                    body,
+                    precision,
+                    res.locals.reportingCurrency
+                )
+            );
+        };
+        next();
+    };
+}
+
+/**
+ * Price override middleware factory
+ * Runs the request with ?prices=BTC:50000,ETH:2500 in place of market
+ * rates, for stress-testing reports; stored prices are left untouched
+ */
+export function priceOverrides() {
+    return (req: Request, res: Response, next: NextFunction): void => {
+        const text = req.query.prices ? String(req.query.prices) : "";
+        if (!text.trim()) {
+            next();
+            return;
+        }
+
+        let overrides: PriceOverrides;
+        try {
+            overrides = parsePriceOverrides(text);
+        } catch (err: any) {
+            next(new ValidationError(err.message, { prices: text }));
+            return;
+        }
+        // Tell clients the figures are hypothetical
+        res.setHeader(
+            "X-Price-Overrides",
+            Array.from(overrides, ([s, p]) => `${s}=${p}`).join(",")
+        );
+        withPriceOverrides(overrides, next);
+    };
+}
+
+/**
+ * Reporting currency middleware factory
+ * With ?currency=EUR (default: the reporting currency setting) adds a
+ * <name>_eur figure beside each <name>_usd one, at the FX rate of each
+ * row's date. `usdPerUnit` resolves today's rate, undefined when unknown.
+ * `history` gives the rates from the first to the last row's day in one
+ * call; a day without one takes the nearest earlier rate. USD and VND are
+ * in every report already, so they pass through.
+ */
+export function reportingCurrency(
+    getDefault: () => string,
+    usdPerUnit: (currency: string) => Promise<number | undefined>,
+    history: (
+        currency: string,
+        start: string,
+        end: string
+    ) => Promise<Array<{ day: string; rate: number }>>
+) {
+    return (req: Request, res: Response, next: NextFunction): void => {
+        const requested = req.query.currency
+            ? String(req.query.currency).trim().toUpperCase()
+            : undefined;
+        if (requested !== undefined && !ISO_CURRENCY.test(requested)) {
+            next(
+                new ValidationError("currency must be an ISO 4217 code", {
+                    currency: requested,
+                })
+            );
+            return;
+        }
+        let currency = requested;
+        if (!currency) {
+            try {
+                currency = getDefault().toUpperCase();
+            } catch {
+                // Settings unavailable - report in USD and VND only
+            }
+        }
+        if (
+            !currency ||
+            !ISO_CURRENCY.test(currency) ||
+            BUILTIN_REPORT_CURRENCIES.includes(currency)
+        ) {
+            next();
+            return;
+        }
+        const code = currency;
+
+        usdPerUnit(code)
+            .then((today) => {
+                if (!today) {
+                    next(
+                        new ValidationError(`No FX rate for ${code}`, {
+                            currency: code,
+                        })
+                    );
+                    return;
+                }
+                res.locals.reportingCurrency = code;
+                res.setHeader("X-Reporting-Currency", code);
+
+                const json = res.json.bind(res);
+                res.json = (body?: unknown) => {
+                    if (res.statusCode >= 400) return json(body);
+                    const days = Array.from(reportDays(body)).sort();
+                    const rates = days.length
+                        ? history(code, days[0], days[days.length - 1])
+                        : Promise.resolve([]);
+                    rates
+                        .catch(() => [])
+                        .then((h) => {
+                            const byDay = ratesOnDays(days, h);
+                            const perUSD = (day?: string) =>
+                                1 / ((day && byDay.get(day)) || today);
+                            json(addCurrencyFigures(body, code, perUSD));
+                        })
+                        .catch(() => json(body));
+                    return res;
+                };
+                next();
+            })
+            .catch(next);
+    };
+}
+
+/**
+ * Conditional GET middleware factory
+ * Sets a strong ETag on JSON responses and answers If-None-Match with 304.
+ * The ETag is the hash of the body built for this request, so it changes
+ * as soon as a write changes the response.
+ */
+export function etagCache() {
+    const matches = (req: Request, etag: string): boolean =>
+        String(req.headers["if-none-match"] || "")
+            .split(",")
+            .map((t) => t.trim().replace(/^W\//, ""))
+            .some((t) => t === etag || t === "*");
+
+    return (req: Request, res: Response, next: NextFunction): void => {
+        if (req.method !== "GET") {
+            next();
+            return;
+        }
+
+        const json = res.json.bind(res);
+        res.json = (body?: unknown) => {
+            if (res.statusCode !== 200) return json(body);
+            const hash = createHash("sha1")
+                .update(JSON.stringify(body ?? null))
+                .digest("base64url");
+            const etag = `"${hash}"`;
+            res.setHeader("ETag", etag);
+            res.setHeader("Cache-Control", "private, no-cache");
+            if (matches(req, etag)) return res.status(304).end();
+            return json(body);
+        };
+        next();
+    };
+}
+
+/**
+ * Audit actor middleware factory
+ * Runs the request on behalf of its X-Nami-User header (or "api"), which
+ * the audit log records with every write the request makes
+ */
+export function auditActor() {
+    return (req: Request, res: Response, next: NextFunction): void => {
+        const user = String(req.header("X-Nami-User") || "").trim();
+        withActor(user.slice(0, 100) || "api", next);
+    };
+}
+
 /**
  * 404 handler for unmatched routes
  */
@@ -7,6 +7,23 @@ const DB_PATH = path.join(DATA_DIR, "nami.db");
 
 let db: Database.Database | null = null;
 
+// Compiled statements by SQL text, least recently used first. Repositories
+// send the same few SQL strings on every request, so each is parsed and
+// planned once per connection instead of once per call. Filters that
+// build SQL per request can add many one-off strings; the bound keeps
+// those from piling up.
+export const STATEMENT_CACHE_SIZE = 500;
+const statements = new Map<string, Database.Statement>();
+let statementHits = 0;
+let statementMisses = 0;
+
+export interface StatementCacheStats {
+  size: number;
+  capacity: number;
+  hits: number;
+  misses: number;
+}
+
 export function getConnection(dbPath?: string): Database.Database {
   if (!db) {
     const actualPath = dbPath || DB_PATH;
@@ -34,12 +51,150 @@ export function getConnection(dbPath?: string): Database.Database {
 }
 
 export function closeConnection(): void {
+  statements.clear(); // statements belong to the connection they came from
   if (db) {
     db.close();
     db = null;
   }
 }
 
+/** Prepare `sql` once and reuse the statement on later calls. */
+export function prepareCached(sql: string): Database.Statement {
+  const cached = statements.get(sql);
+  if (cached) {
+    statementHits++;
+    // Move to the back so the least recently used is evicted first
+    statements.delete(sql);
+    statements.set(sql, cached);
+    return cached;
+  }
+  statementMisses++;
+  const stmt = getConnection().prepare(sql);
+  if (statements.size >= STATEMENT_CACHE_SIZE) {
+    statements.delete(statements.keys().next().value as string);
+  }
+  statements.set(sql, stmt);
+  return stmt;
+}
+
+// Forget compiled statements, e.g. to time the uncached path
+export function clearStatementCache(): void {
+  statements.clear();
+}
+
+export function statementCacheStats(): StatementCacheStats {
+  return {
+    size: statements.size,
+    capacity: STATEMENT_CACHE_SIZE,
+    hits: statementHits,
+    misses: statementMisses,
+  };
+}
+
+// Columns added after a table was first created. CREATE TABLE IF NOT EXISTS
+// won't add them to existing databases, so they are added here on startup.
+const COLUMN_MIGRATIONS: Array<{
+  table: string;
+  column: string;
+  definition: string;
+}> = [
+  { table: "vaults", column: "tags", definition: "TEXT" },
+  { table: "vaults", column: "account", definition: "TEXT" },
+  { table: "vaults", column: "benchmark", definition: "TEXT" },
+  { table: "vault_entries", column: "lots", definition: "TEXT" },
+  {
+    table: "transactions",
+    column: "reimbursable",
+    definition: "INTEGER NOT NULL DEFAULT 0",
+  },
+  { table: "transactions", column: "reimburses_id", definition: "TEXT" },
+  { table: "transactions", column: "project_id", definition: "TEXT" },
+  { table: "transactions", column: "chain", definition: "TEXT" },
+  { table: "transactions", column: "updated_at", definition: "TEXT" },
+  { table: "transactions", column: "card", definition: "TEXT" },
+  { table: "transactions", column: "fee_usd", definition: "REAL" },
+  { table: "transactions", column: "place", definition: "TEXT" },
+  { table: "transactions", column: "latitude", definition: "REAL" },
+  { table: "transactions", column: "longitude", definition: "REAL" },
+  { table: "transactions", column: "reviewed_at", definition: "TEXT" },
+  { table: "transactions", column: "member", definition: "TEXT" },
+  { table: "transactions", column: "deleted_at", definition: "TEXT" },
+  { table: "transactions", column: "account_id", definition: "INTEGER" },
+  { table: "transactions", column: "asset_id", definition: "INTEGER" },
+  { table: "transactions", column: "internal_flow", definition: "INTEGER" },
+  { table: "transactions", column: "fx", definition: "TEXT" },
+  { table: "transactions", column: "fx_margin", definition: "REAL" },
+  { table: "admin_accounts", column: "institution", definition: "TEXT" },
+  { table: "admin_accounts", column: "jurisdiction", definition: "TEXT" },
+  { table: "admin_accounts", column: "default_asset", definition: "TEXT" },
+  {
+    table: "admin_accounts",
+    column: "fx_margin_percent",
+    definition: "REAL",
+  },
+  { table: "admin_assets", column: "jurisdiction", definition: "TEXT" },
+  { table: "admin_assets", column: "kind", definition: "TEXT" },
+  { table: "recurring_transactions", column: "drift", definition: "TEXT" },
+  { table: "action_intents", column: "vault_entries", definition: "TEXT" },
+];
+
+function applyColumnMigrations(connection: Database.Database): void {
+  for (const m of COLUMN_MIGRATIONS) {
+    const columns = connection
+      .prepare(`PRAGMA table_info(${m.table})`)
+      .all() as Array<{ name: string }>;
+    if (columns.some((c) => c.name === m.column)) continue;
+    connection.exec(
+      `ALTER TABLE ${m.table} ADD COLUMN ${m.column} ${m.definition}`,
+    );
+  }
+}
+
+// Tables whose CHECK constraints changed, with a fragment only the current
+// definition contains. SQLite can't alter a constraint, so an outdated
+// table is copied into a fresh one created from schema.sql.
+const TABLE_REBUILDS: Array<{ table: string; marker: string }> = [
+  { table: "price_cache", marker: "'YAHOO_FINANCE'" },
+];
+
+function applyTableRebuilds(
+  connection: Database.Database,
+  schema: string,
+): boolean {
+  let rebuilt = false;
+  for (const r of TABLE_REBUILDS) {
+    const current = connection
+      .prepare(
+        "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?",
+      )
+      .get(r.table) as { sql: string } | undefined;
+    if (!current || current.sql.includes(r.marker)) continue;
+    const head = `CREATE TABLE IF NOT EXISTS ${r.table} (`;
+    const start = schema.indexOf(head);
+    if (start < 0) continue;
+    const create = schema.slice(start, schema.indexOf("\n);", start) + 3);
+
+    const columns = (
+      connection.prepare(`PRAGMA table_info(${r.table})`).all() as Array<{
+        name: string;
+      }>
+    )
+      .map((c) => c.name)
+      .join(", ");
+    connection.transaction(() => {
+      connection.exec(`ALTER TABLE ${r.table} RENAME TO ${r.table}_old`);
+      connection.exec(create);
+      connection.exec(
+        `INSERT INTO ${r.table} (${columns}) ` +
+          `SELECT ${columns} FROM ${r.table}_old`,
+      );
+      connection.exec(`DROP TABLE ${r.table}_old`);
+    })();
+    rebuilt = true;
+  }
+  return rebuilt;
+}
+
 export function initializeDatabase(schemaPath?: string): void {
   const connection = getConnection();
   const actualSchemaPath = schemaPath || path.join(__dirname, "schema.sql");
@@ -47,6 +202,9 @@ export function initializeDatabase(schemaPath?: string): void {
 
   // Always execute schema since it uses IF NOT EXISTS and is safe to re-run.
   connection.exec(schema);
+  applyColumnMigrations(connection);
+  // Indexes of a rebuilt table went with the old copy
+  if (applyTableRebuilds(connection, schema)) connection.exec(schema);
   console.log("Database schema initialized");
 }
 
@@ -20,7 +20,25 @@ CREATE TABLE IF NOT EXISTS transactions (
   source_ref TEXT UNIQUE,
   repay_direction TEXT CHECK(repay_direction IN ('BORROW', 'LOAN')),
   rate TEXT NOT NULL,
-  usd_amount REAL NOT NULL
+  usd_amount REAL NOT NULL,
+  reimbursable INTEGER NOT NULL DEFAULT 0,
+  reimburses_id TEXT,
+  project_id TEXT,
+  chain TEXT,
+  updated_at TEXT,
+  card TEXT,
+  fee_usd REAL,
+  place TEXT,
+  latitude REAL,
+  longitude REAL,
+  reviewed_at TEXT,
+  member TEXT,
+  deleted_at TEXT, -- soft delete; NULL for live rows
+  account_id INTEGER,
+  asset_id INTEGER,
+  internal_flow INTEGER, -- set by a rule; NULL derives it from transfer_id
+  fx TEXT, -- JSON {currency: value of one unit} when recorded; USD is rate
+  fx_margin REAL -- percent the account charged over the rates above
 );
 
 -- Indexes for transactions
@@ -31,12 +49,17 @@ CREATE INDEX IF NOT EXISTS idx_transactions_loan_id ON transactions(loan_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_source_ref ON transactions(source_ref);
 CREATE INDEX IF NOT EXISTS idx_transactions_composite_date_type ON transactions(created_at, type, account);
 CREATE INDEX IF NOT EXISTS idx_transactions_composite_account_date ON transactions(account, created_at DESC);
+-- Keyset pagination (findPage)
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON transactions(created_at DESC, id DESC);
 
 -- Vault configurations
 CREATE TABLE IF NOT EXISTS vaults (
   name TEXT PRIMARY KEY,
   status TEXT NOT NULL CHECK(status IN ('ACTIVE', 'CLOSED')),
-  created_at TEXT NOT NULL
+  created_at TEXT NOT NULL,
+  tags TEXT, -- JSON array of strategy labels
+  account TEXT, -- account the vault is held in; vaults roll up to it
+  benchmark TEXT -- symbol performance is compared against, e.g. BTC, SPY
 );
 
 CREATE INDEX IF NOT EXISTS idx_vaults_status ON vaults(status);
@@ -52,7 +75,8 @@ CREATE TABLE IF NOT EXISTS vault_entries (
   usd_value REAL NOT NULL,
   at TEXT NOT NULL,
   account TEXT,
-  note TEXT
+  note TEXT,
+  lots TEXT -- JSON array of {lot, amount} closed by a SPECIFIC withdrawal
 );
 
 -- Critical composite index for the slow summary endpoint
@@ -115,6 +139,10 @@ CREATE TABLE IF NOT EXISTS admin_accounts (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   name TEXT NOT NULL UNIQUE,
   type TEXT,
+  institution TEXT,
+  jurisdiction TEXT,
+  default_asset TEXT,
+  fx_margin_percent REAL, -- over mid-market on foreign-currency transactions
   is_active INTEGER NOT NULL DEFAULT 1,
   created_at TEXT NOT NULL
 );
@@ -124,6 +152,8 @@ CREATE TABLE IF NOT EXISTS admin_assets (
   symbol TEXT NOT NULL UNIQUE,
   name TEXT,
   decimals INTEGER NOT NULL DEFAULT 8,
+  kind TEXT CHECK(kind IN ('FIAT', 'STABLECOIN', 'CRYPTO', 'EQUITY', 'OTHER')),
+  jurisdiction TEXT,
   is_active INTEGER NOT NULL DEFAULT 1,
   created_at TEXT NOT NULL
 );
@@ -157,6 +187,346 @@ CREATE INDEX IF NOT EXISTS idx_pending_actions_status ON pending_actions(status)
 CREATE INDEX IF NOT EXISTS idx_pending_actions_batch_id ON pending_actions(batch_id);
 CREATE INDEX IF NOT EXISTS idx_pending_actions_composite_status_batch ON pending_actions(status, batch_id);
 
+-- Trips / projects grouping transactions
+CREATE TABLE IF NOT EXISTS projects (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  kind TEXT NOT NULL CHECK(kind IN ('TRIP', 'PROJECT')),
+  start_at TEXT,
+  end_at TEXT,
+  note TEXT,
+  status TEXT NOT NULL CHECK(status IN ('ACTIVE', 'CLOSED')),
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_status ON projects(status);
+
+-- Subscription / warranty registry
+CREATE TABLE IF NOT EXISTS registry_items (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL CHECK(kind IN ('SUBSCRIPTION', 'WARRANTY')),
+  name TEXT NOT NULL,
+  transaction_id TEXT,
+  asset_type TEXT,
+  asset_symbol TEXT,
+  amount REAL,
+  cadence TEXT CHECK(cadence IN ('WEEKLY', 'MONTHLY', 'QUARTERLY', 'YEARLY')),
+  next_renewal_at TEXT,
+  cancel_by TEXT,
+  warranty_expires_at TEXT,
+  status TEXT NOT NULL CHECK(status IN ('ACTIVE', 'CANCELLED', 'EXPIRED')),
+  note TEXT,
+  last_notified_at TEXT,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_items_status ON registry_items(status);
+CREATE INDEX IF NOT EXISTS idx_registry_items_next_renewal ON registry_items(next_renewal_at);
+
+-- Fixed income instruments (term deposits, bonds)
+CREATE TABLE IF NOT EXISTS fixed_income_instruments (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL CHECK(kind IN ('TERM_DEPOSIT', 'BOND')),
+  name TEXT NOT NULL,
+  issuer TEXT,
+  asset_type TEXT NOT NULL,
+  asset_symbol TEXT NOT NULL,
+  face_value REAL NOT NULL,
+  coupon_rate REAL NOT NULL,
+  coupon_frequency TEXT NOT NULL CHECK(coupon_frequency IN ('MONTHLY', 'QUARTERLY', 'SEMIANNUAL', 'ANNUAL', 'AT_MATURITY')),
+  start_at TEXT NOT NULL,
+  maturity_at TEXT NOT NULL,
+  account TEXT,
+  next_coupon_at TEXT,
+  status TEXT NOT NULL CHECK(status IN ('ACTIVE', 'MATURED', 'CLOSED')),
+  note TEXT,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_fixed_income_status ON fixed_income_instruments(status);
+CREATE INDEX IF NOT EXISTS idx_fixed_income_maturity ON fixed_income_instruments(maturity_at);
+
+-- Options positions
+CREATE TABLE IF NOT EXISTS option_positions (
+  id TEXT PRIMARY KEY,
+  underlying_type TEXT NOT NULL,
+  underlying_symbol TEXT NOT NULL,
+  option_type TEXT NOT NULL CHECK(option_type IN ('CALL', 'PUT')),
+  side TEXT NOT NULL CHECK(side IN ('LONG', 'SHORT')),
+  contracts REAL NOT NULL,
+  multiplier REAL NOT NULL,
+  strike REAL NOT NULL,
+  expiry TEXT NOT NULL,
+  premium REAL NOT NULL,
+  premium_asset_type TEXT NOT NULL,
+  premium_asset_symbol TEXT NOT NULL,
+  premium_usd REAL NOT NULL,
+  account TEXT NOT NULL,
+  status TEXT NOT NULL CHECK(status IN ('OPEN', 'EXPIRED', 'EXERCISED')),
+  opened_at TEXT NOT NULL,
+  settled_at TEXT,
+  settlement_price REAL,
+  realized_pnl_usd REAL,
+  note TEXT,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_option_positions_status ON option_positions(status);
+CREATE INDEX IF NOT EXISTS idx_option_positions_expiry ON option_positions(expiry);
+
+-- Employer equity grants (RSU, ESPP) and their vest/purchase events
+CREATE TABLE IF NOT EXISTS vesting_grants (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL CHECK(kind IN ('RSU', 'ESPP')),
+  employer TEXT NOT NULL,
+  asset_type TEXT NOT NULL,
+  asset_symbol TEXT NOT NULL,
+  cash_asset_type TEXT NOT NULL,
+  cash_asset_symbol TEXT NOT NULL,
+  account TEXT NOT NULL,
+  total_units REAL NOT NULL,
+  grant_at TEXT NOT NULL,
+  vest_start_at TEXT NOT NULL,
+  cliff_months INTEGER NOT NULL,
+  vesting_months INTEGER NOT NULL,
+  frequency_months INTEGER NOT NULL,
+  withholding_rate REAL NOT NULL,
+  discount REAL NOT NULL,
+  status TEXT NOT NULL CHECK(status IN ('ACTIVE', 'COMPLETED', 'CANCELLED')),
+  note TEXT,
+  created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS vest_events (
+  id TEXT PRIMARY KEY,
+  grant_id TEXT NOT NULL REFERENCES vesting_grants(id) ON DELETE CASCADE,
+  vest_at TEXT NOT NULL,
+  units REAL NOT NULL,
+  status TEXT NOT NULL CHECK(status IN ('SCHEDULED', 'VESTED', 'CANCELLED')),
+  fmv REAL,
+  purchase_price REAL,
+  withheld_units REAL,
+  net_units REAL,
+  income_usd REAL,
+  transfer_id TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_vest_events_grant ON vest_events(grant_id);
+CREATE INDEX IF NOT EXISTS idx_vest_events_status_date ON vest_events(status, vest_at);
+
+-- Address book of transfer destinations
+CREATE TABLE IF NOT EXISTS address_book (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  kind TEXT NOT NULL CHECK(kind IN ('EXCHANGE_DEPOSIT', 'BANK_ACCOUNT', 'WALLET', 'OTHER')),
+  address TEXT NOT NULL,
+  owner TEXT,
+  chain TEXT,
+  asset TEXT,
+  account TEXT,
+  note TEXT,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_book_address ON address_book(address);
+
+-- Allocation glidepaths (scheduled target share per asset class)
+CREATE TABLE IF NOT EXISTS allocation_glidepaths (
+  id TEXT PRIMARY KEY,
+  asset_class TEXT NOT NULL UNIQUE CHECK(asset_class IN ('FIAT', 'STABLECOIN', 'CRYPTO', 'EQUITY', 'OTHER')),
+  start_percent REAL NOT NULL,
+  end_percent REAL NOT NULL,
+  start_at TEXT NOT NULL,
+  end_at TEXT NOT NULL,
+  note TEXT,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+-- Reference VND term-deposit rates (cash-drag benchmark)
+CREATE TABLE IF NOT EXISTS deposit_rates (
+  id TEXT PRIMARY KEY,
+  effective_date TEXT NOT NULL, -- YYYY-MM-DD
+  term_months INTEGER NOT NULL,
+  rate_percent REAL NOT NULL,
+  bank TEXT,
+  note TEXT,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_deposit_rates_term_date ON deposit_rates(term_months, effective_date);
+
+-- Trash: deleted/overwritten data restorable until it expires
+CREATE TABLE IF NOT EXISTS trash (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL CHECK(kind IN ('VAULT', 'SETTINGS')),
+  label TEXT NOT NULL,
+  payload TEXT NOT NULL, -- JSON
+  deleted_at TEXT NOT NULL,
+  expires_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trash_expires_at ON trash(expires_at);
+
+-- Report subscriptions: saved report configurations delivered on a schedule
+CREATE TABLE IF NOT EXISTS report_subscriptions (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  report TEXT NOT NULL,
+  period_mode TEXT NOT NULL DEFAULT 'none',
+  filters TEXT, -- JSON
+  currency TEXT NOT NULL DEFAULT 'USD' CHECK(currency IN ('USD', 'VND')),
+  cadence TEXT NOT NULL CHECK(cadence IN ('WEEKLY', 'MONTHLY', 'QUARTERLY', 'YEARLY')),
+  next_run_at TEXT NOT NULL,
+  active INTEGER NOT NULL DEFAULT 1,
+  last_run_at TEXT,
+  last_status TEXT CHECK(last_status IN ('OK', 'FAILED')),
+  last_error TEXT,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_subscriptions_next_run ON report_subscriptions(next_run_at);
+
+-- Recurring transactions (rent, salary, subscriptions) on an RRULE-like rule
+CREATE TABLE IF NOT EXISTS recurring_transactions (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  type TEXT NOT NULL CHECK(type IN ('INCOME', 'EXPENSE')),
+  asset_type TEXT NOT NULL CHECK(asset_type IN ('CRYPTO', 'FIAT')),
+  asset_symbol TEXT NOT NULL,
+  amount REAL NOT NULL,
+  account TEXT,
+  note TEXT,
+  category TEXT,
+  tags TEXT, -- JSON array
+  counterparty TEXT,
+  rule TEXT NOT NULL,
+  start_at TEXT NOT NULL,
+  next_run_at TEXT,
+  occurrences INTEGER NOT NULL DEFAULT 0,
+  confirm INTEGER NOT NULL DEFAULT 0,
+  pending_dates TEXT, -- JSON array of due dates awaiting confirmation
+  active INTEGER NOT NULL DEFAULT 1,
+  last_occurrence_at TEXT,
+  last_error TEXT,
+  drift TEXT, -- JSON RecurringDrift
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_transactions_next_run ON recurring_transactions(next_run_at);
+
+-- Monthly spending limits per spending tag
+CREATE TABLE IF NOT EXISTS budgets (
+  id TEXT PRIMARY KEY,
+  tag TEXT NOT NULL UNIQUE,
+  amount REAL NOT NULL,
+  currency TEXT NOT NULL CHECK(currency IN ('USD', 'VND')),
+  note TEXT,
+  active INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+-- Savings goals funded by linked accounts and tagged contributions
+CREATE TABLE IF NOT EXISTS goals (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  target_amount REAL NOT NULL,
+  currency TEXT NOT NULL CHECK(currency IN ('USD', 'VND')),
+  target_date TEXT, -- YYYY-MM-DD
+  accounts TEXT NOT NULL DEFAULT '[]', -- JSON array of account names
+  tags TEXT NOT NULL DEFAULT '[]', -- JSON array of tags
+  note TEXT,
+  active INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT NOT NULL,
+  updated_at TEXT
+);
+
+-- Webhooks receiving domain events
+CREATE TABLE IF NOT EXISTS webhooks (
+  id TEXT PRIMARY KEY,
+  url TEXT NOT NULL,
+  events TEXT NOT NULL, -- JSON array of event types
+  secret TEXT NOT NULL,
+  description TEXT,
+  active INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT NOT NULL,
+  updated_at TEXT,
+  last_delivery_at TEXT,
+  last_status INTEGER,
+  last_error TEXT
+);
+
+-- Closed fiscal years with their frozen year-end snapshot
+CREATE TABLE IF NOT EXISTS year_closes (
+  year INTEGER PRIMARY KEY,
+  start_at TEXT NOT NULL,
+  end_at TEXT NOT NULL,
+  closed_at TEXT NOT NULL,
+  auto INTEGER NOT NULL DEFAULT 0,
+  note TEXT,
+  snapshot TEXT NOT NULL -- JSON YearCloseSnapshot
+);
+
+-- Months signed off in the monthly review
+CREATE TABLE IF NOT EXISTS month_reviews (
+  month TEXT PRIMARY KEY, -- YYYY-MM
+  reviewed_at TEXT NOT NULL,
+  transaction_count INTEGER NOT NULL DEFAULT 0,
+  note TEXT
+);
+
+-- Audit log of writes to audited records (see services/audit.service)
+CREATE TABLE IF NOT EXISTS audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  at TEXT NOT NULL,
+  entity TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  action TEXT NOT NULL CHECK(action IN ('create', 'update', 'delete')),
+  actor TEXT NOT NULL,
+  before TEXT, -- JSON record before the write
+  after TEXT -- JSON record after the write
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+
+-- Current units per account and asset, updated on every transaction write
+CREATE TABLE IF NOT EXISTS account_balances (
+  account TEXT NOT NULL COLLATE NOCASE,
+  asset_type TEXT NOT NULL,
+  asset_symbol TEXT NOT NULL,
+  quantity REAL NOT NULL DEFAULT 0,
+  transaction_count INTEGER NOT NULL DEFAULT 0,
+  last_transaction_at TEXT,
+  PRIMARY KEY (account, asset_type, asset_symbol)
+);
+
+-- Two-phase actions: prepared, then confirmed or cancelled (see
+-- services/action.service)
+CREATE TABLE IF NOT EXISTS action_intents (
+  id TEXT PRIMARY KEY,
+  action TEXT NOT NULL,
+  params TEXT NOT NULL, -- JSON action params
+  status TEXT NOT NULL CHECK(status IN ('PREPARED', 'CONFIRMING', 'CONFIRMED', 'CANCELLED', 'EXPIRED')),
+  client_ref TEXT UNIQUE,
+  external_ref TEXT,
+  transaction_ids TEXT NOT NULL DEFAULT '[]', -- JSON array of ids
+  vault_entries TEXT, -- JSON array of vault entries booked while confirming
+  response TEXT, -- JSON response of the confirmed action
+  error TEXT,
+  cancel_reason TEXT,
+  created_at TEXT NOT NULL,
+  updated_at TEXT,
+  expires_at TEXT NOT NULL,
+  confirmed_at TEXT,
+  cancelled_at TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_action_intents_status ON action_intents(status);
+
 -- Settings (key-value store)
 CREATE TABLE IF NOT EXISTS settings (
   key TEXT PRIMARY KEY,
@@ -170,7 +540,7 @@ CREATE TABLE IF NOT EXISTS price_cache (
   asset_symbol TEXT NOT NULL,
   rate_usd REAL NOT NULL,
   timestamp TEXT NOT NULL,
-  source TEXT NOT NULL CHECK(source IN ('COINGECKO', 'EXCHANGE_RATE_HOST', 'FRANKFURTER', 'ER_API', 'EXCHANGE_RATE_API', 'FALLBACK', 'FIXED')),
+  source TEXT NOT NULL CHECK(source IN ('COINGECKO', 'EXCHANGE_RATE_HOST', 'FRANKFURTER', 'ER_API', 'EXCHANGE_RATE_API', 'YAHOO_FINANCE', 'ALPHA_VANTAGE', 'FALLBACK', 'FIXED')),
   created_at TEXT NOT NULL
 );
 
@@ -182,4 +552,7 @@ INSERT OR IGNORE INTO settings (key, value) VALUES
   ('borrowingVaultName', 'Borrowings'),
   ('borrowingMonthlyRate', '0.02'),
   ('defaultSpendingVaultName', 'Spend'),
-  ('defaultIncomeVaultName', 'Income');
+  ('defaultIncomeVaultName', 'Income'),
+  ('maxManualPriceChangePercent', '50'),
+  ('depegThresholdPercent', '1'),
+  ('homeJurisdiction', 'VN');
@@ -0,0 +1,62 @@
+import { Router, Request, Response } from "express";
+import { AccountBalance } from "../types";
+import { accountBalanceService } from "../services/account-balance.service";
+import { isAppError } from "../core/errors";
+
+// What each account holds, per asset, today or as of a date
+export const accountsRouter = Router();
+
+function toBalanceShape(b: AccountBalance) {
+  return {
+    asset: b.asset,
+    quantity: b.quantity,
+    transaction_count: b.transactionCount,
+    last_transaction_at: b.lastTransactionAt ?? null,
+  };
+}
+
+/**
+ * Balances of every account, grouped by account
+ * GET /api/accounts/balances?as_of=2025-03-31
+ */
+accountsRouter.get("/accounts/balances", (req: Request, res: Response) => {
+  try {
+    const asOf = req.query.as_of ? String(req.query.as_of) : undefined;
+    const accounts = new Map<string, AccountBalance[]>();
+    for (const b of accountBalanceService.getBalances({ asOf })) {
+      const key = b.account.toLowerCase();
+      accounts.set(key, [...(accounts.get(key) || []), b]);
+    }
+    res.json({
+      as_of: asOf ?? null,
+      accounts: [...accounts.values()].map((balances) => ({
+        account: balances[0].account,
+        balances: balances.map(toBalanceShape),
+      })),
+    });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({ error: e?.message || "Failed to load balances" });
+  }
+});
+
+/**
+ * Balance of one account per asset; `id` is the admin account id or name
+ * GET /api/accounts/:id/balance?as_of=2025-03-31
+ */
+accountsRouter.get("/accounts/:id/balance", (req: Request, res: Response) => {
+  try {
+    const account = accountBalanceService.resolveAccount(req.params.id);
+    const asOf = req.query.as_of ? String(req.query.as_of) : undefined;
+    res.json({
+      account,
+      as_of: asOf ?? null,
+      balances: accountBalanceService
+        .getBalances({ account, asOf })
+        .map(toBalanceShape),
+    });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({ error: e?.message || "Failed to load balance" });
+  }
+});
@@ -0,0 +1,50 @@
+import { Router, Request, Response } from "express";
+import {
+  ACTIVITY_KINDS,
+  ActivityKind,
+  activityService,
+} from "../services/activity.service";
+
+// Unified "recent activity" feed across transactions, vaults, imports,
+// price alerts and admin config
+export const activityRouter = Router();
+
+const DEFAULT_LIMIT = 50;
+const MAX_LIMIT = 200;
+
+// GET /api/activity?kinds=transaction,import&start=&end=&limit=&offset=
+activityRouter.get("/activity", (req: Request, res: Response) => {
+  try {
+    const kinds = String(req.query.kinds || "")
+      .split(",")
+      .map((k) => k.trim().toLowerCase())
+      .filter(Boolean);
+    const unknown = kinds.filter(
+      (k) => !ACTIVITY_KINDS.includes(k as ActivityKind),
+    );
+    if (unknown.length) {
+      return res.status(400).json({
+        error: `unknown kinds: ${unknown.join(", ")}`,
+        allowed: ACTIVITY_KINDS,
+      });
+    }
+
+    const limit = Math.min(
+      MAX_LIMIT,
+      Math.max(1, Number(req.query.limit) || DEFAULT_LIMIT),
+    );
+    const offset = Math.max(0, Number(req.query.offset) || 0);
+    const { items, total } = activityService.list({
+      kinds: kinds as ActivityKind[],
+      start: req.query.start ? String(req.query.start) : undefined,
+      end: req.query.end ? String(req.query.end) : undefined,
+      limit,
+      offset,
+    });
+
+    res.setHeader("X-Total-Count", String(total));
+    res.json({ items, total, limit, offset });
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to load activity" });
+  }
+});
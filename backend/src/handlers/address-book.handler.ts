@@ -0,0 +1,45 @@
+import { Router, Request, Response } from "express";
+import { AddressBookCreateSchema, AddressBookUpdateSchema } from "../types";
+import { addressBookService } from "../services/address-book.service";
+
+// Known transfer destinations (exchange deposit addresses, bank accounts)
+export const addressBookRouter = Router();
+
+addressBookRouter.get("/address-book", (_req: Request, res: Response) => {
+  res.json(addressBookService.listEntries());
+});
+
+addressBookRouter.post("/address-book", (req: Request, res: Response) => {
+  try {
+    const body = AddressBookCreateSchema.parse(req.body || {});
+    res.status(201).json(addressBookService.createEntry(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid entry" });
+  }
+});
+
+addressBookRouter.get("/address-book/:id", (req: Request, res: Response) => {
+  const entry = addressBookService.getEntry(req.params.id);
+  if (!entry) return res.status(404).json({ error: "not found" });
+  res.json(entry);
+});
+
+addressBookRouter.put("/address-book/:id", (req: Request, res: Response) => {
+  try {
+    const body = AddressBookUpdateSchema.parse(req.body || {});
+    const updated = addressBookService.updateEntry(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid entry" });
+  }
+});
+
+addressBookRouter.delete(
+  "/address-book/:id",
+  (req: Request, res: Response) => {
+    const ok = addressBookService.deleteEntry(req.params.id);
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  },
+);
@@ -1,4 +1,4 @@
-import { Router, Request, Response } from "express";
+import { Router, Request, Response, NextFunction } from "express";
 import {
   adminRepository,
   pendingActionsRepository,
@@ -6,13 +6,138 @@ import {
   transactionRepository,
   vaultRepository,
   loanRepository,
+  projectRepository,
 } from "../repositories";
 import { vaultService } from "../services/vault.service";
 import { transactionService } from "../services/transaction.service";
-import { Asset } from "../types";
+import { purgeService, PurgeCriteria } from "../services/purge.service";
+import { trashService } from "../services/trash.service";
+import {
+  maintenanceService,
+  MaintenanceRun,
+} from "../services/maintenance.service";
+import { priceService, PriceDiscrepancy } from "../services/price.service";
+import { depositRateService } from "../services/deposit-rate.service";
+import { assetPriceService } from "../services/asset-price.service";
+import { listPriceProviders } from "../services/price-provider.service";
+import {
+  mergeService,
+  MergeKind,
+  MergeRecord,
+} from "../services/merge.service";
+import {
+  fixturesService,
+  FixtureRecord,
+} from "../services/fixtures.service";
+import { renameService } from "../services/rename.service";
+import { ruleService, RuleMatch } from "../services/rule.service";
+import {
+  backupService,
+  BACKUP_VERSION,
+  OpenedBackup,
+  RelinkResult,
+  RESTORE_MODES,
+  RestoreMode,
+} from "../services/backup.service";
+import { auditService } from "../services/audit.service";
+import {
+  consistencyService,
+  CONSISTENCY_FIXES,
+  ConsistencyIssue,
+  ConsistencyReport,
+} from "../services/consistency.service";
+import { accountBalanceService } from "../services/account-balance.service";
+import {
+  recalcService,
+  RecalcRun,
+  RECALC_KINDS,
+  RecalcKind,
+} from "../services/recalc.service";
+import { toJobShape } from "./jobs.handler";
+import { isAppError } from "../core/errors";
+import { config } from "../core/config";
+import { fiscalYearOf, fiscalYearRange } from "../utils/fiscal-year.util";
+import {
+  BUILTIN_REPORT_CURRENCIES,
+  ISO_CURRENCY,
+} from "../utils/currency.util";
+import {
+  ASSET_KINDS,
+  Asset,
+  AssetKind,
+  AuditEntity,
+  AuditEntry,
+  ConsistencyFixSchema,
+  CostBasisSettingsSchema,
+  DepositRateCreateSchema,
+  DepositRateUpdateSchema,
+  FiscalYearSettingsSchema,
+  IncomeTaxSettingsSchema,
+  ManualPrice,
+  ManualPriceSchema,
+  NameHistoryEntry,
+  OVERDRAW_POLICIES,
+  OverdrawPolicy,
+  PRICE_PROVIDERS,
+  PriceSourcePrioritySchema,
+  RecalcStartSchema,
+  RenameKind,
+  RuleApplySchema,
+  SpendingExclusionRulesSchema,
+  TransactionRuleCreateSchema,
+  TransactionRuleUpdateSchema,
+} from "../types";
 
 export const adminRouter = Router();
 
+// ISO 3166-1 alpha-2 country code; empty clears the value
+function normalizeJurisdiction(value: unknown): string | undefined {
+  if (value === undefined || value === null || value === "") return undefined;
+  const code = String(value).trim().toUpperCase();
+  if (!/^[A-Z]{2}$/.test(code)) {
+    throw new Error("jurisdiction must be a 2-letter ISO country code");
+  }
+  return code;
+}
+
+// Asset symbol an account's quick entries default to; empty clears it
+function normalizeDefaultAsset(value: unknown): string | undefined {
+  if (value === undefined || value === null || value === "") return undefined;
+  const symbol = String(value).trim().toUpperCase();
+  if (!/^[A-Z0-9.]{2,12}$/.test(symbol)) {
+    throw new Error("default_asset must be an asset symbol, e.g. VND");
+  }
+  return symbol;
+}
+
+// Percent over mid-market charged on foreign-currency transactions; empty
+// clears it
+function normalizeFxMargin(value: unknown): number | undefined {
+  if (value === undefined || value === null || value === "") return undefined;
+  const margin = Number(value);
+  if (!Number.isFinite(margin) || margin < 0 || margin >= 100) {
+    throw new Error("fx_margin_percent must be a percentage from 0 to 100");
+  }
+  return margin || undefined;
+}
+
+// FIAT, STABLECOIN, CRYPTO, EQUITY or OTHER; empty clears the value
+function normalizeAssetKind(value: unknown): AssetKind | undefined {
+  if (value === undefined || value === null || value === "") return undefined;
+  const kind = String(value).trim().toUpperCase() as AssetKind;
+  if (!ASSET_KINDS.includes(kind)) {
+    throw new Error(`kind must be one of ${ASSET_KINDS.join(", ")}`);
+  }
+  return kind;
+}
+
+// Quantity precision (e.g. 0 for VND, 8 for BTC), enforced on input
+const DECIMALS_ERROR = "decimals must be an integer between 0 and 18";
+function validDecimals(value: unknown): boolean {
+  if (value === undefined) return true;
+  return Number.isInteger(value) && Number(value) >= 0 && Number(value) <= 18;
+}
+
 // Settings: Default Vaults
 adminRouter.get("/admin/settings", (_req: Request, res: Response) => {
   try {
@@ -25,6 +150,23 @@ adminRouter.get("/admin/settings", (_req: Request, res: Response) => {
       borrowing_vault: borrow.name,
       borrowing_monthly_rate: borrow.rate,
       borrowing_last_accrual_at: borrow.lastAccrualStart,
+      max_manual_price_change_percent:
+        settingsRepository.getMaxManualPriceChangePercent(),
+      depeg_threshold_percent: settingsRepository.getDepegThresholdPercent(),
+      card_fx_markup_percent: settingsRepository.getCardFxMarkupPercent(),
+      cash_leakage_tag: settingsRepository.getCashLeakageTag(),
+      price_source_priority: settingsRepository.getPriceSourcePriority(),
+      price_discrepancy_threshold_percent:
+        settingsRepository.getPriceDiscrepancyThresholdPercent(),
+      display_precision: settingsRepository.getDisplayPrecision(),
+      spending_exclusion_rules: settingsRepository.getSpendingExclusionRules(),
+      home_jurisdiction: settingsRepository.getHomeJurisdiction(),
+      overdraw_policy: settingsRepository.getOverdrawPolicy(),
+      reporting_currency: settingsRepository.getReportingCurrency(),
+      cost_basis: settingsRepository.getCostBasisSettings(),
+      long_term_holding_days: settingsRepository.getLongTermHoldingDays(),
+      fiscal_year: settingsRepository.getFiscalYear(),
+      income_tax: settingsRepository.getIncomeTaxSettings(),
     });
   } catch (e: any) {
     res.status(500).json({ error: e?.message || "Failed to read settings" });
@@ -69,6 +211,453 @@ adminRouter.post(
   }
 );
 
+adminRouter.post(
+  "/admin/settings/max-price-change",
+  (req: Request, res: Response) => {
+    try {
+      const percent = Number(req.body?.percent);
+      if (!Number.isFinite(percent) || percent <= 0) {
+        return res.status(400).json({ error: "percent must be positive" });
+      }
+
+      settingsRepository.setMaxManualPriceChangePercent(percent);
+
+      res.status(200).json({ max_manual_price_change_percent: percent });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set max price change" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/depeg-threshold",
+  (req: Request, res: Response) => {
+    try {
+      const percent = Number(req.body?.percent);
+      if (!Number.isFinite(percent) || percent <= 0) {
+        return res.status(400).json({ error: "percent must be positive" });
+      }
+
+      settingsRepository.setDepegThresholdPercent(percent);
+
+      res.status(200).json({ depeg_threshold_percent: percent });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set depeg threshold" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/card-fx-markup",
+  (req: Request, res: Response) => {
+    try {
+      const percent = Number(req.body?.percent);
+      if (!Number.isFinite(percent) || percent < 0 || percent > 100) {
+        return res
+          .status(400)
+          .json({ error: "percent must be between 0 and 100" });
+      }
+
+      settingsRepository.setCardFxMarkupPercent(percent);
+
+      res.status(200).json({ card_fx_markup_percent: percent });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set card FX markup" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/cash-leakage-tag",
+  (req: Request, res: Response) => {
+    try {
+      const tag = String(req.body?.tag || "").trim();
+      if (!tag) return res.status(400).json({ error: "tag is required" });
+
+      settingsRepository.setCashLeakageTag(tag);
+
+      res.status(200).json({ cash_leakage_tag: tag });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set cash leakage tag" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/price-discrepancy-threshold",
+  (req: Request, res: Response) => {
+    try {
+      const percent = Number(req.body?.percent);
+      if (!Number.isFinite(percent) || percent <= 0) {
+        return res.status(400).json({ error: "percent must be positive" });
+      }
+
+      settingsRepository.setPriceDiscrepancyThresholdPercent(percent);
+
+      res.status(200).json({ price_discrepancy_threshold_percent: percent });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set discrepancy threshold" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/home-jurisdiction",
+  (req: Request, res: Response) => {
+    try {
+      const country = normalizeJurisdiction(req.body?.country);
+      if (!country) {
+        return res.status(400).json({ error: "country is required" });
+      }
+
+      settingsRepository.setHomeJurisdiction(country);
+
+      res.status(200).json({ home_jurisdiction: country });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set home jurisdiction" });
+    }
+  }
+);
+
+// What a vault withdrawal of more than is held does: fail, warn or pass
+adminRouter.post(
+  "/admin/settings/overdraw-policy",
+  (req: Request, res: Response) => {
+    try {
+      const policy = String(req.body?.policy || "")
+        .trim()
+        .toUpperCase() as OverdrawPolicy;
+      if (!OVERDRAW_POLICIES.includes(policy)) {
+        return res.status(400).json({
+          error: `policy must be one of ${OVERDRAW_POLICIES.join(", ")}`,
+        });
+      }
+
+      settingsRepository.setOverdrawPolicy(policy);
+
+      res.status(200).json({ overdraw_policy: policy });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set overdraw policy" });
+    }
+  }
+);
+
+// Currency reports add beside USD (and VND) when no ?currency= is given
+adminRouter.post(
+  "/admin/settings/reporting-currency",
+  async (req: Request, res: Response) => {
+    try {
+      const currency = String(req.body?.currency || "")
+        .trim()
+        .toUpperCase();
+      if (!ISO_CURRENCY.test(currency)) {
+        return res
+          .status(400)
+          .json({ error: "currency must be an ISO 4217 code" });
+      }
+      if (!BUILTIN_REPORT_CURRENCIES.includes(currency)) {
+        const rate = await priceService.getRateUSD({
+          type: "FIAT",
+          symbol: currency,
+        });
+        if (rate.missing || !(rate.rateUSD > 0)) {
+          return res
+            .status(400)
+            .json({ error: `No FX rate for ${currency}` });
+        }
+      }
+
+      settingsRepository.setReportingCurrency(currency);
+
+      res.status(200).json({ reporting_currency: currency });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set reporting currency" });
+    }
+  }
+);
+
+adminRouter.post(
+  "/admin/settings/display-precision",
+  (req: Request, res: Response) => {
+    try {
+      const input = req.body?.precision ?? req.body ?? {};
+      const precision: Record<string, number> = {};
+      for (const [symbol, decimals] of Object.entries(input)) {
+        const n = Number(decimals);
+        if (!Number.isInteger(n) || n < 0 || n > 18) {
+          return res
+            .status(400)
+            .json({ error: `invalid precision for ${symbol}` });
+        }
+        precision[symbol.trim().toUpperCase()] = n;
+      }
+
+      settingsRepository.setDisplayPrecision(precision);
+
+      res
+        .status(200)
+        .json({ display_precision: settingsRepository.getDisplayPrecision() });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set display precision" });
+    }
+  }
+);
+
+adminRouter.put(
+  "/admin/settings/spending-exclusions",
+  (req: Request, res: Response) => {
+    try {
+      const { rules } = SpendingExclusionRulesSchema.parse(req.body || {});
+      settingsRepository.setSpendingExclusionRules(rules);
+
+      res.status(200).json({ spending_exclusion_rules: rules });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set spending exclusions" });
+    }
+  }
+);
+
+// Cost basis method: default, per asset symbol and per vault
+adminRouter.put(
+  "/admin/settings/cost-basis",
+  (req: Request, res: Response) => {
+    try {
+      const parsed = CostBasisSettingsSchema.parse(req.body || {});
+      const settings = {
+        ...parsed,
+        byAsset: Object.fromEntries(
+          Object.entries(parsed.byAsset).map(([s, m]) => [s.toUpperCase(), m])
+        ),
+      };
+      settingsRepository.setCostBasisSettings(settings);
+
+      res.status(200).json({ cost_basis: settings });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set cost basis method" });
+    }
+  }
+);
+
+// Holding period after which realized gains are long-term (tax report)
+adminRouter.post(
+  "/admin/settings/long-term-days",
+  (req: Request, res: Response) => {
+    try {
+      const days = Number(req.body?.days);
+      if (!Number.isInteger(days) || days <= 0) {
+        return res
+          .status(400)
+          .json({ error: "days must be a positive integer" });
+      }
+
+      settingsRepository.setLongTermHoldingDays(days);
+
+      res.status(200).json({ long_term_holding_days: days });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set long-term holding days" });
+    }
+  }
+);
+
+// Fiscal year start for tax and annual reports, e.g. {start: "04-01"};
+// `starts` pins a year's start when it moves, e.g. {"2025": "2025-01-29"}
+adminRouter.post(
+  "/admin/settings/fiscal-year",
+  (req: Request, res: Response) => {
+    try {
+      const settings = FiscalYearSettingsSchema.parse(req.body || {});
+      settingsRepository.setFiscalYear(settings);
+
+      const year = fiscalYearOf(new Date(), settings);
+      res.status(200).json({
+        fiscal_year: settings,
+        current: { year, ...fiscalYearRange(year, settings) },
+      });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set fiscal year" });
+    }
+  }
+);
+
+// Income tax estimate: brackets (annual VND), deductions, dependents and
+// the tags of taxable income and of tax paid; omitted fields reset to the
+// VN PIT defaults
+adminRouter.put(
+  "/admin/settings/income-tax",
+  (req: Request, res: Response) => {
+    try {
+      const settings = IncomeTaxSettingsSchema.parse(req.body || {});
+      settingsRepository.setIncomeTaxSettings(settings);
+
+      res.status(200).json({ income_tax: settings });
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "failed to set income tax settings" });
+    }
+  }
+);
+
+// Price sources: per-asset provider priority and cross-check results
+function toPriceDiscrepancyShape(d: PriceDiscrepancy) {
+  return {
+    asset: d.asset,
+    day: d.day,
+    primary: { source: d.primary.source, rate_usd: d.primary.rateUSD },
+    quotes: d.quotes.map((q) => ({
+      source: q.source,
+      rate_usd: q.rateUSD,
+      deviation_percent: q.deviationPercent,
+    })),
+    threshold_percent: d.thresholdPercent,
+    detected_at: d.detectedAt,
+  };
+}
+
+adminRouter.get("/admin/price-sources", (_req: Request, res: Response) => {
+  try {
+    res.json({
+      providers: PRICE_PROVIDERS,
+      registered: listPriceProviders().map((p) => ({
+        name: p.name,
+        asset_kinds: p.assetKinds,
+        available: p.available(),
+      })),
+      priority: settingsRepository.getPriceSourcePriority(),
+      discrepancy_threshold_percent:
+        settingsRepository.getPriceDiscrepancyThresholdPercent(),
+      discrepancies: priceService
+        .getDiscrepancies()
+        .map(toPriceDiscrepancyShape),
+    });
+  } catch (e: any) {
+    res
+      .status(500)
+      .json({ error: e?.message || "Failed to read price sources" });
+  }
+});
+
+adminRouter.put("/admin/price-sources", (req: Request, res: Response) => {
+  try {
+    const { symbol, sources } = PriceSourcePrioritySchema.parse(req.body || {});
+    const priority = settingsRepository.getPriceSourcePriority();
+    const key = symbol.toUpperCase();
+    if (sources.length) priority[key] = Array.from(new Set(sources));
+    else delete priority[key];
+    settingsRepository.setPriceSourcePriority(priority);
+
+    res.status(200).json({ symbol: key, sources: priority[key] ?? [] });
+  } catch (e: any) {
+    res
+      .status(400)
+      .json({ error: e?.message || "failed to set price sources" });
+  }
+});
+
+// Manual prices for assets no provider covers; they win over providers
+function toManualPriceShape(p: ManualPrice) {
+  return {
+    symbol: p.symbol,
+    price_usd: p.priceUSD,
+    at: p.at,
+    note: p.note ?? null,
+    updated_at: p.updatedAt,
+  };
+}
+
+adminRouter.get("/admin/prices/manual", (req: Request, res: Response) => {
+  const symbol = req.query.symbol ? String(req.query.symbol) : undefined;
+  res.json(assetPriceService.listManualPrices(symbol).map(toManualPriceShape));
+});
+
+adminRouter.put("/admin/prices/manual", (req: Request, res: Response) => {
+  try {
+    const body = ManualPriceSchema.parse(req.body || {});
+    res.json(toManualPriceShape(assetPriceService.setManualPrice(body)));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid manual price" });
+  }
+});
+
+adminRouter.delete(
+  "/admin/prices/manual/:symbol",
+  (req: Request, res: Response) => {
+    const at = req.query.at ? String(req.query.at) : undefined;
+    if (at && Number.isNaN(Date.parse(at))) {
+      return res.status(400).json({ error: "at must be an ISO date" });
+    }
+    const removed = assetPriceService.removeManualPrice(
+      String(req.params.symbol),
+      at
+    );
+    if (!removed) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true, removed });
+  }
+);
+
+// Reference VND term-deposit rates (cash-drag benchmark)
+adminRouter.get("/admin/deposit-rates", (req: Request, res: Response) => {
+  const term = req.query.term_months
+    ? Number(req.query.term_months)
+    : undefined;
+  res.json(depositRateService.list(term));
+});
+
+adminRouter.post("/admin/deposit-rates", (req: Request, res: Response) => {
+  try {
+    const body = DepositRateCreateSchema.parse(req.body || {});
+    res.status(201).json(depositRateService.create(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid deposit rate" });
+  }
+});
+
+adminRouter.put("/admin/deposit-rates/:id", (req: Request, res: Response) => {
+  try {
+    const body = DepositRateUpdateSchema.parse(req.body || {});
+    const updated = depositRateService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid deposit rate" });
+  }
+});
+
+adminRouter.delete(
+  "/admin/deposit-rates/:id",
+  (req: Request, res: Response) => {
+    const ok = depositRateService.delete(req.params.id);
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  }
+);
+
 // Transaction Types
 adminRouter.get("/admin/types", (_req: Request, res: Response) => {
   res.json(adminRepository.findAllTypes());
@@ -126,11 +715,27 @@ adminRouter.get("/admin/accounts/:id", (req: Request, res: Response) => {
 
 adminRouter.post("/admin/accounts", (req: Request, res: Response) => {
   try {
-    const { name, type, is_active } = req.body || {};
+    const {
+      name,
+      type,
+      institution,
+      jurisdiction,
+      default_asset,
+      fx_margin_percent,
+      is_active,
+    } = req.body || {};
     if (!name || typeof name !== "string") {
       return res.status(400).json({ error: "name is required" });
     }
-    const created = adminRepository.createAccount({ name, type, is_active });
+    const created = adminRepository.createAccount({
+      name,
+      type,
+      institution,
+      jurisdiction: normalizeJurisdiction(jurisdiction),
+      default_asset: normalizeDefaultAsset(default_asset),
+      fx_margin_percent: normalizeFxMargin(fx_margin_percent),
+      is_active,
+    });
     res.status(201).json(created);
   } catch (e: any) {
     res.status(400).json({ error: e?.message || "Failed to create account" });
@@ -139,7 +744,27 @@ adminRouter.post("/admin/accounts", (req: Request, res: Response) => {
 
 adminRouter.put("/admin/accounts/:id", (req: Request, res: Response) => {
   const id = Number(req.params.id);
-  const updated = adminRepository.updateAccount(id, req.body || {});
+  const body = { ...(req.body || {}) };
+  try {
+    if ("jurisdiction" in body) {
+      body.jurisdiction = normalizeJurisdiction(body.jurisdiction) ?? "";
+    }
+    if ("default_asset" in body) {
+      body.default_asset = normalizeDefaultAsset(body.default_asset) ?? "";
+    }
+    if ("fx_margin_percent" in body) {
+      body.fx_margin_percent = normalizeFxMargin(body.fx_margin_percent) ?? 0;
+    }
+  } catch (e: any) {
+    return res.status(400).json({ error: e.message });
+  }
+  try {
+    renameThrough("account", id, body, "name");
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    throw e;
+  }
+  const updated = adminRepository.updateAccount(id, body);
   if (!updated) return res.status(404).json({ error: "Account not found" });
   res.json(updated);
 });
@@ -165,14 +790,20 @@ adminRouter.get("/admin/assets/:id", (req: Request, res: Response) => {
 
 adminRouter.post("/admin/assets", (req: Request, res: Response) => {
   try {
-    const { symbol, name, decimals, is_active } = req.body || {};
+    const { symbol, name, decimals, kind, jurisdiction, is_active } =
+      req.body || {};
     if (!symbol || typeof symbol !== "string") {
       return res.status(400).json({ error: "symbol is required" });
     }
+    if (!validDecimals(decimals)) {
+      return res.status(400).json({ error: DECIMALS_ERROR });
+    }
     const created = adminRepository.createAsset({
       symbol,
       name,
       decimals,
+      kind: normalizeAssetKind(kind),
+      jurisdiction: normalizeJurisdiction(jurisdiction),
       is_active,
     });
     res.status(201).json(created);
@@ -183,7 +814,23 @@ adminRouter.post("/admin/assets", (req: Request, res: Response) => {
 
 adminRouter.put("/admin/assets/:id", (req: Request, res: Response) => {
   const id = Number(req.params.id);
-  const updated = adminRepository.updateAsset(id, req.body || {});
+  const body = { ...(req.body || {}) };
+  try {
+    if ("jurisdiction" in body) {
+      body.jurisdiction = normalizeJurisdiction(body.jurisdiction) ?? "";
+    }
+    if ("kind" in body) body.kind = normalizeAssetKind(body.kind) ?? "";
+    if (!validDecimals(body.decimals)) throw new Error(DECIMALS_ERROR);
+  } catch (e: any) {
+    return res.status(400).json({ error: e.message });
+  }
+  try {
+    renameThrough("asset", id, body, "symbol");
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    throw e;
+  }
+  const updated = adminRepository.updateAsset(id, body);
   if (!updated) return res.status(404).json({ error: "Asset not found" });
   res.json(updated);
 });
@@ -234,6 +881,316 @@ adminRouter.delete("/admin/tags/:id", (req: Request, res: Response) => {
   res.json({ deleted: 1 });
 });
 
+// Renames
+function toNameShape(h: NameHistoryEntry) {
+  return {
+    name: h.name,
+    effective_from: h.effectiveFrom,
+    created_at: h.createdAt,
+  };
+}
+
+// A plain edit of the name or symbol is a rename effective now, so
+// transactions under the old one follow it
+function renameThrough(
+  kind: RenameKind,
+  id: number,
+  body: Record<string, unknown>,
+  field: "name" | "symbol"
+) {
+  if (!(field in body)) return;
+  if (findNamed(kind, id)) {
+    renameService.rename(kind, id, body[field] as string);
+  }
+  delete body[field];
+}
+
+function findNamed(kind: RenameKind, id: number) {
+  return kind === "account"
+    ? adminRepository.findAccountById(id)
+    : adminRepository.findAssetById(id);
+}
+
+function renameRoute(kind: RenameKind, field: "name" | "symbol") {
+  return (req: Request, res: Response) => {
+    try {
+      const id = Number(req.params.id);
+      const history = renameService.rename(
+        kind,
+        id,
+        req.body?.[field],
+        req.body?.effective_from
+      );
+      res.json({ ...findNamed(kind, id), names: history.map(toNameShape) });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || `Failed to rename ${kind}` });
+    }
+  };
+}
+
+function namesRoute(kind: RenameKind) {
+  return (req: Request, res: Response) => {
+    try {
+      const id = Number(req.params.id);
+      const at = req.query.at as string | undefined;
+      if (at !== undefined) {
+        return res.json({ at, name: renameService.nameAt(kind, id, at) });
+      }
+      res.json(renameService.history(kind, id).map(toNameShape));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to load names" });
+    }
+  };
+}
+
+/**
+ * Rename an account or asset from a date on. Its id stays, and its
+ * transactions, old and new, are reported under the new name
+ * POST /api/admin/accounts/:id/rename  Body: { name, effective_from? }
+ * POST /api/admin/assets/:id/rename  Body: { symbol, effective_from? }
+ */
+adminRouter.post("/admin/accounts/:id/rename", renameRoute("account", "name"));
+adminRouter.post("/admin/assets/:id/rename", renameRoute("asset", "symbol"));
+
+/**
+ * Names an account or asset went by, oldest first; ?at=<ISO date> gives
+ * the one in effect then
+ * GET /api/admin/accounts/:id/names
+ * GET /api/admin/assets/:id/names
+ */
+adminRouter.get("/admin/accounts/:id/names", namesRoute("account"));
+adminRouter.get("/admin/assets/:id/names", namesRoute("asset"));
+
+// Merging duplicates
+function toMergeShape(m: MergeRecord) {
+  return {
+    id: m.id,
+    kind: m.kind,
+    from: m.from,
+    into: m.into,
+    from_id: m.fromId,
+    into_id: m.intoId,
+    counts: m.counts,
+    at: m.at,
+  };
+}
+
+function mergeRoute(kind: MergeKind) {
+  return (req: Request, res: Response) => {
+    try {
+      const merged = mergeService.merge(
+        kind,
+        Number(req.params.id),
+        Number(req.body?.into_id)
+      );
+      res.json(toMergeShape(merged));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || `Failed to merge ${kind}` });
+    }
+  };
+}
+
+/**
+ * Merge a duplicate into another entry: its transactions, vault entries
+ * and investments move over and the duplicate is deleted
+ * POST /api/admin/accounts/:id/merge
+ * POST /api/admin/assets/:id/merge
+ * POST /api/admin/tags/:id/merge
+ * Body: { into_id: number }
+ */
+adminRouter.post("/admin/accounts/:id/merge", mergeRoute("account"));
+adminRouter.post("/admin/assets/:id/merge", mergeRoute("asset"));
+adminRouter.post("/admin/tags/:id/merge", mergeRoute("tag"));
+
+/**
+ * Past merges, newest first
+ * GET /api/admin/merges
+ */
+adminRouter.get("/admin/merges", (_req: Request, res: Response) => {
+  res.json(mergeService.log().reverse().map(toMergeShape));
+});
+
+// Transaction rules
+adminRouter.get("/admin/rules", (_req: Request, res: Response) => {
+  res.json(ruleService.list());
+});
+
+adminRouter.get("/admin/rules/:id", (req: Request, res: Response) => {
+  const rule = ruleService.get(req.params.id);
+  if (!rule) return res.status(404).json({ error: "Rule not found" });
+  res.json(rule);
+});
+
+adminRouter.post("/admin/rules", (req: Request, res: Response) => {
+  try {
+    const body = TransactionRuleCreateSchema.parse(req.body || {});
+    res.status(201).json(ruleService.create(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid rule" });
+  }
+});
+
+adminRouter.put("/admin/rules/:id", (req: Request, res: Response) => {
+  try {
+    const body = TransactionRuleUpdateSchema.parse(req.body || {});
+    const updated = ruleService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "Rule not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid rule" });
+  }
+});
+
+adminRouter.delete("/admin/rules/:id", (req: Request, res: Response) => {
+  const ok = ruleService.delete(req.params.id);
+  if (!ok) return res.status(404).json({ error: "Rule not found" });
+  res.json({ deleted: 1 });
+});
+
+function toRuleMatchShape(m: RuleMatch) {
+  return {
+    transaction_id: m.transactionId,
+    rule_ids: m.ruleIds,
+    before: m.before,
+    changes: m.changes,
+  };
+}
+
+/**
+ * Run rules over existing transactions; a dry run (the default) only
+ * lists the changes
+ * POST /api/admin/rules/apply
+ * Body: { dry_run?: boolean, rule_ids?: string[] }
+ */
+adminRouter.post("/admin/rules/apply", (req: Request, res: Response) => {
+  try {
+    const params = RuleApplySchema.parse({
+      dryRun: req.body?.dry_run,
+      ruleIds: req.body?.rule_ids,
+    });
+    const r = ruleService.applyToExisting(params);
+    res.json({
+      dry_run: r.dryRun,
+      scanned: r.scanned,
+      changed: r.changed.length,
+      transactions: r.changed.map(toRuleMatchShape),
+    });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Failed to apply rules" });
+  }
+});
+
+function toAuditShape(e: AuditEntry) {
+  return {
+    id: e.id,
+    at: e.at,
+    entity: e.entity,
+    entity_id: e.entityId,
+    action: e.action,
+    user: e.actor,
+    before: e.before ?? null,
+    after: e.after ?? null,
+  };
+}
+
+/**
+ * Audit log of creates, updates and deletes, newest first
+ * GET /api/admin/audit?entity=&entity_id=&user=&start=&end=&limit=&cursor=
+ */
+adminRouter.get("/admin/audit", (req: Request, res: Response) => {
+  try {
+    const q = (key: string) =>
+      req.query[key] ? String(req.query[key]) : undefined;
+    const page = auditService.list(
+      {
+        entity: q("entity") as AuditEntity | undefined,
+        entityId: q("entity_id"),
+        actor: q("user"),
+        start: q("start"),
+        end: q("end"),
+      },
+      {
+        limit: q("limit") ? Number(q("limit")) : undefined,
+        cursor: q("cursor"),
+      }
+    );
+    res.json({
+      items: page.items.map(toAuditShape),
+      next_cursor: page.nextCursor ?? null,
+    });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({ error: e?.message || "Failed to read audit log" });
+  }
+});
+
+function toFixtureShape(r: FixtureRecord) {
+  return {
+    scenario: r.scenario,
+    label: r.label,
+    start: r.start,
+    transaction_ids: r.transactionIds,
+    vault_entries: r.vaultEntries,
+    borrowing_ids: r.borrowingIds,
+    vaults: r.vaults,
+    at: r.at,
+  };
+}
+
+// Fixtures write demo data into the live store, so outside development
+// they need ENABLE_FIXTURES
+adminRouter.use(
+  "/admin/fixtures",
+  (_req: Request, res: Response, next: NextFunction) => {
+    if (config.isDevelopment || config.enableFixtures) return next();
+    res.status(403).json({ error: "Fixtures are disabled (ENABLE_FIXTURES)" });
+  }
+);
+
+/**
+ * Available fixture scenarios and the ones already loaded
+ * GET /api/admin/fixtures
+ */
+adminRouter.get("/admin/fixtures", (_req: Request, res: Response) => {
+  res.json({
+    scenarios: fixturesService.scenarios().map((s) => ({
+      name: s.name,
+      description: s.description,
+      defaults: s.defaults,
+    })),
+    loaded: fixturesService.log().reverse().map(toFixtureShape),
+  });
+});
+
+/**
+ * Load a canned scenario (dca-vault, borrow-cycle, multi-currency-month)
+ * POST /api/admin/fixtures/:scenario
+ * Body: { start?: "YYYY-MM-DD", label?: string, months?: number,
+ *         amount?: number }
+ */
+adminRouter.post(
+  "/admin/fixtures/:scenario",
+  async (req: Request, res: Response) => {
+    try {
+      const body = req.body || {};
+      const record = await fixturesService.create(req.params.scenario, {
+        start: body.start,
+        label: body.label,
+        months: body.months === undefined ? undefined : Number(body.months),
+        amount: body.amount === undefined ? undefined : Number(body.amount),
+      });
+      res.status(201).json(toFixtureShape(record));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to load fixture" });
+    }
+  }
+);
+
 // AI Pending Actions
 adminRouter.get("/admin/pending-actions", (req: Request, res: Response) => {
   const status = req.query.status as string | undefined;
@@ -654,21 +1611,394 @@ adminRouter.delete(
   }
 );
 
+function purgeCriteria(body: any): PurgeCriteria {
+  return {
+    before: body?.before ? String(body.before) : undefined,
+    account: body?.account ? String(body.account).trim() : undefined,
+  };
+}
+
+/**
+ * Data retention: list what a purge would remove
+ * POST /api/admin/purge/dry-run
+ * Body: { before?: "YYYY-MM-DD", account?: string }
+ */
+adminRouter.post("/admin/purge/dry-run", (req: Request, res: Response) => {
+  try {
+    const plan = purgeService.plan(purgeCriteria(req.body));
+    res.json({
+      before: plan.criteria.before ?? null,
+      account: plan.criteria.account ?? null,
+      counts: {
+        transactions: plan.transactions.length,
+        vault_entries: plan.vaultEntries.length,
+        carry_forward_entries: plan.carryForward.length,
+        registry_items: plan.registryItems.length,
+        unlinked_transactions: plan.unlinkedTransactions.length,
+        price_cache_rows: plan.priceCacheRows,
+      },
+      transactions: plan.transactions,
+      vault_entries: plan.vaultEntries,
+      carry_forward_entries: plan.carryForward,
+      registry_items: plan.registryItems,
+      unlinked_transactions: plan.unlinkedTransactions,
+      confirm: plan.token,
+    });
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid purge request" });
+  }
+});
+
+/**
+ * Data retention: purge, only with the confirm token from a dry run
+ * POST /api/admin/purge
+ * Body: { before?: "YYYY-MM-DD", account?: string, confirm: string }
+ */
+adminRouter.post("/admin/purge", (req: Request, res: Response) => {
+  try {
+    const result = purgeService.execute(
+      purgeCriteria(req.body),
+      String(req.body?.confirm || "")
+    );
+    res.json({
+      purged: {
+        transactions: result.transactions,
+        vault_entries: result.vaultEntries,
+        registry_items: result.registryItems,
+        price_cache_rows: result.priceCacheRows,
+      },
+      carry_forward_entries: result.carryForward,
+      unlinked_transactions: result.unlinkedTransactions,
+    });
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Failed to purge data" });
+  }
+});
+
+/**
+ * Trash: deleted vaults and overwritten settings, kept for 7 days
+ * GET /api/admin/trash
+ */
+adminRouter.get("/admin/trash", (_req: Request, res: Response) => {
+  res.json(
+    trashService.list().map((t) => ({
+      id: t.id,
+      kind: t.kind,
+      label: t.label,
+      deleted_at: t.deletedAt,
+      expires_at: t.expiresAt,
+    }))
+  );
+});
+
+/**
+ * Undo a deletion or overwrite
+ * POST /api/admin/trash/:id/restore
+ */
+adminRouter.post(
+  "/admin/trash/:id/restore",
+  (req: Request, res: Response) => {
+    try {
+      const item = trashService.restore(req.params.id);
+      if (!item) return res.status(404).json({ error: "not found" });
+      res.json({ ok: true, kind: item.kind, label: item.label });
+    } catch (e: any) {
+      res.status(409).json({ error: e?.message || "Failed to restore" });
+    }
+  }
+);
+
+/**
+ * Drop a trashed item permanently
+ * DELETE /api/admin/trash/:id
+ */
+adminRouter.delete("/admin/trash/:id", (req: Request, res: Response) => {
+  const ok = trashService.discard(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
+
+// Deleted transactions stay restorable this long unless purged sooner
+const DELETED_TRANSACTION_RETENTION_DAYS = 30;
+
+/**
+ * Soft-deleted transactions, most recently deleted first
+ * GET /api/admin/transactions/deleted
+ */
+adminRouter.get(
+  "/admin/transactions/deleted",
+  (_req: Request, res: Response) => {
+    res.json(transactionService.getDeletedTransactions());
+  }
+);
+
+/**
+ * Permanently remove transactions deleted at least N days ago
+ * POST /api/admin/transactions/deleted/purge
+ * Body: { older_than_days?: number } (default 30; 0 purges all)
+ */
+adminRouter.post(
+  "/admin/transactions/deleted/purge",
+  (req: Request, res: Response) => {
+    try {
+      const days =
+        req.body?.older_than_days === undefined
+          ? DELETED_TRANSACTION_RETENTION_DAYS
+          : Number(req.body.older_than_days);
+      const purged = transactionService.purgeDeletedTransactions(days);
+      res.json({ purged, older_than_days: days });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to purge" });
+    }
+  }
+);
+
+function toMaintenanceRunShape(r: MaintenanceRun) {
+  return {
+    started_at: r.startedAt,
+    finished_at: r.finishedAt,
+    pruned_price_rows: r.prunedPriceRows,
+    analyzed_tables: r.analyzedTables,
+    vacuumed: r.vacuumed,
+    freed_bytes: r.freedBytes,
+    error: r.error,
+  };
+}
+
+/**
+ * Database size per table and the last maintenance run
+ * GET /api/admin/maintenance
+ */
+adminRouter.get("/admin/maintenance", (_req: Request, res: Response) => {
+  try {
+    const s = maintenanceService.status();
+    res.json({
+      last_run: s.lastRun ? toMaintenanceRunShape(s.lastRun) : null,
+      database: {
+        size_bytes: s.database.sizeBytes,
+        page_size: s.database.pageSize,
+        page_count: s.database.pageCount,
+        freelist_count: s.database.freelistCount,
+      },
+      statement_cache: s.statementCache,
+      tables: s.tables.map((t) => ({
+        name: t.name,
+        rows: t.rows,
+        bytes: t.bytes ?? null,
+      })),
+    });
+  } catch (e: any) {
+    res
+      .status(500)
+      .json({ error: e?.message || "Failed to read database status" });
+  }
+});
+
+/**
+ * Run maintenance now instead of waiting for the daily schedule
+ * POST /api/admin/maintenance/run
+ */
+adminRouter.post("/admin/maintenance/run", (_req: Request, res: Response) => {
+  const run = maintenanceService.run();
+  res.status(run.error ? 500 : 200).json(toMaintenanceRunShape(run));
+});
+
+function toConsistencyIssueShape(i: ConsistencyIssue) {
+  return {
+    kind: i.kind,
+    message: i.message,
+    transaction_ids: i.transactionIds,
+    account: i.account ?? null,
+    asset: i.asset ?? null,
+    amount: i.amount ?? null,
+    at: i.at ?? null,
+  };
+}
+
+function toConsistencyReportShape(r: ConsistencyReport) {
+  return {
+    checked_at: r.checkedAt,
+    counts: r.counts,
+    fixes: CONSISTENCY_FIXES,
+    issues: r.issues.map(toConsistencyIssueShape),
+  };
+}
+
+/**
+ * Double-entry consistency check: orphaned transfer legs, vaults out of
+ * step with their transactions, negative balances and missing FX rates
+ * GET /api/admin/maintenance/consistency-check
+ */
+adminRouter.get(
+  "/admin/maintenance/consistency-check",
+  (_req: Request, res: Response) => {
+    try {
+      res.json(toConsistencyReportShape(consistencyService.check()));
+    } catch (e: any) {
+      res
+        .status(500)
+        .json({ error: e?.message || "Failed to check consistency" });
+    }
+  }
+);
+
+/**
+ * Apply the auto-fix for each issue kind in `fix`, then check again
+ * POST /api/admin/maintenance/consistency-check
+ * Body: { fix: ["orphaned_transfer", "missing_fx_rate", ...] }
+ */
+adminRouter.post(
+  "/admin/maintenance/consistency-check",
+  async (req: Request, res: Response) => {
+    try {
+      const { fix } = ConsistencyFixSchema.parse(req.body || {});
+      const fixed = await consistencyService.fix(fix);
+      res.json({
+        fixed,
+        report: toConsistencyReportShape(consistencyService.check()),
+      });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res
+        .status(400)
+        .json({ error: e?.message || "Failed to fix consistency issues" });
+    }
+  }
+);
+
+/**
+ * Recompute account balances from the ledger, e.g. after editing the
+ * data store by hand
+ * POST /api/admin/maintenance/rebuild-balances
+ */
+adminRouter.post(
+  "/admin/maintenance/rebuild-balances",
+  (_req: Request, res: Response) => {
+    try {
+      res.json({ rows: accountBalanceService.rebuild() });
+    } catch (e: any) {
+      res
+        .status(500)
+        .json({ error: e?.message || "Failed to rebuild balances" });
+    }
+  }
+);
+
+function toRecalcRunShape(r: RecalcRun) {
+  return {
+    id: r.id,
+    kind: r.kind,
+    status: r.status,
+    job_id: r.jobId,
+    batch_size: r.batchSize,
+    pause_ms: r.pauseMs,
+    total: r.total,
+    processed: r.processed,
+    changed: r.changed,
+    progress: r.total ? Math.round((r.processed / r.total) * 1000) / 10 : 0,
+    error: r.error ?? null,
+    started_at: r.startedAt,
+    updated_at: r.updatedAt,
+    finished_at: r.finishedAt ?? null,
+  };
+}
+
+/**
+ * Recalculation runs, newest first
+ * GET /api/admin/recalc/runs
+ */
+adminRouter.get("/admin/recalc/runs", (_req: Request, res: Response) => {
+  res.json(recalcService.list().map(toRecalcRunShape));
+});
+
+adminRouter.get("/admin/recalc/runs/:id", (req: Request, res: Response) => {
+  const run = recalcService.get(req.params.id);
+  if (!run) return res.status(404).json({ error: "Run not found" });
+  res.json(toRecalcRunShape(run));
+});
+
+/**
+ * Recalculate transaction values in the background, a batch at a time;
+ * follow it at /api/jobs/:id or the run
+ * POST /api/admin/recalc/:kind (fx | usd | enrichment)
+ * Body: { batch_size?: number, pause_ms?: number }
+ */
+adminRouter.post("/admin/recalc/:kind", (req: Request, res: Response) => {
+  try {
+    const kind = req.params.kind as RecalcKind;
+    if (!RECALC_KINDS.includes(kind)) {
+      return res.status(400).json({
+        error: `kind must be one of ${RECALC_KINDS.join(", ")}`,
+      });
+    }
+    const body = RecalcStartSchema.parse(req.body || {});
+    const { run, job } = recalcService.start(kind, {
+      batchSize: body.batch_size,
+      pauseMs: body.pause_ms,
+    });
+    res.status(202).json({ run: toRecalcRunShape(run), job: toJobShape(job) });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Failed to start" });
+  }
+});
+
+/**
+ * Continue a cancelled, failed or interrupted run after its last batch
+ * POST /api/admin/recalc/runs/:id/resume
+ */
+adminRouter.post(
+  "/admin/recalc/runs/:id/resume",
+  (req: Request, res: Response) => {
+    try {
+      const { run, job } = recalcService.resume(req.params.id);
+      res
+        .status(202)
+        .json({ run: toRecalcRunShape(run), job: toJobShape(job) });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to resume" });
+    }
+  }
+);
+
+function summarizeBackup(backup: OpenedBackup) {
+  return {
+    version: backup.version,
+    migrated: backup.version < BACKUP_VERSION,
+    verified: backup.verified,
+    warnings: backup.warnings,
+  };
+}
+
+function toRelinkShape(r: RelinkResult) {
+  return {
+    vaults_created: r.vaultsCreated,
+    vault_entries_added: r.vaultEntriesAdded,
+    account_balances: r.accountBalances,
+    orphans: r.orphans.map((o) => ({
+      transaction_id: o.transactionId,
+      link: o.link,
+      ref: o.ref,
+    })),
+  };
+}
+
 /**
  * Export all data for migration
  * GET /api/admin/export
  */
 adminRouter.get("/admin/export", (_req: Request, res: Response) => {
   try {
-    const data = {
-      version: 1,
-      exported_at: new Date().toISOString(),
+    const data = backupService.seal({
       transactions: transactionRepository.findAll(),
       vaults: vaultRepository.findAll().map((vault) => ({
         ...vault,
         entries: vaultRepository.findAllEntries(vault.name),
       })),
       loans: loanRepository.findAll(),
+      projects: projectRepository.findAll(),
       types: adminRepository.findAllTypes(),
       accounts: adminRepository.findAllAccounts(),
       assets: adminRepository.findAllAssets(),
@@ -680,7 +2010,7 @@ adminRouter.get("/admin/export", (_req: Request, res: Response) => {
         default_income_vault: settingsRepository.getDefaultIncomeVaultName(),
         borrowing: settingsRepository.getBorrowingSettings(),
       },
-    };
+    });
 
     // Set headers for file download
     const timestamp = new Date().toISOString().split("T")[0]; // YYYY-MM-DD
@@ -698,21 +2028,46 @@ adminRouter.get("/admin/export", (_req: Request, res: Response) => {
 
 /**
  * Import data for migration
- * POST /api/admin/import
- * Body: { transactions, vaults, loans, types, accounts, assets, tags, settings }
+ * POST /api/admin/import?mode=skip-existing|overwrite|merge-newer&dry_run=true
+ * Body: an /admin/export file; checked against its version and checksums
+ * unless verify=false
  */
 adminRouter.post("/admin/import", async (req: Request, res: Response) => {
   try {
-    const data = req.body;
-    if (!data || typeof data !== "object") {
+    if (!req.body || typeof req.body !== "object") {
       return res.status(400).json({ error: "Invalid import data" });
     }
+    const backup = backupService.open(req.body, {
+      verify: String(req.query.verify ?? "true").toLowerCase() !== "false",
+    });
+    const data = backup.data;
+    const mode = String(req.query.mode || "skip-existing") as RestoreMode;
+    if (!RESTORE_MODES.includes(mode)) {
+      return res.status(400).json({
+        error: `Unknown restore mode "${mode}"`,
+        allowed: RESTORE_MODES,
+      });
+    }
+    const transactions = Array.isArray(data.transactions)
+      ? data.transactions
+      : [];
+
+    // Conflict report only; nothing is written
+    if (String(req.query.dry_run || "").toLowerCase() === "true") {
+      return res.json({
+        ok: true,
+        dry_run: true,
+        backup: summarizeBackup(backup),
+        transactions: backupService.planTransactions(transactions, mode),
+      });
+    }
 
     const stats = {
       transactions: 0,
       vaults: 0,
       vault_entries: 0,
       loans: 0,
+      projects: 0,
       types: 0,
       accounts: 0,
       assets: 0,
@@ -743,6 +2098,10 @@ adminRouter.post("/admin/import", async (req: Request, res: Response) => {
           adminRepository.createAccount({
             name: account.name,
             type: account.type,
+            institution: account.institution,
+            jurisdiction: account.jurisdiction,
+            default_asset: account.default_asset,
+            fx_margin_percent: account.fx_margin_percent,
             is_active: account.is_active,
           });
           stats.accounts++;
@@ -760,6 +2119,7 @@ adminRouter.post("/admin/import", async (req: Request, res: Response) => {
             symbol: asset.symbol,
             name: asset.name,
             decimals: asset.decimals,
+            jurisdiction: asset.jurisdiction,
             is_active: asset.is_active,
           });
           stats.assets++;
@@ -814,18 +2174,23 @@ adminRouter.post("/admin/import", async (req: Request, res: Response) => {
       }
     }
 
-    // Import transactions
-    if (Array.isArray(data.transactions)) {
-      for (const tx of data.transactions) {
+    // Import projects (referenced by transactions)
+    if (Array.isArray(data.projects)) {
+      for (const project of data.projects) {
         try {
-          transactionRepository.create(tx);
-          stats.transactions++;
+          if (projectRepository.findById(project.id)) continue;
+          projectRepository.create(project);
+          stats.projects++;
         } catch (e) {
-          // Skip duplicates
+          // Skip invalid projects
         }
       }
     }
 
+    // Import transactions; existing ids are resolved per `mode`
+    const restore = backupService.restoreTransactions(transactions, mode);
+    stats.transactions = restore.inserted;
+
     // Import loans
     if (Array.isArray(data.loans)) {
       for (const loan of data.loans) {
@@ -850,8 +2215,19 @@ adminRouter.post("/admin/import", async (req: Request, res: Response) => {
       }
     }
 
-    // Import settings
+    // Rebuild what the restored transactions imply but the backup lacked
+    const links = await backupService.relink(restore.restored, data);
+
+    // Import settings; the values they replace stay restorable in the trash
+    let settingsTrashId: string | undefined;
     if (data.settings) {
+      settingsTrashId = trashService.trashSettings("Settings before import", [
+        "defaultSpendingVaultName",
+        "defaultIncomeVaultName",
+        "borrowingVaultName",
+        "borrowingMonthlyRate",
+        "borrowingLastAccrualAt",
+      ]).id;
       if (data.settings.default_spending_vault) {
         settingsRepository.setDefaultSpendingVaultName(
           data.settings.default_spending_vault
@@ -867,8 +2243,24 @@ adminRouter.post("/admin/import", async (req: Request, res: Response) => {
       }
     }
 
-    res.json({ ok: true, imported: stats });
+    res.json({
+      ok: true,
+      backup: summarizeBackup(backup),
+      imported: stats,
+      transactions: {
+        mode,
+        inserted: restore.inserted,
+        overwritten: restore.overwritten,
+        skipped: restore.skipped,
+        failed: restore.failed,
+        conflicts: restore.plan.conflicts,
+        duplicates: restore.plan.duplicates,
+      },
+      links: toRelinkShape(links),
+      settings_trash_id: settingsTrashId ?? null,
+    });
   } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
     res.status(500).json({ error: e?.message || "Failed to import data" });
   }
 });
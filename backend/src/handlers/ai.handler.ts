@@ -4,6 +4,7 @@ import { v4 as uuidv4 } from "uuid";
 import { transactionService } from "../services/transaction.service";
 import { settingsRepository } from "../repositories";
 import { vaultService } from "../services/vault.service";
+import { accountDefaultsService } from "../services/account-defaults.service";
 import { transactionRepository } from "../repositories";
 import { Asset } from "../types";
 
@@ -359,5 +360,6 @@ aiRouter.get("/ai/settings", (_req: Request, res: Response) => {
   res.json({
     default_spending_vault: settingsRepository.getDefaultSpendingVaultName(),
     default_income_vault: settingsRepository.getDefaultIncomeVaultName(),
+    account_default_assets: accountDefaultsService.defaults(),
   });
 });
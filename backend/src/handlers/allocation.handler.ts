@@ -0,0 +1,84 @@
+import { Router, Request, Response } from "express";
+import {
+  AllocationConstraintsSchema,
+  GlidepathCreateSchema,
+  GlidepathUpdateSchema,
+} from "../types";
+import { allocationService } from "../services/allocation.service";
+
+// Target allocation glidepaths per asset class
+export const allocationRouter = Router();
+
+allocationRouter.get(
+  "/allocation/glidepaths",
+  (_req: Request, res: Response) => {
+    res.json(allocationService.listGlidepaths());
+  },
+);
+
+allocationRouter.post(
+  "/allocation/glidepaths",
+  (req: Request, res: Response) => {
+    try {
+      const body = GlidepathCreateSchema.parse(req.body || {});
+      res.status(201).json(allocationService.createGlidepath(body));
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid glidepath" });
+    }
+  },
+);
+
+allocationRouter.get(
+  "/allocation/glidepaths/:id",
+  (req: Request, res: Response) => {
+    const glidepath = allocationService.getGlidepath(req.params.id);
+    if (!glidepath) return res.status(404).json({ error: "not found" });
+    res.json(glidepath);
+  },
+);
+
+allocationRouter.put(
+  "/allocation/glidepaths/:id",
+  (req: Request, res: Response) => {
+    try {
+      const body = GlidepathUpdateSchema.parse(req.body || {});
+      const updated = allocationService.updateGlidepath(req.params.id, body);
+      if (!updated) return res.status(404).json({ error: "not found" });
+      res.json(updated);
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid glidepath" });
+    }
+  },
+);
+
+allocationRouter.delete(
+  "/allocation/glidepaths/:id",
+  (req: Request, res: Response) => {
+    const ok = allocationService.deleteGlidepath(req.params.id);
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  },
+);
+
+// Hard limits on the mix (e.g. at most 10% in any one token, at least
+// 20% cash), replaced as a whole; see /reports/allocation/constraints
+allocationRouter.get(
+  "/allocation/constraints",
+  (_req: Request, res: Response) => {
+    res.json({ constraints: allocationService.getConstraints() });
+  },
+);
+
+allocationRouter.put(
+  "/allocation/constraints",
+  (req: Request, res: Response) => {
+    try {
+      const { constraints } = AllocationConstraintsSchema.parse(
+        req.body || {},
+      );
+      res.json({ constraints: allocationService.setConstraints(constraints) });
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid constraints" });
+    }
+  },
+);
@@ -0,0 +1,45 @@
+import { Router, Request, Response } from "express";
+import { BudgetCreateSchema, BudgetUpdateSchema } from "../types";
+import { budgetService } from "../services/budget.service";
+import { isAppError } from "../core/errors";
+
+// Monthly spending limits per tag; see /reports/budgets for actual vs. budget
+export const budgetsRouter = Router();
+
+budgetsRouter.get("/budgets", (_req: Request, res: Response) => {
+  res.json(budgetService.list());
+});
+
+budgetsRouter.post("/budgets", (req: Request, res: Response) => {
+  try {
+    const body = BudgetCreateSchema.parse(req.body || {});
+    res.status(201).json(budgetService.create(body));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid budget" });
+  }
+});
+
+budgetsRouter.get("/budgets/:id", (req: Request, res: Response) => {
+  const item = budgetService.get(req.params.id);
+  if (!item) return res.status(404).json({ error: "not found" });
+  res.json(item);
+});
+
+budgetsRouter.put("/budgets/:id", (req: Request, res: Response) => {
+  try {
+    const body = BudgetUpdateSchema.parse(req.body || {});
+    const updated = budgetService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid budget" });
+  }
+});
+
+budgetsRouter.delete("/budgets/:id", (req: Request, res: Response) => {
+  const ok = budgetService.delete(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
@@ -0,0 +1,109 @@
+import { Router, Request, Response } from "express";
+import {
+  ConnectorCreateSchema,
+  ConnectorUpdateSchema,
+  ExchangeConnector,
+} from "../types";
+import { connectorService } from "../services/connector.service";
+import { isAppError } from "../core/errors";
+
+// Exchange accounts synced into transactions through read-only API keys
+export const connectorsRouter = Router();
+
+// The encrypted keys and sync positions are never returned
+function toConnectorShape(c: ExchangeConnector) {
+  return {
+    id: c.id,
+    provider: c.provider,
+    name: c.name,
+    account: c.account,
+    api_key_hint: c.apiKeyHint,
+    pairs: c.pairs,
+    since: c.since,
+    interval_hours: c.intervalHours,
+    active: c.active,
+    next_sync_at: c.nextSyncAt,
+    last_sync_at: c.lastSyncAt ?? null,
+    last_status: c.lastStatus ?? null,
+    last_error: c.lastError ?? null,
+    last_created: c.lastCreated ?? null,
+    created_at: c.createdAt,
+    updated_at: c.updatedAt ?? null,
+  };
+}
+
+connectorsRouter.get("/admin/connectors", (_req: Request, res: Response) => {
+  res.json(connectorService.list().map(toConnectorShape));
+});
+
+connectorsRouter.get("/admin/connectors/:id", (req: Request, res: Response) => {
+  const c = connectorService.get(req.params.id);
+  if (!c) return res.status(404).json({ error: "Connector not found" });
+  res.json(toConnectorShape(c));
+});
+
+connectorsRouter.post(
+  "/admin/connectors",
+  async (req: Request, res: Response) => {
+    try {
+      const body = ConnectorCreateSchema.parse(req.body || {});
+      const c = await connectorService.create(body);
+      res.status(201).json(toConnectorShape(c));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Invalid connector" });
+    }
+  },
+);
+
+connectorsRouter.put(
+  "/admin/connectors/:id",
+  async (req: Request, res: Response) => {
+    try {
+      const body = ConnectorUpdateSchema.parse(req.body || {});
+      const c = await connectorService.update(req.params.id, body);
+      if (!c) return res.status(404).json({ error: "Connector not found" });
+      res.json(toConnectorShape(c));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Invalid connector" });
+    }
+  },
+);
+
+// Transactions already synced are kept
+connectorsRouter.delete(
+  "/admin/connectors/:id",
+  (req: Request, res: Response) => {
+    if (!connectorService.delete(req.params.id)) {
+      return res.status(404).json({ error: "Connector not found" });
+    }
+    res.json({ ok: true });
+  },
+);
+
+// Sync now; the outcome is also kept on the connector
+connectorsRouter.post(
+  "/admin/connectors/:id/sync",
+  async (req: Request, res: Response) => {
+    try {
+      const run = await connectorService.sync(req.params.id);
+      res.json({
+        ok: run.status === "OK",
+        status: run.status,
+        error: run.error ?? null,
+        created: {
+          trades: run.created.TRADE,
+          deposits: run.created.DEPOSIT,
+          withdrawals: run.created.WITHDRAWAL,
+          rewards: run.created.REWARD,
+        },
+        skipped: run.skipped,
+        connector: toConnectorShape(run.connector),
+      });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to sync" });
+    }
+  },
+);
@@ -0,0 +1,64 @@
+import { Router, Request, Response } from "express";
+import {
+  CounterpartyHistory,
+  counterpartyService,
+  parsePaymentTypes,
+} from "../services/counterparty.service";
+import { usdToVnd } from "../services/vnd-rate.service";
+import { isAppError } from "../core/errors";
+
+// What has been paid to whom, for "how much do I usually pay?" lookups
+export const counterpartiesRouter = Router();
+
+function toHistoryShape(h: CounterpartyHistory) {
+  return {
+    counterparty: h.counterparty,
+    types: h.types,
+    count: h.count,
+    total_usd: h.totalUSD,
+    average_usd: h.averageUSD,
+    total_vnd: h.totalVND,
+    average_vnd: h.averageVND,
+    by_asset: h.byAsset.map((a) => ({
+      asset: a.asset,
+      count: a.count,
+      total: a.total,
+      average: a.average,
+      last: a.last,
+    })),
+    first_payment_at: h.firstAt,
+    last_payment_at: h.lastAt,
+    last_payment: h.last,
+    frequency: {
+      cadence: h.frequency.cadence,
+      median_interval_days: h.frequency.medianIntervalDays,
+      per_month: h.frequency.perMonth,
+      next_expected_at: h.frequency.nextExpectedAt,
+    },
+    transactions: h.transactions,
+  };
+}
+
+/**
+ * Payments to one counterparty (matched ignoring case and diacritics)
+ * GET /api/counterparties/:name/history?type=EXPENSE
+ */
+counterpartiesRouter.get(
+  "/counterparties/:name/history",
+  async (req: Request, res: Response) => {
+    try {
+      const types = parsePaymentTypes(
+        req.query.type ? String(req.query.type) : undefined,
+      );
+      const history = counterpartyService.history(
+        req.params.name,
+        await usdToVnd(),
+        types,
+      );
+      res.json(toHistoryShape(history));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to load history" });
+    }
+  },
+);
@@ -0,0 +1,83 @@
+import { Router, Request, Response } from "express";
+import { FixedIncomeCreateSchema, FixedIncomeUpdateSchema } from "../types";
+import {
+  couponSchedule,
+  fixedIncomeService,
+} from "../services/fixed-income.service";
+
+// Term deposits and bonds; coupons are posted by the accrual scheduler
+export const fixedIncomeRouter = Router();
+
+fixedIncomeRouter.get("/fixed-income", (req: Request, res: Response) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : undefined;
+  const kind = req.query.kind
+    ? String(req.query.kind).toUpperCase()
+    : undefined;
+  res.json(fixedIncomeService.listInstruments({ status, kind }));
+});
+
+fixedIncomeRouter.post("/fixed-income", (req: Request, res: Response) => {
+  try {
+    const body = FixedIncomeCreateSchema.parse(req.body || {});
+    res.status(201).json(fixedIncomeService.createInstrument(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid instrument" });
+  }
+});
+
+// Post coupons that are due now instead of waiting for the scheduler
+fixedIncomeRouter.post(
+  "/fixed-income/accrue",
+  async (_req: Request, res: Response) => {
+    try {
+      const posted = await fixedIncomeService.accrueDue();
+      res.json({ posted: posted.length, transactions: posted });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to accrue" });
+    }
+  },
+);
+
+fixedIncomeRouter.get("/fixed-income/:id", (req: Request, res: Response) => {
+  const inst = fixedIncomeService.getInstrument(req.params.id);
+  if (!inst) return res.status(404).json({ error: "not found" });
+  res.json(inst);
+});
+
+fixedIncomeRouter.get(
+  "/fixed-income/:id/schedule",
+  (req: Request, res: Response) => {
+    const inst = fixedIncomeService.getInstrument(req.params.id);
+    if (!inst) return res.status(404).json({ error: "not found" });
+    res.json(
+      couponSchedule(inst).map((c) => ({
+        period_start: c.periodStart,
+        payment_at: c.paymentAt,
+        coupon: c.amount,
+        principal: c.final ? inst.faceValue : 0,
+      })),
+    );
+  },
+);
+
+fixedIncomeRouter.put("/fixed-income/:id", (req: Request, res: Response) => {
+  try {
+    const body = FixedIncomeUpdateSchema.parse(req.body || {});
+    const updated = fixedIncomeService.updateInstrument(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid instrument" });
+  }
+});
+
+fixedIncomeRouter.delete(
+  "/fixed-income/:id",
+  (req: Request, res: Response) => {
+    const ok = fixedIncomeService.deleteInstrument(req.params.id);
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  },
+);
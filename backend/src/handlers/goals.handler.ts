@@ -0,0 +1,66 @@
+import { Router, Request, Response } from "express";
+import { GoalCreateSchema, GoalUpdateSchema } from "../types";
+import { goalMonths, goalService } from "../services/goal.service";
+import { usdToVnd } from "../services/vnd-rate.service";
+import { isAppError } from "../core/errors";
+
+// Savings goals; see /reports/goals for the progress of all of them
+export const goalsRouter = Router();
+
+goalsRouter.get("/goals", (_req: Request, res: Response) => {
+  res.json(goalService.list());
+});
+
+goalsRouter.post("/goals", (req: Request, res: Response) => {
+  try {
+    const body = GoalCreateSchema.parse(req.body || {});
+    res.status(201).json(goalService.create(body));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid goal" });
+  }
+});
+
+goalsRouter.get("/goals/:id", (req: Request, res: Response) => {
+  const item = goalService.get(req.params.id);
+  if (!item) return res.status(404).json({ error: "not found" });
+  res.json(item);
+});
+
+/**
+ * Funded percentage and projected completion of one goal
+ * GET /api/goals/:id/progress?months=6
+ */
+goalsRouter.get("/goals/:id/progress", async (req: Request, res: Response) => {
+  try {
+    const months = goalMonths(
+      req.query.months ? String(req.query.months) : undefined,
+    );
+    const item = goalService.get(req.params.id);
+    if (!item) return res.status(404).json({ error: "not found" });
+    res.json(await goalService.progress(item, await usdToVnd(), months));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to compute goal progress",
+    });
+  }
+});
+
+goalsRouter.put("/goals/:id", (req: Request, res: Response) => {
+  try {
+    const body = GoalUpdateSchema.parse(req.body || {});
+    const updated = goalService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid goal" });
+  }
+});
+
+goalsRouter.delete("/goals/:id", (req: Request, res: Response) => {
+  const ok = goalService.delete(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
@@ -7,3 +7,26 @@ export * from "./reports.handler";
 export * from "./actions.handler";
 export * from "./ai.handler";
 export * from "./prices.handler";
+export * from "./project.handler";
+export * from "./registry.handler";
+export * from "./mobile.handler";
+export * from "./fixed-income.handler";
+export * from "./option.handler";
+export * from "./vesting.handler";
+export * from "./address-book.handler";
+export * from "./allocation.handler";
+export * from "./activity.handler";
+export * from "./report-subscriptions.handler";
+export * from "./meta.handler";
+export * from "./recurring.handler";
+export * from "./budgets.handler";
+export * from "./goals.handler";
+export * from "./jobs.handler";
+export * from "./webhooks.handler";
+export * from "./sheets-export.handler";
+export * from "./connectors.handler";
+export * from "./wallets.handler";
+export * from "./year-close.handler";
+export * from "./month-review.handler";
+export * from "./accounts.handler";
+export * from "./counterparties.handler";
@@ -0,0 +1,43 @@
+import { Router, Request, Response } from "express";
+import { jobService, Job } from "../services/job.service";
+
+// Progress and cancellation of background jobs started by heavyweight
+// endpoints (e.g. GET /reports/networth?async=true)
+export const jobsRouter = Router();
+
+export function toJobShape(j: Job) {
+  return {
+    id: j.id,
+    kind: j.kind,
+    status: j.status,
+    progress: Math.round(j.progress * 10) / 10,
+    stage: j.stage ?? null,
+    cancel_requested: j.cancelRequested,
+    created_at: j.createdAt,
+    started_at: j.startedAt ?? null,
+    finished_at: j.finishedAt ?? null,
+    error: j.error ?? null,
+  };
+}
+
+jobsRouter.get("/jobs", (req: Request, res: Response) => {
+  const kind = req.query.kind ? String(req.query.kind) : undefined;
+  res.json(jobService.list(kind).map(toJobShape));
+});
+
+// Status, plus the result once the job has succeeded
+jobsRouter.get("/jobs/:id", (req: Request, res: Response) => {
+  const job = jobService.get(req.params.id);
+  if (!job) return res.status(404).json({ error: "not found" });
+  res.json({
+    ...toJobShape(job),
+    result: job.status === "succeeded" ? job.result : null,
+  });
+});
+
+// The job stops at its next checkpoint; finished jobs are left as they are
+jobsRouter.post("/jobs/:id/cancel", (req: Request, res: Response) => {
+  const job = jobService.cancel(req.params.id);
+  if (!job) return res.status(404).json({ error: "not found" });
+  res.json(toJobShape(job));
+});
@@ -0,0 +1,74 @@
+import { Router, Request, Response } from "express";
+import { adminRepository } from "../repositories";
+import { etagCache } from "../core/middleware";
+import { accountDefaultsService } from "../services/account-defaults.service";
+import {
+  ACTION_NAMES,
+  ASSET_KINDS,
+  IncomeExpenseSchema,
+  PRICE_PROVIDERS,
+  TRANSACTION_TYPES,
+  VAULT_STATUSES,
+} from "../types";
+
+// Enums and lookup lists for clients in one call. The body is rebuilt on
+// every request so admin edits show at once; clients revalidate with
+// If-None-Match and get a 304 while nothing changed.
+export const metaRouter = Router();
+metaRouter.use("/meta", etagCache());
+
+// Read bounds off the request schema so they can't drift from validation
+function boundsOf(field: "latitude" | "longitude") {
+  const schema = IncomeExpenseSchema.shape[field].unwrap();
+  return { min: schema.minValue, max: schema.maxValue };
+}
+
+// GET /api/meta
+metaRouter.get("/meta", (_req: Request, res: Response) => {
+  try {
+    const defaults = accountDefaultsService.defaults();
+    const accounts = adminRepository
+      .findAllAccounts()
+      .filter((a) => a.is_active)
+      .map((a) => ({
+        name: a.name,
+        type: a.type,
+        institution: a.institution,
+        default_asset: defaults[a.name],
+      }));
+    const assets = adminRepository
+      .findAllAssets()
+      .filter((a) => a.is_active)
+      .map((a) => ({
+        symbol: a.symbol,
+        name: a.name,
+        decimals: a.decimals,
+        kind: a.kind,
+      }));
+    const tags = adminRepository
+      .findAllTags()
+      .filter((t) => t.is_active)
+      .map((t) => t.name);
+
+    res.json({
+      transaction_types: TRANSACTION_TYPES,
+      actions: ACTION_NAMES,
+      vault_statuses: VAULT_STATUSES,
+      asset_kinds: ASSET_KINDS,
+      price_providers: PRICE_PROVIDERS,
+      accounts,
+      assets,
+      tags,
+      constraints: {
+        amount: { exclusive_min: 0 },
+        fee_usd: { min: 0 },
+        percent: { min: 0, max: 100 },
+        latitude: boundsOf("latitude"),
+        longitude: boundsOf("longitude"),
+        location: "latitude and longitude must be given together",
+      },
+    });
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to read metadata" });
+  }
+});
@@ -0,0 +1,119 @@
+import { Router, Request, Response } from "express";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { transactionService } from "../services/transaction.service";
+import { usdToVnd } from "../services/vnd-rate.service";
+import { etagCache } from "../core/middleware";
+import { Transaction } from "../types";
+
+// Compact summaries for the mobile client; polled, so responses carry ETags
+export const mobileRouter = Router();
+mobileRouter.use("/mobile", etagCache());
+
+// Same spending basis as /reports/spending: exclusion rules applied and
+// matched reimbursements netted out
+function spendingFor(account: string): Transaction[] {
+  const reimbursed = transactionService.getReimbursedUSDByExpense(
+    transactionRepository.findAll(),
+  );
+  return transactionRepository
+    .findSpending({
+      account,
+      exclusions: settingsRepository.getSpendingExclusionRules(),
+    })
+    .map((t) => {
+      const refunded = reimbursed.get(t.id) || 0;
+      if (!refunded) return t;
+      return {
+        ...t,
+        usdAmount: Math.max(0, (t.usdAmount || 0) - refunded),
+      } as Transaction;
+    })
+    .filter((t) => (t.usdAmount || 0) > 1e-9);
+}
+
+function accountParam(req: Request): string {
+  return req.query.account
+    ? String(req.query.account)
+    : settingsRepository.getDefaultSpendingVaultName();
+}
+
+mobileRouter.get(
+  "/mobile/today-spend",
+  async (req: Request, res: Response) => {
+    try {
+      const today = new Date().toISOString().slice(0, 10);
+      const txs = spendingFor(accountParam(req)).filter(
+        (t) => String(t.createdAt).slice(0, 10) === today,
+      );
+      const usd = txs.reduce((s, t) => s + (t.usdAmount || 0), 0);
+      const rate = await usdToVnd();
+      res.json({
+        date: today,
+        count: txs.length,
+        total_usd: usd,
+        total_vnd: usd * rate,
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to compute spend" });
+    }
+  },
+);
+
+mobileRouter.get(
+  "/mobile/month-by-tag",
+  async (req: Request, res: Response) => {
+    try {
+      const month = new Date().toISOString().slice(0, 7);
+      const byTag = new Map<string, number>();
+      let total = 0;
+      for (const t of spendingFor(accountParam(req))) {
+        if (String(t.createdAt).slice(0, 7) !== month) continue;
+        const tag = t.category || "uncategorized";
+        byTag.set(tag, (byTag.get(tag) || 0) + (t.usdAmount || 0));
+        total += t.usdAmount || 0;
+      }
+      const rate = await usdToVnd();
+      res.json({
+        month,
+        total_usd: total,
+        total_vnd: total * rate,
+        tags: Array.from(byTag.entries())
+          .sort((a, b) => b[1] - a[1])
+          .map(([tag, usd]) => ({
+            tag,
+            total_usd: usd,
+            total_vnd: usd * rate,
+          })),
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to compute tags" });
+    }
+  },
+);
+
+mobileRouter.get(
+  "/mobile/net-worth",
+  async (_req: Request, res: Response) => {
+    try {
+      const { totals, holdings } = await transactionService.generateReport();
+      const rate = await usdToVnd();
+      const institutions = transactionService.getAccountInstitutions();
+      const byInstitution: Record<string, number> = {};
+      for (const h of holdings) {
+        const inst = (h.account && institutions.get(h.account)) || "Unassigned";
+        byInstitution[inst] = (byInstitution[inst] || 0) + h.valueUSD;
+      }
+      res.json({
+        net_worth_usd: totals.netWorthUSD,
+        net_worth_vnd: totals.netWorthUSD * rate,
+        holdings_usd: totals.holdingsUSD,
+        liabilities_usd: totals.liabilitiesUSD,
+        holdings_by_institution_usd: byInstitution,
+      });
+    } catch (e: any) {
+      res.status(500).json({
+        error: e?.message || "Failed to compute net worth",
+      });
+    }
+  },
+);
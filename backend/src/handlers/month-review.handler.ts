@@ -0,0 +1,86 @@
+import { Router, Request, Response } from "express";
+import {
+  MonthReview,
+  MonthReviewCompleteSchema,
+  MonthReviewMarkSchema,
+} from "../types";
+import {
+  MonthReviewWorklist,
+  monthReviewService,
+} from "../services/month-review.service";
+import { isAppError } from "../core/errors";
+
+// Zero-based monthly review: work a month's list down to empty, then sign
+// it off
+export const monthReviewRouter = Router();
+
+function toReviewShape(r: MonthReview) {
+  return {
+    month: r.month,
+    reviewed_at: r.reviewedAt,
+    transaction_count: r.transactionCount,
+    note: r.note ?? null,
+  };
+}
+
+function toWorklistShape(w: MonthReviewWorklist) {
+  return {
+    month: w.month.month,
+    start: w.month.start,
+    end: w.month.end,
+    status: w.status,
+    review: w.review ? toReviewShape(w.review) : null,
+    transaction_count: w.transactionCount,
+    counts: w.counts,
+    items: w.items.map((i) => ({
+      ...i.transaction,
+      reasons: i.reasons,
+      anomalies: i.anomalies,
+    })),
+  };
+}
+
+monthReviewRouter.get("/reviews", (_req: Request, res: Response) => {
+  res.json(monthReviewService.list().map(toReviewShape));
+});
+
+monthReviewRouter.get("/reviews/:month", (req: Request, res: Response) => {
+  try {
+    res.json(toWorklistShape(monthReviewService.worklist(req.params.month)));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({ error: e?.message || "Failed to load review" });
+  }
+});
+
+// Body: { ids: [...] } — transactions of the month to mark reviewed
+monthReviewRouter.post(
+  "/reviews/:month/transactions",
+  (req: Request, res: Response) => {
+    try {
+      const { ids } = MonthReviewMarkSchema.parse(req.body || {});
+      res.json(
+        toWorklistShape(
+          monthReviewService.markReviewed(req.params.month, ids),
+        ),
+      );
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
+
+monthReviewRouter.post(
+  "/reviews/:month/complete",
+  (req: Request, res: Response) => {
+    try {
+      const { note } = MonthReviewCompleteSchema.parse(req.body || {});
+      const review = monthReviewService.complete(req.params.month, { note });
+      res.status(201).json(toReviewShape(review));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
@@ -0,0 +1,51 @@
+import { Router, Request, Response } from "express";
+import { OptionOpenSchema, OptionSettleSchema } from "../types";
+import { optionService } from "../services/option.service";
+
+export const optionsRouter = Router();
+
+optionsRouter.get("/options", (req: Request, res: Response) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : undefined;
+  res.json(optionService.listPositions(status));
+});
+
+optionsRouter.post("/options", async (req: Request, res: Response) => {
+  try {
+    const body = OptionOpenSchema.parse(req.body || {});
+    const result = await optionService.openPosition(body);
+    res.status(201).json(result);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid option position" });
+  }
+});
+
+optionsRouter.get("/options/:id", (req: Request, res: Response) => {
+  const position = optionService.getPosition(req.params.id);
+  if (!position) return res.status(404).json({ error: "not found" });
+  res.json(position);
+});
+
+optionsRouter.post("/options/:id/expire", (req: Request, res: Response) => {
+  try {
+    const body = OptionSettleSchema.parse(req.body || {});
+    res.json(optionService.expire(req.params.id, body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Failed to expire option" });
+  }
+});
+
+optionsRouter.post(
+  "/options/:id/exercise",
+  async (req: Request, res: Response) => {
+    try {
+      const body = OptionSettleSchema.parse(req.body || {});
+      res.json(await optionService.exercise(req.params.id, body));
+    } catch (e: any) {
+      res
+        .status(400)
+        .json({ error: e?.message || "Failed to exercise option" });
+    }
+  },
+);
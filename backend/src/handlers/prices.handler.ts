@@ -1,6 +1,11 @@
 import { Router } from "express";
 import { priceService } from "../services/price.service";
+import { stablecoinService } from "../services/stablecoin.service";
 import { createAssetFromSymbol } from "../utils/asset.util";
+import { Asset, PriceBatchSchema } from "../types";
+import { jobService } from "../services/job.service";
+import { priceSnapshotService } from "../services/price-snapshot.service";
+import { toJobShape } from "./jobs.handler";
 
 export const pricesRouter = Router();
 
@@ -18,34 +23,239 @@ function atStartOfDayISO(dateStr?: string): string | undefined {
   return iso;
 }
 
-// Simple daily price endpoint to satisfy frontend AdminAssetsTab
-// GET /api/prices/daily?symbol=BTC&currency=USD&start=YYYY-MM-DD&end=YYYY-MM-DD
+const DEFAULT_PAGE_DAYS = 366;
+const MAX_PAGE_DAYS = 1000;
+
+// Accepts ?symbols=BTC,ETH or repeated ?symbol=BTC&symbol=ETH
+function parseSymbols(...values: unknown[]): string[] {
+  const out: string[] = [];
+  for (const v of values) {
+    const parts = Array.isArray(v) ? v : [v];
+    for (const p of parts) {
+      for (const s of String(p ?? "").split(",")) {
+        const sym = s.trim().toUpperCase();
+        if (sym && !out.includes(sym)) out.push(sym);
+      }
+    }
+  }
+  return out;
+}
+
+// Inclusive UTC days between start and end, sorted ascending
+function buildDayRange(startStr: string, endStr: string): string[] | string {
+  const start = new Date(startStr);
+  const end = new Date(endStr);
+  if (isNaN(start.getTime()) || isNaN(end.getTime())) {
+    return "Invalid start or end date";
+  }
+  if (start.getTime() > end.getTime()) {
+    return "start must be before or equal to end";
+  }
+
+  const days: string[] = [];
+  const iter = new Date(
+    Date.UTC(start.getUTCFullYear(), start.getUTCMonth(), start.getUTCDate()),
+  );
+  const endUTC = new Date(
+    Date.UTC(end.getUTCFullYear(), end.getUTCMonth(), end.getUTCDate()),
+  );
+  while (iter.getTime() <= endUTC.getTime()) {
+    days.push(dateOnly(iter));
+    iter.setUTCDate(iter.getUTCDate() + 1);
+  }
+  return days;
+}
+
+// Page over the day range, not the rows: a page holds every row of
+// `days_per_page` days, from the `offset`-th day. The day count is
+// exposed via X-Total-Days
+function paginateDays(req: any, res: any, days: string[]): string[] {
+  const perPage = Math.min(
+    MAX_PAGE_DAYS,
+    Math.max(1, Number(req.query.days_per_page) || DEFAULT_PAGE_DAYS),
+  );
+  const offset = Math.max(0, Number(req.query.offset) || 0);
+  res.setHeader("X-Total-Days", String(days.length));
+  return days.slice(offset, offset + perPage);
+}
+
+// Today's rate uses the live quote; past days use the historical cache
+function rateAtFor(day: string): string | undefined {
+  return day === dateOnly(new Date()) ? undefined : atStartOfDayISO(day);
+}
+
+// Daily price series for one or more assets
+// GET /api/prices/daily?symbols=BTC,ETH&currency=USD&start=YYYY-MM-DD&end=YYYY-MM-DD&days_per_page=&offset=
+// Always [{ date, symbol, price }], by date, then in the order asked for
 pricesRouter.get("/prices/daily", async (req, res) => {
   try {
-    const symbol = String(req.query.symbol || "").toUpperCase();
+    const symbols = parseSymbols(req.query.symbols, req.query.symbol);
     const currency = String(req.query.currency || "USD").toUpperCase();
-    if (!symbol) return res.status(400).json({ error: "symbol required" });
+    if (!symbols.length) {
+      return res.status(400).json({ error: "symbol required" });
+    }
 
-    const asset = createAssetFromSymbol(symbol);
-    const currencyAsset = createAssetFromSymbol(currency);
+    const startStr = String(req.query.start || "") || dateOnly(new Date());
+    const endStr = String(req.query.end || "") || startStr;
+    const days = buildDayRange(startStr, endStr);
+    if (typeof days === "string") return res.status(400).json({ error: days });
+    const page = paginateDays(req, res, days);
 
-    const aRate = await priceService.getRateUSD(asset);
-    const cRate = await priceService.getRateUSD(currencyAsset);
+    const currencyAsset = createAssetFromSymbol(currency);
+    const series: Array<{ date: string; symbol: string; price: number }> = [];
 
-    // price of 1 symbol in target currency
-    // aRate.rateUSD = 1 asset -> USD
-    // cRate.rateUSD = 1 currencyAsset -> USD
-    // To get asset -> currency: (asset->USD) / (currency->USD)
-    const price = aRate.rateUSD / (cRate.rateUSD || 1);
+    for (const day of page) {
+      const at = rateAtFor(day);
+      const cRate = await priceService.getRateUSD(currencyAsset, at);
+      for (const symbol of symbols) {
+        // price of 1 symbol in target currency:
+        // (asset->USD) / (currency->USD)
+        const aRate = await priceService.getRateUSD(
+          createAssetFromSymbol(symbol),
+          at,
+        );
+        series.push({
+          date: day,
+          symbol,
+          price: aRate.rateUSD / (cRate.rateUSD || 1),
+        });
+      }
+    }
 
-    // Return an array with current day (frontend uses the first element)
-    const today = dateOnly(new Date());
-    res.json([{ date: today, price }]);
+    res.json(series);
   } catch (e: any) {
     res.status(500).json({ error: e?.message || "Failed to fetch price" });
   }
 });
 
+// Batch quotes in one call: POST /api/prices/batch
+// { quotes: [{ asset: "BTC" | Asset, currency?: "USD", date?: "YYYY-MM-DD" }] }
+// Results keep request order; cache misses are fetched concurrently.
+pricesRouter.post("/prices/batch", async (req, res) => {
+  let quotes: Array<{
+    asset: Asset;
+    currency: Asset;
+    date: string;
+    at?: string;
+  }>;
+  try {
+    const body = PriceBatchSchema.parse(req.body || {});
+    quotes = body.quotes.map((q) => {
+      const asset: Asset =
+        typeof q.asset === "string"
+          ? createAssetFromSymbol(q.asset)
+          : { ...q.asset, symbol: q.asset.symbol.toUpperCase() };
+      const d = q.date ? new Date(q.date) : new Date();
+      if (isNaN(d.getTime())) throw new Error(`Invalid date: ${q.date}`);
+      const date = dateOnly(d);
+      return {
+        asset,
+        currency: createAssetFromSymbol(q.currency),
+        date,
+        at: rateAtFor(date),
+      };
+    });
+  } catch (e: any) {
+    return res.status(400).json({ error: e?.message || "Invalid request" });
+  }
+
+  try {
+    const rates = await priceService.getRatesBatch(
+      quotes.flatMap((q) => [
+        { asset: q.asset, atISO: q.at },
+        { asset: q.currency, atISO: q.at },
+      ]),
+    );
+
+    res.json(
+      quotes.map((q, i) => {
+        const a = rates[2 * i];
+        const c = rates[2 * i + 1];
+        return {
+          symbol: q.asset.symbol,
+          currency: q.currency.symbol,
+          date: q.date,
+          price: a.rate.rateUSD / (c.rate.rateUSD || 1),
+          rate_usd: a.rate.rateUSD,
+          source: a.rate.source,
+          cached: a.cached && c.cached,
+          stale: !!(a.rate.stale || c.rate.stale),
+        };
+      }),
+    );
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to fetch prices" });
+  }
+});
+
+// Fill in missing daily rates for the last `days` days (default 30, at
+// most 365) as a background job; follow it at /api/jobs/:id
+// POST /api/prices/backfill { days?: number }
+pricesRouter.post("/prices/backfill", (req, res) => {
+  const days = req.body?.days === undefined ? 30 : Number(req.body.days);
+  if (!Number.isInteger(days) || days < 1 || days > 365) {
+    return res.status(400).json({ error: "days must be 1 to 365" });
+  }
+  const job = jobService.start("price-backfill", (ctx) =>
+    priceService.syncHistoricalPrices(days, ctx),
+  );
+  res.status(202).json(toJobShape(job));
+});
+
+// Store the closing rates of a day (default: the last closed UTC day) for
+// every held asset and FX currency, replacing cached rates of that day
+// POST /api/prices/snapshot { date?: "YYYY-MM-DD" }
+pricesRouter.post("/prices/snapshot", async (req, res) => {
+  const date = req.body?.date ? String(req.body.date) : undefined;
+  if (date && !/^\d{4}-\d{2}-\d{2}$/.test(date)) {
+    return res.status(400).json({ error: "date must be YYYY-MM-DD" });
+  }
+  if (date && date >= dateOnly(new Date())) {
+    return res.status(400).json({ error: "date must be a closed day" });
+  }
+  try {
+    const r = await priceSnapshotService.takeSnapshot(date);
+    res.json({
+      date: r.day,
+      assets: r.assets,
+      saved: r.saved.map((rate) => ({
+        asset: rate.asset.symbol,
+        rate_usd: rate.rateUSD,
+        source: rate.source,
+      })),
+      failed: r.failed.map((a) => a.symbol),
+    });
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to take snapshot" });
+  }
+});
+
+// Stablecoin peg status; ?refresh=true fetches live quotes first
+// GET /api/prices/stablecoins
+pricesRouter.get("/prices/stablecoins", async (req, res) => {
+  try {
+    if (String(req.query.refresh) === "true") {
+      await stablecoinService.checkPegs();
+    }
+    res.json({
+      threshold_percent: stablecoinService.getThresholdPercent(),
+      stablecoins: stablecoinService.getStatuses().map((s) => ({
+        symbol: s.symbol,
+        price_usd: s.priceUSD,
+        deviation_percent: s.deviationPercent,
+        depegged: s.depegged,
+        valued_at_usd: s.depegged ? s.priceUSD : 1,
+        checked_at: s.checkedAt,
+        depegged_since: s.depeggedSince ?? null,
+      })),
+    });
+  } catch (e: any) {
+    res
+      .status(500)
+      .json({ error: e?.message || "Failed to check stablecoin pegs" });
+  }
+});
+
 // FX endpoints used by frontend fxService
 // GET /api/fx/today?from=USD&to=VND
 pricesRouter.get("/fx/today", async (req, res) => {
@@ -71,59 +281,44 @@ pricesRouter.get("/fx/today", async (req, res) => {
   }
 });
 
-// GET /api/fx/history?from=USD&to=VND&start=YYYY-MM-DD&end=YYYY-MM-DD
+// GET /api/fx/history?from=USD&to=VND,EUR&start=YYYY-MM-DD&end=YYYY-MM-DD&days_per_page=&offset=
+// Always [{ date, rate, from, to }], by date, then in the order asked for
 pricesRouter.get("/fx/history", async (req, res) => {
   try {
     const from = String(req.query.from || "USD").toUpperCase();
-    const to = String(req.query.to || "USD").toUpperCase();
+    const targets = parseSymbols(req.query.to);
+    if (!targets.length) targets.push("USD");
     const startStr = String(req.query.start || "") || dateOnly(new Date());
     const endStr = String(req.query.end || "") || startStr;
 
-    const fromAsset = createAssetFromSymbol(from);
-    const toAsset = createAssetFromSymbol(to);
-
-    const start = new Date(startStr);
-    const end = new Date(endStr);
-    if (isNaN(start.getTime()) || isNaN(end.getTime())) {
-      return res.status(400).json({ error: "Invalid start or end date" });
-    }
-
-    // Ensure start <= end
-    if (start.getTime() > end.getTime()) {
-      return res
-        .status(400)
-        .json({ error: "start must be before or equal to end" });
-    }
-
-    // Build inclusive list of dates (UTC days)
-    const days: string[] = [];
-    const iter = new Date(
-      Date.UTC(start.getUTCFullYear(), start.getUTCMonth(), start.getUTCDate()),
-    );
-    const endUTC = new Date(
-      Date.UTC(end.getUTCFullYear(), end.getUTCMonth(), end.getUTCDate()),
-    );
-    while (iter.getTime() <= endUTC.getTime()) {
-      days.push(dateOnly(iter));
-      iter.setUTCDate(iter.getUTCDate() + 1);
-    }
+    const days = buildDayRange(startStr, endStr);
+    if (typeof days === "string") return res.status(400).json({ error: days });
+    const page = paginateDays(req, res, days);
 
-    // For each day, compute derived rate from asset->USD divided by to->USD
-    const results = [] as Array<{
+    const fromAsset = createAssetFromSymbol(from);
+    const series: Array<{
       date: string;
       rate: number;
       from: string;
       to: string;
-    }>;
-    for (const day of days) {
+    }> = [];
+
+    // For each day, compute derived rate from asset->USD divided by to->USD
+    for (const day of page) {
       const atISO = atStartOfDayISO(day);
       const fromRate = await priceService.getRateUSD(fromAsset, atISO);
-      const toRate = await priceService.getRateUSD(toAsset, atISO);
-      const rate = fromRate.rateUSD / (toRate.rateUSD || 1);
-      results.push({ date: day, rate, from, to });
+      for (const to of targets) {
+        const toAsset = createAssetFromSymbol(to);
+        const toRate =
+          toAsset.symbol === fromAsset.symbol
+            ? fromRate
+            : await priceService.getRateUSD(toAsset, atISO);
+        const rate = fromRate.rateUSD / (toRate.rateUSD || 1);
+        series.push({ date: day, rate, from, to });
+      }
     }
 
-    res.json(results);
+    res.json(series);
   } catch (e: any) {
     res.status(500).json({ error: e?.message || "Failed to fetch FX history" });
   }
@@ -0,0 +1,126 @@
+import { Router, Request, Response } from "express";
+import {
+  ProjectAssignSchema,
+  ProjectCreateSchema,
+  ProjectUpdateSchema,
+} from "../types";
+import { projectService } from "../services/project.service";
+import { usdToVnd } from "../services/vnd-rate.service";
+
+export const projectsRouter = Router();
+
+projectsRouter.get("/projects", (req: Request, res: Response) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : undefined;
+  res.json(projectService.listProjects(status));
+});
+
+projectsRouter.post("/projects", (req: Request, res: Response) => {
+  try {
+    const body = ProjectCreateSchema.parse(req.body || {});
+    res.status(201).json(projectService.createProject(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid project" });
+  }
+});
+
+projectsRouter.get("/projects/:id", (req: Request, res: Response) => {
+  const project = projectService.getProject(req.params.id);
+  if (!project) return res.status(404).json({ error: "not found" });
+  res.json(project);
+});
+
+projectsRouter.put("/projects/:id", (req: Request, res: Response) => {
+  try {
+    const body = ProjectUpdateSchema.parse(req.body || {});
+    const updated = projectService.updateProject(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid project" });
+  }
+});
+
+projectsRouter.delete("/projects/:id", (req: Request, res: Response) => {
+  const ok = projectService.deleteProject(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
+
+// Assign transactions to a project
+projectsRouter.post(
+  "/projects/:id/transactions",
+  (req: Request, res: Response) => {
+    try {
+      if (!projectService.getProject(req.params.id)) {
+        return res.status(404).json({ error: "not found" });
+      }
+      const body = ProjectAssignSchema.parse(req.body || {});
+      const updated = projectService.assignTransactions(
+        req.params.id,
+        body.transaction_ids,
+      );
+      res.json({ ok: true, assigned: updated.length });
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
+
+projectsRouter.delete(
+  "/projects/:id/transactions/:txId",
+  (req: Request, res: Response) => {
+    const ok = projectService.unassignTransaction(
+      req.params.id,
+      req.params.txId,
+    );
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  },
+);
+
+// Per-project cost report
+projectsRouter.get(
+  "/projects/:id/report",
+  async (req: Request, res: Response) => {
+    try {
+      const report = projectService.buildReport(req.params.id);
+      if (!report) return res.status(404).json({ error: "not found" });
+      const rate = await usdToVnd();
+
+      const withVnd = <T extends { usd: number }>(m: Record<string, T>) =>
+        Object.fromEntries(
+          Object.entries(m).map(([k, { usd, ...rest }]) => [
+            k,
+            { ...rest, total_usd: usd, total_vnd: usd * rate },
+          ]),
+        );
+
+      res.json({
+        project: report.project,
+        total_usd: report.totalUSD,
+        total_vnd: report.totalUSD * rate,
+        refunds_usd: report.refundsUSD,
+        net_usd: report.netUSD,
+        net_vnd: report.netUSD * rate,
+        days: report.days,
+        daily_burn_usd: report.dailyBurnUSD,
+        daily_burn_vnd: report.dailyBurnUSD * rate,
+        by_asset: withVnd(report.byAsset),
+        by_category: withVnd(report.byCategory),
+        by_account: withVnd(report.byAccount),
+        daily: report.daily.map((d) => ({
+          date: d.date,
+          total_usd: d.usd,
+          total_vnd: d.usd * rate,
+        })),
+        transactions: report.transactions,
+      });
+    } catch (e: any) {
+      res
+        .status(500)
+        .json({ error: e?.message || "Failed to build project report" });
+    }
+  },
+);
@@ -0,0 +1,121 @@
+import { Router, Request, Response } from "express";
+import { RecurringCreateSchema, RecurringUpdateSchema } from "../types";
+import { recurringTransactionService } from "../services/recurring.service";
+import { isAppError } from "../core/errors";
+
+// Recurring income and expenses created on an RRULE-like schedule
+export const recurringRouter = Router();
+
+recurringRouter.get("/recurring", (_req: Request, res: Response) => {
+  res.json(recurringTransactionService.list());
+});
+
+recurringRouter.post("/recurring", (req: Request, res: Response) => {
+  try {
+    const body = RecurringCreateSchema.parse(req.body || {});
+    res.status(201).json(recurringTransactionService.create(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid recurring entry" });
+  }
+});
+
+// Create whatever is due now instead of waiting for the hourly run
+recurringRouter.post("/recurring/run", async (_req: Request, res: Response) => {
+  try {
+    res.json(await recurringTransactionService.processDue());
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to run schedule" });
+  }
+});
+
+// Entries whose latest charge no longer matches their amount
+recurringRouter.get(
+  "/recurring/drift",
+  async (_req: Request, res: Response) => {
+    try {
+      res.json(await recurringTransactionService.checkDrifts());
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to check drift" });
+    }
+  },
+);
+
+recurringRouter.get("/recurring/:id", (req: Request, res: Response) => {
+  const item = recurringTransactionService.get(req.params.id);
+  if (!item) return res.status(404).json({ error: "not found" });
+  res.json(item);
+});
+
+recurringRouter.put("/recurring/:id", (req: Request, res: Response) => {
+  try {
+    const body = RecurringUpdateSchema.parse(req.body || {});
+    const updated = recurringTransactionService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid recurring entry" });
+  }
+});
+
+recurringRouter.delete("/recurring/:id", (req: Request, res: Response) => {
+  const ok = recurringTransactionService.delete(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
+
+// What each occurrence actually charged, oldest first
+recurringRouter.get(
+  "/recurring/:id/history",
+  (req: Request, res: Response) => {
+    const item = recurringTransactionService.get(req.params.id);
+    if (!item) return res.status(404).json({ error: "not found" });
+    res.json(recurringTransactionService.history(item));
+  },
+);
+
+// Take the drifted charge as the new amount
+recurringRouter.post(
+  "/recurring/:id/drift/apply",
+  (req: Request, res: Response) => {
+    try {
+      res.json(recurringTransactionService.applyDrift(req.params.id));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Failed to apply drift" });
+    }
+  },
+);
+
+// Pending occurrences (entries with confirm set); body: { date, amount? }
+// where amount is what was charged when it differs from the entry's
+recurringRouter.post(
+  "/recurring/:id/confirm",
+  async (req: Request, res: Response) => {
+    try {
+      const amount =
+        req.body?.amount !== undefined ? Number(req.body.amount) : undefined;
+      const tx = await recurringTransactionService.confirm(
+        req.params.id,
+        String(req.body?.date || ""),
+        amount,
+      );
+      res.status(201).json(tx);
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Failed to confirm" });
+    }
+  },
+);
+
+recurringRouter.post("/recurring/:id/skip", (req: Request, res: Response) => {
+  try {
+    const updated = recurringTransactionService.skip(
+      req.params.id,
+      String(req.body?.date || ""),
+    );
+    res.json(updated);
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Failed to skip" });
+  }
+});
@@ -0,0 +1,86 @@
+import { Router, Request, Response } from "express";
+import { RegistryCreateSchema, RegistryUpdateSchema } from "../types";
+import { registryService } from "../services/registry.service";
+
+export const registryRouter = Router();
+
+registryRouter.get("/registry", (req: Request, res: Response) => {
+  const status = req.query.status
+    ? String(req.query.status).toUpperCase()
+    : undefined;
+  const kind = req.query.kind
+    ? String(req.query.kind).toUpperCase()
+    : undefined;
+  res.json(registryService.listItems({ status, kind }));
+});
+
+registryRouter.post("/registry", (req: Request, res: Response) => {
+  try {
+    const body = RegistryCreateSchema.parse(req.body || {});
+    res.status(201).json(registryService.createItem(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid registry item" });
+  }
+});
+
+// Upcoming renewals, cancel-by deadlines and warranty expiries
+registryRouter.get("/registry/upcoming", (req: Request, res: Response) => {
+  const days = Math.max(1, Number(req.query.days) || 30);
+  const events = registryService.upcoming(days);
+  res.json({
+    days,
+    count: events.length,
+    events: events.map((e) => ({
+      event: e.event,
+      at: e.at,
+      days_until: e.daysUntil,
+      item: e.item,
+    })),
+  });
+});
+
+registryRouter.get("/registry/:id", (req: Request, res: Response) => {
+  const item = registryService.getItem(req.params.id);
+  if (!item) return res.status(404).json({ error: "not found" });
+  res.json(item);
+});
+
+registryRouter.put("/registry/:id", (req: Request, res: Response) => {
+  try {
+    const body = RegistryUpdateSchema.parse(req.body || {});
+    const updated = registryService.updateItem(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(updated);
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid registry item" });
+  }
+});
+
+registryRouter.delete("/registry/:id", (req: Request, res: Response) => {
+  const ok = registryService.deleteItem(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
+
+// Mark an existing expense as a subscription or warranty purchase
+registryRouter.post(
+  "/transactions/:id/registry",
+  (req: Request, res: Response) => {
+    try {
+      const body = RegistryCreateSchema.parse({
+        ...(req.body || {}),
+        transactionId: req.params.id,
+      });
+      res.status(201).json(registryService.createItem(body));
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid registry item" });
+    }
+  },
+);
+
+registryRouter.get(
+  "/transactions/:id/registry",
+  (req: Request, res: Response) => {
+    res.json(registryService.findByTransaction(req.params.id));
+  },
+);
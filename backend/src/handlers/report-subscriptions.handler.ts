@@ -0,0 +1,87 @@
+import { Router, Request, Response } from "express";
+import {
+  ReportSubscriptionCreateSchema,
+  ReportSubscriptionUpdateSchema,
+} from "../types";
+import {
+  REPORT_TYPES,
+  reportSubscriptionService,
+} from "../services/report-subscription.service";
+
+// Saved report configurations delivered on a schedule
+export const reportSubscriptionsRouter = Router();
+
+reportSubscriptionsRouter.get(
+  "/report-subscriptions",
+  (_req: Request, res: Response) => {
+    res.json(reportSubscriptionService.list());
+  },
+);
+
+// Report keys a subscription can use
+reportSubscriptionsRouter.get(
+  "/report-subscriptions/reports",
+  (_req: Request, res: Response) => {
+    res.json(
+      Object.entries(REPORT_TYPES).map(([report, t]) => ({
+        report,
+        path: `/api${t.path}`,
+        uses_period: !!t.range,
+      })),
+    );
+  },
+);
+
+reportSubscriptionsRouter.post(
+  "/report-subscriptions",
+  (req: Request, res: Response) => {
+    try {
+      const body = ReportSubscriptionCreateSchema.parse(req.body || {});
+      res.status(201).json(reportSubscriptionService.create(body));
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid subscription" });
+    }
+  },
+);
+
+reportSubscriptionsRouter.get(
+  "/report-subscriptions/:id",
+  (req: Request, res: Response) => {
+    const sub = reportSubscriptionService.get(req.params.id);
+    if (!sub) return res.status(404).json({ error: "not found" });
+    res.json(sub);
+  },
+);
+
+reportSubscriptionsRouter.put(
+  "/report-subscriptions/:id",
+  (req: Request, res: Response) => {
+    try {
+      const body = ReportSubscriptionUpdateSchema.parse(req.body || {});
+      const updated = reportSubscriptionService.update(req.params.id, body);
+      if (!updated) return res.status(404).json({ error: "not found" });
+      res.json(updated);
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid subscription" });
+    }
+  },
+);
+
+reportSubscriptionsRouter.delete(
+  "/report-subscriptions/:id",
+  (req: Request, res: Response) => {
+    const ok = reportSubscriptionService.delete(req.params.id);
+    if (!ok) return res.status(404).json({ error: "not found" });
+    res.json({ ok: true });
+  },
+);
+
+// Render and deliver now; the schedule is left unchanged
+reportSubscriptionsRouter.post(
+  "/report-subscriptions/:id/run",
+  async (req: Request, res: Response) => {
+    const run = await reportSubscriptionService.run(req.params.id);
+    if (!run) return res.status(404).json({ error: "not found" });
+    res.status(run.status === "OK" ? 200 : 502).json(run);
+  },
+);
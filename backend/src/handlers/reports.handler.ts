@@ -3,27 +3,176 @@ import { vaultRepository } from "../repositories";
 import { transactionRepository } from "../repositories";
 import { borrowingRepository } from "../repositories";
 import { settingsRepository } from "../repositories";
-import { transactionService } from "../services/transaction.service";
+import { loanRepository } from "../repositories";
+import {
+  MemberTotals,
+  transactionService,
+} from "../services/transaction.service";
 import { priceService } from "../services/price.service";
-import { Asset, VaultEntry, PortfolioReportItem } from "../types";
+import { usdToVnd } from "../services/vnd-rate.service";
+import { priceCacheRepository } from "../repositories/price-cache.repository";
+import { vaultService } from "../services/vault.service";
+import { riskService } from "../services/risk.service";
+import { snapshotService } from "../services/snapshot.service";
+import { fixedIncomeService } from "../services/fixed-income.service";
+import { optionService } from "../services/option.service";
+import {
+  allocationService,
+  ConstraintViolation,
+} from "../services/allocation.service";
+import { stakeService, StakeCycleGroup } from "../services/stake.service";
+import { statementService } from "../services/statement.service";
+import { benchmarkService } from "../services/benchmark.service";
+import { cashDragService } from "../services/cash-drag.service";
+import { budgetService, budgetMonth } from "../services/budget.service";
+import { goalMonths, goalService } from "../services/goal.service";
+import {
+  dustThreshold,
+  staleAccountService,
+  staleMonths,
+} from "../services/stale-account.service";
+import { savingsRateService } from "../services/savings-rate.service";
+import { netWorthService } from "../services/networth.service";
+import { jobService } from "../services/job.service";
+import {
+  accountPerformanceService,
+  AccountPerformanceReport,
+} from "../services/account-performance.service";
+import { TaxGainTotals, TaxReport, taxService } from "../services/tax.service";
+import {
+  IncomeTaxItem,
+  incomeTaxService,
+} from "../services/income-tax.service";
+import { walletService } from "../services/wallet.service";
+import { toJobShape } from "./jobs.handler";
+import {
+  RiskMetrics,
+  parseAnnualizationMethod,
+} from "../services/financial.service";
+import { Asset, VaultEntry, PortfolioReportItem, Transaction } from "../types";
+import {
+  displayPrecision,
+  priceOverrides,
+  reportingCurrency,
+} from "../core/middleware";
+import { ValidationError, isAppError } from "../core/errors";
+import { toCsv } from "../utils/csv.util";
+import { fiscalYearOf, fiscalYearRange } from "../utils/fiscal-year.util";
 
 // Price cache keyed by "assetType:assetSymbol:date" to ensure accurate per-day prices
 // This is critical for timeseries calculations where historical prices vary by day
 const priceCache = new Map<string, number>();
+const UNASSIGNED_INSTITUTION = "Unassigned";
 
 export const reportsRouter = Router();
 
-async function usdToVnd(): Promise<number> {
-  try {
-    const vnd = await priceService.getRateUSD({
-      type: "FIAT",
-      symbol: "VND",
-    });
-    // vnd.rateUSD is 1 VND -> USD, so USD->VND = 1 / rateUSD
-    return vnd.rateUSD > 0 ? 1 / vnd.rateUSD : 24000;
-  } catch {
-    return 24000;
+// Round report numbers per currency; clients can pass ?raw=true
+reportsRouter.use(
+  "/reports",
+  displayPrecision(() => settingsRepository.getDisplayPrecision()),
+);
+// Hypothetical prices for stress tests, e.g. ?prices=BTC:50000
+reportsRouter.use("/reports", priceOverrides());
+// Figures in another currency too, e.g. ?currency=EUR adds total_eur
+reportsRouter.use(
+  "/reports",
+  reportingCurrency(
+    () => settingsRepository.getReportingCurrency(),
+    async (currency) => {
+      const rate = await priceService.getRateUSD({
+        type: "FIAT",
+        symbol: currency,
+      });
+      return rate.missing || !(rate.rateUSD > 0) ? undefined : rate.rateUSD;
+    },
+    // Cached daily rates over the range, plus the first day's when the
+    // cache starts later, so the days before follow it
+    async (currency, start, end) => {
+      const asset: Asset = { type: "FIAT", symbol: currency };
+      const from = `${start}T00:00:00.000Z`;
+      const until = new Date(`${end}T23:59:59.999Z`);
+      const rates = priceCacheRepository
+        .getRatesInRange(asset, new Date(from), until)
+        .filter((r) => r.source !== "FIXED" && r.rateUSD > 0);
+      if (!rates.length || rates[0].timestamp > from) {
+        const first = await priceService.getRateUSD(asset, from);
+        if (!first.missing && first.rateUSD > 0) {
+          rates.unshift({ ...first, timestamp: from });
+        }
+      }
+      return rates.map((r) => ({
+        day: r.timestamp.slice(0, 10),
+        rate: r.rateUSD,
+      }));
+    },
+  ),
+);
+
+// CSV for filing: one row per closed lot, or per income receipt
+// ?fiscal_year=2025 as first and last day (YYYY-MM-DD) of that fiscal
+// year under the configured start; undefined when not given
+function fiscalYearDays(
+  value: unknown,
+): { start: string; end: string } | undefined {
+  if (value === undefined || value === "") return undefined;
+  const year = Number(value);
+  if (!Number.isInteger(year) || year < 1970 || year > 9999) {
+    throw new ValidationError("fiscal_year must be a four-digit year");
+  }
+  const r = fiscalYearRange(year, settingsRepository.getFiscalYear());
+  return { start: r.start.slice(0, 10), end: r.end.slice(0, 10) };
+}
+
+function taxCsvRows(
+  r: TaxReport,
+  section: "gains" | "income",
+  vndRate: number,
+): unknown[][] {
+  if (section === "income") {
+    return [
+      ["Date", "Kind", "Source", "Asset", "Amount", "USD", "VND", "Note"],
+      ...r.income.map((i) => [
+        i.at,
+        i.kind,
+        i.source,
+        i.asset.symbol,
+        i.amount,
+        i.usd,
+        i.usd * vndRate,
+        i.note ?? "",
+      ]),
+    ];
   }
+  return [
+    [
+      "Asset",
+      "Vault",
+      "Acquired",
+      "Disposed",
+      "Units",
+      "Proceeds USD",
+      "Cost USD",
+      "Gain USD",
+      "Gain VND",
+      "Holding days",
+      "Term",
+      "Cost basis",
+    ],
+    ...r.disposals.map((d) => [
+      d.asset.symbol,
+      d.vault,
+      d.acquiredAt,
+      d.disposedAt,
+      d.units,
+      d.proceedsUSD,
+      d.costUSD,
+      d.gainUSD,
+      d.gainUSD * vndRate,
+      d.holdingDays,
+      d.term,
+      d.method,
+    ]),
+  ];
 }
 
 function toISODate(d: Date): string {
@@ -919,15 +1068,21 @@ reportsRouter.get("/reports/holdings", async (_req, res) => {
   try {
     const r = await transactionService.generateReport();
     const vndRate = await usdToVnd();
+    const institutions = transactionService.getAccountInstitutions();
+    const wallets = walletService.addressesByAccount();
     const totalUSD = r.totals.holdingsUSD;
     const rows = r.holdings.map((h: PortfolioReportItem) => ({
       asset: h.asset.symbol,
       account: h.account ?? "Portfolio",
+      institution: (h.account && institutions.get(h.account)) || null,
+      wallets: (h.account && wallets.get(h.account)) || [],
       quantity: h.balance,
       value_usd: h.valueUSD,
       value_vnd: h.valueUSD * vndRate,
       percentage: totalUSD > 0 ? (h.valueUSD / totalUSD) * 100 : 0,
-      last_updated: new Date().toISOString(),
+      price_usd: h.rateUSD,
+      price_stale: !!h.priceStale,
+      last_updated: h.priceAsOf ?? new Date().toISOString(),
     }));
     res.json(rows);
   } catch (e: any) {
@@ -950,8 +1105,21 @@ reportsRouter.get("/reports/holdings/summary", async (_req, res) => {
         percentage: number;
       }
     > = {};
+    const by_institution: Record<
+      string,
+      { value_usd: number; value_vnd: number; percentage: number }
+    > = {};
+    const institutions = transactionService.getAccountInstitutions();
     const totalUSD = r.totals.holdingsUSD;
     for (const h of r.holdings) {
+      const inst =
+        (h.account && institutions.get(h.account)) || UNASSIGNED_INSTITUTION;
+      if (!by_institution[inst]) {
+        by_institution[inst] = { value_usd: 0, value_vnd: 0, percentage: 0 };
+      }
+      by_institution[inst].value_usd += h.valueUSD;
+      by_institution[inst].value_vnd += h.valueUSD * vndRate;
+
       const key = h.asset.symbol;
       if (!by_asset[key]) {
         by_asset[key] = {
@@ -970,10 +1138,17 @@ reportsRouter.get("/reports/holdings/summary", async (_req, res) => {
       by_asset[k].percentage =
         totalUSD > 0 ? (by_asset[k].value_usd / totalUSD) * 100 : 0;
     }
+    for (const k of Object.keys(by_institution)) {
+      by_institution[k].percentage =
+        totalUSD > 0 ? (by_institution[k].value_usd / totalUSD) * 100 : 0;
+    }
+    const violations = await allocationService.checkConstraints(r);
     res.json({
       by_asset,
+      by_institution,
       total_value_usd: totalUSD,
       total_value_vnd: totalUSD * vndRate,
+      constraint_violations: violations.map(toViolationShape),
       last_updated: new Date().toISOString(),
     });
   } catch (e: any) {
@@ -983,6 +1158,763 @@ reportsRouter.get("/reports/holdings/summary", async (_req, res) => {
   }
 });
 
+// Net worth (assets minus borrowings and negative balances) per day or
+// month end; ?start=&end= (YYYY-MM-DD) or ?fiscal_year=YYYY, and
+// ?interval=day|month. With ?async=true it runs as a job (202) to
+// follow at /jobs/:id
+reportsRouter.get("/reports/networth", async (req, res) => {
+  try {
+    const fiscal = fiscalYearDays(req.query.fiscal_year);
+    const params = {
+      start: req.query.start ? String(req.query.start) : fiscal?.start,
+      end: req.query.end ? String(req.query.end) : fiscal?.end,
+      interval: req.query.interval ? String(req.query.interval) : undefined,
+    };
+    if (String(req.query.async) === "true") {
+      const job = jobService.start("networth", (ctx) =>
+        netWorthService.seriesJob(params, ctx),
+      );
+      return res.status(202).json(toJobShape(job));
+    }
+    res.json(netWorthService.series(params));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to compute net worth",
+    });
+  }
+});
+
+// Geographic exposure: holdings by country and domestic vs. foreign split
+reportsRouter.get("/reports/exposure/geographic", async (_req, res) => {
+  try {
+    const r = await transactionService.generateReport();
+    const vndRate = await usdToVnd();
+    const home = settingsRepository.getHomeJurisdiction();
+    const totalUSD = r.totals.holdingsUSD;
+
+    const by_country: Record<
+      string,
+      {
+        value_usd: number;
+        value_vnd: number;
+        percentage: number;
+        assets: string[];
+      }
+    > = {};
+    const split = { domestic: 0, foreign: 0, unknown: 0 };
+    const exposures = transactionService.getHoldingJurisdictions(r.holdings);
+    for (const { holding, jurisdiction } of exposures) {
+      const country = jurisdiction || "UNKNOWN";
+      if (!by_country[country]) {
+        by_country[country] = {
+          value_usd: 0,
+          value_vnd: 0,
+          percentage: 0,
+          assets: [],
+        };
+      }
+      const c = by_country[country];
+      c.value_usd += holding.valueUSD;
+      c.value_vnd += holding.valueUSD * vndRate;
+      if (!c.assets.includes(holding.asset.symbol)) {
+        c.assets.push(holding.asset.symbol);
+      }
+
+      if (!jurisdiction) split.unknown += holding.valueUSD;
+      else if (jurisdiction === home) split.domestic += holding.valueUSD;
+      else split.foreign += holding.valueUSD;
+    }
+    for (const k of Object.keys(by_country)) {
+      by_country[k].percentage =
+        totalUSD > 0 ? (by_country[k].value_usd / totalUSD) * 100 : 0;
+    }
+
+    const pct = (v: number) => (totalUSD > 0 ? (v / totalUSD) * 100 : 0);
+    res.json({
+      home_jurisdiction: home,
+      total_value_usd: totalUSD,
+      total_value_vnd: totalUSD * vndRate,
+      domestic_usd: split.domestic,
+      domestic_vnd: split.domestic * vndRate,
+      domestic_percentage: pct(split.domestic),
+      foreign_usd: split.foreign,
+      foreign_vnd: split.foreign * vndRate,
+      foreign_percentage: pct(split.foreign),
+      unknown_usd: split.unknown,
+      unknown_percentage: pct(split.unknown),
+      by_country,
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute geographic exposure",
+    });
+  }
+});
+
+// What changed between two as-of dates. Net worth change is split into net
+// new savings (deposits - withdrawals), investment gains and FX effects.
+// `period` (month|quarter|year) picks `from` relative to `to` when omitted.
+const DIFF_PERIOD_DAYS: Record<string, number> = {
+  month: 30,
+  quarter: 91,
+  year: 365,
+};
+
+reportsRouter.get("/reports/diff", async (req, res) => {
+  try {
+    const isDay = (v: string) => /^\d{4}-\d{2}-\d{2}$/.test(v);
+    const to = req.query.to
+      ? String(req.query.to)
+      : new Date().toISOString().slice(0, 10);
+    let from = req.query.from ? String(req.query.from) : "";
+    const period = String(req.query.period || "").toLowerCase();
+    if (!from && DIFF_PERIOD_DAYS[period] && isDay(to)) {
+      const d = new Date(`${to}T00:00:00.000Z`);
+      d.setUTCDate(d.getUTCDate() - DIFF_PERIOD_DAYS[period]);
+      from = d.toISOString().slice(0, 10);
+    }
+    if (!isDay(from) || !isDay(to)) {
+      return res
+        .status(400)
+        .json({ error: "from and to must be YYYY-MM-DD dates" });
+    }
+    if (from >= to) {
+      return res.status(400).json({ error: "from must be before to" });
+    }
+
+    const d = await snapshotService.diff(from, to);
+    const vndRate = await usdToVnd();
+    res.json({
+      from,
+      to,
+      value_from_usd: d.from.totalUSD,
+      value_to_usd: d.to.totalUSD,
+      net_worth_change_usd: d.netWorthChangeUSD,
+      net_worth_change_vnd: d.netWorthChangeUSD * vndRate,
+      net_contributions_usd: d.netContributionsUSD,
+      market_movement_usd: d.marketMovementUSD,
+      decomposition: {
+        net_savings_usd: d.netContributionsUSD,
+        net_savings_vnd: d.netContributionsUSD * vndRate,
+        investment_gains_usd: d.investmentGainsUSD,
+        investment_gains_vnd: d.investmentGainsUSD * vndRate,
+        fx_effect_usd: d.fxEffectUSD,
+        fx_effect_vnd: d.fxEffectUSD * vndRate,
+      },
+      added: d.positions
+        .filter((p) => p.change === "ADDED")
+        .map((p) => p.asset.symbol),
+      removed: d.positions
+        .filter((p) => p.change === "REMOVED")
+        .map((p) => p.asset.symbol),
+      positions: d.positions.map((p) => ({
+        asset: p.asset,
+        change: p.change,
+        quantity_from: p.quantityFrom,
+        quantity_to: p.quantityTo,
+        quantity_change: p.quantityChange,
+        value_from_usd: p.valueFromUSD,
+        value_to_usd: p.valueToUSD,
+        value_change_usd: p.valueChangeUSD,
+        net_flow_usd: p.netFlowUSD,
+        market_change_usd: p.marketChangeUSD,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute snapshot diff",
+    });
+  }
+});
+
+// Volatility, max drawdown and Sharpe ratio over a trailing window of days;
+// risk_free_rate is an annual percentage
+reportsRouter.get("/reports/risk", async (req, res) => {
+  try {
+    const days = Math.min(3650, Math.max(2, Number(req.query.days) || 90));
+    const riskFree = Number(req.query.risk_free_rate) || 0;
+    const r = riskService.getRiskReport(days, riskFree / 100);
+    const metrics = (m: RiskMetrics) => ({
+      observations: m.observations,
+      annualized_return_percent: m.annualizedReturnPercent,
+      volatility_percent: m.volatilityPercent,
+      max_drawdown_percent: m.maxDrawdownPercent,
+      sharpe_ratio: m.sharpeRatio,
+    });
+
+    const by_asset: Record<string, any> = {};
+    for (const a of r.byAsset) {
+      by_asset[a.asset.symbol] = {
+        ...metrics(a),
+        current_value_usd: a.currentValueUSD,
+      };
+    }
+
+    res.json({
+      start: r.start,
+      end: r.end,
+      days: r.days,
+      risk_free_rate_percent: riskFree,
+      portfolio: metrics(r.portfolio),
+      by_asset,
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute risk metrics",
+    });
+  }
+});
+
+// Term deposits, bonds and loans reaching maturity within ?days (default 90)
+reportsRouter.get("/reports/maturities", async (req, res) => {
+  try {
+    const days = Math.min(3650, Math.max(1, Number(req.query.days) || 90));
+    const now = new Date();
+    const until = now.getTime() + days * 24 * 60 * 60 * 1000;
+    const vndRate = await usdToVnd();
+
+    const items: any[] = [];
+    for (const m of fixedIncomeService.upcomingMaturities(days, now)) {
+      const inst = m.instrument;
+      const rate = await priceService.getRateUSD(inst.asset);
+      const amount = m.principal + m.finalCoupon;
+      items.push({
+        source: "fixed_income",
+        id: inst.id,
+        kind: inst.kind,
+        name: inst.name,
+        counterparty: inst.issuer,
+        account: inst.account,
+        asset: inst.asset,
+        maturity_at: inst.maturityAt,
+        days_until: m.daysUntil,
+        principal: m.principal,
+        final_coupon: m.finalCoupon,
+        amount,
+        value_usd: amount * rate.rateUSD,
+        value_vnd: amount * rate.rateUSD * vndRate,
+      });
+    }
+
+    for (const loan of loanRepository.findAll()) {
+      if (loan.status !== "ACTIVE" || !loan.maturityAt) continue;
+      const t = new Date(loan.maturityAt).getTime();
+      if (Number.isNaN(t) || t > until) continue;
+      const rate = await priceService.getRateUSD(loan.asset);
+      items.push({
+        source: "loan",
+        id: loan.id,
+        kind: "LOAN",
+        name: loan.note || `Loan to ${loan.counterparty}`,
+        counterparty: loan.counterparty,
+        account: loan.account,
+        asset: loan.asset,
+        maturity_at: loan.maturityAt,
+        days_until: Math.max(0, Math.ceil((t - now.getTime()) / 86400000)),
+        principal: loan.principal,
+        final_coupon: 0,
+        amount: loan.principal,
+        value_usd: loan.principal * rate.rateUSD,
+        value_vnd: loan.principal * rate.rateUSD * vndRate,
+      });
+    }
+
+    items.sort((a, b) => a.maturity_at.localeCompare(b.maturity_at));
+    const total_usd = items.reduce((s, i) => s + i.value_usd, 0);
+    res.json({
+      days,
+      items,
+      total_usd,
+      total_vnd: total_usd * vndRate,
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute maturities",
+    });
+  }
+});
+
+// On-chain network (gas) fees by chain and month, in native units and USD
+reportsRouter.get("/reports/gas-fees", async (req, res) => {
+  try {
+    const start = req.query.start_date
+      ? new Date(String(req.query.start_date)).toISOString()
+      : undefined;
+    const end = req.query.end_date
+      ? new Date(String(req.query.end_date)).toISOString()
+      : undefined;
+    const chain = req.query.chain ? String(req.query.chain) : undefined;
+    const r = transactionService.getNetworkFees({ start, end, chain });
+    const vndRate = await usdToVnd();
+
+    const totals = (groups: Record<string, { count: number; usd: number }>) =>
+      Object.fromEntries(
+        Object.entries(groups).map(([k, g]) => [
+          k,
+          { count: g.count, total_usd: g.usd, total_vnd: g.usd * vndRate },
+        ]),
+      );
+    const by_chain = totals(r.byChain);
+
+    res.json({
+      start_date: start,
+      end_date: end,
+      chain,
+      months: r.buckets.map((b) => ({
+        chain: b.chain,
+        month: b.month,
+        count: b.count,
+        native: b.native,
+        total_usd: b.usd,
+        total_vnd: b.usd * vndRate,
+      })),
+      by_chain,
+      total_usd: r.totalUSD,
+      total_vnd: r.totalUSD * vndRate,
+      trading_fees_usd: r.tradingFeesUSD,
+      trading_fees_vnd: r.tradingFeesUSD * vndRate,
+      fx_fees: {
+        by_month: totals(r.fxFees.byMonth),
+        by_card: totals(r.fxFees.byCard),
+        total_usd: r.fxFees.totalUSD,
+        total_vnd: r.fxFees.totalUSD * vndRate,
+      },
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute gas fees",
+    });
+  }
+});
+
+// Internal transfer pairs whose received amount doesn't match the amount
+// sent less the recorded fee, or that are missing a leg
+reportsRouter.get("/reports/transfers/consistency", async (req, res) => {
+  try {
+    const tolerancePercent = Number(req.query.tolerance_percent) || 0;
+    const fxTolerancePercent =
+      req.query.fx_tolerance_percent !== undefined
+        ? Number(req.query.fx_tolerance_percent) || 0
+        : 2;
+    const r = transactionService.checkTransferPairs({
+      tolerancePercent,
+      fxTolerancePercent,
+    });
+    res.json({
+      checked: r.checked,
+      tolerance_percent: tolerancePercent,
+      fx_tolerance_percent: fxTolerancePercent,
+      issues: r.issues.map((i) => ({
+        transfer_id: i.transferId,
+        kind: i.kind,
+        date: (i.out || i.in)!.createdAt,
+        from_account: i.out?.account,
+        to_account: i.in?.account,
+        asset_out: i.out?.asset.symbol,
+        amount_out: i.out?.amount,
+        asset_in: i.in?.asset.symbol,
+        amount_in: i.in?.amount,
+        fee: i.fee,
+        discrepancy: i.discrepancy,
+        discrepancy_usd: i.discrepancyUSD,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to check transfers",
+    });
+  }
+});
+
+function toViolationShape(v: ConstraintViolation) {
+  return {
+    key: v.key,
+    scope: v.constraint.scope,
+    subject: v.subject,
+    kind: v.kind,
+    value_usd: v.valueUSD,
+    current_percent: v.currentPercent,
+    limit_percent: v.limitPercent,
+    gap_usd: v.gapUSD,
+    note: v.constraint.note ?? null,
+  };
+}
+
+// Current holdings against the allocation constraints; only violations
+reportsRouter.get("/reports/allocation/constraints", async (_req, res) => {
+  try {
+    const violations = await allocationService.checkConstraints();
+    res.json({
+      constraints: allocationService.getConstraints().length,
+      violations: violations.map(toViolationShape),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to check allocation constraints",
+    });
+  }
+});
+
+// Holdings by asset class against the glidepath target for a date
+reportsRouter.get("/reports/allocation/drift", async (req, res) => {
+  try {
+    const at = req.query.date ? new Date(String(req.query.date)) : new Date();
+    if (isNaN(at.getTime())) {
+      return res.status(400).json({ error: "Invalid date" });
+    }
+    const r = await allocationService.getDrift(at);
+    const vndRate = await usdToVnd();
+    res.json({
+      date: r.at.slice(0, 10),
+      total_usd: r.totalUSD,
+      total_vnd: r.totalUSD * vndRate,
+      max_abs_drift_percent: r.maxAbsDriftPercent,
+      classes: r.rows.map((row) => ({
+        asset_class: row.assetClass,
+        value_usd: row.valueUSD,
+        value_vnd: row.valueUSD * vndRate,
+        current_percent: row.currentPercent,
+        target_percent: row.targetPercent ?? null,
+        drift_percent: row.driftPercent ?? null,
+        drift_usd: row.driftUSD ?? null,
+        glidepath_id: row.glidepathId ?? null,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute allocation drift",
+    });
+  }
+});
+
+// Realized PnL of completed stake/unstake cycles (vault deposit/withdraw)
+reportsRouter.get("/reports/stake-cycles", async (req, res) => {
+  try {
+    const method = parseAnnualizationMethod(req.query.annualization);
+    const r = stakeService.getCycles({
+      vault: req.query.vault ? String(req.query.vault) : undefined,
+      asset: req.query.asset ? String(req.query.asset) : undefined,
+      start: req.query.start ? String(req.query.start) : undefined,
+      end: req.query.end ? String(req.query.end) : undefined,
+      annualization: method,
+    });
+    const vndRate = await usdToVnd();
+    const group = (g: StakeCycleGroup) => ({
+      cycles: g.cycles,
+      amount: g.amount,
+      cost_usd: g.costUSD,
+      proceeds_usd: g.proceedsUSD,
+      realized_usd: g.realizedUSD,
+      realized_vnd: g.realizedUSD * vndRate,
+      roi_percent: g.roiPercent,
+      holding_days: g.holdingDays,
+      annualized_percent: g.annualizedPercent,
+    });
+    const byKey = (groups: StakeCycleGroup[]) =>
+      Object.fromEntries(groups.map((g) => [g.key, group(g)]));
+
+    res.json({
+      annualization: method,
+      totals: group(r.totals),
+      by_asset: byKey(r.byAsset),
+      by_vault: byKey(r.byVault),
+      cycles: r.cycles.map((c) => ({
+        vault: c.vault,
+        asset: c.asset.symbol,
+        cost_basis: c.method,
+        unstaked_at: c.unstakedAt,
+        amount: c.amount,
+        unmatched_amount: c.unmatchedAmount,
+        cost_usd: c.costUSD,
+        proceeds_usd: c.proceedsUSD,
+        realized_usd: c.realizedUSD,
+        realized_vnd: c.realizedUSD * vndRate,
+        roi_percent: c.roiPercent,
+        holding_days: c.holdingDays,
+        annualized_percent: c.annualizedPercent,
+        stakes: c.stakes.map((l) => ({
+          staked_at: l.stakedAt,
+          amount: l.amount,
+          cost_usd: l.costUSD,
+        })),
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute stake cycles",
+    });
+  }
+});
+
+// Deposits, withdrawals and money-weighted return per account
+reportsRouter.get("/reports/accounts/performance", async (req, res) => {
+  try {
+    const r = await accountPerformanceService.report({
+      account: req.query.account ? String(req.query.account) : undefined,
+    });
+    const vndRate = await usdToVnd();
+    const shape = (p: AccountPerformanceReport["totals"]) => ({
+      deposits_usd: p.depositsUSD,
+      deposits_vnd: p.depositsUSD * vndRate,
+      withdrawals_usd: p.withdrawalsUSD,
+      withdrawals_vnd: p.withdrawalsUSD * vndRate,
+      net_contributed_usd: p.netContributedUSD,
+      value_usd: p.valueUSD,
+      value_vnd: p.valueUSD * vndRate,
+      value_change_usd: p.valueChangeUSD,
+      value_change_vnd: p.valueChangeUSD * vndRate,
+      roi_percent: p.roiPercent,
+      money_weighted_percent: p.moneyWeightedPercent,
+    });
+
+    res.json({
+      accounts: r.accounts.map((a) => ({
+        account: a.account,
+        vaults: a.vaults,
+        first_flow_at: a.firstFlowAt ?? null,
+        ...shape(a),
+      })),
+      totals: shape(r.totals),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute account performance",
+    });
+  }
+});
+
+// Realized gains by holding period, investment income and fees for a year
+reportsRouter.get("/reports/tax", async (req, res) => {
+  try {
+    const year = req.query.year
+      ? Number(req.query.year)
+      : fiscalYearOf(new Date(), settingsRepository.getFiscalYear());
+    if (!Number.isInteger(year) || year < 1970 || year > 9999) {
+      return res.status(400).json({ error: "year must be a four-digit year" });
+    }
+    let longTermDays: number | undefined;
+    if (req.query.long_term_days !== undefined) {
+      longTermDays = Number(req.query.long_term_days);
+      if (!Number.isInteger(longTermDays) || longTermDays <= 0) {
+        return res
+          .status(400)
+          .json({ error: "long_term_days must be a positive integer" });
+      }
+    }
+    const r = taxService.report(year, longTermDays);
+    const vndRate = await usdToVnd();
+
+    if (String(req.query.format || "").toLowerCase() === "csv") {
+      const section = String(req.query.section || "gains").toLowerCase();
+      if (section !== "gains" && section !== "income") {
+        return res
+          .status(400)
+          .json({ error: "section must be gains or income" });
+      }
+      res.setHeader("Content-Type", "text/csv; charset=utf-8");
+      res.setHeader(
+        "Content-Disposition",
+        `attachment; filename="tax-${year}-${section}.csv"`,
+      );
+      return res.send(toCsv(taxCsvRows(r, section, vndRate)));
+    }
+
+    const gains = (g: TaxGainTotals) => ({
+      count: g.count,
+      proceeds_usd: g.proceedsUSD,
+      cost_usd: g.costUSD,
+      gain_usd: g.gainUSD,
+      gain_vnd: g.gainUSD * vndRate,
+    });
+    res.json({
+      year: r.year,
+      start: r.start,
+      end: r.end,
+      long_term_days: r.longTermDays,
+      gains: {
+        short_term: gains(r.gains.short),
+        long_term: gains(r.gains.long),
+        total: gains(r.gains.total),
+      },
+      income: {
+        staking_usd: r.incomeByKind.STAKING,
+        dividend_usd: r.incomeByKind.DIVIDEND,
+        interest_usd: r.incomeByKind.INTEREST,
+        total_usd: r.incomeUSD,
+        total_vnd: r.incomeUSD * vndRate,
+        items: r.income.map((i) => ({
+          kind: i.kind,
+          at: i.at,
+          source: i.source,
+          asset: i.asset.symbol,
+          amount: i.amount,
+          usd: i.usd,
+          note: i.note ?? null,
+        })),
+      },
+      fees: {
+        network_usd: r.fees.networkUSD,
+        trading_usd: r.fees.tradingUSD,
+        fx_usd: r.fees.fxUSD,
+        total_usd: r.fees.totalUSD,
+        total_vnd: r.fees.totalUSD * vndRate,
+      },
+      disposals: r.disposals.map((d) => ({
+        vault: d.vault,
+        asset: d.asset.symbol,
+        cost_basis: d.method,
+        acquired_at: d.acquiredAt,
+        disposed_at: d.disposedAt,
+        units: d.units,
+        proceeds_usd: d.proceedsUSD,
+        cost_usd: d.costUSD,
+        gain_usd: d.gainUSD,
+        holding_days: d.holdingDays,
+        term: d.term,
+      })),
+      unmatched: r.unmatched.map((u) => ({
+        vault: u.vault,
+        asset: u.asset.symbol,
+        disposed_at: u.disposedAt,
+        units: u.units,
+        proceeds_usd: u.proceedsUSD,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute tax report",
+    });
+  }
+});
+
+// Expected personal income tax from recorded income vs. tax paid
+reportsRouter.get("/reports/income-tax", async (req, res) => {
+  try {
+    const year = req.query.year
+      ? Number(req.query.year)
+      : fiscalYearOf(new Date(), settingsRepository.getFiscalYear());
+    if (!Number.isInteger(year) || year < 1970 || year > 9999) {
+      return res.status(400).json({ error: "year must be a four-digit year" });
+    }
+    let dependents: number | undefined;
+    if (req.query.dependents !== undefined) {
+      dependents = Number(req.query.dependents);
+      if (!Number.isInteger(dependents) || dependents < 0) {
+        return res
+          .status(400)
+          .json({ error: "dependents must be a non-negative integer" });
+      }
+    }
+    const vndRate = await usdToVnd();
+    const r = incomeTaxService.estimate(year, vndRate, dependents);
+
+    const item = (i: IncomeTaxItem) => ({
+      id: i.id,
+      at: i.at,
+      account: i.account ?? null,
+      category: i.category ?? null,
+      amount_vnd: i.amountVND,
+      amount_usd: i.amountVND / vndRate,
+      note: i.note ?? null,
+    });
+    res.json({
+      year: r.year,
+      start: r.start,
+      end: r.end,
+      dependents: r.dependents,
+      income_vnd: r.incomeVND,
+      income_usd: r.incomeVND / vndRate,
+      deductions: {
+        personal_vnd: r.deductions.personalVND,
+        dependents_vnd: r.deductions.dependentsVND,
+        total_vnd: r.deductions.totalVND,
+      },
+      taxable_vnd: r.taxableVND,
+      brackets: r.brackets.map((b) => ({
+        from_vnd: b.from,
+        up_to_vnd: b.upTo,
+        rate_percent: b.ratePercent,
+        taxable_vnd: b.taxable,
+        tax_vnd: b.tax,
+      })),
+      expected_tax_vnd: r.expectedTaxVND,
+      expected_tax_usd: r.expectedTaxVND / vndRate,
+      paid_tax_vnd: r.paidTaxVND,
+      paid_tax_usd: r.paidTaxVND / vndRate,
+      difference_vnd: r.differenceVND,
+      difference_usd: r.differenceVND / vndRate,
+      status: r.status,
+      effective_rate_percent: r.effectiveRatePercent,
+      income: r.income.map(item),
+      payments: r.payments.map(item),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to estimate income tax",
+    });
+  }
+});
+
+// Opportunity cost of idle cash against VND term-deposit rates
+reportsRouter.get("/reports/cash-drag", async (req, res) => {
+  try {
+    const isDay = (v: string) => /^\d{4}-\d{2}-\d{2}$/.test(v);
+    const end = req.query.end
+      ? String(req.query.end)
+      : new Date().toISOString().slice(0, 10);
+    let start = req.query.start ? String(req.query.start) : "";
+    if (!start && isDay(end)) {
+      const d = new Date(`${end}T00:00:00.000Z`);
+      d.setUTCFullYear(d.getUTCFullYear() - 1);
+      d.setUTCDate(d.getUTCDate() + 1);
+      start = d.toISOString().slice(0, 10);
+    }
+    if (!isDay(start) || !isDay(end)) {
+      return res
+        .status(400)
+        .json({ error: "start and end must be YYYY-MM-DD dates" });
+    }
+    if (start > end) {
+      return res.status(400).json({ error: "start must be before end" });
+    }
+    const term = req.query.term_months
+      ? Number(req.query.term_months)
+      : undefined;
+    if (term !== undefined && !(Number.isInteger(term) && term > 0)) {
+      return res
+        .status(400)
+        .json({ error: "term_months must be a positive integer" });
+    }
+
+    const r = await cashDragService.report(start, end, term);
+    const vndRate = await usdToVnd();
+    res.json({
+      start: r.start,
+      end: r.end,
+      term_months: r.termMonths,
+      avg_cash_usd: r.avgCashUSD,
+      avg_cash_vnd: r.avgCashUSD * vndRate,
+      opportunity_cost_usd: r.opportunityCostUSD,
+      opportunity_cost_vnd: r.opportunityCostUSD * vndRate,
+      uncovered_days: r.uncoveredDays,
+      months: r.months.map((m) => ({
+        month: m.month,
+        days: m.days,
+        avg_cash_usd: m.avgCashUSD,
+        avg_cash_vnd: m.avgCashUSD * vndRate,
+        benchmark_rate_percent: m.benchmarkRatePercent ?? null,
+        opportunity_cost_usd: m.opportunityCostUSD,
+        opportunity_cost_vnd: m.opportunityCostUSD * vndRate,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute cash drag",
+    });
+  }
+});
+
 // Minimal stubs for other report endpoints expected by frontend
 reportsRouter.get("/reports/cashflow", async (req, res) => {
   try {
@@ -1190,6 +2122,25 @@ reportsRouter.get("/reports/cashflow", async (req, res) => {
   }
 });
 
+// Savings rate per month and where income went; ?start=&end= (YYYY-MM)
+reportsRouter.get("/reports/savings-rate", async (req, res) => {
+  try {
+    const report = savingsRateService.report(
+      {
+        start: req.query.start ? String(req.query.start) : undefined,
+        end: req.query.end ? String(req.query.end) : undefined,
+      },
+      await usdToVnd(),
+    );
+    res.json(report);
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to compute savings rate",
+    });
+  }
+});
+
 const predictedOutflowsHandler = async (req: any, res: any) => {
   try {
     const startInput = req.query.start_date
@@ -1442,29 +2393,54 @@ reportsRouter.get("/reports/predicted-outflows", predictedOutflowsHandler);
 // Backward-compatible alias (now returns outflows-only).
 reportsRouter.get("/reports/predicted-cashflow", predictedOutflowsHandler);
 
+// Spending basis shared by the spending reports: account and exclusion
+// rules from the query, matched reimbursements netted out (fully refunded
+// expenses drop out). `selected` is limited to start/end.
+function selectSpending(query: any) {
+  const start = query.start ? new Date(String(query.start)) : undefined;
+  const end = query.end ? new Date(String(query.end)) : undefined;
+  const account = query.account
+    ? String(query.account)
+    : settingsRepository.getDefaultSpendingVaultName();
+  const applyExclusions =
+    String(query.exclusions ?? "true").toLowerCase() !== "false";
+  const exclusions = applyExclusions
+    ? settingsRepository.getSpendingExclusionRules()
+    : [];
+  const reimbursed = transactionService.getReimbursedUSDByExpense(
+    transactionRepository.findAll(),
+  );
+  const txs = transactionRepository
+    .findSpending({ account, exclusions })
+    .map((t) => {
+      const refunded = reimbursed.get(t.id) || 0;
+      if (!refunded) return t;
+      return {
+        ...t,
+        usdAmount: Math.max(0, (t.usdAmount || 0) - refunded),
+      } as typeof t;
+    })
+    .filter((t) => !reimbursed.has(t.id) || t.usdAmount > 1e-9);
+
+  const inRange = (d: string) => {
+    const dt = new Date(d);
+    if (Number.isNaN(dt.getTime())) return false;
+    if (start && dt < start) return false;
+    if (end && dt > end) return false;
+    return true;
+  };
+  return { account, txs, selected: txs.filter((t) => inRange(t.createdAt)) };
+}
+
+// Tag a spending transaction is grouped under (by_tag, budgets)
+function spendingTag(t: Transaction): string {
+  return t.category || (t as any).tag || "uncategorized";
+}
+
 reportsRouter.get("/reports/spending", async (req, res) => {
   try {
-    const start = req.query.start
-      ? new Date(String(req.query.start))
-      : undefined;
-    const end = req.query.end ? new Date(String(req.query.end)) : undefined;
-    const account = req.query.account
-      ? String(req.query.account)
-      : settingsRepository.getDefaultSpendingVaultName();
     const allTxs = transactionRepository.findAll();
-    const txs = allTxs.filter(
-      (t) => t.type === "EXPENSE" && (t.account || account) === account,
-    );
-
-    const inRange = (d: string) => {
-      const dt = new Date(d);
-      if (Number.isNaN(dt.getTime())) return false;
-      if (start && dt < start) return false;
-      if (end && dt > end) return false;
-      return true;
-    };
-
-    const selected = txs.filter((t) => inRange(t.createdAt));
+    const { account, txs, selected } = selectSpending(req.query);
     const total_usd = selected.reduce((s, t) => s + (t.usdAmount || 0), 0);
     const rateVND = await usdToVnd();
     const total_vnd = total_usd * rateVND;
@@ -1489,7 +2465,7 @@ reportsRouter.get("/reports/spending", async (req, res) => {
       }
     > = {};
     for (const t of selected) {
-      const tag = t.category || (t as any).tag || "uncategorized";
+      const tag = spendingTag(t);
       if (!by_tag[tag])
         by_tag[tag] = {
           total_usd: 0,
@@ -1639,7 +2615,10 @@ reportsRouter.get("/reports/spending", async (req, res) => {
       (t) => t.type === "INCOME" && (t.account || account) === account,
     );
     const totalIncome = incomeTxs.reduce((s, t) => s + (t.usdAmount || 0), 0);
-    const totalExpenses = txs.reduce((s, t) => s + (t.usdAmount || 0), 0);
+    // Balance reflects all expenses, including ones excluded from spending
+    const totalExpenses = allTxs
+      .filter((t) => t.type === "EXPENSE" && (t.account || account) === account)
+      .reduce((s, t) => s + (t.usdAmount || 0), 0);
     const available_balance_usd = totalIncome - totalExpenses;
     const available_balance_vnd = available_balance_usd * rateVND;
 
@@ -1661,6 +2640,7 @@ reportsRouter.get("/reports/spending", async (req, res) => {
       avg_daily_vnd,
       available_balance_usd,
       available_balance_vnd,
+      exclusion_rules: exclusions,
     });
   } catch (e: any) {
     res.status(500).json({
@@ -1669,15 +2649,289 @@ reportsRouter.get("/reports/spending", async (req, res) => {
   }
 });
 
+// Actual vs. budget per tag for ?month=YYYY-MM (default: this month), on
+// the same spending basis and tags as /reports/spending
+reportsRouter.get("/reports/budgets", async (req, res) => {
+  try {
+    const month = budgetMonth(
+      req.query.month ? String(req.query.month) : undefined,
+    );
+    const { selected } = selectSpending({
+      ...req.query,
+      start: month.start,
+      end: month.end,
+    });
+    const spent = new Map<string, number>();
+    for (const t of selected) {
+      const tag = spendingTag(t);
+      spent.set(tag, (spent.get(tag) || 0) + (t.usdAmount || 0));
+    }
+    res.json(budgetService.report(month, spent, await usdToVnd()));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to compute budgets",
+    });
+  }
+});
+
+// Funded percentage and projected completion of every active savings
+// goal, averaging contributions over the trailing ?months= (default 6)
+reportsRouter.get("/reports/goals", async (req, res) => {
+  try {
+    const months = goalMonths(
+      req.query.months ? String(req.query.months) : undefined,
+    );
+    res.json(await goalService.report(await usdToVnd(), months));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to compute goals",
+    });
+  }
+});
+
+// Accounts with a balance but no transactions for ?months= (default 6),
+// valued today, each with a suggested archival or consolidation
+reportsRouter.get("/reports/stale-accounts", async (req, res) => {
+  try {
+    const months = staleMonths(
+      req.query.months ? String(req.query.months) : undefined,
+    );
+    const dust = dustThreshold(
+      req.query.dust_usd !== undefined ? String(req.query.dust_usd) : undefined,
+    );
+    const r = await staleAccountService.report(months, dust);
+    const vndRate = await usdToVnd();
+    res.json({
+      months: r.months,
+      dust_usd: r.dustUSD,
+      cutoff: r.cutoff,
+      total_value_usd: r.totalValueUSD,
+      total_value_vnd: r.totalValueUSD * vndRate,
+      accounts: r.accounts.map((a) => ({
+        account: a.account,
+        account_id: a.accountId ?? null,
+        institution: a.institution ?? null,
+        last_transaction_at: a.lastTransactionAt,
+        idle_days: a.idleDays,
+        value_usd: a.valueUSD,
+        value_vnd: a.valueUSD * vndRate,
+        holdings: a.holdings.map((h) => ({
+          asset: h.asset,
+          quantity: h.quantity,
+          value_usd: h.valueUSD ?? null,
+        })),
+        unpriced: a.unpriced,
+        action: a.action,
+        consolidate_into: a.consolidateInto ?? null,
+        reason: a.reason,
+      })),
+    });
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(500).json({
+      error: e?.message || "Failed to find stale accounts",
+    });
+  }
+});
+
+// Spending by place, or by ~1 km grid cell for coordinate-only expenses
+reportsRouter.get("/reports/spending/locations", async (req, res) => {
+  try {
+    const { selected } = selectSpending(req.query);
+    const r = transactionService.getSpendingByLocation(selected);
+    const vndRate = await usdToVnd();
+    res.json({
+      total_usd: r.totalUSD,
+      total_vnd: r.totalUSD * vndRate,
+      unlocated: {
+        count: r.unlocated.count,
+        total_usd: r.unlocated.usd,
+        total_vnd: r.unlocated.usd * vndRate,
+      },
+      locations: r.locations.map((l) => ({
+        key: l.key,
+        place: l.place ?? null,
+        latitude: l.latitude ?? null,
+        longitude: l.longitude ?? null,
+        count: l.count,
+        total_usd: l.usd,
+        total_vnd: l.usd * vndRate,
+        percentage: r.totalUSD > 0 ? (l.usd / r.totalUSD) * 100 : 0,
+      })),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute spending by location",
+    });
+  }
+});
+
+// Spending (same basis as /reports/spending) and income per household
+// member over ?start&end
+reportsRouter.get("/reports/members", async (req, res) => {
+  try {
+    const { selected } = selectSpending(req.query);
+    const start = req.query.start ? String(req.query.start) : undefined;
+    const end = req.query.end ? String(req.query.end) : undefined;
+    const income = transactionRepository.findAll().filter((t) => {
+      if (t.type !== "INCOME") return false;
+      const at = new Date(t.createdAt);
+      if (start && at < new Date(start)) return false;
+      if (end && at > new Date(end)) return false;
+      return true;
+    });
+    const r = transactionService.getMemberReport(selected, income);
+    const vndRate = await usdToVnd();
+    const shape = (m: MemberTotals) => ({
+      member: m.member ?? null,
+      spending: {
+        count: m.spending.count,
+        total_usd: m.spending.usd,
+        total_vnd: m.spending.usd * vndRate,
+        percentage:
+          r.totalSpendingUSD > 0
+            ? (m.spending.usd / r.totalSpendingUSD) * 100
+            : 0,
+      },
+      contribution: {
+        count: m.contribution.count,
+        total_usd: m.contribution.usd,
+        total_vnd: m.contribution.usd * vndRate,
+        percentage:
+          r.totalContributionUSD > 0
+            ? (m.contribution.usd / r.totalContributionUSD) * 100
+            : 0,
+      },
+      net_usd: m.contribution.usd - m.spending.usd,
+    });
+    res.json({
+      total_spending_usd: r.totalSpendingUSD,
+      total_contribution_usd: r.totalContributionUSD,
+      members: r.members.map(shape),
+      unassigned: shape(r.unassigned),
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute member report",
+    });
+  }
+});
+
+// Reimbursable expenses and what is still owed back
+reportsRouter.get("/reports/reimbursements", async (req, res) => {
+  try {
+    const status = String(req.query.status || "outstanding").toLowerCase();
+    const rate = await usdToVnd();
+    const items = transactionService
+      .getReimbursements()
+      .filter((r) => (status === "all" ? true : r.outstandingUSD > 1e-9))
+      .map((r) => ({
+        expense_id: r.expense.id,
+        date: r.expense.createdAt,
+        description:
+          r.expense.note || r.expense.counterparty || "No description",
+        counterparty: r.expense.counterparty,
+        amount_usd: r.expense.usdAmount || 0,
+        reimbursed_usd: r.reimbursedUSD,
+        outstanding_usd: r.outstandingUSD,
+        outstanding_vnd: r.outstandingUSD * rate,
+        status: r.outstandingUSD > 1e-9 ? "outstanding" : "reimbursed",
+        refund_ids: r.refunds.map((t) => t.id),
+      }))
+      .sort((a, b) => String(a.date).localeCompare(String(b.date)));
+
+    const outstanding_usd = items.reduce((s, i) => s + i.outstanding_usd, 0);
+    res.json({
+      outstanding_usd,
+      outstanding_vnd: outstanding_usd * rate,
+      count: items.length,
+      items,
+    });
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to compute reimbursements",
+    });
+  }
+});
+
 reportsRouter.get("/reports/pnl", async (_req, res) => {
-  res.json({
-    realized_pnl_usd: 0,
-    realized_pnl_vnd: 0,
-    total_pnl_usd: 0,
-    total_pnl_vnd: 0,
-    roi_percent: 0,
-    by_asset: {},
-  });
+  try {
+    const rate = await usdToVnd();
+    const byStrategy: Record<
+      string,
+      {
+        vaults: string[];
+        deposited_usd: number;
+        withdrawn_usd: number;
+        aum_usd: number;
+        pnl_usd: number;
+        pnl_vnd: number;
+        roi_percent: number;
+      }
+    > = {};
+
+    let depositedTotal = 0;
+    let realizedPnl = 0;
+    let totalPnl = 0;
+
+    for (const v of vaultRepository.findAll()) {
+      const stats = await vaultService.vaultStats(v.name);
+      const pnl =
+        stats.aumUSD + stats.totalWithdrawnUSD - stats.totalDepositedUSD;
+      depositedTotal += stats.totalDepositedUSD;
+      totalPnl += pnl;
+      if (v.status === "CLOSED") realizedPnl += pnl;
+
+      const strategies = v.tags && v.tags.length ? v.tags : ["untagged"];
+      for (const strategy of strategies) {
+        const agg = (byStrategy[strategy] ||= {
+          vaults: [],
+          deposited_usd: 0,
+          withdrawn_usd: 0,
+          aum_usd: 0,
+          pnl_usd: 0,
+          pnl_vnd: 0,
+          roi_percent: 0,
+        });
+        agg.vaults.push(v.name);
+        agg.deposited_usd += stats.totalDepositedUSD;
+        agg.withdrawn_usd += stats.totalWithdrawnUSD;
+        agg.aum_usd += stats.aumUSD;
+        agg.pnl_usd += pnl;
+      }
+    }
+
+    for (const agg of Object.values(byStrategy)) {
+      agg.pnl_vnd = agg.pnl_usd * rate;
+      agg.roi_percent =
+        agg.deposited_usd > 0 ? (agg.pnl_usd / agg.deposited_usd) * 100 : 0;
+    }
+
+    // Settled options are realized; open ones stay out of PnL until then
+    const options = optionService.realizedPnL();
+    realizedPnl += options.realizedUSD;
+    totalPnl += options.realizedUSD;
+
+    res.json({
+      realized_pnl_usd: realizedPnl,
+      realized_pnl_vnd: realizedPnl * rate,
+      total_pnl_usd: totalPnl,
+      total_pnl_vnd: totalPnl * rate,
+      roi_percent: depositedTotal > 0 ? (totalPnl / depositedTotal) * 100 : 0,
+      by_asset: {},
+      by_strategy: byStrategy,
+      options: {
+        realized_pnl_usd: options.realizedUSD,
+        realized_pnl_vnd: options.realizedUSD * rate,
+        open_positions: options.openPositions,
+        by_underlying: options.byUnderlying,
+      },
+    });
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to build PnL" });
+  }
 });
 
 // --- New: Per-vault header metrics (rolling AUM, ROI, APR) ---
@@ -1931,19 +3185,57 @@ reportsRouter.get("/reports/vaults/:name/series", async (req, res) => {
   }
 });
 
+// Printable PDF statement of a vault position
+reportsRouter.get("/reports/vaults/:name/statement", async (req, res) => {
+  try {
+    const name = String(req.params.name);
+    const v = vaultRepository.findByName(name);
+    if (!v) return res.status(404).json({ error: "vault not found" });
+    const start = req.query.start ? String(req.query.start) : undefined;
+    const investor = req.query.investor
+      ? String(req.query.investor)
+      : undefined;
+
+    const series = await buildVaultDailySeries(name, start);
+    const statement = await statementService.vaultStatement(
+      name,
+      series.map((p) => ({
+        date: p.date,
+        aumUSD: p.aum_usd,
+        netContributedUSD: p.deposits_cum_usd - p.withdrawals_cum_usd,
+      })),
+      investor,
+    );
+    const pdf = statementService.renderPdf(statement);
+
+    const slug = name.replace(/[^a-zA-Z0-9_-]+/g, "-");
+    res.setHeader("Content-Type", "application/pdf");
+    res.setHeader(
+      "Content-Disposition",
+      `attachment; filename="vault-${slug}-${statement.asOf}.pdf"`,
+    );
+    res.send(pdf);
+  } catch (e: any) {
+    res.status(500).json({
+      error: e?.message || "Failed to build statement",
+    });
+  }
+});
+
 // --- New: Summary of latest metrics for each vault ---
 reportsRouter.get("/reports/vaults/summary", async (req, res) => {
   try {
-    const names = vaultRepository.findAll().map((v) => v.name);
+    const vaults = vaultRepository.findAll();
     const vndRate = await usdToVnd();
 
     // Use optimized function that only computes latest metrics, not entire time series
     const vaultMetrics = await Promise.all(
-      names.map(async (name) => {
-        const metrics = await buildLatestVaultMetrics(name);
+      vaults.map(async (v) => {
+        const metrics = await buildLatestVaultMetrics(v.name);
         if (!metrics) return null;
+        const bench = await benchmarkService.compare(v);
         return {
-          vault: name,
+          vault: v.name,
           aum_usd: metrics.aum_usd,
           aum_vnd: metrics.aum_usd * vndRate,
           pnl_usd: metrics.pnl_usd,
@@ -1951,6 +3243,9 @@ reportsRouter.get("/reports/vaults/summary", async (req, res) => {
           roi_percent: metrics.roi_percent,
           apr_percent: metrics.apr_percent,
           twrr_percent: metrics.twrr_percent,
+          benchmark: bench?.symbol ?? null,
+          benchmark_roi_percent: bench?.roiPercent ?? null,
+          alpha_percent: bench?.alphaPercent ?? null,
         };
       }),
     );
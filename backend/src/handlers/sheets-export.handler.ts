@@ -0,0 +1,79 @@
+import { Router, Request, Response } from "express";
+import { SheetsExport, SheetsExportConfigSchema } from "../types";
+import { sheetsExportService } from "../services/sheets-export.service";
+import { isAppError } from "../core/errors";
+
+// Scheduled export of holdings and the monthly summary to Google Sheets
+export const sheetsExportRouter = Router();
+
+// The encrypted key is never returned; the service account email is
+function toSheetsExportShape(e: SheetsExport) {
+  return {
+    spreadsheet_id: e.spreadsheetId,
+    holdings_sheet: e.holdingsSheet,
+    summary_sheet: e.summarySheet,
+    months: e.months,
+    currency: e.currency,
+    interval_hours: e.intervalHours,
+    client_email: e.clientEmail,
+    active: e.active,
+    next_run_at: e.nextRunAt,
+    last_run_at: e.lastRunAt ?? null,
+    last_status: e.lastStatus ?? null,
+    last_error: e.lastError ?? null,
+    updated_at: e.updatedAt,
+  };
+}
+
+sheetsExportRouter.get(
+  "/admin/integrations/google-sheets",
+  (_req: Request, res: Response) => {
+    const exp = sheetsExportService.get();
+    if (!exp) return res.status(404).json({ error: "not configured" });
+    res.json(toSheetsExportShape(exp));
+  },
+);
+
+sheetsExportRouter.put(
+  "/admin/integrations/google-sheets",
+  (req: Request, res: Response) => {
+    try {
+      const body = SheetsExportConfigSchema.parse(req.body || {});
+      res.json(toSheetsExportShape(sheetsExportService.configure(body)));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(400).json({ error: e?.message || "Invalid export settings" });
+    }
+  },
+);
+
+sheetsExportRouter.delete(
+  "/admin/integrations/google-sheets",
+  (_req: Request, res: Response) => {
+    if (!sheetsExportService.remove()) {
+      return res.status(404).json({ error: "not configured" });
+    }
+    res.json({ ok: true });
+  },
+);
+
+// Push both tabs now; the outcome is also kept on the export
+sheetsExportRouter.post(
+  "/admin/integrations/google-sheets/run",
+  async (_req: Request, res: Response) => {
+    try {
+      const run = await sheetsExportService.push();
+      if (!run) return res.status(404).json({ error: "not configured" });
+      res.json({
+        ok: run.status === "OK",
+        status: run.status,
+        error: run.error ?? null,
+        holdings_rows: run.holdingsRows,
+        summary_rows: run.summaryRows,
+        export: toSheetsExportShape(run.export),
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to export" });
+    }
+  },
+);
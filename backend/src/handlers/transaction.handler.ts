@@ -10,16 +10,50 @@ import {
   BorrowLoanSchema,
   RepayRequest,
   RepaySchema,
+  RecalcStartSchema,
+  ReimbursementMatchSchema,
+  ReportPeriodMode,
+  RewardRequest,
+  RewardSchema,
   Transaction,
+  TransactionImportSchema,
+  TransactionType,
+  TRANSACTION_TYPES,
+  VaultEntry,
 } from "../types";
 import { transactionService } from "../services/transaction.service";
 import { vaultService } from "../services/vault.service";
+import { transactionLinkService } from "../services/transaction-link.service";
+import {
+  DEFAULT_SEARCH_LIMIT,
+  MAX_SEARCH_LIMIT,
+  SEARCH_PRESETS,
+  TransactionSearchParams,
+  transactionSearchService,
+} from "../services/transaction-search.service";
+import {
+  enrichmentService,
+  PendingEnrichment,
+} from "../services/enrichment.service";
 import { vaultRepository } from "../repositories";
 import { transactionRepository } from "../repositories";
 import { priceService } from "../services/price.service";
+import { warningService } from "../services/warning.service";
+import { importService } from "../services/import.service";
+import { precisionService } from "../services/precision.service";
+import { recalcService } from "../services/recalc.service";
+import { toJobShape } from "./jobs.handler";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { isAppError, ValidationError } from "../core/errors";
 
 export const transactionsRouter = Router();
 
+// Created transaction plus non-blocking warnings, when there are any
+async function withWarnings(tx: Transaction) {
+  const warnings = await warningService.forTransaction(tx);
+  return { ...tx, ...(warnings.length ? { warnings } : {}) };
+}
+
 transactionsRouter.get("/health", (_req: Request, res: Response) => {
   res.json({ ok: true });
 });
@@ -29,13 +63,22 @@ transactionsRouter.post(
   async (req: Request, res: Response) => {
     try {
       const body: InitialRequest = InitialRequestSchema.parse(req.body);
+      for (const item of body.items) {
+        precisionService.validate(item.asset, item.amount);
+      }
       const results = await transactionService.createInitialTransactions(
         body.items,
       );
 
+      const warnings: string[] = [];
+      for (const tx of results) {
+        warnings.push(...(await warningService.forTransaction(tx)));
+      }
+
       res.status(201).json({
         created: results.length,
         transactions: results,
+        ...(warnings.length ? { warnings } : {}),
       });
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
@@ -48,6 +91,7 @@ transactionsRouter.post(
   async (req: Request, res: Response) => {
     try {
       const body: IncomeExpenseRequest = IncomeExpenseSchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
       const tx = await transactionService.createIncomeTransaction({
         asset: body.asset,
         amount: body.amount,
@@ -57,21 +101,58 @@ transactionsRouter.post(
         category: body.category,
         tags: body.tags,
         counterparty: body.counterparty,
+        member: body.member,
         dueDate: body.dueDate,
+        projectId: body.projectId,
       });
 
-      res.status(201).json(tx);
+      res.status(201).json(await withWarnings(tx));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
     }
   },
 );
 
+// Staking/interest reward in kind, valued at the reward date's price unless
+// priceLocal is given; rates older than the reward are flagged stale
+transactionsRouter.post(
+  "/transactions/reward",
+  async (req: Request, res: Response) => {
+    try {
+      const body: RewardRequest = RewardSchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
+      const tx = await transactionService.createRewardTransaction(body);
+      res.status(201).json(await withWarnings(tx));
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
+
+// Re-price rewards that were recorded with a stale or missing price
+transactionsRouter.post(
+  "/transactions/rewards/revalue",
+  async (_req: Request, res: Response) => {
+    try {
+      const { revalued, stillStale } =
+        await transactionService.revalueRewards();
+      res.json({
+        revalued: revalued.length,
+        still_stale: stillStale.length,
+        transactions: revalued,
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to revalue" });
+    }
+  },
+);
+
 transactionsRouter.post(
   "/transactions/expense",
   async (req: Request, res: Response) => {
     try {
       const body: IncomeExpenseRequest = IncomeExpenseSchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
       const tx = await transactionService.createExpenseTransaction({
         asset: body.asset,
         amount: body.amount,
@@ -81,21 +162,53 @@ transactionsRouter.post(
         category: body.category,
         tags: body.tags,
         counterparty: body.counterparty,
+        member: body.member,
         dueDate: body.dueDate,
+        reimbursable: body.reimbursable,
+        projectId: body.projectId,
+        card: body.card,
+        feeUSD: body.feeUSD,
+        place: body.place,
+        latitude: body.latitude,
+        longitude: body.longitude,
       });
 
-      res.status(201).json(tx);
+      res.status(201).json(await withWarnings(tx));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
     }
   },
 );
 
+// Bulk CSV/XLSX import; nothing is written unless every row is valid
+transactionsRouter.post(
+  "/transactions/import",
+  async (req: Request, res: Response) => {
+    try {
+      const body = TransactionImportSchema.parse(req.body);
+      const result = await importService.import(body);
+      if (result.errors.length) {
+        return res.status(400).json({
+          error: `${result.errors.length} row(s) failed; nothing imported`,
+          ...result,
+        });
+      }
+      res.status(body.dryRun ? 200 : 201).json(result);
+    } catch (e: any) {
+      res.status(400).json({
+        error: e?.message || "Invalid import",
+        ...(e?.details ? { details: e.details } : {}),
+      });
+    }
+  },
+);
+
 transactionsRouter.post(
   "/transactions/borrow",
   async (req: Request, res: Response) => {
     try {
       const body: BorrowLoanRequest = BorrowLoanSchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
       const tx = await transactionService.createBorrowTransaction({
         asset: body.asset,
         amount: body.amount,
@@ -105,7 +218,7 @@ transactionsRouter.post(
         note: body.note,
       });
 
-      res.status(201).json(tx);
+      res.status(201).json(await withWarnings(tx));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
     }
@@ -117,6 +230,7 @@ transactionsRouter.post(
   async (req: Request, res: Response) => {
     try {
       const body: BorrowLoanRequest = BorrowLoanSchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
       const tx = await transactionService.createLoanTransaction({
         asset: body.asset,
         amount: body.amount,
@@ -126,7 +240,7 @@ transactionsRouter.post(
         note: body.note,
       });
 
-      res.status(201).json(tx);
+      res.status(201).json(await withWarnings(tx));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
     }
@@ -138,6 +252,7 @@ transactionsRouter.post(
   async (req: Request, res: Response) => {
     try {
       const body: RepayRequest = RepaySchema.parse(req.body);
+      precisionService.validate(body.asset, body.amount);
       const tx = await transactionService.createRepayTransaction({
         asset: body.asset,
         amount: body.amount,
@@ -148,7 +263,7 @@ transactionsRouter.post(
         note: body.note,
       });
 
-      res.status(201).json(tx);
+      res.status(201).json(await withWarnings(tx));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "Invalid request" });
     }
@@ -166,6 +281,82 @@ transactionsRouter.get("/report", async (_req: Request, res: Response) => {
   }
 });
 
+// Query parameters that switch GET /transactions to search mode
+const SEARCH_QUERY_KEYS = [
+  "q",
+  "type",
+  "account",
+  "member",
+  "institution",
+  "min_amount",
+  "max_amount",
+  "min_usd",
+  "max_usd",
+  "internal_flow",
+  "start",
+  "end",
+  "preset",
+  "limit",
+  "cursor",
+];
+
+// Repeated (?type=A&type=B) or comma-separated (?type=A,B) values
+function listParam(value: unknown): string[] {
+  const values = Array.isArray(value) ? value : [value];
+  return values
+    .flatMap((v) => (v === undefined ? [] : String(v).split(",")))
+    .map((v) => v.trim())
+    .filter(Boolean);
+}
+
+function numberParam(query: Request["query"], key: string) {
+  if (query[key] === undefined || query[key] === "") return undefined;
+  const n = Number(query[key]);
+  if (!Number.isFinite(n)) {
+    throw new ValidationError(`${key} must be a number`);
+  }
+  return n;
+}
+
+function parseSearchQuery(query: Request["query"]): TransactionSearchParams {
+  const types = listParam(query.type).map((t) => t.toUpperCase());
+  const unknown = types.filter(
+    (t) => !TRANSACTION_TYPES.includes(t as TransactionType),
+  );
+  if (unknown.length) {
+    throw new ValidationError(`unknown types: ${unknown.join(", ")}`, {
+      allowed: TRANSACTION_TYPES,
+    });
+  }
+  const preset = query.preset ? String(query.preset) : undefined;
+  if (preset && !SEARCH_PRESETS.includes(preset as ReportPeriodMode)) {
+    throw new ValidationError(`unknown preset: ${preset}`, {
+      allowed: SEARCH_PRESETS,
+    });
+  }
+  const flow = query.internal_flow;
+  if (flow !== undefined && flow !== "true" && flow !== "false") {
+    throw new ValidationError("internal_flow must be true or false");
+  }
+  return {
+    q: query.q ? String(query.q) : undefined,
+    types: types as TransactionType[],
+    accounts: listParam(query.account),
+    members: listParam(query.member),
+    institutions: listParam(query.institution),
+    minAmount: numberParam(query, "min_amount"),
+    maxAmount: numberParam(query, "max_amount"),
+    minUSD: numberParam(query, "min_usd"),
+    maxUSD: numberParam(query, "max_usd"),
+    internalFlow: flow === undefined ? undefined : flow === "true",
+    start: query.start ? String(query.start) : undefined,
+    end: query.end ? String(query.end) : undefined,
+    preset: preset as ReportPeriodMode | undefined,
+    limit: numberParam(query, "limit"),
+    cursor: query.cursor ? String(query.cursor) : undefined,
+  };
+}
+
 // List transactions
 transactionsRouter.get("/transactions", (req: Request, res: Response) => {
   const investmentId = (
@@ -173,8 +364,7 @@ transactionsRouter.get("/transactions", (req: Request, res: Response) => {
   )?.toString();
 
   if (investmentId) {
-    const entries = vaultService.getVaultEntries(investmentId);
-    const mapped = entries.map((e, idx) => {
+    const toRow = (e: VaultEntry, idx: number) => {
       const isVal = e.type === "VALUATION";
       return {
         id: `${investmentId}-${e.at}-${e.type}-${idx}`,
@@ -198,8 +388,53 @@ transactionsRouter.get("/transactions", (req: Request, res: Response) => {
         note: e.note,
         investment_id: investmentId,
       };
-    });
-    return res.json(mapped);
+    };
+
+    // With limit or cursor: newest first, one page at a time
+    if (req.query.limit !== undefined || req.query.cursor !== undefined) {
+      try {
+        const limit = numberParam(req.query, "limit") ?? DEFAULT_SEARCH_LIMIT;
+        if (!Number.isInteger(limit) || limit < 1 || limit > MAX_SEARCH_LIMIT) {
+          throw new ValidationError(
+            `limit must be between 1 and ${MAX_SEARCH_LIMIT}`,
+          );
+        }
+        const page = vaultRepository.findEntriesPage(investmentId, {
+          limit,
+          cursor: req.query.cursor ? String(req.query.cursor) : undefined,
+        });
+        return res.json({
+          items: page.items.map(toRow),
+          next_cursor: page.nextCursor ?? null,
+        });
+      } catch (e: any) {
+        if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+        return res
+          .status(500)
+          .json({ error: e?.message || "Failed to list transactions" });
+      }
+    }
+
+    return res.json(vaultService.getVaultEntries(investmentId).map(toRow));
+  }
+
+  // Without search parameters the full list is returned as before
+  if (SEARCH_QUERY_KEYS.some((k) => req.query[k] !== undefined)) {
+    try {
+      const page = transactionSearchService.search(
+        parseSearchQuery(req.query),
+      );
+      return res.json({
+        items: page.items,
+        total: page.total,
+        next_cursor: page.nextCursor ?? null,
+      });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      return res
+        .status(500)
+        .json({ error: e?.message || "Failed to search transactions" });
+    }
   }
 
   const transactions = transactionService.getAllTransactions();
@@ -232,7 +467,87 @@ transactionsRouter.get("/transactions", (req: Request, res: Response) => {
   res.json(transactions);
 });
 
-// Delete transaction
+// Single transaction with the transactions linked to it
+function toPendingEnrichmentShape(p: PendingEnrichment) {
+  const t = p.transaction;
+  return {
+    id: t.id,
+    type: t.type,
+    asset: t.asset,
+    amount: t.amount,
+    created_at: t.createdAt,
+    account: t.account,
+    reason: p.reason,
+    rate_usd: t.rate?.rateUSD,
+    rate_timestamp: t.rate?.timestamp,
+  };
+}
+
+// Transactions still waiting for a provider rate (missing or stale)
+transactionsRouter.get(
+  "/transactions/pending-enrichment",
+  (_req: Request, res: Response) => {
+    try {
+      const pending = enrichmentService.pending();
+      res.json({
+        count: pending.length,
+        transactions: pending.map(toPendingEnrichmentShape),
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to list pending" });
+    }
+  },
+);
+
+// Retry now instead of waiting for the background job. With ?async=true
+// it runs as a throttled recalculation (202) to follow at /jobs/:id
+transactionsRouter.post(
+  "/transactions/pending-enrichment/retry",
+  async (req: Request, res: Response) => {
+    try {
+      if (String(req.query.async) === "true") {
+        const throttle = RecalcStartSchema.parse({});
+        const { run, job } = recalcService.start("enrichment", {
+          batchSize: throttle.batch_size,
+          pauseMs: throttle.pause_ms,
+        });
+        return res.status(202).json({ run_id: run.id, ...toJobShape(job) });
+      }
+      const { enriched, stillPending } = await enrichmentService.retry();
+      res.json({
+        enriched: enriched.length,
+        still_pending: stillPending.length,
+        transactions: enriched,
+      });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to retry" });
+    }
+  },
+);
+
+transactionsRouter.get(
+  "/transactions/:id",
+  (req: Request, res: Response) => {
+    const tx = transactionService.getTransactionById(req.params.id);
+    if (!tx) return res.status(404).json({ error: "Transaction not found" });
+    res.json({ ...tx, links: transactionLinkService.getLinks(tx.id) || [] });
+  },
+);
+
+// Every transaction reachable through links, e.g. a loan and all repays
+transactionsRouter.get(
+  "/transactions/:id/related",
+  (req: Request, res: Response) => {
+    const related = transactionLinkService.getRelated(req.params.id);
+    if (!related) {
+      return res.status(404).json({ error: "Transaction not found" });
+    }
+    res.json(related);
+  },
+);
+
+// Delete transaction; it stays restorable until purged
 transactionsRouter.delete(
   "/transactions/:id",
   (req: Request, res: Response) => {
@@ -246,10 +561,73 @@ transactionsRouter.delete(
     const ok = transactionService.deleteTransaction(id);
     if (!ok) return res.status(500).json({ error: "Failed to delete" });
 
+    // No body on 204, so a closed-period warning goes in a header
+    const [warning] = warningService.closedPeriodWarnings(existing.createdAt);
+    if (warning) res.setHeader("X-Nami-Warning", warning);
     return res.status(204).send();
   },
 );
 
+// Undo a delete, until the transaction is purged
+transactionsRouter.post(
+  "/transactions/:id/restore",
+  async (req: Request, res: Response) => {
+    const tx = transactionService.restoreTransaction(req.params.id);
+    if (!tx) {
+      return res.status(404).json({ error: "Deleted transaction not found" });
+    }
+    res.json(await withWarnings(tx));
+  },
+);
+
+// Mark/unmark an expense as reimbursable
+transactionsRouter.post(
+  "/transactions/:id/reimbursable",
+  (req: Request, res: Response) => {
+    try {
+      const id = req.params.id;
+      if (!transactionService.getTransactionById(id)) {
+        return res.status(404).json({ error: "Transaction not found" });
+      }
+      const reimbursable = req.body?.reimbursable !== false;
+      const tx = transactionService.markReimbursable(id, reimbursable);
+      res.json(tx);
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
+
+// Match an incoming refund to a reimbursable expense
+transactionsRouter.post(
+  "/transactions/:id/reimbursement",
+  (req: Request, res: Response) => {
+    try {
+      const id = req.params.id;
+      if (!transactionService.getTransactionById(id)) {
+        return res.status(404).json({ error: "Transaction not found" });
+      }
+      const body = ReimbursementMatchSchema.parse(req.body || {});
+      const result = transactionService.matchReimbursement(id, body.income_id);
+      res.json(result);
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid request" });
+    }
+  },
+);
+
+// Unlink a refund (income id) from its expense
+transactionsRouter.delete(
+  "/transactions/:id/reimbursement",
+  (req: Request, res: Response) => {
+    const income = transactionService.unmatchReimbursement(req.params.id);
+    if (!income) {
+      return res.status(404).json({ error: "Reimbursement link not found" });
+    }
+    res.json(income);
+  },
+);
+
 // Unified create endpoint
 transactionsRouter.post(
   "/transactions",
@@ -271,10 +649,8 @@ transactionsRouter.post(
           return res.status(400).json({ error: "Invalid payload" });
         }
 
-        const asset: Asset = {
-          type: symbol === "USD" || symbol.length === 3 ? "FIAT" : "CRYPTO",
-          symbol,
-        };
+        const asset: Asset = createAssetFromSymbol(symbol);
+        precisionService.validate(asset, qty);
         const rate = await priceService.getRateUSD(asset, at);
         const common = {
           asset,
@@ -306,6 +682,27 @@ transactionsRouter.post(
         }
       }
 
+      if (type === "reward") {
+        const symbol = String(body.asset || "").toUpperCase();
+        const qty = Number(body.quantity || body.amount || 0);
+        if (!symbol || !(qty > 0)) {
+          return res.status(400).json({ error: "Invalid payload" });
+        }
+
+        const asset: Asset = createAssetFromSymbol(symbol);
+        precisionService.validate(asset, qty);
+        const tx = await transactionService.createRewardTransaction({
+          asset,
+          amount: qty,
+          at,
+          account: body.account ? String(body.account) : undefined,
+          note: body.note,
+          counterparty: body.counterparty,
+          priceLocal: Number(body.price_local) || undefined,
+        });
+        return res.status(201).json({ ok: true, transactions: [tx] });
+      }
+
       if (type === "income" || type === "expense") {
         const symbol = String(
           body.asset || body.base_asset || "USD",
@@ -317,10 +714,8 @@ transactionsRouter.post(
           return res.status(400).json({ error: "Invalid payload" });
         }
 
-        const asset: Asset = {
-          type: symbol === "USD" || symbol.length === 3 ? "FIAT" : "CRYPTO",
-          symbol,
-        };
+        const asset: Asset = createAssetFromSymbol(symbol);
+        precisionService.validate(asset, qty);
 
         const tx =
           type.toUpperCase() === "INCOME"
@@ -363,10 +758,8 @@ transactionsRouter.post(
           return res.status(400).json({ error: "Invalid sell payload" });
         }
 
-        const asset: Asset = {
-          type: symbol === "USD" || symbol.length === 3 ? "FIAT" : "CRYPTO",
-          symbol,
-        };
+        const asset: Asset = createAssetFromSymbol(symbol);
+        precisionService.validate(asset, qty);
         const usd: Asset = { type: "FIAT", symbol: "USD" };
 
         const baseRate = await priceService.getRateUSD(asset, at);
@@ -388,7 +781,7 @@ transactionsRouter.post(
           type: "INCOME",
           note: `spot_sell proceeds ${qty * unitPriceUSD} USD`,
           asset: usd,
-          amount: qty * unitPriceUSD,
+          amount: precisionService.round(usd, qty * unitPriceUSD),
           createdAt: at ?? new Date().toISOString(),
           rate: usdRate,
           usdAmount: qty * unitPriceUSD * usdRate.rateUSD,
@@ -417,10 +810,8 @@ transactionsRouter.post(
           return res.status(400).json({ error: "Invalid buy payload" });
         }
 
-        const asset: Asset = {
-          type: symbol === "USD" || symbol.length === 3 ? "FIAT" : "CRYPTO",
-          symbol,
-        };
+        const asset: Asset = createAssetFromSymbol(symbol);
+        precisionService.validate(asset, qty);
         const usd: Asset = { type: "FIAT", symbol: "USD" };
 
         const baseRate = await priceService.getRateUSD(asset, at);
@@ -442,7 +833,7 @@ transactionsRouter.post(
           type: "EXPENSE",
           note: `spot_buy cost ${qty * unitPriceUSD} USD`,
           asset: usd,
-          amount: qty * unitPriceUSD,
+          amount: precisionService.round(usd, qty * unitPriceUSD),
           createdAt: at ?? new Date().toISOString(),
           rate: usdRate,
           usdAmount: qty * unitPriceUSD * usdRate.rateUSD,
@@ -460,7 +851,7 @@ transactionsRouter.post(
 
       return res.status(400).json({
         error:
-          "Unsupported transaction type. Use income/expense, deposit/withdraw, buy/sell or specific endpoints.",
+          "Unsupported transaction type. Use income/expense, reward, deposit/withdraw, buy/sell or specific endpoints.",
       });
     } catch (e: any) {
       res.status(400).json({
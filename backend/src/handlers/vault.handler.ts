@@ -1,26 +1,80 @@
 import { Router, Request, Response } from "express";
 import { v4 as uuidv4 } from "uuid";
-import { Asset, VaultEntry, Transaction } from "../types";
-import { vaultService } from "../services/vault.service";
+import {
+  Asset,
+  VaultEntry,
+  Transaction,
+  LotSelection,
+  LotSelectionSchema,
+} from "../types";
+import {
+  vaultService,
+  normalizeStrategyTags,
+} from "../services/vault.service";
 import { priceService } from "../services/price.service";
+import { trashService } from "../services/trash.service";
+import { costBasisService } from "../services/cost-basis.service";
+import { warningService } from "../services/warning.service";
+import {
+  VaultBenchmark,
+  benchmarkService,
+} from "../services/benchmark.service";
 import { transactionRepository } from "../repositories";
 import { createAssetFromSymbol } from "../utils/asset.util";
+import { isAppError } from "../core/errors";
+import {
+  annualizeReturn,
+  parseAnnualizationMethod,
+} from "../services/financial.service";
 
 export const vaultsRouter = Router();
 
+// Created entry plus closed-period warnings, when there are any
+function withEntryWarnings(entry: VaultEntry, overdraw: string[] = []) {
+  const warnings = [
+    ...overdraw,
+    ...warningService.closedPeriodWarnings(entry.at),
+  ];
+  return { ok: true, entry, ...(warnings.length ? { warnings } : {}) };
+}
+
 // Helper to get rate for cons-vaults deposit/withdraw
 async function getRate(asset: Asset, at?: string) {
   return priceService.getRateUSD(asset, at);
 }
 
+const optionalString = (n: number | null | undefined) =>
+  n === null || n === undefined ? null : String(n);
+
+function toBenchmarkShape(b: VaultBenchmark) {
+  return {
+    symbol: b.symbol,
+    inception_date: b.inceptionDate ?? null,
+    start_price_usd: b.startPriceUSD,
+    price_usd: b.priceUSD,
+    price_return_percent: b.priceReturnPercent,
+    deposited_usd: b.depositedUSD,
+    withdrawn_usd: b.withdrawnUSD,
+    benchmark_value_usd: b.valueUSD,
+    benchmark_pnl_usd: b.pnlUSD,
+    benchmark_roi_percent: b.roiPercent,
+    vault_pnl_usd: b.vaultPnlUSD,
+    vault_roi_percent: b.vaultRoiPercent,
+    excess_pnl_usd: b.excessPnlUSD,
+    alpha_percent: b.alphaPercent,
+    missing_dates: b.missingDates,
+  };
+}
+
 // Helper to create tokenized vault shape (for cons-vaults compatibility)
 function toTokenizedShape(v: {
   name: string;
   status: "ACTIVE" | "CLOSED";
   createdAt: string;
+  benchmark?: string;
 }) {
   const now = new Date().toISOString();
-  return vaultService.vaultStats(v.name).then((stats) => {
+  return vaultService.vaultStats(v.name).then(async (stats) => {
     const aum = stats.aumUSD;
     const depositUSD = stats.totalDepositedUSD;
     const withdrawnUSD = stats.totalWithdrawnUSD;
@@ -34,6 +88,7 @@ function toTokenizedShape(v: {
     const supply = netContributed > 0 ? netContributed : 0;
     // Price = AUM / supply (how much each share is worth now)
     const price = supply > 0 ? aum / supply : 1;
+    const bench = await benchmarkService.compare(v, stats);
     return {
       id: v.name,
       name: v.name,
@@ -58,6 +113,11 @@ function toTokenizedShape(v: {
       inception_date: v.createdAt,
       last_updated: now,
       performance_since_inception: String(perfPct),
+      benchmark_symbol: bench?.symbol ?? null,
+      benchmark_performance_since_inception: optionalString(
+        bench?.roiPercent,
+      ),
+      alpha_since_inception: optionalString(bench?.alphaPercent),
       created_by: "local",
       created_at: v.createdAt,
       updated_at: now,
@@ -105,6 +165,7 @@ function parseWithdrawPayload(body: any): {
   at?: string;
   account?: string;
   note?: string;
+  lots?: LotSelection[];
 } {
   const at: string | undefined =
     body.at || (typeof body.date === "string" ? body.date : undefined);
@@ -134,7 +195,41 @@ function parseWithdrawPayload(body: any): {
     throw new Error("quantity and value required for non-USD withdraw");
   }
 
-  return { asset, amount: quantity, usdValue: value, at, account, note };
+  // Deposit lots to close, for assets on the SPECIFIC cost basis method
+  const lots =
+    body.lots === undefined
+      ? undefined
+      : LotSelectionSchema.array().min(1).parse(body.lots);
+
+  return { asset, amount: quantity, usdValue: value, at, account, note, lots };
+}
+
+function isForced(body: any): boolean {
+  return (
+    String(body?.force || "").toLowerCase() === "true" || body?.force === true
+  );
+}
+
+function allowsOverdraw(body: any): boolean {
+  return (
+    String(body?.allow_overdraw || "").toLowerCase() === "true" ||
+    body?.allow_overdraw === true
+  );
+}
+
+function outlierError(check: {
+  previousUSD: number;
+  newUSD: number;
+  changePercent: number;
+  maxChangePercent: number;
+}) {
+  return {
+    error: `valuation change of ${check.changePercent.toFixed(2)}% exceeds max ${check.maxChangePercent}%; pass force=true to apply`,
+    previous_value_usd: check.previousUSD,
+    new_value_usd: check.newUSD,
+    change_percent: check.changePercent,
+    max_change_percent: check.maxChangePercent,
+  };
 }
 
 // Create or ensure a vault exists
@@ -142,7 +237,10 @@ vaultsRouter.post("/vaults", (req: Request, res: Response) => {
   const name = String(req.body?.name || "").trim();
   if (!name) return res.status(400).json({ error: "name is required" });
 
-  const created = vaultService.ensureVault(name);
+  const tags = normalizeStrategyTags(req.body?.tags);
+  const account = String(req.body?.account || "").trim();
+  const benchmark = String(req.body?.benchmark || "").trim();
+  const created = vaultService.ensureVault(name, tags, account, benchmark);
   res.status(created ? 201 : 200).json(vaultService.getVault(name));
 });
 
@@ -151,10 +249,14 @@ vaultsRouter.get("/vaults", async (req: Request, res: Response) => {
   const isOpen = String(req.query.is_open || "").toLowerCase() === "true";
   const enrich = String(req.query.enrich || "").toLowerCase() === "true";
   const tokenized = String(req.query.tokenized || "").toLowerCase() === "true";
+  const strategy = String(req.query.strategy || "")
+    .trim()
+    .toLowerCase();
 
   const list = vaultService
     .listVaults()
-    .filter((v) => (isOpen ? v.status === "ACTIVE" : true));
+    .filter((v) => (isOpen ? v.status === "ACTIVE" : true))
+    .filter((v) => (strategy ? (v.tags || []).includes(strategy) : true));
 
   // Return tokenized shape if requested
   if (tokenized) {
@@ -170,9 +272,11 @@ vaultsRouter.get("/vaults", async (req: Request, res: Response) => {
 
   if (!enrich) return res.json(list);
 
+  const method = parseAnnualizationMethod(req.query.annualization);
   const enriched = await Promise.all(
     list.map(async (v) => {
-      const stats = await vaultService.vaultStats(v.name);
+      const stats = await vaultService.vaultPerformance(v.name, method);
+      const bench = await benchmarkService.compare(v, stats);
       const depositUSD = stats.totalDepositedUSD;
       const withdrawnUSD = stats.totalWithdrawnUSD;
       const aumUSD = stats.aumUSD;
@@ -186,6 +290,7 @@ vaultsRouter.get("/vaults", async (req: Request, res: Response) => {
         name: v.name,
         status: v.status.toLowerCase() === "active" ? "active" : "closed",
         inception_date: v.createdAt,
+        tags: v.tags || [],
         total_contributed_usd: depositUSD,
         total_withdrawn_usd: withdrawnUSD,
         total_assets_under_management: aumUSD,
@@ -194,6 +299,12 @@ vaultsRouter.get("/vaults", async (req: Request, res: Response) => {
         current_share_price: 0,
         total_supply: 0,
         roi_realtime_percent: roi,
+        apr_percent: stats.annualizedPercent,
+        annualization: stats.annualization,
+        holding_period_days: stats.holdingPeriodDays,
+        benchmark: bench?.symbol ?? null,
+        benchmark_roi_percent: bench?.roiPercent ?? null,
+        alpha_percent: bench?.alphaPercent ?? null,
       };
     }),
   );
@@ -214,7 +325,10 @@ vaultsRouter.get("/vaults/:name", async (req: Request, res: Response) => {
     return res.json(shaped);
   }
 
-  const stats = await vaultService.vaultStats(name);
+  const stats = await vaultService.vaultPerformance(
+    name,
+    parseAnnualizationMethod(req.query.annualization),
+  );
   const depositUSD = stats.totalDepositedUSD;
   const withdrawnUSD = stats.totalWithdrawnUSD;
   const aumUSD = stats.aumUSD;
@@ -223,12 +337,14 @@ vaultsRouter.get("/vaults/:name", async (req: Request, res: Response) => {
       ? ((aumUSD + withdrawnUSD - depositUSD) / depositUSD) * 100
       : 0;
   const firstEntry = vaultService.getVaultEntries(name)[0];
+  const bench = await benchmarkService.compare(vault, stats);
 
   res.json({
     id: vault.name,
     is_vault: true,
     vault_name: vault.name,
     vault_status: vault.status === "ACTIVE" ? "active" : "closed",
+    tags: vault.tags || [],
     vault_ended_at: vault.status === "CLOSED" ? vault.createdAt : undefined,
     asset: "USD",
     account: vault.name,
@@ -241,17 +357,21 @@ vaultsRouter.get("/vaults/:name", async (req: Request, res: Response) => {
     withdrawal_unit_price: "1",
     pnl: String(aumUSD + withdrawnUSD - depositUSD),
     pnl_percent: String(roi),
+    apr_percent: stats.annualizedPercent,
+    annualization: stats.annualization,
+    holding_period_days: stats.holdingPeriodDays,
     is_open: vault.status === "ACTIVE",
     realized_pnl: String(withdrawnUSD - depositUSD),
     remaining_qty: String(aumUSD),
     total_usd_manual: stats.aumUSDManual,
     total_usd_market: stats.aumUSDMarket,
+    benchmark: bench ? toBenchmarkShape(bench) : null,
     created_at: vault.createdAt,
     updated_at: new Date().toISOString(),
   });
 });
 
-// List vault transactions
+// List vault transactions as an ordered timeline with running position
 vaultsRouter.get(
   "/vaults/:name/transactions",
   (req: Request, res: Response) => {
@@ -259,7 +379,72 @@ vaultsRouter.get(
     const vault = vaultService.getVault(name);
     if (!vault) return res.status(404).json({ error: "not found" });
 
-    res.json(vaultService.getVaultEntries(name));
+    const timeline = vaultService
+      .getVaultTimeline(name)
+      .map(
+        ({
+          runningQuantity,
+          runningCostBasisUSD,
+          totalCostBasisUSD,
+          realizedPnLUSD,
+          lastValuationUSD,
+          ...entry
+        }) => ({
+          ...entry,
+          running_quantity: runningQuantity,
+          running_cost_basis_usd: runningCostBasisUSD,
+          total_cost_basis_usd: totalCostBasisUSD,
+          realized_pnl_usd: realizedPnLUSD,
+          last_valuation_usd: lastValuationUSD,
+        }),
+      );
+
+    res.json(timeline);
+  },
+);
+
+// Open deposit lots per asset under the configured cost basis method
+vaultsRouter.get("/vaults/:name/lots", (req: Request, res: Response) => {
+  const name = String(req.params.name);
+  if (!vaultService.getVault(name)) {
+    return res.status(404).json({ error: "not found" });
+  }
+  const at = req.query.at ? String(req.query.at) : undefined;
+
+  res.json(
+    costBasisService.openLots(name, at).map((a) => ({
+      asset: a.asset.symbol,
+      method: a.method,
+      units: a.units,
+      cost_basis_usd: a.costUSD,
+      lots: a.lots.map((l) => ({
+        lot: l.id,
+        acquired_at: l.acquiredAt,
+        units: l.units,
+        cost_basis_usd: l.costUSD,
+      })),
+    })),
+  );
+});
+
+// Performance against the vault's benchmark since inception
+vaultsRouter.get(
+  "/vaults/:name/benchmark",
+  async (req: Request, res: Response) => {
+    const name = String(req.params.name);
+    const vault = vaultService.getVault(name);
+    if (!vault) return res.status(404).json({ error: "not found" });
+    if (!vault.benchmark) {
+      return res.status(404).json({ error: "vault has no benchmark" });
+    }
+    try {
+      const bench = await benchmarkService.compare(vault);
+      res.json({ vault: name, ...toBenchmarkShape(bench!) });
+    } catch (e: any) {
+      res.status(500).json({
+        error: e?.message || "Failed to compare with benchmark",
+      });
+    }
   },
 );
 
@@ -317,7 +502,7 @@ vaultsRouter.post(
       };
 
       vaultService.addVaultEntry(entry);
-      res.status(201).json({ ok: true, entry });
+      res.status(201).json(withEntryWarnings(entry));
     } catch (e: any) {
       res.status(400).json({ error: e?.message || "invalid deposit" });
     }
@@ -342,7 +527,17 @@ vaultsRouter.post(
         at: payload.at ?? new Date().toISOString(),
         account: payload.account,
         note: payload.note,
+        lots: payload.lots,
       };
+      if (entry.lots) {
+        costBasisService.checkLots(
+          name,
+          entry.asset,
+          entry.amount,
+          entry.lots,
+          entry.at,
+        );
+      }
 
       const toVault = String(
         req.body?.to ??
@@ -367,6 +562,7 @@ vaultsRouter.post(
           usdValue: entry.usdValue,
           at: entry.at,
           note: entry.note,
+          allowOverdraw: allowsOverdraw(req.body),
         });
 
         return res.status(201).json({
@@ -374,31 +570,54 @@ vaultsRouter.post(
           entry: result.withdrawEntry,
           withdrawEntry: result.withdrawEntry,
           depositEntry: result.depositEntry,
+          ...(result.warnings.length ? { warnings: result.warnings } : {}),
         });
       }
 
+      const overdraw = await vaultService.checkWithdrawable(
+        name,
+        entry.asset,
+        entry.amount,
+        allowsOverdraw(req.body),
+      );
       vaultService.addVaultEntry(entry);
-      return res.status(201).json({ ok: true, entry });
+      return res.status(201).json(withEntryWarnings(entry, overdraw));
     } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
       res.status(400).json({ error: e?.message || "invalid withdraw" });
     }
   },
 );
 
 // End vault
-vaultsRouter.post("/vaults/:name/end", (req: Request, res: Response) => {
+vaultsRouter.post("/vaults/:name/end", async (req: Request, res: Response) => {
   const name = String(req.params.name);
   const ok = vaultService.endVault(name);
   if (!ok) return res.status(404).json({ error: "not found" });
-  res.json({ ok: true });
+
+  const method = parseAnnualizationMethod(
+    req.body?.annualization ?? req.query.annualization,
+  );
+  const perf = await vaultService.vaultPerformance(name, method);
+  res.json({
+    ok: true,
+    roi_percent: perf.roiPercent,
+    apr_percent: perf.annualizedPercent,
+    annualization: perf.annualization,
+    holding_period_days: perf.holdingPeriodDays,
+  });
 });
 
-// Delete vault
+// Delete vault; it stays restorable from the trash for a week
 vaultsRouter.delete("/vaults/:name", (req: Request, res: Response) => {
   const name = String(req.params.name);
-  const ok = vaultService.deleteVault(name);
-  if (!ok) return res.status(404).json({ error: "not found" });
-  res.json({ ok: true });
+  const trashed = trashService.trashVault(name);
+  if (!trashed) return res.status(404).json({ error: "not found" });
+  res.json({
+    ok: true,
+    trash_id: trashed.id,
+    restorable_until: trashed.expiresAt,
+  });
 });
 
 // Manual refresh
@@ -417,6 +636,15 @@ vaultsRouter.post(
       req.body?.persist === true;
 
     if (persist) {
+      const check = await vaultService.checkManualValuation(
+        name,
+        current_value_usd,
+        isForced(req.body),
+      );
+      if (check.exceeded && !isForced(req.body)) {
+        return res.status(400).json(outlierError(check));
+      }
+
       const at = new Date().toISOString();
       const asset = { type: "FIAT", symbol: "USD" } as const;
       vaultService.addVaultEntry({
@@ -438,13 +666,24 @@ vaultsRouter.post(
           100
         : 0;
 
+    const method = parseAnnualizationMethod(
+      req.body?.annualization ?? req.query.annualization,
+    );
+    const perf = await vaultService.vaultPerformance(name, method);
+
     const resp = {
       as_of: new Date().toISOString(),
       current_value_usd,
       current_value_market: stats.aumUSDMarket,
       total_aum,
       roi_realtime_percent,
-      apr_percent: roi_realtime_percent,
+      apr_percent: annualizeReturn(
+        roi_realtime_percent / 100,
+        perf.holdingPeriodDays,
+        method,
+      ),
+      annualization: method,
+      holding_period_days: perf.holdingPeriodDays,
     };
 
     res.json(resp);
@@ -517,6 +756,7 @@ vaultsRouter.post(
         usdValue,
         at,
         note,
+        allowOverdraw: allowsOverdraw(req.body),
       });
 
       res.status(201).json({
@@ -528,8 +768,10 @@ vaultsRouter.post(
         usdValue,
         withdrawEntry: result.withdrawEntry,
         depositEntry: result.depositEntry,
+        ...(result.warnings.length ? { warnings: result.warnings } : {}),
       });
     } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
       res.status(400).json({ error: e?.message || "transfer failed" });
     }
   },
@@ -567,17 +809,28 @@ vaultsRouter.post(
         ? String(req.body.note)
         : undefined;
       const shouldMark: boolean = req.body?.mark === false ? false : true;
+      const usd = { type: "FIAT", symbol: "USD" } as const;
+
+      // The reward comes out of the value it is marked to, so check it
+      // against that before writing anything
+      const intended = shouldMark
+        ? Number(req.body?.new_total_usd ?? 0) ||
+          (await vaultService.vaultStats(name)).aumUSDManual
+        : undefined;
+      const overdraw = await vaultService.checkWithdrawable(
+        name,
+        usd,
+        amount,
+        allowsOverdraw(req.body),
+        intended,
+      );
 
       let marked_to: number | undefined = undefined;
-      if (shouldMark) {
-        const stats = await vaultService.vaultStats(name);
-        const intended =
-          Number(req.body?.new_total_usd ?? 0) || stats.aumUSDManual;
-        const asset = { type: "FIAT", symbol: "USD" } as const;
+      if (intended !== undefined) {
         vaultService.addVaultEntry({
           vault: name,
           type: "VALUATION",
-          asset: asset as any,
+          asset: usd as any,
           amount: 0,
           usdValue: intended,
           at,
@@ -588,8 +841,6 @@ vaultsRouter.post(
         marked_to = intended;
       }
 
-      const usd = { type: "FIAT", symbol: "USD" } as const;
-
       vaultService.addVaultEntry({
         vault: name,
         type: "WITHDRAW",
@@ -629,8 +880,10 @@ vaultsRouter.post(
         destination,
         reward_usd: amount,
         marked_to,
+        ...(overdraw.length ? { warnings: overdraw } : {}),
       });
     } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
       res.status(400).json({
         error: e?.message || "Failed to distribute reward",
       });
@@ -638,17 +891,38 @@ vaultsRouter.post(
   },
 );
 
-// Update vault (no-op minimal)
-vaultsRouter.put("/vaults/:id", (_req, res) => {
-  res.json({ ok: true });
+// Update vault (tags, account and benchmark; other fields are derived)
+vaultsRouter.put("/vaults/:id", (req, res) => {
+  const id = String(req.params.id);
+  const { tags, account, benchmark } = req.body || {};
+  if (tags === undefined && account === undefined && benchmark === undefined) {
+    return res.json({ ok: true });
+  }
+
+  let updated = vaultService.getVault(id);
+  if (updated && tags !== undefined) {
+    updated = vaultService.setVaultTags(id, normalizeStrategyTags(tags));
+  }
+  if (updated && account !== undefined) {
+    updated = vaultService.setVaultAccount(id, String(account ?? ""));
+  }
+  if (updated && benchmark !== undefined) {
+    updated = vaultService.setVaultBenchmark(id, String(benchmark ?? ""));
+  }
+  if (!updated) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true, vault: updated });
 });
 
 // Delete vault (tokenized vaults endpoint - mirrors /vaults/:name endpoint)
 vaultsRouter.delete("/vaults/:id", (req, res) => {
   const id = String(req.params.id);
-  const ok = vaultService.deleteVault(id);
-  if (!ok) return res.status(404).json({ error: "not found" });
-  res.json({ ok: true });
+  const trashed = trashService.trashVault(id);
+  if (!trashed) return res.status(404).json({ error: "not found" });
+  res.json({
+    ok: true,
+    trash_id: trashed.id,
+    restorable_until: trashed.expiresAt,
+  });
 });
 
 // Close vault
@@ -686,6 +960,13 @@ vaultsRouter.post("/vaults/:id/update-total-value", async (req, res) => {
   if (!v) return res.status(404).json({ error: "not found" });
   const totalValue = Number(req.body?.total_value || 0) || 0;
   const notes = req.body?.notes ? String(req.body.notes) : undefined;
+  const force = isForced(req.body);
+
+  const check = await vaultService.checkManualValuation(id, totalValue, force);
+  if (check.exceeded && !force) {
+    return res.status(400).json(outlierError(check));
+  }
+
   const at = new Date().toISOString();
   const asset: Asset = { type: "FIAT", symbol: "USD" };
 
@@ -0,0 +1,54 @@
+import { Router, Request, Response } from "express";
+import { VestEventCreateSchema, VestingGrantCreateSchema } from "../types";
+import { vestingService } from "../services/vesting.service";
+
+// RSU grants and ESPP plans; due vests are posted by the vesting scheduler
+export const vestingRouter = Router();
+
+vestingRouter.get("/vesting", (_req: Request, res: Response) => {
+  res.json(vestingService.listGrants());
+});
+
+vestingRouter.post("/vesting", (req: Request, res: Response) => {
+  try {
+    const body = VestingGrantCreateSchema.parse(req.body || {});
+    res.status(201).json(vestingService.createGrant(body));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid grant" });
+  }
+});
+
+// Post scheduled vests that are due now instead of waiting for the scheduler
+vestingRouter.post("/vesting/process", async (_req: Request, res: Response) => {
+  try {
+    const vested = await vestingService.processDueVests();
+    res.json({ vested: vested.length, events: vested });
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to process vests" });
+  }
+});
+
+vestingRouter.get("/vesting/:id", (req: Request, res: Response) => {
+  const found = vestingService.getGrant(req.params.id);
+  if (!found) return res.status(404).json({ error: "not found" });
+  res.json(found);
+});
+
+vestingRouter.post(
+  "/vesting/:id/events",
+  async (req: Request, res: Response) => {
+    try {
+      const body = VestEventCreateSchema.parse(req.body || {});
+      const event = await vestingService.addEvent(req.params.id, body);
+      res.status(201).json(event);
+    } catch (e: any) {
+      res.status(400).json({ error: e?.message || "Invalid vest event" });
+    }
+  },
+);
+
+vestingRouter.post("/vesting/:id/cancel", (req: Request, res: Response) => {
+  const grant = vestingService.cancelGrant(req.params.id);
+  if (!grant) return res.status(404).json({ error: "not found" });
+  res.json(grant);
+});
@@ -0,0 +1,89 @@
+import { Router, Request, Response } from "express";
+import { EvmWallet, WalletCreateSchema, WalletUpdateSchema } from "../types";
+import { walletService } from "../services/wallet.service";
+import { isAppError } from "../core/errors";
+
+// On-chain addresses whose transfers are synced into transactions
+export const walletsRouter = Router();
+
+// Sync positions are internal and not returned
+function toWalletShape(w: EvmWallet) {
+  return {
+    id: w.id,
+    chain: w.chain,
+    address: w.address,
+    name: w.name,
+    account: w.account,
+    tokens: w.tokens,
+    since: w.since ?? null,
+    interval_hours: w.intervalHours,
+    active: w.active,
+    next_sync_at: w.nextSyncAt,
+    last_sync_at: w.lastSyncAt ?? null,
+    last_status: w.lastStatus ?? null,
+    last_error: w.lastError ?? null,
+    last_created: w.lastCreated ?? null,
+    created_at: w.createdAt,
+    updated_at: w.updatedAt ?? null,
+  };
+}
+
+walletsRouter.get("/admin/wallets", (_req: Request, res: Response) => {
+  res.json(walletService.list().map(toWalletShape));
+});
+
+walletsRouter.get("/admin/wallets/:id", (req: Request, res: Response) => {
+  const w = walletService.get(req.params.id);
+  if (!w) return res.status(404).json({ error: "Wallet not found" });
+  res.json(toWalletShape(w));
+});
+
+walletsRouter.post("/admin/wallets", (req: Request, res: Response) => {
+  try {
+    const body = WalletCreateSchema.parse(req.body || {});
+    res.status(201).json(toWalletShape(walletService.create(body)));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid wallet" });
+  }
+});
+
+walletsRouter.put("/admin/wallets/:id", (req: Request, res: Response) => {
+  try {
+    const body = WalletUpdateSchema.parse(req.body || {});
+    const w = walletService.update(req.params.id, body);
+    if (!w) return res.status(404).json({ error: "Wallet not found" });
+    res.json(toWalletShape(w));
+  } catch (e: any) {
+    res.status(400).json({ error: e?.message || "Invalid wallet" });
+  }
+});
+
+// Transactions already synced are kept
+walletsRouter.delete("/admin/wallets/:id", (req: Request, res: Response) => {
+  if (!walletService.delete(req.params.id)) {
+    return res.status(404).json({ error: "Wallet not found" });
+  }
+  res.json({ ok: true });
+});
+
+// Sync now; the outcome is also kept on the wallet
+walletsRouter.post(
+  "/admin/wallets/:id/sync",
+  async (req: Request, res: Response) => {
+    try {
+      const run = await walletService.sync(req.params.id);
+      res.json({
+        ok: run.status === "OK",
+        status: run.status,
+        error: run.error ?? null,
+        created: run.created,
+        skipped: run.skipped,
+        wallet: toWalletShape(run.wallet),
+      });
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to sync" });
+    }
+  },
+);
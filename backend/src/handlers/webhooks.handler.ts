@@ -0,0 +1,87 @@
+import { Router, Request, Response } from "express";
+import { Webhook, WebhookCreateSchema, WebhookUpdateSchema } from "../types";
+import { webhookService } from "../services/webhook.service";
+import { DOMAIN_EVENT_TYPES } from "../services/event-bus.service";
+import { isAppError } from "../core/errors";
+
+// External HTTP endpoints receiving signed domain events
+export const webhooksRouter = Router();
+
+// The secret is only returned when the webhook is created
+function toWebhookShape(w: Webhook, withSecret = false) {
+  return {
+    id: w.id,
+    url: w.url,
+    events: w.events,
+    description: w.description ?? null,
+    active: w.active,
+    ...(withSecret ? { secret: w.secret } : {}),
+    created_at: w.createdAt,
+    updated_at: w.updatedAt ?? null,
+    last_delivery_at: w.lastDeliveryAt ?? null,
+    last_status: w.lastStatus ?? null,
+    last_error: w.lastError ?? null,
+  };
+}
+
+webhooksRouter.get("/admin/webhooks", (_req: Request, res: Response) => {
+  res.json(webhookService.list().map((w) => toWebhookShape(w)));
+});
+
+// Event types a webhook can subscribe to ("*" for all)
+webhooksRouter.get("/admin/webhooks/events", (_req: Request, res: Response) => {
+  res.json(DOMAIN_EVENT_TYPES);
+});
+
+webhooksRouter.post("/admin/webhooks", (req: Request, res: Response) => {
+  try {
+    const body = WebhookCreateSchema.parse(req.body || {});
+    res.status(201).json(toWebhookShape(webhookService.create(body), true));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid webhook" });
+  }
+});
+
+webhooksRouter.get("/admin/webhooks/:id", (req: Request, res: Response) => {
+  const hook = webhookService.get(req.params.id);
+  if (!hook) return res.status(404).json({ error: "not found" });
+  res.json(toWebhookShape(hook));
+});
+
+webhooksRouter.put("/admin/webhooks/:id", (req: Request, res: Response) => {
+  try {
+    const body = WebhookUpdateSchema.parse(req.body || {});
+    const updated = webhookService.update(req.params.id, body);
+    if (!updated) return res.status(404).json({ error: "not found" });
+    res.json(toWebhookShape(updated));
+  } catch (e: any) {
+    if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+    res.status(400).json({ error: e?.message || "Invalid webhook" });
+  }
+});
+
+webhooksRouter.delete("/admin/webhooks/:id", (req: Request, res: Response) => {
+  const ok = webhookService.delete(req.params.id);
+  if (!ok) return res.status(404).json({ error: "not found" });
+  res.json({ ok: true });
+});
+
+// Send a signed "ping" event now and report the outcome
+webhooksRouter.post(
+  "/admin/webhooks/:id/test",
+  async (req: Request, res: Response) => {
+    try {
+      const delivery = await webhookService.test(req.params.id);
+      if (!delivery) return res.status(404).json({ error: "not found" });
+      res.json({
+        ok: !delivery.error,
+        status: delivery.status,
+        attempts: delivery.attempts,
+        error: delivery.error ?? null,
+      });
+    } catch (e: any) {
+      res.status(500).json({ error: e?.message || "Failed to send test" });
+    }
+  },
+);
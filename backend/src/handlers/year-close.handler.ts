@@ -0,0 +1,105 @@
+import { Router, Request, Response } from "express";
+import { YearClose } from "../types";
+import { yearCloseService } from "../services/year-close.service";
+import { isAppError } from "../core/errors";
+
+// Closed fiscal years and their archived year-end summaries
+export const yearCloseRouter = Router();
+
+function parseYear(value: unknown): number | undefined {
+  const year = Number(value);
+  return Number.isInteger(year) && year >= 1970 && year <= 9999
+    ? year
+    : undefined;
+}
+
+// Headline figures; `full` adds holdings and balances
+function toYearCloseShape(c: YearClose, full = false) {
+  const s = c.snapshot;
+  return {
+    year: c.year,
+    start: c.start,
+    end: c.end,
+    closed_at: c.closedAt,
+    auto: c.auto,
+    note: c.note ?? null,
+    net_worth_usd: s.netWorthUSD,
+    net_worth_vnd: s.netWorthVND,
+    assets_usd: s.assetsUSD,
+    liabilities_usd: s.liabilitiesUSD,
+    holdings_usd: s.holdingsUSD,
+    pnl: {
+      opening_usd: s.pnl.openingUSD,
+      net_contributions_usd: s.pnl.netContributionsUSD,
+      market_movement_usd: s.pnl.marketMovementUSD,
+      investment_gains_usd: s.pnl.investmentGainsUSD,
+      fx_effect_usd: s.pnl.fxEffectUSD,
+      realized_gain_usd: s.pnl.realizedGainUSD,
+      income_usd: s.pnl.incomeUSD,
+      fees_usd: s.pnl.feesUSD,
+    },
+    ...(full
+      ? {
+          holdings: s.holdings.map((h) => ({
+            asset: h.asset.symbol,
+            asset_type: h.asset.type,
+            quantity: h.quantity,
+            rate_usd: h.rateUSD,
+            value_usd: h.valueUSD,
+          })),
+          balances: s.balances.map((b) => ({
+            vault: b.vault,
+            asset: b.asset.symbol,
+            quantity: b.quantity,
+          })),
+        }
+      : {}),
+    unpriced: s.unpriced,
+  };
+}
+
+yearCloseRouter.get("/reports/year-end", (_req: Request, res: Response) => {
+  try {
+    res.json(yearCloseService.list().map((c) => toYearCloseShape(c)));
+  } catch (e: any) {
+    res.status(500).json({ error: e?.message || "Failed to list year ends" });
+  }
+});
+
+// Archived as closed; nothing is recomputed
+yearCloseRouter.get(
+  "/reports/year-end/:year",
+  (req: Request, res: Response) => {
+    const year = parseYear(req.params.year);
+    if (year === undefined) {
+      return res.status(400).json({ error: "year must be a four-digit year" });
+    }
+    const close = yearCloseService.get(year);
+    if (!close) {
+      return res
+        .status(404)
+        .json({ error: `Fiscal year ${year} is not closed` });
+    }
+    res.json(toYearCloseShape(close, true));
+  },
+);
+
+yearCloseRouter.post(
+  "/admin/year-close",
+  async (req: Request, res: Response) => {
+    try {
+      const year = parseYear(req.body?.year);
+      if (year === undefined) {
+        return res
+          .status(400)
+          .json({ error: "year must be a four-digit year" });
+      }
+      const note = req.body?.note ? String(req.body.note) : undefined;
+      const close = await yearCloseService.close(year, { note });
+      res.status(201).json(toYearCloseShape(close, true));
+    } catch (e: any) {
+      if (isAppError(e)) return res.status(e.statusCode).json(e.toJSON());
+      res.status(500).json({ error: e?.message || "Failed to close year" });
+    }
+  },
+);
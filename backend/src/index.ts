@@ -3,7 +3,11 @@ import express from "express";
 import cors from "cors";
 import swaggerUi from "swagger-ui-express";
 import { config } from "./core/config";
-import { errorHandler, notFoundHandler } from "./core/middleware";
+import {
+    auditActor,
+    errorHandler,
+    notFoundHandler,
+} from "./core/middleware";
 
 import { transactionsRouter } from "./handlers/transaction.handler";
 import { reportsRouter } from "./handlers/reports.handler";
@@ -15,13 +19,54 @@ import { adminRouter } from "./handlers/admin.handler";
 import { loansRouter } from "./handlers/loan.handler";
 import { borrowingsRouter } from "./handlers/borrowing.handler";
 import { aiRouter } from "./handlers/ai.handler";
+import { projectsRouter } from "./handlers/project.handler";
+import { registryRouter } from "./handlers/registry.handler";
+import { mobileRouter } from "./handlers/mobile.handler";
+import { fixedIncomeRouter } from "./handlers/fixed-income.handler";
+import { optionsRouter } from "./handlers/option.handler";
+import { vestingRouter } from "./handlers/vesting.handler";
+import { addressBookRouter } from "./handlers/address-book.handler";
+import { allocationRouter } from "./handlers/allocation.handler";
+import { activityRouter } from "./handlers/activity.handler";
+import { reportSubscriptionsRouter } from "./handlers/report-subscriptions.handler";
+import { metaRouter } from "./handlers/meta.handler";
+import { recurringRouter } from "./handlers/recurring.handler";
+import { budgetsRouter } from "./handlers/budgets.handler";
+import { goalsRouter } from "./handlers/goals.handler";
+import { jobsRouter } from "./handlers/jobs.handler";
+import { webhooksRouter } from "./handlers/webhooks.handler";
+import { sheetsExportRouter } from "./handlers/sheets-export.handler";
+import { connectorsRouter } from "./handlers/connectors.handler";
+import { walletsRouter } from "./handlers/wallets.handler";
+import { yearCloseRouter } from "./handlers/year-close.handler";
+import { monthReviewRouter } from "./handlers/month-review.handler";
+import { accountsRouter } from "./handlers/accounts.handler";
+import { counterpartiesRouter } from "./handlers/counterparties.handler";
 import { settingsRepository } from "./repositories";
 import { vaultService } from "./services/vault.service";
 import { initializeDatabase, closeConnection } from "./database/connection";
 import { setupMonitoring, setMetrics } from "./monitoring";
 import { logger } from "./utils/logger";
 import { priceService } from "./services/price.service";
+import { priceSnapshotService } from "./services/price-snapshot.service";
+import { yearCloseService } from "./services/year-close.service";
+import { accountBalanceService } from "./services/account-balance.service";
 import { borrowingService } from "./services/borrowing.service";
+import { registryService } from "./services/registry.service";
+import { fixedIncomeService } from "./services/fixed-income.service";
+import { optionService } from "./services/option.service";
+import { vestingService } from "./services/vesting.service";
+import { stablecoinService } from "./services/stablecoin.service";
+import { allocationService } from "./services/allocation.service";
+import { trashService } from "./services/trash.service";
+import { reportSubscriptionService } from "./services/report-subscription.service";
+import { maintenanceService } from "./services/maintenance.service";
+import { enrichmentService } from "./services/enrichment.service";
+import { recurringTransactionService } from "./services/recurring.service";
+import { webhookService } from "./services/webhook.service";
+import { sheetsExportService } from "./services/sheets-export.service";
+import { connectorService } from "./services/connector.service";
+import { walletService } from "./services/wallet.service";
 
 const app = express();
 
@@ -35,6 +80,8 @@ app.use(cors());
 // Increase body size limits for large JSON imports
 app.use(express.json({ limit: "4mb" }));
 app.use(express.urlencoded({ limit: "4mb", extended: true }));
+// Attribute each request's writes in the audit log
+app.use(auditActor());
 
 app.get("/health", (_req, res) =>
     res.json({
@@ -54,6 +101,29 @@ app.use("/api", adminRouter);
 app.use("/api", loansRouter);
 app.use("/api", borrowingsRouter);
 app.use("/api", aiRouter);
+app.use("/api", projectsRouter);
+app.use("/api", registryRouter);
+app.use("/api", mobileRouter);
+app.use("/api", fixedIncomeRouter);
+app.use("/api", optionsRouter);
+app.use("/api", vestingRouter);
+app.use("/api", addressBookRouter);
+app.use("/api", allocationRouter);
+app.use("/api", activityRouter);
+app.use("/api", reportSubscriptionsRouter);
+app.use("/api", metaRouter);
+app.use("/api", recurringRouter);
+app.use("/api", budgetsRouter);
+app.use("/api", goalsRouter);
+app.use("/api", jobsRouter);
+app.use("/api", webhooksRouter);
+app.use("/api", sheetsExportRouter);
+app.use("/api", connectorsRouter);
+app.use("/api", walletsRouter);
+app.use("/api", yearCloseRouter);
+app.use("/api", monthReviewRouter);
+app.use("/api", accountsRouter);
+app.use("/api", counterpartiesRouter);
 
 // Metrics endpoint for Prometheus scraping
 registerMetricsEndpoint();
@@ -109,9 +179,66 @@ async function bootstrap() {
         // Initialize borrowing settings
         settingsRepository.getBorrowingSettings();
 
+        // Catch account balances up with writes that bypassed them
+        accountBalanceService.rebuild();
+
         // Start auto-deduction scheduler for borrowings
         borrowingService.startAutoDeductionScheduler();
 
+        // Roll subscription renewals forward and send upcoming reminders
+        registryService.startReminderScheduler();
+
+        // Post due coupons for term deposits and bonds
+        fixedIncomeService.startAccrualScheduler();
+
+        // Expire or exercise options past their expiry
+        optionService.startExpiryScheduler();
+
+        // Post RSU vests as they come due
+        vestingService.startVestingScheduler();
+
+        // Watch stablecoin quotes and value depegged coins at market
+        stablecoinService.startPegMonitor();
+
+        // Notify when price moves push holdings past allocation constraints
+        allocationService.startConstraintMonitor();
+
+        // Drop trashed vaults/settings past their retention
+        trashService.startCleanupScheduler();
+
+        // Render and deliver due report subscriptions
+        reportSubscriptionService.startScheduler();
+
+        // Prune placeholder prices, ANALYZE hot tables, VACUUM when bloated
+        maintenanceService.startScheduler();
+
+        // Reprice transactions recorded while price providers were down
+        enrichmentService.startScheduler();
+
+        // Create due recurring transactions (rent, salary, subscriptions)
+        recurringTransactionService.startScheduler();
+
+        // Push transaction and vault events to registered webhooks
+        webhookService.start();
+
+        // Keep the configured Google Sheet in sync with holdings
+        sheetsExportService.startScheduler();
+
+        // Sync exchange trades, deposits, withdrawals and rewards
+        connectorService.startScheduler();
+
+        // Sync transfers of the tracked EVM wallets
+        walletService.startScheduler();
+
+        // Warm today's prices for held assets (repeats daily)
+        await priceService.startCacheWarmup(() => vaultService.heldAssets());
+
+        // Store end-of-day prices and FX rates of held assets each day
+        priceSnapshotService.startScheduler();
+
+        // Archive the previous fiscal year a month after it ends
+        yearCloseService.startScheduler();
+
         // Sync last 30 days of prices on startup
         logger.info("Syncing last 30 days of prices...");
         await priceService.syncHistoricalPrices(30);
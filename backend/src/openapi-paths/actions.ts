@@ -0,0 +1,327 @@
+import { z } from "zod";
+import { registry, TransactionSchemaOpenAPI } from "../openapi-registry";
+import {
+  badRequest,
+  conflict,
+  idParams,
+  json,
+  jsonBody,
+  notFound,
+} from "./common";
+import { ACTION_INTENT_STATUSES } from "../types";
+
+const date = z.string().openapi({ example: "2025-01-05" }); // YYYY-MM-DD
+
+// One action of POST /actions; `params` depend on `action`
+function action(name: string, id: string, params: z.AnyZodObject) {
+  return registry.register(
+    id,
+    z.object({ action: z.literal(name), params }),
+  );
+}
+
+const SpotBuyActionOpenAPI = action(
+  "spot_buy",
+  "SpotBuyAction",
+  z.object({
+    date: date.optional(),
+    exchange_account: z.string().optional(),
+    base_asset: z.string().openapi({ example: "BTC" }),
+    quote_asset: z.string().openapi({ example: "USDT" }),
+    quantity: z.number().positive(),
+    price_quote: z.number().optional().openapi({
+      description: "Price in the quote asset; from USD rates when omitted",
+    }),
+    fee_percent: z.number().optional(),
+  }),
+);
+
+const InitBalanceActionOpenAPI = action(
+  "init_balance",
+  "InitBalanceAction",
+  z.object({
+    date: date.optional(),
+    account: z.string().optional(),
+    asset: z.string().optional().openapi({
+      description: "Defaults to the account's default asset",
+    }),
+    quantity: z.number().positive(),
+    price_local: z.number().optional().openapi({
+      description: "USD price per unit, fixed instead of looked up",
+    }),
+    note: z.string().optional(),
+  }),
+);
+
+const TransferActionOpenAPI = action(
+  "transfer",
+  "TransferAction",
+  z.object({
+    date: date.optional(),
+    from_account: z.string(),
+    to_account: z.string().optional(),
+    to_address: z.string().optional().openapi({
+      description: "Address book entry by id, name or address",
+    }),
+    asset: z.string().optional(),
+    quantity: z.number().positive(),
+    to_asset: z.string().optional(),
+    to_amount: z.number().optional(),
+    fee: z.number().optional(),
+    chain: z.string().optional().openapi({
+      description: "On-chain transfers: the fee is the network fee",
+    }),
+    note: z.string().optional(),
+  }),
+);
+
+const DripActionOpenAPI = action(
+  "drip",
+  "DripAction",
+  z.object({
+    date: date.optional(),
+    account: z.string(),
+    asset: z.string(),
+    dividend: z.number().positive(),
+    dividend_asset: z.string().optional().openapi({ example: "USD" }),
+    quantity: z.number().optional().openapi({
+      description: "Units bought; defaults to dividend / price",
+    }),
+    price: z.number().optional(),
+    note: z.string().optional(),
+  }),
+);
+
+const NetworkFeeActionOpenAPI = action(
+  "network_fee",
+  "NetworkFeeAction",
+  z.object({
+    date: date.optional(),
+    account: z.string(),
+    chain: z.string(),
+    asset: z.string().optional(),
+    amount: z.number().positive(),
+    tx_hash: z.string().optional(),
+    note: z.string().optional(),
+  }),
+);
+
+const AtmWithdrawalActionOpenAPI = action(
+  "atm_withdrawal",
+  "AtmWithdrawalAction",
+  z.object({
+    date: date.optional(),
+    from_account: z.string(),
+    amount: z.number().positive(),
+    asset: z.string().optional(),
+    to_account: z.string().optional(),
+    fee: z.number().optional(),
+    note: z.string().optional(),
+  }),
+);
+
+const CashCountActionOpenAPI = action(
+  "cash_count",
+  "CashCountAction",
+  z.object({
+    date: date.optional(),
+    counted: z.number(),
+    account: z.string().optional(),
+    asset: z.string().optional(),
+    note: z.string().optional(),
+  }),
+);
+
+const ActionRequestSchemaOpenAPI = registry.register(
+  "ActionRequest",
+  z
+    .discriminatedUnion("action", [
+      SpotBuyActionOpenAPI,
+      InitBalanceActionOpenAPI,
+      TransferActionOpenAPI,
+      DripActionOpenAPI,
+      NetworkFeeActionOpenAPI,
+      AtmWithdrawalActionOpenAPI,
+      CashCountActionOpenAPI,
+    ])
+    .openapi({ description: "A compound action and its parameters" }),
+);
+
+const ActionResultSchemaOpenAPI = registry.register(
+  "ActionResult",
+  z
+    .object({
+      ok: z.boolean(),
+      created: z.number(),
+      transactions: z.array(TransactionSchemaOpenAPI),
+      warnings: z.array(z.string()).optional(),
+      count: z
+        .object({
+          account: z.string(),
+          asset: z.string(),
+          recorded: z.number(),
+          counted: z.number(),
+          difference: z.number(),
+        })
+        .optional()
+        .openapi({ description: "cash_count only" }),
+    })
+    .openapi({ description: "The transactions an action created" }),
+);
+
+const ActionIntentSchemaOpenAPI = registry.register(
+  "ActionIntent",
+  z
+    .object({
+      id: z.string(),
+      action: z.string().openapi({ example: "spot_buy" }),
+      params: z.record(z.any()),
+      status: z.enum(ACTION_INTENT_STATUSES),
+      client_ref: z.string().nullable(),
+      external_ref: z.string().nullable().openapi({
+        description: "e.g. the exchange order id, given on confirm",
+      }),
+      transaction_ids: z.array(z.string()),
+      error: z.string().nullable().openapi({
+        description: "Why the last confirm was rejected",
+      }),
+      cancel_reason: z.string().nullable(),
+      created_at: z.string().datetime(),
+      updated_at: z.string().datetime().nullable(),
+      expires_at: z.string().datetime(),
+      confirmed_at: z.string().datetime().nullable(),
+      cancelled_at: z.string().datetime().nullable(),
+    })
+    .openapi({ description: "A prepared two-phase action" }),
+);
+
+const ActionPrepareSchemaOpenAPI = registry.register(
+  "ActionPrepareRequest",
+  z
+    .object({
+      action: z.string().openapi({ example: "spot_buy" }),
+      params: z.record(z.any()).optional(),
+      client_ref: z.string().optional().openapi({
+        description: "Idempotency key; preparing it again returns the intent",
+      }),
+      expires_in_seconds: z.number().int().positive().optional().openapi({
+        description: "Default 15 minutes, at most 7 days",
+      }),
+    })
+    .openapi({ description: "An action to prepare before acting on it" }),
+);
+
+const ActionConfirmSchemaOpenAPI = registry.register(
+  "ActionConfirmRequest",
+  z.object({
+    params: z.record(z.any()).optional().openapi({
+      description: "Override the prepared params, e.g. with the fill price",
+    }),
+    external_ref: z.string().optional(),
+  }),
+);
+
+const ActionConfirmResultSchemaOpenAPI = registry.register(
+  "ActionConfirmResult",
+  ActionResultSchemaOpenAPI.extend({
+    intent: ActionIntentSchemaOpenAPI,
+    replayed: z.boolean().openapi({
+      description: "The intent was confirmed before; nothing new recorded",
+    }),
+  }),
+);
+
+export function registerActionPaths() {
+  registry.registerPath({
+    method: "post",
+    path: "/api/actions",
+    operationId: "runAction",
+    summary: "Record a compound action as transactions",
+    tags: ["Actions"],
+    request: { body: jsonBody(ActionRequestSchemaOpenAPI) },
+    responses: {
+      200: json(ActionResultSchemaOpenAPI, "Nothing to record"),
+      201: json(ActionResultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/actions/prepare",
+    operationId: "prepareAction",
+    summary: "Prepare a two-phase action",
+    tags: ["Actions"],
+    request: { body: jsonBody(ActionPrepareSchemaOpenAPI) },
+    responses: {
+      200: json(ActionIntentSchemaOpenAPI, "Already prepared"),
+      201: json(ActionIntentSchemaOpenAPI, "Prepared"),
+      400: badRequest,
+      409: conflict,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/actions/intents",
+    operationId: "listActionIntents",
+    summary: "List action intents, newest first",
+    tags: ["Actions"],
+    request: {
+      query: z.object({ status: z.enum(ACTION_INTENT_STATUSES).optional() }),
+    },
+    responses: {
+      200: json(z.array(ActionIntentSchemaOpenAPI)),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/actions/intents/{id}",
+    operationId: "getActionIntent",
+    summary: "Get an action intent",
+    tags: ["Actions"],
+    request: { params: idParams },
+    responses: {
+      200: json(ActionIntentSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/actions/intents/{id}/confirm",
+    operationId: "confirmActionIntent",
+    summary: "Record a prepared action's transactions, once",
+    tags: ["Actions"],
+    request: {
+      params: idParams,
+      body: jsonBody(ActionConfirmSchemaOpenAPI),
+    },
+    responses: {
+      200: json(ActionConfirmResultSchemaOpenAPI, "Confirmed before"),
+      201: json(ActionConfirmResultSchemaOpenAPI, "Confirmed"),
+      400: badRequest,
+      404: notFound,
+      409: conflict,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/actions/intents/{id}/cancel",
+    operationId: "cancelActionIntent",
+    summary: "Cancel a prepared action",
+    tags: ["Actions"],
+    request: {
+      params: idParams,
+      body: jsonBody(z.object({ reason: z.string().optional() })),
+    },
+    responses: {
+      200: json(ActionIntentSchemaOpenAPI),
+      404: notFound,
+      409: conflict,
+    },
+  });
+}
@@ -0,0 +1,72 @@
+import { z } from "zod";
+import { registry } from "../openapi-registry";
+
+// Shared pieces of the handler path registrations
+
+export const ErrorSchemaOpenAPI = registry.register(
+  "Error",
+  z
+    .object({
+      error: z.string(),
+      code: z.string().optional(),
+      details: z.record(z.any()).optional(),
+    })
+    .openapi({
+      description: "An error; `code` and `details` come with app errors",
+    }),
+);
+
+export const OkSchemaOpenAPI = registry.register(
+  "Ok",
+  z.object({ ok: z.boolean() }).openapi({
+    description: "Acknowledgement of a change",
+  }),
+);
+
+export const nameParams = z.object({ name: z.string() });
+export const idParams = z.object({ id: z.string() });
+
+/** A JSON response of `schema`. */
+export function json(schema: z.ZodTypeAny, description = "OK") {
+  return {
+    description,
+    content: { "application/json": { schema } },
+  };
+}
+
+/** A JSON request body of `schema`. */
+export function jsonBody(schema: z.ZodTypeAny) {
+  return { content: { "application/json": { schema } } };
+}
+
+export const badRequest = json(ErrorSchemaOpenAPI, "Bad request");
+export const notFound = json(ErrorSchemaOpenAPI, "Not found");
+export const conflict = json(ErrorSchemaOpenAPI, "Conflict");
+export const serverError = json(ErrorSchemaOpenAPI, "Internal server error");
+
+// Amounts a report gives in USD and in VND
+export const usdVnd = <K extends string>(...keys: K[]) =>
+  Object.fromEntries(
+    keys.flatMap((k) => [
+      [`${k}_usd`, z.number()],
+      [`${k}_vnd`, z.number()],
+    ]),
+  ) as Record<`${K}_usd` | `${K}_vnd`, z.ZodNumber>;
+
+export const JobSchemaOpenAPI = registry.register(
+  "Job",
+  z
+    .object({
+      id: z.string(),
+      kind: z.string(),
+      status: z.enum(["queued", "running", "succeeded", "failed", "cancelled"]),
+      progress: z.number().openapi({ description: "Percent done" }),
+      stage: z.string().nullable(),
+      cancel_requested: z.boolean(),
+      created_at: z.string().datetime(),
+      started_at: z.string().datetime().nullable(),
+      finished_at: z.string().datetime().nullable(),
+      error: z.string().nullable(),
+    })
+    .openapi({ description: "A background job; follow it at /jobs/{id}" }),
+);
@@ -0,0 +1,16 @@
+import { registerActionPaths } from "./actions";
+import { registerInvestmentPaths } from "./investments";
+import { registerReportPaths } from "./reports";
+import { registerVaultPaths } from "./vaults";
+
+let registered = false;
+
+// Register the handler routes that openapi-registry.ts doesn't list itself
+export function registerHandlerPaths() {
+  if (registered) return;
+  registered = true;
+  registerVaultPaths();
+  registerActionPaths();
+  registerInvestmentPaths();
+  registerReportPaths();
+}
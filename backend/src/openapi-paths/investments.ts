@@ -0,0 +1,449 @@
+import { z } from "zod";
+import {
+  AssetSchema,
+  FixedIncomeCreateSchema,
+  FixedIncomeUpdateSchema,
+  OptionOpenSchema,
+  OptionSettleSchema,
+  VestEventCreateSchema,
+  VestingGrantCreateSchema,
+} from "../types";
+import { registry, TransactionSchemaOpenAPI } from "../openapi-registry";
+import {
+  OkSchemaOpenAPI,
+  badRequest,
+  idParams,
+  json,
+  jsonBody,
+  notFound,
+  serverError,
+} from "./common";
+
+// Fixed income
+const FixedIncomeSchemaOpenAPI = registry.register(
+  "FixedIncomeInstrument",
+  z
+    .object({
+      id: z.string().uuid(),
+      kind: z.enum(["TERM_DEPOSIT", "BOND"]),
+      name: z.string(),
+      issuer: z.string().optional(),
+      asset: AssetSchema,
+      faceValue: z.number(),
+      couponRate: z.number().openapi({ description: "Annual, as a decimal" }),
+      couponFrequency: z.enum([
+        "MONTHLY",
+        "QUARTERLY",
+        "SEMIANNUAL",
+        "ANNUAL",
+        "AT_MATURITY",
+      ]),
+      startAt: z.string().datetime(),
+      maturityAt: z.string().datetime(),
+      account: z.string().optional(),
+      nextCouponAt: z.string().datetime().optional(),
+      status: z.enum(["ACTIVE", "MATURED", "CLOSED"]),
+      note: z.string().optional(),
+      createdAt: z.string().datetime(),
+    })
+    .openapi({ description: "A term deposit or bond" }),
+);
+
+const FixedIncomeCreateSchemaOpenAPI = registry.register(
+  "FixedIncomeCreateRequest",
+  FixedIncomeCreateSchema.openapi({
+    description: "Request to add a term deposit or bond",
+  }),
+);
+
+const FixedIncomeUpdateSchemaOpenAPI = registry.register(
+  "FixedIncomeUpdateRequest",
+  FixedIncomeUpdateSchema.openapi({
+    description: "Request to update a fixed income instrument",
+  }),
+);
+
+const CouponPaymentSchemaOpenAPI = registry.register(
+  "CouponPayment",
+  z
+    .object({
+      period_start: z.string().datetime(),
+      payment_at: z.string().datetime(),
+      coupon: z.number(),
+      principal: z.number().openapi({ description: "Repaid at maturity" }),
+    })
+    .openapi({ description: "A scheduled coupon payment" }),
+);
+
+// Options
+const OptionPositionSchemaOpenAPI = registry.register(
+  "OptionPosition",
+  z
+    .object({
+      id: z.string().uuid(),
+      underlying: AssetSchema,
+      optionType: z.enum(["CALL", "PUT"]),
+      side: z.enum(["LONG", "SHORT"]),
+      contracts: z.number(),
+      multiplier: z.number(),
+      strike: z.number(),
+      expiry: z.string().datetime(),
+      premium: z.number(),
+      premiumAsset: AssetSchema,
+      premiumUSD: z.number(),
+      account: z.string(),
+      status: z.enum(["OPEN", "EXPIRED", "EXERCISED"]),
+      openedAt: z.string().datetime(),
+      settledAt: z.string().datetime().optional(),
+      settlementPrice: z.number().optional(),
+      realizedPnLUSD: z.number().optional(),
+      note: z.string().optional(),
+      createdAt: z.string().datetime(),
+    })
+    .openapi({ description: "A long or short call or put" }),
+);
+
+const OptionOpenSchemaOpenAPI = registry.register(
+  "OptionOpenRequest",
+  OptionOpenSchema.openapi({ description: "Request to open a position" }),
+);
+
+const OptionSettleSchemaOpenAPI = registry.register(
+  "OptionSettleRequest",
+  OptionSettleSchema.openapi({
+    description: "Settle at a price, by default the underlying's at `at`",
+  }),
+);
+
+const OptionTradeSchemaOpenAPI = registry.register(
+  "OptionTrade",
+  z
+    .object({
+      position: OptionPositionSchemaOpenAPI,
+      transactions: z.array(TransactionSchemaOpenAPI),
+    })
+    .openapi({ description: "A position and the transactions recorded" }),
+);
+
+// Employer equity
+const VestingGrantSchemaOpenAPI = registry.register(
+  "VestingGrant",
+  z
+    .object({
+      id: z.string().uuid(),
+      kind: z.enum(["RSU", "ESPP"]),
+      employer: z.string(),
+      asset: AssetSchema,
+      cashAsset: AssetSchema,
+      account: z.string(),
+      totalUnits: z.number(),
+      grantAt: z.string().datetime(),
+      vestStartAt: z.string().datetime(),
+      cliffMonths: z.number(),
+      vestingMonths: z.number(),
+      frequencyMonths: z.number(),
+      withholdingRate: z.number(),
+      discount: z.number(),
+      status: z.enum(["ACTIVE", "COMPLETED", "CANCELLED"]),
+      note: z.string().optional(),
+      createdAt: z.string().datetime(),
+    })
+    .openapi({ description: "An RSU grant or ESPP plan" }),
+);
+
+const VestEventSchemaOpenAPI = registry.register(
+  "VestEvent",
+  z
+    .object({
+      id: z.string().uuid(),
+      grantId: z.string().uuid(),
+      vestAt: z.string().datetime(),
+      units: z.number(),
+      status: z.enum(["SCHEDULED", "VESTED", "CANCELLED"]),
+      fmv: z.number().optional(),
+      purchasePrice: z.number().optional(),
+      withheldUnits: z.number().optional(),
+      netUnits: z.number().optional(),
+      incomeUSD: z.number().optional(),
+      transferId: z.string().optional(),
+    })
+    .openapi({ description: "A vest or ESPP purchase" }),
+);
+
+const VestingGrantCreateSchemaOpenAPI = registry.register(
+  "VestingGrantCreateRequest",
+  VestingGrantCreateSchema.openapi({
+    description: "Request to add a grant; RSU vests are scheduled from it",
+  }),
+);
+
+const VestEventCreateSchemaOpenAPI = registry.register(
+  "VestEventCreateRequest",
+  VestEventCreateSchema.openapi({
+    description: "Request to add a vest or ESPP purchase",
+  }),
+);
+
+export function registerInvestmentPaths() {
+  const fixedIncome = ["Fixed Income"];
+  registry.registerPath({
+    method: "get",
+    path: "/api/fixed-income",
+    operationId: "listFixedIncome",
+    summary: "List term deposits and bonds",
+    tags: fixedIncome,
+    request: {
+      query: z.object({
+        status: z.enum(["ACTIVE", "MATURED", "CLOSED"]).optional(),
+        kind: z.enum(["TERM_DEPOSIT", "BOND"]).optional(),
+      }),
+    },
+    responses: { 200: json(z.array(FixedIncomeSchemaOpenAPI)) },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/fixed-income",
+    operationId: "createFixedIncome",
+    summary: "Add a term deposit or bond",
+    tags: fixedIncome,
+    request: { body: jsonBody(FixedIncomeCreateSchemaOpenAPI) },
+    responses: {
+      201: json(FixedIncomeSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/fixed-income/accrue",
+    operationId: "accrueFixedIncome",
+    summary: "Post the coupons that are due",
+    tags: fixedIncome,
+    responses: {
+      200: json(
+        z.object({
+          posted: z.number(),
+          transactions: z.array(TransactionSchemaOpenAPI),
+        }),
+      ),
+      500: serverError,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/fixed-income/{id}",
+    operationId: "getFixedIncome",
+    summary: "Get a fixed income instrument",
+    tags: fixedIncome,
+    request: { params: idParams },
+    responses: {
+      200: json(FixedIncomeSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/fixed-income/{id}/schedule",
+    operationId: "getFixedIncomeSchedule",
+    summary: "Coupon schedule of an instrument",
+    tags: fixedIncome,
+    request: { params: idParams },
+    responses: {
+      200: json(z.array(CouponPaymentSchemaOpenAPI)),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "put",
+    path: "/api/fixed-income/{id}",
+    operationId: "updateFixedIncome",
+    summary: "Update a fixed income instrument",
+    tags: fixedIncome,
+    request: {
+      params: idParams,
+      body: jsonBody(FixedIncomeUpdateSchemaOpenAPI),
+    },
+    responses: {
+      200: json(FixedIncomeSchemaOpenAPI),
+      400: badRequest,
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "delete",
+    path: "/api/fixed-income/{id}",
+    operationId: "deleteFixedIncome",
+    summary: "Delete a fixed income instrument",
+    tags: fixedIncome,
+    request: { params: idParams },
+    responses: {
+      200: json(OkSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  const options = ["Options"];
+  registry.registerPath({
+    method: "get",
+    path: "/api/options",
+    operationId: "listOptions",
+    summary: "List option positions",
+    tags: options,
+    request: {
+      query: z.object({
+        status: z.enum(["OPEN", "EXPIRED", "EXERCISED"]).optional(),
+      }),
+    },
+    responses: { 200: json(z.array(OptionPositionSchemaOpenAPI)) },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/options",
+    operationId: "openOption",
+    summary: "Open an option position",
+    tags: options,
+    request: { body: jsonBody(OptionOpenSchemaOpenAPI) },
+    responses: {
+      201: json(OptionTradeSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/options/{id}",
+    operationId: "getOption",
+    summary: "Get an option position",
+    tags: options,
+    request: { params: idParams },
+    responses: {
+      200: json(OptionPositionSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/options/{id}/expire",
+    operationId: "expireOption",
+    summary: "Let an option expire worthless",
+    tags: options,
+    request: {
+      params: idParams,
+      body: jsonBody(OptionSettleSchemaOpenAPI),
+    },
+    responses: {
+      200: json(OptionPositionSchemaOpenAPI),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/options/{id}/exercise",
+    operationId: "exerciseOption",
+    summary: "Exercise (or be assigned) an option",
+    tags: options,
+    request: {
+      params: idParams,
+      body: jsonBody(OptionSettleSchemaOpenAPI),
+    },
+    responses: {
+      200: json(OptionTradeSchemaOpenAPI),
+      400: badRequest,
+    },
+  });
+
+  const vesting = ["Employer Equity"];
+  registry.registerPath({
+    method: "get",
+    path: "/api/vesting",
+    operationId: "listVestingGrants",
+    summary: "List RSU grants and ESPP plans",
+    tags: vesting,
+    responses: { 200: json(z.array(VestingGrantSchemaOpenAPI)) },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vesting",
+    operationId: "createVestingGrant",
+    summary: "Add an RSU grant or ESPP plan",
+    tags: vesting,
+    request: { body: jsonBody(VestingGrantCreateSchemaOpenAPI) },
+    responses: {
+      201: json(VestingGrantSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vesting/process",
+    operationId: "processVests",
+    summary: "Post the scheduled vests that are due",
+    tags: vesting,
+    responses: {
+      200: json(
+        z.object({
+          vested: z.number(),
+          events: z.array(VestEventSchemaOpenAPI),
+        }),
+      ),
+      500: serverError,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vesting/{id}",
+    operationId: "getVestingGrant",
+    summary: "Get a grant and its vests",
+    tags: vesting,
+    request: { params: idParams },
+    responses: {
+      200: json(
+        z.object({
+          grant: VestingGrantSchemaOpenAPI,
+          events: z.array(VestEventSchemaOpenAPI),
+        }),
+      ),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vesting/{id}/events",
+    operationId: "addVestEvent",
+    summary: "Add a vest or ESPP purchase",
+    tags: vesting,
+    request: {
+      params: idParams,
+      body: jsonBody(VestEventCreateSchemaOpenAPI),
+    },
+    responses: {
+      201: json(VestEventSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vesting/{id}/cancel",
+    operationId: "cancelVestingGrant",
+    summary: "Cancel a grant and its scheduled vests",
+    tags: vesting,
+    request: { params: idParams },
+    responses: {
+      200: json(VestingGrantSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+}
@@ -0,0 +1,1226 @@
+import { z } from "zod";
+import { ResponseConfig } from "@asteasolutions/zod-to-openapi";
+import { AssetSchema } from "../types";
+import {
+  registry,
+  ConstraintViolationSchemaOpenAPI,
+  HoldingsRowSchemaOpenAPI,
+  HoldingsSummarySchemaOpenAPI,
+} from "../openapi-registry";
+import {
+  JobSchemaOpenAPI,
+  badRequest,
+  json,
+  nameParams,
+  notFound,
+  serverError,
+  usdVnd,
+} from "./common";
+
+const day = z.string().openapi({ example: "2025-01-31" }); // YYYY-MM-DD
+const month = z.string().openapi({ example: "2025-01" }); // YYYY-MM
+const annualization = z.enum(["APR", "APY"]);
+const countUsdVnd = z.object({ count: z.number(), ...usdVnd("total") });
+
+const NetWorthSchemaOpenAPI = registry.register(
+  "NetWorthSeries",
+  z
+    .object({
+      start: day,
+      end: day,
+      interval: z.enum(["day", "month"]),
+      points: z.array(
+        z.object({
+          date: day,
+          assets_usd: z.number(),
+          receivables_usd: z.number(),
+          liabilities_usd: z.number(),
+          borrowings_usd: z.number(),
+          credit_usd: z.number(),
+          net_worth_usd: z.number(),
+          net_worth_vnd: z.number(),
+        }),
+      ),
+      unpriced: z.array(z.string()),
+    })
+    .openapi({ description: "Assets minus liabilities over time" }),
+);
+
+const GeographicExposureSchemaOpenAPI = registry.register(
+  "GeographicExposure",
+  z
+    .object({
+      home_jurisdiction: z.string(),
+      ...usdVnd("total_value", "domestic", "foreign"),
+      domestic_percentage: z.number(),
+      foreign_percentage: z.number(),
+      unknown_usd: z.number(),
+      unknown_percentage: z.number(),
+      by_country: z.record(
+        z.object({
+          ...usdVnd("value"),
+          percentage: z.number(),
+          assets: z.array(z.string()),
+        }),
+      ),
+    })
+    .openapi({ description: "Holdings by country, domestic vs. foreign" }),
+);
+
+const SnapshotDiffSchemaOpenAPI = registry.register(
+  "SnapshotDiff",
+  z
+    .object({
+      from: day,
+      to: day,
+      value_from_usd: z.number(),
+      value_to_usd: z.number(),
+      ...usdVnd("net_worth_change"),
+      net_contributions_usd: z.number(),
+      market_movement_usd: z.number(),
+      decomposition: z.object(
+        usdVnd("net_savings", "investment_gains", "fx_effect"),
+      ),
+      added: z.array(z.string()),
+      removed: z.array(z.string()),
+      positions: z.array(
+        z.object({
+          asset: AssetSchema,
+          change: z.enum(["ADDED", "REMOVED", "CHANGED", "UNCHANGED"]),
+          quantity_from: z.number(),
+          quantity_to: z.number(),
+          quantity_change: z.number(),
+          value_from_usd: z.number(),
+          value_to_usd: z.number(),
+          value_change_usd: z.number(),
+          net_flow_usd: z.number(),
+          market_change_usd: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "What changed between two as-of dates" }),
+);
+
+const riskMetrics = {
+  observations: z.number(),
+  annualized_return_percent: z.number(),
+  volatility_percent: z.number(),
+  max_drawdown_percent: z.number(),
+  sharpe_ratio: z.number(),
+};
+
+const RiskReportSchemaOpenAPI = registry.register(
+  "RiskReport",
+  z
+    .object({
+      start: day,
+      end: day,
+      days: z.number(),
+      risk_free_rate_percent: z.number(),
+      portfolio: z.object(riskMetrics),
+      by_asset: z.record(
+        z.object({ ...riskMetrics, current_value_usd: z.number() }),
+      ),
+    })
+    .openapi({ description: "Volatility, drawdown and Sharpe ratio" }),
+);
+
+const MaturitiesSchemaOpenAPI = registry.register(
+  "Maturities",
+  z
+    .object({
+      days: z.number(),
+      items: z.array(
+        z.object({
+          source: z.enum(["fixed_income", "loan"]),
+          id: z.string(),
+          kind: z.enum(["TERM_DEPOSIT", "BOND", "LOAN"]),
+          name: z.string(),
+          counterparty: z.string().optional(),
+          account: z.string().optional(),
+          asset: AssetSchema,
+          maturity_at: z.string().datetime(),
+          days_until: z.number(),
+          principal: z.number(),
+          final_coupon: z.number(),
+          amount: z.number(),
+          ...usdVnd("value"),
+        }),
+      ),
+      ...usdVnd("total"),
+    })
+    .openapi({ description: "Deposits, bonds and loans maturing soon" }),
+);
+
+const GasFeesSchemaOpenAPI = registry.register(
+  "GasFees",
+  z
+    .object({
+      start_date: z.string().datetime().optional(),
+      end_date: z.string().datetime().optional(),
+      chain: z.string().optional(),
+      months: z.array(
+        z.object({
+          chain: z.string(),
+          month,
+          count: z.number(),
+          native: z.record(z.number()).openapi({
+            description: "Fees in each native asset",
+          }),
+          ...usdVnd("total"),
+        }),
+      ),
+      by_chain: z.record(countUsdVnd),
+      ...usdVnd("total", "trading_fees"),
+      fx_fees: z.object({
+        by_month: z.record(countUsdVnd),
+        by_card: z.record(countUsdVnd),
+        ...usdVnd("total"),
+      }),
+    })
+    .openapi({ description: "Network, trading and card FX fees" }),
+);
+
+const TransferConsistencySchemaOpenAPI = registry.register(
+  "TransferConsistency",
+  z
+    .object({
+      checked: z.number(),
+      tolerance_percent: z.number(),
+      fx_tolerance_percent: z.number(),
+      issues: z.array(
+        z.object({
+          transfer_id: z.string(),
+          kind: z.enum(["AMOUNT_MISMATCH", "MISSING_IN", "MISSING_OUT"]),
+          date: z.string().datetime(),
+          from_account: z.string().optional(),
+          to_account: z.string().optional(),
+          asset_out: z.string().optional(),
+          amount_out: z.number().optional(),
+          asset_in: z.string().optional(),
+          amount_in: z.number().optional(),
+          fee: z.number(),
+          discrepancy: z.number(),
+          discrepancy_usd: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "Internal transfers that don't add up" }),
+);
+
+const ConstraintCheckSchemaOpenAPI = registry.register(
+  "ConstraintCheck",
+  z.object({
+    constraints: z.number().openapi({ description: "Constraints checked" }),
+    violations: z.array(ConstraintViolationSchemaOpenAPI),
+  }),
+);
+
+const AllocationDriftSchemaOpenAPI = registry.register(
+  "AllocationDrift",
+  z
+    .object({
+      date: day,
+      ...usdVnd("total"),
+      max_abs_drift_percent: z.number(),
+      classes: z.array(
+        z.object({
+          asset_class: z.string(),
+          ...usdVnd("value"),
+          current_percent: z.number(),
+          target_percent: z.number().nullable(),
+          drift_percent: z.number().nullable(),
+          drift_usd: z.number().nullable(),
+          glidepath_id: z.string().nullable(),
+        }),
+      ),
+    })
+    .openapi({ description: "Holdings by class against the glidepath" }),
+);
+
+const stakeGroup = z.object({
+  cycles: z.number(),
+  amount: z.number(),
+  cost_usd: z.number(),
+  proceeds_usd: z.number(),
+  ...usdVnd("realized"),
+  roi_percent: z.number(),
+  holding_days: z.number(),
+  annualized_percent: z.number(),
+});
+
+const StakeCyclesSchemaOpenAPI = registry.register(
+  "StakeCycles",
+  z
+    .object({
+      annualization,
+      totals: stakeGroup,
+      by_asset: z.record(stakeGroup),
+      by_vault: z.record(stakeGroup),
+      cycles: z.array(
+        z.object({
+          vault: z.string(),
+          asset: z.string(),
+          cost_basis: z.enum(["AVERAGE", "FIFO", "LIFO", "SPECIFIC"]),
+          unstaked_at: z.string().datetime(),
+          amount: z.number(),
+          unmatched_amount: z.number(),
+          cost_usd: z.number(),
+          proceeds_usd: z.number(),
+          ...usdVnd("realized"),
+          roi_percent: z.number(),
+          holding_days: z.number(),
+          annualized_percent: z.number(),
+          stakes: z.array(
+            z.object({
+              staked_at: z.string().datetime(),
+              amount: z.number(),
+              cost_usd: z.number(),
+            }),
+          ),
+        }),
+      ),
+    })
+    .openapi({ description: "Realized PnL of stake/unstake cycles" }),
+);
+
+const accountPerformance = z.object({
+  ...usdVnd("deposits", "withdrawals", "value", "value_change"),
+  net_contributed_usd: z.number(),
+  roi_percent: z.number(),
+  money_weighted_percent: z.number(),
+});
+
+const AccountPerformanceSchemaOpenAPI = registry.register(
+  "AccountPerformance",
+  z
+    .object({
+      accounts: z.array(
+        accountPerformance.extend({
+          account: z.string(),
+          vaults: z.array(z.string()),
+          first_flow_at: z.string().datetime().nullable(),
+        }),
+      ),
+      totals: accountPerformance,
+    })
+    .openapi({ description: "Flows and return per account of vaults" }),
+);
+
+const gains = z.object({
+  count: z.number(),
+  proceeds_usd: z.number(),
+  cost_usd: z.number(),
+  ...usdVnd("gain"),
+});
+
+const TaxReportSchemaOpenAPI = registry.register(
+  "TaxReport",
+  z
+    .object({
+      year: z.number(),
+      start: z.string().datetime(),
+      end: z.string().datetime(),
+      long_term_days: z.number(),
+      gains: z.object({ short_term: gains, long_term: gains, total: gains }),
+      income: z.object({
+        staking_usd: z.number(),
+        dividend_usd: z.number(),
+        interest_usd: z.number(),
+        ...usdVnd("total"),
+        items: z.array(
+          z.object({
+            kind: z.enum(["STAKING", "DIVIDEND", "INTEREST"]),
+            at: z.string().datetime(),
+            source: z.string(),
+            asset: z.string(),
+            amount: z.number(),
+            usd: z.number(),
+            note: z.string().nullable(),
+          }),
+        ),
+      }),
+      fees: z.object({
+        network_usd: z.number(),
+        trading_usd: z.number(),
+        fx_usd: z.number(),
+        ...usdVnd("total"),
+      }),
+      disposals: z.array(
+        z.object({
+          vault: z.string(),
+          asset: z.string(),
+          cost_basis: z.enum(["AVERAGE", "FIFO", "LIFO", "SPECIFIC"]),
+          acquired_at: z.string().datetime(),
+          disposed_at: z.string().datetime(),
+          units: z.number(),
+          proceeds_usd: z.number(),
+          cost_usd: z.number(),
+          gain_usd: z.number(),
+          holding_days: z.number(),
+          term: z.enum(["SHORT", "LONG"]),
+        }),
+      ),
+      unmatched: z.array(
+        z.object({
+          vault: z.string(),
+          asset: z.string(),
+          disposed_at: z.string().datetime(),
+          units: z.number(),
+          proceeds_usd: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "Realized gains, income and fees of a year" }),
+);
+
+const incomeTaxItem = z.object({
+  id: z.string(),
+  at: z.string().datetime(),
+  account: z.string().nullable(),
+  category: z.string().nullable(),
+  ...usdVnd("amount"),
+  note: z.string().nullable(),
+});
+
+const IncomeTaxEstimateSchemaOpenAPI = registry.register(
+  "IncomeTaxEstimate",
+  z
+    .object({
+      year: z.number(),
+      start: z.string().datetime(),
+      end: z.string().datetime(),
+      dependents: z.number(),
+      ...usdVnd("income"),
+      deductions: z.object({
+        personal_vnd: z.number(),
+        dependents_vnd: z.number(),
+        total_vnd: z.number(),
+      }),
+      taxable_vnd: z.number(),
+      brackets: z.array(
+        z.object({
+          from_vnd: z.number(),
+          up_to_vnd: z.number().nullable(),
+          rate_percent: z.number(),
+          taxable_vnd: z.number(),
+          tax_vnd: z.number(),
+        }),
+      ),
+      ...usdVnd("expected_tax", "paid_tax", "difference"),
+      status: z.enum(["OVER_WITHHELD", "UNDER_WITHHELD", "SETTLED"]),
+      effective_rate_percent: z.number(),
+      income: z.array(incomeTaxItem),
+      payments: z.array(incomeTaxItem),
+    })
+    .openapi({ description: "Progressive income tax vs. tax paid" }),
+);
+
+const CashDragSchemaOpenAPI = registry.register(
+  "CashDrag",
+  z
+    .object({
+      start: day,
+      end: day,
+      term_months: z.number(),
+      ...usdVnd("avg_cash", "opportunity_cost"),
+      uncovered_days: z.number(),
+      months: z.array(
+        z.object({
+          month,
+          days: z.number(),
+          ...usdVnd("avg_cash", "opportunity_cost"),
+          benchmark_rate_percent: z.number().nullable(),
+        }),
+      ),
+    })
+    .openapi({ description: "Interest forgone by holding cash" }),
+);
+
+const flow = z.object({
+  ...usdVnd("inflow", "outflow", "net"),
+  count: z.number(),
+});
+
+const CashflowSchemaOpenAPI = registry.register(
+  "Cashflow",
+  z
+    .object({
+      ...usdVnd(
+        "combined_in",
+        "combined_out",
+        "combined_net",
+        "total_in",
+        "total_out",
+        "net",
+        "operating_in",
+        "operating_out",
+        "operating_net",
+        "financing_in",
+        "financing_out",
+        "financing_net",
+      ),
+      by_type: z.record(flow),
+      account: z.string(),
+      start_date: z.string().optional(),
+      end_date: z.string().optional(),
+    })
+    .openapi({ description: "Operating and financing cash flows" }),
+);
+
+const SavingsRateSchemaOpenAPI = registry.register(
+  "SavingsRate",
+  z
+    .object({
+      start: month,
+      end: month,
+      months: z.array(
+        z.object({
+          month,
+          ...usdVnd(
+            "income",
+            "spending",
+            "investing",
+            "debt_repayment",
+            "cash_buildup",
+            "savings",
+          ),
+          savings_rate: z.number().nullable(),
+          allocation: z.object({
+            spending_pct: z.number().nullable(),
+            investing_pct: z.number().nullable(),
+            debt_repayment_pct: z.number().nullable(),
+            cash_buildup_pct: z.number().nullable(),
+          }),
+        }),
+      ),
+      totals: z.object({
+        income_usd: z.number(),
+        savings_usd: z.number(),
+        savings_rate: z.number().nullable(),
+      }),
+    })
+    .openapi({ description: "Savings rate and allocation of income" }),
+);
+
+const PredictedOutflowsSchemaOpenAPI = registry.register(
+  "PredictedOutflows",
+  z
+    .object({
+      start_date: day,
+      end_date: day,
+      account: z.string(),
+      ...usdVnd("baseline_daily_spend"),
+      totals: z.object(
+        usdVnd("predicted_spend", "expected_repayments", "total_out"),
+      ),
+      series: z.array(
+        z.object({
+          date: day,
+          ...usdVnd("predicted_spend", "expected_repayments", "total_out"),
+        }),
+      ),
+      repayment_items: z.array(
+        z.object({
+          date: day,
+          borrowing_id: z.string(),
+          counterparty: z.string(),
+          amount_usd: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "Forecast spending and loan repayments" }),
+);
+
+const SpendingSchemaOpenAPI = registry.register(
+  "Spending",
+  z
+    .object({
+      ...usdVnd(
+        "total",
+        "current_month",
+        "last_month",
+        "avg_daily",
+        "available_balance",
+      ),
+      by_tag: z.record(
+        z.object({
+          ...usdVnd("total", "amount"),
+          count: z.number(),
+          percentage: z.number(),
+        }),
+      ),
+      daily: z.array(z.object({ date: day, ...usdVnd("total") })),
+      by_day: z.record(z.object(usdVnd("amount"))),
+      account: z.string(),
+      mom_change_usd: z.number(),
+      mom_change_percent: z.number(),
+      monthly_trend: z.array(z.object({ month, ...usdVnd("amount") })),
+      exclusion_rules: z.array(z.record(z.any())),
+    })
+    .openapi({ description: "Spending by tag, day and month" }),
+);
+
+const BudgetReportSchemaOpenAPI = registry.register(
+  "BudgetReport",
+  z
+    .object({
+      month,
+      days_in_month: z.number(),
+      days_elapsed: z.number(),
+      budgets: z.array(
+        z.object({
+          id: z.string(),
+          tag: z.string(),
+          currency: z.string(),
+          ...usdVnd(
+            "budget",
+            "actual",
+            "remaining",
+            "daily_burn",
+            "projected",
+          ),
+          used_percentage: z.number(),
+          over_budget: z.boolean(),
+          projected_over_budget: z.boolean(),
+        }),
+      ),
+      totals: z.object(
+        usdVnd("budget", "actual", "remaining", "unbudgeted"),
+      ),
+    })
+    .openapi({ description: "Actual vs. budget for a month" }),
+);
+
+const GoalReportSchemaOpenAPI = registry.register(
+  "GoalReport",
+  z
+    .object({
+      months: z.number().int(),
+      goals: z.array(
+        z.object({
+          id: z.string(),
+          name: z.string(),
+          currency: z.enum(["USD", "VND"]),
+          target_date: day.nullable(),
+          ...usdVnd("target", "funded", "remaining"),
+          accounts_usd: z.number(),
+          contributions_usd: z.number(),
+          funded_percentage: z.number(),
+          funded: z.boolean(),
+          ...usdVnd("avg_monthly_contribution"),
+          required_monthly_usd: z.number().nullable(),
+          required_monthly_vnd: z.number().nullable(),
+          projected_completion_date: day.nullable(),
+          on_track: z.boolean().nullable(),
+          unpriced: z.array(z.string()),
+        }),
+      ),
+      totals: z.object({
+        ...usdVnd(
+          "target",
+          "funded",
+          "remaining",
+          "avg_monthly_contribution",
+        ),
+        funded_percentage: z.number(),
+      }),
+    })
+    .openapi({ description: "Progress of the active savings goals" }),
+);
+
+const StaleAccountsSchemaOpenAPI = registry.register(
+  "StaleAccounts",
+  z
+    .object({
+      months: z.number().int(),
+      dust_usd: z.number(),
+      cutoff: z.string(),
+      ...usdVnd("total_value"),
+      accounts: z.array(
+        z.object({
+          account: z.string(),
+          account_id: z.number().int().nullable(),
+          institution: z.string().nullable(),
+          last_transaction_at: z.string().nullable(),
+          idle_days: z.number().int().nullable(),
+          ...usdVnd("value"),
+          holdings: z.array(
+            z.object({
+              asset: AssetSchema,
+              quantity: z.number(),
+              value_usd: z.number().nullable(),
+            }),
+          ),
+          unpriced: z.array(z.string()),
+          action: z.enum(["ARCHIVE", "CONSOLIDATE", "REVIEW"]),
+          consolidate_into: z.string().nullable(),
+          reason: z.string(),
+        }),
+      ),
+    })
+    .openapi({ description: "Dormant accounts that still hold a balance" }),
+);
+
+const SpendingLocationsSchemaOpenAPI = registry.register(
+  "SpendingLocations",
+  z
+    .object({
+      ...usdVnd("total"),
+      unlocated: countUsdVnd,
+      locations: z.array(
+        z.object({
+          key: z.string(),
+          place: z.string().nullable(),
+          latitude: z.number().nullable(),
+          longitude: z.number().nullable(),
+          count: z.number(),
+          ...usdVnd("total"),
+          percentage: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "Spending grouped by location" }),
+);
+
+const memberShare = countUsdVnd.extend({ percentage: z.number() });
+const memberTotals = z.object({
+  member: z.string().nullable(),
+  spending: memberShare,
+  contribution: memberShare,
+  net_usd: z.number(),
+});
+
+const MemberReportSchemaOpenAPI = registry.register(
+  "MemberReport",
+  z
+    .object({
+      total_spending_usd: z.number(),
+      total_contribution_usd: z.number(),
+      members: z.array(memberTotals),
+      unassigned: memberTotals,
+    })
+    .openapi({ description: "Spending and income per household member" }),
+);
+
+const ReimbursementsSchemaOpenAPI = registry.register(
+  "Reimbursements",
+  z
+    .object({
+      ...usdVnd("outstanding"),
+      count: z.number(),
+      items: z.array(
+        z.object({
+          expense_id: z.string(),
+          date: z.string().datetime(),
+          description: z.string(),
+          counterparty: z.string().optional(),
+          amount_usd: z.number(),
+          reimbursed_usd: z.number(),
+          ...usdVnd("outstanding"),
+          status: z.enum(["outstanding", "reimbursed"]),
+          refund_ids: z.array(z.string()),
+        }),
+      ),
+    })
+    .openapi({ description: "Reimbursable expenses and what is owed" }),
+);
+
+const PnlReportSchemaOpenAPI = registry.register(
+  "PnlReport",
+  z
+    .object({
+      ...usdVnd("realized_pnl", "total_pnl"),
+      roi_percent: z.number(),
+      by_asset: z.record(z.any()),
+      by_strategy: z.record(
+        z.object({
+          vaults: z.array(z.string()),
+          deposited_usd: z.number(),
+          withdrawn_usd: z.number(),
+          aum_usd: z.number(),
+          ...usdVnd("pnl"),
+          roi_percent: z.number(),
+        }),
+      ),
+      options: z.object({
+        ...usdVnd("realized_pnl"),
+        open_positions: z.number(),
+        by_underlying: z.record(z.number()),
+      }),
+    })
+    .openapi({ description: "Realized and total PnL" }),
+);
+
+const VaultHeaderSchemaOpenAPI = registry.register(
+  "VaultHeader",
+  z
+    .object({
+      vault: z.string(),
+      aum_usd: z.number(),
+      pnl_usd: z.number(),
+      roi_percent: z.number(),
+      apr_percent: z.number(),
+      twrr_percent: z.number(),
+      last_valuation_usd: z.number(),
+      net_flow_since_valuation_usd: z.number(),
+      deposits_cum_usd: z.number(),
+      withdrawals_cum_usd: z.number(),
+      as_of: z.string().datetime(),
+    })
+    .openapi({ description: "Vault AUM, PnL, ROI and APR" }),
+);
+
+const SeriesPointSchemaOpenAPI = registry.register(
+  "SeriesPoint",
+  z
+    .object({
+      date: day,
+      ...usdVnd("aum", "pnl"),
+      deposits_cum_usd: z.number(),
+      withdrawals_cum_usd: z.number(),
+      roi_percent: z.number(),
+      apr_percent: z.number(),
+      twrr_percent: z.number(),
+    })
+    .openapi({ description: "A day of a vault or portfolio series" }),
+);
+
+const VaultSummarySchemaOpenAPI = registry.register(
+  "VaultSummary",
+  z
+    .object({
+      rows: z.array(
+        z.object({
+          vault: z.string(),
+          ...usdVnd("aum", "pnl"),
+          roi_percent: z.number(),
+          apr_percent: z.number(),
+          twrr_percent: z.number(),
+          benchmark: z.string().nullable(),
+          benchmark_roi_percent: z.number().nullable(),
+          alpha_percent: z.number().nullable(),
+        }),
+      ),
+      totals: z.object(usdVnd("aum", "pnl")),
+    })
+    .openapi({ description: "Latest metrics of every vault" }),
+);
+
+const spendingQuery = z.object({
+  start: z.string().optional(),
+  end: z.string().optional(),
+  account: z.string().optional().openapi({
+    description: "Defaults to the spending vault",
+  }),
+  exclusions: z.boolean().optional().openapi({
+    description: "Apply the spending exclusion rules (default true)",
+  }),
+});
+
+// Query parameters every report takes
+const reportQuery = z.object({
+  currency: z.string().optional().openapi({
+    description:
+      "ISO currency to add beside USD, e.g. EUR adds total_eur to total_usd",
+    example: "EUR",
+  }),
+});
+
+// A GET report; its JSON response and any error responses
+function report(
+  path: string,
+  operationId: string,
+  summary: string,
+  response: z.ZodTypeAny,
+  opts: {
+    query?: z.AnyZodObject;
+    params?: z.AnyZodObject;
+    responses?: Record<number, ResponseConfig>;
+  } = {},
+) {
+  registry.registerPath({
+    method: "get",
+    path: `/api/reports${path}`,
+    operationId,
+    summary,
+    tags: ["Reports"],
+    request: {
+      query: opts.query ? opts.query.merge(reportQuery) : reportQuery,
+      params: opts.params,
+    },
+    responses: {
+      200: json(response),
+      500: serverError,
+      ...opts.responses,
+    },
+  });
+}
+
+export function registerReportPaths() {
+  report(
+    "/holdings",
+    "getHoldings",
+    "Holdings by asset and account",
+    z.array(HoldingsRowSchemaOpenAPI),
+  );
+  report(
+    "/holdings/summary",
+    "getHoldingsSummary",
+    "Holdings by asset and institution",
+    HoldingsSummarySchemaOpenAPI,
+  );
+  report(
+    "/networth",
+    "getNetWorth",
+    "Net worth over time",
+    NetWorthSchemaOpenAPI,
+    {
+      query: z.object({
+        start: day.optional(),
+        end: day.optional(),
+        fiscal_year: z.number().int().optional(),
+        interval: z.enum(["day", "month"]).optional(),
+        async: z.boolean().optional(),
+      }),
+      responses: {
+        202: json(JobSchemaOpenAPI, "Started as a job (async=true)"),
+        400: badRequest,
+      },
+    },
+  );
+  report(
+    "/exposure/geographic",
+    "getGeographicExposure",
+    "Holdings by country",
+    GeographicExposureSchemaOpenAPI,
+  );
+  report(
+    "/diff",
+    "getSnapshotDiff",
+    "Compare two as-of dates",
+    SnapshotDiffSchemaOpenAPI,
+    {
+      query: z.object({
+        from: day.optional(),
+        to: day.optional(),
+        period: z.enum(["month", "quarter", "year"]).optional(),
+      }),
+      responses: { 400: badRequest },
+    },
+  );
+  report("/risk", "getRisk", "Risk metrics", RiskReportSchemaOpenAPI, {
+    query: z.object({
+      days: z.number().int().optional(),
+      risk_free_rate: z.number().optional().openapi({
+        description: "Annual, in percent",
+      }),
+    }),
+  });
+  report(
+    "/maturities",
+    "getMaturities",
+    "Maturities within a window",
+    MaturitiesSchemaOpenAPI,
+    { query: z.object({ days: z.number().int().optional() }) },
+  );
+  report(
+    "/gas-fees",
+    "getGasFees",
+    "Fees by chain and month",
+    GasFeesSchemaOpenAPI,
+    {
+      query: z.object({
+        start_date: z.string().optional(),
+        end_date: z.string().optional(),
+        chain: z.string().optional(),
+      }),
+    },
+  );
+  report(
+    "/transfers/consistency",
+    "getTransferConsistency",
+    "Transfers that don't add up",
+    TransferConsistencySchemaOpenAPI,
+    {
+      query: z.object({
+        tolerance_percent: z.number().optional(),
+        fx_tolerance_percent: z.number().optional(),
+      }),
+    },
+  );
+  report(
+    "/allocation/constraints",
+    "getConstraintViolations",
+    "Broken allocation constraints",
+    ConstraintCheckSchemaOpenAPI,
+  );
+  report(
+    "/allocation/drift",
+    "getAllocationDrift",
+    "Drift from the glidepath targets",
+    AllocationDriftSchemaOpenAPI,
+    {
+      query: z.object({ date: day.optional() }),
+      responses: { 400: badRequest },
+    },
+  );
+  report(
+    "/stake-cycles",
+    "getStakeCycles",
+    "Realized PnL of stake cycles",
+    StakeCyclesSchemaOpenAPI,
+    {
+      query: z.object({
+        vault: z.string().optional(),
+        asset: z.string().optional(),
+        start: day.optional(),
+        end: day.optional(),
+        annualization: annualization.optional(),
+      }),
+    },
+  );
+  report(
+    "/accounts/performance",
+    "getAccountPerformance",
+    "Performance per account",
+    AccountPerformanceSchemaOpenAPI,
+    { query: z.object({ account: z.string().optional() }) },
+  );
+  report(
+    "/tax",
+    "getTaxReport",
+    "Tax report for a year",
+    TaxReportSchemaOpenAPI,
+    {
+      query: z.object({
+        year: z.number().int().optional(),
+        long_term_days: z.number().int().optional(),
+        format: z.enum(["json", "csv"]).optional(),
+        section: z.enum(["gains", "income"]).optional(),
+      }),
+      responses: {
+        200: {
+          description: "OK; a CSV download with format=csv",
+          content: {
+            "application/json": { schema: TaxReportSchemaOpenAPI },
+            "text/csv": { schema: z.string() },
+          },
+        },
+        400: badRequest,
+      },
+    },
+  );
+  report(
+    "/income-tax",
+    "getIncomeTaxEstimate",
+    "Expected income tax vs. tax paid",
+    IncomeTaxEstimateSchemaOpenAPI,
+    {
+      query: z.object({
+        year: z.number().int().optional(),
+        dependents: z.number().int().min(0).optional().openapi({
+          description: "Overrides the configured number of dependents",
+        }),
+      }),
+      responses: { 400: badRequest },
+    },
+  );
+  report(
+    "/cash-drag",
+    "getCashDrag",
+    "Interest forgone on cash",
+    CashDragSchemaOpenAPI,
+    {
+      query: z.object({
+        start: day.optional(),
+        end: day.optional(),
+        term_months: z.number().int().optional(),
+      }),
+      responses: { 400: badRequest },
+    },
+  );
+  report("/cashflow", "getCashflow", "Cash flows", CashflowSchemaOpenAPI, {
+    query: z.object({
+      start_date: day.optional(),
+      end_date: day.optional(),
+      account: z.string().optional(),
+    }),
+  });
+  report(
+    "/savings-rate",
+    "getSavingsRate",
+    "Savings rate per month",
+    SavingsRateSchemaOpenAPI,
+    {
+      query: z.object({ start: month.optional(), end: month.optional() }),
+      responses: { 400: badRequest },
+    },
+  );
+  const outflowsQuery = z.object({
+    start_date: day.optional(),
+    end_date: day.optional(),
+    account: z.string().optional(),
+  });
+  report(
+    "/predicted-outflows",
+    "getPredictedOutflows",
+    "Forecast outflows",
+    PredictedOutflowsSchemaOpenAPI,
+    { query: outflowsQuery, responses: { 400: badRequest } },
+  );
+  report(
+    "/predicted-cashflow",
+    "getPredictedCashflow",
+    "Forecast outflows (alias of predicted-outflows)",
+    PredictedOutflowsSchemaOpenAPI,
+    { query: outflowsQuery, responses: { 400: badRequest } },
+  );
+  report(
+    "/spending",
+    "getSpending",
+    "Spending analysis",
+    SpendingSchemaOpenAPI,
+    {
+      query: spendingQuery,
+    },
+  );
+  report(
+    "/budgets",
+    "getBudgetReport",
+    "Actual vs. budget",
+    BudgetReportSchemaOpenAPI,
+    {
+      query: spendingQuery.pick({ account: true, exclusions: true }).extend({
+        month: month.optional(),
+      }),
+      responses: { 400: badRequest },
+    },
+  );
+  report(
+    "/goals",
+    "getGoalReport",
+    "Savings goal progress",
+    GoalReportSchemaOpenAPI,
+    {
+      query: z.object({ months: z.number().int().min(1).max(60).optional() }),
+      responses: { 400: badRequest },
+    },
+  );
+  report(
+    "/stale-accounts",
+    "getStaleAccounts",
+    "Dormant accounts with a balance",
+    StaleAccountsSchemaOpenAPI,
+    {
+      query: z.object({
+        months: z.number().int().min(1).max(120).optional(),
+        dust_usd: z.number().min(0).optional(),
+      }),
+      responses: { 400: badRequest },
+    },
+  );
+  report(
+    "/spending/locations",
+    "getSpendingLocations",
+    "Spending by location",
+    SpendingLocationsSchemaOpenAPI,
+    { query: spendingQuery },
+  );
+  report(
+    "/members",
+    "getMemberReport",
+    "Spending per member",
+    MemberReportSchemaOpenAPI,
+    {
+      query: spendingQuery,
+    },
+  );
+  report(
+    "/reimbursements",
+    "getReimbursements",
+    "Reimbursable expenses",
+    ReimbursementsSchemaOpenAPI,
+    {
+      query: z.object({
+        status: z.enum(["outstanding", "all"]).optional(),
+      }),
+    },
+  );
+  report("/pnl", "getPnl", "Profit and loss", PnlReportSchemaOpenAPI);
+  report(
+    "/vaults/{name}/header",
+    "getVaultHeader",
+    "Vault header metrics",
+    VaultHeaderSchemaOpenAPI,
+    { params: nameParams, responses: { 404: notFound } },
+  );
+  report(
+    "/vaults/{name}/series",
+    "getVaultSeries",
+    "Vault daily series",
+    z.object({
+      vault: z.string(),
+      series: z.array(SeriesPointSchemaOpenAPI),
+    }),
+    {
+      params: nameParams,
+      query: z.object({ start: day.optional(), end: day.optional() }),
+      responses: { 404: notFound },
+    },
+  );
+  report(
+    "/vaults/{name}/statement",
+    "getVaultStatement",
+    "PDF statement of a vault",
+    z.string(),
+    {
+      params: nameParams,
+      query: z.object({
+        investor: z.string().optional(),
+        start: day.optional(),
+      }),
+      responses: {
+        200: {
+          description: "A PDF download",
+          content: {
+            "application/pdf": {
+              schema: z.string().openapi({ format: "binary" }),
+            },
+          },
+        },
+        404: notFound,
+      },
+    },
+  );
+  report(
+    "/vaults/summary",
+    "getVaultSummary",
+    "Latest metrics of every vault",
+    VaultSummarySchemaOpenAPI,
+  );
+  report(
+    "/series",
+    "getPortfolioSeries",
+    "Daily series across vaults",
+    z.object({
+      account: z.string(),
+      series: z.array(SeriesPointSchemaOpenAPI),
+    }),
+    {
+      query: z.object({
+        account: z.string().optional().openapi({
+          description: "One vault",
+        }),
+        vaults: z.string().optional().openapi({
+          description: "Comma-separated vaults to include",
+        }),
+        exclude: z.string().optional().openapi({
+          description: "Comma-separated vaults to leave out",
+        }),
+        start: day.optional(),
+        end: day.optional(),
+      }),
+    },
+  );
+}
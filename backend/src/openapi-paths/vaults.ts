@@ -0,0 +1,725 @@
+import { z } from "zod";
+import { AssetSchema, LotSelectionSchema } from "../types";
+import {
+  registry,
+  VaultSchemaOpenAPI,
+  VaultEntrySchemaOpenAPI,
+} from "../openapi-registry";
+import {
+  OkSchemaOpenAPI,
+  badRequest,
+  json,
+  jsonBody,
+  nameParams,
+  notFound,
+} from "./common";
+
+const annualization = z.enum(["APR", "APY"]);
+
+const VaultCreateSchemaOpenAPI = registry.register(
+  "VaultCreateRequest",
+  z
+    .object({
+      name: z.string(),
+      tags: z.array(z.string()).optional(),
+      account: z.string().optional(),
+      benchmark: z.string().optional().openapi({ example: "BTC" }),
+    })
+    .openapi({ description: "Create a vault, or keep the existing one" }),
+);
+
+const VaultUpdateSchemaOpenAPI = registry.register(
+  "VaultUpdateRequest",
+  z
+    .object({
+      tags: z.array(z.string()).optional(),
+      account: z.string().optional(),
+      benchmark: z.string().optional(),
+    })
+    .openapi({ description: "Vault fields that are not derived" }),
+);
+
+const VaultPerformanceSchemaOpenAPI = registry.register(
+  "VaultPerformance",
+  z
+    .object({
+      id: z.string(),
+      name: z.string(),
+      status: z.enum(["active", "closed"]),
+      inception_date: z.string().datetime(),
+      tags: z.array(z.string()),
+      total_contributed_usd: z.number(),
+      total_withdrawn_usd: z.number(),
+      total_assets_under_management: z.number(),
+      total_usd_manual: z.number(),
+      total_usd_market: z.number(),
+      current_share_price: z.number(),
+      total_supply: z.number(),
+      roi_realtime_percent: z.number(),
+      apr_percent: z.number(),
+      annualization,
+      holding_period_days: z.number(),
+      benchmark: z.string().nullable(),
+      benchmark_roi_percent: z.number().nullable(),
+      alpha_percent: z.number().nullable(),
+    })
+    .openapi({ description: "A vault with its performance (enrich=true)" }),
+);
+
+const TokenizedVaultSchemaOpenAPI = registry.register(
+  "TokenizedVault",
+  z
+    .object({
+      id: z.string(),
+      name: z.string(),
+      description: z.string(),
+      type: z.string(),
+      status: z.enum(["active", "closed"]),
+      token_symbol: z.string(),
+      token_decimals: z.number(),
+      total_supply: z.string(),
+      total_assets_under_management: z.string(),
+      current_share_price: z.string(),
+      initial_share_price: z.string(),
+      is_user_defined_price: z.boolean(),
+      manual_price_per_share: z.string(),
+      price_last_updated_by: z.string(),
+      price_last_updated_at: z.string().datetime(),
+      price_update_notes: z.string(),
+      is_deposit_allowed: z.boolean(),
+      is_withdrawal_allowed: z.boolean(),
+      min_deposit_amount: z.string(),
+      min_withdrawal_amount: z.string(),
+      inception_date: z.string().datetime(),
+      last_updated: z.string().datetime(),
+      performance_since_inception: z.string(),
+      benchmark_symbol: z.string().nullable(),
+      benchmark_performance_since_inception: z.string().nullable(),
+      alpha_since_inception: z.string().nullable(),
+      created_by: z.string(),
+      created_at: z.string().datetime(),
+      updated_at: z.string().datetime(),
+    })
+    .openapi({
+      description: "A vault as shares of its AUM (tokenized=true)",
+    }),
+);
+
+const BenchmarkComparisonSchemaOpenAPI = registry.register(
+  "BenchmarkComparison",
+  z
+    .object({
+      symbol: z.string(),
+      inception_date: z.string().nullable(),
+      start_price_usd: z.number(),
+      price_usd: z.number(),
+      price_return_percent: z.number(),
+      deposited_usd: z.number(),
+      withdrawn_usd: z.number(),
+      benchmark_value_usd: z.number(),
+      benchmark_pnl_usd: z.number(),
+      benchmark_roi_percent: z.number(),
+      vault_pnl_usd: z.number(),
+      vault_roi_percent: z.number(),
+      excess_pnl_usd: z.number(),
+      alpha_percent: z.number(),
+      missing_dates: z.array(z.string()),
+    })
+    .openapi({
+      description: "A vault against the same flows into its benchmark",
+    }),
+);
+
+const VaultDetailSchemaOpenAPI = registry.register(
+  "VaultDetail",
+  z
+    .object({
+      id: z.string(),
+      is_vault: z.boolean(),
+      vault_name: z.string(),
+      vault_status: z.enum(["active", "closed"]),
+      tags: z.array(z.string()),
+      vault_ended_at: z.string().datetime().optional(),
+      asset: z.string(),
+      account: z.string(),
+      deposit_date: z.string().datetime(),
+      deposit_qty: z.string(),
+      deposit_cost: z.string(),
+      deposit_unit_cost: z.string(),
+      withdrawal_qty: z.string(),
+      withdrawal_value: z.string(),
+      withdrawal_unit_price: z.string(),
+      pnl: z.string(),
+      pnl_percent: z.string(),
+      apr_percent: z.number(),
+      annualization,
+      holding_period_days: z.number(),
+      is_open: z.boolean(),
+      realized_pnl: z.string(),
+      remaining_qty: z.string(),
+      total_usd_manual: z.number(),
+      total_usd_market: z.number(),
+      benchmark: BenchmarkComparisonSchemaOpenAPI.nullable(),
+      created_at: z.string().datetime(),
+      updated_at: z.string().datetime(),
+    })
+    .openapi({ description: "A vault position with its performance" }),
+);
+
+const VaultTimelineEntrySchemaOpenAPI = registry.register(
+  "VaultTimelineEntry",
+  VaultEntrySchemaOpenAPI.extend({
+    running_quantity: z.number(),
+    running_cost_basis_usd: z.number(),
+    total_cost_basis_usd: z.number(),
+    realized_pnl_usd: z.number(),
+    last_valuation_usd: z.number().optional(),
+  }).openapi({ description: "A vault entry and the position after it" }),
+);
+
+const VaultLotsSchemaOpenAPI = registry.register(
+  "VaultAssetLots",
+  z
+    .object({
+      asset: z.string(),
+      method: z.enum(["AVERAGE", "FIFO", "LIFO", "SPECIFIC"]),
+      units: z.number(),
+      cost_basis_usd: z.number(),
+      lots: z.array(
+        z.object({
+          lot: z.string(),
+          acquired_at: z.string().datetime(),
+          units: z.number(),
+          cost_basis_usd: z.number(),
+        }),
+      ),
+    })
+    .openapi({ description: "Open deposit lots of one asset" }),
+);
+
+const VaultHoldingsSchemaOpenAPI = registry.register(
+  "VaultHoldings",
+  z
+    .object({
+      total_shares: z.number(),
+      total_aum: z.number(),
+      total_usd_manual: z.number(),
+      total_usd_market: z.number(),
+      share_price: z.number(),
+      transaction_count: z.number(),
+      last_transaction_at: z.string().datetime(),
+    })
+    .openapi({ description: "Vault holdings summary" }),
+);
+
+const flowFields = {
+  asset: z
+    .union([AssetSchema, z.string()])
+    .optional()
+    .openapi({ description: "Asset or symbol; defaults to USD" }),
+  quantity: z.number().optional().openapi({ description: "Units" }),
+  amount: z.number().optional().openapi({ description: "Alias of quantity" }),
+  at: z.string().datetime().optional(),
+  note: z.string().optional(),
+};
+
+const VaultDepositSchemaOpenAPI = registry.register(
+  "VaultDepositRequest",
+  z
+    .object({
+      ...flowFields,
+      cost: z.number().optional().openapi({
+        description: "USD cost; required unless the asset is USD",
+      }),
+      account: z.string().optional().openapi({
+        description: "Account the funds came from",
+      }),
+    })
+    .openapi({ description: "Deposit into a vault" }),
+);
+
+const VaultWithdrawSchemaOpenAPI = registry.register(
+  "VaultWithdrawRequest",
+  z
+    .object({
+      ...flowFields,
+      value: z.number().optional().openapi({
+        description: "USD value; required unless the asset is USD",
+      }),
+      account: z.string().optional().openapi({
+        description: "Account the funds go to",
+      }),
+      to: z.string().optional().openapi({
+        description: "Vault to transfer into instead",
+      }),
+      lots: z.array(LotSelectionSchema).optional().openapi({
+        description: "Deposit lots to close, under SPECIFIC",
+      }),
+      allow_overdraw: z.boolean().optional(),
+    })
+    .openapi({ description: "Withdraw from a vault" }),
+);
+
+const VaultEntryResultSchemaOpenAPI = registry.register(
+  "VaultEntryResult",
+  z
+    .object({
+      ok: z.boolean(),
+      entry: VaultEntrySchemaOpenAPI,
+      warnings: z.array(z.string()).optional().openapi({
+        description: "The entry is dated in a closed fiscal year",
+      }),
+      withdrawEntry: VaultEntrySchemaOpenAPI.optional(),
+      depositEntry: VaultEntrySchemaOpenAPI.optional(),
+    })
+    .openapi({
+      description:
+        "The recorded entry; a withdrawal to another vault also has both legs",
+    }),
+);
+
+const VaultTransferSchemaOpenAPI = registry.register(
+  "VaultTransferRequest",
+  z
+    .object({
+      ...flowFields,
+      to: z.string(),
+      value: z.number().optional(),
+      allow_overdraw: z.boolean().optional(),
+    })
+    .openapi({ description: "Move assets to another vault" }),
+);
+
+const VaultTransferResultSchemaOpenAPI = registry.register(
+  "VaultTransferResult",
+  z
+    .object({
+      ok: z.boolean(),
+      from: z.string(),
+      to: z.string(),
+      asset: AssetSchema,
+      amount: z.number(),
+      usdValue: z.number(),
+      withdrawEntry: VaultEntrySchemaOpenAPI,
+      depositEntry: VaultEntrySchemaOpenAPI,
+    })
+    .openapi({ description: "Both legs of a transfer between vaults" }),
+);
+
+const RewardDistributionSchemaOpenAPI = registry.register(
+  "RewardDistributionRequest",
+  z
+    .object({
+      amount: z.number().positive().openapi({ description: "USD" }),
+      destination: z.string().optional().openapi({ example: "Spend" }),
+      at: z.string().datetime().optional(),
+      note: z.string().optional(),
+      new_total_usd: z.number().optional(),
+      mark: z.boolean().optional().openapi({
+        description: "Record a valuation first (default true)",
+      }),
+      create_income: z.boolean().optional(),
+    })
+    .openapi({ description: "Pay a vault's reward out to another vault" }),
+);
+
+const RewardDistributionResultSchemaOpenAPI = registry.register(
+  "RewardDistributionResult",
+  z.object({
+    ok: z.boolean(),
+    source: z.string(),
+    destination: z.string(),
+    reward_usd: z.number(),
+    marked_to: z.number().optional(),
+  }),
+);
+
+const VaultRefreshSchemaOpenAPI = registry.register(
+  "VaultRefreshRequest",
+  z.object({
+    current_value_usd: z.number().optional(),
+    persist: z.boolean().optional().openapi({
+      description: "Record the value as a valuation entry",
+    }),
+    force: z.boolean().optional().openapi({
+      description: "Apply a value past the valuation outlier check",
+    }),
+    annualization: annualization.optional(),
+  }),
+);
+
+const VaultRefreshResultSchemaOpenAPI = registry.register(
+  "VaultRefreshResult",
+  z.object({
+    as_of: z.string().datetime(),
+    current_value_usd: z.number(),
+    current_value_market: z.number(),
+    total_aum: z.number(),
+    roi_realtime_percent: z.number(),
+    apr_percent: z.number(),
+    annualization,
+    holding_period_days: z.number(),
+  }),
+);
+
+const ValuationOutlierSchemaOpenAPI = registry.register(
+  "ValuationOutlier",
+  z
+    .object({
+      error: z.string(),
+      previous_value_usd: z.number(),
+      new_value_usd: z.number(),
+      change_percent: z.number(),
+      max_change_percent: z.number(),
+    })
+    .openapi({
+      description: "A valuation change too large to apply without force",
+    }),
+);
+
+const VaultEndResultSchemaOpenAPI = registry.register(
+  "VaultEndResult",
+  z.object({
+    ok: z.boolean(),
+    roi_percent: z.number(),
+    apr_percent: z.number(),
+    annualization,
+    holding_period_days: z.number(),
+  }),
+);
+
+export const TrashResultSchemaOpenAPI = registry.register(
+  "TrashResult",
+  z
+    .object({
+      ok: z.boolean(),
+      trash_id: z.string(),
+      restorable_until: z.string().datetime(),
+    })
+    .openapi({ description: "Moved to the trash; restorable until then" }),
+);
+
+const VaultPriceSchemaOpenAPI = registry.register(
+  "VaultPrice",
+  z.object({
+    current_share_price: z.string(),
+    total_assets_under_management: z.string(),
+  }),
+);
+
+const tags = ["Vaults"];
+
+export function registerVaultPaths() {
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults",
+    operationId: "listVaults",
+    summary: "List vaults",
+    tags,
+    request: {
+      query: z.object({
+        is_open: z.boolean().optional(),
+        strategy: z.string().optional(),
+        enrich: z.boolean().optional(),
+        tokenized: z.boolean().optional(),
+        annualization: annualization.optional(),
+      }),
+    },
+    responses: {
+      200: json(
+        z.union([
+          z.array(VaultSchemaOpenAPI),
+          z.array(VaultPerformanceSchemaOpenAPI),
+          z.array(TokenizedVaultSchemaOpenAPI),
+        ]),
+        "Vaults; with performance when enrich=true, as shares when " +
+          "tokenized=true",
+      ),
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults",
+    operationId: "createVault",
+    summary: "Create vault",
+    tags,
+    request: { body: jsonBody(VaultCreateSchemaOpenAPI) },
+    responses: {
+      200: json(VaultSchemaOpenAPI, "Already exists"),
+      201: json(VaultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults/{name}",
+    operationId: "getVault",
+    summary: "Get vault by name",
+    tags,
+    request: {
+      params: nameParams,
+      query: z.object({
+        tokenized: z.boolean().optional(),
+        annualization: annualization.optional(),
+      }),
+    },
+    responses: {
+      200: json(
+        z.union([VaultDetailSchemaOpenAPI, TokenizedVaultSchemaOpenAPI]),
+      ),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "put",
+    path: "/api/vaults/{name}",
+    operationId: "updateVault",
+    summary: "Update vault tags, account and benchmark",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(VaultUpdateSchemaOpenAPI),
+    },
+    responses: {
+      200: json(
+        z.object({ ok: z.boolean(), vault: VaultSchemaOpenAPI.optional() }),
+      ),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "delete",
+    path: "/api/vaults/{name}",
+    operationId: "deleteVault",
+    summary: "Move vault and its entries to the trash",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(TrashResultSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults/{name}/transactions",
+    operationId: "listVaultEntries",
+    summary: "List vault entries with the running position",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(z.array(VaultTimelineEntrySchemaOpenAPI)),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults/{name}/lots",
+    operationId: "listVaultLots",
+    summary: "Open deposit lots per asset",
+    tags,
+    request: {
+      params: nameParams,
+      query: z.object({ at: z.string().datetime().optional() }),
+    },
+    responses: {
+      200: json(z.array(VaultLotsSchemaOpenAPI)),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults/{name}/benchmark",
+    operationId: "getVaultBenchmark",
+    summary: "Performance against the vault's benchmark",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(
+        BenchmarkComparisonSchemaOpenAPI.extend({ vault: z.string() }),
+      ),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "get",
+    path: "/api/vaults/{name}/holdings",
+    operationId: "getVaultHoldings",
+    summary: "Vault holdings summary",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(VaultHoldingsSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/deposit",
+    operationId: "depositToVault",
+    summary: "Deposit to vault",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(VaultDepositSchemaOpenAPI),
+    },
+    responses: {
+      201: json(VaultEntryResultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/withdraw",
+    operationId: "withdrawFromVault",
+    summary: "Withdraw from vault",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(VaultWithdrawSchemaOpenAPI),
+    },
+    responses: {
+      201: json(VaultEntryResultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/transfer",
+    operationId: "transferBetweenVaults",
+    summary: "Transfer to another vault",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(VaultTransferSchemaOpenAPI),
+    },
+    responses: {
+      201: json(VaultTransferResultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/distribute-reward",
+    operationId: "distributeVaultReward",
+    summary: "Pay a reward out to another vault",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(RewardDistributionSchemaOpenAPI),
+    },
+    responses: {
+      201: json(RewardDistributionResultSchemaOpenAPI, "Created"),
+      400: badRequest,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/refresh",
+    operationId: "refreshVault",
+    summary: "Revalue a vault, optionally recording the value",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(VaultRefreshSchemaOpenAPI),
+    },
+    responses: {
+      200: json(VaultRefreshResultSchemaOpenAPI),
+      400: json(ValuationOutlierSchemaOpenAPI, "Valuation outlier"),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/end",
+    operationId: "endVault",
+    summary: "Close a vault and report its return",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(z.object({ annualization: annualization.optional() })),
+    },
+    responses: {
+      200: json(VaultEndResultSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/close",
+    operationId: "closeVault",
+    summary: "Close a vault",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(OkSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  for (const [action, operationId] of [
+    ["enable", "enableVaultManualPricing"],
+    ["disable", "disableVaultManualPricing"],
+  ]) {
+    const verb = action === "enable" ? "Enable" : "Disable";
+    registry.registerPath({
+      method: "post",
+      path: `/api/vaults/{name}/${action}-manual-pricing`,
+      operationId,
+      summary: `${verb} manual pricing (no-op; vault prices are manual)`,
+      tags,
+      request: { params: nameParams },
+      responses: { 200: json(OkSchemaOpenAPI) },
+    });
+  }
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/update-price",
+    operationId: "getVaultPrice",
+    summary: "Current share price (computed from AUM)",
+    tags,
+    request: { params: nameParams },
+    responses: {
+      200: json(VaultPriceSchemaOpenAPI),
+      404: notFound,
+    },
+  });
+
+  registry.registerPath({
+    method: "post",
+    path: "/api/vaults/{name}/update-total-value",
+    operationId: "updateVaultTotalValue",
+    summary: "Record the vault's total value",
+    tags,
+    request: {
+      params: nameParams,
+      body: jsonBody(
+        z.object({
+          total_value: z.number(),
+          notes: z.string().optional(),
+          force: z.boolean().optional(),
+        }),
+      ),
+    },
+    responses: {
+      200: json(VaultPriceSchemaOpenAPI),
+      400: json(ValuationOutlierSchemaOpenAPI, "Valuation outlier"),
+      404: notFound,
+    },
+  });
+}
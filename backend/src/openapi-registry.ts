@@ -81,6 +81,22 @@ export const TransactionSchemaOpenAPI = registry.register(
       sourceRef: z.string().optional(),
       rate: RateSchemaOpenAPI,
       usdAmount: z.number(),
+      fx: z
+        .record(z.number())
+        .optional()
+        .openapi({
+          description:
+            "Value of one unit of the asset in other currencies when " +
+            "recorded, by code (USD is rate)",
+        }),
+      fxMargin: z
+        .number()
+        .optional()
+        .openapi({
+          description:
+            "Percent over mid-market the account charged; included in " +
+            "usdAmount",
+        }),
       direction: z.enum(["BORROW", "LOAN"]).optional(),
     })
     .openapi({
@@ -138,10 +154,16 @@ export const HoldingsRowSchemaOpenAPI = registry.register(
     .object({
       asset: z.string(),
       account: z.string().optional(),
+      institution: z.string().nullable(),
+      wallets: z.array(z.string()).openapi({
+        description: "Tracked EVM addresses of the account",
+      }),
       quantity: z.number(),
       value_usd: z.number(),
       value_vnd: z.number(),
       percentage: z.number(),
+      price_usd: z.number(),
+      price_stale: z.boolean(),
       last_updated: z.string().datetime(),
     })
     .openapi({
@@ -149,6 +171,25 @@ export const HoldingsRowSchemaOpenAPI = registry.register(
     }),
 );
 
+export const ConstraintViolationSchemaOpenAPI = registry.register(
+  "ConstraintViolation",
+  z
+    .object({
+      key: z.string(),
+      scope: z.enum(["ASSET", "ASSET_CLASS"]),
+      subject: z.string(),
+      kind: z.enum(["ABOVE_MAX", "BELOW_MIN"]),
+      value_usd: z.number(),
+      current_percent: z.number(),
+      limit_percent: z.number(),
+      gap_usd: z.number(),
+      note: z.string().nullable(),
+    })
+    .openapi({
+      description: "An allocation constraint the holdings break",
+    }),
+);
+
 export const HoldingsSummarySchemaOpenAPI = registry.register(
   "HoldingsSummary",
   z
@@ -161,8 +202,16 @@ export const HoldingsSummarySchemaOpenAPI = registry.register(
           percentage: z.number(),
         }),
       ),
+      by_institution: z.record(
+        z.object({
+          value_usd: z.number(),
+          value_vnd: z.number(),
+          percentage: z.number(),
+        }),
+      ),
       total_value_usd: z.number(),
       total_value_vnd: z.number(),
+      constraint_violations: z.array(ConstraintViolationSchemaOpenAPI),
       last_updated: z.string().datetime(),
     })
     .openapi({
@@ -177,6 +226,9 @@ export const VaultSchemaOpenAPI = registry.register(
       name: z.string(),
       status: z.enum(["ACTIVE", "CLOSED"]),
       createdAt: z.string().datetime(),
+      tags: z.array(z.string()).optional(),
+      account: z.string().optional(),
+      benchmark: z.string().optional(),
     })
     .openapi({
       description: "A vault for organizing funds",
@@ -195,6 +247,9 @@ export const VaultEntrySchemaOpenAPI = registry.register(
       at: z.string().datetime(),
       account: z.string().optional(),
       note: z.string().optional(),
+      lots: z
+        .array(z.object({ lot: z.string(), amount: z.number() }))
+        .optional(),
     })
     .openapi({
       description: "A vault deposit or withdrawal",
@@ -320,7 +375,7 @@ export const ExportDataSchemaOpenAPI = registry.register(
   "ExportData",
   z
     .object({
-      version: z.number(),
+      version: z.number().openapi({ description: "Backup format version" }),
       exported_at: z.string().datetime(),
       transactions: z.array(TransactionSchemaOpenAPI),
       vaults: z.array(
@@ -354,6 +409,9 @@ export const ExportDataSchemaOpenAPI = registry.register(
           borrowing: BorrowingSettingsSchemaOpenAPI,
         })
         .optional(),
+      checksums: z.record(z.string()).optional().openapi({
+        description: "SHA-256 per section, checked on import",
+      }),
     })
     .openapi({
       description: "Complete export of all application data",
@@ -362,8 +420,12 @@ export const ExportDataSchemaOpenAPI = registry.register(
 
 export const ImportRequestSchemaOpenAPI = registry.register(
   "ImportRequest",
-  ExportDataSchemaOpenAPI.omit({ version: true, exported_at: true }).openapi({
-    description: "Request to import data",
+  ExportDataSchemaOpenAPI.partial({
+    version: true,
+    exported_at: true,
+  }).openapi({
+    description:
+      "Request to import data; a missing version is read as version 1",
   }),
 );
 
@@ -626,188 +688,6 @@ export function registerRoutes() {
     },
   });
 
-  // Reports endpoints
-  registry.registerPath({
-    method: "get",
-    path: "/api/reports/holdings",
-    summary: "Get portfolio holdings by asset and account",
-    responses: {
-      200: {
-        description: "OK",
-        content: {
-          "application/json": {
-            schema: z.array(HoldingsRowSchemaOpenAPI),
-          },
-        },
-      },
-    },
-  });
-
-  registry.registerPath({
-    method: "get",
-    path: "/api/reports/holdings/summary",
-    summary: "Get holdings summary",
-    responses: {
-      200: {
-        description: "OK",
-        content: {
-          "application/json": {
-            schema: HoldingsSummarySchemaOpenAPI,
-          },
-        },
-      },
-    },
-  });
-
-  // Vaults endpoints
-  registry.registerPath({
-    method: "get",
-    path: "/api/vaults",
-    summary: "List vaults",
-    request: {
-      query: z.object({
-        is_open: z.boolean().optional(),
-        enrich: z.boolean().optional(),
-      }),
-    },
-    responses: {
-      200: {
-        description: "OK",
-        content: {
-          "application/json": {
-            schema: z.array(VaultSchemaOpenAPI),
-          },
-        },
-      },
-    },
-  });
-
-  registry.registerPath({
-    method: "post",
-    path: "/api/vaults",
-    summary: "Create vault",
-    request: {
-      body: {
-        content: {
-          "application/json": {
-            schema: z.object({
-              name: z.string(),
-            }),
-          },
-        },
-      },
-    },
-    responses: {
-      201: {
-        description: "Created",
-        content: {
-          "application/json": {
-            schema: VaultSchemaOpenAPI,
-          },
-        },
-      },
-    },
-  });
-
-  registry.registerPath({
-    method: "get",
-    path: "/api/vaults/{name}",
-    summary: "Get vault by name",
-    request: {
-      params: z.object({
-        name: z.string(),
-      }),
-    },
-    responses: {
-      200: {
-        description: "OK",
-        content: {
-          "application/json": {
-            schema: VaultSchemaOpenAPI,
-          },
-        },
-      },
-      404: { description: "Not found" },
-    },
-  });
-
-  registry.registerPath({
-    method: "get",
-    path: "/api/vaults/{name}/transactions",
-    summary: "List vault entries",
-    request: {
-      params: z.object({
-        name: z.string(),
-      }),
-    },
-    responses: {
-      200: {
-        description: "OK",
-        content: {
-          "application/json": {
-            schema: z.array(VaultEntrySchemaOpenAPI),
-          },
-        },
-      },
-    },
-  });
-
-  registry.registerPath({
-    method: "post",
-    path: "/api/vaults/{name}/deposit",
-    summary: "Deposit to vault",
-    request: {
-      params: z.object({
-        name: z.string(),
-      }),
-      body: {
-        content: {
-          "application/json": {
-            schema: z.object({
-              asset: AssetSchema,
-              amount: z.number().positive(),
-              at: z.string().datetime().optional(),
-              account: z.string().optional(),
-              note: z.string().optional(),
-              affect_account: z.boolean().optional(),
-            }),
-          },
-        },
-      },
-    },
-    responses: {
-      201: { description: "Created" },
-    },
-  });
-
-  registry.registerPath({
-    method: "post",
-    path: "/api/vaults/{name}/withdraw",
-    summary: "Withdraw from vault",
-    request: {
-      params: z.object({
-        name: z.string(),
-      }),
-      body: {
-        content: {
-          "application/json": {
-            schema: z.object({
-              asset: AssetSchema,
-              amount: z.number().positive(),
-              at: z.string().datetime().optional(),
-              account: z.string().optional(),
-              note: z.string().optional(),
-              affect_account: z.boolean().optional(),
-            }),
-          },
-        },
-      },
-    },
-    responses: {
-      201: { description: "Created" },
-    },
-  });
-
   // Admin - Export/Import endpoints
   registry.registerPath({
     method: "get",
@@ -1,10 +1,12 @@
 import { generateOpenAPIDocument } from "./openapi-registry";
+import { registerHandlerPaths } from "./openapi-paths";
 
 // Lazy-load the spec to avoid initialization issues
 let _cachedSpec: any = null;
 
 export function getOpenapiSpec() {
   if (!_cachedSpec) {
+    registerHandlerPaths();
     _cachedSpec = generateOpenAPIDocument();
   }
   return _cachedSpec;
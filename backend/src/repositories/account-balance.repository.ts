@@ -0,0 +1,231 @@
+import {
+  AccountBalance,
+  Asset,
+  Transaction,
+  assetKey,
+  unitsIn,
+} from "../types";
+import { readStore, writeStore } from "./base.repository";
+import {
+  IAccountBalanceRepository,
+  ITransactionRepository,
+} from "./repository.interface";
+import {
+  BaseDbRepository,
+  accountBalanceToRow,
+  rowToAccountBalance,
+} from "./base-db.repository";
+import { logger } from "../utils/logger";
+
+function byAccountThenAsset(a: AccountBalance, b: AccountBalance): number {
+  return (
+    a.account.toLowerCase().localeCompare(b.account.toLowerCase()) ||
+    assetKey(a.asset).localeCompare(assetKey(b.asset))
+  );
+}
+
+// JSON-based implementation
+export class AccountBalanceRepositoryJson
+  implements IAccountBalanceRepository
+{
+  findAll(): AccountBalance[] {
+    return [...readStore().accountBalances].sort(byAccountThenAsset);
+  }
+
+  findByAccount(account: string): AccountBalance[] {
+    const name = account.toLowerCase();
+    return this.findAll().filter((b) => b.account.toLowerCase() === name);
+  }
+
+  apply(
+    account: string,
+    asset: Asset,
+    quantity: number,
+    count: number,
+    at?: string,
+  ): void {
+    const store = readStore();
+    const name = account.toLowerCase();
+    const key = assetKey(asset);
+    let row = store.accountBalances.find(
+      (b) => b.account.toLowerCase() === name && assetKey(b.asset) === key,
+    );
+    if (!row) {
+      row = { account, asset, quantity: 0, transactionCount: 0 };
+      store.accountBalances.push(row);
+    }
+    row.quantity += quantity;
+    row.transactionCount += count;
+    if (at && (!row.lastTransactionAt || at > row.lastTransactionAt)) {
+      row.lastTransactionAt = at;
+    }
+    writeStore(store);
+  }
+
+  replaceAll(balances: AccountBalance[]): void {
+    const store = readStore();
+    store.accountBalances = balances;
+    writeStore(store);
+  }
+}
+
+// Database-based implementation
+export class AccountBalanceRepositoryDb
+  extends BaseDbRepository
+  implements IAccountBalanceRepository
+{
+  findAll(): AccountBalance[] {
+    return this.findMany(
+      `SELECT * FROM account_balances
+       ORDER BY account, asset_type, asset_symbol`,
+      [],
+      rowToAccountBalance,
+    );
+  }
+
+  findByAccount(account: string): AccountBalance[] {
+    return this.findMany(
+      `SELECT * FROM account_balances WHERE account = ?
+       ORDER BY asset_type, asset_symbol`,
+      [account],
+      rowToAccountBalance,
+    );
+  }
+
+  apply(
+    account: string,
+    asset: Asset,
+    quantity: number,
+    count: number,
+    at?: string,
+  ): void {
+    this.execute(
+      `INSERT INTO account_balances (
+        account, asset_type, asset_symbol, quantity, transaction_count,
+        last_transaction_at
+      ) VALUES (?, ?, ?, ?, ?, ?)
+      ON CONFLICT(account, asset_type, asset_symbol) DO UPDATE SET
+        quantity = quantity + excluded.quantity,
+        transaction_count = transaction_count + excluded.transaction_count,
+        last_transaction_at = MAX(
+          COALESCE(last_transaction_at, ''),
+          COALESCE(excluded.last_transaction_at, '')
+        )`,
+      [account, asset.type, asset.symbol, quantity, count, at ?? null],
+    );
+  }
+
+  replaceAll(balances: AccountBalance[]): void {
+    this.db.transaction(() => {
+      this.execute("DELETE FROM account_balances", []);
+      for (const b of balances) {
+        const row = accountBalanceToRow(b);
+        this.execute(
+          `INSERT INTO account_balances (
+            account, asset_type, asset_symbol, quantity, transaction_count,
+            last_transaction_at
+          ) VALUES (?, ?, ?, ?, ?, ?)`,
+          [
+            row.account,
+            row.asset_type,
+            row.asset_symbol,
+            row.quantity,
+            row.transaction_count,
+            row.last_transaction_at,
+          ],
+        );
+      }
+    })();
+  }
+}
+
+/** Balances of `transactions`, one per account and asset. */
+export function computeBalances(
+  transactions: Transaction[],
+): AccountBalance[] {
+  const balances = new Map<string, AccountBalance>();
+  for (const t of transactions) {
+    if (!t.account) continue;
+    const key = `${t.account.toLowerCase()}|${assetKey(t.asset)}`;
+    const b = balances.get(key) || {
+      account: t.account,
+      asset: t.asset,
+      quantity: 0,
+      transactionCount: 0,
+    };
+    b.quantity += unitsIn(t);
+    b.transactionCount++;
+    if (!b.lastTransactionAt || t.createdAt > b.lastTransactionAt) {
+      b.lastTransactionAt = t.createdAt;
+    }
+    balances.set(key, b);
+  }
+  return [...balances.values()].sort(byAccountThenAsset);
+}
+
+/**
+ * The transaction repository keeping `balances()` in step with every
+ * write, the way withAudit records them. The write happens first; a
+ * balance update that fails is logged, and the next rebuild repairs it.
+ */
+export function withAccountBalances(
+  repo: ITransactionRepository,
+  balances: () => IAccountBalanceRepository,
+): ITransactionRepository {
+  const wrapped: ITransactionRepository = Object.create(repo);
+  const apply = (t: Transaction | undefined, sign: 1 | -1) => {
+    if (!t?.account) return;
+    try {
+      balances().apply(
+        t.account,
+        t.asset,
+        sign * unitsIn(t),
+        sign,
+        sign > 0 ? t.createdAt : undefined,
+      );
+    } catch (err: any) {
+      logger.warn(
+        { transactionId: t.id, error: err?.message },
+        "Account balance update failed",
+      );
+    }
+  };
+
+  wrapped.create = (tx: Transaction) => {
+    const created = repo.create(tx);
+    apply(created, 1);
+    return created;
+  };
+  wrapped.createMany = (txs: Transaction[]) => {
+    const created = repo.createMany(txs);
+    for (const t of created) apply(t, 1);
+    return created;
+  };
+  wrapped.update = (id: string, updates: Partial<Transaction>) => {
+    const before = repo.findById(id);
+    const updated = repo.update(id, updates);
+    if (updated) {
+      apply(before, -1);
+      apply(updated, 1);
+    }
+    return updated;
+  };
+  wrapped.delete = (id: string) => {
+    const before = repo.findById(id); // undefined once soft-deleted
+    const ok = repo.delete(id);
+    if (ok) apply(before, -1);
+    return ok;
+  };
+  wrapped.softDelete = (id: string, deletedAt: string) => {
+    const before = repo.findById(id);
+    const ok = repo.softDelete(id, deletedAt);
+    if (ok) apply(before, -1);
+    return ok;
+  };
+  wrapped.restore = (id: string) => {
+    const restored = repo.restore(id);
+    apply(restored, 1);
+    return restored;
+  };
+  return wrapped;
+}
@@ -0,0 +1,143 @@
+import { ActionIntent } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IActionIntentRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  actionIntentToRow,
+  rowToActionIntent,
+} from "./base-db.repository";
+
+const newestFirst = (a: ActionIntent, b: ActionIntent) =>
+  b.createdAt.localeCompare(a.createdAt);
+
+// JSON-based implementation
+export class ActionIntentRepositoryJson implements IActionIntentRepository {
+  findAll(): ActionIntent[] {
+    return [...readStore().actionIntents].sort(newestFirst);
+  }
+
+  findById(id: string): ActionIntent | undefined {
+    return readStore().actionIntents.find((i) => i.id === id);
+  }
+
+  findByClientRef(clientRef: string): ActionIntent | undefined {
+    return readStore().actionIntents.find((i) => i.clientRef === clientRef);
+  }
+
+  create(intent: ActionIntent): ActionIntent {
+    const store = readStore();
+    store.actionIntents.push(intent);
+    writeStore(store);
+    return intent;
+  }
+
+  update(
+    id: string,
+    updates: Partial<ActionIntent>,
+  ): ActionIntent | undefined {
+    const store = readStore();
+    const index = store.actionIntents.findIndex((i) => i.id === id);
+    if (index === -1) return undefined;
+
+    store.actionIntents[index] = {
+      ...store.actionIntents[index],
+      ...updates,
+      id,
+    };
+    writeStore(store);
+    return store.actionIntents[index];
+  }
+}
+
+// Database-based implementation
+export class ActionIntentRepositoryDb
+  extends BaseDbRepository
+  implements IActionIntentRepository
+{
+  findAll(): ActionIntent[] {
+    return this.findMany(
+      "SELECT * FROM action_intents ORDER BY created_at DESC",
+      [],
+      rowToActionIntent,
+    );
+  }
+
+  findById(id: string): ActionIntent | undefined {
+    return this.findOne(
+      "SELECT * FROM action_intents WHERE id = ?",
+      [id],
+      rowToActionIntent,
+    );
+  }
+
+  findByClientRef(clientRef: string): ActionIntent | undefined {
+    return this.findOne(
+      "SELECT * FROM action_intents WHERE client_ref = ?",
+      [clientRef],
+      rowToActionIntent,
+    );
+  }
+
+  create(intent: ActionIntent): ActionIntent {
+    const row = actionIntentToRow(intent);
+    this.execute(
+      `INSERT INTO action_intents (
+        id, action, params, status, client_ref, external_ref,
+        transaction_ids, vault_entries, response, error, cancel_reason,
+        created_at, updated_at, expires_at, confirmed_at, cancelled_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.action,
+        row.params,
+        row.status,
+        row.client_ref,
+        row.external_ref,
+        row.transaction_ids,
+        row.vault_entries,
+        row.response,
+        row.error,
+        row.cancel_reason,
+        row.created_at,
+        row.updated_at,
+        row.expires_at,
+        row.confirmed_at,
+        row.cancelled_at,
+      ],
+    );
+    return intent;
+  }
+
+  update(
+    id: string,
+    updates: Partial<ActionIntent>,
+  ): ActionIntent | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = actionIntentToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE action_intents SET
+        params = ?, status = ?, external_ref = ?, transaction_ids = ?,
+        vault_entries = ?, response = ?, error = ?, cancel_reason = ?,
+        updated_at = ?, expires_at = ?, confirmed_at = ?, cancelled_at = ?
+      WHERE id = ?`,
+      [
+        row.params,
+        row.status,
+        row.external_ref,
+        row.transaction_ids,
+        row.vault_entries,
+        row.response,
+        row.error,
+        row.cancel_reason,
+        row.updated_at,
+        row.expires_at,
+        row.confirmed_at,
+        row.cancelled_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+}
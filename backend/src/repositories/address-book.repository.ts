@@ -0,0 +1,140 @@
+import { AddressBookEntry } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IAddressBookRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToAddressBookEntry,
+  addressBookEntryToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class AddressBookRepositoryJson implements IAddressBookRepository {
+  findAll(): AddressBookEntry[] {
+    return readStore().addressBook;
+  }
+
+  findById(id: string): AddressBookEntry | undefined {
+    return readStore().addressBook.find((e) => e.id === id);
+  }
+
+  findByName(name: string): AddressBookEntry | undefined {
+    const key = name.toLowerCase();
+    return readStore().addressBook.find((e) => e.name.toLowerCase() === key);
+  }
+
+  create(entry: AddressBookEntry): AddressBookEntry {
+    const store = readStore();
+    store.addressBook.push(entry);
+    writeStore(store);
+    return entry;
+  }
+
+  update(
+    id: string,
+    updates: Partial<AddressBookEntry>,
+  ): AddressBookEntry | undefined {
+    const store = readStore();
+    const index = store.addressBook.findIndex((e) => e.id === id);
+    if (index === -1) return undefined;
+
+    store.addressBook[index] = { ...store.addressBook[index], ...updates, id };
+    writeStore(store);
+    return store.addressBook[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.addressBook.length;
+    store.addressBook = store.addressBook.filter((e) => e.id !== id);
+    writeStore(store);
+    return store.addressBook.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class AddressBookRepositoryDb
+  extends BaseDbRepository
+  implements IAddressBookRepository
+{
+  findAll(): AddressBookEntry[] {
+    return this.findMany(
+      "SELECT * FROM address_book ORDER BY name ASC",
+      [],
+      rowToAddressBookEntry,
+    );
+  }
+
+  findById(id: string): AddressBookEntry | undefined {
+    return this.findOne(
+      "SELECT * FROM address_book WHERE id = ?",
+      [id],
+      rowToAddressBookEntry,
+    );
+  }
+
+  findByName(name: string): AddressBookEntry | undefined {
+    return this.findOne(
+      "SELECT * FROM address_book WHERE LOWER(name) = LOWER(?)",
+      [name],
+      rowToAddressBookEntry,
+    );
+  }
+
+  create(entry: AddressBookEntry): AddressBookEntry {
+    const row = addressBookEntryToRow(entry);
+    this.execute(
+      `INSERT INTO address_book (
+        id, name, kind, address, owner, chain, asset, account, note,
+        created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.name,
+        row.kind,
+        row.address,
+        row.owner,
+        row.chain,
+        row.asset,
+        row.account,
+        row.note,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return entry;
+  }
+
+  update(
+    id: string,
+    updates: Partial<AddressBookEntry>,
+  ): AddressBookEntry | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = addressBookEntryToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE address_book SET
+        name = ?, kind = ?, address = ?, owner = ?, chain = ?, asset = ?,
+        account = ?, note = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.kind,
+        row.address,
+        row.owner,
+        row.chain,
+        row.asset,
+        row.account,
+        row.note,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM address_book WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+}
@@ -1,7 +1,7 @@
 import crypto from "crypto";
+import { AdminAccount } from "../types";
 import {
   AdminType,
-  AdminAccount,
   AdminAsset,
   AdminTag,
   PendingAction,
@@ -36,12 +36,12 @@ const DEFAULT_TRANSACTION_TYPES: Array<
 ];
 
 const DEFAULT_ADMIN_ASSETS: Array<
-  Pick<AdminAsset, "symbol" | "name" | "decimals">
+  Pick<AdminAsset, "symbol" | "name" | "decimals" | "kind">
 > = [
-  { symbol: "BTC", name: "Bitcoin", decimals: 8 },
-  { symbol: "ETH", name: "Ethereum", decimals: 18 },
-  { symbol: "USDT", name: "Tether USD", decimals: 6 },
-  { symbol: "VND", name: "Vietnamese Dong", decimals: 0 },
+  { symbol: "BTC", name: "Bitcoin", decimals: 8, kind: "CRYPTO" },
+  { symbol: "ETH", name: "Ethereum", decimals: 18, kind: "CRYPTO" },
+  { symbol: "USDT", name: "Tether USD", decimals: 6, kind: "STABLECOIN" },
+  { symbol: "VND", name: "Vietnamese Dong", decimals: 0, kind: "FIAT" },
 ];
 
 // JSON-based implementation
@@ -109,6 +109,10 @@ export class AdminRepositoryJson implements IAdminRepository {
       id: nextId(store.adminAccounts),
       name: data.name,
       type: data.type ?? "",
+      institution: data.institution || undefined,
+      jurisdiction: data.jurisdiction || undefined,
+      default_asset: data.default_asset || undefined,
+      fx_margin_percent: data.fx_margin_percent || undefined,
       is_active: data.is_active !== false,
       created_at: new Date().toISOString(),
     };
@@ -161,6 +165,8 @@ export class AdminRepositoryJson implements IAdminRepository {
       symbol: data.symbol.toUpperCase(),
       name: data.name ?? "",
       decimals: typeof data.decimals === "number" ? data.decimals : 8,
+      kind: data.kind || undefined,
+      jurisdiction: data.jurisdiction || undefined,
       is_active: data.is_active !== false,
       created_at: new Date().toISOString(),
     };
@@ -267,6 +273,7 @@ export class AdminRepositoryJson implements IAdminRepository {
       symbol: a.symbol.toUpperCase(),
       name: a.name ?? "",
       decimals: typeof a.decimals === "number" ? a.decimals : 8,
+      kind: a.kind,
       is_active: true,
       created_at: now,
     }));
@@ -294,6 +301,10 @@ export class AdminRepositoryDb
       id: row.id,
       name: row.name,
       type: row.type,
+      institution: row.institution || undefined,
+      jurisdiction: row.jurisdiction || undefined,
+      default_asset: row.default_asset || undefined,
+      fx_margin_percent: row.fx_margin_percent || undefined,
       is_active: !!row.is_active,
       created_at: row.created_at,
     };
@@ -305,6 +316,8 @@ export class AdminRepositoryDb
       symbol: row.symbol,
       name: row.name,
       decimals: row.decimals,
+      kind: row.kind || undefined,
+      jurisdiction: row.jurisdiction || undefined,
       is_active: !!row.is_active,
       created_at: row.created_at,
     };
@@ -348,8 +361,8 @@ export class AdminRepositoryDb
     const now = new Date().toISOString();
     for (const [idx, a] of DEFAULT_ADMIN_ASSETS.entries()) {
       this.execute(
-        "INSERT INTO admin_assets (id, symbol, name, decimals, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?)",
-        [idx + 1, a.symbol.toUpperCase(), a.name, a.decimals, 1, now],
+        "INSERT INTO admin_assets (id, symbol, name, decimals, kind, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+        [idx + 1, a.symbol.toUpperCase(), a.name, a.decimals, a.kind, 1, now],
       );
     }
   }
@@ -442,10 +455,14 @@ export class AdminRepositoryDb
 
   createAccount(data: Partial<AdminAccount> & { name: string }): AdminAccount {
     const result = this.execute(
-      "INSERT INTO admin_accounts (name, type, is_active, created_at) VALUES (?, ?, ?, ?)",
+      "INSERT INTO admin_accounts (name, type, institution, jurisdiction, default_asset, fx_margin_percent, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
       [
         data.name,
         data.type ?? "",
+        data.institution || null,
+        data.jurisdiction || null,
+        data.default_asset || null,
+        data.fx_margin_percent || null,
         data.is_active !== false ? 1 : 0,
         new Date().toISOString(),
       ],
@@ -454,6 +471,10 @@ export class AdminRepositoryDb
       id: result.lastInsertRowid as number,
       name: data.name,
       type: data.type ?? "",
+      institution: data.institution || undefined,
+      jurisdiction: data.jurisdiction || undefined,
+      default_asset: data.default_asset || undefined,
+      fx_margin_percent: data.fx_margin_percent || undefined,
       is_active: data.is_active !== false,
       created_at: new Date().toISOString(),
     };
@@ -474,6 +495,22 @@ export class AdminRepositoryDb
       fields.push("type = ?");
       values.push(data.type);
     }
+    if (data.institution !== undefined) {
+      fields.push("institution = ?");
+      values.push(data.institution || null);
+    }
+    if (data.jurisdiction !== undefined) {
+      fields.push("jurisdiction = ?");
+      values.push(data.jurisdiction || null);
+    }
+    if (data.default_asset !== undefined) {
+      fields.push("default_asset = ?");
+      values.push(data.default_asset || null);
+    }
+    if (data.fx_margin_percent !== undefined) {
+      fields.push("fx_margin_percent = ?");
+      values.push(data.fx_margin_percent || null);
+    }
     if (data.is_active !== undefined) {
       fields.push("is_active = ?");
       values.push(data.is_active ? 1 : 0);
@@ -516,11 +553,13 @@ export class AdminRepositoryDb
 
   createAsset(data: Partial<AdminAsset> & { symbol: string }): AdminAsset {
     const result = this.execute(
-      "INSERT INTO admin_assets (symbol, name, decimals, is_active, created_at) VALUES (?, ?, ?, ?, ?)",
+      "INSERT INTO admin_assets (symbol, name, decimals, kind, jurisdiction, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
       [
         data.symbol.toUpperCase(),
         data.name ?? "",
         typeof data.decimals === "number" ? data.decimals : 8,
+        data.kind || null,
+        data.jurisdiction || null,
         data.is_active !== false ? 1 : 0,
         new Date().toISOString(),
       ],
@@ -530,6 +569,8 @@ export class AdminRepositoryDb
       symbol: data.symbol.toUpperCase(),
       name: data.name ?? "",
       decimals: typeof data.decimals === "number" ? data.decimals : 8,
+      kind: data.kind || undefined,
+      jurisdiction: data.jurisdiction || undefined,
       is_active: data.is_active !== false,
       created_at: new Date().toISOString(),
     };
@@ -551,6 +592,14 @@ export class AdminRepositoryDb
       fields.push("decimals = ?");
       values.push(data.decimals);
     }
+    if (data.kind !== undefined) {
+      fields.push("kind = ?");
+      values.push(data.kind || null);
+    }
+    if (data.jurisdiction !== undefined) {
+      fields.push("jurisdiction = ?");
+      values.push(data.jurisdiction || null);
+    }
     if (data.is_active !== undefined) {
       fields.push("is_active = ?");
       values.push(data.is_active ? 1 : 0);
@@ -0,0 +1,259 @@
+import {
+  AuditAction,
+  AuditEntity,
+  AuditEntry,
+  AuditFilter,
+  Page,
+  PageRequest,
+} from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IAuditRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  auditEntryToRow,
+  rowToAuditEntry,
+} from "./base-db.repository";
+import { decodeCursor, encodeCursor } from "../utils/cursor.util";
+import { currentActor } from "../utils/audit-context.util";
+import { logger } from "../utils/logger";
+
+function matches(e: AuditEntry, filter: AuditFilter): boolean {
+  return (
+    (!filter.entity || e.entity === filter.entity) &&
+    (!filter.entityId || e.entityId === filter.entityId) &&
+    (!filter.actor || e.actor === filter.actor) &&
+    (!filter.start || e.at >= filter.start) &&
+    (!filter.end || e.at <= filter.end)
+  );
+}
+
+// The JSON store keeps the newest entries only, as every read of the
+// store loads the log; the database keeps them all
+export const JSON_AUDIT_LOG_LIMIT = 5000;
+
+// JSON-based implementation
+export class AuditRepositoryJson implements IAuditRepository {
+  append(entry: Omit<AuditEntry, "id">): AuditEntry {
+    return this.appendMany([entry])[0];
+  }
+
+  // One read and write of the store for the whole batch
+  appendMany(entries: Omit<AuditEntry, "id">[]): AuditEntry[] {
+    if (!entries.length) return [];
+    const store = readStore();
+    let id =
+      store.auditNextId ??
+      store.auditLog.reduce((m, e) => Math.max(m, e.id), 0) + 1;
+    const created = entries.map((entry) => ({ ...entry, id: id++ }));
+    store.auditLog.push(...created);
+    if (store.auditLog.length > JSON_AUDIT_LOG_LIMIT) {
+      store.auditLog = store.auditLog.slice(-JSON_AUDIT_LOG_LIMIT);
+    }
+    store.auditNextId = id;
+    writeStore(store);
+    return created;
+  }
+
+  findPage(filter: AuditFilter, page: PageRequest): Page<AuditEntry> {
+    const sorted = readStore()
+      .auditLog.filter((e) => matches(e, filter))
+      .sort((a, b) => b.at.localeCompare(a.at) || b.id - a.id);
+    let from = 0;
+    if (page.cursor) {
+      const { at, id } = decodeCursor(page.cursor);
+      from = sorted.findIndex(
+        (e) => e.at < at || (e.at === at && e.id < Number(id)),
+      );
+      if (from === -1) from = sorted.length;
+    }
+    const items = sorted.slice(from, from + page.limit);
+    const last = items[items.length - 1];
+    return {
+      items,
+      nextCursor:
+        from + page.limit < sorted.length
+          ? encodeCursor(last.at, last.id)
+          : undefined,
+    };
+  }
+}
+
+// Database-based implementation
+export class AuditRepositoryDb
+  extends BaseDbRepository
+  implements IAuditRepository
+{
+  append(entry: Omit<AuditEntry, "id">): AuditEntry {
+    const row = auditEntryToRow(entry);
+    const { lastInsertRowid } = this.execute(
+      `INSERT INTO audit_log (
+        at, entity, entity_id, action, actor, before, after
+      ) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.at,
+        row.entity,
+        row.entity_id,
+        row.action,
+        row.actor,
+        row.before,
+        row.after,
+      ],
+    );
+    return { ...entry, id: lastInsertRowid };
+  }
+
+  appendMany(entries: Omit<AuditEntry, "id">[]): AuditEntry[] {
+    return this.db.transaction(() => entries.map((e) => this.append(e)))();
+  }
+
+  findPage(filter: AuditFilter, page: PageRequest): Page<AuditEntry> {
+    const where: string[] = [];
+    const args: (string | number)[] = [];
+    const add = (sql: string, ...values: (string | number)[]) => {
+      where.push(sql);
+      args.push(...values);
+    };
+    if (filter.entity) add("entity = ?", filter.entity);
+    if (filter.entityId) add("entity_id = ?", filter.entityId);
+    if (filter.actor) add("actor = ?", filter.actor);
+    if (filter.start) add("at >= ?", filter.start);
+    if (filter.end) add("at <= ?", filter.end);
+    if (page.cursor) {
+      const { at, id } = decodeCursor(page.cursor);
+      add("(at < ? OR (at = ? AND id < ?))", at, at, Number(id));
+    }
+    // One extra row tells whether another page follows
+    const rows: AuditEntry[] = this.findMany(
+      `SELECT * FROM audit_log
+       ${where.length ? `WHERE ${where.join(" AND ")}` : ""}
+       ORDER BY at DESC, id DESC
+       LIMIT ?`,
+      [...args, page.limit + 1],
+      rowToAuditEntry,
+    );
+    const items = rows.slice(0, page.limit);
+    const last = items[items.length - 1];
+    return {
+      items,
+      nextCursor:
+        rows.length > page.limit ? encodeCursor(last.at, last.id) : undefined,
+    };
+  }
+}
+
+// Which methods of a repository write one kind of record. `find` reads a
+// record by the id the update/remove methods take as their first argument
+export interface AuditSpec<R> {
+  entity: AuditEntity;
+  find: (repo: R, id: any) => unknown;
+  idOf: (record: any) => string | number;
+  create?: (keyof R)[]; // return the created record (or records)
+  update?: (keyof R)[]; // (id, ...) => the updated record, if found
+  remove?: (keyof R)[]; // (id) => whether it was deleted
+  // Deletes returning a count (or whether one went); `find` lists the
+  // records they remove
+  removeMany?: { method: keyof R; find: (repo: R, ...args: any[]) => any[] }[];
+}
+
+/**
+ * `repo` recording every write of its audited methods through `append`,
+ * the way withTransactionEvents publishes events. A bulk write is
+ * recorded as one batch. The write happens first; a failure to record it
+ * is logged and never undoes the write.
+ */
+export function withAudit<R extends object>(
+  repo: R,
+  specs: AuditSpec<R>[],
+  append: (entries: Omit<AuditEntry, "id">[]) => void,
+): R {
+  const target = repo as any;
+  const wrapped = Object.create(repo);
+
+  const record = (
+    entity: AuditEntity,
+    action: AuditAction,
+    changes: Array<{ id: string | number; before: unknown; after: unknown }>,
+  ) => {
+    if (!changes.length) return;
+    const at = new Date().toISOString();
+    const actor = currentActor();
+    try {
+      append(
+        changes.map(({ id, before, after }) => ({
+          at,
+          entity,
+          entityId: String(id),
+          action,
+          actor,
+          before,
+          after,
+        })),
+      );
+    } catch (err: any) {
+      logger.warn(
+        { entity, count: changes.length, action, error: err?.message },
+        "Audit log write failed",
+      );
+    }
+  };
+
+  for (const spec of specs) {
+    for (const m of spec.create || []) {
+      wrapped[m] = (...args: any[]) => {
+        const result = target[m](...args);
+        const rows: any[] = Array.isArray(result) ? result : [result];
+        record(
+          spec.entity,
+          "create",
+          rows
+            .filter(Boolean)
+            .map((row) => ({
+              id: spec.idOf(row),
+              before: undefined,
+              after: row,
+            })),
+        );
+        return result;
+      };
+    }
+    for (const m of spec.update || []) {
+      wrapped[m] = (id: any, ...args: any[]) => {
+        const before = spec.find(repo, id);
+        const result = target[m](id, ...args);
+        if (result) {
+          record(spec.entity, "update", [{ id, before, after: result }]);
+        }
+        return result;
+      };
+    }
+    for (const m of spec.remove || []) {
+      wrapped[m] = (id: any, ...args: any[]) => {
+        const before = spec.find(repo, id);
+        const ok = target[m](id, ...args);
+        if (ok && before) {
+          record(spec.entity, "delete", [{ id, before, after: undefined }]);
+        }
+        return ok;
+      };
+    }
+    for (const { method, find } of spec.removeMany || []) {
+      wrapped[method] = (...args: any[]) => {
+        const before: any[] = find(repo, ...args);
+        const count = target[method](...args);
+        if (count > 0) {
+          record(
+            spec.entity,
+            "delete",
+            before.map((row) => ({
+              id: spec.idOf(row),
+              before: row,
+              after: undefined,
+            })),
+          );
+        }
+        return count;
+      };
+    }
+  }
+  return wrapped as R;
+}
@@ -1,10 +1,30 @@
-import { getConnection } from "../database/connection";
+import { getConnection, prepareCached } from "../database/connection";
 import {
   Transaction,
   Vault,
   VaultEntry,
   LoanAgreement,
   BorrowingAgreement,
+  Project,
+  RegistryItem,
+  FixedIncomeInstrument,
+  OptionPosition,
+  VestingGrant,
+  VestEvent,
+  AddressBookEntry,
+  AllocationGlidepath,
+  DepositRate,
+  TrashItem,
+  ReportSubscription,
+  RecurringTransaction,
+  Budget,
+  Goal,
+  Webhook,
+  YearClose,
+  MonthReview,
+  AuditEntry,
+  AccountBalance,
+  ActionIntent,
 } from "../types";
 import { coerceNumber } from "../utils/number.util";
 
@@ -19,6 +39,7 @@ export function rowToTransaction(row: any): Transaction {
     },
     amount: row.amount,
     createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
     account: row.account,
     note: row.note,
     category: row.category,
@@ -30,6 +51,26 @@ export function rowToTransaction(row: any): Transaction {
     sourceRef: row.source_ref,
     rate: JSON.parse(row.rate),
     usdAmount: row.usd_amount,
+    reimbursable: row.reimbursable ? true : undefined,
+    reimbursesId: row.reimburses_id || undefined,
+    projectId: row.project_id || undefined,
+    chain: row.chain || undefined,
+    card: row.card || undefined,
+    feeUSD: row.fee_usd ?? undefined,
+    place: row.place || undefined,
+    latitude: row.latitude ?? undefined,
+    longitude: row.longitude ?? undefined,
+    reviewedAt: row.reviewed_at || undefined,
+    member: row.member || undefined,
+    deletedAt: row.deleted_at || undefined,
+    accountId: row.account_id ?? undefined,
+    assetId: row.asset_id ?? undefined,
+    internalFlow:
+      row.internal_flow === null || row.internal_flow === undefined
+        ? undefined
+        : !!row.internal_flow,
+    fx: row.fx ? JSON.parse(row.fx) : undefined,
+    fxMargin: row.fx_margin ?? undefined,
   };
 
   if (row.repay_direction) {
@@ -59,6 +100,25 @@ export function transactionToRow(tx: Transaction): any {
     source_ref: tx.sourceRef,
     rate: JSON.stringify(tx.rate),
     usd_amount: tx.usdAmount,
+    reimbursable: tx.reimbursable ? 1 : 0,
+    reimburses_id: tx.reimbursesId ?? null,
+    project_id: tx.projectId ?? null,
+    chain: tx.chain ?? null,
+    updated_at: tx.updatedAt ?? null,
+    card: tx.card ?? null,
+    fee_usd: tx.feeUSD ?? null,
+    place: tx.place ?? null,
+    latitude: tx.latitude ?? null,
+    longitude: tx.longitude ?? null,
+    reviewed_at: tx.reviewedAt ?? null,
+    member: tx.member ?? null,
+    deleted_at: tx.deletedAt ?? null,
+    account_id: tx.accountId ?? null,
+    asset_id: tx.assetId ?? null,
+    internal_flow:
+      tx.internalFlow === undefined ? null : Number(tx.internalFlow),
+    fx: tx.fx ? JSON.stringify(tx.fx) : null,
+    fx_margin: tx.fxMargin ?? null,
   };
 
   if ((tx as any).direction) {
@@ -74,6 +134,9 @@ export function rowToVault(row: any): Vault {
     name: row.name,
     status: row.status,
     createdAt: row.created_at,
+    tags: row.tags ? JSON.parse(row.tags) : undefined,
+    account: row.account || undefined,
+    benchmark: row.benchmark || undefined,
   };
 }
 
@@ -83,6 +146,9 @@ export function vaultToRow(vault: Vault): any {
     name: vault.name,
     status: vault.status,
     created_at: vault.createdAt,
+    tags: vault.tags ? JSON.stringify(vault.tags) : null,
+    account: vault.account || null,
+    benchmark: vault.benchmark || null,
   };
 }
 
@@ -100,6 +166,7 @@ export function rowToVaultEntry(row: any): VaultEntry {
     at: row.at,
     account: row.account,
     note: row.note,
+    lots: row.lots ? JSON.parse(row.lots) : undefined,
   };
 }
 
@@ -115,6 +182,701 @@ export function vaultEntryToRow(entry: VaultEntry): any {
     at: entry.at,
     account: entry.account,
     note: entry.note,
+    lots: entry.lots?.length ? JSON.stringify(entry.lots) : null,
+  };
+}
+
+// Helper to convert SQLite row to Project
+export function rowToProject(row: any): Project {
+  return {
+    id: row.id,
+    name: row.name,
+    kind: row.kind,
+    startAt: row.start_at || undefined,
+    endAt: row.end_at || undefined,
+    note: row.note || undefined,
+    status: row.status,
+    createdAt: row.created_at,
+  };
+}
+
+// Helper to convert Project to SQLite row
+export function projectToRow(project: Project): any {
+  return {
+    id: project.id,
+    name: project.name,
+    kind: project.kind,
+    start_at: project.startAt ?? null,
+    end_at: project.endAt ?? null,
+    note: project.note ?? null,
+    status: project.status,
+    created_at: project.createdAt,
+  };
+}
+
+// Helper to convert SQLite row to RegistryItem
+export function rowToRegistryItem(row: any): RegistryItem {
+  return {
+    id: row.id,
+    kind: row.kind,
+    name: row.name,
+    transactionId: row.transaction_id || undefined,
+    asset: row.asset_symbol
+      ? { type: row.asset_type, symbol: row.asset_symbol }
+      : undefined,
+    amount: row.amount ?? undefined,
+    cadence: row.cadence || undefined,
+    nextRenewalAt: row.next_renewal_at || undefined,
+    cancelBy: row.cancel_by || undefined,
+    warrantyExpiresAt: row.warranty_expires_at || undefined,
+    status: row.status,
+    note: row.note || undefined,
+    lastNotifiedAt: row.last_notified_at || undefined,
+    createdAt: row.created_at,
+  };
+}
+
+// Helper to convert RegistryItem to SQLite row
+export function registryItemToRow(item: RegistryItem): any {
+  return {
+    id: item.id,
+    kind: item.kind,
+    name: item.name,
+    transaction_id: item.transactionId ?? null,
+    asset_type: item.asset?.type ?? null,
+    asset_symbol: item.asset?.symbol ?? null,
+    amount: item.amount ?? null,
+    cadence: item.cadence ?? null,
+    next_renewal_at: item.nextRenewalAt ?? null,
+    cancel_by: item.cancelBy ?? null,
+    warranty_expires_at: item.warrantyExpiresAt ?? null,
+    status: item.status,
+    note: item.note ?? null,
+    last_notified_at: item.lastNotifiedAt ?? null,
+    created_at: item.createdAt,
+  };
+}
+
+// Helper to convert SQLite row to FixedIncomeInstrument
+export function rowToFixedIncome(row: any): FixedIncomeInstrument {
+  return {
+    id: row.id,
+    kind: row.kind,
+    name: row.name,
+    issuer: row.issuer || undefined,
+    asset: { type: row.asset_type, symbol: row.asset_symbol },
+    faceValue: row.face_value,
+    couponRate: row.coupon_rate,
+    couponFrequency: row.coupon_frequency,
+    startAt: row.start_at,
+    maturityAt: row.maturity_at,
+    account: row.account || undefined,
+    nextCouponAt: row.next_coupon_at || undefined,
+    status: row.status,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+  };
+}
+
+// Helper to convert FixedIncomeInstrument to SQLite row
+export function fixedIncomeToRow(item: FixedIncomeInstrument): any {
+  return {
+    id: item.id,
+    kind: item.kind,
+    name: item.name,
+    issuer: item.issuer ?? null,
+    asset_type: item.asset.type,
+    asset_symbol: item.asset.symbol,
+    face_value: item.faceValue,
+    coupon_rate: item.couponRate,
+    coupon_frequency: item.couponFrequency,
+    start_at: item.startAt,
+    maturity_at: item.maturityAt,
+    account: item.account ?? null,
+    next_coupon_at: item.nextCouponAt ?? null,
+    status: item.status,
+    note: item.note ?? null,
+    created_at: item.createdAt,
+  };
+}
+
+// Helper to convert SQLite row to OptionPosition
+export function rowToOption(row: any): OptionPosition {
+  return {
+    id: row.id,
+    underlying: { type: row.underlying_type, symbol: row.underlying_symbol },
+    optionType: row.option_type,
+    side: row.side,
+    contracts: row.contracts,
+    multiplier: row.multiplier,
+    strike: row.strike,
+    expiry: row.expiry,
+    premium: row.premium,
+    premiumAsset: {
+      type: row.premium_asset_type,
+      symbol: row.premium_asset_symbol,
+    },
+    premiumUSD: row.premium_usd,
+    account: row.account,
+    status: row.status,
+    openedAt: row.opened_at,
+    settledAt: row.settled_at || undefined,
+    settlementPrice: row.settlement_price ?? undefined,
+    realizedPnLUSD: row.realized_pnl_usd ?? undefined,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+  };
+}
+
+// Helper to convert OptionPosition to SQLite row
+export function optionToRow(p: OptionPosition): any {
+  return {
+    id: p.id,
+    underlying_type: p.underlying.type,
+    underlying_symbol: p.underlying.symbol,
+    option_type: p.optionType,
+    side: p.side,
+    contracts: p.contracts,
+    multiplier: p.multiplier,
+    strike: p.strike,
+    expiry: p.expiry,
+    premium: p.premium,
+    premium_asset_type: p.premiumAsset.type,
+    premium_asset_symbol: p.premiumAsset.symbol,
+    premium_usd: p.premiumUSD,
+    account: p.account,
+    status: p.status,
+    opened_at: p.openedAt,
+    settled_at: p.settledAt ?? null,
+    settlement_price: p.settlementPrice ?? null,
+    realized_pnl_usd: p.realizedPnLUSD ?? null,
+    note: p.note ?? null,
+    created_at: p.createdAt,
+  };
+}
+
+// Helper to convert SQLite row to VestingGrant
+export function rowToVestingGrant(row: any): VestingGrant {
+  return {
+    id: row.id,
+    kind: row.kind,
+    employer: row.employer,
+    asset: { type: row.asset_type, symbol: row.asset_symbol },
+    cashAsset: { type: row.cash_asset_type, symbol: row.cash_asset_symbol },
+    account: row.account,
+    totalUnits: row.total_units,
+    grantAt: row.grant_at,
+    vestStartAt: row.vest_start_at,
+    cliffMonths: row.cliff_months,
+    vestingMonths: row.vesting_months,
+    frequencyMonths: row.frequency_months,
+    withholdingRate: row.withholding_rate,
+    discount: row.discount,
+    status: row.status,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+  };
+}
+
+// Helper to convert VestingGrant to SQLite row
+export function vestingGrantToRow(g: VestingGrant): any {
+  return {
+    id: g.id,
+    kind: g.kind,
+    employer: g.employer,
+    asset_type: g.asset.type,
+    asset_symbol: g.asset.symbol,
+    cash_asset_type: g.cashAsset.type,
+    cash_asset_symbol: g.cashAsset.symbol,
+    account: g.account,
+    total_units: g.totalUnits,
+    grant_at: g.grantAt,
+    vest_start_at: g.vestStartAt,
+    cliff_months: g.cliffMonths,
+    vesting_months: g.vestingMonths,
+    frequency_months: g.frequencyMonths,
+    withholding_rate: g.withholdingRate,
+    discount: g.discount,
+    status: g.status,
+    note: g.note ?? null,
+    created_at: g.createdAt,
+  };
+}
+
+// Helper to convert SQLite row to VestEvent
+export function rowToVestEvent(row: any): VestEvent {
+  return {
+    id: row.id,
+    grantId: row.grant_id,
+    vestAt: row.vest_at,
+    units: row.units,
+    status: row.status,
+    fmv: row.fmv ?? undefined,
+    purchasePrice: row.purchase_price ?? undefined,
+    withheldUnits: row.withheld_units ?? undefined,
+    netUnits: row.net_units ?? undefined,
+    incomeUSD: row.income_usd ?? undefined,
+    transferId: row.transfer_id || undefined,
+  };
+}
+
+// Helper to convert VestEvent to SQLite row
+export function vestEventToRow(e: VestEvent): any {
+  return {
+    id: e.id,
+    grant_id: e.grantId,
+    vest_at: e.vestAt,
+    units: e.units,
+    status: e.status,
+    fmv: e.fmv ?? null,
+    purchase_price: e.purchasePrice ?? null,
+    withheld_units: e.withheldUnits ?? null,
+    net_units: e.netUnits ?? null,
+    income_usd: e.incomeUSD ?? null,
+    transfer_id: e.transferId ?? null,
+  };
+}
+
+// Helper to convert SQLite row to AddressBookEntry
+export function rowToAddressBookEntry(row: any): AddressBookEntry {
+  return {
+    id: row.id,
+    name: row.name,
+    kind: row.kind,
+    address: row.address,
+    owner: row.owner || undefined,
+    chain: row.chain || undefined,
+    asset: row.asset || undefined,
+    account: row.account || undefined,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert AddressBookEntry to SQLite row
+export function addressBookEntryToRow(e: AddressBookEntry): any {
+  return {
+    id: e.id,
+    name: e.name,
+    kind: e.kind,
+    address: e.address,
+    owner: e.owner ?? null,
+    chain: e.chain ?? null,
+    asset: e.asset ?? null,
+    account: e.account ?? null,
+    note: e.note ?? null,
+    created_at: e.createdAt,
+    updated_at: e.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to AllocationGlidepath
+export function rowToGlidepath(row: any): AllocationGlidepath {
+  return {
+    id: row.id,
+    assetClass: row.asset_class,
+    startPercent: row.start_percent,
+    endPercent: row.end_percent,
+    startAt: row.start_at,
+    endAt: row.end_at,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert AllocationGlidepath to SQLite row
+export function glidepathToRow(g: AllocationGlidepath): any {
+  return {
+    id: g.id,
+    asset_class: g.assetClass,
+    start_percent: g.startPercent,
+    end_percent: g.endPercent,
+    start_at: g.startAt,
+    end_at: g.endAt,
+    note: g.note ?? null,
+    created_at: g.createdAt,
+    updated_at: g.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to DepositRate
+export function rowToDepositRate(row: any): DepositRate {
+  return {
+    id: row.id,
+    effectiveDate: row.effective_date,
+    termMonths: row.term_months,
+    ratePercent: row.rate_percent,
+    bank: row.bank || undefined,
+    note: row.note || undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert DepositRate to SQLite row
+export function depositRateToRow(r: DepositRate): any {
+  return {
+    id: r.id,
+    effective_date: r.effectiveDate,
+    term_months: r.termMonths,
+    rate_percent: r.ratePercent,
+    bank: r.bank ?? null,
+    note: r.note ?? null,
+    created_at: r.createdAt,
+    updated_at: r.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to TrashItem
+export function rowToTrashItem(row: any): TrashItem {
+  return {
+    id: row.id,
+    kind: row.kind,
+    label: row.label,
+    payload: JSON.parse(row.payload),
+    deletedAt: row.deleted_at,
+    expiresAt: row.expires_at,
+  };
+}
+
+// Helper to convert SQLite row to ReportSubscription
+export function rowToReportSubscription(row: any): ReportSubscription {
+  return {
+    id: row.id,
+    name: row.name,
+    report: row.report,
+    periodMode: row.period_mode,
+    filters: row.filters ? JSON.parse(row.filters) : undefined,
+    currency: row.currency,
+    cadence: row.cadence,
+    nextRunAt: row.next_run_at,
+    active: !!row.active,
+    lastRunAt: row.last_run_at || undefined,
+    lastStatus: row.last_status || undefined,
+    lastError: row.last_error || undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert ReportSubscription to SQLite row
+export function reportSubscriptionToRow(s: ReportSubscription): any {
+  return {
+    id: s.id,
+    name: s.name,
+    report: s.report,
+    period_mode: s.periodMode,
+    filters: s.filters ? JSON.stringify(s.filters) : null,
+    currency: s.currency,
+    cadence: s.cadence,
+    next_run_at: s.nextRunAt,
+    active: s.active ? 1 : 0,
+    last_run_at: s.lastRunAt ?? null,
+    last_status: s.lastStatus ?? null,
+    last_error: s.lastError ?? null,
+    created_at: s.createdAt,
+    updated_at: s.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to RecurringTransaction
+export function rowToRecurring(row: any): RecurringTransaction {
+  return {
+    id: row.id,
+    name: row.name,
+    type: row.type,
+    asset: { type: row.asset_type, symbol: row.asset_symbol },
+    amount: row.amount,
+    account: row.account || undefined,
+    note: row.note || undefined,
+    category: row.category || undefined,
+    tags: row.tags ? JSON.parse(row.tags) : undefined,
+    counterparty: row.counterparty || undefined,
+    rule: row.rule,
+    startAt: row.start_at,
+    nextRunAt: row.next_run_at || undefined,
+    occurrences: row.occurrences,
+    confirm: !!row.confirm,
+    pendingDates: row.pending_dates ? JSON.parse(row.pending_dates) : undefined,
+    active: !!row.active,
+    lastOccurrenceAt: row.last_occurrence_at || undefined,
+    lastError: row.last_error || undefined,
+    drift: row.drift ? JSON.parse(row.drift) : undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert RecurringTransaction to SQLite row
+export function recurringToRow(r: RecurringTransaction): any {
+  return {
+    id: r.id,
+    name: r.name,
+    type: r.type,
+    asset_type: r.asset.type,
+    asset_symbol: r.asset.symbol,
+    amount: r.amount,
+    account: r.account ?? null,
+    note: r.note ?? null,
+    category: r.category ?? null,
+    tags: r.tags ? JSON.stringify(r.tags) : null,
+    counterparty: r.counterparty ?? null,
+    rule: r.rule,
+    start_at: r.startAt,
+    next_run_at: r.nextRunAt ?? null,
+    occurrences: r.occurrences,
+    confirm: r.confirm ? 1 : 0,
+    pending_dates: r.pendingDates?.length
+      ? JSON.stringify(r.pendingDates)
+      : null,
+    active: r.active ? 1 : 0,
+    last_occurrence_at: r.lastOccurrenceAt ?? null,
+    last_error: r.lastError ?? null,
+    drift: r.drift ? JSON.stringify(r.drift) : null,
+    created_at: r.createdAt,
+    updated_at: r.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to Budget
+export function rowToBudget(row: any): Budget {
+  return {
+    id: row.id,
+    tag: row.tag,
+    amount: row.amount,
+    currency: row.currency,
+    note: row.note || undefined,
+    active: !!row.active,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert Budget to SQLite row
+export function budgetToRow(b: Budget): any {
+  return {
+    id: b.id,
+    tag: b.tag,
+    amount: b.amount,
+    currency: b.currency,
+    note: b.note ?? null,
+    active: b.active ? 1 : 0,
+    created_at: b.createdAt,
+    updated_at: b.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to Goal
+export function rowToGoal(row: any): Goal {
+  return {
+    id: row.id,
+    name: row.name,
+    targetAmount: row.target_amount,
+    currency: row.currency,
+    targetDate: row.target_date || undefined,
+    accounts: JSON.parse(row.accounts || "[]"),
+    tags: JSON.parse(row.tags || "[]"),
+    note: row.note || undefined,
+    active: !!row.active,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+  };
+}
+
+// Helper to convert Goal to SQLite row
+export function goalToRow(g: Goal): any {
+  return {
+    id: g.id,
+    name: g.name,
+    target_amount: g.targetAmount,
+    currency: g.currency,
+    target_date: g.targetDate ?? null,
+    accounts: JSON.stringify(g.accounts),
+    tags: JSON.stringify(g.tags),
+    note: g.note ?? null,
+    active: g.active ? 1 : 0,
+    created_at: g.createdAt,
+    updated_at: g.updatedAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to Webhook
+export function rowToWebhook(row: any): Webhook {
+  return {
+    id: row.id,
+    url: row.url,
+    events: JSON.parse(row.events || "[]"),
+    secret: row.secret,
+    description: row.description || undefined,
+    active: !!row.active,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+    lastDeliveryAt: row.last_delivery_at || undefined,
+    lastStatus: row.last_status ?? undefined,
+    lastError: row.last_error || undefined,
+  };
+}
+
+// Helper to convert Webhook to SQLite row
+export function webhookToRow(w: Webhook): any {
+  return {
+    id: w.id,
+    url: w.url,
+    events: JSON.stringify(w.events),
+    secret: w.secret,
+    description: w.description ?? null,
+    active: w.active ? 1 : 0,
+    created_at: w.createdAt,
+    updated_at: w.updatedAt ?? null,
+    last_delivery_at: w.lastDeliveryAt ?? null,
+    last_status: w.lastStatus ?? null,
+    last_error: w.lastError ?? null,
+  };
+}
+
+// Helper to convert SQLite row to YearClose
+export function rowToYearClose(row: any): YearClose {
+  return {
+    year: Number(row.year),
+    start: row.start_at,
+    end: row.end_at,
+    closedAt: row.closed_at,
+    auto: !!row.auto,
+    note: row.note || undefined,
+    snapshot: JSON.parse(row.snapshot),
+  };
+}
+
+// Helper to convert YearClose to SQLite row
+export function yearCloseToRow(c: YearClose): any {
+  return {
+    year: c.year,
+    start_at: c.start,
+    end_at: c.end,
+    closed_at: c.closedAt,
+    auto: c.auto ? 1 : 0,
+    note: c.note ?? null,
+    snapshot: JSON.stringify(c.snapshot),
+  };
+}
+
+// Helper to convert SQLite row to MonthReview
+export function rowToMonthReview(row: any): MonthReview {
+  return {
+    month: row.month,
+    reviewedAt: row.reviewed_at,
+    transactionCount: Number(row.transaction_count) || 0,
+    note: row.note || undefined,
+  };
+}
+
+// Helper to convert MonthReview to SQLite row
+export function monthReviewToRow(r: MonthReview): any {
+  return {
+    month: r.month,
+    reviewed_at: r.reviewedAt,
+    transaction_count: r.transactionCount,
+    note: r.note ?? null,
+  };
+}
+
+// Helper to convert SQLite row to AuditEntry
+export function rowToAuditEntry(row: any): AuditEntry {
+  return {
+    id: Number(row.id),
+    at: row.at,
+    entity: row.entity,
+    entityId: row.entity_id,
+    action: row.action,
+    actor: row.actor,
+    before: row.before ? JSON.parse(row.before) : undefined,
+    after: row.after ? JSON.parse(row.after) : undefined,
+  };
+}
+
+// Helper to convert AuditEntry to SQLite row
+export function auditEntryToRow(e: Omit<AuditEntry, "id">): any {
+  return {
+    at: e.at,
+    entity: e.entity,
+    entity_id: e.entityId,
+    action: e.action,
+    actor: e.actor,
+    before: e.before === undefined ? null : JSON.stringify(e.before),
+    after: e.after === undefined ? null : JSON.stringify(e.after),
+  };
+}
+
+// Helper to convert SQLite row to AccountBalance
+export function rowToAccountBalance(row: any): AccountBalance {
+  return {
+    account: row.account,
+    asset: { type: row.asset_type, symbol: row.asset_symbol },
+    quantity: Number(row.quantity) || 0,
+    transactionCount: Number(row.transaction_count) || 0,
+    lastTransactionAt: row.last_transaction_at || undefined,
+  };
+}
+
+// Helper to convert AccountBalance to SQLite row
+export function accountBalanceToRow(b: AccountBalance): any {
+  return {
+    account: b.account,
+    asset_type: b.asset.type,
+    asset_symbol: b.asset.symbol,
+    quantity: b.quantity,
+    transaction_count: b.transactionCount,
+    last_transaction_at: b.lastTransactionAt ?? null,
+  };
+}
+
+// Helper to convert SQLite row to ActionIntent
+export function rowToActionIntent(row: any): ActionIntent {
+  return {
+    id: row.id,
+    action: row.action,
+    params: JSON.parse(row.params || "{}"),
+    status: row.status,
+    clientRef: row.client_ref || undefined,
+    externalRef: row.external_ref || undefined,
+    transactionIds: JSON.parse(row.transaction_ids || "[]"),
+    vaultEntries: row.vault_entries
+      ? JSON.parse(row.vault_entries)
+      : undefined,
+    response: row.response ? JSON.parse(row.response) : undefined,
+    error: row.error || undefined,
+    cancelReason: row.cancel_reason || undefined,
+    createdAt: row.created_at,
+    updatedAt: row.updated_at || undefined,
+    expiresAt: row.expires_at,
+    confirmedAt: row.confirmed_at || undefined,
+    cancelledAt: row.cancelled_at || undefined,
+  };
+}
+
+// Helper to convert ActionIntent to SQLite row
+export function actionIntentToRow(i: ActionIntent): any {
+  return {
+    id: i.id,
+    action: i.action,
+    params: JSON.stringify(i.params),
+    status: i.status,
+    client_ref: i.clientRef ?? null,
+    external_ref: i.externalRef ?? null,
+    transaction_ids: JSON.stringify(i.transactionIds),
+    vault_entries: i.vaultEntries?.length
+      ? JSON.stringify(i.vaultEntries)
+      : null,
+    response: i.response ? JSON.stringify(i.response) : null,
+    error: i.error ?? null,
+    cancel_reason: i.cancelReason ?? null,
+    created_at: i.createdAt,
+    updated_at: i.updatedAt ?? null,
+    expires_at: i.expiresAt,
+    confirmed_at: i.confirmedAt ?? null,
+    cancelled_at: i.cancelledAt ?? null,
   };
 }
 
@@ -209,7 +971,7 @@ export class BaseDbRepository {
     params: any[] = [],
     rowMapper: (row: any) => any,
   ): any[] {
-    const stmt = this.db.prepare(sql);
+    const stmt = prepareCached(sql);
     const rows = stmt.all(...params);
     return rows.map(rowMapper);
   }
@@ -219,7 +981,7 @@ export class BaseDbRepository {
     params: any[] = [],
     rowMapper: (row: any) => any,
   ): any | undefined {
-    const stmt = this.db.prepare(sql);
+    const stmt = prepareCached(sql);
     const row = stmt.get(...params);
     return row ? rowMapper(row) : undefined;
   }
@@ -228,7 +990,7 @@ export class BaseDbRepository {
     sql: string,
     params: any[],
   ): { changes: number; lastInsertRowid: number } {
-    const stmt = this.db.prepare(sql);
+    const stmt = prepareCached(sql);
     const result = stmt.run(...params);
     return {
       changes: result.changes,
@@ -6,6 +6,28 @@ import {
   VaultEntry,
   LoanAgreement,
   BorrowingAgreement,
+  Project,
+  RegistryItem,
+  FixedIncomeInstrument,
+  OptionPosition,
+  VestingGrant,
+  VestEvent,
+  AddressBookEntry,
+  AssetKind,
+  AllocationGlidepath,
+  DepositRate,
+  TrashItem,
+  ReportSubscription,
+  RecurringTransaction,
+  Budget,
+  Goal,
+  Webhook,
+  YearClose,
+  MonthReview,
+  AuditEntry,
+  AccountBalance,
+  ActionIntent,
+  AdminAccount,
 } from "../types";
 
 const DATA_DIR = path.resolve(__dirname, "..", "..", "data");
@@ -20,19 +42,13 @@ export interface AdminType {
   created_at: string;
 }
 
-export interface AdminAccount {
-  id: number;
-  name: string;
-  type?: string;
-  is_active: boolean;
-  created_at: string;
-}
-
 export interface AdminAsset {
   id: number;
   symbol: string;
   name?: string;
   decimals?: number;
+  kind?: AssetKind; // unset: classified from the symbol
+  jurisdiction?: string; // ISO 3166-1 alpha-2 country of the issuer/market
   is_active: boolean;
   created_at: string;
 }
@@ -77,6 +93,27 @@ export interface StoreShape {
   adminAssets: AdminAsset[];
   adminTags: AdminTag[];
   pendingActions: PendingAction[];
+  projects: Project[];
+  registry: RegistryItem[];
+  fixedIncome: FixedIncomeInstrument[];
+  options: OptionPosition[];
+  vestingGrants: VestingGrant[];
+  vestEvents: VestEvent[];
+  addressBook: AddressBookEntry[];
+  glidepaths: AllocationGlidepath[];
+  depositRates: DepositRate[];
+  trash: TrashItem[];
+  reportSubscriptions: ReportSubscription[];
+  recurring: RecurringTransaction[];
+  budgets: Budget[];
+  goals: Goal[];
+  webhooks: Webhook[];
+  yearCloses: YearClose[];
+  monthReviews: MonthReview[];
+  auditLog: AuditEntry[]; // newest JSON_AUDIT_LOG_LIMIT only
+  auditNextId?: number; // id of the next audit entry
+  accountBalances: AccountBalance[];
+  actionIntents: ActionIntent[];
   settings?: {
     borrowingVaultName?: string;
     borrowingMonthlyRate?: number;
@@ -86,6 +123,13 @@ export interface StoreShape {
     migratedIncomeToSpend?: boolean;
     defaultSpendingVaultName?: string;
     defaultIncomeVaultName?: string;
+    maxManualPriceChangePercent?: string; // number as text, default 50
+    depegThresholdPercent?: string; // number as text, default 1
+    displayPrecision?: string; // JSON map of symbol -> decimals
+    spendingExclusionRules?: string; // JSON array of SpendingExclusionRule
+    homeJurisdiction?: string; // ISO country of tax residency (domestic)
+    overdrawPolicy?: string; // REJECT, WARN or ALLOW
+    mergeLog?: string; // JSON array of MergeRecord, latest 200
   };
 }
 
@@ -103,6 +147,26 @@ function ensureDataFile(): void {
       adminAssets: [],
       adminTags: [],
       pendingActions: [],
+      projects: [],
+      registry: [],
+      fixedIncome: [],
+      options: [],
+      vestingGrants: [],
+      vestEvents: [],
+      addressBook: [],
+      glidepaths: [],
+      depositRates: [],
+      trash: [],
+      reportSubscriptions: [],
+      recurring: [],
+      budgets: [],
+      goals: [],
+      webhooks: [],
+      yearCloses: [],
+      monthReviews: [],
+      auditLog: [],
+      accountBalances: [],
+      actionIntents: [],
       settings: {},
     };
     fs.writeFileSync(STORE_FILE, JSON.stringify(initial, null, 2));
@@ -129,6 +193,36 @@ export function readStore(): StoreShape {
       pendingActions: Array.isArray(data.pendingActions)
         ? data.pendingActions
         : [],
+      projects: Array.isArray(data.projects) ? data.projects : [],
+      registry: Array.isArray(data.registry) ? data.registry : [],
+      fixedIncome: Array.isArray(data.fixedIncome) ? data.fixedIncome : [],
+      options: Array.isArray(data.options) ? data.options : [],
+      vestingGrants: Array.isArray(data.vestingGrants)
+        ? data.vestingGrants
+        : [],
+      vestEvents: Array.isArray(data.vestEvents) ? data.vestEvents : [],
+      addressBook: Array.isArray(data.addressBook) ? data.addressBook : [],
+      glidepaths: Array.isArray(data.glidepaths) ? data.glidepaths : [],
+      depositRates: Array.isArray(data.depositRates) ? data.depositRates : [],
+      trash: Array.isArray(data.trash) ? data.trash : [],
+      reportSubscriptions: Array.isArray(data.reportSubscriptions)
+        ? data.reportSubscriptions
+        : [],
+      recurring: Array.isArray(data.recurring) ? data.recurring : [],
+      budgets: Array.isArray(data.budgets) ? data.budgets : [],
+      goals: Array.isArray(data.goals) ? data.goals : [],
+      webhooks: Array.isArray(data.webhooks) ? data.webhooks : [],
+      yearCloses: Array.isArray(data.yearCloses) ? data.yearCloses : [],
+      monthReviews: Array.isArray(data.monthReviews) ? data.monthReviews : [],
+      auditLog: Array.isArray(data.auditLog) ? data.auditLog : [],
+      auditNextId:
+        typeof data.auditNextId === "number" ? data.auditNextId : undefined,
+      accountBalances: Array.isArray(data.accountBalances)
+        ? data.accountBalances
+        : [],
+      actionIntents: Array.isArray(data.actionIntents)
+        ? data.actionIntents
+        : [],
       settings:
         typeof data.settings === "object" && data.settings ? data.settings : {},
     } as StoreShape;
@@ -144,6 +238,26 @@ export function readStore(): StoreShape {
       adminAssets: [],
       adminTags: [],
       pendingActions: [],
+      projects: [],
+      registry: [],
+      fixedIncome: [],
+      options: [],
+      vestingGrants: [],
+      vestEvents: [],
+      addressBook: [],
+      glidepaths: [],
+      depositRates: [],
+      trash: [],
+      reportSubscriptions: [],
+      recurring: [],
+      budgets: [],
+      goals: [],
+      webhooks: [],
+      yearCloses: [],
+      monthReviews: [],
+      auditLog: [],
+      accountBalances: [],
+      actionIntents: [],
       settings: {},
     } as StoreShape;
   }
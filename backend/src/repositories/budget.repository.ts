@@ -0,0 +1,126 @@
+import { Budget } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IBudgetRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToBudget,
+  budgetToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class BudgetRepositoryJson implements IBudgetRepository {
+  findAll(): Budget[] {
+    return readStore().budgets;
+  }
+
+  findById(id: string): Budget | undefined {
+    return readStore().budgets.find((b) => b.id === id);
+  }
+
+  findByTag(tag: string): Budget | undefined {
+    return readStore().budgets.find((b) => b.tag === tag);
+  }
+
+  create(budget: Budget): Budget {
+    const store = readStore();
+    store.budgets.push(budget);
+    writeStore(store);
+    return budget;
+  }
+
+  update(id: string, updates: Partial<Budget>): Budget | undefined {
+    const store = readStore();
+    const index = store.budgets.findIndex((b) => b.id === id);
+    if (index === -1) return undefined;
+
+    store.budgets[index] = { ...store.budgets[index], ...updates, id };
+    writeStore(store);
+    return store.budgets[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.budgets.length;
+    store.budgets = store.budgets.filter((b) => b.id !== id);
+    writeStore(store);
+    return store.budgets.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class BudgetRepositoryDb
+  extends BaseDbRepository
+  implements IBudgetRepository
+{
+  findAll(): Budget[] {
+    return this.findMany(
+      "SELECT * FROM budgets ORDER BY tag ASC",
+      [],
+      rowToBudget,
+    );
+  }
+
+  findById(id: string): Budget | undefined {
+    return this.findOne(
+      "SELECT * FROM budgets WHERE id = ?",
+      [id],
+      rowToBudget,
+    );
+  }
+
+  findByTag(tag: string): Budget | undefined {
+    return this.findOne(
+      "SELECT * FROM budgets WHERE tag = ?",
+      [tag],
+      rowToBudget,
+    );
+  }
+
+  create(budget: Budget): Budget {
+    const row = budgetToRow(budget);
+    this.execute(
+      `INSERT INTO budgets (
+        id, tag, amount, currency, note, active, created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.tag,
+        row.amount,
+        row.currency,
+        row.note,
+        row.active,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return budget;
+  }
+
+  update(id: string, updates: Partial<Budget>): Budget | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = budgetToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE budgets SET
+        tag = ?, amount = ?, currency = ?, note = ?, active = ?,
+        updated_at = ?
+      WHERE id = ?`,
+      [
+        row.tag,
+        row.amount,
+        row.currency,
+        row.note,
+        row.active,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM budgets WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+}
@@ -0,0 +1,125 @@
+import { DepositRate } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IDepositRateRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToDepositRate,
+  depositRateToRow,
+} from "./base-db.repository";
+
+const byDateThenTerm = (a: DepositRate, b: DepositRate) =>
+  a.effectiveDate.localeCompare(b.effectiveDate) || a.termMonths - b.termMonths;
+
+// JSON-based implementation
+export class DepositRateRepositoryJson implements IDepositRateRepository {
+  findAll(): DepositRate[] {
+    return [...readStore().depositRates].sort(byDateThenTerm);
+  }
+
+  findById(id: string): DepositRate | undefined {
+    return readStore().depositRates.find((r) => r.id === id);
+  }
+
+  create(rate: DepositRate): DepositRate {
+    const store = readStore();
+    store.depositRates.push(rate);
+    writeStore(store);
+    return rate;
+  }
+
+  update(id: string, updates: Partial<DepositRate>): DepositRate | undefined {
+    const store = readStore();
+    const index = store.depositRates.findIndex((r) => r.id === id);
+    if (index === -1) return undefined;
+
+    store.depositRates[index] = {
+      ...store.depositRates[index],
+      ...updates,
+      id,
+    };
+    writeStore(store);
+    return store.depositRates[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.depositRates.length;
+    store.depositRates = store.depositRates.filter((r) => r.id !== id);
+    writeStore(store);
+    return store.depositRates.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class DepositRateRepositoryDb
+  extends BaseDbRepository
+  implements IDepositRateRepository
+{
+  findAll(): DepositRate[] {
+    return this.findMany(
+      `SELECT * FROM deposit_rates
+       ORDER BY effective_date ASC, term_months ASC`,
+      [],
+      rowToDepositRate,
+    );
+  }
+
+  findById(id: string): DepositRate | undefined {
+    return this.findOne(
+      "SELECT * FROM deposit_rates WHERE id = ?",
+      [id],
+      rowToDepositRate,
+    );
+  }
+
+  create(rate: DepositRate): DepositRate {
+    const row = depositRateToRow(rate);
+    this.execute(
+      `INSERT INTO deposit_rates (
+        id, effective_date, term_months, rate_percent, bank, note,
+        created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.effective_date,
+        row.term_months,
+        row.rate_percent,
+        row.bank,
+        row.note,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return rate;
+  }
+
+  update(id: string, updates: Partial<DepositRate>): DepositRate | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = depositRateToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE deposit_rates SET
+        effective_date = ?, term_months = ?, rate_percent = ?, bank = ?,
+        note = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.effective_date,
+        row.term_months,
+        row.rate_percent,
+        row.bank,
+        row.note,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM deposit_rates WHERE id = ?", [
+      id,
+    ]);
+    return result.changes > 0;
+  }
+}
@@ -0,0 +1,146 @@
+import { FixedIncomeInstrument } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IFixedIncomeRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToFixedIncome,
+  fixedIncomeToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class FixedIncomeRepositoryJson implements IFixedIncomeRepository {
+  findAll(): FixedIncomeInstrument[] {
+    return readStore().fixedIncome;
+  }
+
+  findById(id: string): FixedIncomeInstrument | undefined {
+    return readStore().fixedIncome.find((f) => f.id === id);
+  }
+
+  findByStatus(status: string): FixedIncomeInstrument[] {
+    return readStore().fixedIncome.filter((f) => f.status === status);
+  }
+
+  create(item: FixedIncomeInstrument): FixedIncomeInstrument {
+    const store = readStore();
+    store.fixedIncome.push(item);
+    writeStore(store);
+    return item;
+  }
+
+  update(
+    id: string,
+    updates: Partial<FixedIncomeInstrument>,
+  ): FixedIncomeInstrument | undefined {
+    const store = readStore();
+    const index = store.fixedIncome.findIndex((f) => f.id === id);
+    if (index === -1) return undefined;
+
+    store.fixedIncome[index] = { ...store.fixedIncome[index], ...updates, id };
+    writeStore(store);
+    return store.fixedIncome[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.fixedIncome.length;
+    store.fixedIncome = store.fixedIncome.filter((f) => f.id !== id);
+    writeStore(store);
+    return store.fixedIncome.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class FixedIncomeRepositoryDb
+  extends BaseDbRepository
+  implements IFixedIncomeRepository
+{
+  findAll(): FixedIncomeInstrument[] {
+    return this.findMany(
+      "SELECT * FROM fixed_income_instruments ORDER BY maturity_at ASC",
+      [],
+      rowToFixedIncome,
+    );
+  }
+
+  findById(id: string): FixedIncomeInstrument | undefined {
+    return this.findOne(
+      "SELECT * FROM fixed_income_instruments WHERE id = ?",
+      [id],
+      rowToFixedIncome,
+    );
+  }
+
+  findByStatus(status: string): FixedIncomeInstrument[] {
+    return this.findMany(
+      `SELECT * FROM fixed_income_instruments WHERE status = ?
+       ORDER BY maturity_at ASC`,
+      [status],
+      rowToFixedIncome,
+    );
+  }
+
+  create(item: FixedIncomeInstrument): FixedIncomeInstrument {
+    const row = fixedIncomeToRow(item);
+    this.execute(
+      `INSERT INTO fixed_income_instruments (
+        id, kind, name, issuer, asset_type, asset_symbol, face_value,
+        coupon_rate, coupon_frequency, start_at, maturity_at, account,
+        next_coupon_at, status, note, created_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.kind,
+        row.name,
+        row.issuer,
+        row.asset_type,
+        row.asset_symbol,
+        row.face_value,
+        row.coupon_rate,
+        row.coupon_frequency,
+        row.start_at,
+        row.maturity_at,
+        row.account,
+        row.next_coupon_at,
+        row.status,
+        row.note,
+        row.created_at,
+      ],
+    );
+    return item;
+  }
+
+  update(
+    id: string,
+    updates: Partial<FixedIncomeInstrument>,
+  ): FixedIncomeInstrument | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = fixedIncomeToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE fixed_income_instruments SET
+        name = ?, issuer = ?, account = ?, next_coupon_at = ?, status = ?,
+        note = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.issuer,
+        row.account,
+        row.next_coupon_at,
+        row.status,
+        row.note,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute(
+      "DELETE FROM fixed_income_instruments WHERE id = ?",
+      [id],
+    );
+    return result.changes > 0;
+  }
+}
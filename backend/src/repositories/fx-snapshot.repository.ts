@@ -0,0 +1,93 @@
+import { Asset, Transaction } from "../types";
+import { ITransactionRepository } from "./repository.interface";
+import {
+  FX_MARGIN_TYPES,
+  fxMarginFactor,
+  fxMarginsSuspended,
+  fxSnapshot,
+} from "../utils/fx.util";
+
+/**
+ * The transaction repository with an FX snapshot (`fx`) stamped on every
+ * new transaction that has none, whichever path creates it. An edit that
+ * changes the asset, date or rate takes a fresh snapshot; amount edits
+ * keep it, as it is per unit. Rates come from `usdPerUnit` and must be at
+ * hand (cached): currencies without one are skipped, for the backfill
+ * script to fill in later.
+ *
+ * `margins` gives a lookup of the FX margin (percent) a transaction's
+ * account charges on it, taken once per write so a batch shares one. On
+ * spending (`FX_MARGIN_TYPES`) it is recorded as `fxMargin` and
+ * applied to the mid-market `usdAmount` callers give; snapshots stay
+ * mid-market. A write that sets `fxMargin` itself, or one made under
+ * `withoutFxMargins` (e.g. a restore), is taken as is.
+ */
+export function withFxSnapshots(
+  repo: ITransactionRepository,
+  currencies: () => string[],
+  usdPerUnit: (currency: Asset, atISO: string) => number | undefined,
+  margins: () => (tx: Transaction) => number = () => () => 0,
+): ITransactionRepository {
+  const wrapped: ITransactionRepository = Object.create(repo);
+  const marginsFor = () => {
+    const marginOf = margins();
+    return (tx: Transaction) =>
+      FX_MARGIN_TYPES.includes(tx.type) ? marginOf(tx) : 0;
+  };
+  const stamp = (tx: Transaction, marginOf = marginsFor()) => {
+    if (tx.fxMargin === undefined && !fxMarginsSuspended()) {
+      const margin = marginOf(tx);
+      if (margin) {
+        tx.fxMargin = margin;
+        tx.usdAmount = tx.usdAmount * fxMarginFactor(tx);
+      }
+    }
+    if (tx.fx) return tx;
+    try {
+      const fx = fxSnapshot(tx, currencies(), usdPerUnit);
+      if (fx) tx.fx = fx;
+    } catch {
+      // Rates unavailable - record the transaction without a snapshot
+    }
+    return tx;
+  };
+
+  wrapped.create = (tx: Transaction) => repo.create(stamp(tx));
+  wrapped.createMany = (txs: Transaction[]) => {
+    const marginOf = marginsFor();
+    return repo.createMany(txs.map((tx) => stamp(tx, marginOf)));
+  };
+  wrapped.update = (id: string, updates: Partial<Transaction>) => {
+    const snapshot =
+      updates.fx === undefined &&
+      (updates.asset || updates.createdAt || updates.rate);
+    // A new USD amount is mid-market; a new account, asset or type may
+    // charge another margin on the existing one
+    const margin =
+      !("fxMargin" in updates) &&
+      !fxMarginsSuspended() &&
+      (updates.usdAmount !== undefined ||
+        updates.account ||
+        updates.asset ||
+        updates.type);
+    if (snapshot || margin) {
+      const existing = repo.findById(id);
+      if (existing) {
+        const next = { ...existing, ...updates, fx: undefined } as Transaction;
+        if (margin) {
+          const fxMargin = marginsFor()(next) || undefined;
+          const mid =
+            updates.usdAmount ?? existing.usdAmount / fxMarginFactor(existing);
+          updates = {
+            ...updates,
+            fxMargin,
+            usdAmount: mid * fxMarginFactor({ fxMargin }),
+          };
+        }
+        if (snapshot) updates = { ...updates, fx: stamp(next, () => 0).fx };
+      }
+    }
+    return repo.update(id, updates);
+  };
+  return wrapped;
+}
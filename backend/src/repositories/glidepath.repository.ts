@@ -0,0 +1,126 @@
+import { AllocationGlidepath } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IGlidepathRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToGlidepath,
+  glidepathToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class GlidepathRepositoryJson implements IGlidepathRepository {
+  findAll(): AllocationGlidepath[] {
+    return readStore().glidepaths;
+  }
+
+  findById(id: string): AllocationGlidepath | undefined {
+    return readStore().glidepaths.find((g) => g.id === id);
+  }
+
+  create(glidepath: AllocationGlidepath): AllocationGlidepath {
+    const store = readStore();
+    store.glidepaths.push(glidepath);
+    writeStore(store);
+    return glidepath;
+  }
+
+  update(
+    id: string,
+    updates: Partial<AllocationGlidepath>,
+  ): AllocationGlidepath | undefined {
+    const store = readStore();
+    const index = store.glidepaths.findIndex((g) => g.id === id);
+    if (index === -1) return undefined;
+
+    store.glidepaths[index] = { ...store.glidepaths[index], ...updates, id };
+    writeStore(store);
+    return store.glidepaths[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.glidepaths.length;
+    store.glidepaths = store.glidepaths.filter((g) => g.id !== id);
+    writeStore(store);
+    return store.glidepaths.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class GlidepathRepositoryDb
+  extends BaseDbRepository
+  implements IGlidepathRepository
+{
+  findAll(): AllocationGlidepath[] {
+    return this.findMany(
+      "SELECT * FROM allocation_glidepaths ORDER BY asset_class ASC",
+      [],
+      rowToGlidepath,
+    );
+  }
+
+  findById(id: string): AllocationGlidepath | undefined {
+    return this.findOne(
+      "SELECT * FROM allocation_glidepaths WHERE id = ?",
+      [id],
+      rowToGlidepath,
+    );
+  }
+
+  create(glidepath: AllocationGlidepath): AllocationGlidepath {
+    const row = glidepathToRow(glidepath);
+    this.execute(
+      `INSERT INTO allocation_glidepaths (
+        id, asset_class, start_percent, end_percent, start_at, end_at, note,
+        created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.asset_class,
+        row.start_percent,
+        row.end_percent,
+        row.start_at,
+        row.end_at,
+        row.note,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return glidepath;
+  }
+
+  update(
+    id: string,
+    updates: Partial<AllocationGlidepath>,
+  ): AllocationGlidepath | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = glidepathToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE allocation_glidepaths SET
+        asset_class = ?, start_percent = ?, end_percent = ?, start_at = ?,
+        end_at = ?, note = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.asset_class,
+        row.start_percent,
+        row.end_percent,
+        row.start_at,
+        row.end_at,
+        row.note,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute(
+      "DELETE FROM allocation_glidepaths WHERE id = ?",
+      [id],
+    );
+    return result.changes > 0;
+  }
+}
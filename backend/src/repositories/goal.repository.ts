@@ -0,0 +1,113 @@
+import { Goal } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IGoalRepository } from "./repository.interface";
+import { BaseDbRepository, rowToGoal, goalToRow } from "./base-db.repository";
+
+// JSON-based implementation
+export class GoalRepositoryJson implements IGoalRepository {
+  findAll(): Goal[] {
+    return readStore().goals;
+  }
+
+  findById(id: string): Goal | undefined {
+    return readStore().goals.find((g) => g.id === id);
+  }
+
+  create(goal: Goal): Goal {
+    const store = readStore();
+    store.goals.push(goal);
+    writeStore(store);
+    return goal;
+  }
+
+  update(id: string, updates: Partial<Goal>): Goal | undefined {
+    const store = readStore();
+    const index = store.goals.findIndex((g) => g.id === id);
+    if (index === -1) return undefined;
+
+    store.goals[index] = { ...store.goals[index], ...updates, id };
+    writeStore(store);
+    return store.goals[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.goals.length;
+    store.goals = store.goals.filter((g) => g.id !== id);
+    writeStore(store);
+    return store.goals.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class GoalRepositoryDb
+  extends BaseDbRepository
+  implements IGoalRepository
+{
+  findAll(): Goal[] {
+    return this.findMany(
+      "SELECT * FROM goals ORDER BY created_at ASC",
+      [],
+      rowToGoal,
+    );
+  }
+
+  findById(id: string): Goal | undefined {
+    return this.findOne("SELECT * FROM goals WHERE id = ?", [id], rowToGoal);
+  }
+
+  create(goal: Goal): Goal {
+    const row = goalToRow(goal);
+    this.execute(
+      `INSERT INTO goals (
+        id, name, target_amount, currency, target_date, accounts, tags,
+        note, active, created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.name,
+        row.target_amount,
+        row.currency,
+        row.target_date,
+        row.accounts,
+        row.tags,
+        row.note,
+        row.active,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return goal;
+  }
+
+  update(id: string, updates: Partial<Goal>): Goal | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = goalToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE goals SET
+        name = ?, target_amount = ?, currency = ?, target_date = ?,
+        accounts = ?, tags = ?, note = ?, active = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.target_amount,
+        row.currency,
+        row.target_date,
+        row.accounts,
+        row.tags,
+        row.note,
+        row.active,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM goals WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+}
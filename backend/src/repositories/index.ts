@@ -13,6 +13,27 @@ import {
   adminRepository,
   pendingActionsRepository,
   settingsRepository,
+  projectRepository,
+  registryRepository,
+  fixedIncomeRepository,
+  optionRepository,
+  vestingRepository,
+  addressBookRepository,
+  glidepathRepository,
+  depositRateRepository,
+  trashRepository,
+  reportSubscriptionRepository,
+  mergeRepository,
+  recurringRepository,
+  budgetRepository,
+  goalRepository,
+  reportingRepository,
+  webhookRepository,
+  yearCloseRepository,
+  monthReviewRepository,
+  auditRepository,
+  accountBalanceRepository,
+  actionIntentRepository,
   TransactionRepositoryDb,
   TransactionRepositoryJson,
   VaultRepositoryDb,
@@ -27,6 +48,48 @@ import {
   PendingActionsRepositoryJson,
   SettingsRepositoryDb,
   SettingsRepositoryJson,
+  ProjectRepositoryDb,
+  ProjectRepositoryJson,
+  RegistryRepositoryDb,
+  RegistryRepositoryJson,
+  FixedIncomeRepositoryDb,
+  FixedIncomeRepositoryJson,
+  OptionRepositoryDb,
+  OptionRepositoryJson,
+  VestingRepositoryDb,
+  VestingRepositoryJson,
+  AddressBookRepositoryDb,
+  AddressBookRepositoryJson,
+  GlidepathRepositoryDb,
+  GlidepathRepositoryJson,
+  DepositRateRepositoryDb,
+  DepositRateRepositoryJson,
+  TrashRepositoryDb,
+  TrashRepositoryJson,
+  ReportSubscriptionRepositoryDb,
+  ReportSubscriptionRepositoryJson,
+  MergeRepositoryDb,
+  MergeRepositoryJson,
+  RecurringRepositoryDb,
+  RecurringRepositoryJson,
+  BudgetRepositoryDb,
+  BudgetRepositoryJson,
+  GoalRepositoryDb,
+  GoalRepositoryJson,
+  ReportingRepositoryDb,
+  ReportingRepositoryJson,
+  WebhookRepositoryDb,
+  WebhookRepositoryJson,
+  YearCloseRepositoryDb,
+  YearCloseRepositoryJson,
+  MonthReviewRepositoryDb,
+  MonthReviewRepositoryJson,
+  AuditRepositoryDb,
+  AuditRepositoryJson,
+  AccountBalanceRepositoryDb,
+  AccountBalanceRepositoryJson,
+  ActionIntentRepositoryDb,
+  ActionIntentRepositoryJson,
 } from "../core/di";
 
 // Re-export repository instances (backward compatibility)
@@ -38,6 +101,27 @@ export {
   adminRepository,
   pendingActionsRepository,
   settingsRepository,
+  projectRepository,
+  registryRepository,
+  fixedIncomeRepository,
+  optionRepository,
+  vestingRepository,
+  addressBookRepository,
+  glidepathRepository,
+  depositRateRepository,
+  trashRepository,
+  reportSubscriptionRepository,
+  mergeRepository,
+  recurringRepository,
+  budgetRepository,
+  goalRepository,
+  reportingRepository,
+  webhookRepository,
+  yearCloseRepository,
+  monthReviewRepository,
+  auditRepository,
+  accountBalanceRepository,
+  actionIntentRepository,
 };
 
 // Export classes for type imports and testing
@@ -56,6 +140,48 @@ export {
   PendingActionsRepositoryDb,
   SettingsRepositoryJson,
   SettingsRepositoryDb,
+  ProjectRepositoryJson,
+  ProjectRepositoryDb,
+  RegistryRepositoryJson,
+  RegistryRepositoryDb,
+  FixedIncomeRepositoryJson,
+  FixedIncomeRepositoryDb,
+  OptionRepositoryJson,
+  OptionRepositoryDb,
+  VestingRepositoryJson,
+  VestingRepositoryDb,
+  AddressBookRepositoryJson,
+  AddressBookRepositoryDb,
+  GlidepathRepositoryJson,
+  GlidepathRepositoryDb,
+  DepositRateRepositoryJson,
+  DepositRateRepositoryDb,
+  TrashRepositoryJson,
+  TrashRepositoryDb,
+  ReportSubscriptionRepositoryJson,
+  ReportSubscriptionRepositoryDb,
+  MergeRepositoryJson,
+  MergeRepositoryDb,
+  RecurringRepositoryJson,
+  RecurringRepositoryDb,
+  BudgetRepositoryJson,
+  BudgetRepositoryDb,
+  GoalRepositoryJson,
+  GoalRepositoryDb,
+  ReportingRepositoryJson,
+  ReportingRepositoryDb,
+  WebhookRepositoryJson,
+  WebhookRepositoryDb,
+  YearCloseRepositoryJson,
+  YearCloseRepositoryDb,
+  MonthReviewRepositoryJson,
+  MonthReviewRepositoryDb,
+  AuditRepositoryJson,
+  AuditRepositoryDb,
+  AccountBalanceRepositoryJson,
+  AccountBalanceRepositoryDb,
+  ActionIntentRepositoryJson,
+  ActionIntentRepositoryDb,
 };
 
 // Export other repository types
@@ -67,3 +193,9 @@ export {
   priceCacheRepository,
   PriceCacheRepository,
 } from "./price-cache.repository";
+
+// Export database maintenance repository
+export {
+  maintenanceRepository,
+  MaintenanceRepository,
+} from "./maintenance.repository";
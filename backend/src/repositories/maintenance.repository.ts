@@ -0,0 +1,91 @@
+import { BaseDbRepository } from "./base-db.repository";
+import {
+  StatementCacheStats,
+  statementCacheStats,
+} from "../database/connection";
+
+export interface TableSize {
+  name: string;
+  rows: number;
+  bytes?: number; // undefined when SQLite is built without dbstat
+}
+
+export interface DatabaseSize {
+  pageSize: number;
+  pageCount: number;
+  freelistCount: number; // unused pages VACUUM would reclaim
+  sizeBytes: number;
+}
+
+// SQLite housekeeping: statistics, space reclamation and size reporting
+export class MaintenanceRepository extends BaseDbRepository {
+  tableNames(): string[] {
+    return this.findMany(
+      `SELECT name FROM sqlite_master
+       WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+       ORDER BY name`,
+      [],
+      (r: any) => r.name,
+    );
+  }
+
+  tableSizes(): TableSize[] {
+    const bytes = new Map<string, number>();
+    try {
+      for (const r of this.findMany(
+        `SELECT name, SUM(pgsize) AS bytes FROM dbstat GROUP BY name`,
+        [],
+        (r: any) => r,
+      )) {
+        bytes.set(r.name, r.bytes);
+      }
+    } catch {
+      // dbstat is an optional SQLite extension
+    }
+
+    // Names come from sqlite_master, so interpolating them is safe
+    return this.tableNames().map((name) => ({
+      name,
+      rows: this.findOne(
+        `SELECT COUNT(*) AS count FROM "${name}"`,
+        [],
+        (r: any) => r.count,
+      ),
+      bytes: bytes.get(name),
+    }));
+  }
+
+  databaseSize(): DatabaseSize {
+    const pageSize = this.db.pragma("page_size", { simple: true }) as number;
+    const pageCount = this.db.pragma("page_count", { simple: true }) as number;
+    const freelistCount = this.db.pragma("freelist_count", {
+      simple: true,
+    }) as number;
+    return {
+      pageSize,
+      pageCount,
+      freelistCount,
+      sizeBytes: pageSize * pageCount,
+    };
+  }
+
+  /** Refresh query planner statistics for the tables that exist. */
+  analyze(tables: string[]): string[] {
+    const existing = new Set(this.tableNames());
+    const analyzed = tables.filter((t) => existing.has(t));
+    for (const t of analyzed) this.db.exec(`ANALYZE "${t}"`);
+    return analyzed;
+  }
+
+  vacuum(): void {
+    this.db.exec("VACUUM");
+  }
+
+  // Cached statements re-plan by themselves after ANALYZE or VACUUM
+  statementCache(): StatementCacheStats {
+    return statementCacheStats();
+  }
+}
+
+// Singleton instance
+export const maintenanceRepository = new MaintenanceRepository();
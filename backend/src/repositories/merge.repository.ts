@@ -0,0 +1,189 @@
+import { Asset } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IMergeRepository, MergeCounts } from "./repository.interface";
+import { BaseDbRepository } from "./base-db.repository";
+
+// Tables holding an account name or asset symbol, as [table, column]
+const ACCOUNT_COLUMNS: Array<[string, string]> = [
+  ["transactions", "account"],
+  ["vault_entries", "account"],
+  ["loans", "account"],
+  ["borrowings", "account"],
+  ["fixed_income_instruments", "account"],
+  ["option_positions", "account"],
+  ["vesting_grants", "account"],
+  ["address_book", "account"],
+];
+
+const ASSET_COLUMNS: Array<[string, string]> = [
+  ["transactions", "asset_symbol"],
+  ["vault_entries", "asset_symbol"],
+  ["loans", "asset_symbol"],
+  ["borrowings", "asset_symbol"],
+  ["registry_items", "asset_symbol"],
+  ["fixed_income_instruments", "asset_symbol"],
+  ["option_positions", "underlying_symbol"],
+  ["option_positions", "premium_asset_symbol"],
+  ["vesting_grants", "asset_symbol"],
+  ["vesting_grants", "cash_asset_symbol"],
+  ["address_book", "asset"],
+];
+
+// Number of references moved; `move` renames one row and reports hits
+function count<T>(rows: T[], move: (row: T) => number): number {
+  return rows.reduce((n, r) => n + move(r), 0);
+}
+
+// Tags without `from`, with `into` added once
+function retag(tags: string[], from: string, into: string): string[] {
+  return Array.from(new Set(tags.map((t) => (t === from ? into : t))));
+}
+
+// Only tables that changed
+function nonZero(counts: MergeCounts): MergeCounts {
+  return Object.fromEntries(Object.entries(counts).filter(([, n]) => n > 0));
+}
+
+// JSON-based implementation: one read, one write
+export class MergeRepositoryJson implements IMergeRepository {
+  mergeAccount(fromId: number, from: string, into: string): MergeCounts {
+    const store = readStore();
+    const move = (r: { account?: string }) => {
+      if (r.account !== from) return 0;
+      r.account = into;
+      return 1;
+    };
+    const counts = {
+      transactions: count(store.transactions, move),
+      vault_entries: count(store.vaultEntries, move),
+      loans: count(store.loans, move),
+      borrowings: count(store.borrowings, move),
+      fixed_income_instruments: count(store.fixedIncome, move),
+      option_positions: count(store.options, move),
+      vesting_grants: count(store.vestingGrants, move),
+      address_book: count(store.addressBook, move),
+    };
+    store.adminAccounts = store.adminAccounts.filter((a) => a.id !== fromId);
+    writeStore(store);
+    return nonZero(counts);
+  }
+
+  mergeAsset(fromId: number, from: string, into: string): MergeCounts {
+    const store = readStore();
+    const swap = (a?: Asset) => {
+      if (a?.symbol !== from) return 0;
+      a.symbol = into;
+      return 1;
+    };
+    const counts = {
+      transactions: count(store.transactions, (t) => {
+        swap(t.rate?.asset); // keep the FX snapshot on the same symbol
+        return swap(t.asset);
+      }),
+      vault_entries: count(store.vaultEntries, (e) => swap(e.asset)),
+      loans: count(store.loans, (l) => swap(l.asset)),
+      borrowings: count(store.borrowings, (b) => swap(b.asset)),
+      registry_items: count(store.registry, (i) => swap(i.asset)),
+      fixed_income_instruments: count(store.fixedIncome, (f) => swap(f.asset)),
+      option_positions: count(
+        store.options,
+        (o) => swap(o.underlying) + swap(o.premiumAsset),
+      ),
+      vesting_grants: count(
+        store.vestingGrants,
+        (g) => swap(g.asset) + swap(g.cashAsset),
+      ),
+      address_book: count(store.addressBook, (a) => {
+        if (a.asset !== from) return 0;
+        a.asset = into;
+        return 1;
+      }),
+    };
+    store.adminAssets = store.adminAssets.filter((a) => a.id !== fromId);
+    writeStore(store);
+    return nonZero(counts);
+  }
+
+  mergeTag(fromId: number, from: string, into: string): MergeCounts {
+    const store = readStore();
+    const counts = {
+      transactions: count(store.transactions, (t) => {
+        if (!t.tags?.includes(from)) return 0;
+        t.tags = retag(t.tags, from, into);
+        return 1;
+      }),
+    };
+    store.adminTags = store.adminTags.filter((t) => t.id !== fromId);
+    writeStore(store);
+    return nonZero(counts);
+  }
+}
+
+// Database-based implementation: one SQLite transaction per merge
+export class MergeRepositoryDb
+  extends BaseDbRepository
+  implements IMergeRepository
+{
+  private renameAll(
+    columns: Array<[string, string]>,
+    from: string,
+    into: string,
+  ): MergeCounts {
+    const counts: MergeCounts = {};
+    // Table and column names are constants above, never user input
+    for (const [table, column] of columns) {
+      const { changes } = this.execute(
+        `UPDATE ${table} SET ${column} = ? WHERE ${column} = ?`,
+        [into, from],
+      );
+      counts[table] = (counts[table] || 0) + changes;
+    }
+    return counts;
+  }
+
+  mergeAccount(fromId: number, from: string, into: string): MergeCounts {
+    return this.db.transaction(() => {
+      const counts = this.renameAll(ACCOUNT_COLUMNS, from, into);
+      this.execute("DELETE FROM admin_accounts WHERE id = ?", [fromId]);
+      return nonZero(counts);
+    })();
+  }
+
+  mergeAsset(fromId: number, from: string, into: string): MergeCounts {
+    return this.db.transaction(() => {
+      const counts = this.renameAll(ASSET_COLUMNS, from, into);
+      // Keep the FX snapshot on the same symbol as the transaction
+      this.execute(
+        `UPDATE transactions
+         SET rate = json_set(rate, '$.asset.symbol', ?)
+         WHERE json_extract(rate, '$.asset.symbol') = ?`,
+        [into, from],
+      );
+      this.execute("DELETE FROM admin_assets WHERE id = ?", [fromId]);
+      return nonZero(counts);
+    })();
+  }
+
+  mergeTag(fromId: number, from: string, into: string): MergeCounts {
+    return this.db.transaction(() => {
+      // Tags are a JSON array; LIKE narrows the scan, the parse decides
+      const rows: Array<{ id: string; tags: string }> = this.findMany(
+        "SELECT id, tags FROM transactions WHERE tags LIKE ?",
+        [`%${JSON.stringify(from)}%`],
+        (r: any) => r,
+      );
+      let transactions = 0;
+      for (const row of rows) {
+        const tags: string[] = JSON.parse(row.tags);
+        if (!tags.includes(from)) continue;
+        this.execute("UPDATE transactions SET tags = ? WHERE id = ?", [
+          JSON.stringify(retag(tags, from, into)),
+          row.id,
+        ]);
+        transactions++;
+      }
+      this.execute("DELETE FROM admin_tags WHERE id = ?", [fromId]);
+      return nonZero({ transactions });
+    })();
+  }
+}
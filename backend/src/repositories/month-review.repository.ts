@@ -0,0 +1,68 @@
+import { MonthReview } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IMonthReviewRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  monthReviewToRow,
+  rowToMonthReview,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class MonthReviewRepositoryJson implements IMonthReviewRepository {
+  findAll(): MonthReview[] {
+    return [...readStore().monthReviews].sort((a, b) =>
+      a.month.localeCompare(b.month),
+    );
+  }
+
+  findByMonth(month: string): MonthReview | undefined {
+    return readStore().monthReviews.find((r) => r.month === month);
+  }
+
+  save(review: MonthReview): MonthReview {
+    const store = readStore();
+    const index = store.monthReviews.findIndex(
+      (r) => r.month === review.month,
+    );
+    if (index === -1) store.monthReviews.push(review);
+    else store.monthReviews[index] = review;
+    writeStore(store);
+    return review;
+  }
+}
+
+// Database-based implementation
+export class MonthReviewRepositoryDb
+  extends BaseDbRepository
+  implements IMonthReviewRepository
+{
+  findAll(): MonthReview[] {
+    return this.findMany(
+      "SELECT * FROM month_reviews ORDER BY month ASC",
+      [],
+      rowToMonthReview,
+    );
+  }
+
+  findByMonth(month: string): MonthReview | undefined {
+    return this.findOne(
+      "SELECT * FROM month_reviews WHERE month = ?",
+      [month],
+      rowToMonthReview,
+    );
+  }
+
+  save(review: MonthReview): MonthReview {
+    const row = monthReviewToRow(review);
+    this.execute(
+      `INSERT INTO month_reviews (month, reviewed_at, transaction_count, note)
+       VALUES (?, ?, ?, ?)
+       ON CONFLICT(month) DO UPDATE SET
+         reviewed_at = excluded.reviewed_at,
+         transaction_count = excluded.transaction_count,
+         note = excluded.note`,
+      [row.month, row.reviewed_at, row.transaction_count, row.note],
+    );
+    return review;
+  }
+}
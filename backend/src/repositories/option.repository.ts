@@ -0,0 +1,134 @@
+import { OptionPosition } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IOptionRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToOption,
+  optionToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class OptionRepositoryJson implements IOptionRepository {
+  findAll(): OptionPosition[] {
+    return readStore().options;
+  }
+
+  findById(id: string): OptionPosition | undefined {
+    return readStore().options.find((p) => p.id === id);
+  }
+
+  findByStatus(status: string): OptionPosition[] {
+    return readStore().options.filter((p) => p.status === status);
+  }
+
+  create(position: OptionPosition): OptionPosition {
+    const store = readStore();
+    store.options.push(position);
+    writeStore(store);
+    return position;
+  }
+
+  update(
+    id: string,
+    updates: Partial<OptionPosition>,
+  ): OptionPosition | undefined {
+    const store = readStore();
+    const index = store.options.findIndex((p) => p.id === id);
+    if (index === -1) return undefined;
+
+    store.options[index] = { ...store.options[index], ...updates, id };
+    writeStore(store);
+    return store.options[index];
+  }
+}
+
+// Database-based implementation
+export class OptionRepositoryDb
+  extends BaseDbRepository
+  implements IOptionRepository
+{
+  findAll(): OptionPosition[] {
+    return this.findMany(
+      "SELECT * FROM option_positions ORDER BY expiry ASC",
+      [],
+      rowToOption,
+    );
+  }
+
+  findById(id: string): OptionPosition | undefined {
+    return this.findOne(
+      "SELECT * FROM option_positions WHERE id = ?",
+      [id],
+      rowToOption,
+    );
+  }
+
+  findByStatus(status: string): OptionPosition[] {
+    return this.findMany(
+      "SELECT * FROM option_positions WHERE status = ? ORDER BY expiry ASC",
+      [status],
+      rowToOption,
+    );
+  }
+
+  create(position: OptionPosition): OptionPosition {
+    const row = optionToRow(position);
+    this.execute(
+      `INSERT INTO option_positions (
+        id, underlying_type, underlying_symbol, option_type, side, contracts,
+        multiplier, strike, expiry, premium, premium_asset_type,
+        premium_asset_symbol, premium_usd, account, status, opened_at,
+        settled_at, settlement_price, realized_pnl_usd, note, created_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.underlying_type,
+        row.underlying_symbol,
+        row.option_type,
+        row.side,
+        row.contracts,
+        row.multiplier,
+        row.strike,
+        row.expiry,
+        row.premium,
+        row.premium_asset_type,
+        row.premium_asset_symbol,
+        row.premium_usd,
+        row.account,
+        row.status,
+        row.opened_at,
+        row.settled_at,
+        row.settlement_price,
+        row.realized_pnl_usd,
+        row.note,
+        row.created_at,
+      ],
+    );
+    return position;
+  }
+
+  update(
+    id: string,
+    updates: Partial<OptionPosition>,
+  ): OptionPosition | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = optionToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE option_positions SET
+        status = ?, settled_at = ?, settlement_price = ?,
+        realized_pnl_usd = ?, note = ?
+      WHERE id = ?`,
+      [
+        row.status,
+        row.settled_at,
+        row.settlement_price,
+        row.realized_pnl_usd,
+        row.note,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+}
@@ -110,6 +110,39 @@ export class PriceCacheRepository extends BaseDbRepository {
     return result.changes;
   }
 
+  /**
+   * Count / delete rates whose price date is before a cutoff (retention)
+   */
+  countBefore(date: Date): number {
+    const row = this.findOne(
+      `SELECT COUNT(*) as count FROM price_cache WHERE timestamp < ?`,
+      [date.toISOString()],
+      (r: any) => r.count,
+    );
+    return row || 0;
+  }
+
+  deleteBefore(date: Date): number {
+    const result = this.execute(
+      `DELETE FROM price_cache WHERE timestamp < ?`,
+      [date.toISOString()],
+    );
+    return result.changes;
+  }
+
+  /**
+   * Delete placeholder rates cached when no provider could price the asset
+   * (FIXED for anything but USD), so the next lookup retries a provider
+   */
+  deletePlaceholdersBefore(date: Date): number {
+    const result = this.execute(
+      `DELETE FROM price_cache
+       WHERE source = 'FIXED' AND asset_symbol != 'USD' AND created_at < ?`,
+      [date.toISOString()],
+    );
+    return result.changes;
+  }
+
   /**
    * Get count of cached entries for an asset
    */
@@ -137,6 +170,32 @@ export class PriceCacheRepository extends BaseDbRepository {
     return row || null;
   }
 
+  /**
+   * Get the most recent provider-sourced rate at or before a date
+   */
+  getLatestRate(asset: Asset, before: Date): Rate | null {
+    const row = this.findOne(
+      `SELECT * FROM price_cache
+       WHERE asset_type = ? AND asset_symbol = ?
+       AND timestamp <= ? AND source != 'FIXED'
+       ORDER BY timestamp DESC LIMIT 1`,
+      [asset.type, asset.symbol.toUpperCase(), before.toISOString()],
+      (r: any) => r,
+    );
+
+    if (!row) return null;
+
+    return {
+      asset: {
+        type: row.asset_type,
+        symbol: row.asset_symbol,
+      },
+      rateUSD: row.rate_usd,
+      timestamp: row.timestamp,
+      source: row.source,
+    };
+  }
+
   /**
    * Get the oldest cached timestamp for an asset
    */
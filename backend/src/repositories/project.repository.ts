@@ -0,0 +1,140 @@
+import { Project } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IProjectRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToProject,
+  projectToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class ProjectRepositoryJson implements IProjectRepository {
+  findAll(): Project[] {
+    return readStore().projects;
+  }
+
+  findById(id: string): Project | undefined {
+    return readStore().projects.find((p) => p.id === id);
+  }
+
+  findByStatus(status: string): Project[] {
+    return readStore().projects.filter((p) => p.status === status);
+  }
+
+  create(project: Project): Project {
+    const store = readStore();
+    store.projects.push(project);
+    writeStore(store);
+    return project;
+  }
+
+  update(id: string, updates: Partial<Project>): Project | undefined {
+    const store = readStore();
+    const index = store.projects.findIndex((p) => p.id === id);
+    if (index === -1) return undefined;
+
+    store.projects[index] = { ...store.projects[index], ...updates, id };
+    writeStore(store);
+    return store.projects[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.projects.length;
+    store.projects = store.projects.filter((p) => p.id !== id);
+    // Unassign transactions that pointed at the deleted project
+    for (const t of store.transactions) {
+      if (t.projectId === id) delete t.projectId;
+    }
+    writeStore(store);
+    return store.projects.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class ProjectRepositoryDb
+  extends BaseDbRepository
+  implements IProjectRepository
+{
+  findAll(): Project[] {
+    return this.findMany(
+      "SELECT * FROM projects ORDER BY created_at DESC",
+      [],
+      rowToProject,
+    );
+  }
+
+  findById(id: string): Project | undefined {
+    return this.findOne(
+      "SELECT * FROM projects WHERE id = ?",
+      [id],
+      rowToProject,
+    );
+  }
+
+  findByStatus(status: string): Project[] {
+    return this.findMany(
+      "SELECT * FROM projects WHERE status = ? ORDER BY created_at DESC",
+      [status],
+      rowToProject,
+    );
+  }
+
+  create(project: Project): Project {
+    const row = projectToRow(project);
+    this.execute(
+      `INSERT INTO projects (id, name, kind, start_at, end_at, note, status, created_at)
+       VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.name,
+        row.kind,
+        row.start_at,
+        row.end_at,
+        row.note,
+        row.status,
+        row.created_at,
+      ],
+    );
+    return project;
+  }
+
+  update(id: string, updates: Partial<Project>): Project | undefined {
+    const fields: string[] = [];
+    const values: any[] = [];
+
+    const columns: Array<[keyof Project, string]> = [
+      ["name", "name"],
+      ["kind", "kind"],
+      ["startAt", "start_at"],
+      ["endAt", "end_at"],
+      ["note", "note"],
+      ["status", "status"],
+    ];
+
+    for (const [field, column] of columns) {
+      if (field in updates) {
+        fields.push(`${column} = ?`);
+        values.push(updates[field] ?? null);
+      }
+    }
+
+    if (fields.length === 0) return this.findById(id);
+
+    values.push(id);
+    this.execute(
+      `UPDATE projects SET ${fields.join(", ")} WHERE id = ?`,
+      values,
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    this.execute(
+      "UPDATE transactions SET project_id = NULL WHERE project_id = ?",
+      [id],
+    );
+    const result = this.execute("DELETE FROM projects WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+}
@@ -0,0 +1,176 @@
+import { RecurringTransaction } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IRecurringRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToRecurring,
+  recurringToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class RecurringRepositoryJson implements IRecurringRepository {
+  findAll(): RecurringTransaction[] {
+    return readStore().recurring;
+  }
+
+  findById(id: string): RecurringTransaction | undefined {
+    return readStore().recurring.find((r) => r.id === id);
+  }
+
+  findDue(now: string): RecurringTransaction[] {
+    return readStore()
+      .recurring.filter((r) => r.active && r.nextRunAt && r.nextRunAt <= now)
+      .sort((a, b) => a.nextRunAt!.localeCompare(b.nextRunAt!));
+  }
+
+  create(recurring: RecurringTransaction): RecurringTransaction {
+    const store = readStore();
+    store.recurring.push(recurring);
+    writeStore(store);
+    return recurring;
+  }
+
+  update(
+    id: string,
+    updates: Partial<RecurringTransaction>,
+  ): RecurringTransaction | undefined {
+    const store = readStore();
+    const index = store.recurring.findIndex((r) => r.id === id);
+    if (index === -1) return undefined;
+
+    store.recurring[index] = { ...store.recurring[index], ...updates, id };
+    writeStore(store);
+    return store.recurring[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.recurring.length;
+    store.recurring = store.recurring.filter((r) => r.id !== id);
+    writeStore(store);
+    return store.recurring.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class RecurringRepositoryDb
+  extends BaseDbRepository
+  implements IRecurringRepository
+{
+  findAll(): RecurringTransaction[] {
+    return this.findMany(
+      "SELECT * FROM recurring_transactions ORDER BY created_at ASC",
+      [],
+      rowToRecurring,
+    );
+  }
+
+  findById(id: string): RecurringTransaction | undefined {
+    return this.findOne(
+      "SELECT * FROM recurring_transactions WHERE id = ?",
+      [id],
+      rowToRecurring,
+    );
+  }
+
+  findDue(now: string): RecurringTransaction[] {
+    return this.findMany(
+      `SELECT * FROM recurring_transactions
+      WHERE active = 1 AND next_run_at IS NOT NULL AND next_run_at <= ?
+      ORDER BY next_run_at ASC`,
+      [now],
+      rowToRecurring,
+    );
+  }
+
+  create(recurring: RecurringTransaction): RecurringTransaction {
+    const row = recurringToRow(recurring);
+    this.execute(
+      `INSERT INTO recurring_transactions (
+        id, name, type, asset_type, asset_symbol, amount, account, note,
+        category, tags, counterparty, rule, start_at, next_run_at,
+        occurrences, confirm, pending_dates, active, last_occurrence_at,
+        last_error, drift, created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?)`,
+      [
+        row.id,
+        row.name,
+        row.type,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.account,
+        row.note,
+        row.category,
+        row.tags,
+        row.counterparty,
+        row.rule,
+        row.start_at,
+        row.next_run_at,
+        row.occurrences,
+        row.confirm,
+        row.pending_dates,
+        row.active,
+        row.last_occurrence_at,
+        row.last_error,
+        row.drift,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return recurring;
+  }
+
+  update(
+    id: string,
+    updates: Partial<RecurringTransaction>,
+  ): RecurringTransaction | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = recurringToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE recurring_transactions SET
+        name = ?, type = ?, asset_type = ?, asset_symbol = ?, amount = ?,
+        account = ?, note = ?, category = ?, tags = ?, counterparty = ?,
+        rule = ?, start_at = ?, next_run_at = ?, occurrences = ?,
+        confirm = ?, pending_dates = ?, active = ?, last_occurrence_at = ?,
+        last_error = ?, drift = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.type,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.account,
+        row.note,
+        row.category,
+        row.tags,
+        row.counterparty,
+        row.rule,
+        row.start_at,
+        row.next_run_at,
+        row.occurrences,
+        row.confirm,
+        row.pending_dates,
+        row.active,
+        row.last_occurrence_at,
+        row.last_error,
+        row.drift,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute(
+      "DELETE FROM recurring_transactions WHERE id = ?",
+      [id],
+    );
+    return result.changes > 0;
+  }
+}
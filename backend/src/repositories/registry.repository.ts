@@ -0,0 +1,164 @@
+import { RegistryItem } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IRegistryRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToRegistryItem,
+  registryItemToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class RegistryRepositoryJson implements IRegistryRepository {
+  findAll(): RegistryItem[] {
+    return readStore().registry;
+  }
+
+  findById(id: string): RegistryItem | undefined {
+    return readStore().registry.find((r) => r.id === id);
+  }
+
+  findByStatus(status: string): RegistryItem[] {
+    return readStore().registry.filter((r) => r.status === status);
+  }
+
+  findByTransactionId(transactionId: string): RegistryItem[] {
+    return readStore().registry.filter(
+      (r) => r.transactionId === transactionId,
+    );
+  }
+
+  create(item: RegistryItem): RegistryItem {
+    const store = readStore();
+    store.registry.push(item);
+    writeStore(store);
+    return item;
+  }
+
+  update(
+    id: string,
+    updates: Partial<RegistryItem>,
+  ): RegistryItem | undefined {
+    const store = readStore();
+    const index = store.registry.findIndex((r) => r.id === id);
+    if (index === -1) return undefined;
+
+    store.registry[index] = { ...store.registry[index], ...updates, id };
+    writeStore(store);
+    return store.registry[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.registry.length;
+    store.registry = store.registry.filter((r) => r.id !== id);
+    writeStore(store);
+    return store.registry.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class RegistryRepositoryDb
+  extends BaseDbRepository
+  implements IRegistryRepository
+{
+  findAll(): RegistryItem[] {
+    return this.findMany(
+      "SELECT * FROM registry_items ORDER BY created_at DESC",
+      [],
+      rowToRegistryItem,
+    );
+  }
+
+  findById(id: string): RegistryItem | undefined {
+    return this.findOne(
+      "SELECT * FROM registry_items WHERE id = ?",
+      [id],
+      rowToRegistryItem,
+    );
+  }
+
+  findByStatus(status: string): RegistryItem[] {
+    return this.findMany(
+      "SELECT * FROM registry_items WHERE status = ? ORDER BY created_at DESC",
+      [status],
+      rowToRegistryItem,
+    );
+  }
+
+  findByTransactionId(transactionId: string): RegistryItem[] {
+    return this.findMany(
+      "SELECT * FROM registry_items WHERE transaction_id = ?",
+      [transactionId],
+      rowToRegistryItem,
+    );
+  }
+
+  create(item: RegistryItem): RegistryItem {
+    const row = registryItemToRow(item);
+    this.execute(
+      `INSERT INTO registry_items (
+        id, kind, name, transaction_id, asset_type, asset_symbol, amount,
+        cadence, next_renewal_at, cancel_by, warranty_expires_at, status,
+        note, last_notified_at, created_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.kind,
+        row.name,
+        row.transaction_id,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.cadence,
+        row.next_renewal_at,
+        row.cancel_by,
+        row.warranty_expires_at,
+        row.status,
+        row.note,
+        row.last_notified_at,
+        row.created_at,
+      ],
+    );
+    return item;
+  }
+
+  update(
+    id: string,
+    updates: Partial<RegistryItem>,
+  ): RegistryItem | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = registryItemToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE registry_items SET
+        name = ?, transaction_id = ?, asset_type = ?, asset_symbol = ?,
+        amount = ?, cadence = ?, next_renewal_at = ?, cancel_by = ?,
+        warranty_expires_at = ?, status = ?, note = ?, last_notified_at = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.transaction_id,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.cadence,
+        row.next_renewal_at,
+        row.cancel_by,
+        row.warranty_expires_at,
+        row.status,
+        row.note,
+        row.last_notified_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM registry_items WHERE id = ?", [
+      id,
+    ]);
+    return result.changes > 0;
+  }
+}
@@ -0,0 +1,162 @@
+import { ReportSubscription } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IReportSubscriptionRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToReportSubscription,
+  reportSubscriptionToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class ReportSubscriptionRepositoryJson
+  implements IReportSubscriptionRepository
+{
+  findAll(): ReportSubscription[] {
+    return readStore().reportSubscriptions;
+  }
+
+  findById(id: string): ReportSubscription | undefined {
+    return readStore().reportSubscriptions.find((s) => s.id === id);
+  }
+
+  findDue(now: string): ReportSubscription[] {
+    return readStore()
+      .reportSubscriptions.filter((s) => s.active && s.nextRunAt <= now)
+      .sort((a, b) => a.nextRunAt.localeCompare(b.nextRunAt));
+  }
+
+  create(subscription: ReportSubscription): ReportSubscription {
+    const store = readStore();
+    store.reportSubscriptions.push(subscription);
+    writeStore(store);
+    return subscription;
+  }
+
+  update(
+    id: string,
+    updates: Partial<ReportSubscription>,
+  ): ReportSubscription | undefined {
+    const store = readStore();
+    const index = store.reportSubscriptions.findIndex((s) => s.id === id);
+    if (index === -1) return undefined;
+
+    store.reportSubscriptions[index] = {
+      ...store.reportSubscriptions[index],
+      ...updates,
+      id,
+    };
+    writeStore(store);
+    return store.reportSubscriptions[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.reportSubscriptions.length;
+    store.reportSubscriptions = store.reportSubscriptions.filter(
+      (s) => s.id !== id,
+    );
+    writeStore(store);
+    return store.reportSubscriptions.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class ReportSubscriptionRepositoryDb
+  extends BaseDbRepository
+  implements IReportSubscriptionRepository
+{
+  findAll(): ReportSubscription[] {
+    return this.findMany(
+      "SELECT * FROM report_subscriptions ORDER BY created_at ASC",
+      [],
+      rowToReportSubscription,
+    );
+  }
+
+  findById(id: string): ReportSubscription | undefined {
+    return this.findOne(
+      "SELECT * FROM report_subscriptions WHERE id = ?",
+      [id],
+      rowToReportSubscription,
+    );
+  }
+
+  findDue(now: string): ReportSubscription[] {
+    return this.findMany(
+      `SELECT * FROM report_subscriptions
+      WHERE active = 1 AND next_run_at <= ?
+      ORDER BY next_run_at ASC`,
+      [now],
+      rowToReportSubscription,
+    );
+  }
+
+  create(subscription: ReportSubscription): ReportSubscription {
+    const row = reportSubscriptionToRow(subscription);
+    this.execute(
+      `INSERT INTO report_subscriptions (
+        id, name, report, period_mode, filters, currency, cadence,
+        next_run_at, active, last_run_at, last_status, last_error,
+        created_at, updated_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.name,
+        row.report,
+        row.period_mode,
+        row.filters,
+        row.currency,
+        row.cadence,
+        row.next_run_at,
+        row.active,
+        row.last_run_at,
+        row.last_status,
+        row.last_error,
+        row.created_at,
+        row.updated_at,
+      ],
+    );
+    return subscription;
+  }
+
+  update(
+    id: string,
+    updates: Partial<ReportSubscription>,
+  ): ReportSubscription | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = reportSubscriptionToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE report_subscriptions SET
+        name = ?, report = ?, period_mode = ?, filters = ?, currency = ?,
+        cadence = ?, next_run_at = ?, active = ?, last_run_at = ?,
+        last_status = ?, last_error = ?, updated_at = ?
+      WHERE id = ?`,
+      [
+        row.name,
+        row.report,
+        row.period_mode,
+        row.filters,
+        row.currency,
+        row.cadence,
+        row.next_run_at,
+        row.active,
+        row.last_run_at,
+        row.last_status,
+        row.last_error,
+        row.updated_at,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute(
+      "DELETE FROM report_subscriptions WHERE id = ?",
+      [id],
+    );
+    return result.changes > 0;
+  }
+}
@@ -0,0 +1,31 @@
+import { Transaction } from "../types";
+import { readStore } from "./base.repository";
+import { IReportingRepository } from "./repository.interface";
+import { BaseDbRepository, rowToTransaction } from "./base-db.repository";
+
+// JSON-based implementation
+export class ReportingRepositoryJson implements IReportingRepository {
+  findLedgerUntil(endDate: string): Transaction[] {
+    return readStore()
+      .transactions.filter((t) => !t.deletedAt && t.createdAt <= endDate)
+      .sort(
+        (a, b) =>
+          a.createdAt.localeCompare(b.createdAt) || a.id.localeCompare(b.id),
+      );
+  }
+}
+
+// Database-based implementation
+export class ReportingRepositoryDb
+  extends BaseDbRepository
+  implements IReportingRepository
+{
+  findLedgerUntil(endDate: string): Transaction[] {
+    return this.findMany(
+      `SELECT * FROM transactions WHERE created_at <= ? AND deleted_at IS NULL
+      ORDER BY created_at ASC, id ASC`,
+      [endDate],
+      rowToTransaction,
+    );
+  }
+}
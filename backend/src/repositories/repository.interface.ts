@@ -1,13 +1,49 @@
 import {
+  Page,
+  PageRequest,
   Transaction,
   Vault,
   VaultEntry,
   LoanAgreement,
   BorrowingAgreement,
+  SpendingExclusionRule,
+  CostBasisSettings,
+  SheetsExport,
+  ExchangeConnector,
+  EvmWallet,
+  ManualPrice,
+  FiscalYearSettings,
+  IncomeTaxSettings,
+  PriceProvider,
+  OverdrawPolicy,
+  Project,
+  RegistryItem,
+  FixedIncomeInstrument,
+  OptionPosition,
+  VestingGrant,
+  VestEvent,
+  AddressBookEntry,
+  AllocationGlidepath,
+  DepositRate,
+  TrashItem,
+  ReportSubscription,
+  RecurringTransaction,
+  Budget,
+  Goal,
+  Webhook,
+  YearClose,
+  MonthReview,
+  AuditEntry,
+  AuditFilter,
+  AccountBalance,
+  ActionIntent,
+  Asset,
+  AllocationConstraint,
+  TransactionRule,
+  AdminAccount,
 } from "../types";
 import {
   AdminType,
-  AdminAccount,
   AdminAsset,
   AdminTag,
   PendingAction,
@@ -17,14 +53,34 @@ import {
 // Transaction repository interface
 export interface ITransactionRepository {
   findAll(): Transaction[];
+  // Newest first by createdAt, then id
+  findPage(page: PageRequest): Page<Transaction>;
+  count(): number;
   findById(id: string): Transaction | undefined;
   findByLoanId(loanId: string): Transaction[];
+  findByProjectId(projectId: string): Transaction[];
   create(transaction: Transaction): Transaction;
-  delete(id: string): boolean;
+  createMany(transactions: Transaction[]): Transaction[];
+  update(id: string, updates: Partial<Transaction>): Transaction | undefined;
+  delete(id: string): boolean; // permanent
+  // Soft delete: hidden from every other read until restored or purged
+  softDelete(id: string, deletedAt: string): boolean;
+  restore(id: string): Transaction | undefined;
+  findDeleted(): Transaction[]; // most recently deleted first
+  purgeDeleted(before: string): number; // deleted at or before this ISO time
   findByAccount(account: string): Transaction[];
   findByType(type: string): Transaction[];
   findByDateRange(startDate: string, endDate: string): Transaction[];
-  findBySourceRef(sourceRef: string): Transaction | undefined;
+  // includeDeleted for dedupe: a deleted transaction keeps its source ref,
+  // so whatever created it must not create it again
+  findBySourceRef(
+    sourceRef: string,
+    opts?: { includeDeleted?: boolean },
+  ): Transaction | undefined;
+  findSpending(params: {
+    account: string;
+    exclusions?: SpendingExclusionRule[];
+  }): Transaction[];
   findExisting(params: {
     sourceRef?: string;
     date: string;
@@ -43,6 +99,8 @@ export interface IVaultRepository {
   update(name: string, updates: Partial<Vault>): Vault | undefined;
   delete(name: string): boolean;
   findAllEntries(vaultName: string): VaultEntry[];
+  // Newest first by at, then insertion order
+  findEntriesPage(vaultName: string, page: PageRequest): Page<VaultEntry>;
   createEntry(entry: VaultEntry): VaultEntry;
   findEntriesByType(vaultName: string, type: string): VaultEntry[];
   findEntriesByDateRange(
@@ -50,6 +108,12 @@ export interface IVaultRepository {
     startDate: string,
     endDate: string,
   ): VaultEntry[];
+  // Entries across all vaults at or before endDate, oldest first
+  findAllEntriesUntil(endDate: string): VaultEntry[];
+  // Remove entries of one vault and/or strictly before a date
+  deleteEntries(params: { vault?: string; before?: string }): number;
+  // The latest entry of the same vault, type, asset, amount and time
+  deleteEntry(entry: VaultEntry): boolean;
 }
 
 // Loan repository interface
@@ -113,6 +177,126 @@ export interface IAdminRepository {
   deleteTag(id: number): boolean;
 }
 
+// Project repository interface
+export interface IProjectRepository {
+  findAll(): Project[];
+  findById(id: string): Project | undefined;
+  findByStatus(status: string): Project[];
+  create(project: Project): Project;
+  update(id: string, updates: Partial<Project>): Project | undefined;
+  delete(id: string): boolean;
+}
+
+// Subscription / warranty registry repository interface
+export interface IRegistryRepository {
+  findAll(): RegistryItem[];
+  findById(id: string): RegistryItem | undefined;
+  findByStatus(status: string): RegistryItem[];
+  findByTransactionId(transactionId: string): RegistryItem[];
+  create(item: RegistryItem): RegistryItem;
+  update(id: string, updates: Partial<RegistryItem>): RegistryItem | undefined;
+  delete(id: string): boolean;
+}
+
+// Fixed income (term deposit / bond) repository interface
+export interface IFixedIncomeRepository {
+  findAll(): FixedIncomeInstrument[];
+  findById(id: string): FixedIncomeInstrument | undefined;
+  findByStatus(status: string): FixedIncomeInstrument[];
+  create(item: FixedIncomeInstrument): FixedIncomeInstrument;
+  update(
+    id: string,
+    updates: Partial<FixedIncomeInstrument>,
+  ): FixedIncomeInstrument | undefined;
+  delete(id: string): boolean;
+}
+
+// Options position repository interface
+export interface IOptionRepository {
+  findAll(): OptionPosition[];
+  findById(id: string): OptionPosition | undefined;
+  findByStatus(status: string): OptionPosition[];
+  create(position: OptionPosition): OptionPosition;
+  update(
+    id: string,
+    updates: Partial<OptionPosition>,
+  ): OptionPosition | undefined;
+}
+
+// Vesting grant and vest event repository interface
+export interface IVestingRepository {
+  findAll(): VestingGrant[];
+  findById(id: string): VestingGrant | undefined;
+  create(grant: VestingGrant): VestingGrant;
+  update(
+    id: string,
+    updates: Partial<VestingGrant>,
+  ): VestingGrant | undefined;
+  findEvents(grantId: string): VestEvent[];
+  // SCHEDULED events across all grants dated at or before `until`
+  findDueEvents(until: string): VestEvent[];
+  createEvent(event: VestEvent): VestEvent;
+  updateEvent(id: string, updates: Partial<VestEvent>): VestEvent | undefined;
+}
+
+// Address book repository interface
+export interface IAddressBookRepository {
+  findAll(): AddressBookEntry[];
+  findById(id: string): AddressBookEntry | undefined;
+  findByName(name: string): AddressBookEntry | undefined;
+  create(entry: AddressBookEntry): AddressBookEntry;
+  update(
+    id: string,
+    updates: Partial<AddressBookEntry>,
+  ): AddressBookEntry | undefined;
+  delete(id: string): boolean;
+}
+
+// Allocation glidepath repository interface
+export interface IGlidepathRepository {
+  findAll(): AllocationGlidepath[];
+  findById(id: string): AllocationGlidepath | undefined;
+  create(glidepath: AllocationGlidepath): AllocationGlidepath;
+  update(
+    id: string,
+    updates: Partial<AllocationGlidepath>,
+  ): AllocationGlidepath | undefined;
+  delete(id: string): boolean;
+}
+
+// Deposit rate repository interface
+export interface IDepositRateRepository {
+  findAll(): DepositRate[]; // by effective date, then term
+  findById(id: string): DepositRate | undefined;
+  create(rate: DepositRate): DepositRate;
+  update(id: string, updates: Partial<DepositRate>): DepositRate | undefined;
+  delete(id: string): boolean;
+}
+
+// Trash repository interface
+export interface ITrashRepository {
+  findAll(): TrashItem[];
+  findById(id: string): TrashItem | undefined;
+  create(item: TrashItem): TrashItem;
+  delete(id: string): boolean;
+  // Remove items whose expiresAt is at or before `now`; returns the count
+  deleteExpired(now: string): number;
+}
+
+// Report subscription repository interface
+export interface IReportSubscriptionRepository {
+  findAll(): ReportSubscription[];
+  findById(id: string): ReportSubscription | undefined;
+  // Active subscriptions whose nextRunAt is at or before `now`
+  findDue(now: string): ReportSubscription[];
+  create(subscription: ReportSubscription): ReportSubscription;
+  update(
+    id: string,
+    updates: Partial<ReportSubscription>,
+  ): ReportSubscription | undefined;
+  delete(id: string): boolean;
+}
+
 // Settings repository interface
 export interface ISettingsRepository {
   getSettings(): StoreShape["settings"];
@@ -125,6 +309,50 @@ export interface ISettingsRepository {
   setDefaultSpendingVaultName(name: string): void;
   getDefaultIncomeVaultName(): string;
   setDefaultIncomeVaultName(name: string): void;
+  getMaxManualPriceChangePercent(): number;
+  setMaxManualPriceChangePercent(percent: number): void;
+  getDepegThresholdPercent(): number;
+  setDepegThresholdPercent(percent: number): void;
+  getCardFxMarkupPercent(): number;
+  setCardFxMarkupPercent(percent: number): void;
+  getCashLeakageTag(): string; // tag of cash-count adjustments
+  setCashLeakageTag(tag: string): void;
+  getPriceSourcePriority(): Record<string, PriceProvider[]>;
+  setPriceSourcePriority(priority: Record<string, PriceProvider[]>): void;
+  getPriceDiscrepancyThresholdPercent(): number;
+  setPriceDiscrepancyThresholdPercent(percent: number): void;
+  getHomeJurisdiction(): string;
+  setHomeJurisdiction(country: string): void;
+  getOverdrawPolicy(): OverdrawPolicy; // default REJECT
+  setOverdrawPolicy(policy: OverdrawPolicy): void;
+  getReportingCurrency(): string; // extra report currency; default VND
+  setReportingCurrency(currency: string): void;
+  getDisplayPrecision(): Record<string, number>;
+  setDisplayPrecision(precision: Record<string, number>): void;
+  getSpendingExclusionRules(): SpendingExclusionRule[];
+  setSpendingExclusionRules(rules: SpendingExclusionRule[]): void;
+  getAllocationConstraints(): AllocationConstraint[];
+  setAllocationConstraints(constraints: AllocationConstraint[]): void;
+  getTransactionRules(): TransactionRule[];
+  setTransactionRules(rules: TransactionRule[]): void;
+  getCostBasisSettings(): CostBasisSettings;
+  setCostBasisSettings(settings: CostBasisSettings): void;
+  getManualPrices(): ManualPrice[];
+  setManualPrices(prices: ManualPrice[]): void;
+  getLongTermHoldingDays(): number; // holding period for long-term gains
+  setLongTermHoldingDays(days: number): void;
+  getLastPriceSnapshotDay(): string | undefined; // YYYY-MM-DD
+  setLastPriceSnapshotDay(day: string): void;
+  getFiscalYear(): FiscalYearSettings; // default: the calendar year
+  setFiscalYear(fy: FiscalYearSettings): void;
+  getIncomeTaxSettings(): IncomeTaxSettings; // default: VN PIT
+  setIncomeTaxSettings(settings: IncomeTaxSettings): void;
+  getSheetsExport(): SheetsExport | undefined;
+  setSheetsExport(exp: SheetsExport | undefined): void; // undefined removes
+  getExchangeConnectors(): ExchangeConnector[];
+  setExchangeConnectors(connectors: ExchangeConnector[]): void;
+  getEvmWallets(): EvmWallet[];
+  setEvmWallets(wallets: EvmWallet[]): void;
 
   // Borrowing settings
   getBorrowingSettings(): {
@@ -158,3 +386,108 @@ export interface IPendingActionsRepository {
   ): PendingAction | undefined;
   delete(id: string): boolean;
 }
+
+export interface IRecurringRepository {
+  findAll(): RecurringTransaction[];
+  findById(id: string): RecurringTransaction | undefined;
+  // Active entries whose nextRunAt is at or before `now`
+  findDue(now: string): RecurringTransaction[];
+  create(recurring: RecurringTransaction): RecurringTransaction;
+  update(
+    id: string,
+    updates: Partial<RecurringTransaction>,
+  ): RecurringTransaction | undefined;
+  delete(id: string): boolean;
+}
+
+// Read-only queries behind the history reports (net worth series)
+export interface IReportingRepository {
+  // Transactions at or before endDate, oldest first
+  findLedgerUntil(endDate: string): Transaction[];
+}
+
+export interface IBudgetRepository {
+  findAll(): Budget[];
+  findById(id: string): Budget | undefined;
+  findByTag(tag: string): Budget | undefined;
+  create(budget: Budget): Budget;
+  update(id: string, updates: Partial<Budget>): Budget | undefined;
+  delete(id: string): boolean;
+}
+
+export interface IGoalRepository {
+  findAll(): Goal[];
+  findById(id: string): Goal | undefined;
+  create(goal: Goal): Goal;
+  update(id: string, updates: Partial<Goal>): Goal | undefined;
+  delete(id: string): boolean;
+}
+
+export interface IWebhookRepository {
+  findAll(): Webhook[];
+  findById(id: string): Webhook | undefined;
+  create(webhook: Webhook): Webhook;
+  update(id: string, updates: Partial<Webhook>): Webhook | undefined;
+  delete(id: string): boolean;
+}
+
+// Closed years are archived once and never rewritten
+export interface IYearCloseRepository {
+  findAll(): YearClose[]; // by year
+  findByYear(year: number): YearClose | undefined;
+  create(close: YearClose): YearClose;
+}
+
+// Month review repository interface
+export interface IMonthReviewRepository {
+  findAll(): MonthReview[];
+  findByMonth(month: string): MonthReview | undefined;
+  // Insert, or replace the month's earlier sign-off
+  save(review: MonthReview): MonthReview;
+}
+
+// The audit log is append-only
+export interface IAuditRepository {
+  append(entry: Omit<AuditEntry, "id">): AuditEntry;
+  appendMany(entries: Omit<AuditEntry, "id">[]): AuditEntry[]; // one write
+  // Newest first by at, then id
+  findPage(filter: AuditFilter, page: PageRequest): Page<AuditEntry>;
+}
+
+// Materialized balances, one row per account and asset. Accounts match
+// case-insensitively
+export interface IAccountBalanceRepository {
+  findAll(): AccountBalance[];
+  findByAccount(account: string): AccountBalance[];
+  // Add `quantity` units and `count` transactions, creating the row if new
+  apply(
+    account: string,
+    asset: Asset,
+    quantity: number,
+    count: number,
+    at?: string,
+  ): void;
+  replaceAll(balances: AccountBalance[]): void;
+}
+
+export interface IActionIntentRepository {
+  findAll(): ActionIntent[]; // newest first
+  findById(id: string): ActionIntent | undefined;
+  findByClientRef(clientRef: string): ActionIntent | undefined;
+  create(intent: ActionIntent): ActionIntent;
+  update(
+    id: string,
+    updates: Partial<ActionIntent>,
+  ): ActionIntent | undefined;
+}
+
+// Rows changed per table by a merge, e.g. { transactions: 12 }
+export type MergeCounts = Record<string, number>;
+
+// Merging duplicate admin entries: every reference to `from` moves onto
+// `into` and the admin row `fromId` is removed, all or nothing
+export interface IMergeRepository {
+  mergeAccount(fromId: number, from: string, into: string): MergeCounts;
+  mergeAsset(fromId: number, from: string, into: string): MergeCounts;
+  mergeTag(fromId: number, from: string, into: string): MergeCounts;
+}
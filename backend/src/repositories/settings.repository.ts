@@ -1,6 +1,28 @@
 import { readStore, writeStore, StoreShape } from "./base.repository";
 import { ISettingsRepository } from "./repository.interface";
 import { BaseDbRepository } from "./base-db.repository";
+import { DEFAULT_DISPLAY_PRECISION } from "../utils/number.util";
+import {
+  AllocationConstraint,
+  CostBasisSettings,
+  CostBasisSettingsSchema,
+  EvmWallet,
+  ExchangeConnector,
+  FiscalYearSettings,
+  IncomeTaxSettings,
+  IncomeTaxSettingsSchema,
+  ManualPrice,
+  OVERDRAW_POLICIES,
+  OverdrawPolicy,
+  PRICE_PROVIDERS,
+  PriceProvider,
+  SheetsExport,
+  SpendingExclusionRule,
+  TransactionRule,
+} from "../types";
+
+// Tag of the transactions a cash count books for untracked cash spending
+export const DEFAULT_CASH_LEAKAGE_TAG = "cash_leakage";
 
 export interface BorrowingSettings {
   name: string;
@@ -8,6 +30,82 @@ export interface BorrowingSettings {
   lastAccrualStart: string;
 }
 
+function parseDisplayPrecision(raw?: string): Record<string, number> {
+  const precision = { ...DEFAULT_DISPLAY_PRECISION };
+  if (!raw) return precision;
+  try {
+    const parsed = JSON.parse(raw);
+    for (const [symbol, decimals] of Object.entries(parsed || {})) {
+      if (Number.isInteger(decimals) && (decimals as number) >= 0) {
+        precision[symbol.toUpperCase()] = decimals as number;
+      }
+    }
+  } catch {
+    // Ignore malformed setting and use defaults
+  }
+  return precision;
+}
+
+function parsePriceSourcePriority(
+  raw?: string,
+): Record<string, PriceProvider[]> {
+  const priority: Record<string, PriceProvider[]> = {};
+  if (!raw) return priority;
+  try {
+    const parsed = JSON.parse(raw);
+    for (const [symbol, sources] of Object.entries(parsed || {})) {
+      if (!Array.isArray(sources)) continue;
+      const known = sources.filter((s): s is PriceProvider =>
+        (PRICE_PROVIDERS as readonly string[]).includes(s),
+      );
+      if (known.length) priority[symbol.toUpperCase()] = known;
+    }
+  } catch {
+    // Ignore malformed setting and use default provider order
+  }
+  return priority;
+}
+
+function parseCostBasisSettings(raw?: string): CostBasisSettings {
+  try {
+    const parsed = CostBasisSettingsSchema.safeParse(JSON.parse(raw || "{}"));
+    if (parsed.success) return parsed.data;
+  } catch {
+    // Ignore malformed setting and use defaults
+  }
+  return { byAsset: {}, byVault: {} };
+}
+
+function parseIncomeTaxSettings(raw?: string): IncomeTaxSettings {
+  try {
+    const parsed = IncomeTaxSettingsSchema.safeParse(JSON.parse(raw || "{}"));
+    if (parsed.success) return parsed.data;
+  } catch {
+    // Ignore malformed setting and use defaults
+  }
+  return IncomeTaxSettingsSchema.parse({});
+}
+
+function parseJsonObject<T>(raw?: string): T | undefined {
+  if (!raw) return undefined;
+  try {
+    const parsed = JSON.parse(raw);
+    return parsed && typeof parsed === "object" ? parsed : undefined;
+  } catch {
+    return undefined;
+  }
+}
+
+function parseJsonArray<T>(raw?: string): T[] {
+  if (!raw) return [];
+  try {
+    const parsed = JSON.parse(raw);
+    return Array.isArray(parsed) ? parsed : [];
+  } catch {
+    return [];
+  }
+}
+
 // JSON-based implementation
 export class SettingsRepositoryJson implements ISettingsRepository {
   getSettings(): StoreShape["settings"] {
@@ -81,6 +179,192 @@ export class SettingsRepositoryJson implements ISettingsRepository {
     this.setSetting("defaultIncomeVaultName", name.trim() || "Income");
   }
 
+  getMaxManualPriceChangePercent(): number {
+    const value = Number(this.getSetting("maxManualPriceChangePercent"));
+    return Number.isFinite(value) && value > 0 ? value : 50;
+  }
+
+  setMaxManualPriceChangePercent(percent: number): void {
+    this.setSetting("maxManualPriceChangePercent", String(percent));
+  }
+
+  getDepegThresholdPercent(): number {
+    const value = Number(this.getSetting("depegThresholdPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 1;
+  }
+
+  setDepegThresholdPercent(percent: number): void {
+    this.setSetting("depegThresholdPercent", String(percent));
+  }
+
+  getCardFxMarkupPercent(): number {
+    const value = Number(this.getSetting("cardFxMarkupPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 0;
+  }
+
+  setCardFxMarkupPercent(percent: number): void {
+    this.setSetting("cardFxMarkupPercent", String(percent));
+  }
+
+  getCashLeakageTag(): string {
+    return this.getSetting("cashLeakageTag") || DEFAULT_CASH_LEAKAGE_TAG;
+  }
+
+  setCashLeakageTag(tag: string): void {
+    this.setSetting("cashLeakageTag", tag);
+  }
+
+  getPriceSourcePriority(): Record<string, PriceProvider[]> {
+    return parsePriceSourcePriority(this.getSetting("priceSourcePriority"));
+  }
+
+  setPriceSourcePriority(priority: Record<string, PriceProvider[]>): void {
+    this.setSetting("priceSourcePriority", JSON.stringify(priority));
+  }
+
+  getPriceDiscrepancyThresholdPercent(): number {
+    const value = Number(this.getSetting("priceDiscrepancyThresholdPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 2;
+  }
+
+  setPriceDiscrepancyThresholdPercent(percent: number): void {
+    this.setSetting("priceDiscrepancyThresholdPercent", String(percent));
+  }
+
+  getDisplayPrecision(): Record<string, number> {
+    return parseDisplayPrecision(this.getSetting("displayPrecision"));
+  }
+
+  setDisplayPrecision(precision: Record<string, number>): void {
+    this.setSetting("displayPrecision", JSON.stringify(precision));
+  }
+
+  getSpendingExclusionRules(): SpendingExclusionRule[] {
+    return parseJsonArray(this.getSetting("spendingExclusionRules"));
+  }
+
+  setSpendingExclusionRules(rules: SpendingExclusionRule[]): void {
+    this.setSetting("spendingExclusionRules", JSON.stringify(rules));
+  }
+
+  getAllocationConstraints(): AllocationConstraint[] {
+    return parseJsonArray(this.getSetting("allocationConstraints"));
+  }
+
+  setAllocationConstraints(constraints: AllocationConstraint[]): void {
+    this.setSetting("allocationConstraints", JSON.stringify(constraints));
+  }
+
+  getTransactionRules(): TransactionRule[] {
+    return parseJsonArray(this.getSetting("transactionRules"));
+  }
+
+  setTransactionRules(rules: TransactionRule[]): void {
+    this.setSetting("transactionRules", JSON.stringify(rules));
+  }
+
+  getCostBasisSettings(): CostBasisSettings {
+    return parseCostBasisSettings(this.getSetting("costBasis"));
+  }
+
+  setCostBasisSettings(settings: CostBasisSettings): void {
+    this.setSetting("costBasis", JSON.stringify(settings));
+  }
+
+  getManualPrices(): ManualPrice[] {
+    return parseJsonArray(this.getSetting("manualPrices"));
+  }
+
+  setManualPrices(prices: ManualPrice[]): void {
+    this.setSetting("manualPrices", JSON.stringify(prices));
+  }
+
+  getLongTermHoldingDays(): number {
+    const value = Number(this.getSetting("longTermHoldingDays"));
+    return Number.isInteger(value) && value > 0 ? value : 365;
+  }
+
+  setLongTermHoldingDays(days: number): void {
+    this.setSetting("longTermHoldingDays", String(days));
+  }
+
+  getLastPriceSnapshotDay(): string | undefined {
+    return this.getSetting("lastPriceSnapshotDay");
+  }
+
+  setLastPriceSnapshotDay(day: string): void {
+    this.setSetting("lastPriceSnapshotDay", day);
+  }
+
+  getFiscalYear(): FiscalYearSettings {
+    const fy = parseJsonObject<FiscalYearSettings>(
+      this.getSetting("fiscalYear"),
+    );
+    return { start: fy?.start || "01-01", starts: fy?.starts || {} };
+  }
+
+  setFiscalYear(fy: FiscalYearSettings): void {
+    this.setSetting("fiscalYear", JSON.stringify(fy));
+  }
+
+  getIncomeTaxSettings(): IncomeTaxSettings {
+    return parseIncomeTaxSettings(this.getSetting("incomeTax"));
+  }
+
+  setIncomeTaxSettings(settings: IncomeTaxSettings): void {
+    this.setSetting("incomeTax", JSON.stringify(settings));
+  }
+
+  getSheetsExport(): SheetsExport | undefined {
+    return parseJsonObject<SheetsExport>(this.getSetting("sheetsExport"));
+  }
+
+  setSheetsExport(exp: SheetsExport | undefined): void {
+    if (exp) this.setSetting("sheetsExport", JSON.stringify(exp));
+    else this.deleteSetting("sheetsExport");
+  }
+
+  getExchangeConnectors(): ExchangeConnector[] {
+    return parseJsonArray(this.getSetting("exchangeConnectors"));
+  }
+
+  setExchangeConnectors(connectors: ExchangeConnector[]): void {
+    this.setSetting("exchangeConnectors", JSON.stringify(connectors));
+  }
+
+  getEvmWallets(): EvmWallet[] {
+    return parseJsonArray(this.getSetting("evmWallets"));
+  }
+
+  setEvmWallets(wallets: EvmWallet[]): void {
+    this.setSetting("evmWallets", JSON.stringify(wallets));
+  }
+
+  getHomeJurisdiction(): string {
+    return (this.getSetting("homeJurisdiction") || "VN").toUpperCase();
+  }
+
+  setHomeJurisdiction(country: string): void {
+    this.setSetting("homeJurisdiction", country.trim().toUpperCase());
+  }
+
+  getOverdrawPolicy(): OverdrawPolicy {
+    const policy = this.getSetting("overdrawPolicy") as OverdrawPolicy;
+    return OVERDRAW_POLICIES.includes(policy) ? policy : "REJECT";
+  }
+
+  setOverdrawPolicy(policy: OverdrawPolicy): void {
+    this.setSetting("overdrawPolicy", policy);
+  }
+
+  getReportingCurrency(): string {
+    return (this.getSetting("reportingCurrency") || "VND").toUpperCase();
+  }
+
+  setReportingCurrency(currency: string): void {
+    this.setSetting("reportingCurrency", currency.trim().toUpperCase());
+  }
+
   isMigratedToVaultOnly(): boolean {
     return this.getSetting("migratedVaultOnly") === "true";
   }
@@ -189,6 +473,192 @@ export class SettingsRepositoryDb
     this.setSetting("defaultIncomeVaultName", name.trim() || "Income");
   }
 
+  getMaxManualPriceChangePercent(): number {
+    const value = Number(this.getSetting("maxManualPriceChangePercent"));
+    return Number.isFinite(value) && value > 0 ? value : 50;
+  }
+
+  setMaxManualPriceChangePercent(percent: number): void {
+    this.setSetting("maxManualPriceChangePercent", String(percent));
+  }
+
+  getDepegThresholdPercent(): number {
+    const value = Number(this.getSetting("depegThresholdPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 1;
+  }
+
+  setDepegThresholdPercent(percent: number): void {
+    this.setSetting("depegThresholdPercent", String(percent));
+  }
+
+  getCardFxMarkupPercent(): number {
+    const value = Number(this.getSetting("cardFxMarkupPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 0;
+  }
+
+  setCardFxMarkupPercent(percent: number): void {
+    this.setSetting("cardFxMarkupPercent", String(percent));
+  }
+
+  getCashLeakageTag(): string {
+    return this.getSetting("cashLeakageTag") || DEFAULT_CASH_LEAKAGE_TAG;
+  }
+
+  setCashLeakageTag(tag: string): void {
+    this.setSetting("cashLeakageTag", tag);
+  }
+
+  getPriceSourcePriority(): Record<string, PriceProvider[]> {
+    return parsePriceSourcePriority(this.getSetting("priceSourcePriority"));
+  }
+
+  setPriceSourcePriority(priority: Record<string, PriceProvider[]>): void {
+    this.setSetting("priceSourcePriority", JSON.stringify(priority));
+  }
+
+  getPriceDiscrepancyThresholdPercent(): number {
+    const value = Number(this.getSetting("priceDiscrepancyThresholdPercent"));
+    return Number.isFinite(value) && value > 0 ? value : 2;
+  }
+
+  setPriceDiscrepancyThresholdPercent(percent: number): void {
+    this.setSetting("priceDiscrepancyThresholdPercent", String(percent));
+  }
+
+  getDisplayPrecision(): Record<string, number> {
+    return parseDisplayPrecision(this.getSetting("displayPrecision"));
+  }
+
+  setDisplayPrecision(precision: Record<string, number>): void {
+    this.setSetting("displayPrecision", JSON.stringify(precision));
+  }
+
+  getSpendingExclusionRules(): SpendingExclusionRule[] {
+    return parseJsonArray(this.getSetting("spendingExclusionRules"));
+  }
+
+  setSpendingExclusionRules(rules: SpendingExclusionRule[]): void {
+    this.setSetting("spendingExclusionRules", JSON.stringify(rules));
+  }
+
+  getAllocationConstraints(): AllocationConstraint[] {
+    return parseJsonArray(this.getSetting("allocationConstraints"));
+  }
+
+  setAllocationConstraints(constraints: AllocationConstraint[]): void {
+    this.setSetting("allocationConstraints", JSON.stringify(constraints));
+  }
+
+  getTransactionRules(): TransactionRule[] {
+    return parseJsonArray(this.getSetting("transactionRules"));
+  }
+
+  setTransactionRules(rules: TransactionRule[]): void {
+    this.setSetting("transactionRules", JSON.stringify(rules));
+  }
+
+  getCostBasisSettings(): CostBasisSettings {
+    return parseCostBasisSettings(this.getSetting("costBasis"));
+  }
+
+  setCostBasisSettings(settings: CostBasisSettings): void {
+    this.setSetting("costBasis", JSON.stringify(settings));
+  }
+
+  getManualPrices(): ManualPrice[] {
+    return parseJsonArray(this.getSetting("manualPrices"));
+  }
+
+  setManualPrices(prices: ManualPrice[]): void {
+    this.setSetting("manualPrices", JSON.stringify(prices));
+  }
+
+  getLongTermHoldingDays(): number {
+    const value = Number(this.getSetting("longTermHoldingDays"));
+    return Number.isInteger(value) && value > 0 ? value : 365;
+  }
+
+  setLongTermHoldingDays(days: number): void {
+    this.setSetting("longTermHoldingDays", String(days));
+  }
+
+  getLastPriceSnapshotDay(): string | undefined {
+    return this.getSetting("lastPriceSnapshotDay");
+  }
+
+  setLastPriceSnapshotDay(day: string): void {
+    this.setSetting("lastPriceSnapshotDay", day);
+  }
+
+  getFiscalYear(): FiscalYearSettings {
+    const fy = parseJsonObject<FiscalYearSettings>(
+      this.getSetting("fiscalYear"),
+    );
+    return { start: fy?.start || "01-01", starts: fy?.starts || {} };
+  }
+
+  setFiscalYear(fy: FiscalYearSettings): void {
+    this.setSetting("fiscalYear", JSON.stringify(fy));
+  }
+
+  getIncomeTaxSettings(): IncomeTaxSettings {
+    return parseIncomeTaxSettings(this.getSetting("incomeTax"));
+  }
+
+  setIncomeTaxSettings(settings: IncomeTaxSettings): void {
+    this.setSetting("incomeTax", JSON.stringify(settings));
+  }
+
+  getSheetsExport(): SheetsExport | undefined {
+    return parseJsonObject<SheetsExport>(this.getSetting("sheetsExport"));
+  }
+
+  setSheetsExport(exp: SheetsExport | undefined): void {
+    if (exp) this.setSetting("sheetsExport", JSON.stringify(exp));
+    else this.deleteSetting("sheetsExport");
+  }
+
+  getExchangeConnectors(): ExchangeConnector[] {
+    return parseJsonArray(this.getSetting("exchangeConnectors"));
+  }
+
+  setExchangeConnectors(connectors: ExchangeConnector[]): void {
+    this.setSetting("exchangeConnectors", JSON.stringify(connectors));
+  }
+
+  getEvmWallets(): EvmWallet[] {
+    return parseJsonArray(this.getSetting("evmWallets"));
+  }
+
+  setEvmWallets(wallets: EvmWallet[]): void {
+    this.setSetting("evmWallets", JSON.stringify(wallets));
+  }
+
+  getHomeJurisdiction(): string {
+    return (this.getSetting("homeJurisdiction") || "VN").toUpperCase();
+  }
+
+  setHomeJurisdiction(country: string): void {
+    this.setSetting("homeJurisdiction", country.trim().toUpperCase());
+  }
+
+  getOverdrawPolicy(): OverdrawPolicy {
+    const policy = this.getSetting("overdrawPolicy") as OverdrawPolicy;
+    return OVERDRAW_POLICIES.includes(policy) ? policy : "REJECT";
+  }
+
+  setOverdrawPolicy(policy: OverdrawPolicy): void {
+    this.setSetting("overdrawPolicy", policy);
+  }
+
+  getReportingCurrency(): string {
+    return (this.getSetting("reportingCurrency") || "VND").toUpperCase();
+  }
+
+  setReportingCurrency(currency: string): void {
+    this.setSetting("reportingCurrency", currency.trim().toUpperCase());
+  }
+
   isMigratedToVaultOnly(): boolean {
     return this.getSetting("migratedVaultOnly") === "true";
   }
@@ -0,0 +1,263 @@
+import { NameHistoryEntry, RenameKind, Transaction } from "../types";
+import {
+  IAdminRepository,
+  IReportingRepository,
+  ISettingsRepository,
+  ITransactionRepository,
+} from "./repository.interface";
+
+// Settings key holding every rename, as a JSON list like the merge log
+export const NAME_HISTORY_KEY = "nameHistory";
+export const EPOCH = new Date(0).toISOString();
+
+export function readNameHistory(
+  settings: ISettingsRepository,
+): NameHistoryEntry[] {
+  const raw = settings.getSetting(NAME_HISTORY_KEY);
+  if (!raw) return [];
+  try {
+    const list = JSON.parse(raw);
+    return Array.isArray(list) ? list : [];
+  } catch {
+    return [];
+  }
+}
+
+interface NamedEntity {
+  current: string;
+  names: Array<{ name: string; from: string }>; // oldest first
+  aliases: string[]; // names of entities merged into this one
+}
+
+const lower = (s: string) => s.trim().toLowerCase();
+
+/**
+ * What admin accounts and assets were called over time. An entity with no
+ * renames has had its current name all along.
+ */
+export class NameResolver {
+  private entities: Record<RenameKind, Map<number, NamedEntity>> = {
+    account: new Map(),
+    asset: new Map(),
+  };
+  // Lowercase name to the ids that ever carried it
+  private byName: Record<RenameKind, Map<string, number[]>> = {
+    account: new Map(),
+    asset: new Map(),
+  };
+
+  constructor(
+    accounts: Array<{ id: number; name: string }>,
+    assets: Array<{ id: number; symbol: string }>,
+    history: NameHistoryEntry[],
+  ) {
+    const add = (kind: RenameKind, id: number, current: string) => {
+      const names = history
+        .filter((h) => h.kind === kind && h.entityId === id)
+        .sort((a, b) => a.effectiveFrom.localeCompare(b.effectiveFrom))
+        .map((h) => ({ name: h.name, from: h.effectiveFrom }));
+      if (!names.length) names.push({ name: current, from: EPOCH });
+      this.entities[kind].set(id, { current, names, aliases: [] });
+      for (const name of new Set([current, ...names.map((n) => n.name)])) {
+        const ids = this.byName[kind].get(lower(name)) || [];
+        if (!ids.includes(id)) ids.push(id);
+        this.byName[kind].set(lower(name), ids);
+      }
+    };
+    for (const a of accounts) add("account", a.id, a.name);
+    for (const a of assets) add("asset", a.id, a.symbol);
+    // Names of a merged entity now refer to the one it was merged into
+    for (const { kind, name, mergedInto } of history) {
+      if (mergedInto === undefined) continue;
+      const into = this.entities[kind].get(mergedInto);
+      if (!into || into.aliases.includes(name)) continue;
+      into.aliases.push(name);
+      const ids = this.byName[kind].get(lower(name)) || [];
+      if (!ids.includes(mergedInto)) ids.push(mergedInto);
+      this.byName[kind].set(lower(name), ids);
+    }
+  }
+
+  currentName(kind: RenameKind, id: number): string | undefined {
+    return this.entities[kind].get(id)?.current;
+  }
+
+  namesOf(kind: RenameKind, id: number): string[] {
+    const e = this.entities[kind].get(id);
+    if (!e) return [];
+    const names = [...e.names.map((n) => n.name), e.current, ...e.aliases];
+    return [...new Set(names)];
+  }
+
+  /** The name in effect at `at` (ISO); the first name before any rename. */
+  nameAt(kind: RenameKind, id: number, at: string): string | undefined {
+    const e = this.entities[kind].get(id);
+    if (!e) return undefined;
+    let name = e.names[0].name;
+    for (const n of e.names) if (n.from <= at) name = n.name;
+    return name;
+  }
+
+  /**
+   * The entity a name refers to. When the name was reused, the one
+   * carrying it at `at` wins, then the one that carried it first.
+   */
+  idOf(kind: RenameKind, name: string, at?: string): number | undefined {
+    const ids = this.byName[kind].get(lower(name)) || [];
+    if (ids.length <= 1 || !at) return ids[0];
+    const then = ids.find(
+      (id) => lower(this.nameAt(kind, id, at) || "") === lower(name),
+    );
+    return then ?? ids[0];
+  }
+
+  /** `t` with the ids of its account and asset, when they are admin ones. */
+  stamp<T extends Partial<Transaction>>(t: T, at?: string): T {
+    const out: Partial<Transaction> = { ...t };
+    if ("account" in t) {
+      out.accountId = t.account
+        ? this.idOf("account", t.account, at ?? t.createdAt)
+        : undefined;
+    }
+    if (t.asset) {
+      out.assetId = this.idOf("asset", t.asset.symbol, at ?? t.createdAt);
+    }
+    return out as T;
+  }
+
+  /** `t` under the current names of its account and asset. */
+  canonical(t: Transaction): Transaction {
+    const accountId =
+      t.accountId !== undefined && this.entities.account.has(t.accountId)
+        ? t.accountId
+        : t.account
+          ? this.idOf("account", t.account, t.createdAt)
+          : undefined;
+    const assetId =
+      t.assetId !== undefined && this.entities.asset.has(t.assetId)
+        ? t.assetId
+        : this.idOf("asset", t.asset.symbol, t.createdAt);
+    const account =
+      accountId !== undefined && this.currentName("account", accountId);
+    const symbol = assetId !== undefined && this.currentName("asset", assetId);
+
+    let out = t;
+    if (account && account !== t.account) out = { ...out, account };
+    if (symbol && symbol !== t.asset.symbol) {
+      const asset = { ...t.asset, symbol };
+      out = { ...out, asset };
+      if (t.rate?.asset?.symbol === t.asset.symbol) {
+        out.rate = { ...t.rate, asset }; // keep the FX snapshot in step
+      }
+    }
+    return out;
+  }
+}
+
+type Deps = () => { admin: IAdminRepository; settings: ISettingsRepository };
+
+function resolverOf(deps: Deps, history: NameHistoryEntry[]): NameResolver {
+  const { admin } = deps();
+  return new NameResolver(
+    admin.findAllAccounts(),
+    admin.findAllAssets(),
+    history,
+  );
+}
+
+// Reads under current names; untouched until something was renamed
+function renamedReader(deps: Deps) {
+  return <R>(result: R): R => {
+    const history = readNameHistory(deps().settings);
+    if (!history.length || !result) return result;
+    const names = resolverOf(deps, history);
+    if (Array.isArray(result)) {
+      return result.map((t) => names.canonical(t)) as R;
+    }
+    return names.canonical(result as Transaction) as R;
+  };
+}
+
+const READS = [
+  "findAll",
+  "findById",
+  "findByLoanId",
+  "findByProjectId",
+  "findDeleted",
+  "findByType",
+  "findByDateRange",
+  "findBySourceRef",
+  "findExisting",
+  "restore",
+] as const;
+
+/**
+ * The transaction repository with stable names: writes record the admin
+ * account and asset ids, and reads report every transaction under the
+ * current name of its account and asset. A rename then needs no mass
+ * update, and reports group old and new names together.
+ */
+export function withStableNames(
+  repo: ITransactionRepository,
+  deps: Deps,
+): ITransactionRepository {
+  const wrapped: ITransactionRepository = Object.create(repo);
+  const named = renamedReader(deps);
+  const stamper = () => resolverOf(deps, readNameHistory(deps().settings));
+
+  for (const method of READS) {
+    const read = (repo[method] as (...args: any[]) => any).bind(repo);
+    (wrapped as any)[method] = (...args: any[]) => named(read(...args));
+  }
+  wrapped.findPage = (page) => {
+    const result = repo.findPage(page);
+    return { ...result, items: named(result.items) };
+  };
+  // Rows still carry the name they were written under, so look up each
+  // name the account went by
+  const acrossNames = (
+    account: string,
+    find: (name: string) => Transaction[],
+  ): Transaction[] => {
+    const history = readNameHistory(deps().settings);
+    if (!history.length) return find(account);
+    const names = resolverOf(deps, history);
+    const id = names.idOf("account", account);
+    const seen = new Set<string>();
+    return (id === undefined ? [account] : names.namesOf("account", id))
+      .flatMap(find)
+      .filter((t) => !seen.has(t.id) && !!seen.add(t.id))
+      .map((t) => names.canonical(t))
+      .sort((a, b) => b.createdAt.localeCompare(a.createdAt));
+  };
+  wrapped.findByAccount = (account: string) =>
+    acrossNames(account, (name) => repo.findByAccount(name));
+  wrapped.findSpending = (params) =>
+    acrossNames(params.account, (account) =>
+      repo.findSpending({ ...params, account }),
+    );
+
+  wrapped.create = (tx: Transaction) =>
+    named(repo.create(stamper().stamp(tx)));
+  wrapped.createMany = (txs: Transaction[]) => {
+    const names = stamper();
+    return named(repo.createMany(txs.map((t) => names.stamp(t))));
+  };
+  wrapped.update = (id: string, updates: Partial<Transaction>) => {
+    const at = updates.createdAt ?? repo.findById(id)?.createdAt;
+    return named(repo.update(id, stamper().stamp(updates, at)));
+  };
+  return wrapped;
+}
+
+/** The reporting repository's ledger under current names, as above. */
+export function withStableLedgerNames(
+  repo: IReportingRepository,
+  deps: Deps,
+): IReportingRepository {
+  const wrapped: IReportingRepository = Object.create(repo);
+  const named = renamedReader(deps);
+  wrapped.findLedgerUntil = (endDate: string) =>
+    named(repo.findLedgerUntil(endDate));
+  return wrapped;
+}
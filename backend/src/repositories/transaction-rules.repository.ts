@@ -0,0 +1,26 @@
+import { Transaction, TransactionRule } from "../types";
+import { ITransactionRepository } from "./repository.interface";
+import { applyRules, rulesSuspended } from "../utils/transaction-rules.util";
+
+/**
+ * The transaction repository with the transaction rules applied to every
+ * new transaction, whichever path creates it (API, imports, recurring).
+ * Rules change the transaction in place, so callers that report the
+ * object they built report the result. Edits are left alone.
+ */
+export function withTransactionRules(
+  repo: ITransactionRepository,
+  rules: () => TransactionRule[],
+): ITransactionRepository {
+  const wrapped: ITransactionRepository = Object.create(repo);
+  const ruled = (list: TransactionRule[]) => (tx: Transaction) => {
+    if (list.length) applyRules(tx, list);
+    return tx;
+  };
+  const current = () => (rulesSuspended() ? [] : rules());
+
+  wrapped.create = (tx: Transaction) => repo.create(ruled(current())(tx));
+  wrapped.createMany = (txs: Transaction[]) =>
+    repo.createMany(txs.map(ruled(current())));
+  return wrapped;
+}
@@ -1,4 +1,9 @@
-import { Transaction } from "../types";
+import {
+  Page,
+  PageRequest,
+  SpendingExclusionRule,
+  Transaction,
+} from "../types";
 import { readStore, writeStore } from "./base.repository";
 import { ITransactionRepository } from "./repository.interface";
 import {
@@ -6,21 +11,86 @@ import {
   rowToTransaction,
   transactionToRow,
 } from "./base-db.repository";
+import { decodeCursor, encodeCursor } from "../utils/cursor.util";
+
+// Unicode-aware, so "ĐIỆN" and "điện" are the same name
+const fold = (v?: string) => (v || "").normalize("NFC").trim().toLowerCase();
+
+// Case-insensitive match; "tag" checks category and tags, "note" is
+// substring. A blank value matches nothing rather than everything.
+export function matchesExclusionRule(
+  t: Transaction,
+  rule: SpendingExclusionRule,
+): boolean {
+  const value = fold(rule.value);
+  if (!value) return false;
+  const eq = (v?: string) => fold(v) === value;
+  switch (rule.field) {
+    case "tag":
+      return eq(t.category) || (t.tags || []).some(eq);
+    case "counterparty":
+      return eq(t.counterparty);
+    case "account":
+      return eq(t.account);
+    case "note":
+      return fold(t.note).includes(value);
+    default:
+      return false;
+  }
+}
+
+// Transactions not soft-deleted; every read but findDeleted sees only these
+function live(): Transaction[] {
+  return readStore().transactions.filter((t) => !t.deletedAt);
+}
 
 // JSON-based implementation
 export class TransactionRepositoryJson implements ITransactionRepository {
   findAll(): Transaction[] {
-    return readStore().transactions.sort((a, b) =>
+    return live().sort((a, b) =>
       String(b.createdAt || '').localeCompare(String(a.createdAt || '')),
     );
   }
 
+  findPage(page: PageRequest): Page<Transaction> {
+    const sorted = live().sort(
+      (a, b) =>
+        String(b.createdAt).localeCompare(String(a.createdAt)) ||
+        String(b.id).localeCompare(String(a.id)),
+    );
+    let from = 0;
+    if (page.cursor) {
+      const { at, id } = decodeCursor(page.cursor);
+      from = sorted.findIndex(
+        (t) => t.createdAt < at || (t.createdAt === at && t.id < String(id)),
+      );
+      if (from === -1) from = sorted.length;
+    }
+    const items = sorted.slice(from, from + page.limit);
+    const last = items[items.length - 1];
+    return {
+      items,
+      nextCursor:
+        from + page.limit < sorted.length
+          ? encodeCursor(last.createdAt, last.id)
+          : undefined,
+    };
+  }
+
+  count(): number {
+    return live().length;
+  }
+
   findById(id: string): Transaction | undefined {
-    return readStore().transactions.find((t) => t.id === id);
+    return live().find((t) => t.id === id);
   }
 
   findByLoanId(loanId: string): Transaction[] {
-    return readStore().transactions.filter((t) => t.loanId === loanId);
+    return live().filter((t) => t.loanId === loanId);
+  }
+
+  findByProjectId(projectId: string): Transaction[] {
+    return live().filter((t) => t.projectId === projectId);
   }
 
   create(transaction: Transaction): Transaction {
@@ -30,6 +100,30 @@ export class TransactionRepositoryJson implements ITransactionRepository {
     return transaction;
   }
 
+  createMany(transactions: Transaction[]): Transaction[] {
+    const store = readStore();
+    store.transactions.push(...transactions);
+    writeStore(store);
+    return transactions;
+  }
+
+  update(id: string, updates: Partial<Transaction>): Transaction | undefined {
+    const store = readStore();
+    const index = store.transactions.findIndex(
+      (t) => t.id === id && !t.deletedAt,
+    );
+    if (index === -1) return undefined;
+
+    store.transactions[index] = {
+      ...store.transactions[index],
+      ...updates,
+      id,
+      updatedAt: updates.updatedAt ?? new Date().toISOString(),
+    } as Transaction;
+    writeStore(store);
+    return store.transactions[index];
+  }
+
   delete(id: string): boolean {
     const store = readStore();
     const initialLength = store.transactions.length;
@@ -38,22 +132,73 @@ export class TransactionRepositoryJson implements ITransactionRepository {
     return store.transactions.length < initialLength;
   }
 
+  softDelete(id: string, deletedAt: string): boolean {
+    const store = readStore();
+    const t = store.transactions.find((t) => t.id === id && !t.deletedAt);
+    if (!t) return false;
+    t.deletedAt = deletedAt;
+    writeStore(store);
+    return true;
+  }
+
+  restore(id: string): Transaction | undefined {
+    const store = readStore();
+    const t = store.transactions.find((t) => t.id === id && t.deletedAt);
+    if (!t) return undefined;
+    delete t.deletedAt;
+    writeStore(store);
+    return t;
+  }
+
+  findDeleted(): Transaction[] {
+    return readStore()
+      .transactions.filter((t) => t.deletedAt)
+      .sort((a, b) => b.deletedAt!.localeCompare(a.deletedAt!));
+  }
+
+  purgeDeleted(before: string): number {
+    const store = readStore();
+    const initialLength = store.transactions.length;
+    store.transactions = store.transactions.filter(
+      (t) => !t.deletedAt || t.deletedAt > before,
+    );
+    writeStore(store);
+    return initialLength - store.transactions.length;
+  }
+
   findByAccount(account: string): Transaction[] {
-    return readStore().transactions.filter((t) => t.account === account);
+    return live().filter((t) => t.account === account);
   }
 
   findByType(type: string): Transaction[] {
-    return readStore().transactions.filter((t) => t.type === type);
+    return live().filter((t) => t.type === type);
   }
 
   findByDateRange(startDate: string, endDate: string): Transaction[] {
-    return readStore().transactions.filter(
+    return live().filter(
       (t) => t.createdAt >= startDate && t.createdAt <= endDate,
     );
   }
 
-  findBySourceRef(sourceRef: string): Transaction | undefined {
-    return readStore().transactions.find((t) => t.sourceRef === sourceRef);
+  findBySourceRef(
+    sourceRef: string,
+    opts: { includeDeleted?: boolean } = {},
+  ): Transaction | undefined {
+    const txs = opts.includeDeleted ? readStore().transactions : live();
+    return txs.find((t) => t.sourceRef === sourceRef);
+  }
+
+  findSpending(params: {
+    account: string;
+    exclusions?: SpendingExclusionRule[];
+  }): Transaction[] {
+    const { account, exclusions = [] } = params;
+    return this.findAll().filter(
+      (t) =>
+        t.type === "EXPENSE" &&
+        (t.account || account) === account &&
+        !exclusions.some((rule) => matchesExclusionRule(t, rule)),
+    );
   }
 
   findExisting(params: {
@@ -65,9 +210,9 @@ export class TransactionRepositoryJson implements ITransactionRepository {
   }): Transaction | undefined {
     const { sourceRef, date, amount, type, account } = params;
 
-    // First try: exact match by sourceRef (most reliable)
+    // First try: exact match by sourceRef (most reliable), deleted or not
     if (sourceRef) {
-      const byRef = this.findBySourceRef(sourceRef);
+      const byRef = this.findBySourceRef(sourceRef, { includeDeleted: true });
       if (byRef) {
         return byRef;
       }
@@ -75,7 +220,7 @@ export class TransactionRepositoryJson implements ITransactionRepository {
 
     // Second try: match by date, amount, type, and optional account
     const dateStr = date.startsWith("T") ? date.split("T")[0] : date;
-    const candidates = readStore().transactions.filter((t) => {
+    const candidates = live().filter((t) => {
       const txDate = t.createdAt.startsWith("T")
         ? t.createdAt.split("T")[0]
         : t.createdAt;
@@ -103,15 +248,50 @@ export class TransactionRepositoryDb
 {
   findAll(): Transaction[] {
     return this.findMany(
-      "SELECT * FROM transactions ORDER BY created_at DESC",
+      `SELECT * FROM transactions WHERE deleted_at IS NULL
+       ORDER BY created_at DESC`,
       [],
       rowToTransaction,
     );
   }
 
+  findPage(page: PageRequest): Page<Transaction> {
+    const after = page.cursor ? decodeCursor(page.cursor) : undefined;
+    // One extra row tells whether another page follows
+    const rows: Transaction[] = this.findMany(
+      `SELECT * FROM transactions WHERE deleted_at IS NULL
+       ${after ? "AND (created_at < ? OR (created_at = ? AND id < ?))" : ""}
+       ORDER BY created_at DESC, id DESC
+       LIMIT ?`,
+      after
+        ? [after.at, after.at, String(after.id), page.limit + 1]
+        : [page.limit + 1],
+      rowToTransaction,
+    );
+    const items = rows.slice(0, page.limit);
+    const last = items[items.length - 1];
+    return {
+      items,
+      nextCursor:
+        rows.length > page.limit
+          ? encodeCursor(last.createdAt, last.id)
+          : undefined,
+    };
+  }
+
+  count(): number {
+    return (
+      this.findOne(
+        "SELECT COUNT(*) AS count FROM transactions WHERE deleted_at IS NULL",
+        [],
+        (r: any) => r.count,
+      ) || 0
+    );
+  }
+
   findById(id: string): Transaction | undefined {
     return this.findOne(
-      "SELECT * FROM transactions WHERE id = ?",
+      "SELECT * FROM transactions WHERE id = ? AND deleted_at IS NULL",
       [id],
       rowToTransaction,
     );
@@ -119,20 +299,36 @@ export class TransactionRepositoryDb
 
   findByLoanId(loanId: string): Transaction[] {
     return this.findMany(
-      "SELECT * FROM transactions WHERE loan_id = ? ORDER BY created_at DESC",
+      `SELECT * FROM transactions WHERE loan_id = ? AND deleted_at IS NULL
+       ORDER BY created_at DESC`,
       [loanId],
       rowToTransaction,
     );
   }
 
+  findByProjectId(projectId: string): Transaction[] {
+    return this.findMany(
+      `SELECT * FROM transactions WHERE project_id = ? AND deleted_at IS NULL
+       ORDER BY created_at DESC`,
+      [projectId],
+      rowToTransaction,
+    );
+  }
+
   create(transaction: Transaction): Transaction {
     const row = transactionToRow(transaction);
     this.execute(
       `INSERT INTO transactions (
         id, type, asset_type, asset_symbol, amount, created_at, account,
         note, category, tags, counterparty, due_date, transfer_id,
-        loan_id, source_ref, repay_direction, rate, usd_amount
-      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        loan_id, source_ref, repay_direction, rate, usd_amount,
+        reimbursable, reimburses_id, project_id, chain, updated_at, card,
+        fee_usd, place, latitude, longitude, reviewed_at, member, deleted_at,
+        account_id, asset_id, internal_flow, fx, fx_margin
+      ) VALUES (
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+      )`,
       [
         row.id,
         row.type,
@@ -152,19 +348,144 @@ export class TransactionRepositoryDb
         row.repay_direction,
         row.rate,
         row.usd_amount,
+        row.reimbursable,
+        row.reimburses_id,
+        row.project_id,
+        row.chain,
+        row.updated_at,
+        row.card,
+        row.fee_usd,
+        row.place,
+        row.latitude,
+        row.longitude,
+        row.reviewed_at,
+        row.member,
+        row.deleted_at,
+        row.account_id,
+        row.asset_id,
+        row.internal_flow,
+        row.fx,
+        row.fx_margin,
       ],
     );
     return transaction;
   }
 
+  // All or nothing: a failing insert rolls the whole batch back
+  createMany(transactions: Transaction[]): Transaction[] {
+    this.db.transaction((txs: Transaction[]) => {
+      for (const t of txs) this.create(t);
+    })(transactions);
+    return transactions;
+  }
+
+  update(id: string, updates: Partial<Transaction>): Transaction | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const merged = {
+      ...existing,
+      ...updates,
+      id,
+      updatedAt: updates.updatedAt ?? new Date().toISOString(),
+    } as Transaction;
+    const row = transactionToRow(merged);
+    this.execute(
+      `UPDATE transactions SET
+        type = ?, asset_type = ?, asset_symbol = ?, amount = ?, created_at = ?,
+        account = ?, note = ?, category = ?, tags = ?, counterparty = ?,
+        due_date = ?, transfer_id = ?, loan_id = ?, source_ref = ?,
+        repay_direction = ?, rate = ?, usd_amount = ?, reimbursable = ?,
+        reimburses_id = ?, project_id = ?, chain = ?, updated_at = ?,
+        card = ?, fee_usd = ?, place = ?, latitude = ?, longitude = ?,
+        reviewed_at = ?, member = ?, account_id = ?, asset_id = ?,
+        internal_flow = ?, fx = ?, fx_margin = ?
+      WHERE id = ?`,
+      [
+        row.type,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.created_at,
+        row.account,
+        row.note,
+        row.category,
+        row.tags,
+        row.counterparty,
+        row.due_date,
+        row.transfer_id,
+        row.loan_id,
+        row.source_ref,
+        row.repay_direction ?? null,
+        row.rate,
+        row.usd_amount,
+        row.reimbursable,
+        row.reimburses_id,
+        row.project_id,
+        row.chain,
+        row.updated_at,
+        row.card,
+        row.fee_usd,
+        row.place,
+        row.latitude,
+        row.longitude,
+        row.reviewed_at,
+        row.member,
+        row.account_id,
+        row.asset_id,
+        row.internal_flow,
+        row.fx,
+        row.fx_margin,
+        id,
+      ],
+    );
+    return merged;
+  }
+
   delete(id: string): boolean {
     const result = this.execute("DELETE FROM transactions WHERE id = ?", [id]);
     return result.changes > 0;
   }
 
+  softDelete(id: string, deletedAt: string): boolean {
+    const result = this.execute(
+      `UPDATE transactions SET deleted_at = ?
+       WHERE id = ? AND deleted_at IS NULL`,
+      [deletedAt, id],
+    );
+    return result.changes > 0;
+  }
+
+  restore(id: string): Transaction | undefined {
+    const result = this.execute(
+      `UPDATE transactions SET deleted_at = NULL
+       WHERE id = ? AND deleted_at IS NOT NULL`,
+      [id],
+    );
+    return result.changes > 0 ? this.findById(id) : undefined;
+  }
+
+  findDeleted(): Transaction[] {
+    return this.findMany(
+      `SELECT * FROM transactions WHERE deleted_at IS NOT NULL
+       ORDER BY deleted_at DESC`,
+      [],
+      rowToTransaction,
+    );
+  }
+
+  purgeDeleted(before: string): number {
+    return this.execute(
+      `DELETE FROM transactions
+       WHERE deleted_at IS NOT NULL AND deleted_at <= ?`,
+      [before],
+    ).changes;
+  }
+
   findByAccount(account: string): Transaction[] {
     return this.findMany(
-      "SELECT * FROM transactions WHERE account = ? ORDER BY created_at DESC",
+      `SELECT * FROM transactions WHERE account = ? AND deleted_at IS NULL
+       ORDER BY created_at DESC`,
       [account],
       rowToTransaction,
     );
@@ -172,7 +493,8 @@ export class TransactionRepositoryDb
 
   findByType(type: string): Transaction[] {
     return this.findMany(
-      "SELECT * FROM transactions WHERE type = ? ORDER BY created_at DESC",
+      `SELECT * FROM transactions WHERE type = ? AND deleted_at IS NULL
+       ORDER BY created_at DESC`,
       [type],
       rowToTransaction,
     );
@@ -181,21 +503,46 @@ export class TransactionRepositoryDb
   findByDateRange(startDate: string, endDate: string): Transaction[] {
     return this.findMany(
       `SELECT * FROM transactions
-       WHERE created_at >= ? AND created_at <= ?
+       WHERE created_at >= ? AND created_at <= ? AND deleted_at IS NULL
        ORDER BY created_at DESC`,
       [startDate, endDate],
       rowToTransaction,
     );
   }
 
-  findBySourceRef(sourceRef: string): Transaction | undefined {
+  findBySourceRef(
+    sourceRef: string,
+    opts: { includeDeleted?: boolean } = {},
+  ): Transaction | undefined {
     return this.findOne(
-      "SELECT * FROM transactions WHERE source_ref = ?",
+      opts.includeDeleted
+        ? "SELECT * FROM transactions WHERE source_ref = ?"
+        : "SELECT * FROM transactions WHERE source_ref = ? AND deleted_at IS NULL",
       [sourceRef],
       rowToTransaction,
     );
   }
 
+  findSpending(params: {
+    account: string;
+    exclusions?: SpendingExclusionRule[];
+  }): Transaction[] {
+    const { account, exclusions = [] } = params;
+    // Exclusions are matched in JS, as on the JSON backend: SQLite's LOWER
+    // only folds ASCII, so Vietnamese names would not match
+    return this.findMany(
+      `SELECT * FROM transactions
+       WHERE deleted_at IS NULL AND type = 'EXPENSE'
+         AND COALESCE(account, ?) = ?
+       ORDER BY created_at DESC`,
+      [account, account],
+      rowToTransaction,
+    ).filter(
+      (t: Transaction) =>
+        !exclusions.some((rule) => matchesExclusionRule(t, rule)),
+    );
+  }
+
   findExisting(params: {
     sourceRef?: string;
     date: string;
@@ -205,9 +552,9 @@ export class TransactionRepositoryDb
   }): Transaction | undefined {
     const { sourceRef, date, amount, type, account } = params;
 
-    // First try: exact match by sourceRef (most reliable)
+    // First try: exact match by sourceRef (most reliable), deleted or not
     if (sourceRef) {
-      return this.findBySourceRef(sourceRef);
+      return this.findBySourceRef(sourceRef, { includeDeleted: true });
     }
 
     // Second try: match by date, amount, type, and optional account
@@ -217,11 +564,11 @@ export class TransactionRepositoryDb
 
     const sql = account
       ? `SELECT * FROM transactions
-         WHERE created_at >= ? AND created_at <= ?
+         WHERE created_at >= ? AND created_at <= ? AND deleted_at IS NULL
          AND type = ? AND account = ?
          ORDER BY created_at DESC LIMIT 1`
       : `SELECT * FROM transactions
-         WHERE created_at >= ? AND created_at <= ?
+         WHERE created_at >= ? AND created_at <= ? AND deleted_at IS NULL
          AND type = ?
          ORDER BY created_at DESC LIMIT 1`;
 
@@ -0,0 +1,88 @@
+import { TrashItem } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { ITrashRepository } from "./repository.interface";
+import { BaseDbRepository, rowToTrashItem } from "./base-db.repository";
+
+// JSON-based implementation
+export class TrashRepositoryJson implements ITrashRepository {
+  findAll(): TrashItem[] {
+    return readStore().trash;
+  }
+
+  findById(id: string): TrashItem | undefined {
+    return readStore().trash.find((t) => t.id === id);
+  }
+
+  create(item: TrashItem): TrashItem {
+    const store = readStore();
+    store.trash.push(item);
+    writeStore(store);
+    return item;
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.trash.length;
+    store.trash = store.trash.filter((t) => t.id !== id);
+    writeStore(store);
+    return store.trash.length < initialLength;
+  }
+
+  deleteExpired(now: string): number {
+    const store = readStore();
+    const initialLength = store.trash.length;
+    store.trash = store.trash.filter((t) => t.expiresAt > now);
+    if (store.trash.length < initialLength) writeStore(store);
+    return initialLength - store.trash.length;
+  }
+}
+
+// Database-based implementation
+export class TrashRepositoryDb
+  extends BaseDbRepository
+  implements ITrashRepository
+{
+  findAll(): TrashItem[] {
+    return this.findMany(
+      "SELECT * FROM trash ORDER BY deleted_at DESC",
+      [],
+      rowToTrashItem,
+    );
+  }
+
+  findById(id: string): TrashItem | undefined {
+    return this.findOne(
+      "SELECT * FROM trash WHERE id = ?",
+      [id],
+      rowToTrashItem,
+    );
+  }
+
+  create(item: TrashItem): TrashItem {
+    this.execute(
+      `INSERT INTO trash (id, kind, label, payload, deleted_at, expires_at)
+      VALUES (?, ?, ?, ?, ?, ?)`,
+      [
+        item.id,
+        item.kind,
+        item.label,
+        JSON.stringify(item.payload),
+        item.deletedAt,
+        item.expiresAt,
+      ],
+    );
+    return item;
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM trash WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+
+  deleteExpired(now: string): number {
+    const result = this.execute("DELETE FROM trash WHERE expires_at <= ?", [
+      now,
+    ]);
+    return result.changes;
+  }
+}
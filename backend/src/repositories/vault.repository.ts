@@ -1,4 +1,4 @@
-import { Vault, VaultEntry } from "../types";
+import { Page, PageRequest, Vault, VaultEntry } from "../types";
 import { readStore, writeStore } from "./base.repository";
 import { IVaultRepository } from "./repository.interface";
 import {
@@ -8,6 +8,19 @@ import {
   rowToVaultEntry,
   vaultEntryToRow,
 } from "./base-db.repository";
+import { decodeCursor, encodeCursor } from "../utils/cursor.util";
+
+// Entries have no id; these fields tell one apart within its vault
+function sameEntry(a: VaultEntry, b: VaultEntry): boolean {
+  return (
+    a.vault === b.vault &&
+    a.type === b.type &&
+    a.asset.type === b.asset.type &&
+    a.asset.symbol === b.asset.symbol &&
+    a.amount === b.amount &&
+    a.at === b.at
+  );
+}
 
 // JSON-based implementation
 export class VaultRepositoryJson implements IVaultRepository {
@@ -57,6 +70,37 @@ export class VaultRepositoryJson implements IVaultRepository {
       .sort((a, b) => String(a.at).localeCompare(String(b.at)));
   }
 
+  // Ties on `at` break on the position in the store, which only a purge
+  // (deleteEntries) shifts
+  findEntriesPage(vaultName: string, page: PageRequest): Page<VaultEntry> {
+    const sorted = readStore()
+      .vaultEntries.map((entry, seq) => ({ entry, seq }))
+      .filter(({ entry }) => entry.vault === vaultName)
+      .sort(
+        (a, b) =>
+          String(b.entry.at).localeCompare(String(a.entry.at)) ||
+          b.seq - a.seq,
+      );
+    let from = 0;
+    if (page.cursor) {
+      const { at, id } = decodeCursor(page.cursor);
+      from = sorted.findIndex(
+        ({ entry, seq }) =>
+          entry.at < at || (entry.at === at && seq < Number(id)),
+      );
+      if (from === -1) from = sorted.length;
+    }
+    const rows = sorted.slice(from, from + page.limit);
+    const last = rows[rows.length - 1];
+    return {
+      items: rows.map((r) => r.entry),
+      nextCursor:
+        from + page.limit < sorted.length
+          ? encodeCursor(last.entry.at, last.seq)
+          : undefined,
+    };
+  }
+
   createEntry(entry: VaultEntry): VaultEntry {
     const store = readStore();
     store.vaultEntries.push(entry);
@@ -79,6 +123,39 @@ export class VaultRepositoryJson implements IVaultRepository {
       (e) => e.vault === vaultName && e.at >= startDate && e.at <= endDate,
     );
   }
+
+  findAllEntriesUntil(endDate: string): VaultEntry[] {
+    return readStore()
+      .vaultEntries.filter((e) => e.at <= endDate)
+      .sort((a, b) => String(a.at).localeCompare(String(b.at)));
+  }
+
+  deleteEntries(params: { vault?: string; before?: string }): number {
+    if (!params.vault && !params.before) return 0;
+    const store = readStore();
+    const initialLength = store.vaultEntries.length;
+    store.vaultEntries = store.vaultEntries.filter(
+      (e) =>
+        !(
+          (!params.vault || e.vault === params.vault) &&
+          (!params.before || e.at < params.before)
+        ),
+    );
+    writeStore(store);
+    return initialLength - store.vaultEntries.length;
+  }
+
+  deleteEntry(entry: VaultEntry): boolean {
+    const store = readStore();
+    let index = -1;
+    store.vaultEntries.forEach((e, i) => {
+      if (sameEntry(e, entry)) index = i;
+    });
+    if (index === -1) return false;
+    store.vaultEntries.splice(index, 1);
+    writeStore(store);
+    return true;
+  }
 }
 
 // Database-based implementation
@@ -113,8 +190,15 @@ export class VaultRepositoryDb
   create(vault: Vault): Vault {
     const row = vaultToRow(vault);
     this.execute(
-      "INSERT INTO vaults (name, status, created_at) VALUES (?, ?, ?) ON CONFLICT(name) DO UPDATE SET status = excluded.status",
-      [row.name, row.status, row.created_at],
+      "INSERT INTO vaults (name, status, created_at, tags, account, benchmark) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(name) DO UPDATE SET status = excluded.status",
+      [
+        row.name,
+        row.status,
+        row.created_at,
+        row.tags,
+        row.account,
+        row.benchmark,
+      ],
     );
     return vault;
   }
@@ -128,6 +212,21 @@ export class VaultRepositoryDb
       values.push(updates.status);
     }
 
+    if (updates.tags !== undefined) {
+      fields.push("tags = ?");
+      values.push(JSON.stringify(updates.tags));
+    }
+
+    if (updates.account !== undefined) {
+      fields.push("account = ?");
+      values.push(updates.account || null);
+    }
+
+    if (updates.benchmark !== undefined) {
+      fields.push("benchmark = ?");
+      values.push(updates.benchmark || null);
+    }
+
     if (fields.length === 0) return this.findByName(name);
 
     values.push(name);
@@ -152,11 +251,36 @@ export class VaultRepositoryDb
     );
   }
 
+  findEntriesPage(vaultName: string, page: PageRequest): Page<VaultEntry> {
+    const after = page.cursor ? decodeCursor(page.cursor) : undefined;
+    // One extra row tells whether another page follows
+    const rows: { entry: VaultEntry; id: number }[] = this.findMany(
+      `SELECT * FROM vault_entries
+       WHERE vault = ?
+       ${after ? "AND (at < ? OR (at = ? AND id < ?))" : ""}
+       ORDER BY at DESC, id DESC
+       LIMIT ?`,
+      after
+        ? [vaultName, after.at, after.at, Number(after.id), page.limit + 1]
+        : [vaultName, page.limit + 1],
+      (row: any) => ({ entry: rowToVaultEntry(row), id: row.id }),
+    );
+    const items = rows.slice(0, page.limit);
+    const last = items[items.length - 1];
+    return {
+      items: items.map((r) => r.entry),
+      nextCursor:
+        rows.length > page.limit
+          ? encodeCursor(last.entry.at, last.id)
+          : undefined,
+    };
+  }
+
   createEntry(entry: VaultEntry): VaultEntry {
     const row = vaultEntryToRow(entry);
     this.execute(
-      `INSERT INTO vault_entries (vault, type, asset_type, asset_symbol, amount, usd_value, at, account, note)
-       VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      `INSERT INTO vault_entries (vault, type, asset_type, asset_symbol, amount, usd_value, at, account, note, lots)
+       VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
       [
         row.vault,
         row.type,
@@ -167,6 +291,7 @@ export class VaultRepositoryDb
         row.at,
         row.account,
         row.note,
+        row.lots,
       ],
     );
     return entry;
@@ -191,4 +316,52 @@ export class VaultRepositoryDb
       rowToVaultEntry,
     );
   }
+
+  findAllEntriesUntil(endDate: string): VaultEntry[] {
+    return this.findMany(
+      "SELECT * FROM vault_entries WHERE at <= ? ORDER BY at ASC",
+      [endDate],
+      rowToVaultEntry,
+    );
+  }
+
+  deleteEntries(params: { vault?: string; before?: string }): number {
+    const where: string[] = [];
+    const args: string[] = [];
+    if (params.vault) {
+      where.push("vault = ?");
+      args.push(params.vault);
+    }
+    if (params.before) {
+      where.push("at < ?");
+      args.push(params.before);
+    }
+    if (where.length === 0) return 0;
+    const result = this.execute(
+      `DELETE FROM vault_entries WHERE ${where.join(" AND ")}`,
+      args,
+    );
+    return result.changes;
+  }
+
+  deleteEntry(entry: VaultEntry): boolean {
+    const row = vaultEntryToRow(entry);
+    const result = this.execute(
+      `DELETE FROM vault_entries WHERE id = (
+        SELECT id FROM vault_entries
+        WHERE vault = ? AND type = ? AND asset_type = ? AND asset_symbol = ?
+          AND amount = ? AND at = ?
+        ORDER BY id DESC LIMIT 1
+      )`,
+      [
+        row.vault,
+        row.type,
+        row.asset_type,
+        row.asset_symbol,
+        row.amount,
+        row.at,
+      ],
+    );
+    return result.changes > 0;
+  }
 }
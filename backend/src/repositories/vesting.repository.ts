@@ -0,0 +1,223 @@
+import { VestingGrant, VestEvent } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IVestingRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToVestingGrant,
+  vestingGrantToRow,
+  rowToVestEvent,
+  vestEventToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class VestingRepositoryJson implements IVestingRepository {
+  findAll(): VestingGrant[] {
+    return readStore().vestingGrants;
+  }
+
+  findById(id: string): VestingGrant | undefined {
+    return readStore().vestingGrants.find((g) => g.id === id);
+  }
+
+  create(grant: VestingGrant): VestingGrant {
+    const store = readStore();
+    store.vestingGrants.push(grant);
+    writeStore(store);
+    return grant;
+  }
+
+  update(
+    id: string,
+    updates: Partial<VestingGrant>,
+  ): VestingGrant | undefined {
+    const store = readStore();
+    const index = store.vestingGrants.findIndex((g) => g.id === id);
+    if (index === -1) return undefined;
+
+    store.vestingGrants[index] = {
+      ...store.vestingGrants[index],
+      ...updates,
+      id,
+    };
+    writeStore(store);
+    return store.vestingGrants[index];
+  }
+
+  findEvents(grantId: string): VestEvent[] {
+    return readStore()
+      .vestEvents.filter((e) => e.grantId === grantId)
+      .sort((a, b) => a.vestAt.localeCompare(b.vestAt));
+  }
+
+  findDueEvents(until: string): VestEvent[] {
+    return readStore()
+      .vestEvents.filter((e) => e.status === "SCHEDULED" && e.vestAt <= until)
+      .sort((a, b) => a.vestAt.localeCompare(b.vestAt));
+  }
+
+  createEvent(event: VestEvent): VestEvent {
+    const store = readStore();
+    store.vestEvents.push(event);
+    writeStore(store);
+    return event;
+  }
+
+  updateEvent(id: string, updates: Partial<VestEvent>): VestEvent | undefined {
+    const store = readStore();
+    const index = store.vestEvents.findIndex((e) => e.id === id);
+    if (index === -1) return undefined;
+
+    store.vestEvents[index] = { ...store.vestEvents[index], ...updates, id };
+    writeStore(store);
+    return store.vestEvents[index];
+  }
+}
+
+// Database-based implementation
+export class VestingRepositoryDb
+  extends BaseDbRepository
+  implements IVestingRepository
+{
+  findAll(): VestingGrant[] {
+    return this.findMany(
+      "SELECT * FROM vesting_grants ORDER BY grant_at DESC",
+      [],
+      rowToVestingGrant,
+    );
+  }
+
+  findById(id: string): VestingGrant | undefined {
+    return this.findOne(
+      "SELECT * FROM vesting_grants WHERE id = ?",
+      [id],
+      rowToVestingGrant,
+    );
+  }
+
+  create(grant: VestingGrant): VestingGrant {
+    const row = vestingGrantToRow(grant);
+    this.execute(
+      `INSERT INTO vesting_grants (
+        id, kind, employer, asset_type, asset_symbol, cash_asset_type,
+        cash_asset_symbol, account, total_units, grant_at, vest_start_at,
+        cliff_months, vesting_months, frequency_months, withholding_rate,
+        discount, status, note, created_at
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.kind,
+        row.employer,
+        row.asset_type,
+        row.asset_symbol,
+        row.cash_asset_type,
+        row.cash_asset_symbol,
+        row.account,
+        row.total_units,
+        row.grant_at,
+        row.vest_start_at,
+        row.cliff_months,
+        row.vesting_months,
+        row.frequency_months,
+        row.withholding_rate,
+        row.discount,
+        row.status,
+        row.note,
+        row.created_at,
+      ],
+    );
+    return grant;
+  }
+
+  update(
+    id: string,
+    updates: Partial<VestingGrant>,
+  ): VestingGrant | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = vestingGrantToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE vesting_grants SET
+        account = ?, withholding_rate = ?, discount = ?, status = ?, note = ?
+      WHERE id = ?`,
+      [
+        row.account,
+        row.withholding_rate,
+        row.discount,
+        row.status,
+        row.note,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  findEvents(grantId: string): VestEvent[] {
+    return this.findMany(
+      "SELECT * FROM vest_events WHERE grant_id = ? ORDER BY vest_at ASC",
+      [grantId],
+      rowToVestEvent,
+    );
+  }
+
+  findDueEvents(until: string): VestEvent[] {
+    return this.findMany(
+      `SELECT * FROM vest_events WHERE status = 'SCHEDULED' AND vest_at <= ?
+       ORDER BY vest_at ASC`,
+      [until],
+      rowToVestEvent,
+    );
+  }
+
+  createEvent(event: VestEvent): VestEvent {
+    const row = vestEventToRow(event);
+    this.execute(
+      `INSERT INTO vest_events (
+        id, grant_id, vest_at, units, status, fmv, purchase_price,
+        withheld_units, net_units, income_usd, transfer_id
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.grant_id,
+        row.vest_at,
+        row.units,
+        row.status,
+        row.fmv,
+        row.purchase_price,
+        row.withheld_units,
+        row.net_units,
+        row.income_usd,
+        row.transfer_id,
+      ],
+    );
+    return event;
+  }
+
+  updateEvent(id: string, updates: Partial<VestEvent>): VestEvent | undefined {
+    const existing = this.findOne(
+      "SELECT * FROM vest_events WHERE id = ?",
+      [id],
+      rowToVestEvent,
+    );
+    if (!existing) return undefined;
+
+    const row = vestEventToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE vest_events SET
+        status = ?, fmv = ?, purchase_price = ?, withheld_units = ?,
+        net_units = ?, income_usd = ?, transfer_id = ?
+      WHERE id = ?`,
+      [
+        row.status,
+        row.fmv,
+        row.purchase_price,
+        row.withheld_units,
+        row.net_units,
+        row.income_usd,
+        row.transfer_id,
+        id,
+      ],
+    );
+    return { ...existing, ...updates, id };
+  }
+}
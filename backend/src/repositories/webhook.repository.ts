@@ -0,0 +1,122 @@
+import { Webhook } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IWebhookRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToWebhook,
+  webhookToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class WebhookRepositoryJson implements IWebhookRepository {
+  findAll(): Webhook[] {
+    return readStore().webhooks;
+  }
+
+  findById(id: string): Webhook | undefined {
+    return readStore().webhooks.find((w) => w.id === id);
+  }
+
+  create(webhook: Webhook): Webhook {
+    const store = readStore();
+    store.webhooks.push(webhook);
+    writeStore(store);
+    return webhook;
+  }
+
+  update(id: string, updates: Partial<Webhook>): Webhook | undefined {
+    const store = readStore();
+    const index = store.webhooks.findIndex((w) => w.id === id);
+    if (index === -1) return undefined;
+
+    store.webhooks[index] = { ...store.webhooks[index], ...updates, id };
+    writeStore(store);
+    return store.webhooks[index];
+  }
+
+  delete(id: string): boolean {
+    const store = readStore();
+    const initialLength = store.webhooks.length;
+    store.webhooks = store.webhooks.filter((w) => w.id !== id);
+    writeStore(store);
+    return store.webhooks.length < initialLength;
+  }
+}
+
+// Database-based implementation
+export class WebhookRepositoryDb
+  extends BaseDbRepository
+  implements IWebhookRepository
+{
+  findAll(): Webhook[] {
+    return this.findMany(
+      "SELECT * FROM webhooks ORDER BY created_at ASC",
+      [],
+      rowToWebhook,
+    );
+  }
+
+  findById(id: string): Webhook | undefined {
+    return this.findOne(
+      "SELECT * FROM webhooks WHERE id = ?",
+      [id],
+      rowToWebhook,
+    );
+  }
+
+  create(webhook: Webhook): Webhook {
+    const row = webhookToRow(webhook);
+    this.execute(
+      `INSERT INTO webhooks (
+        id, url, events, secret, description, active, created_at,
+        updated_at, last_delivery_at, last_status, last_error
+      ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.id,
+        row.url,
+        row.events,
+        row.secret,
+        row.description,
+        row.active,
+        row.created_at,
+        row.updated_at,
+        row.last_delivery_at,
+        row.last_status,
+        row.last_error,
+      ],
+    );
+    return webhook;
+  }
+
+  update(id: string, updates: Partial<Webhook>): Webhook | undefined {
+    const existing = this.findById(id);
+    if (!existing) return undefined;
+
+    const row = webhookToRow({ ...existing, ...updates, id });
+    this.execute(
+      `UPDATE webhooks SET
+        url = ?, events = ?, secret = ?, description = ?, active = ?,
+        updated_at = ?, last_delivery_at = ?, last_status = ?,
+        last_error = ?
+      WHERE id = ?`,
+      [
+        row.url,
+        row.events,
+        row.secret,
+        row.description,
+        row.active,
+        row.updated_at,
+        row.last_delivery_at,
+        row.last_status,
+        row.last_error,
+        id,
+      ],
+    );
+    return this.findById(id);
+  }
+
+  delete(id: string): boolean {
+    const result = this.execute("DELETE FROM webhooks WHERE id = ?", [id]);
+    return result.changes > 0;
+  }
+}
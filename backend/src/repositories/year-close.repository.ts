@@ -0,0 +1,67 @@
+import { YearClose } from "../types";
+import { readStore, writeStore } from "./base.repository";
+import { IYearCloseRepository } from "./repository.interface";
+import {
+  BaseDbRepository,
+  rowToYearClose,
+  yearCloseToRow,
+} from "./base-db.repository";
+
+// JSON-based implementation
+export class YearCloseRepositoryJson implements IYearCloseRepository {
+  findAll(): YearClose[] {
+    return [...readStore().yearCloses].sort((a, b) => a.year - b.year);
+  }
+
+  findByYear(year: number): YearClose | undefined {
+    return readStore().yearCloses.find((c) => c.year === year);
+  }
+
+  create(close: YearClose): YearClose {
+    const store = readStore();
+    store.yearCloses.push(close);
+    writeStore(store);
+    return close;
+  }
+}
+
+// Database-based implementation
+export class YearCloseRepositoryDb
+  extends BaseDbRepository
+  implements IYearCloseRepository
+{
+  findAll(): YearClose[] {
+    return this.findMany(
+      "SELECT * FROM year_closes ORDER BY year ASC",
+      [],
+      rowToYearClose,
+    );
+  }
+
+  findByYear(year: number): YearClose | undefined {
+    return this.findOne(
+      "SELECT * FROM year_closes WHERE year = ?",
+      [year],
+      rowToYearClose,
+    );
+  }
+
+  create(close: YearClose): YearClose {
+    const row = yearCloseToRow(close);
+    this.execute(
+      `INSERT INTO year_closes (
+        year, start_at, end_at, closed_at, auto, note, snapshot
+      ) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+      [
+        row.year,
+        row.start_at,
+        row.end_at,
+        row.closed_at,
+        row.auto,
+        row.note,
+        row.snapshot,
+      ],
+    );
+    return close;
+  }
+}
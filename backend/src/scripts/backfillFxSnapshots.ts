@@ -0,0 +1,61 @@
+/*
+  Record FX snapshots on transactions saved before they existed.
+  - Looks up the rate of each missing currency on the transaction's day
+    (cache, then the price providers); missing and stale rates are skipped
+    rather than guessed
+  - Only transactions without `fx` are touched, so it is safe to re-run
+  - Currencies are VND plus the reporting currency setting, or the
+    codes given on the command line
+
+  Usage:
+    npm run migrate:fx-snapshots [-- EUR JPY]
+*/
+
+import { settingsRepository, transactionRepository } from "../repositories";
+import { priceService } from "../services/price.service";
+import { Asset } from "../types";
+import { DEFAULT_FX_CURRENCIES, fxSnapshot } from "../utils/fx.util";
+
+async function run() {
+  const currencies = [
+    ...new Set(
+      (process.argv.length > 2
+        ? process.argv.slice(2)
+        : [...DEFAULT_FX_CURRENCIES, settingsRepository.getReportingCurrency()]
+      ).map((c) => c.toUpperCase()),
+    ),
+  ].filter((c) => c !== "USD");
+  console.log("Currencies:", currencies.join(", ") || "(none)");
+
+  const usdPerUnit = async (currency: string, atISO: string) => {
+    const asset: Asset = { type: "FIAT", symbol: currency };
+    const rate = await priceService.getRateUSD(asset, atISO);
+    return rate.missing || rate.stale ? undefined : rate.rateUSD;
+  };
+
+  let updated = 0;
+  let skipped = 0;
+  for (const tx of transactionRepository.findAll()) {
+    if (tx.fx) continue;
+    const rates = new Map<string, number | undefined>();
+    for (const code of currencies) {
+      rates.set(code, await usdPerUnit(code, tx.createdAt));
+    }
+    const fx = fxSnapshot(tx, currencies, (c) => rates.get(c.symbol));
+    if (!fx) {
+      skipped++;
+      continue;
+    }
+    transactionRepository.update(tx.id, { fx });
+    updated++;
+  }
+
+  console.log(`Recorded snapshots on ${updated} transactions.`);
+  if (skipped) console.log(`${skipped} left without (no rate found).`);
+  console.log("Done.");
+}
+
+run().catch((e) => {
+  console.error("Backfill failed:", (e as any)?.message || e);
+  process.exit(1);
+});
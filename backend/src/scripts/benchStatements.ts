@@ -0,0 +1,154 @@
+/*
+  Measure what the prepared statement cache saves per dashboard request.
+  - Seeds an in-memory database with a year of transactions and vaults
+  - Replays the repository reads of a dashboard load, CONCURRENCY requests
+    at a time, first compiling every statement per request (the old
+    behaviour), then with the cache warm
+  - Prints p50/p95 request latency and SQL compilations for both runs
+
+  Usage:
+    npm run bench:statements [-- <requests> <concurrency>]
+*/
+
+import { v4 as uuidv4 } from "uuid";
+import {
+  clearStatementCache,
+  getConnection,
+  initializeDatabase,
+  statementCacheStats,
+} from "../database/connection";
+import {
+  ITransactionRepository,
+  IVaultRepository,
+} from "../repositories/repository.interface";
+import { Transaction } from "../types";
+
+const REQUESTS = Number(process.argv[2]) || 400;
+const CONCURRENCY = Number(process.argv[3]) || 8;
+const ACCOUNTS = ["Bank", "Cash", "Card", "Savings", "Broker"];
+const TRANSACTIONS = 5000;
+
+function seed(transactions: ITransactionRepository, vaults: IVaultRepository) {
+  const start = Date.parse("2025-01-01T00:00:00.000Z");
+  const usd = { type: "FIAT" as const, symbol: "USD" };
+  const txs: Transaction[] = [];
+  for (let i = 0; i < TRANSACTIONS; i++) {
+    txs.push({
+      id: uuidv4(),
+      type: i % 5 === 0 ? "INCOME" : "EXPENSE",
+      asset: usd,
+      amount: 10 + (i % 90),
+      createdAt: new Date(start + i * 6_300_000).toISOString(),
+      account: ACCOUNTS[i % ACCOUNTS.length],
+      category: `category-${i % 12}`,
+      rate: { asset: usd, rateUSD: 1, timestamp: new Date().toISOString() },
+      usdAmount: 10 + (i % 90),
+    } as Transaction);
+  }
+  transactions.createMany(txs);
+  for (const name of ACCOUNTS) {
+    vaults.create({
+      name,
+      status: "ACTIVE",
+      createdAt: new Date(start).toISOString(),
+    });
+    for (let m = 0; m < 12; m++) {
+      vaults.createEntry({
+        vault: name,
+        type: "DEPOSIT",
+        asset: usd,
+        amount: 100,
+        usdValue: 100,
+        at: new Date(start + m * 30 * 86_400_000).toISOString(),
+      });
+    }
+  }
+  return txs.map((t) => t.id);
+}
+
+function percentile(sorted: number[], p: number): number {
+  return sorted[Math.min(sorted.length - 1, Math.floor(sorted.length * p))];
+}
+
+async function run() {
+  getConnection(":memory:");
+  initializeDatabase();
+  // Repositories take the connection when constructed, so load them after
+  const { TransactionRepositoryDb } = await import(
+    "../repositories/transaction.repository"
+  );
+  const { VaultRepositoryDb } = await import(
+    "../repositories/vault.repository"
+  );
+  const transactions = new TransactionRepositoryDb();
+  const vaults = new VaultRepositoryDb();
+  const ids = seed(transactions, vaults);
+
+  // The reads behind one dashboard load: recent activity, per-account
+  // lists, vault holdings and a few transaction lookups
+  const dashboard = (n: number) => {
+    const month = new Date(Date.UTC(2025, n % 12, 1));
+    const next = new Date(Date.UTC(2025, (n % 12) + 1, 1));
+    transactions.findByDateRange(month.toISOString(), next.toISOString());
+    for (const account of ACCOUNTS) transactions.findByAccount(account);
+    for (const v of vaults.findAll()) vaults.findAllEntries(v.name);
+    vaults.findAllEntriesUntil(next.toISOString());
+    for (let i = 0; i < 10; i++) {
+      transactions.findById(ids[(n * 10 + i) % ids.length]);
+    }
+  };
+
+  const measure = async (cached: boolean) => {
+    clearStatementCache();
+    const before = statementCacheStats().misses;
+    const latencies: number[] = [];
+    for (let b = 0; b < REQUESTS; b += CONCURRENCY) {
+      const batchStart = performance.now();
+      const batch = [];
+      for (let n = b; n < Math.min(REQUESTS, b + CONCURRENCY); n++) {
+        batch.push(
+          new Promise<void>((resolve) =>
+            setImmediate(() => {
+              if (!cached) clearStatementCache();
+              dashboard(n);
+              latencies.push(performance.now() - batchStart);
+              resolve();
+            }),
+          ),
+        );
+      }
+      await Promise.all(batch);
+    }
+    latencies.sort((x, y) => x - y);
+    return {
+      p50: percentile(latencies, 0.5),
+      p95: percentile(latencies, 0.95),
+      compiled: statementCacheStats().misses - before,
+    };
+  };
+
+  dashboard(0); // warm SQLite's page cache before timing anything
+  const uncached = await measure(false);
+  const cached = await measure(true);
+  const ms = (x: number) => `${x.toFixed(2)} ms`;
+  console.log(
+    `${REQUESTS} dashboard requests, ${CONCURRENCY} at a time, ` +
+      `${TRANSACTIONS} transactions`,
+  );
+  for (const [label, r] of [
+    ["compile per request", uncached],
+    ["statement cache", cached],
+  ] as const) {
+    console.log(
+      `${label.padEnd(20)} p50 ${ms(r.p50)}  p95 ${ms(r.p95)}  ` +
+        `${r.compiled} statements compiled`,
+    );
+  }
+  const saved = (1 - cached.p50 / uncached.p50) * 100;
+  console.log(`p50 latency reduced by ${saved.toFixed(1)}%`);
+}
+
+run().catch((e) => {
+  console.error(e);
+  process.exit(1);
+});
@@ -0,0 +1,26 @@
+/*
+  Write the OpenAPI spec the API serves at /api/openapi.json to a file,
+  for generating the TypeScript and Go clients (`make sdk`).
+
+  Usage:
+    npm run openapi:export [-- <output path>]
+    (default: ../sdk/openapi.json, relative to backend/)
+*/
+
+import fs from "fs";
+import path from "path";
+import { getOpenapiSpec } from "../openapi";
+
+const ROOT = path.join(__dirname, "..", "..", "..");
+const DEFAULT_OUT = path.join(ROOT, "sdk", "openapi.json");
+
+function main() {
+  const out = path.resolve(process.argv[2] || DEFAULT_OUT);
+  const spec = getOpenapiSpec();
+  fs.mkdirSync(path.dirname(out), { recursive: true });
+  fs.writeFileSync(out, JSON.stringify(spec, null, 2) + "\n");
+  const paths = Object.keys(spec.paths ?? {}).length;
+  console.log(`Wrote ${paths} paths to ${out}`);
+}
+
+main();
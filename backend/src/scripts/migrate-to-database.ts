@@ -189,7 +189,7 @@ async function migrateAdminData(store: any): Promise<void> {
 
   // Accounts
   const acctStmt = db.prepare(
-    "INSERT INTO admin_accounts (id, name, type, is_active, created_at) VALUES (?, ?, ?, ?, ?)",
+    "INSERT INTO admin_accounts (id, name, type, institution, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?)",
   );
   for (const a of store.adminAccounts || []) {
     try {
@@ -197,6 +197,7 @@ async function migrateAdminData(store: any): Promise<void> {
         a.id,
         a.name,
         a.type || null,
+        a.institution || null,
         a.is_active ? 1 : 0,
         a.created_at,
       );
@@ -207,7 +208,7 @@ async function migrateAdminData(store: any): Promise<void> {
 
   // Assets
   const assetStmt = db.prepare(
-    "INSERT INTO admin_assets (id, symbol, name, decimals, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+    "INSERT INTO admin_assets (id, symbol, name, decimals, kind, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
   );
   for (const a of store.adminAssets || []) {
     try {
@@ -216,6 +217,7 @@ async function migrateAdminData(store: any): Promise<void> {
         a.symbol,
         a.name || null,
         a.decimals,
+        a.kind || null,
         a.is_active ? 1 : 0,
         a.created_at,
       );
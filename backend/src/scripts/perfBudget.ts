@@ -0,0 +1,144 @@
+/*
+  Check the core service paths against the budgets in perf/budgets.json.
+  - Seeds an in-memory database with a year of expenses (all from the
+    default spending vault, as the service requires) and vault entries
+  - Times ITERATIONS calls each of transactionService
+    .createExpenseTransaction and .generateReport (the holdings report)
+  - Prints p50/p95 latency and throughput per path, and exits 1 when any
+    path is slower than its p95 budget or below its throughput floor
+
+  Usage:
+    npm run perf:budget [-- <iterations>]
+*/
+
+import fs from "fs";
+import path from "path";
+
+const ITERATIONS = Number(process.argv[2]) || 500;
+const ACCOUNTS = ["Bank", "Cash", "Card", "Savings", "Broker"];
+const TRANSACTIONS = 5000;
+const BUDGETS = path.join(__dirname, "..", "..", "perf", "budgets.json");
+
+interface ServiceBudget {
+  p95_ms: number;
+  min_ops_per_sec: number;
+}
+
+function percentile(sorted: number[], p: number): number {
+  return sorted[Math.min(sorted.length - 1, Math.floor(sorted.length * p))];
+}
+
+async function time(fn: (n: number) => Promise<unknown>) {
+  const latencies: number[] = [];
+  const start = performance.now();
+  for (let n = 0; n < ITERATIONS; n++) {
+    const t = performance.now();
+    await fn(n);
+    latencies.push(performance.now() - t);
+  }
+  const elapsed = performance.now() - start;
+  latencies.sort((x, y) => x - y);
+  return {
+    p50: percentile(latencies, 0.5),
+    p95: percentile(latencies, 0.95),
+    opsPerSec: (ITERATIONS / elapsed) * 1000,
+  };
+}
+
+async function run() {
+  // The repositories pick their backend and connection when first used,
+  // so configure both before loading anything that touches them
+  process.env.STORAGE_BACKEND = "database";
+  process.env.NO_EXTERNAL_RATES = "true";
+  const { getConnection, initializeDatabase } = await import(
+    "../database/connection"
+  );
+  getConnection(":memory:");
+  initializeDatabase();
+  const { transactionRepository, vaultRepository } = await import(
+    "../repositories"
+  );
+  const { transactionService } = await import(
+    "../services/transaction.service"
+  );
+  const budgets: Record<string, ServiceBudget> = JSON.parse(
+    fs.readFileSync(BUDGETS, "utf8"),
+  ).service;
+
+  const start = Date.parse("2025-01-01T00:00:00.000Z");
+  const usd = { type: "FIAT" as const, symbol: "USD" };
+  for (let i = 0; i < TRANSACTIONS; i++) {
+    await transactionService.createExpenseTransaction({
+      asset: usd,
+      amount: 10 + (i % 90),
+      at: new Date(start + i * 6_300_000).toISOString(),
+      category: `category-${i % 12}`,
+      note: `seed ${i}`,
+    });
+  }
+  for (const name of ACCOUNTS) {
+    if (!vaultRepository.findByName(name)) {
+      vaultRepository.create({
+        name,
+        status: "ACTIVE",
+        createdAt: new Date(start).toISOString(),
+      });
+    }
+    for (let m = 0; m < 12; m++) {
+      vaultRepository.createEntry({
+        vault: name,
+        type: "DEPOSIT",
+        asset: usd,
+        amount: 100,
+        usdValue: 100,
+        at: new Date(start + m * 30 * 86_400_000).toISOString(),
+      });
+    }
+  }
+
+  const results = {
+    createExpenseTransaction: await time((n) =>
+      transactionService.createExpenseTransaction({
+        asset: usd,
+        amount: 10 + (n % 90),
+        category: "perf-budget",
+        note: `iteration ${n}`,
+      }),
+    ),
+    generateReport: await time(() => transactionService.generateReport()),
+  };
+
+  const ms = (x: number) => `${x.toFixed(2)} ms`;
+  console.log(
+    `${ITERATIONS} iterations per path, ` +
+      `${transactionRepository.findAll().length} transactions`,
+  );
+  const failures: string[] = [];
+  for (const [name, r] of Object.entries(results)) {
+    const budget = budgets[name];
+    console.log(
+      `${name.padEnd(26)} p50 ${ms(r.p50)}  p95 ${ms(r.p95)}  ` +
+        `${r.opsPerSec.toFixed(0)} ops/s (budget p95 < ${budget.p95_ms} ms, ` +
+        `>= ${budget.min_ops_per_sec} ops/s)`,
+    );
+    if (r.p95 >= budget.p95_ms) {
+      failures.push(`${name}: p95 ${ms(r.p95)} over ${budget.p95_ms} ms`);
+    }
+    if (r.opsPerSec < budget.min_ops_per_sec) {
+      failures.push(
+        `${name}: ${r.opsPerSec.toFixed(0)} ops/s under ` +
+          `${budget.min_ops_per_sec} ops/s`,
+      );
+    }
+  }
+  if (failures.length) {
+    console.error(`Performance budget exceeded:\n  ${failures.join("\n  ")}`);
+    process.exit(1);
+  }
+  console.log("All paths within budget");
+}
+
+run().catch((e) => {
+  console.error(e);
+  process.exit(1);
+});
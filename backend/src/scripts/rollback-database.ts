@@ -173,6 +173,7 @@ async function run(): Promise<void> {
         symbol: a.symbol,
         name: a.name,
         decimals: a.decimals,
+        kind: a.kind || undefined,
         is_active: !!a.is_active,
         created_at: a.created_at,
       })),
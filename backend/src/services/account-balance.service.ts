@@ -0,0 +1,89 @@
+import { AccountBalance } from "../types";
+import {
+  accountBalanceRepository,
+  adminRepository,
+  reportingRepository,
+  transactionRepository,
+} from "../repositories";
+import { computeBalances } from "../repositories/account-balance.repository";
+import { precisionService } from "./precision.service";
+import { NotFoundError, ValidationError } from "../core/errors";
+import { logger } from "../utils/logger";
+
+const DATE_ONLY = /^\d{4}-\d{2}-\d{2}$/;
+
+export interface AccountBalanceQuery {
+  account?: string; // every account when omitted
+  asOf?: string; // YYYY-MM-DD (end of that day) or ISO time; default now
+}
+
+/**
+ * Per-account balances. Current balances come from the materialized
+ * table the transaction repository keeps up to date; balances as of a
+ * past date are summed from the ledger up to it.
+ */
+export class AccountBalanceService {
+  /** Recompute the table from the ledger; returns the rows written. */
+  rebuild(): number {
+    const balances = computeBalances(transactionRepository.findAll());
+    accountBalanceRepository.replaceAll(balances);
+    logger.info({ rows: balances.length }, "Account balances rebuilt");
+    return balances.length;
+  }
+
+  /** Admin account `idOrName` (numeric id or name), as transactions name it. */
+  resolveAccount(idOrName: string): string {
+    const key = idOrName.trim();
+    const accounts = adminRepository.findAllAccounts();
+    const byId = /^\d+$/.test(key)
+      ? accounts.find((a) => a.id === Number(key))
+      : undefined;
+    const byName = accounts.find(
+      (a) => a.name.toLowerCase() === key.toLowerCase(),
+    );
+    const name = byId?.name ?? byName?.name;
+    if (name) return name;
+    // Accounts used on transactions need not be registered as admin accounts
+    if (key && accountBalanceRepository.findByAccount(key).length) return key;
+    throw new NotFoundError("Account", key);
+  }
+
+  /** Non-zero balances, sorted by account then asset. */
+  getBalances(
+    query: AccountBalanceQuery = {},
+    now = new Date(),
+  ): AccountBalance[] {
+    const asOf = this.parseAsOf(query.asOf);
+    let balances: AccountBalance[];
+    if (!asOf || asOf >= now.toISOString()) {
+      balances = query.account
+        ? accountBalanceRepository.findByAccount(query.account)
+        : accountBalanceRepository.findAll();
+    } else {
+      const name = query.account?.toLowerCase();
+      balances = computeBalances(
+        reportingRepository
+          .findLedgerUntil(asOf)
+          .filter((t) => !name || (t.account || "").toLowerCase() === name),
+      );
+    }
+    return balances
+      .map((b) => ({
+        ...b,
+        quantity: precisionService.round(b.asset, b.quantity),
+      }))
+      .filter((b) => b.quantity !== 0);
+  }
+
+  private parseAsOf(asOf?: string): string | undefined {
+    if (!asOf) return undefined;
+    const iso = DATE_ONLY.test(asOf) ? `${asOf}T23:59:59.999Z` : asOf;
+    const at = new Date(iso);
+    if (Number.isNaN(at.getTime())) {
+      throw new ValidationError("as_of must be YYYY-MM-DD or an ISO time");
+    }
+    return at.toISOString();
+  }
+}
+
+export const accountBalanceService = new AccountBalanceService();
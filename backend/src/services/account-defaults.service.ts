@@ -0,0 +1,71 @@
+import { AdminAccount, Asset, Transaction } from "../types";
+import { adminRepository, settingsRepository } from "../repositories";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { fxMarginPercent, JURISDICTION_CURRENCY } from "../utils/fx.util";
+
+function symbolOf(a: AdminAccount): string | undefined {
+  return (
+    a.default_asset ||
+    (a.jurisdiction ? JURISDICTION_CURRENCY[a.jurisdiction] : undefined)
+  );
+}
+
+const accountKey = (name?: string) => (name || "").trim().toLowerCase();
+
+/**
+ * Default asset of an account for quick entry (actions, AI and CSV
+ * import), so entries can leave the asset out: the account's configured
+ * default_asset, else the currency of its jurisdiction.
+ */
+export class AccountDefaultsService {
+  defaultAsset(account?: string): Asset | undefined {
+    const name = account?.trim().toLowerCase();
+    if (!name) return undefined;
+    const a = adminRepository
+      .findAllAccounts()
+      .find((x) => x.name.trim().toLowerCase() === name);
+    const symbol = a ? symbolOf(a) : undefined;
+    return symbol ? createAssetFromSymbol(symbol) : undefined;
+  }
+
+  /** The given symbol when there is one, else the account's default. */
+  resolveAsset(symbol: unknown, account?: string): Asset | undefined {
+    const s = String(symbol ?? "")
+      .trim()
+      .toUpperCase();
+    return s ? createAssetFromSymbol(s) : this.defaultAsset(account);
+  }
+
+  /**
+   * FX margin (percent) each transaction's account charges on it, for the
+   * transaction repository. Accounts are read once per lookup, on first
+   * use, so a batch of transactions shares one read.
+   */
+  fxMargins(): (tx: Transaction) => number {
+    let accounts: Map<string, AdminAccount> | undefined;
+    let home = "";
+    return (tx) => {
+      const name = accountKey(tx.account);
+      if (!name) return 0;
+      if (!accounts) {
+        accounts = new Map(
+          adminRepository.findAllAccounts().map((a) => [accountKey(a.name), a]),
+        );
+        home = settingsRepository.getHomeJurisdiction();
+      }
+      return fxMarginPercent(tx.asset, accounts.get(name), home);
+    };
+  }
+
+  /** Default asset symbol per account name, for clients that infer it. */
+  defaults(): Record<string, string> {
+    const out: Record<string, string> = {};
+    for (const a of adminRepository.findAllAccounts()) {
+      const symbol = symbolOf(a);
+      if (a.is_active && symbol) out[a.name] = symbol.toUpperCase();
+    }
+    return out;
+  }
+}
+
+export const accountDefaultsService = new AccountDefaultsService();
@@ -0,0 +1,148 @@
+import { Vault } from "../types";
+import { settingsRepository, vaultRepository } from "../repositories";
+import { vaultService } from "./vault.service";
+import {
+  CashFlow,
+  calculateIRRBasedAPR,
+  dateDiffInDays,
+} from "./financial.service";
+
+export interface AccountPerformance {
+  account: string;
+  vaults: string[];
+  depositsUSD: number; // money moved in from outside the account
+  withdrawalsUSD: number; // money taken out of the account
+  netContributedUSD: number;
+  valueUSD: number; // current value of the account's vaults
+  valueChangeUSD: number; // value not explained by deposits/withdrawals
+  roiPercent: number; // value change over deposits
+  moneyWeightedPercent: number; // annualized IRR of the account's flows
+  firstFlowAt?: string;
+}
+
+export interface AccountPerformanceReport {
+  accounts: AccountPerformance[];
+  totals: Omit<AccountPerformance, "account" | "vaults" | "firstFlowAt">;
+}
+
+/** The account a vault rolls up to: its own name unless one is set. */
+export function accountOf(vault: Vault): string {
+  return vault.account?.trim() || vault.name;
+}
+
+function summarize(
+  depositsUSD: number,
+  withdrawalsUSD: number,
+  valueUSD: number,
+  flows: Array<{ at: string; amount: number }>,
+  now: Date,
+) {
+  const netContributedUSD = depositsUSD - withdrawalsUSD;
+  const valueChangeUSD = valueUSD - netContributedUSD;
+  const roi = depositsUSD > 0 ? valueChangeUSD / depositsUSD : 0;
+
+  let moneyWeightedPercent = 0;
+  const sorted = [...flows].sort((a, b) => a.at.localeCompare(b.at));
+  if (sorted.length) {
+    const start = new Date(sorted[0].at);
+    const cashFlows: CashFlow[] = sorted.map((f) => ({
+      amount: f.amount,
+      daysFromStart: Math.max(0, dateDiffInDays(start, new Date(f.at))),
+    }));
+    const days = Math.max(1, dateDiffInDays(start, now));
+    cashFlows.push({ amount: valueUSD, daysFromStart: days });
+    const apr = calculateIRRBasedAPR(cashFlows, days, roi);
+    moneyWeightedPercent = Number.isFinite(apr) ? apr : roi * 100;
+  }
+
+  return {
+    depositsUSD,
+    withdrawalsUSD,
+    netContributedUSD,
+    valueUSD,
+    valueChangeUSD,
+    roiPercent: roi * 100,
+    moneyWeightedPercent,
+  };
+}
+
+export class AccountPerformanceService {
+  /**
+   * Performance per account across the vaults held in it. Transfers
+   * between vaults of the same account are internal and not counted as
+   * deposits or withdrawals. The default spending and income vaults hold
+   * cash rather than investments and are left out.
+   */
+  async report(
+    filter: { account?: string } = {},
+    now: Date = new Date(),
+  ): Promise<AccountPerformanceReport> {
+    const cash = new Set([
+      settingsRepository.getDefaultSpendingVaultName(),
+      settingsRepository.getDefaultIncomeVaultName(),
+    ]);
+    const vaults = vaultRepository.findAll();
+    const accountByVault = new Map(vaults.map((v) => [v.name, accountOf(v)]));
+    const wanted = filter.account?.toLowerCase();
+
+    const groups = new Map<string, Vault[]>();
+    for (const v of vaults) {
+      if (cash.has(v.name)) continue;
+      const account = accountOf(v);
+      if (wanted && account.toLowerCase() !== wanted) continue;
+      groups.set(account, [...(groups.get(account) || []), v]);
+    }
+
+    const allFlows: Array<{ at: string; amount: number }> = [];
+    const accounts: AccountPerformance[] = [];
+    for (const [account, members] of groups) {
+      let deposits = 0;
+      let withdrawals = 0;
+      let value = 0;
+      const flows: Array<{ at: string; amount: number }> = [];
+      for (const v of members) {
+        for (const e of vaultRepository.findAllEntries(v.name)) {
+          if (e.type === "VALUATION") continue;
+          const internal =
+            !!e.account &&
+            e.account !== v.name &&
+            accountByVault.get(e.account) === account;
+          if (internal) continue;
+          const usd = Number(e.usdValue || 0);
+          if (e.type === "DEPOSIT") deposits += usd;
+          else withdrawals += usd;
+          flows.push({ at: e.at, amount: e.type === "DEPOSIT" ? -usd : usd });
+        }
+        value += (await vaultService.vaultStats(v.name)).aumUSD;
+      }
+
+      allFlows.push(...flows);
+      accounts.push({
+        account,
+        vaults: members.map((v) => v.name).sort(),
+        ...summarize(deposits, withdrawals, value, flows, now),
+        firstFlowAt: flows.reduce<string | undefined>(
+          (m, f) => (!m || f.at < m ? f.at : m),
+          undefined,
+        ),
+      });
+    }
+
+    accounts.sort((a, b) => b.valueUSD - a.valueUSD);
+    const sum = (k: "depositsUSD" | "withdrawalsUSD" | "valueUSD") =>
+      accounts.reduce((s, a) => s + a[k], 0);
+    // Transfers between accounts cancel out in the totals' flows
+    return {
+      accounts,
+      totals: summarize(
+        sum("depositsUSD"),
+        sum("withdrawalsUSD"),
+        sum("valueUSD"),
+        allFlows,
+        now,
+      ),
+    };
+  }
+}
+
+export const accountPerformanceService = new AccountPerformanceService();
@@ -0,0 +1,701 @@
+import { AsyncLocalStorage } from "async_hooks";
+import { v4 as uuidv4 } from "uuid";
+import { priceService } from "./price.service";
+import {
+  ActionConfirmRequest,
+  ActionIntent,
+  ActionIntentStatus,
+  ActionPrepareRequest,
+  Asset,
+  Transaction,
+  VaultEntry,
+} from "../types";
+import {
+  actionIntentRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import { transactionService } from "./transaction.service";
+import { addressBookService } from "./address-book.service";
+import { precisionService } from "./precision.service";
+import { accountDefaultsService } from "./account-defaults.service";
+import { cashService, DEFAULT_CASH_ACCOUNT } from "./cash.service";
+import { eventBus } from "./event-bus.service";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { ConflictError, NotFoundError, ValidationError } from "../core/errors";
+
+// Compound actions of POST /actions
+export const ACTIONS = [
+  "spot_buy",
+  "init_balance",
+  "transfer",
+  "drip",
+  "network_fee",
+  "atm_withdrawal",
+  "cash_count",
+];
+
+const DEFAULT_INTENT_TTL_MS = 15 * 60 * 1000;
+// A running confirm touches its intent this often, so every process sees
+// it is alive however long its price lookups take
+const CONFIRM_HEARTBEAT_MS = 60 * 1000;
+// A CONFIRMING intent without a heartbeat for this long was interrupted
+// (e.g. the process died during the confirm)
+const CONFIRM_TIMEOUT_MS = 5 * 60 * 1000;
+
+// The intent whose confirm is creating the current transactions
+const confirming = new AsyncLocalStorage<string>();
+
+// HTTP status and body of an action
+export interface ActionResponse {
+  status: number;
+  body: Record<string, unknown>;
+}
+
+const reply = (status: number, body: Record<string, unknown>) => ({
+  status,
+  body,
+});
+
+function toISODate(dateStr: string | undefined): string | undefined {
+  if (!dateStr) return undefined;
+  // Accept YYYY-MM-DD and convert to start of day UTC
+  const d = new Date(dateStr);
+  if (isNaN(d.getTime())) return undefined;
+  const iso = new Date(
+    Date.UTC(d.getUTCFullYear(), d.getUTCMonth(), d.getUTCDate(), 0, 0, 0),
+  ).toISOString();
+  return iso;
+}
+
+export class ActionService {
+  // Intents with a confirm running in this process
+  private inFlight = new Set<string>();
+
+  /**
+   * Record an action as transactions right away. Invalid params come back
+   * as a 400 response; failures further in throw.
+   */
+  async execute(action: string, params: any): Promise<ActionResponse> {
+    switch (action) {
+      case "spot_buy": {
+        // params: { date, exchange_account, base_asset, quote_asset, quantity, price_quote?, fee_percent? }
+        const base = String(params?.base_asset ?? "").toUpperCase();
+        const quote = String(params?.quote_asset ?? "").toUpperCase();
+        const quantity = Number(params?.quantity ?? 0);
+        if (!base || !quote || !(quantity > 0)) {
+          return reply(400, { error: "Invalid spot_buy params" });
+        }
+        const feePercent = params?.fee_percent ? Number(params.fee_percent) : 0;
+        let priceQuote = params?.price_quote ? Number(params.price_quote) : NaN;
+        const atISO = toISODate(params?.date);
+
+        const baseAsset: Asset = createAssetFromSymbol(base);
+        const quoteAsset: Asset = createAssetFromSymbol(quote);
+        precisionService.validate(baseAsset, quantity, "quantity");
+
+        if (!isFinite(priceQuote) || priceQuote <= 0) {
+          // derive from USD rates: price_quote = (base/USD) / (quote/USD) in quote units
+          const baseRate = await priceService.getRateUSD(baseAsset, atISO);
+          const quoteRate = await priceService.getRateUSD(quoteAsset, atISO);
+          priceQuote = baseRate.rateUSD / quoteRate.rateUSD;
+        }
+
+        const grossQuoteSpent = quantity * priceQuote;
+        const totalQuoteSpent = precisionService.round(
+          quoteAsset,
+          grossQuoteSpent * (1 + (isFinite(feePercent) ? feePercent / 100 : 0)),
+        );
+
+        // Create two transactions: income base, expense quote
+        const baseRate = await priceService.getRateUSD(baseAsset, atISO);
+        const quoteRate = await priceService.getRateUSD(quoteAsset, atISO);
+
+        const account: string | undefined = params?.exchange_account
+          ? String(params.exchange_account)
+          : undefined;
+
+        const incomeTx: Transaction = {
+          id: uuidv4(),
+          type: "INCOME",
+          note: `spot_buy ${quantity} ${base} @ ${priceQuote} ${quote}`,
+          asset: baseAsset,
+          amount: quantity,
+          createdAt: atISO ?? new Date().toISOString(),
+          account,
+          rate: baseRate,
+          usdAmount: quantity * baseRate.rateUSD,
+        } as Transaction;
+
+        const expenseTx: Transaction = {
+          id: uuidv4(),
+          type: "EXPENSE",
+          note: `spot_buy cost ${totalQuoteSpent} ${quote}${
+            isFinite(feePercent) && feePercent > 0
+              ? ` (+${feePercent}% fee)`
+              : ""
+          }`,
+          asset: quoteAsset,
+          amount: totalQuoteSpent,
+          createdAt: atISO ?? new Date().toISOString(),
+          account,
+          rate: quoteRate,
+          usdAmount: totalQuoteSpent * quoteRate.rateUSD,
+        } as Transaction;
+
+        transactionRepository.create(incomeTx);
+        transactionRepository.create(expenseTx);
+
+        return reply(201, {
+          ok: true,
+          created: 2,
+          transactions: [incomeTx, expenseTx],
+        });
+      }
+      case "init_balance": {
+        // params: { date, account, asset?, quantity, price_local?, note? }
+        // asset defaults to the account's default asset
+        const account: string | undefined = params?.account
+          ? String(params.account)
+          : undefined;
+        const asset = accountDefaultsService.resolveAsset(
+          params?.asset,
+          account,
+        );
+        const quantity = Number(params?.quantity ?? 0);
+        if (!asset || !(quantity > 0)) {
+          return reply(400, { error: "Invalid init_balance params" });
+        }
+        const atISO = toISODate(params?.date);
+        const note: string | undefined = params?.note
+          ? String(params.note)
+          : undefined;
+
+        precisionService.validate(asset, quantity, "quantity");
+
+        let rateUSD: number | undefined = undefined;
+        if (params?.price_local && Number(params.price_local) > 0) {
+          rateUSD = Number(params.price_local);
+        }
+        const rate =
+          rateUSD && isFinite(rateUSD) && rateUSD > 0
+            ? {
+                asset,
+                rateUSD,
+                timestamp: atISO ?? new Date().toISOString(),
+                source: "FIXED" as const,
+              }
+            : await priceService.getRateUSD(asset, atISO);
+
+        const tx: Transaction = {
+          id: uuidv4(),
+          type: "INITIAL",
+          note,
+          asset,
+          amount: quantity,
+          createdAt: atISO ?? new Date().toISOString(),
+          account,
+          rate,
+          usdAmount: quantity * rate.rateUSD,
+        } as Transaction;
+
+        transactionRepository.create(tx);
+        return reply(201, { ok: true, created: 1, transactions: [tx] });
+      }
+      case "transfer": {
+        const transferId = uuidv4();
+        const fromAccount = String(params?.from_account || "");
+        // to_address references an address book entry by id, name or address
+        const entry = params?.to_address
+          ? addressBookService.resolve(String(params.to_address))
+          : undefined;
+        if (params?.to_address && !entry) {
+          return reply(400, {
+            error: `Unknown address book entry: ${params.to_address}`,
+          });
+        }
+        const toAccount = String(
+          params?.to_account || entry?.account || entry?.name || "",
+        );
+        const quantity = Number(params?.quantity || 0);
+        // asset defaults to the source account's default asset
+        const sourceAsset = accountDefaultsService.resolveAsset(
+          params?.asset,
+          fromAccount,
+        );
+        const assetSymbol = sourceAsset?.symbol ?? "";
+
+        if (!fromAccount || !toAccount || !assetSymbol || quantity <= 0) {
+          return reply(400, { error: "Invalid transfer params" });
+        }
+
+        const atISO = toISODate(params?.date);
+        const note = params?.note ? String(params.note) : undefined;
+        // On-chain transfers: the fee is the network (gas) fee on this chain
+        const chain = params?.chain
+          ? String(params.chain).trim().toLowerCase()
+          : undefined;
+        const { warnings } = addressBookService.checkDestination({
+          toAccount: entry?.name ?? toAccount,
+          asset: assetSymbol,
+          chain,
+        });
+
+        const asset: Asset = createAssetFromSymbol(assetSymbol);
+
+        // Destination asset (for cross-currency)
+        const toAssetSymbol = params?.to_asset
+          ? String(params.to_asset).toUpperCase()
+          : assetSymbol;
+        const toAsset: Asset = createAssetFromSymbol(toAssetSymbol);
+
+        const toQuantity = params?.to_amount
+          ? Number(params.to_amount)
+          : quantity; // Default to 1:1 if not specified (will be fixed by rate if same asset, but if different need input or rate)
+
+        const fee = params?.fee ? Number(params.fee) : 0;
+        precisionService.validate(asset, quantity, "quantity");
+        precisionService.validate(toAsset, toQuantity, "to_amount");
+        precisionService.validate(asset, fee, "fee");
+
+        // Rates
+        const rateFrom = await priceService.getRateUSD(asset, atISO);
+        const rateTo = await priceService.getRateUSD(toAsset, atISO);
+
+        const txOut: Transaction = {
+          id: uuidv4(),
+          type: "TRANSFER_OUT",
+          asset,
+          amount: quantity,
+          createdAt: atISO ?? new Date().toISOString(),
+          account: fromAccount,
+          note: note
+            ? `Transfer to ${toAccount}: ${note}`
+            : `Transfer to ${toAccount}`,
+          counterparty: entry?.owner,
+          transferId,
+          chain,
+          rate: rateFrom,
+          usdAmount: quantity * rateFrom.rateUSD,
+        } as Transaction;
+
+        const txIn: Transaction = {
+          id: uuidv4(),
+          type: "TRANSFER_IN",
+          asset: toAsset,
+          amount: toQuantity,
+          createdAt: atISO ?? new Date().toISOString(),
+          account: toAccount,
+          note: note
+            ? `Transfer from ${fromAccount}: ${note}`
+            : `Transfer from ${fromAccount}`,
+          transferId,
+          chain,
+          rate: rateTo,
+          usdAmount: toQuantity * rateTo.rateUSD,
+        } as Transaction;
+
+        const txs: Transaction[] = [txOut, txIn];
+
+        // Fee?
+        if (fee > 0) {
+          // Assuming fee is in source asset unless specified
+          // For simplicity, let's assume fee is in source asset for now or verify 'fee_asset'
+          // If fee is separate from transfer amount? "Fees treated as operating expenses."
+          // Usually fee is deducted from source.
+          // Let's create an EXPENSE transaction for the fee.
+          const feeTx: Transaction = {
+            id: uuidv4(),
+            type: "EXPENSE",
+            asset,
+            amount: fee,
+            createdAt: atISO ?? new Date().toISOString(),
+            account: fromAccount,
+            note: chain ? `Network fee (${chain})` : `Transfer fee`,
+            category: chain ? "network_fee" : undefined,
+            transferId,
+            chain,
+            rate: rateFrom,
+            usdAmount: fee * rateFrom.rateUSD,
+          } as Transaction;
+          txs.push(feeTx);
+          transactionRepository.create(feeTx);
+        }
+
+        // Same-asset transfers can lose at most the fee in transit
+        const lost = quantity - toQuantity - fee;
+        if (toAsset.symbol === asset.symbol && lost > 1e-9) {
+          warnings.push(
+            `Received ${toQuantity} ${asset.symbol} of ${quantity} sent; ` +
+              `${lost} ${asset.symbol} not explained by the fee`,
+          );
+        }
+
+        transactionRepository.create(txOut);
+        transactionRepository.create(txIn);
+
+        return reply(201, {
+          ok: true,
+          created: txs.length,
+          transactions: txs,
+          ...(warnings.length ? { warnings } : {}),
+        });
+      }
+      case "drip": {
+        // params: { date, account, asset, dividend, dividend_asset?, quantity?, price?, note? }
+        // quantity defaults to dividend / price (price in dividend_asset)
+        const symbol = String(params?.asset ?? "").toUpperCase();
+        const account = String(params?.account ?? "").trim();
+        const dividend = Number(params?.dividend ?? 0);
+        const price = params?.price ? Number(params.price) : NaN;
+        const asset = createAssetFromSymbol(symbol);
+        const dividendAsset = createAssetFromSymbol(
+          String(params?.dividend_asset ?? "USD").toUpperCase(),
+        );
+        const quantity = params?.quantity
+          ? Number(params.quantity)
+          : isFinite(price) && price > 0
+            ? precisionService.round(asset, dividend / price)
+            : NaN;
+        if (!symbol || !account || !(dividend > 0) || !(quantity > 0)) {
+          return reply(400, { error: "Invalid drip params" });
+        }
+        precisionService.validate(asset, quantity, "quantity");
+        precisionService.validate(dividendAsset, dividend, "dividend");
+
+        const txs = await transactionService.createDripTransactions({
+          asset,
+          units: quantity,
+          dividend,
+          dividendAsset,
+          account,
+          at: toISODate(params?.date),
+          note: params?.note ? String(params.note) : undefined,
+        });
+        return reply(201, { ok: true, created: txs.length, transactions: txs });
+      }
+      case "network_fee": {
+        // params: { date, account, chain, asset?, amount, tx_hash?, note? }
+        // Gas paid for on-chain activity other than transfers (swaps,
+        // approvals, contract calls), in the chain's native asset
+        const amount = Number(params?.amount ?? 0);
+        const account = String(params?.account ?? "").trim();
+        const asset = accountDefaultsService.resolveAsset(
+          params?.asset,
+          account,
+        );
+        const chain = String(params?.chain ?? "")
+          .trim()
+          .toLowerCase();
+        if (!asset || !account || !chain || !(amount > 0)) {
+          return reply(400, { error: "Invalid network_fee params" });
+        }
+
+        const atISO = toISODate(params?.date);
+        precisionService.validate(asset, amount);
+        const rate = await priceService.getRateUSD(asset, atISO);
+        const tx: Transaction = {
+          id: uuidv4(),
+          type: "EXPENSE",
+          asset,
+          amount,
+          createdAt: atISO ?? new Date().toISOString(),
+          account,
+          note: params?.note
+            ? `Network fee (${chain}): ${params.note}`
+            : `Network fee (${chain})`,
+          category: "network_fee",
+          sourceRef: params?.tx_hash ? String(params.tx_hash) : undefined,
+          chain,
+          rate,
+          usdAmount: amount * rate.rateUSD,
+        } as Transaction;
+
+        transactionRepository.create(tx);
+        return reply(201, { ok: true, created: 1, transactions: [tx] });
+      }
+      case "atm_withdrawal": {
+        // params: { date, from_account, amount, asset?, to_account?, fee?, note? }
+        // Bank to cash wallet; the fee is an expense of the bank account
+        const fromAccount = String(params?.from_account ?? "").trim();
+        const asset = accountDefaultsService.resolveAsset(
+          params?.asset,
+          fromAccount,
+        );
+        if (!asset || !fromAccount) {
+          return reply(400, { error: "Invalid atm_withdrawal params" });
+        }
+        const txs = await cashService.withdraw({
+          fromAccount,
+          toAccount: params?.to_account
+            ? String(params.to_account)
+            : undefined,
+          asset,
+          amount: Number(params?.amount ?? 0),
+          fee: params?.fee ? Number(params.fee) : undefined,
+          at: toISODate(params?.date),
+          note: params?.note ? String(params.note) : undefined,
+        });
+        return reply(201, { ok: true, created: txs.length, transactions: txs });
+      }
+      case "cash_count": {
+        // params: { date, counted, account?, asset?, note? }
+        // Books the gap between the count and the ledger as cash leakage
+        const account = String(params?.account || DEFAULT_CASH_ACCOUNT).trim();
+        const asset = accountDefaultsService.resolveAsset(
+          params?.asset,
+          account,
+        );
+        if (!asset || params?.counted === undefined) {
+          return reply(400, { error: "Invalid cash_count params" });
+        }
+        const r = await cashService.count({
+          account,
+          asset,
+          counted: Number(params.counted),
+          at: toISODate(params?.date),
+          note: params?.note ? String(params.note) : undefined,
+        });
+        return reply(r.transaction ? 201 : 200, {
+          ok: true,
+          created: r.transaction ? 1 : 0,
+          transactions: r.transaction ? [r.transaction] : [],
+          count: {
+            account: r.account,
+            asset: r.asset.symbol,
+            recorded: r.recorded,
+            counted: r.counted,
+            difference: r.difference,
+          },
+        });
+      }
+      default:
+        return reply(400, { error: `Unknown action: ${action}` });
+    }
+  }
+
+  /**
+   * First phase of an action with an external side effect: record what is
+   * about to happen, before it does. Nothing is booked until the intent is
+   * confirmed. With `client_ref`, preparing again (e.g. a retry after a
+   * timeout) returns the intent already made for it.
+   */
+  prepare(
+    input: ActionPrepareRequest,
+    now = new Date(),
+  ): { intent: ActionIntent; created: boolean } {
+    if (!ACTIONS.includes(input.action)) {
+      throw new ValidationError(`Unknown action: ${input.action}`);
+    }
+    if (input.client_ref) {
+      const existing = actionIntentRepository.findByClientRef(
+        input.client_ref,
+      );
+      if (existing) {
+        if (existing.action !== input.action) {
+          throw new ConflictError(
+            `client_ref ${input.client_ref} is a ${existing.action} intent`,
+          );
+        }
+        return { intent: this.refresh(existing, now), created: false };
+      }
+    }
+    const ttl = input.expires_in_seconds
+      ? input.expires_in_seconds * 1000
+      : DEFAULT_INTENT_TTL_MS;
+    const intent = actionIntentRepository.create({
+      id: uuidv4(),
+      action: input.action,
+      params: input.params,
+      status: "PREPARED",
+      clientRef: input.client_ref,
+      transactionIds: [],
+      createdAt: now.toISOString(),
+      expiresAt: new Date(now.getTime() + ttl).toISOString(),
+    });
+    return { intent, created: true };
+  }
+
+  /**
+   * Second phase: the side effect happened, so book the action. Its
+   * transactions are created once; confirming again returns the first
+   * response (`replayed`). A confirm already in flight, or one for a
+   * cancelled or expired intent, is a conflict. When the action is
+   * rejected or fails part way, whatever it booked is deleted and the
+   * intent is PREPARED again with the error, to be confirmed again with
+   * corrected params.
+   */
+  async confirm(
+    id: string,
+    input: ActionConfirmRequest = {},
+    now = new Date(),
+  ): Promise<{
+    intent: ActionIntent;
+    response: ActionResponse;
+    replayed: boolean;
+  }> {
+    const intent = this.get(id, now);
+    const externalRef = input.external_ref;
+    if (intent.status === "CONFIRMED") {
+      if (externalRef && intent.externalRef !== externalRef) {
+        throw new ConflictError(
+          `Intent ${id} was confirmed for ${intent.externalRef ?? "no ref"}`,
+        );
+      }
+      return { intent, response: intent.response!, replayed: true };
+    }
+    if (intent.status !== "PREPARED") {
+      throw new ConflictError(`Intent ${id} is ${intent.status}`);
+    }
+
+    // Claimed before the first await, so a concurrent confirm sees it
+    const params = { ...intent.params, ...(input.params ?? {}) };
+    this.save(id, {
+      status: "CONFIRMING",
+      params,
+      externalRef: externalRef ?? intent.externalRef,
+      transactionIds: [],
+      vaultEntries: [],
+    });
+    let response: ActionResponse;
+    this.inFlight.add(id);
+    const heartbeat = setInterval(
+      () => this.save(id, {}),
+      CONFIRM_HEARTBEAT_MS,
+    );
+    heartbeat.unref?.();
+    // Transactions and vault entries are recorded on the intent as they
+    // are created, so a confirm that fails (or never finishes) can take
+    // them back out
+    const unsubscribe = eventBus.subscribe((event) => {
+      if (confirming.getStore() !== id) return;
+      const current = actionIntentRepository.findById(id)!;
+      if (event.type === "transaction.created") {
+        this.save(id, {
+          transactionIds: [
+            ...current.transactionIds,
+            (event.data as Transaction).id,
+          ],
+        });
+      } else if (event.type === "vault_entry.created") {
+        this.save(id, {
+          vaultEntries: [
+            ...(current.vaultEntries ?? []),
+            event.data as VaultEntry,
+          ],
+        });
+      }
+    });
+    try {
+      response = await confirming.run(id, () =>
+        this.execute(intent.action, params),
+      );
+    } catch (e: any) {
+      response = reply(400, {
+        error: e?.message || "Invalid action request",
+      });
+    } finally {
+      clearInterval(heartbeat);
+      unsubscribe();
+      this.inFlight.delete(id);
+    }
+
+    if (response.status >= 400) {
+      const failed = this.rollback(
+        id,
+        String(response.body.error ?? "Action rejected"),
+      );
+      return { intent: failed, response, replayed: false };
+    }
+    const txs = (response.body.transactions ?? []) as Transaction[];
+    const confirmed = this.save(id, {
+      status: "CONFIRMED",
+      transactionIds: txs.map((t) => t.id),
+      vaultEntries: undefined,
+      response,
+      error: undefined,
+      confirmedAt: new Date().toISOString(),
+    });
+    return { intent: confirmed, response, replayed: false };
+  }
+
+  /**
+   * Give up on a prepared intent, e.g. the exchange rejected the order.
+   * Cancelling a cancelled or expired intent changes nothing; one that
+   * is confirmed, or being confirmed, can't be cancelled.
+   */
+  cancel(id: string, reason?: string, now = new Date()): ActionIntent {
+    const intent = this.get(id, now);
+    if (intent.status === "CANCELLED" || intent.status === "EXPIRED") {
+      return intent;
+    }
+    if (intent.status !== "PREPARED") {
+      throw new ConflictError(`Intent ${id} is ${intent.status}`);
+    }
+    return this.save(id, {
+      status: "CANCELLED",
+      cancelReason: reason,
+      cancelledAt: now.toISOString(),
+    });
+  }
+
+  get(id: string, now = new Date()): ActionIntent {
+    const intent = actionIntentRepository.findById(id);
+    if (!intent) throw new NotFoundError("Action intent", id);
+    return this.refresh(intent, now);
+  }
+
+  /** Intents, newest first, optionally of one status. */
+  list(status?: ActionIntentStatus, now = new Date()): ActionIntent[] {
+    return actionIntentRepository
+      .findAll()
+      .map((i) => this.refresh(i, now))
+      .filter((i) => !status || i.status === status);
+  }
+
+  // A prepared intent past its expiry is EXPIRED from then on; a confirm
+  // whose heartbeat stopped is rolled back, to be confirmed again
+  private refresh(intent: ActionIntent, now: Date): ActionIntent {
+    if (intent.status === "CONFIRMING") {
+      const since = Date.parse(intent.updatedAt ?? intent.createdAt);
+      if (
+        this.inFlight.has(intent.id) ||
+        now.getTime() - since < CONFIRM_TIMEOUT_MS
+      ) {
+        return intent;
+      }
+      return this.rollback(intent.id, "Confirm was interrupted");
+    }
+    if (intent.status !== "PREPARED" || intent.expiresAt > now.toISOString()) {
+      return intent;
+    }
+    return this.save(intent.id, { status: "EXPIRED" });
+  }
+
+  // Delete what a failed confirm booked, vault entries included, and make
+  // the intent PREPARED again
+  private rollback(id: string, error: string): ActionIntent {
+    const intent = actionIntentRepository.findById(id)!;
+    for (const txId of intent.transactionIds) {
+      transactionRepository.delete(txId);
+    }
+    for (const entry of intent.vaultEntries ?? []) {
+      vaultRepository.deleteEntry(entry);
+    }
+    return this.save(id, {
+      status: "PREPARED",
+      transactionIds: [],
+      vaultEntries: undefined,
+      error,
+    });
+  }
+
+  private save(id: string, updates: Partial<ActionIntent>): ActionIntent {
+    return actionIntentRepository.update(id, {
+      ...updates,
+      updatedAt: new Date().toISOString(),
+    })!;
+  }
+}
+
+export const actionService = new ActionService();
@@ -0,0 +1,223 @@
+import {
+  adminRepository,
+  pendingActionsRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import { stablecoinService } from "./stablecoin.service";
+import { mergeService } from "./merge.service";
+
+export type ActivityKind =
+  | "transaction"
+  | "vault"
+  | "vault_entry"
+  | "import"
+  | "price_alert"
+  | "admin";
+export const ACTIVITY_KINDS: ActivityKind[] = [
+  "transaction",
+  "vault",
+  "vault_entry",
+  "import",
+  "price_alert",
+  "admin",
+];
+
+export interface ActivityItem {
+  id: string; // `${kind}:${source id}`, stable across requests
+  kind: ActivityKind;
+  at: string;
+  title: string;
+  meta: Record<string, unknown>;
+}
+
+export interface ActivityQuery {
+  kinds?: ActivityKind[];
+  start?: string;
+  end?: string;
+  limit?: number;
+  offset?: number;
+}
+
+function transactionItems(): ActivityItem[] {
+  return transactionRepository.findAll().map(
+    (t): ActivityItem => ({
+      id: `transaction:${t.id}`,
+      kind: "transaction",
+      at: t.createdAt,
+      title: `${t.type} ${t.amount} ${t.asset.symbol}`,
+      meta: {
+        transactionId: t.id,
+        type: t.type,
+        asset: t.asset.symbol,
+        amount: t.amount,
+        usdAmount: t.usdAmount,
+        account: t.account,
+        category: t.category,
+        counterparty: t.counterparty,
+      },
+    }),
+  );
+}
+
+function vaultItems(): ActivityItem[] {
+  return vaultRepository.findAll().map(
+    (v): ActivityItem => ({
+      id: `vault:${v.name}`,
+      kind: "vault",
+      at: v.createdAt,
+      title: `Vault ${v.name} created`,
+      meta: { vault: v.name, status: v.status },
+    }),
+  );
+}
+
+// Vault entries have no id; their position within the vault stands in
+function vaultEntryItems(): ActivityItem[] {
+  return vaultRepository.findAll().flatMap((v) =>
+    vaultRepository.findAllEntries(v.name).map(
+      (e, i): ActivityItem => ({
+        id: `vault_entry:${v.name}:${i}`,
+        kind: "vault_entry",
+        at: e.at,
+        title: `${v.name}: ${e.type} ${e.amount} ${e.asset.symbol}`,
+        meta: {
+          vault: v.name,
+          type: e.type,
+          asset: e.asset.symbol,
+          amount: e.amount,
+          usdValue: e.usdValue,
+          note: e.note,
+        },
+      }),
+    ),
+  );
+}
+
+// Pending actions arrive in batches (one bank statement, one chat import)
+function importItems(): ActivityItem[] {
+  const batches = new Map<string, ActivityItem & { counts: any }>();
+  for (const p of pendingActionsRepository.findAll()) {
+    const key = p.batch_id || p.id;
+    let item = batches.get(key);
+    if (!item) {
+      item = {
+        id: `import:${key}`,
+        kind: "import",
+        at: p.created_at,
+        title: "",
+        meta: { batchId: p.batch_id, source: p.source },
+        counts: { pending: 0, accepted: 0, rejected: 0 },
+      };
+      batches.set(key, item);
+    }
+    if (p.created_at < item.at) item.at = p.created_at;
+    item.counts[p.status] += 1;
+  }
+  return Array.from(batches.values()).map(({ counts, ...item }) => {
+    const total = counts.pending + counts.accepted + counts.rejected;
+    return {
+      ...item,
+      title: `Imported ${total} item(s) from ${item.meta.source}`,
+      meta: { ...item.meta, total, ...counts },
+    };
+  });
+}
+
+function priceAlertItems(): ActivityItem[] {
+  return stablecoinService.getAlerts().map(
+    (a): ActivityItem => ({
+      id: `price_alert:${a.symbol}:${a.at}`,
+      kind: "price_alert",
+      at: a.at,
+      title:
+        a.kind === "DEPEG"
+          ? `${a.symbol} depegged at $${a.priceUSD}`
+          : `${a.symbol} back at peg ($${a.priceUSD})`,
+      meta: {
+        symbol: a.symbol,
+        alert: a.kind,
+        priceUSD: a.priceUSD,
+        deviationPercent: a.deviationPercent,
+      },
+    }),
+  );
+}
+
+// Admin config has creation timestamps only; of later edits just merges
+// are logged
+function adminItems(): ActivityItem[] {
+  const added = (
+    entity: string,
+    rows: Array<{ id: number; created_at: string }>,
+    label: (r: any) => string,
+  ): ActivityItem[] =>
+    rows.map((r): ActivityItem => ({
+      id: `admin:${entity}:${r.id}`,
+      kind: "admin",
+      at: r.created_at,
+      title: `${entity} ${label(r)} added`,
+      meta: { entity, entityId: r.id, action: "created" },
+    }));
+
+  return [
+    ...added("type", adminRepository.findAllTypes(), (r) => r.name),
+    ...added("account", adminRepository.findAllAccounts(), (r) => r.name),
+    ...added("asset", adminRepository.findAllAssets(), (r) => r.symbol),
+    ...added("tag", adminRepository.findAllTags(), (r) => r.name),
+    ...mergeService.log().map(
+      (m): ActivityItem => ({
+        id: `admin:merge:${m.id}`,
+        kind: "admin",
+        at: m.at,
+        title: `${m.kind} ${m.from} merged into ${m.into}`,
+        meta: {
+          entity: m.kind,
+          entityId: m.intoId,
+          action: "merged",
+          mergedId: m.fromId,
+          counts: m.counts,
+        },
+      }),
+    ),
+  ];
+}
+
+// Date-only bounds cover the whole day
+function endTime(value: string): number {
+  const t = new Date(value).getTime();
+  return /^\d{4}-\d{2}-\d{2}$/.test(value) ? t + 24 * 60 * 60 * 1000 - 1 : t;
+}
+
+const SOURCES: Record<ActivityKind, () => ActivityItem[]> = {
+  transaction: transactionItems,
+  vault: vaultItems,
+  vault_entry: vaultEntryItems,
+  import: importItems,
+  price_alert: priceAlertItems,
+  admin: adminItems,
+};
+
+export class ActivityService {
+  /**
+   * Everything that happened, newest first, as one list. Each item keeps
+   * its kind and source ids in `meta` so the client can link back.
+   */
+  list(query: ActivityQuery = {}): { items: ActivityItem[]; total: number } {
+    const kinds = query.kinds?.length ? query.kinds : ACTIVITY_KINDS;
+    const start = query.start ? new Date(query.start).getTime() : -Infinity;
+    const end = query.end ? endTime(query.end) : Infinity;
+
+    const time = (i: ActivityItem) => new Date(i.at).getTime();
+    const all = kinds
+      .flatMap((k) => SOURCES[k]())
+      .filter((i) => time(i) >= start && time(i) <= end)
+      .sort((a, b) => time(b) - time(a) || a.id.localeCompare(b.id));
+
+    const offset = Math.max(0, query.offset ?? 0);
+    const limit = Math.max(1, query.limit ?? 50);
+    return { items: all.slice(offset, offset + limit), total: all.length };
+  }
+}
+
+export const activityService = new ActivityService();
@@ -0,0 +1,113 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  AddressBookCreateRequest,
+  AddressBookEntry,
+  AddressBookUpdateRequest,
+} from "../types";
+import {
+  addressBookRepository,
+  adminRepository,
+  vaultRepository,
+} from "../repositories";
+
+export interface TransferDestinationCheck {
+  entry?: AddressBookEntry;
+  warnings: string[];
+}
+
+export class AddressBookService {
+  listEntries(): AddressBookEntry[] {
+    return addressBookRepository.findAll();
+  }
+
+  getEntry(id: string): AddressBookEntry | undefined {
+    return addressBookRepository.findById(id);
+  }
+
+  createEntry(data: AddressBookCreateRequest): AddressBookEntry {
+    if (addressBookRepository.findByName(data.name)) {
+      throw new Error(`Address book entry "${data.name}" already exists`);
+    }
+    return addressBookRepository.create({
+      id: uuidv4(),
+      ...data,
+      chain: data.chain?.toLowerCase(),
+      asset: data.asset?.toUpperCase(),
+      createdAt: new Date().toISOString(),
+    });
+  }
+
+  updateEntry(
+    id: string,
+    data: AddressBookUpdateRequest,
+  ): AddressBookEntry | undefined {
+    if (data.name) {
+      const clash = addressBookRepository.findByName(data.name);
+      if (clash && clash.id !== id) {
+        throw new Error(`Address book entry "${data.name}" already exists`);
+      }
+    }
+    return addressBookRepository.update(id, {
+      ...data,
+      chain: data.chain?.toLowerCase(),
+      asset: data.asset?.toUpperCase(),
+      updatedAt: new Date().toISOString(),
+    });
+  }
+
+  deleteEntry(id: string): boolean {
+    return addressBookRepository.delete(id);
+  }
+
+  /** Find an entry by id, name or address. */
+  resolve(ref: string): AddressBookEntry | undefined {
+    const key = ref.trim().toLowerCase();
+    if (!key) return undefined;
+    return (
+      addressBookRepository.findById(ref) ||
+      addressBookRepository.findByName(ref) ||
+      addressBookRepository
+        .findAll()
+        .find((e) => e.address.toLowerCase() === key)
+    );
+  }
+
+  /**
+   * Check a transfer destination. Vaults and admin accounts are internal
+   * and always known; anything else should be in the address book. Known
+   * entries are also checked against the transfer's asset and chain.
+   * Problems are returned as warnings and never block the transfer.
+   */
+  checkDestination(params: {
+    toAccount: string;
+    asset?: string;
+    chain?: string;
+  }): TransferDestinationCheck {
+    const entry = this.resolve(params.toAccount);
+    if (!entry) {
+      const internal =
+        !!vaultRepository.findByName(params.toAccount) ||
+        adminRepository
+          .findAllAccounts()
+          .some((a) => a.name.toLowerCase() === params.toAccount.toLowerCase());
+      return {
+        warnings: internal
+          ? []
+          : [`Unknown transfer destination "${params.toAccount}"`],
+      };
+    }
+
+    const warnings: string[] = [];
+    const asset = params.asset?.toUpperCase();
+    const chain = params.chain?.toLowerCase();
+    if (entry.asset && asset && entry.asset !== asset) {
+      warnings.push(`"${entry.name}" accepts ${entry.asset}, not ${asset}`);
+    }
+    if (entry.chain && chain && entry.chain !== chain) {
+      warnings.push(`"${entry.name}" is on ${entry.chain}, not ${chain}`);
+    }
+    return { entry, warnings };
+  }
+}
+
+export const addressBookService = new AddressBookService();
@@ -0,0 +1,341 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  ASSET_KINDS,
+  AllocationConstraint,
+  AllocationGlidepath,
+  AssetKind,
+  GlidepathCreateRequest,
+  GlidepathUpdateRequest,
+  PortfolioReport,
+} from "../types";
+import { glidepathRepository, settingsRepository } from "../repositories";
+import { transactionService } from "./transaction.service";
+import { notificationService } from "./notification.service";
+import { getAssetKind } from "../utils/asset.util";
+import { logger } from "../utils/logger";
+
+const CONSTRAINT_CHECK_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const BREACHES_KEY = "allocationBreaches";
+let monitorStarted = false;
+
+export interface AllocationDriftRow {
+  assetClass: AssetKind;
+  valueUSD: number;
+  currentPercent: number;
+  targetPercent?: number;
+  driftPercent?: number; // current minus target, in percentage points
+  driftUSD?: number; // value above (+) or below (-) the target
+  glidepathId?: string;
+}
+
+export interface AllocationDriftReport {
+  at: string;
+  totalUSD: number;
+  rows: AllocationDriftRow[];
+  maxAbsDriftPercent: number;
+}
+
+export interface ConstraintViolation {
+  key: string; // stable per constraint and subject, e.g. "ASSET:BTC:max"
+  constraint: AllocationConstraint;
+  subject: string; // the symbol or asset class measured
+  kind: "ABOVE_MAX" | "BELOW_MIN";
+  valueUSD: number;
+  currentPercent: number;
+  limitPercent: number;
+  gapUSD: number; // value to sell (above max) or add (below min)
+}
+
+const isCash = (kind: AssetKind) => kind === "FIAT" || kind === "STABLECOIN";
+
+/**
+ * Holdings in `report` that break `constraints`. A limit is broken only
+ * past it, so exactly 10% meets a 10% maximum.
+ */
+export function constraintViolations(
+  report: Pick<PortfolioReport, "holdings" | "totals">,
+  constraints: AllocationConstraint[],
+): ConstraintViolation[] {
+  const totalUSD = report.totals.holdingsUSD;
+  if (totalUSD <= 0) return [];
+  const bySymbol = new Map<string, number>();
+  for (const h of report.holdings) {
+    const symbol = h.asset.symbol.toUpperCase();
+    bySymbol.set(symbol, (bySymbol.get(symbol) || 0) + h.valueUSD);
+  }
+  const valueOf = (match: (symbol: string) => boolean) =>
+    [...bySymbol].reduce((s, [sym, v]) => (match(sym) ? s + v : s), 0);
+
+  const violations: ConstraintViolation[] = [];
+  const check = (
+    c: AllocationConstraint,
+    subject: string,
+    valueUSD: number,
+  ) => {
+    const currentPercent = (valueUSD / totalUSD) * 100;
+    const base = { constraint: c, subject, valueUSD, currentPercent };
+    const key = `${c.scope}:${subject}`;
+    if (c.maxPercent !== undefined && currentPercent > c.maxPercent + 1e-9) {
+      violations.push({
+        ...base,
+        key: `${key}:max`,
+        kind: "ABOVE_MAX",
+        limitPercent: c.maxPercent,
+        gapUSD: valueUSD - (totalUSD * c.maxPercent) / 100,
+      });
+    }
+    if (c.minPercent !== undefined && currentPercent < c.minPercent - 1e-9) {
+      violations.push({
+        ...base,
+        key: `${key}:min`,
+        kind: "BELOW_MIN",
+        limitPercent: c.minPercent,
+        gapUSD: (totalUSD * c.minPercent) / 100 - valueUSD,
+      });
+    }
+  };
+
+  for (const c of constraints) {
+    if (c.scope === "ASSET_CLASS") {
+      const cls = c.assetClass!;
+      const inClass = (sym: string) => {
+        const kind = getAssetKind(sym);
+        return cls === "CASH" ? isCash(kind) : kind === cls;
+      };
+      check(c, cls, valueOf(inClass));
+    } else if (c.symbol) {
+      const symbol = c.symbol.toUpperCase();
+      check(c, symbol, bySymbol.get(symbol) || 0);
+    } else {
+      // Every non-cash asset; cash is limited by class
+      for (const [symbol, valueUSD] of bySymbol) {
+        if (!isCash(getAssetKind(symbol))) check(c, symbol, valueUSD);
+      }
+    }
+  }
+  return violations;
+}
+
+/** Scheduled target at `at`: start before the path, end after it. */
+export function targetPercentAt(g: AllocationGlidepath, at: Date): number {
+  const start = new Date(g.startAt).getTime();
+  const end = new Date(g.endAt).getTime();
+  const t = at.getTime();
+  if (t >= end) return g.endPercent;
+  if (t <= start) return g.startPercent;
+  const progress = (t - start) / (end - start);
+  return g.startPercent + (g.endPercent - g.startPercent) * progress;
+}
+
+function toISO(value: string, field: string): string {
+  const d = new Date(value);
+  if (isNaN(d.getTime())) throw new Error(`${field} is not a valid date`);
+  return d.toISOString();
+}
+
+export class AllocationService {
+  listGlidepaths(): AllocationGlidepath[] {
+    return glidepathRepository.findAll();
+  }
+
+  getGlidepath(id: string): AllocationGlidepath | undefined {
+    return glidepathRepository.findById(id);
+  }
+
+  createGlidepath(data: GlidepathCreateRequest): AllocationGlidepath {
+    const startAt = toISO(data.startAt ?? new Date().toISOString(), "startAt");
+    const glidepath: AllocationGlidepath = {
+      id: uuidv4(),
+      assetClass: data.assetClass,
+      startPercent: data.startPercent,
+      endPercent: data.endPercent ?? data.startPercent,
+      startAt,
+      endAt: data.endAt ? toISO(data.endAt, "endAt") : startAt,
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    };
+    this.validate(glidepath);
+    return glidepathRepository.create(glidepath);
+  }
+
+  updateGlidepath(
+    id: string,
+    data: GlidepathUpdateRequest,
+  ): AllocationGlidepath | undefined {
+    const existing = glidepathRepository.findById(id);
+    if (!existing) return undefined;
+
+    const updated: AllocationGlidepath = {
+      ...existing,
+      ...data,
+      startAt: data.startAt ? toISO(data.startAt, "startAt") : existing.startAt,
+      endAt: data.endAt ? toISO(data.endAt, "endAt") : existing.endAt,
+      updatedAt: new Date().toISOString(),
+    };
+    this.validate(updated);
+    return glidepathRepository.update(id, updated);
+  }
+
+  deleteGlidepath(id: string): boolean {
+    return glidepathRepository.delete(id);
+  }
+
+  /**
+   * One glidepath per asset class, and targets may never add up to more
+   * than 100%. Sums are piecewise linear, so checking every path's start
+   * and end dates covers the whole schedule.
+   */
+  private validate(glidepath: AllocationGlidepath): void {
+    if (glidepath.endAt < glidepath.startAt) {
+      throw new Error("endAt must not be before startAt");
+    }
+    const others = glidepathRepository
+      .findAll()
+      .filter((g) => g.id !== glidepath.id);
+    if (others.some((g) => g.assetClass === glidepath.assetClass)) {
+      throw new Error(`A glidepath for ${glidepath.assetClass} already exists`);
+    }
+
+    const all = [...others, glidepath];
+    const dates = new Set(all.flatMap((g) => [g.startAt, g.endAt]));
+    for (const d of dates) {
+      const at = new Date(d);
+      const sum = all.reduce((s, g) => s + targetPercentAt(g, at), 0);
+      if (sum > 100 + 1e-9) {
+        const day = d.slice(0, 10);
+        throw new Error(`Targets add up to ${sum}% on ${day}`);
+      }
+    }
+  }
+
+  /**
+   * Current holdings by asset class against each class's scheduled target
+   * at `at`. Holdings are always valued now; `at` only picks the target.
+   */
+  async getDrift(at: Date = new Date()): Promise<AllocationDriftReport> {
+    const report = await transactionService.generateReport();
+    const totalUSD = report.totals.holdingsUSD;
+    const byClass = new Map<AssetKind, number>();
+    for (const h of report.holdings) {
+      const kind = getAssetKind(h.asset.symbol);
+      byClass.set(kind, (byClass.get(kind) || 0) + h.valueUSD);
+    }
+
+    const paths = new Map(
+      glidepathRepository.findAll().map((g) => [g.assetClass, g]),
+    );
+    const rows: AllocationDriftRow[] = [];
+    for (const assetClass of ASSET_KINDS) {
+      const glidepath = paths.get(assetClass);
+      if (!byClass.has(assetClass) && !glidepath) continue;
+
+      const valueUSD = byClass.get(assetClass) || 0;
+      const currentPercent = totalUSD > 0 ? (valueUSD / totalUSD) * 100 : 0;
+      const row: AllocationDriftRow = { assetClass, valueUSD, currentPercent };
+      if (glidepath) {
+        const targetPercent = targetPercentAt(glidepath, at);
+        row.targetPercent = targetPercent;
+        row.driftPercent = currentPercent - targetPercent;
+        row.driftUSD = valueUSD - (totalUSD * targetPercent) / 100;
+        row.glidepathId = glidepath.id;
+      }
+      rows.push(row);
+    }
+
+    return {
+      at: at.toISOString(),
+      totalUSD,
+      rows,
+      maxAbsDriftPercent: rows.reduce(
+        (m, r) => Math.max(m, Math.abs(r.driftPercent ?? 0)),
+        0,
+      ),
+    };
+  }
+
+  getConstraints(): AllocationConstraint[] {
+    return settingsRepository.getAllocationConstraints();
+  }
+
+  setConstraints(constraints: AllocationConstraint[]): AllocationConstraint[] {
+    const normalized = constraints.map((c) => {
+      if (c.scope === "ASSET_CLASS") return { ...c, symbol: undefined };
+      const symbol = c.symbol?.trim().toUpperCase();
+      return { ...c, symbol, assetClass: undefined };
+    });
+    settingsRepository.setAllocationConstraints(normalized);
+    return normalized;
+  }
+
+  /** Violations of the constraints by current holdings. */
+  async checkConstraints(
+    report?: PortfolioReport,
+  ): Promise<ConstraintViolation[]> {
+    const constraints = this.getConstraints();
+    if (!constraints.length) return [];
+    return constraintViolations(
+      report ?? (await transactionService.generateReport()),
+      constraints,
+    );
+  }
+
+  /**
+   * Check the constraints and notify once for each new breach, e.g. a
+   * token rallying past its maximum. A breach that clears and comes back
+   * is notified again.
+   */
+  async monitorConstraints(
+    report?: PortfolioReport,
+  ): Promise<ConstraintViolation[]> {
+    const violations = await this.checkConstraints(report);
+    let known: string[] = [];
+    try {
+      known = JSON.parse(settingsRepository.getSetting(BREACHES_KEY) || "[]");
+    } catch {
+      known = [];
+    }
+    const fresh = violations.filter((v) => !known.includes(v.key));
+    settingsRepository.setSetting(
+      BREACHES_KEY,
+      JSON.stringify(violations.map((v) => v.key)),
+    );
+    for (const v of fresh) {
+      const side =
+        v.kind === "ABOVE_MAX" ? "above its maximum" : "below its minimum";
+      try {
+        await notificationService.send({
+          kind: "allocation_constraint",
+          title:
+            `${v.subject} is ${v.currentPercent.toFixed(1)}% of the ` +
+            `portfolio, ${side} of ${v.limitPercent}%`,
+          body: `About ${v.gapUSD.toFixed(2)} USD to rebalance`,
+          data: v,
+        });
+      } catch (e: any) {
+        logger.warn(
+          { key: v.key, error: e?.message },
+          "Allocation constraint notification failed",
+        );
+      }
+    }
+    return violations;
+  }
+
+  startConstraintMonitor(): void {
+    if (monitorStarted) return;
+    monitorStarted = true;
+
+    const run = () => {
+      this.monitorConstraints().catch((e: any) =>
+        logger.warn(
+          { error: e?.message },
+          "Allocation constraint check failed",
+        ),
+      );
+    };
+    run();
+    setInterval(run, CONSTRAINT_CHECK_INTERVAL_MS);
+  }
+}
+
+export const allocationService = new AllocationService();
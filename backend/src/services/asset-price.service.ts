@@ -0,0 +1,115 @@
+import {
+  Asset,
+  AssetKind,
+  ManualPrice,
+  ManualPriceRequest,
+  PriceProvider,
+} from "../types";
+import { settingsRepository } from "../repositories";
+import { getAssetKind } from "../utils/asset.util";
+import { getPriceProvider } from "./price-provider.service";
+
+/**
+ * Which providers price an asset, by asset class: FX APIs for fiat,
+ * CoinGecko for crypto and stablecoins, Yahoo Finance then Alpha Vantage
+ * for stocks and ETFs. A manual price, when one is set, wins over all of
+ * them.
+ */
+export class AssetPriceService {
+  assetClass(asset: Asset): AssetKind {
+    return asset.type === "FIAT" ? "FIAT" : getAssetKind(asset.symbol);
+  }
+
+  // Fallback order when the asset has no configured priority
+  defaultProviders(asset: Asset, historical: boolean): PriceProvider[] {
+    const kind = this.assetClass(asset);
+    let names: PriceProvider[];
+    if (kind === "FIAT") {
+      // VND is not supported by Frankfurter, use ExchangeRate-API current rate
+      names = !historical
+        ? ["EXCHANGE_RATE_HOST", "FRANKFURTER", "ER_API"]
+        : asset.symbol.toUpperCase() === "VND"
+          ? ["EXCHANGE_RATE_API"]
+          : ["FRANKFURTER"];
+    } else if (kind === "EQUITY") {
+      names = ["YAHOO_FINANCE", "ALPHA_VANTAGE"];
+    } else if (kind === "CRYPTO" || kind === "STABLECOIN") {
+      names = ["COINGECKO"];
+    } else {
+      names = [];
+    }
+    return names.filter((n) => getPriceProvider(n)?.available());
+  }
+
+  /** The configured priority for the symbol, else the class default. */
+  providersFor(
+    asset: Asset,
+    historical: boolean,
+  ): { providers: PriceProvider[]; configured: boolean } {
+    const configured =
+      settingsRepository.getPriceSourcePriority()[asset.symbol.toUpperCase()];
+    if (configured) {
+      return {
+        providers: configured.filter((n) => getPriceProvider(n)),
+        configured: true,
+      };
+    }
+    return {
+      providers: this.defaultProviders(asset, historical),
+      configured: false,
+    };
+  }
+
+  /** Manual prices, per symbol and oldest first. */
+  listManualPrices(symbol?: string): ManualPrice[] {
+    const sym = symbol?.toUpperCase();
+    return settingsRepository
+      .getManualPrices()
+      .filter((p) => !sym || p.symbol === sym)
+      .sort(
+        (a, b) => a.symbol.localeCompare(b.symbol) || a.at.localeCompare(b.at),
+      );
+  }
+
+  /** The manual price in effect for the asset at a time, if any. */
+  manualPrice(asset: Asset, at: Date = new Date()): ManualPrice | undefined {
+    const when = at.toISOString();
+    return this.listManualPrices(asset.symbol)
+      .filter((p) => p.at <= when)
+      .pop();
+  }
+
+  /** Set a price from `at` on; one set at the same time is replaced. */
+  setManualPrice(req: ManualPriceRequest): ManualPrice {
+    const now = new Date().toISOString();
+    const price: ManualPrice = {
+      symbol: req.symbol.toUpperCase(),
+      priceUSD: req.price_usd,
+      at: req.at ? new Date(req.at).toISOString() : now,
+      note: req.note,
+      updatedAt: now,
+    };
+    const rest = settingsRepository
+      .getManualPrices()
+      .filter((p) => p.symbol !== price.symbol || p.at !== price.at);
+    settingsRepository.setManualPrices([...rest, price]);
+    return price;
+  }
+
+  /**
+   * Remove a symbol's manual prices, or only the one set at `at`. Returns
+   * how many were removed.
+   */
+  removeManualPrice(symbol: string, at?: string): number {
+    const sym = symbol.toUpperCase();
+    const when = at ? new Date(at).toISOString() : undefined;
+    const all = settingsRepository.getManualPrices();
+    const kept = all.filter(
+      (p) => p.symbol !== sym || (when !== undefined && p.at !== when),
+    );
+    if (kept.length !== all.length) settingsRepository.setManualPrices(kept);
+    return all.length - kept.length;
+  }
+}
+
+export const assetPriceService = new AssetPriceService();
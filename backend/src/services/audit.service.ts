@@ -0,0 +1,60 @@
+import {
+  AUDIT_ENTITIES,
+  AuditEntity,
+  AuditEntry,
+  AuditFilter,
+  Page,
+} from "../types";
+import { auditRepository } from "../repositories";
+import { ValidationError } from "../core/errors";
+
+export const DEFAULT_AUDIT_LIMIT = 100;
+export const MAX_AUDIT_LIMIT = 1000;
+
+/**
+ * Read side of the audit log. Writes are recorded by the audited
+ * repositories themselves (see withAudit in repositories/audit.repository),
+ * so every service, import and action that changes a record is covered.
+ */
+export class AuditService {
+  list(
+    filter: AuditFilter,
+    page: { limit?: number; cursor?: string } = {},
+  ): Page<AuditEntry> {
+    if (
+      filter.entity &&
+      !AUDIT_ENTITIES.includes(filter.entity as AuditEntity)
+    ) {
+      throw new ValidationError(`unknown entity: ${filter.entity}`, {
+        allowed: AUDIT_ENTITIES,
+      });
+    }
+    for (const [key, value] of [
+      ["start", filter.start],
+      ["end", filter.end],
+    ]) {
+      if (value && Number.isNaN(Date.parse(value))) {
+        throw new ValidationError(`${key} must be an ISO date`);
+      }
+    }
+    const limit = page.limit ?? DEFAULT_AUDIT_LIMIT;
+    if (!Number.isInteger(limit) || limit < 1 || limit > MAX_AUDIT_LIMIT) {
+      throw new ValidationError(
+        `limit must be between 1 and ${MAX_AUDIT_LIMIT}`,
+      );
+    }
+    return auditRepository.findPage(
+      {
+        ...filter,
+        // A bare date as `end` covers that whole day
+        end:
+          filter.end && filter.end.length === 10
+            ? `${filter.end}T23:59:59.999Z`
+            : filter.end,
+      },
+      { limit, cursor: page.cursor },
+    );
+  }
+}
+
+export const auditService = new AuditService();
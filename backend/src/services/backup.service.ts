@@ -0,0 +1,398 @@
+import { createHash } from "crypto";
+import { Transaction } from "../types";
+import {
+  loanRepository,
+  projectRepository,
+  settingsRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import { vaultService } from "./vault.service";
+import { transactionService } from "./transaction.service";
+import { consistencyService } from "./consistency.service";
+import { accountBalanceService } from "./account-balance.service";
+import { ValidationError } from "../core/errors";
+import { withoutRules } from "../utils/transaction-rules.util";
+import { withoutFxMargins } from "../utils/fx.util";
+
+// Format of /admin/export files. 1 had no checksums; 2 added them; 3 added
+// projects, so project links restore with the transactions.
+export const BACKUP_VERSION = 3;
+export const BACKUP_SECTIONS = [
+  "transactions",
+  "vaults",
+  "loans",
+  "projects",
+  "types",
+  "accounts",
+  "assets",
+  "tags",
+  "pending_actions",
+  "settings",
+] as const;
+export type BackupSection = (typeof BACKUP_SECTIONS)[number];
+
+// How a restore treats transactions whose id already exists here
+export type RestoreMode = "skip-existing" | "overwrite" | "merge-newer";
+export const RESTORE_MODES: RestoreMode[] = [
+  "skip-existing",
+  "overwrite",
+  "merge-newer",
+];
+
+export interface RestoreConflict {
+  id: string;
+  existingHash: string;
+  incomingHash: string;
+  existingUpdatedAt: string;
+  incomingUpdatedAt: string;
+  existingDeleted: boolean; // in the trash; overwriting restores it
+  resolution: "skip" | "overwrite";
+}
+
+export interface RestorePlan {
+  mode: RestoreMode;
+  insert: string[]; // ids new to this database
+  identical: string[]; // same id, same content
+  // Same content already stored under another id (e.g. re-created by hand)
+  duplicates: Array<{ id: string; existingId: string; hash: string }>;
+  conflicts: RestoreConflict[]; // same id, different content
+}
+
+export interface RestoreResult {
+  plan: RestorePlan;
+  inserted: number;
+  overwritten: number;
+  skipped: number;
+  failed: Array<{ id: string; error: string }>;
+  restored: string[]; // ids inserted or overwritten
+}
+
+// A restored transaction pointing at something the restore did not bring
+export interface OrphanedLink {
+  transactionId: string;
+  link: "loan" | "project" | "transfer";
+  ref: string; // the missing loan or project id, or the transfer id
+}
+
+export interface RelinkResult {
+  vaultsCreated: string[];
+  vaultEntriesAdded: number;
+  accountBalances: number; // rows after the rebuild
+  orphans: OrphanedLink[];
+}
+
+function canonical(value: any): any {
+  if (Array.isArray(value)) return value.map(canonical);
+  if (!value || typeof value !== "object") return value;
+  const out: Record<string, unknown> = {};
+  for (const key of Object.keys(value).sort()) {
+    if (value[key] != null) out[key] = canonical(value[key]);
+  }
+  return out;
+}
+
+/**
+ * Content hash of a transaction, ignoring its id and edit time, so the
+ * same transaction hashes equal across databases (unset and null fields
+ * count as absent).
+ */
+export function transactionHash(tx: Transaction): string {
+  const { id: _id, updatedAt: _updatedAt, ...content } = tx;
+  return createHash("sha1")
+    .update(JSON.stringify(canonical(content)))
+    .digest("hex")
+    .slice(0, 16);
+}
+
+// Restored as they were backed up, without the current rules, and with
+// the FX margin already in their amounts
+const asBackedUp = <T>(fn: () => T): T =>
+  withoutRules(() => withoutFxMargins(fn));
+
+// Deleting a transaction is its last edit
+const editedAt = (tx: Transaction) =>
+  tx.deletedAt ?? tx.updatedAt ?? tx.createdAt;
+
+/** SHA-256 of a backup section, independent of key order. */
+export function sectionChecksum(value: unknown): string {
+  return createHash("sha256")
+    .update(JSON.stringify(canonical(value ?? null)))
+    .digest("hex");
+}
+
+export interface OpenedBackup {
+  data: any;
+  version: number; // version the file was written in
+  verified: boolean; // checksums were present and matched
+  warnings: string[];
+}
+
+// Upgrade steps from version N to N + 1, applied in order on restore
+const MIGRATIONS: Record<number, (data: any, warnings: string[]) => void> = {
+  // Same layout; version 1 files just weren't sealed
+  1: (_data, warnings) => {
+    warnings.push("Backup version 1 has no checksums; contents not verified");
+  },
+  // No projects section; links to missing projects are reported on restore
+  2: () => {},
+};
+
+export class BackupService {
+  /** Stamp an export with the format version and per-section checksums. */
+  seal(sections: Record<BackupSection, unknown>) {
+    const checksums: Record<string, string> = {};
+    for (const name of BACKUP_SECTIONS) {
+      checksums[name] = sectionChecksum(sections[name]);
+    }
+    return {
+      version: BACKUP_VERSION,
+      exported_at: new Date().toISOString(),
+      ...sections,
+      checksums,
+    };
+  }
+
+  /**
+   * Check a backup before restoring it: reject versions this server
+   * doesn't know, migrate older ones, and compare every section against
+   * its checksum. `verify: false` skips the checksums, e.g. for a backup
+   * edited by hand.
+   */
+  open(data: any, opts: { verify?: boolean } = {}): OpenedBackup {
+    const version = data.version === undefined ? 1 : Number(data.version);
+    if (!Number.isInteger(version) || version < 1) {
+      throw new ValidationError(`Invalid backup version "${data.version}"`);
+    }
+    if (version > BACKUP_VERSION) {
+      throw new ValidationError(
+        `Backup version ${version} is newer than this server supports ` +
+          `(${BACKUP_VERSION}); upgrade the server first`,
+        { version, supported: BACKUP_VERSION },
+      );
+    }
+
+    const warnings: string[] = [];
+    for (let v = version; v < BACKUP_VERSION; v++) {
+      MIGRATIONS[v](data, warnings);
+    }
+
+    let verified = false;
+    if (version >= 2 && opts.verify !== false) {
+      const checksums = data.checksums;
+      if (!checksums || typeof checksums !== "object") {
+        throw new ValidationError(
+          "Backup has no checksums; pass verify=false to restore anyway",
+        );
+      }
+      const mismatched = BACKUP_SECTIONS.filter(
+        (name) =>
+          checksums[name] !== undefined &&
+          checksums[name] !== sectionChecksum(data[name]),
+      );
+      if (mismatched.length) {
+        throw new ValidationError(
+          `Checksum mismatch in ${mismatched.join(", ")}; the backup is ` +
+            "damaged or was edited (pass verify=false to restore anyway)",
+          { sections: mismatched },
+        );
+      }
+      verified = true;
+    } else if (version >= 2) {
+      warnings.push("Checksums not verified (verify=false)");
+    }
+
+    return { data, version, verified, warnings };
+  }
+
+  /**
+   * Compare backup transactions with the current ones without writing
+   * anything. Conflicts carry the resolution `mode` would apply.
+   */
+  planTransactions(incoming: Transaction[], mode: RestoreMode): RestorePlan {
+    const existing = transactionRepository.findAll();
+    // Transactions in the trash still hold their ids, so a backup copy of
+    // one is a conflict with the deletion rather than a new transaction
+    const byId = new Map(
+      [...transactionRepository.findDeleted(), ...existing].map((t) => [
+        t.id,
+        t,
+      ]),
+    );
+    const byHash = new Map(existing.map((t) => [transactionHash(t), t.id]));
+
+    const plan: RestorePlan = {
+      mode,
+      insert: [],
+      identical: [],
+      duplicates: [],
+      conflicts: [],
+    };
+    for (const tx of incoming) {
+      const hash = transactionHash(tx);
+      const current = byId.get(tx.id);
+      if (!current) {
+        const existingId = byHash.get(hash);
+        if (existingId) plan.duplicates.push({ id: tx.id, existingId, hash });
+        else plan.insert.push(tx.id);
+        continue;
+      }
+
+      const currentHash = transactionHash(current);
+      if (currentHash === hash) {
+        plan.identical.push(tx.id);
+        continue;
+      }
+      const newer = editedAt(tx) > editedAt(current);
+      plan.conflicts.push({
+        id: tx.id,
+        existingHash: currentHash,
+        incomingHash: hash,
+        existingUpdatedAt: editedAt(current),
+        incomingUpdatedAt: editedAt(tx),
+        existingDeleted: !!current.deletedAt,
+        resolution:
+          mode === "overwrite" || (mode === "merge-newer" && newer)
+            ? "overwrite"
+            : "skip",
+      });
+    }
+    return plan;
+  }
+
+  /** Apply a plan: insert new transactions and resolve conflicts. */
+  restoreTransactions(
+    incoming: Transaction[],
+    mode: RestoreMode,
+  ): RestoreResult {
+    const plan = this.planTransactions(incoming, mode);
+    const byId = new Map(incoming.map((t) => [t.id, t]));
+    const result: RestoreResult = {
+      plan,
+      inserted: 0,
+      overwritten: 0,
+      skipped: plan.identical.length + plan.duplicates.length,
+      failed: [],
+      restored: [],
+    };
+
+    for (const id of plan.insert) {
+      try {
+        asBackedUp(() => transactionRepository.create(byId.get(id)!));
+        result.inserted++;
+        result.restored.push(id);
+      } catch (e: any) {
+        result.failed.push({ id, error: e?.message || String(e) });
+      }
+    }
+    for (const c of plan.conflicts) {
+      if (c.resolution === "skip") {
+        result.skipped++;
+        continue;
+      }
+      try {
+        const tx = byId.get(c.id)!;
+        if (c.existingDeleted && !tx.deletedAt) {
+          transactionRepository.restore(c.id);
+        }
+        if (!asBackedUp(() => transactionRepository.update(c.id, tx))) {
+          throw new Error(`Transaction ${c.id} could not be updated`);
+        }
+        result.overwritten++;
+        result.restored.push(c.id);
+      } catch (e: any) {
+        result.failed.push({ id: c.id, error: e?.message || String(e) });
+      }
+    }
+    return result;
+  }
+
+  /**
+   * Rebuild what restored transactions imply but a backup may lack, e.g.
+   * one holding transactions alone. Vaults the transactions name are
+   * created, and vaults whose entries were not in the backup get entries
+   * for the difference from their transactions, as the consistency fix
+   * does. Links to loans, projects or transfer legs that are still
+   * missing are reported, not guessed. Balances are rebuilt last.
+   */
+  async relink(
+    restoredIds: string[],
+    data: any,
+    now = new Date(),
+  ): Promise<RelinkResult> {
+    const ids = new Set(restoredIds);
+    const restored = transactionRepository
+      .findAll()
+      .filter((t) => ids.has(t.id));
+    const lower = (s: string) => s.toLowerCase();
+
+    // Vaults known here, in the backup, or named as the default vaults
+    const vaults = new Map<string, string>();
+    const addVault = (name: unknown) => {
+      if (typeof name === "string" && name.trim()) {
+        vaults.set(lower(name.trim()), name.trim());
+      }
+    };
+    for (const v of vaultRepository.findAll()) addVault(v.name);
+    const backupVaults = Array.isArray(data?.vaults) ? data.vaults : [];
+    for (const v of backupVaults) addVault(v?.name);
+    addVault(data?.settings?.default_spending_vault);
+    addVault(data?.settings?.default_income_vault);
+    addVault(settingsRepository.getDefaultSpendingVaultName());
+    addVault(settingsRepository.getDefaultIncomeVaultName());
+
+    const withEntries = new Set<string>(
+      backupVaults
+        .filter((v: any) => Array.isArray(v?.entries) && v.entries.length)
+        .map((v: any) => lower(String(v.name))),
+    );
+    const touched = new Set<string>();
+    for (const t of restored) {
+      const key = t.account && lower(t.account);
+      if (key && vaults.has(key)) touched.add(vaults.get(key)!);
+    }
+
+    const vaultsCreated: string[] = [];
+    for (const name of touched) {
+      if (vaultService.ensureVault(name)) vaultsCreated.push(name);
+    }
+    const rebuild = [...touched].filter((n) => !withEntries.has(lower(n)));
+    const fixed = rebuild.length
+      ? await consistencyService.fix(["vault_mismatch"], now, rebuild)
+      : {};
+
+    const orphans: OrphanedLink[] = [];
+    for (const t of restored) {
+      if (t.loanId && !loanRepository.findById(t.loanId)) {
+        orphans.push({ transactionId: t.id, link: "loan", ref: t.loanId });
+      }
+      if (t.projectId && !projectRepository.findById(t.projectId)) {
+        orphans.push({
+          transactionId: t.id,
+          link: "project",
+          ref: t.projectId,
+        });
+      }
+    }
+    for (const issue of transactionService.checkTransferPairs().issues) {
+      const leg = issue.out || issue.in;
+      if (issue.kind === "AMOUNT_MISMATCH" || !leg || !ids.has(leg.id)) {
+        continue;
+      }
+      orphans.push({
+        transactionId: leg.id,
+        link: "transfer",
+        ref: issue.transferId,
+      });
+    }
+
+    return {
+      vaultsCreated,
+      vaultEntriesAdded: fixed.vault_mismatch ?? 0,
+      accountBalances: accountBalanceService.rebuild(),
+      orphans,
+    };
+  }
+}
+
+export const backupService = new BackupService();
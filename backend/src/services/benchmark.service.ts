@@ -0,0 +1,117 @@
+import { Vault } from "../types";
+import { vaultRepository } from "../repositories";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { priceService } from "./price.service";
+import { VaultStats, vaultService } from "./vault.service";
+
+/**
+ * A vault measured against a benchmark. The benchmark figures replay the
+ * vault's own deposits and withdrawals in the benchmark asset, so both
+ * sides had the same money for the same time. Figures that need a price
+ * are null while any is missing (see `missingDates`).
+ */
+export interface VaultBenchmark {
+  symbol: string;
+  inceptionDate?: string; // first deposit
+  startPriceUSD: number | null;
+  priceUSD: number | null;
+  priceReturnPercent: number | null; // benchmark price change since then
+  depositedUSD: number;
+  withdrawnUSD: number;
+  valueUSD: number | null; // benchmark units bought by the flows, now
+  pnlUSD: number | null;
+  roiPercent: number | null;
+  vaultPnlUSD: number;
+  vaultRoiPercent: number;
+  excessPnlUSD: number | null; // vault PnL minus benchmark PnL
+  alphaPercent: number | null; // vault ROI minus benchmark ROI
+  missingDates: string[];
+}
+
+const EPSILON = 1e-12;
+
+export class BenchmarkService {
+  /**
+   * Compare a vault with its benchmark since inception; undefined when it
+   * has none. ROI on both sides is PnL over deposits, as on `/vaults`.
+   * Pass `stats` when the caller already has them.
+   */
+  async compare(
+    vault: Vault,
+    stats?: VaultStats,
+  ): Promise<VaultBenchmark | undefined> {
+    const symbol = vault.benchmark?.trim().toUpperCase();
+    if (!symbol) return undefined;
+    const asset = createAssetFromSymbol(symbol);
+
+    const s = stats ?? (await vaultService.vaultStats(vault.name));
+    const vaultPnlUSD = s.aumUSD + s.totalWithdrawnUSD - s.totalDepositedUSD;
+    const roi = (pnl: number) =>
+      s.totalDepositedUSD > 0 ? (pnl / s.totalDepositedUSD) * 100 : 0;
+
+    // One lookup per day; the price history serves past days
+    const prices = new Map<string, number | null>();
+    const missingDates: string[] = [];
+    const priceOn = async (at?: string): Promise<number | null> => {
+      const day = (at ?? new Date().toISOString()).slice(0, 10);
+      if (!prices.has(day)) {
+        const rate = await priceService.getRateUSD(asset, at);
+        const ok = !rate.missing && rate.rateUSD > 0;
+        prices.set(day, ok ? rate.rateUSD : null);
+        if (!ok) missingDates.push(day);
+      }
+      return prices.get(day) ?? null;
+    };
+
+    let units = 0;
+    let inceptionDate: string | undefined;
+    for (const e of vaultRepository.findAllEntries(vault.name)) {
+      const usd = Number(e.usdValue || 0);
+      if (e.type === "VALUATION" || !(usd > 0)) continue;
+      if (e.type === "DEPOSIT" && !inceptionDate) inceptionDate = e.at;
+      if (!inceptionDate) continue;
+      const price = await priceOn(e.at);
+      if (price === null) continue;
+      // Selling more than the replay holds leaves it empty, not short
+      units =
+        e.type === "DEPOSIT"
+          ? units + usd / price
+          : Math.max(0, units - usd / price);
+    }
+
+    const startPriceUSD = inceptionDate ? await priceOn(inceptionDate) : null;
+    const priceUSD = await priceOn();
+    const complete = missingDates.length === 0 && priceUSD !== null;
+
+    const valueUSD = complete ? (units < EPSILON ? 0 : units * priceUSD) : null;
+    const pnlUSD =
+      valueUSD === null
+        ? null
+        : valueUSD + s.totalWithdrawnUSD - s.totalDepositedUSD;
+    const roiPercent = pnlUSD === null ? null : roi(pnlUSD);
+
+    return {
+      symbol,
+      inceptionDate,
+      startPriceUSD,
+      priceUSD,
+      priceReturnPercent:
+        startPriceUSD && priceUSD !== null
+          ? (priceUSD / startPriceUSD - 1) * 100
+          : null,
+      depositedUSD: s.totalDepositedUSD,
+      withdrawnUSD: s.totalWithdrawnUSD,
+      valueUSD,
+      pnlUSD,
+      roiPercent,
+      vaultPnlUSD,
+      vaultRoiPercent: roi(vaultPnlUSD),
+      excessPnlUSD: pnlUSD === null ? null : vaultPnlUSD - pnlUSD,
+      alphaPercent:
+        roiPercent === null ? null : roi(vaultPnlUSD) - roiPercent,
+      missingDates,
+    };
+  }
+}
+
+export const benchmarkService = new BenchmarkService();
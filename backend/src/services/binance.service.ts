@@ -0,0 +1,259 @@
+import axios from "axios";
+import crypto from "crypto";
+import { ExchangeActivity, ExchangeConnector } from "../types";
+import { ValidationError } from "../core/errors";
+import {
+  ConnectorClient,
+  ConnectorStream,
+  ExchangeCredentials,
+} from "./connector.service";
+
+const API = "https://api.binance.com";
+const REQUEST_TIMEOUT_MS = 30000;
+const RECV_WINDOW_MS = 10000;
+const DAY_MS = 24 * 60 * 60 * 1000;
+const WINDOW_MS = 90 * DAY_MS; // widest range the history endpoints take
+const TRADE_PAGE = 1000;
+const REWARD_PAGE = 500;
+
+// Deposit statuses that mean the funds arrived; 0 is still pending
+const DEPOSIT_DONE = [1, 6];
+const WITHDRAWAL_DONE = 6;
+const WITHDRAWAL_FAILED = [1, 3, 5]; // cancelled, rejected, failed
+
+// Permissions a key used for syncing must not have
+const WRITE_PERMISSIONS = [
+  "enableWithdrawals",
+  "enableInternalTransfer",
+  "permitsUniversalTransfer",
+  "enableSpotAndMarginTrading",
+  "enableMargin",
+  "enableFutures",
+  "enableVanillaOptions",
+];
+
+/** Query string of a signed request: HMAC-SHA256 of the query, in hex. */
+export function signQuery(
+  params: Record<string, string | number>,
+  secret: string,
+): string {
+  const query = new URLSearchParams(
+    Object.entries(params).map(([k, v]) => [k, String(v)]),
+  ).toString();
+  const signature = crypto
+    .createHmac("sha256", secret)
+    .update(query)
+    .digest("hex");
+  return `${query}&signature=${signature}`;
+}
+
+// Withdrawal times are "2025-03-01 08:00:00" in UTC
+function utcTime(v: string | number): number {
+  return typeof v === "number" ? v : Date.parse(`${v.replace(" ", "T")}Z`);
+}
+
+const iso = (ms: number) => new Date(ms).toISOString();
+
+export function tradeActivity(t: any, pair: string): ExchangeActivity {
+  const [base, quote] = pair.split("/");
+  return {
+    kind: "TRADE",
+    ref: `trade:${t.symbol}:${t.id}`,
+    at: iso(t.time),
+    base,
+    quote,
+    side: t.isBuyer ? "BUY" : "SELL",
+    quantity: Number(t.qty),
+    quoteQuantity: Number(t.quoteQty),
+    fee: Number(t.commission) || 0,
+    feeAsset: t.commissionAsset || undefined,
+  };
+}
+
+export function depositActivity(d: any): ExchangeActivity {
+  return {
+    kind: "DEPOSIT",
+    ref: `deposit:${d.id ?? d.txId}`,
+    at: iso(d.insertTime),
+    asset: d.coin,
+    amount: Number(d.amount),
+    fee: 0,
+    network: d.network || undefined,
+    txId: d.txId || undefined,
+  };
+}
+
+export function withdrawalActivity(w: any): ExchangeActivity {
+  return {
+    kind: "WITHDRAWAL",
+    ref: `withdrawal:${w.id}`,
+    at: iso(utcTime(w.applyTime)),
+    asset: w.coin,
+    amount: Number(w.amount),
+    fee: Number(w.transactionFee) || 0,
+    network: w.network || undefined,
+    txId: w.txId || undefined,
+  };
+}
+
+// Staking, Simple Earn and other distributions ("asset dividends")
+export function rewardActivity(r: any): ExchangeActivity {
+  return {
+    kind: "REWARD",
+    ref: `reward:${r.id ?? r.tranId}`,
+    at: iso(r.divTime),
+    asset: r.asset,
+    amount: Number(r.amount),
+    note: r.enInfo || undefined,
+  };
+}
+
+/**
+ * Binance spot trades, deposits, withdrawals and rewards. Trades resume
+ * after the last trade id of each pair; the rest resume after the last
+ * time scanned, held back to the oldest deposit or withdrawal still in
+ * progress so it is picked up once it completes.
+ */
+export class BinanceClient implements ConnectorClient {
+  async checkReadOnly(creds: ExchangeCredentials): Promise<void> {
+    const rights = await this.get(creds, "/sapi/v1/account/apiRestrictions");
+    const granted = WRITE_PERMISSIONS.filter((p) => rights?.[p] === true);
+    if (granted.length) {
+      throw new ValidationError(
+        `The API key must be read-only; it has ${granted.join(", ")}`,
+      );
+    }
+  }
+
+  streams(c: ExchangeConnector): ConnectorStream[] {
+    const since = Date.parse(c.since);
+    return [
+      ...c.pairs.map((pair) => ({
+        key: `trades:${pair}`,
+        fetch: (creds: ExchangeCredentials, cursor?: number) =>
+          this.trades(creds, pair, since, cursor),
+      })),
+      {
+        key: "deposits",
+        fetch: (creds, cursor, now) =>
+          this.history(creds, cursor ?? since, now, async (range) => {
+            const rows = await this.get(
+              creds,
+              "/sapi/v1/capital/deposit/hisrec",
+              range,
+            );
+            return (rows || []).map((d: any) => ({
+              time: d.insertTime,
+              done: DEPOSIT_DONE.includes(d.status),
+              activity: depositActivity(d),
+            }));
+          }),
+      },
+      {
+        key: "withdrawals",
+        fetch: (creds, cursor, now) =>
+          this.history(creds, cursor ?? since, now, async (range) => {
+            const rows = await this.get(
+              creds,
+              "/sapi/v1/capital/withdraw/history",
+              range,
+            );
+            return (rows || [])
+              .filter((w: any) => !WITHDRAWAL_FAILED.includes(w.status))
+              .map((w: any) => ({
+                time: utcTime(w.applyTime),
+                done: w.status === WITHDRAWAL_DONE,
+                activity: withdrawalActivity(w),
+              }));
+          }),
+      },
+      {
+        key: "rewards",
+        fetch: (creds, cursor, now) =>
+          this.history(creds, cursor ?? since, now, async (range) => {
+            const res = await this.get(creds, "/sapi/v1/asset/assetDividend", {
+              ...range,
+              limit: REWARD_PAGE,
+            });
+            return (res?.rows || []).map((r: any) => ({
+              time: r.divTime,
+              done: true,
+              activity: rewardActivity(r),
+            }));
+          }),
+      },
+    ];
+  }
+
+  // Trades of one pair after trade id `cursor`, a page at a time
+  private async trades(
+    creds: ExchangeCredentials,
+    pair: string,
+    since: number,
+    cursor?: number,
+  ): Promise<{ activities: ExchangeActivity[]; cursor?: number }> {
+    const symbol = pair.replace("/", "");
+    const activities: ExchangeActivity[] = [];
+    let fromId = cursor === undefined ? 0 : cursor + 1;
+    for (;;) {
+      const page: any[] = await this.get(creds, "/api/v3/myTrades", {
+        symbol,
+        fromId,
+        limit: TRADE_PAGE,
+      });
+      for (const t of page || []) {
+        if (t.time >= since) activities.push(tradeActivity(t, pair));
+        cursor = Math.max(cursor ?? -1, t.id);
+      }
+      if (!page || page.length < TRADE_PAGE) break;
+      fromId = cursor! + 1;
+    }
+    return { activities, cursor };
+  }
+
+  // Entries from `from` (ms) to now, 90 days per request
+  private async history(
+    creds: ExchangeCredentials,
+    from: number,
+    now: Date,
+    page: (range: {
+      startTime: number;
+      endTime: number;
+    }) => Promise<
+      Array<{ time: number; done: boolean; activity: ExchangeActivity }>
+    >,
+  ): Promise<{ activities: ExchangeActivity[]; cursor?: number }> {
+    const end = now.getTime();
+    const activities: ExchangeActivity[] = [];
+    let pending = Infinity;
+    for (let start = from; start <= end; start += WINDOW_MS) {
+      const rows = await page({
+        startTime: start,
+        endTime: Math.min(end, start + WINDOW_MS - 1),
+      });
+      for (const r of rows) {
+        if (r.done) activities.push(r.activity);
+        else pending = Math.min(pending, r.time);
+      }
+    }
+    return { activities, cursor: Math.min(end + 1, pending) };
+  }
+
+  private async get(
+    creds: ExchangeCredentials,
+    path: string,
+    params: Record<string, string | number> = {},
+  ): Promise<any> {
+    const query = signQuery(
+      { ...params, recvWindow: RECV_WINDOW_MS, timestamp: Date.now() },
+      creds.apiSecret,
+    );
+    const res = await axios.get(`${API}${path}?${query}`, {
+      headers: { "X-MBX-APIKEY": creds.apiKey },
+      timeout: REQUEST_TIMEOUT_MS,
+    });
+    return res.data;
+  }
+}
+
+export const binanceClient = new BinanceClient();
@@ -8,6 +8,7 @@ import {
 import { transactionService } from "./transaction.service";
 import { vaultService } from "./vault.service";
 import { priceService } from "./price.service";
+import { eventBus } from "./event-bus.service";
 
 const AUTO_DEDUCTION_INTERVAL_MS = 6 * 60 * 60 * 1000; // every 6 hours
 let schedulerStarted = false;
@@ -29,6 +30,15 @@ function addMonths(iso: string, months: number): string {
   return next.toISOString();
 }
 
+function emitBorrowingClosed(b: BorrowingAgreement): void {
+  eventBus.emit("borrowing.closed", {
+    id: b.id,
+    counterparty: b.counterparty,
+    asset: b.asset,
+    principal: b.principal,
+  });
+}
+
 export class BorrowingService {
   async createBorrowingAgreement(
     params: BorrowingCreateRequest,
@@ -197,6 +207,7 @@ export class BorrowingService {
           status: newOutstanding > 0 ? "ACTIVE" : "CLOSED",
         };
         borrowingRepository.update(borrowing.id, updates);
+        if (updates.status === "CLOSED") emitBorrowingClosed(borrowing);
         updatedBorrowingIds.push(borrowing.id);
 
         remainingAmount -= paymentAmount;
@@ -274,6 +285,7 @@ export class BorrowingService {
         status: outstanding > 0 ? "ACTIVE" : "CLOSED",
       };
       borrowingRepository.update(borrowing.id, updates);
+      if (updates.status === "CLOSED") emitBorrowingClosed(borrowing);
     }
   }
 
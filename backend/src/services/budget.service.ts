@@ -0,0 +1,210 @@
+import { v4 as uuidv4 } from "uuid";
+import { Budget, BudgetCreateRequest, BudgetUpdateRequest } from "../types";
+import { budgetRepository } from "../repositories";
+import { ConflictError, ValidationError } from "../core/errors";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export interface BudgetMonth {
+  month: string; // YYYY-MM
+  start: string; // first millisecond of the month (UTC)
+  end: string; // last millisecond of the month (UTC)
+  days: number;
+}
+
+export interface BudgetReportItem {
+  id: string;
+  tag: string;
+  currency: "USD" | "VND";
+  budget_usd: number;
+  budget_vnd: number;
+  actual_usd: number;
+  actual_vnd: number;
+  remaining_usd: number; // negative once over budget
+  remaining_vnd: number;
+  used_percentage: number;
+  daily_burn_usd: number; // actual spread over the days elapsed
+  daily_burn_vnd: number;
+  projected_usd: number; // month-end spend at the current burn rate
+  projected_vnd: number;
+  over_budget: boolean;
+  projected_over_budget: boolean;
+}
+
+export interface BudgetReport {
+  month: string;
+  days_in_month: number;
+  days_elapsed: number;
+  budgets: BudgetReportItem[];
+  totals: {
+    budget_usd: number;
+    budget_vnd: number;
+    actual_usd: number;
+    actual_vnd: number;
+    remaining_usd: number;
+    remaining_vnd: number;
+    unbudgeted_usd: number; // spending under tags without a budget
+    unbudgeted_vnd: number;
+  };
+}
+
+/** The calendar month `month` (YYYY-MM), or the month containing `now`. */
+export function budgetMonth(
+  month?: string,
+  now: Date = new Date(),
+): BudgetMonth {
+  let y = now.getUTCFullYear();
+  let m = now.getUTCMonth();
+  if (month) {
+    const match = /^(\d{4})-(\d{2})$/.exec(month);
+    if (!match || +match[2] < 1 || +match[2] > 12) {
+      throw new ValidationError("month must be YYYY-MM");
+    }
+    y = +match[1];
+    m = +match[2] - 1;
+  }
+  const start = Date.UTC(y, m, 1);
+  const next = Date.UTC(y, m + 1, 1);
+  return {
+    month: new Date(start).toISOString().slice(0, 7),
+    start: new Date(start).toISOString(),
+    end: new Date(next - 1).toISOString(),
+    days: Math.round((next - start) / DAY_MS),
+  };
+}
+
+/**
+ * Monthly spending limits per tag. Limits are set in USD or VND and are
+ * compared against the spending report's totals for the same tag.
+ */
+export class BudgetService {
+  list(): Budget[] {
+    return budgetRepository.findAll();
+  }
+
+  get(id: string): Budget | undefined {
+    return budgetRepository.findById(id);
+  }
+
+  create(req: BudgetCreateRequest): Budget {
+    const tag = req.tag.trim();
+    this.assertTagFree(tag);
+    return budgetRepository.create({
+      id: uuidv4(),
+      tag,
+      amount: req.amount,
+      currency: req.currency,
+      note: req.note,
+      active: req.active,
+      createdAt: new Date().toISOString(),
+    });
+  }
+
+  update(id: string, req: BudgetUpdateRequest): Budget | undefined {
+    const existing = budgetRepository.findById(id);
+    if (!existing) return undefined;
+
+    const updates: Partial<Budget> = {
+      ...req,
+      updatedAt: new Date().toISOString(),
+    };
+    if (req.tag !== undefined) {
+      updates.tag = req.tag.trim();
+      if (updates.tag !== existing.tag) this.assertTagFree(updates.tag);
+    }
+    return budgetRepository.update(id, updates);
+  }
+
+  delete(id: string): boolean {
+    return budgetRepository.delete(id);
+  }
+
+  /**
+   * Actual vs. budget for `month`, given that month's spending in USD by
+   * tag. The burn rate spreads spending over the days elapsed (all of
+   * them for a past month) and projects it to the month's end.
+   */
+  report(
+    month: BudgetMonth,
+    spentUSDByTag: Map<string, number>,
+    rateVND: number,
+    now: Date = new Date(),
+  ): BudgetReport {
+    const sinceStart = now.getTime() - Date.parse(month.start);
+    const elapsed = Math.min(
+      month.days,
+      Math.max(0, Math.ceil(sinceStart / DAY_MS)),
+    );
+    const vnd = (usd: number) => usd * rateVND;
+    const toUSD = (b: Budget) => {
+      if (b.currency === "USD") return b.amount;
+      return rateVND > 0 ? b.amount / rateVND : 0;
+    };
+
+    const budgets = budgetRepository
+      .findAll()
+      .filter((b) => b.active)
+      .map((b): BudgetReportItem => {
+        const budget = toUSD(b);
+        const actual = spentUSDByTag.get(b.tag) || 0;
+        const daily = elapsed > 0 ? actual / elapsed : 0;
+        const projected = daily * month.days;
+        return {
+          id: b.id,
+          tag: b.tag,
+          currency: b.currency,
+          budget_usd: budget,
+          budget_vnd: vnd(budget),
+          actual_usd: actual,
+          actual_vnd: vnd(actual),
+          remaining_usd: budget - actual,
+          remaining_vnd: vnd(budget - actual),
+          used_percentage: budget > 0 ? (actual / budget) * 100 : 0,
+          daily_burn_usd: daily,
+          daily_burn_vnd: vnd(daily),
+          projected_usd: projected,
+          projected_vnd: vnd(projected),
+          over_budget: actual > budget,
+          projected_over_budget: projected > budget,
+        };
+      });
+
+    const budgeted = new Set(budgets.map((b) => b.tag));
+    let unbudgeted = 0;
+    for (const [tag, usd] of spentUSDByTag) {
+      if (!budgeted.has(tag)) unbudgeted += usd;
+    }
+    const budgetUSD = budgets.reduce((s, b) => s + b.budget_usd, 0);
+    const actualUSD = budgets.reduce((s, b) => s + b.actual_usd, 0);
+
+    return {
+      month: month.month,
+      days_in_month: month.days,
+      days_elapsed: elapsed,
+      budgets,
+      totals: {
+        budget_usd: budgetUSD,
+        budget_vnd: vnd(budgetUSD),
+        actual_usd: actualUSD,
+        actual_vnd: vnd(actualUSD),
+        remaining_usd: budgetUSD - actualUSD,
+        remaining_vnd: vnd(budgetUSD - actualUSD),
+        unbudgeted_usd: unbudgeted,
+        unbudgeted_vnd: vnd(unbudgeted),
+      },
+    };
+  }
+
+  // One budget per tag, so the report has a single limit to compare with
+  private assertTagFree(tag: string): void {
+    if (!tag) throw new ValidationError("tag is required");
+    const existing = budgetRepository.findByTag(tag);
+    if (existing) {
+      throw new ConflictError(`A budget for "${tag}" already exists`, {
+        id: existing.id,
+      });
+    }
+  }
+}
+
+export const budgetService = new BudgetService();
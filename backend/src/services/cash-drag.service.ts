@@ -0,0 +1,149 @@
+import { Asset, assetKey } from "../types";
+import { vaultRepository } from "../repositories";
+import { priceService } from "./price.service";
+import {
+  depositRateService,
+  DEFAULT_DEPOSIT_TERM_MONTHS,
+} from "./deposit-rate.service";
+import { toISODate } from "./financial.service";
+import { getAssetKind } from "../utils/asset.util";
+
+const DAYS_PER_YEAR = 365;
+const EPS = 1e-12;
+
+export interface CashDragMonth {
+  month: string; // YYYY-MM
+  days: number;
+  avgCashUSD: number;
+  benchmarkRatePercent?: number; // day-weighted; undefined with no rate
+  opportunityCostUSD: number;
+}
+
+export interface CashDragReport {
+  start: string;
+  end: string;
+  termMonths: number;
+  avgCashUSD: number;
+  opportunityCostUSD: number;
+  uncoveredDays: number; // days holding cash with no deposit rate on file
+  months: CashDragMonth[];
+}
+
+function isCash(asset: Asset): boolean {
+  const kind = getAssetKind(asset.symbol);
+  return kind === "FIAT" || kind === "STABLECOIN";
+}
+
+function endOfDay(date: string): string {
+  return `${date}T23:59:59.999Z`;
+}
+
+function monthEnd(date: string): string {
+  const d = new Date(`${date}T00:00:00.000Z`);
+  return toISODate(
+    new Date(Date.UTC(d.getUTCFullYear(), d.getUTCMonth() + 1, 0)),
+  );
+}
+
+function nextDay(date: string): string {
+  const d = new Date(`${date}T00:00:00.000Z`);
+  d.setUTCDate(d.getUTCDate() + 1);
+  return toISODate(d);
+}
+
+export class CashDragService {
+  /**
+   * Interest forgone by holding fiat and stablecoins in vaults instead of
+   * a VND term deposit. Each day's cash balance earns the deposit rate in
+   * effect that day; balances are valued at each month's closing price.
+   */
+  async report(
+    start: string,
+    end: string,
+    termMonths = DEFAULT_DEPOSIT_TERM_MONTHS,
+  ): Promise<CashDragReport> {
+    const entries = vaultRepository
+      .findAllEntriesUntil(endOfDay(end))
+      .filter(
+        (e) =>
+          (e.type === "DEPOSIT" || e.type === "WITHDRAW") && isCash(e.asset),
+      )
+      .sort((a, b) => a.at.localeCompare(b.at));
+    const rates = depositRateService.list(termMonths);
+    const today = toISODate(new Date());
+
+    const units = new Map<string, { asset: Asset; quantity: number }>();
+    const months: CashDragMonth[] = [];
+    let next = 0;
+    let uncoveredDays = 0;
+    let day = start;
+
+    while (day <= end) {
+      const close = monthEnd(day) < end ? monthEnd(day) : end;
+      const prices = new Map<string, number>(); // closing price per asset
+      let days = 0;
+      let cashSum = 0;
+      let cost = 0;
+      let rateSum = 0;
+      let rateDays = 0;
+
+      for (; day <= close; day = nextDay(day)) {
+        while (next < entries.length && entries[next].at <= endOfDay(day)) {
+          const e = entries[next++];
+          const k = assetKey(e.asset);
+          const u = units.get(k) || { asset: e.asset, quantity: 0 };
+          u.quantity += e.type === "DEPOSIT" ? e.amount : -e.amount;
+          units.set(k, u);
+        }
+
+        let cashUSD = 0;
+        for (const [k, u] of units) {
+          if (Math.abs(u.quantity) <= EPS) continue;
+          if (!prices.has(k)) {
+            const rate = await priceService.getRateUSD(
+              u.asset,
+              close >= today ? undefined : endOfDay(close),
+            );
+            prices.set(k, rate.rateUSD);
+          }
+          cashUSD += u.quantity * prices.get(k)!;
+        }
+        cashUSD = Math.max(0, cashUSD);
+
+        const ratePercent = depositRateService.rateAt(day, termMonths, rates);
+        if (ratePercent === undefined) {
+          if (cashUSD > 0) uncoveredDays++;
+        } else {
+          cost += (cashUSD * ratePercent) / 100 / DAYS_PER_YEAR;
+          rateSum += ratePercent;
+          rateDays++;
+        }
+        cashSum += cashUSD;
+        days++;
+      }
+
+      months.push({
+        month: close.slice(0, 7),
+        days,
+        avgCashUSD: cashSum / days,
+        benchmarkRatePercent: rateDays ? rateSum / rateDays : undefined,
+        opportunityCostUSD: cost,
+      });
+    }
+
+    const totalDays = months.reduce((s, m) => s + m.days, 0);
+    return {
+      start,
+      end,
+      termMonths,
+      avgCashUSD: totalDays
+        ? months.reduce((s, m) => s + m.avgCashUSD * m.days, 0) / totalDays
+        : 0,
+      opportunityCostUSD: months.reduce((s, m) => s + m.opportunityCostUSD, 0),
+      uncoveredDays,
+      months,
+    };
+  }
+}
+
+export const cashDragService = new CashDragService();
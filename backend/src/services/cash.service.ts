@@ -0,0 +1,170 @@
+import { v4 as uuidv4 } from "uuid";
+import { Asset, Transaction, assetKey, unitsIn } from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { priceService } from "./price.service";
+import { precisionService } from "./precision.service";
+import { ValidationError } from "../core/errors";
+
+// Wallet physical cash is tracked in unless another account is named
+export const DEFAULT_CASH_ACCOUNT = "Cash";
+
+export interface CashWithdrawalParams {
+  fromAccount: string; // bank account the ATM debits
+  toAccount?: string; // default DEFAULT_CASH_ACCOUNT
+  asset: Asset;
+  amount: number; // cash received
+  fee?: number; // ATM fee charged by the bank, in the same asset
+  at?: string;
+  note?: string;
+}
+
+export interface CashCountParams {
+  account?: string; // default DEFAULT_CASH_ACCOUNT
+  asset: Asset;
+  counted: number; // cash actually in the wallet
+  at?: string;
+  note?: string;
+}
+
+export interface CashCountResult {
+  account: string;
+  asset: Asset;
+  recorded: number; // balance the ledger expected at the count
+  counted: number;
+  difference: number; // counted - recorded
+  transaction?: Transaction; // the adjustment, when the two differ
+}
+
+/**
+ * Physical cash: ATM withdrawals move money from a bank account into a
+ * cash wallet, cash expenses are recorded against that wallet as usual,
+ * and a periodic count books whatever went unrecorded under the cash
+ * leakage tag so the wallet matches what is really in it.
+ */
+export class CashService {
+  async withdraw(params: CashWithdrawalParams): Promise<Transaction[]> {
+    const from = params.fromAccount.trim();
+    const to = (params.toAccount || DEFAULT_CASH_ACCOUNT).trim();
+    const fee = params.fee ?? 0;
+    if (!from) throw new ValidationError("from_account is required");
+    if (from.toLowerCase() === to.toLowerCase()) {
+      throw new ValidationError("from_account and to_account are the same");
+    }
+    if (!(params.amount > 0)) {
+      throw new ValidationError("amount must be positive");
+    }
+    if (!(fee >= 0)) throw new ValidationError("fee must not be negative");
+    precisionService.validate(params.asset, params.amount);
+    precisionService.validate(params.asset, fee, "fee");
+
+    const at = params.at ?? new Date().toISOString();
+    const rate = await priceService.getRateUSD(params.asset, params.at);
+    const transferId = uuidv4();
+    const leg = (
+      type: "TRANSFER_OUT" | "TRANSFER_IN",
+      account: string,
+      note: string,
+    ) =>
+      ({
+        id: uuidv4(),
+        type,
+        asset: params.asset,
+        amount: params.amount,
+        createdAt: at,
+        account,
+        note: params.note ? `${note}: ${params.note}` : note,
+        category: "atm_withdrawal",
+        transferId,
+        rate,
+        usdAmount: params.amount * rate.rateUSD,
+      }) as Transaction;
+
+    const txs = [
+      leg("TRANSFER_OUT", from, `ATM withdrawal to ${to}`),
+      leg("TRANSFER_IN", to, `ATM withdrawal from ${from}`),
+    ];
+    if (fee > 0) {
+      txs.push({
+        id: uuidv4(),
+        type: "EXPENSE",
+        asset: params.asset,
+        amount: fee,
+        createdAt: at,
+        account: from,
+        note: "ATM fee",
+        category: "atm_fee",
+        transferId,
+        rate,
+        usdAmount: fee * rate.rateUSD,
+      } as Transaction);
+    }
+    return transactionRepository.createMany(txs);
+  }
+
+  /** Units of `asset` the ledger holds in `account` as of `at`. */
+  balance(account: string, asset: Asset, at?: string): number {
+    const key = assetKey(asset);
+    const name = account.trim().toLowerCase();
+    const total = transactionRepository
+      .findAll()
+      .filter(
+        (t) =>
+          (t.account || "").toLowerCase() === name &&
+          assetKey(t.asset) === key &&
+          (!at || t.createdAt <= at),
+      )
+      .reduce((sum, t) => sum + unitsIn(t), 0);
+    return precisionService.round(asset, total);
+  }
+
+  /**
+   * Reconcile the wallet with a count of the cash in it. A shortfall is
+   * booked as an expense, a surplus as income, both under the cash
+   * leakage tag; a matching count records nothing.
+   */
+  async count(params: CashCountParams): Promise<CashCountResult> {
+    const account = (params.account || DEFAULT_CASH_ACCOUNT).trim();
+    if (!(params.counted >= 0)) {
+      throw new ValidationError("counted must not be negative");
+    }
+    precisionService.validate(params.asset, params.counted, "counted");
+
+    const at = params.at ?? new Date().toISOString();
+    const recorded = this.balance(account, params.asset, at);
+    const difference = precisionService.round(
+      params.asset,
+      params.counted - recorded,
+    );
+    const result: CashCountResult = {
+      account,
+      asset: params.asset,
+      recorded,
+      counted: params.counted,
+      difference,
+    };
+    if (difference === 0) return result;
+
+    const amount = Math.abs(difference);
+    const rate = await priceService.getRateUSD(params.asset, params.at);
+    const note =
+      difference < 0
+        ? `Cash count: ${amount} ${params.asset.symbol} unaccounted for`
+        : `Cash count: ${amount} ${params.asset.symbol} more than recorded`;
+    const tx = {
+      id: uuidv4(),
+      type: difference < 0 ? "EXPENSE" : "INCOME",
+      asset: params.asset,
+      amount,
+      createdAt: at,
+      account,
+      note: params.note ? `${note}: ${params.note}` : note,
+      category: settingsRepository.getCashLeakageTag(),
+      rate,
+      usdAmount: amount * rate.rateUSD,
+    } as Transaction;
+    transactionRepository.create(tx);
+    return { ...result, transaction: tx };
+  }
+}
+
+export const cashService = new CashService();
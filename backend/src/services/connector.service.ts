@@ -0,0 +1,421 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  ConnectorCreateRequest,
+  ConnectorProvider,
+  ConnectorUpdateRequest,
+  ExchangeActivity,
+  ExchangeConnector,
+  Transaction,
+  TransactionType,
+} from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { config } from "../core/config";
+import {
+  BusinessError,
+  ConflictError,
+  NotFoundError,
+  ValidationError,
+} from "../core/errors";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { decryptSecret, encryptSecret } from "../utils/secret.util";
+import { logger } from "../utils/logger";
+import { transactionService } from "./transaction.service";
+import { binanceClient } from "./binance.service";
+
+const SCHEDULER_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const HOUR_MS = 60 * 60 * 1000;
+const DEFAULT_SINCE_DAYS = 90;
+let schedulerStarted = false;
+const syncing = new Set<string>(); // connector ids with a sync running
+
+export interface ExchangeCredentials {
+  apiKey: string;
+  apiSecret: string;
+}
+
+/**
+ * One kind of history (e.g. the trades of a pair). `cursor` is where the
+ * last sync stopped; the stream returns where the next one should start.
+ */
+export interface ConnectorStream {
+  key: string;
+  fetch(
+    creds: ExchangeCredentials,
+    cursor: number | undefined,
+    now: Date,
+  ): Promise<{ activities: ExchangeActivity[]; cursor?: number }>;
+}
+
+export interface ConnectorClient {
+  checkReadOnly(creds: ExchangeCredentials): Promise<void>; // throws if not
+  streams(connector: ExchangeConnector): ConnectorStream[];
+}
+
+const CLIENTS: Record<ConnectorProvider, ConnectorClient> = {
+  binance: binanceClient,
+};
+const PROVIDER_NAMES: Record<ConnectorProvider, string> = {
+  binance: "Binance",
+};
+
+export interface ConnectorSyncRun {
+  connector: ExchangeConnector;
+  status: "OK" | "FAILED";
+  error?: string;
+  created: Record<ExchangeActivity["kind"], number>; // transactions
+  skipped: number; // activity recorded by an earlier sync
+}
+
+// A transaction an activity turns into; `ref` is appended to its own
+interface Leg {
+  ref: string;
+  type: TransactionType;
+  asset: string;
+  amount: number;
+  note: string;
+  category?: string;
+  internalFlow?: boolean;
+  chain?: string;
+}
+
+/** The transactions an exchange activity is recorded as. */
+export function activityLegs(a: ExchangeActivity, exchange: string): Leg[] {
+  if (a.kind === "TRADE") {
+    const buy = a.side === "BUY";
+    const note = `${exchange}: ${buy ? "buy" : "sell"} ${a.quantity} ${
+      a.base
+    } for ${a.quoteQuantity} ${a.quote}`;
+    // The two sides of a trade swap holdings; neither is income or spending
+    const legs: Leg[] = [
+      {
+        ref: ":in",
+        type: "INCOME",
+        asset: buy ? a.base : a.quote,
+        amount: buy ? a.quantity : a.quoteQuantity,
+        note,
+        category: "trade",
+        internalFlow: true,
+      },
+      {
+        ref: ":out",
+        type: "EXPENSE",
+        asset: buy ? a.quote : a.base,
+        amount: buy ? a.quoteQuantity : a.quantity,
+        note,
+        category: "trade",
+        internalFlow: true,
+      },
+    ];
+    if (a.fee > 0 && a.feeAsset) {
+      legs.push({
+        ref: ":fee",
+        type: "EXPENSE",
+        asset: a.feeAsset,
+        amount: a.fee,
+        note: `${exchange}: trading fee`,
+        category: "trading_fee",
+      });
+    }
+    return legs;
+  }
+  if (a.kind === "REWARD") return []; // recorded as a reward instead
+
+  const chain = a.network?.toLowerCase();
+  const via = [a.network, a.txId].filter(Boolean).join(" ");
+  const legs: Leg[] = [
+    {
+      ref: "",
+      type: a.kind === "DEPOSIT" ? "TRANSFER_IN" : "TRANSFER_OUT",
+      asset: a.asset,
+      amount: a.amount,
+      note: `${exchange}: ${a.kind === "DEPOSIT" ? "deposit" : "withdrawal"}${
+        via ? ` (${via})` : ""
+      }`,
+      chain,
+    },
+  ];
+  if (a.fee > 0) {
+    legs.push({
+      ref: ":fee",
+      type: "EXPENSE",
+      asset: a.asset,
+      amount: a.fee,
+      note: `${exchange}: withdrawal fee`,
+      category: "network_fee",
+      chain,
+    });
+  }
+  return legs;
+}
+
+// Exchanges put the reason in the response body
+function describeError(e: any): string {
+  const data = e?.response?.data;
+  return data?.msg || data?.message || e?.message || String(e);
+}
+
+// Synced before, even if since deleted: a deleted one stays deleted
+function recorded(sourceRef: string): boolean {
+  return !!transactionRepository.findBySourceRef(sourceRef, {
+    includeDeleted: true,
+  });
+}
+
+/**
+ * Syncs exchange history into transactions through read-only API keys:
+ * spot trades, deposits, withdrawals and staking rewards. Every synced
+ * transaction has a `sourceRef` of the provider and the exchange's own
+ * id, so running a sync again never records anything twice. Keys are
+ * stored encrypted with SETTINGS_ENCRYPTION_KEY.
+ */
+export class ConnectorService {
+  list(): ExchangeConnector[] {
+    return settingsRepository.getExchangeConnectors();
+  }
+
+  get(id: string): ExchangeConnector | undefined {
+    return this.list().find((c) => c.id === id);
+  }
+
+  /** Rejects keys that can trade or withdraw. */
+  async create(
+    req: ConnectorCreateRequest,
+    now: Date = new Date(),
+  ): Promise<ExchangeConnector> {
+    const creds = { apiKey: req.apiKey, apiSecret: req.apiSecret };
+    const sealed = this.sealed(creds);
+    await CLIENTS[req.provider].checkReadOnly(creds);
+
+    const since = req.since
+      ? new Date(req.since)
+      : new Date(now.getTime() - DEFAULT_SINCE_DAYS * 24 * HOUR_MS);
+    const connector: ExchangeConnector = {
+      id: uuidv4(),
+      provider: req.provider,
+      name: req.name,
+      account: req.account ?? req.name,
+      ...sealed,
+      pairs: req.pairs,
+      since: since.toISOString(),
+      intervalHours: req.intervalHours,
+      active: req.active,
+      cursors: {},
+      nextSyncAt: now.toISOString(),
+      createdAt: now.toISOString(),
+    };
+    settingsRepository.setExchangeConnectors([...this.list(), connector]);
+    return connector;
+  }
+
+  /** New keys are checked like on create; without them the old stay. */
+  async update(
+    id: string,
+    req: ConnectorUpdateRequest,
+  ): Promise<ExchangeConnector | undefined> {
+    const existing = this.get(id);
+    if (!existing) return undefined;
+
+    const { apiKey, apiSecret, ...rest } = req;
+    let sealed = {};
+    if (apiKey || apiSecret) {
+      if (!apiKey || !apiSecret) {
+        throw new ValidationError(
+          "apiKey and apiSecret must be given together",
+        );
+      }
+      sealed = this.sealed({ apiKey, apiSecret });
+      await CLIENTS[existing.provider].checkReadOnly({ apiKey, apiSecret });
+    }
+    const defined = Object.fromEntries(
+      Object.entries(rest).filter(([, v]) => v !== undefined),
+    );
+    const updated: ExchangeConnector = {
+      ...existing,
+      ...defined,
+      ...sealed,
+      since: rest.since ? new Date(rest.since).toISOString() : existing.since,
+      id,
+      updatedAt: new Date().toISOString(),
+    };
+    this.save(updated);
+    return updated;
+  }
+
+  /** Synced transactions stay. */
+  delete(id: string): boolean {
+    const all = this.list();
+    const kept = all.filter((c) => c.id !== id);
+    if (kept.length === all.length) return false;
+    settingsRepository.setExchangeConnectors(kept);
+    return true;
+  }
+
+  /**
+   * Fetch everything since the last sync and record what is new. The
+   * streams that finished keep their position when a later one fails,
+   * so the next sync picks up where this one stopped. Syncing by hand
+   * leaves the schedule alone.
+   */
+  async sync(id: string, now: Date = new Date()): Promise<ConnectorSyncRun> {
+    const c = this.get(id);
+    if (!c) throw new NotFoundError("connector", id);
+    if (syncing.has(id)) {
+      throw new ConflictError("A sync of this connector is already running");
+    }
+    syncing.add(id);
+
+    const created = { TRADE: 0, DEPOSIT: 0, WITHDRAWAL: 0, REWARD: 0 };
+    const cursors = { ...c.cursors };
+    let skipped = 0;
+    let status: ConnectorSyncRun["status"] = "OK";
+    let error: string | undefined;
+    try {
+      const creds: ExchangeCredentials = JSON.parse(
+        decryptSecret(c.credentials, this.passphrase()),
+      );
+      for (const stream of CLIENTS[c.provider].streams(c)) {
+        const res = await stream.fetch(creds, cursors[stream.key], now);
+        for (const a of res.activities) {
+          const n = await this.record(c, a);
+          if (n) created[a.kind] += n;
+          else skipped++;
+        }
+        if (res.cursor !== undefined) cursors[stream.key] = res.cursor;
+      }
+    } catch (e: any) {
+      status = "FAILED";
+      error = describeError(e);
+      logger.warn(
+        { connector: c.name, provider: c.provider, error },
+        "Exchange sync failed",
+      );
+    } finally {
+      syncing.delete(id);
+    }
+
+    const total = Object.values(created).reduce((s, n) => s + n, 0);
+    // The connector may have been edited while the sync ran
+    const updated: ExchangeConnector = {
+      ...(this.get(id) ?? c),
+      cursors,
+      lastSyncAt: now.toISOString(),
+      lastStatus: status,
+      lastError: error,
+      lastCreated: total,
+    };
+    this.save(updated);
+    if (total) {
+      logger.info(
+        { connector: c.name, created, skipped },
+        "Synced exchange activity",
+      );
+    }
+    return { connector: updated, status, error, created, skipped };
+  }
+
+  /** Sync the active connectors that are due, then move them past `now`. */
+  async processDue(now: Date = new Date()): Promise<ConnectorSyncRun[]> {
+    const runs: ConnectorSyncRun[] = [];
+    for (const c of this.list()) {
+      if (!c.active || c.nextSyncAt > now.toISOString()) continue;
+      if (syncing.has(c.id)) continue;
+      const run = await this.sync(c.id, now);
+      const step = c.intervalHours * HOUR_MS;
+      let next = Date.parse(c.nextSyncAt) || now.getTime();
+      while (next <= now.getTime()) next += step;
+      run.connector = {
+        ...run.connector,
+        nextSyncAt: new Date(next).toISOString(),
+      };
+      this.save(run.connector);
+      runs.push(run);
+    }
+    return runs;
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDue().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Exchange sync failed"),
+      );
+    };
+    run();
+    setInterval(run, SCHEDULER_INTERVAL_MS);
+  }
+
+  // Transactions created for one activity; 0 when it was synced before
+  private async record(
+    c: ExchangeConnector,
+    a: ExchangeActivity,
+  ): Promise<number> {
+    const exchange = PROVIDER_NAMES[c.provider];
+    const sourceRef = `${c.provider}:${a.ref}`;
+    if (a.kind === "REWARD") {
+      if (recorded(sourceRef)) return 0;
+      await transactionService.createRewardTransaction({
+        asset: createAssetFromSymbol(a.asset),
+        amount: a.amount,
+        at: a.at,
+        account: c.account,
+        note: `${exchange}: ${a.note ?? "reward"}`,
+        counterparty: exchange,
+        sourceRef,
+      });
+      return 1;
+    }
+
+    let n = 0;
+    for (const leg of activityLegs(a, exchange)) {
+      const ref = sourceRef + leg.ref;
+      if (recorded(ref)) continue;
+      const base = await transactionService.buildTransactionBase(
+        createAssetFromSymbol(leg.asset),
+        leg.amount,
+        a.at,
+        c.account,
+      );
+      transactionRepository.create({
+        id: uuidv4(),
+        type: leg.type,
+        note: leg.note,
+        category: leg.category,
+        internalFlow: leg.internalFlow,
+        chain: leg.chain,
+        sourceRef: ref,
+        ...base,
+      } as Transaction);
+      n++;
+    }
+    return n;
+  }
+
+  private sealed(creds: ExchangeCredentials) {
+    return {
+      apiKeyHint: `…${creds.apiKey.slice(-4)}`,
+      credentials: encryptSecret(JSON.stringify(creds), this.passphrase()),
+    };
+  }
+
+  private save(c: ExchangeConnector): void {
+    const all = this.list();
+    const i = all.findIndex((x) => x.id === c.id);
+    if (i < 0) return; // deleted meanwhile
+    all[i] = c;
+    settingsRepository.setExchangeConnectors(all);
+  }
+
+  private passphrase(): string {
+    const key = config.settingsEncryptionKey;
+    if (!key) {
+      throw new BusinessError(
+        "SETTINGS_ENCRYPTION_KEY must be set to store exchange API keys",
+      );
+    }
+    return key;
+  }
+}
+
+export const connectorService = new ConnectorService();
@@ -0,0 +1,306 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  Asset,
+  CONSISTENCY_ISSUE_KINDS,
+  ConsistencyIssueKind,
+  Transaction,
+  assetKey,
+  unitsIn,
+} from "../types";
+import {
+  adminRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import { transactionService } from "./transaction.service";
+import { priceService } from "./price.service";
+import { precisionService } from "./precision.service";
+
+// Accounts of these admin types owe money, so a balance below zero is
+// expected there
+const LIABILITY_ACCOUNT_TYPES = new Set(["credit", "credit_card", "liability"]);
+
+// What the auto-fix of each kind of issue does
+export const CONSISTENCY_FIXES: Record<ConsistencyIssueKind, string> = {
+  orphaned_transfer: "Soft-delete the orphaned leg; it stays restorable",
+  vault_mismatch: "Add a vault entry for the difference",
+  negative_balance: "Add an opening balance before the first transaction",
+  missing_fx_rate: "Fetch the USD rate for the transaction date",
+};
+
+export interface ConsistencyIssue {
+  kind: ConsistencyIssueKind;
+  message: string;
+  transactionIds: string[]; // the transactions involved, if any
+  account?: string; // account or vault name
+  asset?: Asset;
+  amount?: number; // units the ledger is off by
+  at?: string; // when the problem starts
+}
+
+export interface ConsistencyReport {
+  checkedAt: string;
+  counts: Record<ConsistencyIssueKind, number>;
+  issues: ConsistencyIssue[];
+}
+
+function group<T>(items: T[], keyOf: (item: T) => string): Map<string, T[]> {
+  const groups = new Map<string, T[]>();
+  for (const item of items) {
+    const key = keyOf(item);
+    const list = groups.get(key) || [];
+    list.push(item);
+    groups.set(key, list);
+  }
+  return groups;
+}
+
+/**
+ * Double-entry checks over the whole ledger. Each check reports issues of
+ * one kind, and each kind has an auto-fix the admin can apply; fixes
+ * re-run the check, so only what is still wrong gets changed.
+ */
+export class ConsistencyService {
+  check(now = new Date()): ConsistencyReport {
+    const issues = [
+      ...this.orphanedTransfers(),
+      ...this.vaultMismatches(),
+      ...this.negativeBalances(),
+      ...this.missingFxRates(),
+    ];
+    const counts = Object.fromEntries(
+      CONSISTENCY_ISSUE_KINDS.map((k) => [
+        k,
+        issues.filter((i) => i.kind === k).length,
+      ]),
+    ) as Record<ConsistencyIssueKind, number>;
+    return { checkedAt: now.toISOString(), counts, issues };
+  }
+
+  /**
+   * Apply the auto-fix of each of `kinds`, only to issues in `accounts`
+   * when given; returns issues fixed per kind.
+   */
+  async fix(
+    kinds: ConsistencyIssueKind[],
+    now = new Date(),
+    accounts?: string[],
+  ): Promise<Partial<Record<ConsistencyIssueKind, number>>> {
+    const fixed: Partial<Record<ConsistencyIssueKind, number>> = {};
+    const scope = accounts && new Set(accounts.map((a) => a.toLowerCase()));
+    // Orphans first: deleting a leg changes balances the later checks see
+    for (const kind of CONSISTENCY_ISSUE_KINDS) {
+      if (!kinds.includes(kind)) continue;
+      const issues = this.check(now).issues.filter(
+        (i) =>
+          i.kind === kind &&
+          (!scope || scope.has((i.account || "").toLowerCase())),
+      );
+      for (const issue of issues) await this.apply(issue, now);
+      fixed[kind] = issues.length;
+    }
+    return fixed;
+  }
+
+  private orphanedTransfers(): ConsistencyIssue[] {
+    return transactionService
+      .checkTransferPairs()
+      .issues.filter((i) => i.kind !== "AMOUNT_MISMATCH")
+      .map((i) => {
+        const leg = (i.out || i.in)!;
+        const missing = i.out ? "TRANSFER_IN" : "TRANSFER_OUT";
+        return {
+          kind: "orphaned_transfer" as const,
+          message: `${leg.type} of ${leg.amount} ${leg.asset.symbol} in ${
+            leg.account || "no account"
+          } has no matching ${missing}`,
+          transactionIds: [leg.id],
+          account: leg.account,
+          asset: leg.asset,
+          amount: leg.amount,
+          at: leg.createdAt,
+        };
+      });
+  }
+
+  // Vaults whose transactions (account = vault name) don't net to the
+  // DEPOSIT - WITHDRAW of their entries, per asset
+  private vaultMismatches(): ConsistencyIssue[] {
+    const byAccount = group(
+      transactionRepository.findAll(),
+      (t) => (t.account || "").toLowerCase(),
+    );
+    const issues: ConsistencyIssue[] = [];
+    for (const vault of vaultRepository.findAll()) {
+      const txs = byAccount.get(vault.name.toLowerCase());
+      if (!txs?.length) continue; // tracked by its entries alone
+
+      const net = new Map<
+        string,
+        { asset: Asset; ledger: number; vault: number }
+      >();
+      const at = (asset: Asset) => {
+        const key = assetKey(asset);
+        if (!net.has(key)) net.set(key, { asset, ledger: 0, vault: 0 });
+        return net.get(key)!;
+      };
+      for (const t of txs) at(t.asset).ledger += unitsIn(t);
+      for (const e of vaultRepository.findAllEntries(vault.name)) {
+        if (e.type === "DEPOSIT") at(e.asset).vault += e.amount;
+        else if (e.type === "WITHDRAW") at(e.asset).vault -= e.amount;
+      }
+
+      for (const n of net.values()) {
+        const { asset } = n;
+        const held = precisionService.round(asset, n.vault);
+        const ledger = precisionService.round(asset, n.ledger);
+        const diff = precisionService.round(asset, ledger - held);
+        if (diff === 0) continue;
+        issues.push({
+          kind: "vault_mismatch",
+          message:
+            `Vault ${vault.name} holds ${held} ${asset.symbol} ` +
+            `but its transactions net to ${ledger}`,
+          transactionIds: [],
+          account: vault.name,
+          asset,
+          amount: diff,
+        });
+      }
+    }
+    return issues;
+  }
+
+  private negativeBalances(): ConsistencyIssue[] {
+    const liabilities = new Set(
+      adminRepository
+        .findAllAccounts()
+        .filter((a) =>
+          LIABILITY_ACCOUNT_TYPES.has((a.type || "").toLowerCase()),
+        )
+        .map((a) => a.name.toLowerCase()),
+    );
+    const groups = group(
+      transactionRepository
+        .findAll()
+        .filter(
+          (t) => t.account && !liabilities.has(t.account.toLowerCase()),
+        ),
+      (t) => `${t.account!.toLowerCase()}|${assetKey(t.asset)}`,
+    );
+
+    const issues: ConsistencyIssue[] = [];
+    for (const txs of groups.values()) {
+      txs.sort(
+        (a, b) =>
+          a.createdAt.localeCompare(b.createdAt) || a.id.localeCompare(b.id),
+      );
+      const asset = txs[0].asset;
+      let balance = 0;
+      let lowest = 0;
+      let first: Transaction | undefined; // first to go below zero
+      for (const t of txs) {
+        balance += unitsIn(t);
+        if (precisionService.round(asset, balance) < 0 && !first) first = t;
+        lowest = Math.min(lowest, balance);
+      }
+      if (!first) continue;
+      const shortfall = precisionService.round(asset, -lowest);
+      issues.push({
+        kind: "negative_balance",
+        message: `${txs[0].account} goes ${shortfall} ${
+          asset.symbol
+        } below zero`,
+        transactionIds: [first.id],
+        account: txs[0].account,
+        asset,
+        amount: shortfall,
+        at: first.createdAt,
+      });
+    }
+    return issues;
+  }
+
+  private missingFxRates(): ConsistencyIssue[] {
+    return transactionRepository
+      .findAll()
+      .filter(
+        (t) =>
+          !(Number(t.rate?.rateUSD) > 0) || !Number.isFinite(t.usdAmount),
+      )
+      .map((t) => ({
+        kind: "missing_fx_rate" as const,
+        message: `${t.type} of ${t.amount} ${t.asset.symbol} has no USD rate`,
+        transactionIds: [t.id],
+        account: t.account,
+        asset: t.asset,
+        at: t.createdAt,
+      }));
+  }
+
+  private async apply(issue: ConsistencyIssue, now: Date): Promise<void> {
+    switch (issue.kind) {
+      case "orphaned_transfer":
+        transactionRepository.softDelete(
+          issue.transactionIds[0],
+          now.toISOString(),
+        );
+        return;
+      case "vault_mismatch": {
+        const amount = Math.abs(issue.amount!);
+        const rate = await priceService.getRateUSD(issue.asset!);
+        vaultRepository.createEntry({
+          vault: issue.account!,
+          type: issue.amount! > 0 ? "DEPOSIT" : "WITHDRAW",
+          asset: issue.asset!,
+          amount,
+          usdValue: amount * rate.rateUSD,
+          at: now.toISOString(),
+          note: "Consistency fix: match the vault's transactions",
+        });
+        return;
+      }
+      case "negative_balance": {
+        const first = transactionRepository
+          .findAll()
+          .filter(
+            (t) =>
+              (t.account || "").toLowerCase() ===
+                issue.account!.toLowerCase() &&
+              assetKey(t.asset) === assetKey(issue.asset!),
+          )
+          .reduce((a, b) => (b.createdAt < a.createdAt ? b : a));
+        // Just before the account's first transaction
+        const at = new Date(new Date(first.createdAt).getTime() - 1);
+        const rate = await priceService.getRateUSD(
+          issue.asset!,
+          at.toISOString(),
+        );
+        transactionRepository.create({
+          id: uuidv4(),
+          type: "INITIAL",
+          asset: issue.asset!,
+          amount: issue.amount!,
+          createdAt: at.toISOString(),
+          account: first.account,
+          note: "Consistency fix: opening balance",
+          rate,
+          usdAmount: issue.amount! * rate.rateUSD,
+        } as Transaction);
+        return;
+      }
+      case "missing_fx_rate": {
+        const t = transactionRepository.findById(issue.transactionIds[0]);
+        if (!t) return;
+        const rate = await priceService.getRateUSD(t.asset, t.createdAt);
+        transactionRepository.update(t.id, {
+          rate,
+          usdAmount: t.amount * rate.rateUSD,
+        });
+        return;
+      }
+    }
+  }
+}
+
+export const consistencyService = new ConsistencyService();
@@ -0,0 +1,226 @@
+import {
+  Asset,
+  CostBasisMethod,
+  LotSelection,
+  VaultEntry,
+  assetKey,
+} from "../types";
+import { settingsRepository, vaultRepository } from "../repositories";
+import { ValidationError } from "../core/errors";
+
+const EPSILON = 1e-12;
+
+// Units of one deposit still held, with the cost basis left on them
+export interface Lot {
+  id: string; // deposit timestamp, "#n" appended for same-time deposits
+  acquiredAt: string;
+  units: number;
+  costUSD: number;
+}
+
+// Part of a lot closed by one withdrawal
+export interface LotRelease {
+  lot: string;
+  acquiredAt: string;
+  units: number;
+  costUSD: number;
+}
+
+export interface AssetLots {
+  asset: Asset;
+  method: CostBasisMethod;
+  units: number;
+  costUSD: number;
+  lots: Lot[];
+}
+
+// Reward distributions pay out profit; they don't close a lot
+export function isRewardDistribution(e: VaultEntry): boolean {
+  return !!e.note?.toLowerCase().includes("reward distribution");
+}
+
+/**
+ * Open lots of one asset in acquisition order. Withdrawals release cost
+ * basis from them according to the cost basis method.
+ */
+export class LotBook {
+  private lots: Lot[] = [];
+  private issued = new Map<string, number>();
+
+  add(acquiredAt: string, units: number, costUSD: number): Lot {
+    const n = (this.issued.get(acquiredAt) || 0) + 1;
+    this.issued.set(acquiredAt, n);
+    const lot: Lot = {
+      id: n > 1 ? `${acquiredAt}#${n}` : acquiredAt,
+      acquiredAt,
+      units,
+      costUSD,
+    };
+    this.lots.push(lot);
+    return lot;
+  }
+
+  get units(): number {
+    return this.lots.reduce((s, l) => s + l.units, 0);
+  }
+
+  get costUSD(): number {
+    return this.lots.reduce((s, l) => s + l.costUSD, 0);
+  }
+
+  open(): Lot[] {
+    return this.lots.map((l) => ({ ...l }));
+  }
+
+  /**
+   * Close `units` across the open lots. SPECIFIC takes the picked lots
+   * first; whatever the picks don't cover is closed FIFO. `unmatched` is
+   * what was withdrawn beyond the open units.
+   */
+  release(
+    units: number,
+    method: CostBasisMethod,
+    picks: LotSelection[] = [],
+  ): { released: LotRelease[]; unmatched: number } {
+    const released: LotRelease[] = [];
+    let left = units;
+    const take = (lot: Lot, amount: number) => {
+      const n = Math.min(amount, lot.units);
+      if (!(n > 0)) return;
+      const cost = lot.costUSD * (n / lot.units);
+      lot.units -= n;
+      lot.costUSD -= cost;
+      left -= n;
+      released.push({
+        lot: lot.id,
+        acquiredAt: lot.acquiredAt,
+        units: n,
+        costUSD: cost,
+      });
+    };
+
+    if (method === "AVERAGE") {
+      const total = this.units;
+      const fraction = total > 0 ? Math.min(1, units / total) : 0;
+      for (const lot of this.lots) take(lot, lot.units * fraction);
+    } else {
+      if (method === "SPECIFIC") {
+        for (const pick of picks) {
+          const lot = this.lots.find((l) => l.id === pick.lot);
+          if (lot) take(lot, Math.min(pick.amount, left));
+        }
+      }
+      const order = method === "LIFO" ? [...this.lots].reverse() : this.lots;
+      for (const lot of order) {
+        if (left <= EPSILON) break;
+        take(lot, left);
+      }
+    }
+
+    this.lots = this.lots.filter((l) => l.units > EPSILON);
+    return { released, unmatched: left > EPSILON ? left : 0 };
+  }
+
+  clear(): void {
+    this.lots = [];
+  }
+}
+
+export class CostBasisService {
+  /**
+   * Method for an asset held in a vault: the vault's setting, then the
+   * asset's, then the default. Without any setting each report keeps its
+   * historical method (`fallback`).
+   */
+  methodFor(
+    vault: string,
+    symbol: string,
+    fallback: CostBasisMethod = "AVERAGE",
+  ): CostBasisMethod {
+    const s = settingsRepository.getCostBasisSettings();
+    return (
+      s.byVault[vault] ??
+      s.byAsset[symbol.toUpperCase()] ??
+      s.default ??
+      fallback
+    );
+  }
+
+  /** Lots still open in a vault, per asset, after replaying its entries. */
+  openLots(vault: string, at?: string): AssetLots[] {
+    const books = new Map<string, { asset: Asset; book: LotBook }>();
+    const entries = [...vaultRepository.findAllEntries(vault)]
+      .filter((e) => !at || e.at <= at)
+      .sort((a, b) => String(a.at).localeCompare(String(b.at)));
+
+    for (const e of entries) {
+      if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+      const k = assetKey(e.asset);
+      const cur = books.get(k) || { asset: e.asset, book: new LotBook() };
+      books.set(k, cur);
+      if (e.type === "DEPOSIT") {
+        if (e.amount > 0) {
+          cur.book.add(e.at, e.amount, Number(e.usdValue || 0));
+        }
+      } else if (!isRewardDistribution(e) && e.amount > 0) {
+        const method = this.methodFor(vault, e.asset.symbol);
+        cur.book.release(e.amount, method, e.lots);
+      }
+    }
+
+    return Array.from(books.values())
+      .filter(({ book }) => book.units > EPSILON)
+      .map(({ asset, book }) => ({
+        asset,
+        method: this.methodFor(vault, asset.symbol),
+        units: book.units,
+        costUSD: book.costUSD,
+        lots: book.open(),
+      }));
+  }
+
+  /**
+   * Check the lots picked for a withdrawal: only under SPECIFIC, each one
+   * open with enough units, and together no more than the withdrawal.
+   */
+  checkLots(
+    vault: string,
+    asset: Asset,
+    amount: number,
+    picks: LotSelection[],
+    at?: string,
+  ): void {
+    const method = this.methodFor(vault, asset.symbol);
+    if (method !== "SPECIFIC") {
+      throw new ValidationError(
+        `lots can only be picked under the SPECIFIC method (${method} ` +
+          `applies to ${asset.symbol} in ${vault})`,
+      );
+    }
+    const open =
+      this.openLots(vault, at).find(
+        (a) => assetKey(a.asset) === assetKey(asset),
+      )?.lots || [];
+    const picked = new Map<string, number>();
+    for (const p of picks) {
+      picked.set(p.lot, (picked.get(p.lot) || 0) + p.amount);
+    }
+    for (const [id, units] of picked) {
+      const lot = open.find((l) => l.id === id);
+      if (!lot) throw new ValidationError(`lot ${id} is not open`);
+      if (units > lot.units + EPSILON) {
+        throw new ValidationError(
+          `lot ${id} holds ${lot.units} ${asset.symbol}, ${units} picked`,
+        );
+      }
+    }
+    const total = picks.reduce((s, p) => s + p.amount, 0);
+    if (total > amount + EPSILON) {
+      throw new ValidationError(
+        `picked lots total ${total}, more than the ${amount} withdrawn`,
+      );
+    }
+  }
+}
+
+export const costBasisService = new CostBasisService();
@@ -0,0 +1,181 @@
+import {
+  Asset,
+  RenewalCadence,
+  Transaction,
+  TRANSACTION_TYPES,
+  TransactionType,
+  assetKey,
+} from "../types";
+import { transactionRepository } from "../repositories";
+import { foldText } from "./transaction-search.service";
+import { amountUSD, amountVND } from "../utils/fx.util";
+import { NotFoundError, ValidationError } from "../core/errors";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const DAYS_PER_MONTH = 365.25 / 12;
+
+// Typical days between payments of a cadence, and the slack a gap may
+// have to still count as it
+interface CadenceGap {
+  cadence: RenewalCadence;
+  days: number;
+  slack: number;
+}
+const CADENCES: CadenceGap[] = [
+  { cadence: "WEEKLY", days: 7, slack: 2 },
+  { cadence: "MONTHLY", days: DAYS_PER_MONTH, slack: 5 },
+  { cadence: "QUARTERLY", days: 3 * DAYS_PER_MONTH, slack: 15 },
+  { cadence: "YEARLY", days: 365.25, slack: 30 },
+];
+
+// Payments in one asset, in its own units
+export interface CounterpartyAssetStats {
+  asset: Asset;
+  count: number;
+  total: number;
+  average: number;
+  last: number;
+}
+
+export interface CounterpartyFrequency {
+  cadence: RenewalCadence | null; // null when irregular or too few
+  medianIntervalDays: number | null;
+  perMonth: number | null; // payments per month from first to last
+  nextExpectedAt: string | null; // the last one plus the cadence
+}
+
+export interface CounterpartyHistory {
+  counterparty: string; // as written on the latest transaction
+  types: TransactionType[]; // the types counted as payments
+  count: number;
+  totalUSD: number;
+  averageUSD: number;
+  totalVND: number;
+  averageVND: number;
+  byAsset: CounterpartyAssetStats[];
+  firstAt: string | null;
+  lastAt: string | null;
+  last: Transaction | null; // the latest payment
+  frequency: CounterpartyFrequency;
+  transactions: Transaction[]; // every one with the counterparty
+}
+
+function median(values: number[]): number {
+  const sorted = [...values].sort((a, b) => a - b);
+  const mid = Math.floor(sorted.length / 2);
+  return sorted.length % 2
+    ? sorted[mid]
+    : (sorted[mid - 1] + sorted[mid]) / 2;
+}
+
+/** Types in `type` (comma-separated, or "all"); expenses by default. */
+export function parsePaymentTypes(type?: string): TransactionType[] {
+  if (!type) return ["EXPENSE"];
+  if (type.trim().toLowerCase() === "all") return [...TRANSACTION_TYPES];
+  const types = type
+    .split(",")
+    .map((t) => t.trim().toUpperCase())
+    .filter(Boolean);
+  const unknown = types.filter(
+    (t) => !TRANSACTION_TYPES.includes(t as TransactionType),
+  );
+  if (unknown.length) {
+    throw new ValidationError(`Unknown type: ${unknown.join(", ")}`);
+  }
+  return types as TransactionType[];
+}
+
+function frequencyOf(payments: Transaction[]): CounterpartyFrequency {
+  // One payment a day at most, so a split bill isn't a daily habit
+  const days = [
+    ...new Set(payments.map((t) => String(t.createdAt).slice(0, 10))),
+  ].sort();
+  if (days.length < 2) {
+    return {
+      cadence: null,
+      medianIntervalDays: null,
+      perMonth: null,
+      nextExpectedAt: null,
+    };
+  }
+  const times = days.map((d) => Date.parse(`${d}T00:00:00.000Z`));
+  const gaps = times.slice(1).map((t, i) => (t - times[i]) / DAY_MS);
+  const typical = median(gaps);
+  const match = CADENCES.find((c) => Math.abs(typical - c.days) <= c.slack);
+  const spanDays = (times[times.length - 1] - times[0]) / DAY_MS;
+  const last = times[times.length - 1];
+  return {
+    cadence: match?.cadence ?? null,
+    medianIntervalDays: typical,
+    perMonth: (days.length - 1) / (spanDays / DAYS_PER_MONTH),
+    nextExpectedAt: match
+      ? new Date(last + Math.round(match.days) * DAY_MS)
+          .toISOString()
+          .slice(0, 10)
+      : null,
+  };
+}
+
+export class CounterpartyService {
+  /**
+   * What has been paid to a counterparty: the count, totals and average
+   * in USD and VND and per asset, when it was last paid, and how often.
+   * Counterparties match ignoring case and diacritics. `types` are the
+   * transaction types counted as payments (expenses by default); the
+   * `transactions` list has every type, newest first. VND uses the rate
+   * recorded with each transaction, else `rateVND` per USD.
+   */
+  history(
+    name: string,
+    rateVND: number,
+    types: TransactionType[] = ["EXPENSE"],
+  ): CounterpartyHistory {
+    const key = foldText(name.trim());
+    const transactions = transactionRepository
+      .findAll()
+      .filter((t) => t.counterparty && foldText(t.counterparty) === key)
+      .sort((a, b) => String(b.createdAt).localeCompare(String(a.createdAt)));
+    if (!key || !transactions.length) {
+      throw new NotFoundError("Counterparty", name);
+    }
+
+    const payments = transactions.filter((t) => types.includes(t.type));
+    const byAsset = new Map<string, CounterpartyAssetStats>();
+    // Newest first, so the first of each asset is its last payment
+    for (const t of payments) {
+      const k = assetKey(t.asset);
+      const s = byAsset.get(k) ?? {
+        asset: t.asset,
+        count: 0,
+        total: 0,
+        average: 0,
+        last: t.amount,
+      };
+      s.count++;
+      s.total += t.amount;
+      s.average = s.total / s.count;
+      byAsset.set(k, s);
+    }
+    const totalUSD = payments.reduce((s, t) => s + amountUSD(t), 0);
+    const totalVND = payments.reduce((s, t) => s + amountVND(t, rateVND), 0);
+    const n = payments.length;
+
+    return {
+      counterparty: transactions[0].counterparty!,
+      types,
+      count: n,
+      totalUSD,
+      averageUSD: n ? totalUSD / n : 0,
+      totalVND,
+      averageVND: n ? totalVND / n : 0,
+      byAsset: [...byAsset.values()].sort((a, b) => b.count - a.count),
+      firstAt: n ? payments[n - 1].createdAt : null,
+      lastAt: n ? payments[0].createdAt : null,
+      last: n ? payments[0] : null,
+      frequency: frequencyOf(payments),
+      transactions,
+    };
+  }
+}
+
+export const counterpartyService = new CounterpartyService();
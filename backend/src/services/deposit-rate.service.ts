@@ -0,0 +1,76 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  DepositRate,
+  DepositRateCreateRequest,
+  DepositRateUpdateRequest,
+} from "../types";
+import { depositRateRepository } from "../repositories";
+
+// 12-month deposits are the usual reference for VN savers
+export const DEFAULT_DEPOSIT_TERM_MONTHS = 12;
+
+export class DepositRateService {
+  list(termMonths?: number): DepositRate[] {
+    const rates = depositRateRepository.findAll();
+    return termMonths === undefined
+      ? rates
+      : rates.filter((r) => r.termMonths === termMonths);
+  }
+
+  get(id: string): DepositRate | undefined {
+    return depositRateRepository.findById(id);
+  }
+
+  create(data: DepositRateCreateRequest): DepositRate {
+    return depositRateRepository.create({
+      id: uuidv4(),
+      effectiveDate: data.effectiveDate,
+      termMonths: data.termMonths,
+      ratePercent: data.ratePercent,
+      bank: data.bank?.trim() || undefined,
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    });
+  }
+
+  update(id: string, data: DepositRateUpdateRequest): DepositRate | undefined {
+    const existing = depositRateRepository.findById(id);
+    if (!existing) return undefined;
+    const updates: Partial<DepositRate> = { ...data };
+    if (data.bank !== undefined) updates.bank = data.bank.trim() || undefined;
+    return depositRateRepository.update(id, {
+      ...updates,
+      updatedAt: new Date().toISOString(),
+    });
+  }
+
+  delete(id: string): boolean {
+    return depositRateRepository.delete(id);
+  }
+
+  /**
+   * Annual rate (percent) in effect on `date` for the term: each bank's
+   * latest entry on or before the date, averaged across banks. Undefined
+   * when nothing is on file yet. Pass `rates` to avoid re-reading them.
+   */
+  rateAt(
+    date: string,
+    termMonths = DEFAULT_DEPOSIT_TERM_MONTHS,
+    rates: DepositRate[] = this.list(termMonths),
+  ): number | undefined {
+    const latest = new Map<string, DepositRate>();
+    for (const r of rates) {
+      if (r.termMonths !== termMonths || r.effectiveDate > date) continue;
+      const bank = (r.bank ?? "").toLowerCase();
+      const current = latest.get(bank);
+      if (!current || r.effectiveDate >= current.effectiveDate) {
+        latest.set(bank, r);
+      }
+    }
+    if (!latest.size) return undefined;
+    const values = Array.from(latest.values());
+    return values.reduce((s, r) => s + r.ratePercent, 0) / values.length;
+  }
+}
+
+export const depositRateService = new DepositRateService();
@@ -0,0 +1,96 @@
+import { Transaction } from "../types";
+import { transactionRepository } from "../repositories";
+import { priceService } from "./price.service";
+import { logger } from "../utils/logger";
+
+const RETRY_INTERVAL_MS = 30 * 60 * 1000; // every 30 minutes
+let schedulerStarted = false;
+
+export type EnrichmentReason = "missing" | "stale";
+
+export interface PendingEnrichment {
+  transaction: Transaction;
+  reason: EnrichmentReason;
+}
+
+// Rewards are revalued separately (POST /transactions/rewards/revalue)
+function reasonFor(t: Transaction): EnrichmentReason | undefined {
+  if (t.category === "reward") return undefined;
+  if (t.rate?.missing) return "missing";
+  if (t.rate?.stale) return "stale";
+  return undefined;
+}
+
+/**
+ * Transactions recorded while no price provider answered keep a
+ * placeholder (missing) or last-known (stale) rate. They stay in the books
+ * and are repriced in the background once a provider is back.
+ */
+export class EnrichmentService {
+  pending(): PendingEnrichment[] {
+    return transactionRepository
+      .findAll()
+      .map((t) => ({ transaction: t, reason: reasonFor(t) }))
+      .filter((p): p is PendingEnrichment => !!p.reason)
+      .sort((a, b) =>
+        a.transaction.createdAt.localeCompare(b.transaction.createdAt),
+      );
+  }
+
+  /**
+   * Look each pending transaction's rate up again at its own date. Those
+   * that get a fresh provider rate are updated, keeping the sign of
+   * usdAmount; the rest stay queued.
+   */
+  async retry(): Promise<{
+    enriched: Transaction[];
+    stillPending: PendingEnrichment[];
+  }> {
+    const enriched: Transaction[] = [];
+    const stillPending: PendingEnrichment[] = [];
+
+    for (const p of this.pending()) {
+      const t = p.transaction;
+      try {
+        const rate = await priceService.getRateUSD(t.asset, t.createdAt);
+        if (rate.missing || rate.stale) {
+          stillPending.push(p);
+          continue;
+        }
+        const sign = (t.usdAmount || 0) < 0 ? -1 : 1;
+        const updated = transactionRepository.update(t.id, {
+          rate,
+          usdAmount: sign * t.amount * rate.rateUSD,
+        });
+        if (updated) enriched.push(updated);
+      } catch {
+        stillPending.push(p);
+      }
+    }
+    return { enriched, stillPending };
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = async () => {
+      try {
+        if (!this.pending().length) return;
+        const { enriched, stillPending } = await this.retry();
+        logger.info(
+          { enriched: enriched.length, stillPending: stillPending.length },
+          "Transaction rate enrichment retried",
+        );
+      } catch (e: any) {
+        logger.warn({ error: e?.message }, "Rate enrichment failed");
+      }
+    };
+    void run();
+    setInterval(() => {
+      void run();
+    }, RETRY_INTERVAL_MS);
+  }
+}
+
+export const enrichmentService = new EnrichmentService();
@@ -0,0 +1,132 @@
+import { v4 as uuidv4 } from "uuid";
+import { Transaction, VaultEntry } from "../types";
+import {
+  ITransactionRepository,
+  IVaultRepository,
+} from "../repositories/repository.interface";
+import { logger } from "../utils/logger";
+
+export const DOMAIN_EVENT_TYPES = [
+  "transaction.created",
+  "transaction.updated",
+  "transaction.deleted",
+  "transaction.restored",
+  "vault_entry.created",
+  "vault.ended",
+  "vault.deleted",
+  "investment.closed",
+  "borrowing.closed",
+] as const;
+export type DomainEventType = (typeof DOMAIN_EVENT_TYPES)[number];
+
+export interface DomainEvent<T = unknown> {
+  id: string;
+  type: DomainEventType;
+  at: string;
+  data: T;
+}
+
+export type DomainEventHandler = (event: DomainEvent) => void;
+
+/**
+ * In-process publish/subscribe for domain events. Handlers run
+ * synchronously in subscription order; one that throws is logged and does
+ * not affect the others or the code that emitted the event.
+ */
+export class EventBus {
+  private handlers = new Set<DomainEventHandler>();
+
+  emit<T>(type: DomainEventType, data: T): DomainEvent<T> {
+    const event: DomainEvent<T> = {
+      id: uuidv4(),
+      type,
+      at: new Date().toISOString(),
+      data,
+    };
+    for (const handler of this.handlers) {
+      try {
+        handler(event);
+      } catch (err: any) {
+        logger.warn(
+          { event: type, error: err?.message },
+          "Event handler failed",
+        );
+      }
+    }
+    return event;
+  }
+
+  /** Returns a function that removes the handler again. */
+  subscribe(handler: DomainEventHandler): () => void {
+    this.handlers.add(handler);
+    return () => {
+      this.handlers.delete(handler);
+    };
+  }
+}
+
+export const eventBus = new EventBus();
+
+/**
+ * The transaction repository emitting transaction.created, .updated,
+ * .deleted and .restored, so every write path (services, imports,
+ * actions) publishes events without each one doing it.
+ */
+export function withTransactionEvents(
+  repo: ITransactionRepository,
+  bus: EventBus = eventBus,
+): ITransactionRepository {
+  const wrapped: ITransactionRepository = Object.create(repo);
+  wrapped.create = (tx: Transaction) => {
+    const created = repo.create(tx);
+    bus.emit("transaction.created", created);
+    return created;
+  };
+  wrapped.createMany = (txs: Transaction[]) => {
+    const created = repo.createMany(txs);
+    for (const tx of created) bus.emit("transaction.created", tx);
+    return created;
+  };
+  wrapped.update = (id: string, updates: Partial<Transaction>) => {
+    const updated = repo.update(id, updates);
+    if (updated) bus.emit("transaction.updated", updated);
+    return updated;
+  };
+  wrapped.delete = (id: string) => {
+    const existing = repo.findById(id);
+    const ok = repo.delete(id);
+    if (ok && existing) bus.emit("transaction.deleted", existing);
+    return ok;
+  };
+  wrapped.softDelete = (id: string, deletedAt: string) => {
+    const existing = repo.findById(id);
+    const ok = repo.softDelete(id, deletedAt);
+    if (ok && existing) {
+      bus.emit("transaction.deleted", { ...existing, deletedAt });
+    }
+    return ok;
+  };
+  wrapped.restore = (id: string) => {
+    const restored = repo.restore(id);
+    if (restored) bus.emit("transaction.restored", restored);
+    return restored;
+  };
+  return wrapped;
+}
+
+/**
+ * The vault repository emitting vault_entry.created for every entry,
+ * whichever service books it.
+ */
+export function withVaultEntryEvents(
+  repo: IVaultRepository,
+  bus: EventBus = eventBus,
+): IVaultRepository {
+  const wrapped: IVaultRepository = Object.create(repo);
+  wrapped.createEntry = (entry: VaultEntry) => {
+    const created = repo.createEntry(entry);
+    bus.emit("vault_entry.created", created);
+    return created;
+  };
+  return wrapped;
+}
@@ -0,0 +1,213 @@
+import axios from "axios";
+import { EvmChain } from "../types";
+import { config } from "../core/config";
+
+const REQUEST_TIMEOUT_MS = 30000;
+const PAGE = 1000;
+const WINDOW = 10000; // most rows an explorer returns for one query
+
+export const CHAIN_IDS: Record<EvmChain, number> = {
+  ethereum: 1,
+  bsc: 56,
+  polygon: 137,
+  arbitrum: 42161,
+  optimism: 10,
+  base: 8453,
+};
+export const CHAIN_NAMES: Record<EvmChain, string> = {
+  ethereum: "Ethereum",
+  bsc: "BNB Chain",
+  polygon: "Polygon",
+  arbitrum: "Arbitrum",
+  optimism: "Optimism",
+  base: "Base",
+};
+// Asset gas is paid in
+export const NATIVE_ASSETS: Record<EvmChain, string> = {
+  ethereum: "ETH",
+  bsc: "BNB",
+  polygon: "POL",
+  arbitrum: "ETH",
+  optimism: "ETH",
+  base: "ETH",
+};
+
+// Wallets are synced from these lists of the explorer API
+export const WALLET_STREAMS = ["native", "internal", "tokens"] as const;
+export type WalletStream = (typeof WALLET_STREAMS)[number];
+const ACTIONS: Record<WalletStream, string> = {
+  native: "txlist",
+  internal: "txlistinternal", // value sent by contracts, e.g. unwrapping
+  tokens: "tokentx", // ERC-20
+};
+
+/** A movement of value on chain; `ref` is unique on its chain. */
+export interface EvmTransfer {
+  ref: string;
+  hash: string;
+  block: number;
+  at: string;
+  from: string; // lowercase addresses
+  to: string;
+  asset: string;
+  amount: number;
+  contract?: string; // token contract; native transfers have none
+  fee: number; // gas the sender paid, in the native asset
+  failed: boolean; // reverted: no value moved, the gas was still paid
+}
+
+/** `value` in the token's smallest unit, as a number of whole tokens. */
+export function fromUnits(value: string, decimals: number): number {
+  const digits = BigInt(value || "0")
+    .toString()
+    .padStart(decimals + 1, "0");
+  const whole = digits.slice(0, digits.length - decimals);
+  return Number(decimals ? `${whole}.${digits.slice(whole.length)}` : whole);
+}
+
+const iso = (seconds: string) =>
+  new Date(Number(seconds) * 1000).toISOString();
+const lower = (a?: string) => (a || "").toLowerCase();
+
+export function nativeTransfer(r: any, chain: EvmChain): EvmTransfer {
+  return {
+    ref: r.hash,
+    hash: r.hash,
+    block: Number(r.blockNumber),
+    at: iso(r.timeStamp),
+    from: lower(r.from),
+    to: lower(r.to),
+    asset: NATIVE_ASSETS[chain],
+    amount: fromUnits(r.value, 18),
+    fee: fromUnits(
+      String(BigInt(r.gasUsed || "0") * BigInt(r.gasPrice || "0")),
+      18,
+    ),
+    failed: r.isError === "1",
+  };
+}
+
+export function internalTransfer(r: any, chain: EvmChain): EvmTransfer {
+  const trace = r.traceId || `${lower(r.from)}-${lower(r.to)}-${r.value}`;
+  return {
+    ref: `${r.hash}:internal:${trace}`,
+    hash: r.hash,
+    block: Number(r.blockNumber),
+    at: iso(r.timeStamp),
+    from: lower(r.from),
+    to: lower(r.to),
+    asset: NATIVE_ASSETS[chain],
+    amount: fromUnits(r.value, 18),
+    fee: 0, // paid by the outer transaction
+    failed: r.isError === "1",
+  };
+}
+
+export function tokenTransfer(r: any): EvmTransfer {
+  return {
+    ref: `${r.hash}:token:${r.logIndex}`,
+    hash: r.hash,
+    block: Number(r.blockNumber),
+    at: iso(r.timeStamp),
+    from: lower(r.from),
+    to: lower(r.to),
+    asset: String(r.tokenSymbol || "").trim().toUpperCase(),
+    amount: fromUnits(r.value, Number(r.tokenDecimal) || 0),
+    contract: lower(r.contractAddress),
+    fee: 0,
+    failed: false,
+  };
+}
+
+/**
+ * Transfers of an address from an Etherscan-compatible explorer API
+ * (EVM_EXPLORER_URL, by default the multichain Etherscan API, with
+ * EVM_EXPLORER_API_KEY). Lists are read oldest first from a block on.
+ */
+export class EvmExplorerClient {
+  /** Transfers from block `cursor` on, and the block to read next. */
+  async transfers(
+    chain: EvmChain,
+    address: string,
+    stream: WalletStream,
+    cursor = 0,
+  ): Promise<{ transfers: EvmTransfer[]; cursor?: number }> {
+    const rows = await this.list(chain, ACTIONS[stream], address, cursor);
+    const transfers = rows.map((r) =>
+      stream === "native"
+        ? nativeTransfer(r, chain)
+        : stream === "internal"
+          ? internalTransfer(r, chain)
+          : tokenTransfer(r),
+    );
+    const last = transfers.reduce((m, t) => Math.max(m, t.block), -1);
+    return { transfers, cursor: last < 0 ? undefined : last + 1 };
+  }
+
+  // Every row from `startBlock` on, a page at a time
+  private async list(
+    chain: EvmChain,
+    action: string,
+    address: string,
+    startBlock: number,
+  ): Promise<any[]> {
+    const rows: any[] = [];
+    const seen = new Set<string>();
+    let start = startBlock;
+    let page = 1;
+    for (;;) {
+      const batch = await this.call(chain, {
+        module: "account",
+        action,
+        address,
+        startblock: start,
+        endblock: 999999999,
+        page,
+        offset: PAGE,
+        sort: "asc",
+      });
+      for (const r of batch) {
+        // Rows of the block a window restarts at come again
+        const key = [r.hash, r.logIndex, r.traceId, r.from, r.to, r.value]
+          .map((v) => v ?? "")
+          .join(":");
+        if (seen.has(key)) continue;
+        seen.add(key);
+        rows.push(r);
+      }
+      if (batch.length < PAGE) return rows;
+      if (page * PAGE < WINDOW) {
+        page++;
+      } else {
+        start = Number(batch[batch.length - 1].blockNumber);
+        page = 1;
+      }
+    }
+  }
+
+  private async call(
+    chain: EvmChain,
+    params: Record<string, string | number>,
+  ): Promise<any[]> {
+    const res = await axios.get(config.evmExplorerUrl, {
+      params: {
+        chainid: CHAIN_IDS[chain],
+        ...params,
+        ...(config.evmExplorerApiKey
+          ? { apikey: config.evmExplorerApiKey }
+          : {}),
+      },
+      timeout: REQUEST_TIMEOUT_MS,
+    });
+    // An empty list comes back as status "0" too; errors have a string
+    const result = res.data?.result;
+    if (Array.isArray(result)) return result;
+    throw new Error(
+      typeof result === "string" && result
+        ? result
+        : res.data?.message || "Unexpected explorer response",
+    );
+  }
+}
+
+export const evmExplorerClient = new EvmExplorerClient();
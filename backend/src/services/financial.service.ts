@@ -171,6 +171,93 @@ export function annualizeRate(rate: number, days: number): number {
   return (Math.pow(1 + rate, 365 / days) - 1) * 100;
 }
 
+export type AnnualizationMethod = "APR" | "APY";
+
+export function parseAnnualizationMethod(v: unknown): AnnualizationMethod {
+  return String(v ?? "").toUpperCase() === "APY" ? "APY" : "APR";
+}
+
+/**
+ * Annualize a simple ROI (decimal) over a holding period.
+ * APR scales linearly, APY compounds. Periods under 30 days return the
+ * non-annualized ROI, matching calculateIRRBasedAPR.
+ * @returns Annualized return as percentage, clamped to -100%..1000%
+ */
+export function annualizeReturn(
+  roi: number,
+  days: number,
+  method: AnnualizationMethod = "APR",
+): number {
+  if (!Number.isFinite(roi)) return 0;
+  if (days < 30) return roi * 100;
+
+  const annualized =
+    method === "APY"
+      ? roi <= -1
+        ? -100
+        : annualizeRate(roi, days)
+      : roi * (365 / days) * 100;
+
+  return Number.isFinite(annualized)
+    ? Math.max(-100, Math.min(1000, annualized))
+    : 0;
+}
+
+export interface RiskMetrics {
+  observations: number; // number of daily returns
+  annualizedReturnPercent: number;
+  volatilityPercent: number; // annualized standard deviation of returns
+  maxDrawdownPercent: number; // largest peak-to-trough decline (<= 0)
+  sharpeRatio: number;
+}
+
+/**
+ * Risk metrics from a series of daily simple returns (decimals).
+ * Annualizes with 365 periods since crypto trades every day.
+ * @param riskFreeRate Annual risk-free rate as decimal (e.g. 0.05)
+ */
+export function computeRiskMetrics(
+  dailyReturns: number[],
+  riskFreeRate = 0,
+): RiskMetrics {
+  const n = dailyReturns.length;
+  if (n === 0) {
+    return {
+      observations: 0,
+      annualizedReturnPercent: 0,
+      volatilityPercent: 0,
+      maxDrawdownPercent: 0,
+      sharpeRatio: 0,
+    };
+  }
+
+  const mean = dailyReturns.reduce((s, r) => s + r, 0) / n;
+  const variance =
+    n > 1
+      ? dailyReturns.reduce((s, r) => s + (r - mean) ** 2, 0) / (n - 1)
+      : 0;
+  const volatility = Math.sqrt(variance) * Math.sqrt(365);
+  const annualReturn = mean * 365;
+
+  let index = 1;
+  let peak = 1;
+  let maxDrawdown = 0;
+  for (const r of dailyReturns) {
+    index *= 1 + r;
+    peak = Math.max(peak, index);
+    maxDrawdown = Math.min(maxDrawdown, index / peak - 1);
+  }
+
+  return {
+    observations: n,
+    annualizedReturnPercent: annualReturn * 100,
+    volatilityPercent: volatility * 100,
+    maxDrawdownPercent: maxDrawdown * 100,
+    sharpeRatio:
+      volatility > 0 ? (annualReturn - riskFreeRate) / volatility : 0,
+  };
+}
+
 /**
  * Date utilities
  */
@@ -0,0 +1,224 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  CouponFrequency,
+  FixedIncomeCreateRequest,
+  FixedIncomeInstrument,
+  FixedIncomeUpdateRequest,
+  Transaction,
+} from "../types";
+import { fixedIncomeRepository, transactionRepository } from "../repositories";
+import { transactionService } from "./transaction.service";
+import { precisionService } from "./precision.service";
+import { eventBus } from "./event-bus.service";
+import { logger } from "../utils/logger";
+
+const ACCRUAL_INTERVAL_MS = 6 * 60 * 60 * 1000; // every 6 hours
+const DAY_MS = 24 * 60 * 60 * 1000;
+let schedulerStarted = false;
+
+const FREQUENCY_MONTHS: Record<
+  Exclude<CouponFrequency, "AT_MATURITY">,
+  number
+> = {
+  MONTHLY: 1,
+  QUARTERLY: 3,
+  SEMIANNUAL: 6,
+  ANNUAL: 12,
+};
+
+export interface CouponPayment {
+  periodStart: string;
+  paymentAt: string;
+  amount: number;
+  final: boolean; // paid together with the principal at maturity
+}
+
+export interface UpcomingMaturity {
+  instrument: FixedIncomeInstrument;
+  daysUntil: number;
+  principal: number;
+  finalCoupon: number;
+}
+
+function addMonths(iso: string, months: number): string {
+  const d = new Date(iso);
+  d.setUTCMonth(d.getUTCMonth() + months);
+  return d.toISOString();
+}
+
+/**
+ * Coupon dates from start to maturity. Periodic coupons fall every
+ * frequency step after start; a short last period ends at maturity.
+ * Amounts accrue Actual/365, the convention Vietnamese banks use for term
+ * deposits.
+ */
+export function couponSchedule(inst: FixedIncomeInstrument): CouponPayment[] {
+  const dates: string[] = [];
+  if (inst.couponFrequency !== "AT_MATURITY") {
+    const step = FREQUENCY_MONTHS[inst.couponFrequency];
+    for (let k = 1; ; k++) {
+      const at = addMonths(inst.startAt, step * k);
+      if (at >= inst.maturityAt) break;
+      dates.push(at);
+    }
+  }
+  dates.push(inst.maturityAt);
+
+  let prev = inst.startAt;
+  return dates.map((at) => {
+    const days = (new Date(at).getTime() - new Date(prev).getTime()) / DAY_MS;
+    const payment = {
+      periodStart: prev,
+      paymentAt: at,
+      amount: (inst.faceValue * inst.couponRate * days) / 365,
+      final: at === inst.maturityAt,
+    };
+    prev = at;
+    return payment;
+  });
+}
+
+export class FixedIncomeService {
+  createInstrument(data: FixedIncomeCreateRequest): FixedIncomeInstrument {
+    if (new Date(data.maturityAt) <= new Date(data.startAt)) {
+      throw new Error("maturityAt must be after startAt");
+    }
+
+    const inst: FixedIncomeInstrument = {
+      id: uuidv4(),
+      kind: data.kind,
+      name: data.name,
+      issuer: data.issuer,
+      asset: { ...data.asset, symbol: data.asset.symbol.toUpperCase() },
+      faceValue: data.faceValue,
+      couponRate: data.couponRate,
+      couponFrequency: data.couponFrequency,
+      startAt: data.startAt,
+      maturityAt: data.maturityAt,
+      account: data.account,
+      status: "ACTIVE",
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    };
+    inst.nextCouponAt = couponSchedule(inst)[0].paymentAt;
+    return fixedIncomeRepository.create(inst);
+  }
+
+  listInstruments(
+    filters: { status?: string; kind?: string } = {},
+  ): FixedIncomeInstrument[] {
+    const items = filters.status
+      ? fixedIncomeRepository.findByStatus(filters.status)
+      : fixedIncomeRepository.findAll();
+    return filters.kind ? items.filter((i) => i.kind === filters.kind) : items;
+  }
+
+  getInstrument(id: string): FixedIncomeInstrument | undefined {
+    return fixedIncomeRepository.findById(id);
+  }
+
+  updateInstrument(
+    id: string,
+    updates: FixedIncomeUpdateRequest,
+  ): FixedIncomeInstrument | undefined {
+    return fixedIncomeRepository.update(id, updates);
+  }
+
+  deleteInstrument(id: string): boolean {
+    return fixedIncomeRepository.delete(id);
+  }
+
+  /**
+   * Post every coupon that has come due as INTEREST_INCOME and mark
+   * instruments MATURED once the final coupon is paid. Each coupon carries
+   * a sourceRef so a rerun never posts it twice.
+   */
+  async accrueDue(now: Date = new Date()): Promise<Transaction[]> {
+    const posted: Transaction[] = [];
+    for (const inst of fixedIncomeRepository.findByStatus("ACTIVE")) {
+      for (const c of couponSchedule(inst)) {
+        if (new Date(c.paymentAt) > now) {
+          if (inst.nextCouponAt !== c.paymentAt) {
+            fixedIncomeRepository.update(inst.id, {
+              nextCouponAt: c.paymentAt,
+            });
+          }
+          break;
+        }
+
+        const sourceRef = `fixed-income:${inst.id}:${c.paymentAt.slice(0, 10)}`;
+        const recorded = transactionRepository.findBySourceRef(sourceRef, {
+          includeDeleted: true,
+        });
+        if (c.amount > 0 && !recorded) {
+          posted.push(
+            await transactionService.createIncomeTransaction({
+              asset: inst.asset,
+              amount: precisionService.round(inst.asset, c.amount),
+              at: c.paymentAt,
+              account: inst.account,
+              note: `${c.final ? "Final coupon" : "Coupon"}: ${inst.name}`,
+              category: "INTEREST_INCOME",
+              counterparty: inst.issuer,
+              sourceRef,
+            }),
+          );
+        }
+
+        if (c.final) {
+          fixedIncomeRepository.update(inst.id, {
+            status: "MATURED",
+            nextCouponAt: undefined,
+          });
+          logger.info(
+            { instrumentId: inst.id, name: inst.name, at: c.paymentAt },
+            "Fixed income instrument matured",
+          );
+          eventBus.emit("investment.closed", {
+            kind: "fixed_income",
+            id: inst.id,
+            name: inst.name,
+            status: "MATURED",
+            at: c.paymentAt,
+          });
+        }
+      }
+    }
+    return posted;
+  }
+
+  /** Active instruments maturing within `days`, soonest first. */
+  upcomingMaturities(days: number, now: Date = new Date()): UpcomingMaturity[] {
+    const until = now.getTime() + days * DAY_MS;
+    const out: UpcomingMaturity[] = [];
+    for (const inst of fixedIncomeRepository.findByStatus("ACTIVE")) {
+      const t = new Date(inst.maturityAt).getTime();
+      if (Number.isNaN(t) || t > until) continue;
+      const schedule = couponSchedule(inst);
+      out.push({
+        instrument: inst,
+        daysUntil: Math.max(0, Math.ceil((t - now.getTime()) / DAY_MS)),
+        principal: inst.faceValue,
+        finalCoupon: schedule[schedule.length - 1].amount,
+      });
+    }
+    return out.sort((a, b) =>
+      a.instrument.maturityAt.localeCompare(b.instrument.maturityAt),
+    );
+  }
+
+  startAccrualScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.accrueDue().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Fixed income accrual failed"),
+      );
+    };
+    run();
+    setInterval(run, ACCRUAL_INTERVAL_MS);
+  }
+}
+
+export const fixedIncomeService = new FixedIncomeService();
@@ -0,0 +1,342 @@
+import { Asset, VaultEntry } from "../types";
+import {
+  borrowingRepository,
+  settingsRepository,
+  transactionRepository,
+} from "../repositories";
+import { transactionService } from "./transaction.service";
+import { borrowingService } from "./borrowing.service";
+import { vaultService } from "./vault.service";
+import { ConflictError, NotFoundError, ValidationError } from "../core/errors";
+import { withPriceOverrides } from "../utils/price-override.util";
+import { logger } from "../utils/logger";
+
+const LOG_KEY = "fixtureLog";
+const LOG_LIMIT = 200;
+const DEFAULT_START = "2025-01-01";
+const MAX_MONTHS = 60;
+
+// Fixed USD prices so a scenario produces the same values on every run
+const VND_USD = 1 / 25000;
+const EUR_USD = 1.08;
+const BTC_PATH = [
+  42000, 45500, 39800, 47200, 52500, 48900, 55100, 60300, 57400, 63800,
+  61200, 66500,
+];
+
+export interface FixtureOptions {
+  start: string; // YYYY-MM-DD, the scenario's first day
+  label: string; // vault or counterparty name the scenario is built on
+  months: number;
+  amount?: number;
+}
+
+export interface FixtureParams {
+  start?: string;
+  label?: string;
+  months?: number;
+  amount?: number;
+}
+
+export interface FixtureRecord {
+  scenario: string;
+  label: string;
+  start: string;
+  transactionIds: string[];
+  vaultEntries: number;
+  borrowingIds: string[];
+  vaults: string[];
+  at: string;
+}
+
+export interface FixtureScenario {
+  name: string;
+  description: string;
+  defaults: { label: string; months: number; amount?: number };
+  build(opts: FixtureOptions, out: FixtureRecord): Promise<void>;
+}
+
+// Test data factories shared by the scenarios
+
+export const fiat = (symbol: string): Asset => ({ type: "FIAT", symbol });
+export const crypto = (symbol: string): Asset => ({
+  type: "CRYPTO",
+  symbol,
+});
+
+/** Midday (UTC) `days` after `start`, `months` calendar months later. */
+export function fixtureDate(start: string, months: number, days = 0): string {
+  const [y, m, d] = start.split("-").map(Number);
+  return new Date(Date.UTC(y, m - 1 + months, d + days, 12)).toISOString();
+}
+
+/** Run `fn` with the given USD prices in place of market rates. */
+export function atPrices<T>(prices: Record<string, number>, fn: () => T): T {
+  return withPriceOverrides(new Map(Object.entries(prices)), fn);
+}
+
+function addEntry(out: FixtureRecord, entry: VaultEntry): void {
+  if (vaultService.ensureVault(entry.vault)) out.vaults.push(entry.vault);
+  vaultService.addVaultEntry(entry);
+  out.vaultEntries++;
+}
+
+/**
+ * Monthly BTC buys into an investment vault, funded from the spending
+ * vault, with a closing valuation a month after the last buy.
+ */
+const dcaVault: FixtureScenario = {
+  name: "dca-vault",
+  description: "Monthly BTC buys into a vault, then a valuation",
+  defaults: { label: "Fixture DCA", months: 12, amount: 500 },
+  async build(opts, out) {
+    const spend = settingsRepository.getDefaultSpendingVaultName();
+    const btc = crypto("BTC");
+    const amount = opts.amount!;
+    if (vaultService.ensureVault(opts.label, ["fixture"])) {
+      out.vaults.push(opts.label);
+    }
+
+    let units = 0;
+    for (let i = 0; i < opts.months; i++) {
+      const at = fixtureDate(opts.start, i);
+      const bought = amount / BTC_PATH[i % BTC_PATH.length];
+      units += bought;
+      addEntry(out, {
+        vault: spend,
+        type: "WITHDRAW",
+        asset: fiat("USD"),
+        amount,
+        usdValue: amount,
+        at,
+        account: opts.label,
+        note: `Transfer to ${opts.label}`,
+      });
+      addEntry(out, {
+        vault: opts.label,
+        type: "DEPOSIT",
+        asset: btc,
+        amount: bought,
+        usdValue: amount,
+        at,
+        account: spend,
+        note: "DCA buy",
+      });
+    }
+
+    const price = BTC_PATH[opts.months % BTC_PATH.length];
+    addEntry(out, {
+      vault: opts.label,
+      type: "VALUATION",
+      asset: btc,
+      amount: units,
+      usdValue: units * price,
+      at: fixtureDate(opts.start, opts.months),
+      note: "DCA valuation",
+    });
+  },
+};
+
+/**
+ * A VND loan taken out and repaid in equal monthly payments until it
+ * closes.
+ */
+const borrowCycle: FixtureScenario = {
+  name: "borrow-cycle",
+  description: "A VND loan repaid monthly until closed",
+  defaults: { label: "Fixture Bank", months: 6, amount: 4000000 },
+  async build(opts, out) {
+    const vnd = fiat("VND");
+    const open = borrowingRepository
+      .findByStatus("ACTIVE")
+      .find((b) => b.counterparty === opts.label);
+    if (open) {
+      throw new ConflictError(
+        `"${opts.label}" already has an active borrowing`,
+        { id: open.id },
+      );
+    }
+
+    const payment = opts.amount!;
+    await atPrices({ VND: VND_USD }, async () => {
+      const borrowing = await borrowingService.createBorrowingAgreement({
+        asset: vnd,
+        principal: payment * opts.months,
+        monthlyPayment: payment,
+        counterparty: opts.label,
+        startAt: fixtureDate(opts.start, 0),
+        firstDueAt: fixtureDate(opts.start, 1),
+        note: "Fixture loan",
+      });
+      out.borrowingIds.push(borrowing.id);
+      const opened = transactionRepository.findBySourceRef(
+        `borrow-open:${borrowing.id}`,
+      );
+      if (opened) out.transactionIds.push(opened.id);
+
+      for (let i = 1; i <= opts.months; i++) {
+        const { transaction } = await borrowingService.recordManualRepayment({
+          counterparty: opts.label,
+          asset: vnd,
+          amount: payment,
+          at: fixtureDate(opts.start, i),
+          note: `Fixture repayment ${i}/${opts.months}`,
+        });
+        if (transaction) out.transactionIds.push(transaction.id);
+      }
+    });
+  },
+};
+
+// Day of the month, asset, amount, category and note of each entry
+const MONTH_INCOME: Array<[number, string, number, string, string]> = [
+  [0, "USD", 3000, "salary", "Salary"],
+  [24, "EUR", 400, "freelance", "Freelance invoice"],
+];
+const MONTH_EXPENSES: Array<[number, string, number, string, string]> = [
+  [1, "VND", 1200000, "groceries", "Supermarket"],
+  [4, "VND", 450000, "dining", "Pho with friends"],
+  [8, "VND", 980000, "groceries", "Market"],
+  [11, "EUR", 240, "travel", "Hotel, Berlin"],
+  [12, "EUR", 56, "dining", "Dinner, Berlin"],
+  [13, "VND", 160000, "transport", "Grab"],
+  [14, "USD", 15.99, "subscriptions", "Streaming"],
+  [19, "VND", 620000, "dining", "Hotpot"],
+];
+
+/**
+ * One month of USD and EUR income with spending in VND, EUR and USD.
+ * The label is the counterparty of every entry.
+ */
+const multiCurrencyMonth: FixtureScenario = {
+  name: "multi-currency-month",
+  description: "A month of USD/EUR income and VND/EUR/USD spending",
+  defaults: { label: "Fixture", months: 1 },
+  async build(opts, out) {
+    const prices = { VND: VND_USD, EUR: EUR_USD };
+    await atPrices(prices, async () => {
+      for (let m = 0; m < opts.months; m++) {
+        for (const [day, symbol, amount, category, note] of MONTH_INCOME) {
+          const tx = await transactionService.createIncomeTransaction({
+            asset: fiat(symbol),
+            amount,
+            at: fixtureDate(opts.start, m, day),
+            note,
+            category,
+            tags: ["fixture", category],
+            counterparty: opts.label,
+          });
+          out.transactionIds.push(tx.id);
+        }
+        for (const [day, symbol, amount, category, note] of MONTH_EXPENSES) {
+          const tx = await transactionService.createExpenseTransaction({
+            asset: fiat(symbol),
+            amount,
+            at: fixtureDate(opts.start, m, day),
+            note,
+            category,
+            tags: ["fixture", category],
+            counterparty: opts.label,
+          });
+          out.transactionIds.push(tx.id);
+        }
+      }
+    });
+  },
+};
+
+export const FIXTURE_SCENARIOS: FixtureScenario[] = [
+  dcaVault,
+  borrowCycle,
+  multiCurrencyMonth,
+];
+
+/**
+ * Canned scenarios for QA and demos, built through the regular services
+ * with fixed dates, amounts and prices so the same call always produces
+ * the same state. Each scenario and label can be loaded once.
+ */
+export class FixturesService {
+  scenarios(): FixtureScenario[] {
+    return FIXTURE_SCENARIOS;
+  }
+
+  async create(
+    name: string,
+    params: FixtureParams,
+  ): Promise<FixtureRecord> {
+    const scenario = FIXTURE_SCENARIOS.find((s) => s.name === name);
+    if (!scenario) throw new NotFoundError("Fixture scenario", name);
+    const opts = this.options(scenario, params);
+
+    const previous = this.log().find(
+      (r) => r.scenario === name && r.label === opts.label,
+    );
+    if (previous) {
+      throw new ConflictError(
+        `Scenario ${name} was already loaded as "${opts.label}"`,
+        { at: previous.at },
+      );
+    }
+
+    const record: FixtureRecord = {
+      scenario: name,
+      label: opts.label,
+      start: opts.start,
+      transactionIds: [],
+      vaultEntries: 0,
+      borrowingIds: [],
+      vaults: [],
+      at: new Date().toISOString(),
+    };
+    try {
+      await scenario.build(opts, record);
+    } finally {
+      // Partial runs are logged too, so they are not doubled by a retry
+      if (record.transactionIds.length || record.vaultEntries) {
+        const log = [...this.log(), record].slice(-LOG_LIMIT);
+        settingsRepository.setSetting(LOG_KEY, JSON.stringify(log));
+      }
+    }
+    logger.info({ fixture: record }, `Loaded fixture ${name}`);
+    return record;
+  }
+
+  /** Scenarios loaded so far, oldest first (the latest 200). */
+  log(): FixtureRecord[] {
+    const raw = settingsRepository.getSetting(LOG_KEY);
+    if (!raw) return [];
+    try {
+      const parsed = JSON.parse(raw);
+      return Array.isArray(parsed) ? parsed : [];
+    } catch {
+      return [];
+    }
+  }
+
+  private options(
+    scenario: FixtureScenario,
+    params: FixtureParams,
+  ): FixtureOptions {
+    const start = params.start || DEFAULT_START;
+    if (!/^\d{4}-\d{2}-\d{2}$/.test(start) || Number.isNaN(Date.parse(start))) {
+      throw new ValidationError("start must be YYYY-MM-DD");
+    }
+    const months = params.months ?? scenario.defaults.months;
+    if (!Number.isInteger(months) || months < 1 || months > MAX_MONTHS) {
+      throw new ValidationError(`months must be 1 to ${MAX_MONTHS}`);
+    }
+    const amount = params.amount ?? scenario.defaults.amount;
+    if (amount !== undefined && !(amount > 0)) {
+      throw new ValidationError("amount must be positive");
+    }
+    return {
+      start,
+      label: params.label?.trim() || scenario.defaults.label,
+      months,
+      amount,
+    };
+  }
+}
+
+export const fixturesService = new FixturesService();
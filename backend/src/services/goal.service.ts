@@ -0,0 +1,332 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  AccountBalance,
+  Goal,
+  GoalCreateRequest,
+  GoalUpdateRequest,
+  Transaction,
+  assetKey,
+  unitsIn,
+} from "../types";
+import { goalRepository, transactionRepository } from "../repositories";
+import { accountBalanceService } from "./account-balance.service";
+import { priceService } from "./price.service";
+import { amountUSD } from "../utils/fx.util";
+import { ValidationError } from "../core/errors";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const DAYS_PER_MONTH = 365.25 / 12;
+const DEFAULT_MONTHS = 6;
+const MAX_MONTHS = 60;
+
+export interface GoalProgress {
+  id: string;
+  name: string;
+  currency: "USD" | "VND";
+  target_date: string | null;
+  target_usd: number;
+  target_vnd: number;
+  funded_usd: number; // accounts plus contributions
+  funded_vnd: number;
+  accounts_usd: number; // what the linked accounts hold today
+  contributions_usd: number; // tagged transactions in other accounts
+  remaining_usd: number; // zero once funded
+  remaining_vnd: number;
+  funded_percentage: number;
+  funded: boolean;
+  avg_monthly_contribution_usd: number; // over the trailing months
+  avg_monthly_contribution_vnd: number;
+  required_monthly_usd: number | null; // to finish by target_date
+  required_monthly_vnd: number | null;
+  projected_completion_date: string | null; // null without contributions
+  on_track: boolean | null; // null without a target date
+  unpriced: string[]; // held assets left out for lack of a rate
+}
+
+export interface GoalReport {
+  months: number; // trailing months averaged
+  goals: GoalProgress[];
+  totals: {
+    target_usd: number;
+    target_vnd: number;
+    funded_usd: number;
+    funded_vnd: number;
+    remaining_usd: number;
+    remaining_vnd: number;
+    funded_percentage: number;
+    avg_monthly_contribution_usd: number;
+    avg_monthly_contribution_vnd: number;
+  };
+}
+
+/** Trailing months to average contributions over; 6 by default. */
+export function goalMonths(months?: string): number {
+  if (months === undefined || months === "") return DEFAULT_MONTHS;
+  const n = Number(months);
+  if (!Number.isInteger(n) || n < 1 || n > MAX_MONTHS) {
+    throw new ValidationError(`months must be 1-${MAX_MONTHS}`);
+  }
+  return n;
+}
+
+const names = (values: string[]) =>
+  values.map((v) => v.trim()).filter(Boolean);
+
+const lower = (values: string[]) =>
+  new Set(values.map((v) => v.toLowerCase()));
+
+// USD added to the goal by a transaction, or undefined when it has no
+// part in it. Moves in a linked account count by direction (opening
+// balances aren't contributions); a tagged transaction elsewhere counts
+// in full.
+function contribution(
+  t: Transaction,
+  accounts: Set<string>,
+  tags: Set<string>,
+): number | undefined {
+  if (accounts.has((t.account || "").toLowerCase())) {
+    if (t.type === "INITIAL") return undefined;
+    return Math.sign(unitsIn(t)) * Math.abs(amountUSD(t));
+  }
+  const tagged = [t.category, ...(t.tags || [])].some(
+    (tag) => !!tag && tags.has(tag.toLowerCase()),
+  );
+  return tagged ? Math.abs(amountUSD(t)) : undefined;
+}
+
+/**
+ * Savings goals. A goal is funded by what its linked accounts hold,
+ * valued at today's prices, plus transactions tagged with one of its
+ * tags in other accounts. Completion is projected from the average
+ * monthly contribution over the trailing complete months.
+ */
+export class GoalService {
+  list(): Goal[] {
+    return goalRepository.findAll();
+  }
+
+  get(id: string): Goal | undefined {
+    return goalRepository.findById(id);
+  }
+
+  create(req: GoalCreateRequest): Goal {
+    const name = req.name.trim();
+    if (!name) throw new ValidationError("name is required");
+    return goalRepository.create({
+      id: uuidv4(),
+      name,
+      targetAmount: req.target_amount,
+      currency: req.currency,
+      targetDate: req.target_date,
+      accounts: names(req.accounts),
+      tags: names(req.tags),
+      note: req.note,
+      active: req.active,
+      createdAt: new Date().toISOString(),
+    });
+  }
+
+  update(id: string, req: GoalUpdateRequest): Goal | undefined {
+    if (!goalRepository.findById(id)) return undefined;
+
+    const updates: Partial<Goal> = { updatedAt: new Date().toISOString() };
+    if (req.name !== undefined) {
+      updates.name = req.name.trim();
+      if (!updates.name) throw new ValidationError("name is required");
+    }
+    if (req.target_amount !== undefined) {
+      updates.targetAmount = req.target_amount;
+    }
+    if (req.currency !== undefined) updates.currency = req.currency;
+    if (req.target_date !== undefined) updates.targetDate = req.target_date;
+    if (req.accounts !== undefined) updates.accounts = names(req.accounts);
+    if (req.tags !== undefined) updates.tags = names(req.tags);
+    if (req.note !== undefined) updates.note = req.note;
+    if (req.active !== undefined) updates.active = req.active;
+    return goalRepository.update(id, updates);
+  }
+
+  delete(id: string): boolean {
+    return goalRepository.delete(id);
+  }
+
+  /** Progress of one goal; see report() for the figures. */
+  async progress(
+    goal: Goal,
+    rateVND: number,
+    months: number = DEFAULT_MONTHS,
+    now: Date = new Date(),
+  ): Promise<GoalProgress> {
+    const [progress] = await this.evaluate([goal], rateVND, months, now);
+    return progress;
+  }
+
+  /**
+   * Progress of every active goal and their totals. Figures are in USD
+   * and in VND at `rateVND`; a VND target is converted at that rate.
+   */
+  async report(
+    rateVND: number,
+    months: number = DEFAULT_MONTHS,
+    now: Date = new Date(),
+  ): Promise<GoalReport> {
+    const goals = await this.evaluate(
+      goalRepository.findAll().filter((g) => g.active),
+      rateVND,
+      months,
+      now,
+    );
+    const vnd = (usd: number) => usd * rateVND;
+    const sum = (f: (g: GoalProgress) => number) =>
+      goals.reduce((s, g) => s + f(g), 0);
+    const target = sum((g) => g.target_usd);
+    const funded = sum((g) => g.funded_usd);
+    const remaining = sum((g) => g.remaining_usd);
+    const monthly = sum((g) => g.avg_monthly_contribution_usd);
+    return {
+      months,
+      goals,
+      totals: {
+        target_usd: target,
+        target_vnd: vnd(target),
+        funded_usd: funded,
+        funded_vnd: vnd(funded),
+        remaining_usd: remaining,
+        remaining_vnd: vnd(remaining),
+        funded_percentage: target > 0 ? (funded / target) * 100 : 0,
+        avg_monthly_contribution_usd: monthly,
+        avg_monthly_contribution_vnd: vnd(monthly),
+      },
+    };
+  }
+
+  private async evaluate(
+    goals: Goal[],
+    rateVND: number,
+    months: number,
+    now: Date,
+  ): Promise<GoalProgress[]> {
+    const balances: AccountBalance[] = goals.some((g) => g.accounts.length)
+      ? accountBalanceService.getBalances({}, now)
+      : [];
+    const transactions = transactionRepository.findAll();
+    const rates = new Map<string, Promise<number | undefined>>();
+    const rateOf = (b: AccountBalance) => {
+      const key = assetKey(b.asset);
+      if (!rates.has(key)) {
+        rates.set(
+          key,
+          priceService
+            .getRateUSD(b.asset)
+            .then((r) => (r.rateUSD > 0 ? r.rateUSD : undefined))
+            .catch(() => undefined),
+        );
+      }
+      return rates.get(key)!;
+    };
+
+    // The trailing complete months, before the one `now` is in
+    const windowEnd = Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), 1);
+    const windowStart = Date.UTC(
+      now.getUTCFullYear(),
+      now.getUTCMonth() - months,
+      1,
+    );
+    const vnd = (usd: number) => usd * rateVND;
+
+    const evaluated: GoalProgress[] = [];
+    for (const goal of goals) {
+      const accounts = lower(goal.accounts);
+      const tags = lower(goal.tags);
+
+      let accountsUSD = 0;
+      const unpriced = new Set<string>();
+      for (const b of balances) {
+        if (!accounts.has(b.account.toLowerCase())) continue;
+        const rate = await rateOf(b);
+        if (rate === undefined) unpriced.add(assetKey(b.asset));
+        else accountsUSD += b.quantity * rate;
+      }
+
+      let contributionsUSD = 0;
+      let trailingUSD = 0;
+      let firstAt = Infinity;
+      for (const t of transactions) {
+        const usd = contribution(t, accounts, tags);
+        if (usd === undefined) continue;
+        const at = Date.parse(t.createdAt);
+        if (Number.isNaN(at) || at > now.getTime()) continue;
+        if (!accounts.has((t.account || "").toLowerCase())) {
+          contributionsUSD += usd;
+        }
+        firstAt = Math.min(firstAt, at);
+        if (at >= windowStart && at < windowEnd) trailingUSD += usd;
+      }
+      // Months before the first contribution don't dilute the average
+      const first = new Date(firstAt);
+      const since = Number.isFinite(firstAt)
+        ? (now.getUTCFullYear() - first.getUTCFullYear()) * 12 +
+          now.getUTCMonth() -
+          first.getUTCMonth()
+        : 0;
+      const averaged = Math.min(months, since);
+      const monthly = averaged > 0 ? trailingUSD / averaged : 0;
+
+      const target =
+        goal.currency === "USD"
+          ? goal.targetAmount
+          : rateVND > 0
+            ? goal.targetAmount / rateVND
+            : 0;
+      const funded = accountsUSD + contributionsUSD;
+      const remaining = Math.max(0, target - funded);
+      const done = remaining === 0;
+
+      let projected: string | null = null;
+      if (done) projected = now.toISOString().slice(0, 10);
+      else if (monthly > 0) {
+        const days = (remaining / monthly) * DAYS_PER_MONTH;
+        projected = new Date(now.getTime() + Math.ceil(days) * DAY_MS)
+          .toISOString()
+          .slice(0, 10);
+      }
+
+      let required: number | null = null;
+      if (goal.targetDate && !done) {
+        const end = Date.parse(`${goal.targetDate}T23:59:59.999Z`);
+        const monthsLeft = (end - now.getTime()) / DAY_MS / DAYS_PER_MONTH;
+        // All of it at once when the target date is under a month away
+        required = monthsLeft > 1 ? remaining / monthsLeft : remaining;
+      }
+
+      evaluated.push({
+        id: goal.id,
+        name: goal.name,
+        currency: goal.currency,
+        target_date: goal.targetDate ?? null,
+        target_usd: target,
+        target_vnd: vnd(target),
+        funded_usd: funded,
+        funded_vnd: vnd(funded),
+        accounts_usd: accountsUSD,
+        contributions_usd: contributionsUSD,
+        remaining_usd: remaining,
+        remaining_vnd: vnd(remaining),
+        funded_percentage: target > 0 ? (funded / target) * 100 : 0,
+        funded: done,
+        avg_monthly_contribution_usd: monthly,
+        avg_monthly_contribution_vnd: vnd(monthly),
+        required_monthly_usd: required,
+        required_monthly_vnd: required === null ? null : vnd(required),
+        projected_completion_date: projected,
+        on_track: goal.targetDate
+          ? projected !== null && projected <= goal.targetDate
+          : null,
+        unpriced: [...unpriced],
+      });
+    }
+    return evaluated;
+  }
+}
+
+export const goalService = new GoalService();
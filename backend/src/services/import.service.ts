@@ -0,0 +1,523 @@
+import XLSX from "xlsx";
+import { createHash } from "crypto";
+import { v4 as uuidv4 } from "uuid";
+import {
+  ImportProfile,
+  StatementImportRequest,
+  TableImportRequest,
+  Transaction,
+  TransactionImportRequest,
+} from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { transactionService } from "./transaction.service";
+import { precisionService } from "./precision.service";
+import { vaultService } from "./vault.service";
+import { accountDefaultsService } from "./account-defaults.service";
+import { addressBookService } from "./address-book.service";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { parseCsv } from "../utils/csv.util";
+import {
+  StatementEntry,
+  parseOfx,
+  parseQif,
+} from "../utils/bank-statement.util";
+import { applyRules } from "../utils/transaction-rules.util";
+import { ValidationError } from "../core/errors";
+
+type Cell = string | number | Date;
+type ImportField = keyof ImportProfile["columns"];
+
+export interface ImportRowError {
+  row: number; // 1-based row as a spreadsheet shows it; entry for OFX/QIF
+  errors: string[];
+}
+
+// A statement entry already in the ledger, from an earlier overlapping export
+export interface ImportDuplicate {
+  row: number;
+  sourceRef: string;
+  transactionId?: string; // undefined when repeated within the file
+}
+
+export interface ImportResult {
+  rows: number; // data rows read, blank rows skipped
+  created: number;
+  transactions: Transaction[];
+  errors: ImportRowError[];
+  duplicates?: ImportDuplicate[]; // OFX/QIF only; skipped, not errors
+  suggestedTags?: Record<string, string[]>; // OFX/QIF, by transaction id
+}
+
+// Tags used on at least this share of a counterparty's past transactions
+const TAG_SUGGESTION_SHARE = 0.5;
+const MAX_SUGGESTED_TAGS = 3;
+
+// Payees carry card numbers, dates and branch codes: "GRAB*FOOD 0412"
+const payeeKey = (s: string): string =>
+  s
+    .toLowerCase()
+    .replace(/[^\p{L}\p{N}]+/gu, " ")
+    .split(" ")
+    .filter((w) => w && !/\d/.test(w))
+    .join(" ");
+
+interface CounterpartyHistory {
+  name: string;
+  count: number;
+  tags: Map<string, number>;
+}
+
+const text = (c: Cell | undefined): string =>
+  c instanceof Date ? c.toISOString() : String(c ?? "").trim();
+
+function parseAmount(c: Cell | undefined, decimalSeparator: string): number {
+  if (typeof c === "number") return c;
+  let s = text(c).replace(/\s/g, "");
+  if (!s) return NaN;
+  // Accounting negatives: (1,000) means -1000
+  const negative = /^\(.*\)$/.test(s);
+  s = s.replace(/^\((.*)\)$/, "$1");
+  s =
+    decimalSeparator === ","
+      ? s.replace(/\./g, "").replace(",", ".")
+      : s.replace(/,/g, "");
+  const n = Number(s);
+  return negative ? -n : n;
+}
+
+// Date-only values are taken as 00:00 UTC, like the create endpoints do
+function parseDate(
+  c: Cell | undefined,
+  format: ImportProfile["dateFormat"],
+): string | undefined {
+  if (c instanceof Date) {
+    return isNaN(c.getTime()) ? undefined : c.toISOString();
+  }
+  const s = text(c);
+  let y: number, m: number, d: number;
+  const dmy = /^(\d{1,2})[/.-](\d{1,2})[/.-](\d{4})$/.exec(s);
+  if (format !== "YYYY-MM-DD" && dmy) {
+    [d, m] = format === "DD/MM/YYYY" ? [+dmy[1], +dmy[2]] : [+dmy[2], +dmy[1]];
+    y = +dmy[3];
+  } else if (/^\d{4}-\d{2}-\d{2}$/.test(s)) {
+    [y, m, d] = s.split("-").map(Number);
+  } else {
+    const full = new Date(s);
+    return /^\d{4}-\d{2}-\d{2}T/.test(s) && !isNaN(full.getTime())
+      ? full.toISOString()
+      : undefined;
+  }
+  const at = new Date(Date.UTC(y, m - 1, d));
+  return at.getUTCMonth() === m - 1 && at.getUTCDate() === d
+    ? at.toISOString()
+    : undefined;
+}
+
+/**
+ * Bulk import of bank statements and exchange exports. A profile maps the
+ * file's columns onto transaction fields; every row is validated and priced
+ * first, and the batch is only written when all rows pass.
+ */
+export class ImportService {
+  private readRows(req: TableImportRequest): Cell[][] {
+    if (req.format === "csv") {
+      return parseCsv(req.content, req.profile.delimiter);
+    }
+    const wb = XLSX.read(Buffer.from(req.content, "base64"), {
+      type: "buffer",
+      cellDates: true,
+    });
+    const name = req.profile.sheet ?? wb.SheetNames[0];
+    const sheet = name ? wb.Sheets[name] : undefined;
+    if (!sheet) throw new ValidationError(`Sheet "${name}" not found`);
+    return XLSX.utils.sheet_to_json<Cell[]>(sheet, {
+      header: 1,
+      raw: true,
+      defval: "",
+    });
+  }
+
+  async import(req: TransactionImportRequest): Promise<ImportResult> {
+    if (req.format === "ofx" || req.format === "qif") {
+      return this.importStatement(req);
+    }
+    const { profile } = req;
+    const rows = this.readRows(req);
+    const header = (rows[profile.headerRow - 1] || []).map((h) =>
+      text(h).toLowerCase(),
+    );
+
+    const index = new Map<ImportField, number>();
+    const missing: string[] = [];
+    for (const [field, column] of Object.entries(profile.columns)) {
+      if (!column) continue;
+      const i = header.indexOf(column.trim().toLowerCase());
+      if (i === -1) missing.push(column);
+      else index.set(field as ImportField, i);
+    }
+    if (missing.length) {
+      throw new ValidationError(
+        `Column(s) not found in row ${profile.headerRow}: ` +
+          missing.join(", "),
+        { header },
+      );
+    }
+
+    const seenRefs = new Map<string, number>();
+    const transactions: Transaction[] = [];
+    const errors: ImportRowError[] = [];
+    let count = 0;
+
+    for (let r = profile.headerRow; r < rows.length; r++) {
+      const cells = rows[r];
+      if (!cells.some((c) => text(c))) continue;
+      count++;
+      const line = r + 1;
+      const get = (f: ImportField) => {
+        const i = index.get(f);
+        return i === undefined ? undefined : cells[i];
+      };
+      const rowErrors: string[] = [];
+
+      const at = parseDate(get("date"), profile.dateFormat);
+      if (!at) rowErrors.push(`invalid date "${text(get("date"))}"`);
+
+      let amount: number;
+      let type: "INCOME" | "EXPENSE";
+      if (index.has("amount")) {
+        const n = parseAmount(get("amount"), profile.decimalSeparator);
+        amount = Math.abs(n);
+        type = n < 0 ? "EXPENSE" : "INCOME";
+        if (!Number.isFinite(n)) rowErrors.push("invalid amount");
+      } else {
+        const debit = parseAmount(get("debit"), profile.decimalSeparator);
+        const credit = parseAmount(get("credit"), profile.decimalSeparator);
+        const hasDebit = Number.isFinite(debit) && debit !== 0;
+        const hasCredit = Number.isFinite(credit) && credit !== 0;
+        amount = Math.abs(hasDebit ? debit : credit);
+        type = hasDebit ? "EXPENSE" : "INCOME";
+        if (hasDebit === hasCredit) {
+          rowErrors.push("expected exactly one of debit or credit");
+        }
+      }
+      const typeCell = text(get("type")).toUpperCase();
+      if (typeCell === "INCOME" || typeCell === "EXPENSE") {
+        type = typeCell;
+      } else if (typeCell) {
+        rowErrors.push(`type must be INCOME or EXPENSE, got "${typeCell}"`);
+      }
+      if (Number.isFinite(amount) && amount <= 0) {
+        rowErrors.push("amount must be positive");
+      }
+
+      const account =
+        text(get("account")) ||
+        profile.account ||
+        (type === "EXPENSE"
+          ? settingsRepository.getDefaultSpendingVaultName()
+          : settingsRepository.getDefaultIncomeVaultName());
+      const symbol = text(get("asset")) || profile.asset;
+      const asset = symbol
+        ? createAssetFromSymbol(symbol.toUpperCase())
+        : accountDefaultsService.defaultAsset(account);
+      if (!asset) rowErrors.push("asset is required");
+      else if (amount > 0) {
+        try {
+          precisionService.validate(asset, amount);
+        } catch (e: any) {
+          rowErrors.push(e.message);
+        }
+      }
+
+      const note = text(get("note")) || undefined;
+      const counterparty = text(get("counterparty")) || undefined;
+      if (!note && !counterparty) {
+        rowErrors.push("note or counterparty is required");
+      }
+
+      const sourceRef = text(get("reference")) || undefined;
+      if (sourceRef) {
+        const first = seenRefs.get(sourceRef);
+        if (first) {
+          rowErrors.push(`reference ${sourceRef} repeats row ${first}`);
+        } else if (
+          transactionRepository.findBySourceRef(sourceRef, {
+            includeDeleted: true,
+          })
+        ) {
+          rowErrors.push(`reference ${sourceRef} was already imported`);
+        }
+        seenRefs.set(sourceRef, first ?? line);
+      }
+
+      if (rowErrors.length || !asset || !at) {
+        errors.push({ row: line, errors: rowErrors });
+        continue;
+      }
+
+      try {
+        // Fills the FX rate from the price providers, like single creates
+        const base = await transactionService.buildTransactionBase(
+          asset,
+          amount,
+          at,
+          account,
+          type,
+        );
+        const tags = text(get("tags"))
+          .split(/[,;]/)
+          .map((t) => t.trim())
+          .filter(Boolean);
+        transactions.push({
+          id: uuidv4(),
+          type,
+          note,
+          category: text(get("category")) || undefined,
+          tags: tags.length ? tags : undefined,
+          counterparty,
+          member: text(get("member")) || undefined,
+          sourceRef,
+          ...base,
+        } as Transaction);
+      } catch (e: any) {
+        errors.push({ row: line, errors: [e?.message || "Invalid row"] });
+      }
+    }
+
+    if (errors.length || req.dryRun) {
+      return {
+        rows: count,
+        created: 0,
+        transactions: errors.length ? [] : this.preview(transactions),
+        errors,
+      };
+    }
+
+    this.save(transactions);
+    return {
+      rows: count,
+      created: transactions.length,
+      transactions,
+      errors,
+    };
+  }
+
+  /**
+   * Import an OFX or QIF export. Entries already imported (same FITID, or
+   * same content hash when the file has none) are skipped, so overlapping
+   * exports can be imported again. Payees map onto known counterparties,
+   * and tags are suggested from how each counterparty was tagged before.
+   */
+  async importStatement(req: StatementImportRequest): Promise<ImportResult> {
+    const entries =
+      req.format === "ofx"
+        ? parseOfx(req.content)
+        : parseQif(req.content, req.dateFormat);
+    if (!entries.length) {
+      throw new ValidationError(
+        `No transactions found in the ${req.format.toUpperCase()} file`,
+      );
+    }
+
+    const history = this.counterpartyHistory();
+    const refs = this.statementRefs(entries);
+    const seenRefs = new Set<string>();
+    const transactions: Transaction[] = [];
+    const duplicates: ImportDuplicate[] = [];
+    const suggestedTags: Record<string, string[]> = {};
+    const errors: ImportRowError[] = [];
+
+    for (const [i, e] of entries.entries()) {
+      const row = i + 1;
+      const sourceRef = refs[i];
+      const existing = transactionRepository.findBySourceRef(sourceRef, {
+        includeDeleted: true,
+      });
+      if (existing || seenRefs.has(sourceRef)) {
+        duplicates.push({ row, sourceRef, transactionId: existing?.id });
+        continue;
+      }
+      seenRefs.add(sourceRef);
+
+      const rowErrors: string[] = [];
+      if (!e.date) rowErrors.push(`invalid date "${e.rawDate}"`);
+      if (!Number.isFinite(e.amount)) rowErrors.push("invalid amount");
+      else if (e.amount === 0) rowErrors.push("amount must not be zero");
+      const amount = Math.abs(e.amount);
+      const type = e.amount < 0 ? "EXPENSE" : "INCOME";
+
+      const account =
+        req.account ||
+        (type === "EXPENSE"
+          ? settingsRepository.getDefaultSpendingVaultName()
+          : settingsRepository.getDefaultIncomeVaultName());
+      const symbol = req.asset || e.currency;
+      const asset = symbol
+        ? createAssetFromSymbol(symbol.toUpperCase())
+        : accountDefaultsService.defaultAsset(account);
+      if (!asset) rowErrors.push("asset is required");
+      else if (amount > 0) {
+        try {
+          precisionService.validate(asset, amount);
+        } catch (err: any) {
+          rowErrors.push(err.message);
+        }
+      }
+
+      const counterparty = e.payee
+        ? this.matchCounterparty(e.payee, history)
+        : undefined;
+      const note = e.memo || e.payee;
+      if (!note && !counterparty) {
+        rowErrors.push("payee or memo is required");
+      }
+
+      if (rowErrors.length || !asset || !e.date) {
+        errors.push({ row, errors: rowErrors });
+        continue;
+      }
+
+      try {
+        const base = await transactionService.buildTransactionBase(
+          asset,
+          amount,
+          e.date,
+          account,
+          type,
+        );
+        const suggested = counterparty
+          ? this.suggestTags(history.get(counterparty.toLowerCase()))
+          : [];
+        const tx = {
+          id: uuidv4(),
+          type,
+          note,
+          category: e.category,
+          tags:
+            req.applySuggestedTags && suggested.length ? suggested : undefined,
+          counterparty,
+          sourceRef,
+          ...base,
+        } as Transaction;
+        if (suggested.length) suggestedTags[tx.id] = suggested;
+        transactions.push(tx);
+      } catch (err: any) {
+        errors.push({ row, errors: [err?.message || "Invalid entry"] });
+      }
+    }
+
+    const result = {
+      rows: entries.length,
+      duplicates,
+      suggestedTags,
+      errors,
+    };
+    if (errors.length || req.dryRun) {
+      return {
+        ...result,
+        created: 0,
+        transactions: errors.length ? [] : this.preview(transactions),
+      };
+    }
+    this.save(transactions);
+    return { ...result, created: transactions.length, transactions };
+  }
+
+  // FITID when the bank gives one, else a hash of the entry's content;
+  // the nth identical entry gets its own hash so real repeats survive
+  private statementRefs(entries: StatementEntry[]): string[] {
+    const occurrences = new Map<string, number>();
+    return entries.map((e) => {
+      if (e.fitid) return e.fitid;
+      const content = [e.date ?? e.rawDate, e.amount, e.payee, e.memo]
+        .map((v) => String(v ?? "").trim().toLowerCase())
+        .join("|");
+      const n = (occurrences.get(content) ?? 0) + 1;
+      occurrences.set(content, n);
+      const hash = createHash("sha1")
+        .update(`${content}|${n}`)
+        .digest("hex")
+        .slice(0, 16);
+      return `stmt:${hash}`;
+    });
+  }
+
+  // Past counterparties by lowercase name, with how often each tag was used
+  private counterpartyHistory(): Map<string, CounterpartyHistory> {
+    const history = new Map<string, CounterpartyHistory>();
+    for (const t of transactionRepository.findAll()) {
+      const name = t.counterparty?.trim();
+      if (!name) continue;
+      const h = history.get(name.toLowerCase()) || {
+        name,
+        count: 0,
+        tags: new Map<string, number>(),
+      };
+      h.count++;
+      for (const tag of new Set(t.tags || [])) {
+        h.tags.set(tag, (h.tags.get(tag) ?? 0) + 1);
+      }
+      history.set(name.toLowerCase(), h);
+    }
+    return history;
+  }
+
+  /**
+   * The counterparty a statement payee stands for: a past counterparty
+   * whose name matches once card numbers and codes are stripped, else an
+   * address book entry, else the payee as the bank wrote it.
+   */
+  private matchCounterparty(
+    payee: string,
+    history: Map<string, CounterpartyHistory>,
+  ): string {
+    const exact = history.get(payee.trim().toLowerCase());
+    if (exact) return exact.name;
+    const key = payeeKey(payee);
+    if (key) {
+      for (const h of history.values()) {
+        if (payeeKey(h.name) === key) return h.name;
+      }
+    }
+    return addressBookService.resolve(payee)?.name ?? payee.trim();
+  }
+
+  private suggestTags(h: CounterpartyHistory | undefined): string[] {
+    if (!h) return [];
+    return [...h.tags]
+      .filter(([, n]) => n / h.count >= TAG_SUGGESTION_SHARE)
+      .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+      .slice(0, MAX_SUGGESTED_TAGS)
+      .map(([tag]) => tag);
+  }
+
+  // A dry run shows transactions as the rules would save them
+  private preview(transactions: Transaction[]): Transaction[] {
+    const rules = settingsRepository.getTransactionRules();
+    if (rules.length) for (const t of transactions) applyRules(t, rules);
+    return transactions;
+  }
+
+  private save(transactions: Transaction[]): void {
+    transactionRepository.createMany(transactions);
+    // Expenses paid from Spend withdraw from its vault, as single creates do
+    for (const tx of transactions) {
+      if (tx.type !== "EXPENSE" || tx.account?.toLowerCase() !== "spend") {
+        continue;
+      }
+      vaultService.ensureVault("Spend");
+      vaultService.addVaultEntry({
+        vault: "Spend",
+        type: "WITHDRAW",
+        asset: tx.asset,
+        amount: tx.amount,
+        usdValue: tx.usdAmount,
+        at: tx.createdAt,
+        account: tx.account,
+        note: tx.note ? `Expense: ${tx.note}` : "Expense",
+      });
+    }
+  }
+}
+
+export const importService = new ImportService();
@@ -0,0 +1,171 @@
+import { TaxBracket, Transaction } from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { incomeKind } from "./tax.service";
+import { fiscalYearRange } from "../utils/fiscal-year.util";
+import { amountVND } from "../utils/fx.util";
+
+export type WithholdingStatus = "OVER_WITHHELD" | "UNDER_WITHHELD" | "SETTLED";
+
+// Tax due on the part of taxable income that falls in one bracket
+export interface TaxBracketShare {
+  from: number;
+  upTo: number | null;
+  ratePercent: number;
+  taxable: number;
+  tax: number;
+}
+
+// A transaction counted as taxable income or as tax paid, in VND
+export interface IncomeTaxItem {
+  id: string;
+  at: string;
+  account?: string;
+  category?: string;
+  amountVND: number;
+  note?: string;
+}
+
+export interface IncomeTaxEstimate {
+  year: number; // fiscal year, named by the year it starts in
+  start: string;
+  end: string;
+  dependents: number;
+  incomeVND: number;
+  deductions: { personalVND: number; dependentsVND: number; totalVND: number };
+  taxableVND: number;
+  brackets: TaxBracketShare[];
+  expectedTaxVND: number;
+  paidTaxVND: number;
+  differenceVND: number; // paid minus expected; > 0 is over-withheld
+  status: WithholdingStatus;
+  effectiveRatePercent: number; // expected tax over gross income
+  income: IncomeTaxItem[];
+  payments: IncomeTaxItem[];
+}
+
+// Differences under 1,000 VND are rounding, not a balance due
+const TOLERANCE_VND = 1000;
+
+/** Progressive tax on `taxable`, bracket by bracket. */
+export function progressiveTax(
+  taxable: number,
+  brackets: TaxBracket[],
+): { tax: number; shares: TaxBracketShare[] } {
+  const shares: TaxBracketShare[] = [];
+  let from = 0;
+  for (const b of brackets) {
+    const top = b.upTo === null ? Infinity : b.upTo;
+    const inBracket = Math.max(0, Math.min(taxable, top) - from);
+    shares.push({
+      from,
+      upTo: b.upTo,
+      ratePercent: b.ratePercent,
+      taxable: inBracket,
+      tax: (inBracket * b.ratePercent) / 100,
+    });
+    if (b.upTo === null) break;
+    from = b.upTo;
+  }
+  return { tax: shares.reduce((s, x) => s + x.tax, 0), shares };
+}
+
+const labels = (t: Transaction) =>
+  [t.category, ...(t.tags || [])]
+    .filter((l): l is string => !!l)
+    .map((l) => l.toLowerCase());
+
+export class IncomeTaxService {
+  /**
+   * Expected personal income tax for a fiscal year against the tax
+   * actually paid, in VND.
+   *
+   * Gross income is every INCOME in the year except investment income
+   * (dividends, interest, staking; taxed at flat rates and covered by the
+   * tax report), refunds of reimbursable expenses and internal flows.
+   * With `incomeTags` set, only income carrying one of them counts. The
+   * personal and dependent deductions come off before the brackets.
+   * Tax paid is every EXPENSE tagged with the tax tag. VND amounts are
+   * taken as recorded, other currencies at the VND rate snapshotted with
+   * the transaction, else at `rateVND` per USD.
+   */
+  estimate(
+    year: number,
+    rateVND: number,
+    dependents?: number,
+  ): IncomeTaxEstimate {
+    const settings = settingsRepository.getIncomeTaxSettings();
+    const { start, end } = fiscalYearRange(
+      year,
+      settingsRepository.getFiscalYear(),
+    );
+    const incomeTags = settings.incomeTags.map((t) => t.toLowerCase());
+    const taxTag = settings.taxTag.toLowerCase();
+    const toVND = (t: Transaction) => amountVND(t, rateVND);
+    const item = (t: Transaction): IncomeTaxItem => ({
+      id: t.id,
+      at: t.createdAt,
+      account: t.account,
+      category: t.category,
+      amountVND: toVND(t),
+      note: t.note,
+    });
+
+    const income: IncomeTaxItem[] = [];
+    const payments: IncomeTaxItem[] = [];
+    for (const t of transactionRepository.findAll()) {
+      if (t.createdAt < start || t.createdAt > end || t.internalFlow) {
+        continue;
+      }
+      if (t.type === "INCOME") {
+        if (t.reimbursesId || incomeKind(t.category)) continue;
+        const l = labels(t);
+        if (incomeTags.length && !incomeTags.some((x) => l.includes(x))) {
+          continue;
+        }
+        income.push(item(t));
+      } else if (t.type === "EXPENSE" && labels(t).includes(taxTag)) {
+        payments.push(item(t));
+      }
+    }
+    income.sort((a, b) => a.at.localeCompare(b.at));
+    payments.sort((a, b) => a.at.localeCompare(b.at));
+
+    const n = dependents ?? settings.dependents;
+    const personalVND = settings.personalDeduction;
+    const dependentsVND = settings.dependentDeduction * n;
+    const incomeVND = income.reduce((s, i) => s + i.amountVND, 0);
+    const taxableVND = Math.max(0, incomeVND - personalVND - dependentsVND);
+    const { tax, shares } = progressiveTax(taxableVND, settings.brackets);
+    const paidTaxVND = payments.reduce((s, p) => s + p.amountVND, 0);
+    const differenceVND = paidTaxVND - tax;
+
+    return {
+      year,
+      start,
+      end,
+      dependents: n,
+      incomeVND,
+      deductions: {
+        personalVND,
+        dependentsVND,
+        totalVND: personalVND + dependentsVND,
+      },
+      taxableVND,
+      brackets: shares,
+      expectedTaxVND: tax,
+      paidTaxVND,
+      differenceVND,
+      status:
+        differenceVND > TOLERANCE_VND
+          ? "OVER_WITHHELD"
+          : differenceVND < -TOLERANCE_VND
+            ? "UNDER_WITHHELD"
+            : "SETTLED",
+      effectiveRatePercent: incomeVND > 0 ? (tax / incomeVND) * 100 : 0,
+      income,
+      payments,
+    };
+  }
+}
+
+export const incomeTaxService = new IncomeTaxService();
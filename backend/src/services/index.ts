@@ -4,3 +4,66 @@ export * from "./vault.service";
 export * from "./transaction.service";
 export * from "./financial.service";
 export * from "./price.service";
+export * from "./price-provider.service";
+export * from "./asset-price.service";
+export * from "./project.service";
+export * from "./registry.service";
+export * from "./risk.service";
+export * from "./snapshot.service";
+export * from "./purge.service";
+export * from "./fixed-income.service";
+export * from "./option.service";
+export * from "./vesting.service";
+export * from "./address-book.service";
+export * from "./precision.service";
+export * from "./stablecoin.service";
+export * from "./allocation.service";
+export * from "./activity.service";
+export * from "./trash.service";
+export * from "./notification.service";
+export * from "./report-subscription.service";
+export * from "./backup.service";
+export * from "./transaction-link.service";
+export * from "./stake.service";
+export * from "./statement.service";
+export * from "./maintenance.service";
+export * from "./enrichment.service";
+export * from "./deposit-rate.service";
+export * from "./cash-drag.service";
+export * from "./warning.service";
+export * from "./import.service";
+export * from "./account-defaults.service";
+export * from "./merge.service";
+export * from "./rename.service";
+export * from "./rule.service";
+export * from "./recurring.service";
+export * from "./budget.service";
+export * from "./savings-rate.service";
+export * from "./networth.service";
+export * from "./fixtures.service";
+export * from "./job.service";
+export * from "./event-bus.service";
+export * from "./webhook.service";
+export * from "./cost-basis.service";
+export * from "./sheets-export.service";
+export * from "./connector.service";
+export * from "./binance.service";
+export * from "./evm-explorer.service";
+export * from "./wallet.service";
+export * from "./account-performance.service";
+export * from "./tax.service";
+export * from "./income-tax.service";
+export * from "./benchmark.service";
+export * from "./price-snapshot.service";
+export * from "./year-close.service";
+export * from "./transaction-search.service";
+export * from "./month-review.service";
+export * from "./audit.service";
+export * from "./cash.service";
+export * from "./consistency.service";
+export * from "./account-balance.service";
+export * from "./recalc.service";
+export * from "./action.service";
+export * from "./counterparty.service";
+export * from "./goal.service";
+export * from "./stale-account.service";
@@ -0,0 +1,137 @@
+import { v4 as uuidv4 } from "uuid";
+import { logger } from "../utils/logger";
+
+const KEEP_FINISHED = 50;
+const FINISHED_TTL_MS = 60 * 60 * 1000;
+
+export type JobStatus =
+  | "queued"
+  | "running"
+  | "succeeded"
+  | "failed"
+  | "cancelled";
+
+export interface Job {
+  id: string;
+  kind: string; // e.g. "networth", "price-backfill"
+  status: JobStatus;
+  progress: number; // percentage, 0-100
+  stage?: string; // what the job is doing right now
+  result?: unknown;
+  error?: string;
+  cancelRequested: boolean;
+  createdAt: string;
+  startedAt?: string;
+  finishedAt?: string;
+}
+
+export class JobCancelledError extends Error {
+  constructor() {
+    super("Job cancelled");
+  }
+}
+
+/** Handed to a running job to report progress and notice cancellation. */
+export interface JobContext {
+  progress(percentage: number, stage?: string): void;
+  /**
+   * Yield to the event loop (so progress and cancel requests are served)
+   * and throw JobCancelledError once the job has been cancelled.
+   */
+  checkpoint(): Promise<void>;
+}
+
+const isFinished = (j: Job) =>
+  j.status === "succeeded" || j.status === "failed" || j.status === "cancelled";
+
+/**
+ * Background jobs for heavyweight work (full-history reports, price
+ * backfills). Jobs run in this process and are kept in memory: the latest
+ * finished ones stay readable for an hour, and a restart forgets them.
+ */
+export class JobService {
+  private jobs = new Map<string, Job>();
+
+  start<T>(kind: string, run: (ctx: JobContext) => Promise<T>): Job {
+    this.prune();
+    const job: Job = {
+      id: uuidv4(),
+      kind,
+      status: "queued",
+      progress: 0,
+      cancelRequested: false,
+      createdAt: new Date().toISOString(),
+    };
+    this.jobs.set(job.id, job);
+
+    const ctx: JobContext = {
+      progress: (percentage, stage) => {
+        job.progress = Math.max(0, Math.min(100, percentage));
+        if (stage !== undefined) job.stage = stage;
+      },
+      checkpoint: async () => {
+        await new Promise((resolve) => setImmediate(resolve));
+        if (job.cancelRequested) throw new JobCancelledError();
+      },
+    };
+
+    setImmediate(async () => {
+      if (job.cancelRequested) return this.finish(job, "cancelled");
+      job.status = "running";
+      job.startedAt = new Date().toISOString();
+      try {
+        job.result = await run(ctx);
+        job.progress = 100;
+        this.finish(job, "succeeded");
+      } catch (err: any) {
+        if (err instanceof JobCancelledError) {
+          return this.finish(job, "cancelled");
+        }
+        job.error = err?.message || String(err);
+        logger.warn({ job: job.id, kind, error: job.error }, "Job failed");
+        this.finish(job, "failed");
+      }
+    });
+    return job;
+  }
+
+  get(id: string): Job | undefined {
+    return this.jobs.get(id);
+  }
+
+  /** Jobs newest first, optionally of one kind. */
+  list(kind?: string): Job[] {
+    this.prune();
+    return Array.from(this.jobs.values())
+      .filter((j) => !kind || j.kind === kind)
+      .reverse();
+  }
+
+  /**
+   * Ask a job to stop. It stops at its next checkpoint; a queued job
+   * never starts. Returns undefined for an unknown job.
+   */
+  cancel(id: string): Job | undefined {
+    const job = this.jobs.get(id);
+    if (job && !isFinished(job)) job.cancelRequested = true;
+    return job;
+  }
+
+  private finish(job: Job, status: JobStatus): void {
+    job.status = status;
+    job.finishedAt = new Date().toISOString();
+  }
+
+  // Drop finished jobs past the TTL, and the oldest beyond the cap
+  private prune(now = Date.now()): void {
+    const finished = Array.from(this.jobs.values()).filter(isFinished);
+    finished.forEach((j, i) => {
+      const age = now - Date.parse(j.finishedAt!);
+      if (age > FINISHED_TTL_MS || i < finished.length - KEEP_FINISHED) {
+        this.jobs.delete(j.id);
+      }
+    });
+  }
+}
+
+export const jobService = new JobService();
@@ -0,0 +1,119 @@
+import {
+  maintenanceRepository,
+  DatabaseSize,
+  TableSize,
+} from "../repositories/maintenance.repository";
+import { StatementCacheStats } from "../database/connection";
+import { priceService } from "./price.service";
+import { logger } from "../utils/logger";
+
+const MAINTENANCE_INTERVAL_MS = 24 * 60 * 60 * 1000; // daily
+// Placeholder rates are kept a day so an outage doesn't cause a retry storm
+const PLACEHOLDER_MAX_AGE_MS = 24 * 60 * 60 * 1000;
+// Reclaim space once this share of the file is free pages
+const VACUUM_FREE_RATIO = 0.2;
+// Tables read on most requests; their statistics drive index choice
+export const HOT_TABLES = [
+  "transactions",
+  "vault_entries",
+  "price_cache",
+  "pending_actions",
+];
+let schedulerStarted = false;
+
+export interface MaintenanceRun {
+  startedAt: string;
+  finishedAt: string;
+  prunedPriceRows: number;
+  analyzedTables: string[];
+  vacuumed: boolean;
+  freedBytes: number;
+  error?: string;
+}
+
+let lastRun: MaintenanceRun | undefined;
+
+export class MaintenanceService {
+  /**
+   * Prune placeholder price rows, refresh statistics on hot tables and
+   * VACUUM when enough of the file is free pages. Failures are recorded
+   * on the run rather than thrown, like the other schedulers.
+   */
+  run(now: Date = new Date()): MaintenanceRun {
+    const run: MaintenanceRun = {
+      startedAt: now.toISOString(),
+      finishedAt: now.toISOString(),
+      prunedPriceRows: 0,
+      analyzedTables: [],
+      vacuumed: false,
+      freedBytes: 0,
+    };
+    try {
+      run.prunedPriceRows = priceService.pruneCache(
+        new Date(now.getTime() - PLACEHOLDER_MAX_AGE_MS),
+      );
+      run.analyzedTables = maintenanceRepository.analyze(HOT_TABLES);
+
+      const before = maintenanceRepository.databaseSize();
+      if (
+        before.pageCount > 0 &&
+        before.freelistCount / before.pageCount >= VACUUM_FREE_RATIO
+      ) {
+        maintenanceRepository.vacuum();
+        const after = maintenanceRepository.databaseSize();
+        run.vacuumed = true;
+        run.freedBytes = Math.max(0, before.sizeBytes - after.sizeBytes);
+      }
+    } catch (e: any) {
+      run.error = e?.message || String(e);
+    }
+    run.finishedAt = new Date().toISOString();
+    lastRun = run;
+    return run;
+  }
+
+  getLastRun(): MaintenanceRun | undefined {
+    return lastRun;
+  }
+
+  status(): {
+    lastRun?: MaintenanceRun;
+    database: DatabaseSize;
+    tables: TableSize[];
+    statementCache: StatementCacheStats;
+  } {
+    return {
+      lastRun,
+      database: maintenanceRepository.databaseSize(),
+      statementCache: maintenanceRepository.statementCache(),
+      tables: maintenanceRepository
+        .tableSizes()
+        .sort((a, b) => (b.bytes ?? 0) - (a.bytes ?? 0) || b.rows - a.rows),
+    };
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      const r = this.run();
+      if (r.error) {
+        logger.warn({ error: r.error }, "Database maintenance failed");
+      } else {
+        logger.info(
+          {
+            prunedPriceRows: r.prunedPriceRows,
+            vacuumed: r.vacuumed,
+            freedBytes: r.freedBytes,
+          },
+          "Database maintenance complete",
+        );
+      }
+    };
+    run();
+    setInterval(run, MAINTENANCE_INTERVAL_MS);
+  }
+}
+
+export const maintenanceService = new MaintenanceService();
@@ -0,0 +1,100 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  adminRepository,
+  mergeRepository,
+  settingsRepository,
+  MergeCounts,
+} from "../repositories";
+import { NotFoundError, ValidationError } from "../core/errors";
+import { accountBalanceService } from "./account-balance.service";
+import { renameService } from "./rename.service";
+import { logger } from "../utils/logger";
+import { invalidateAssetKinds } from "../utils/asset.util";
+
+export type MergeKind = "account" | "asset" | "tag";
+export const MERGE_KINDS: MergeKind[] = ["account", "asset", "tag"];
+
+export interface MergeRecord {
+  id: string;
+  kind: MergeKind;
+  from: string; // name (or symbol) of the removed entry
+  into: string;
+  fromId: number;
+  intoId: number;
+  counts: MergeCounts;
+  at: string;
+}
+
+const LOG_KEY = "mergeLog";
+const LOG_LIMIT = 200;
+
+// Admin entry of `kind` by id, as the label transactions refer to it by
+function labelOf(kind: MergeKind, id: number): string | undefined {
+  if (kind === "account") return adminRepository.findAccountById(id)?.name;
+  if (kind === "asset") return adminRepository.findAssetById(id)?.symbol;
+  return adminRepository.findTagById(id)?.name;
+}
+
+/**
+ * Merging duplicate accounts, assets and tags (e.g. "Bank" into "Bank
+ * Account" after an import). Everything pointing at the duplicate is
+ * reassigned in one step, the duplicate is removed, and the merge is
+ * kept in a log for the activity feed.
+ */
+export class MergeService {
+  merge(kind: MergeKind, fromId: number, intoId: number): MergeRecord {
+    if (!Number.isInteger(intoId)) {
+      throw new ValidationError("into_id is required");
+    }
+    if (fromId === intoId) {
+      throw new ValidationError(`Cannot merge a ${kind} into itself`);
+    }
+    const from = labelOf(kind, fromId);
+    if (!from) throw new NotFoundError(kind, String(fromId));
+    const into = labelOf(kind, intoId);
+    if (!into) throw new NotFoundError(kind, String(intoId));
+
+    // Rows written under an earlier name of the duplicate are read back
+    // under the entry it merges into
+    if (kind !== "tag") renameService.reassign(kind, fromId, intoId);
+    const counts =
+      kind === "account"
+        ? mergeRepository.mergeAccount(fromId, from, into)
+        : kind === "asset"
+          ? mergeRepository.mergeAsset(fromId, from, into)
+          : mergeRepository.mergeTag(fromId, from, into);
+    // The merge rewrites transactions in bulk, past the balance table
+    if (kind !== "tag" && counts.transactions) accountBalanceService.rebuild();
+    // The duplicate asset is deleted past the admin repository
+    if (kind === "asset") invalidateAssetKinds();
+
+    const record: MergeRecord = {
+      id: uuidv4(),
+      kind,
+      from,
+      into,
+      fromId,
+      intoId,
+      counts,
+      at: new Date().toISOString(),
+    };
+    const log = [...this.log(), record].slice(-LOG_LIMIT);
+    settingsRepository.setSetting(LOG_KEY, JSON.stringify(log));
+    logger.info({ merge: record }, `Merged ${kind} ${from} into ${into}`);
+    return record;
+  }
+
+  /** Past merges, oldest first (the latest 200). */
+  log(): MergeRecord[] {
+    const raw = settingsRepository.getSetting(LOG_KEY);
+    if (!raw) return [];
+    try {
+      const parsed = JSON.parse(raw);
+      return Array.isArray(parsed) ? parsed : [];
+    } catch {
+      return [];
+    }
+  }
+}
+
+export const mergeService = new MergeService();
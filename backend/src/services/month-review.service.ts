@@ -0,0 +1,191 @@
+import { MonthReview, Transaction } from "../types";
+import { monthReviewRepository, transactionRepository } from "../repositories";
+import { ConflictError, ValidationError } from "../core/errors";
+import { BudgetMonth, budgetMonth } from "./budget.service";
+import { isInternalFlow } from "./transaction-search.service";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+// An amount is unusual at UNUSUAL_FACTOR times the median of the same
+// category (or counterparty) over the LOOKBACK_DAYS before it
+const LOOKBACK_DAYS = 180;
+const MIN_HISTORY = 3;
+const UNUSUAL_FACTOR = 3;
+
+export type ReviewReason = "untagged" | "unreviewed" | "anomalous";
+
+export interface MonthReviewItem {
+  transaction: Transaction;
+  reasons: ReviewReason[];
+  anomalies: string[]; // why it is anomalous, e.g. "possible duplicate of t1"
+}
+
+export interface MonthReviewWorklist {
+  month: BudgetMonth;
+  // open: never signed off; reopened: signed off, then items came back
+  status: "open" | "reviewed" | "reopened";
+  review?: MonthReview;
+  transactionCount: number;
+  counts: Record<ReviewReason, number>;
+  items: MonthReviewItem[]; // empty once the month can be signed off
+}
+
+/** Unreviewed until marked, and again once edited after being marked. */
+export function isReviewed(t: Transaction): boolean {
+  return !!t.reviewedAt && (!t.updatedAt || t.updatedAt <= t.reviewedAt);
+}
+
+// Income and spending need a category or tag; transfers and openings don't
+function isUntagged(t: Transaction): boolean {
+  if (t.type !== "INCOME" && t.type !== "EXPENSE") return false;
+  return !t.category && !(t.tags || []).length;
+}
+
+function median(values: number[]): number {
+  const sorted = [...values].sort((a, b) => a - b);
+  const mid = Math.floor(sorted.length / 2);
+  return sorted.length % 2
+    ? sorted[mid]
+    : (sorted[mid - 1] + sorted[mid]) / 2;
+}
+
+/**
+ * Zero-based monthly review: every transaction of a month is either
+ * marked reviewed or still on the list, along with anything untagged or
+ * unusual. A month is signed off only once its list is empty.
+ */
+export class MonthReviewService {
+  list(): MonthReview[] {
+    return monthReviewRepository.findAll();
+  }
+
+  worklist(month?: string, now: Date = new Date()): MonthReviewWorklist {
+    const period = budgetMonth(month, now);
+    const all = transactionRepository.findAll();
+    const inMonth = all.filter(
+      (t) => t.createdAt >= period.start && t.createdAt <= period.end,
+    );
+
+    const items: MonthReviewItem[] = [];
+    for (const t of inMonth) {
+      const reasons: ReviewReason[] = [];
+      if (isUntagged(t)) reasons.push("untagged");
+      const reviewed = isReviewed(t);
+      if (!reviewed) reasons.push("unreviewed");
+      // Marking a transaction reviewed acknowledges its anomalies
+      const anomalies = reviewed ? [] : this.anomalies(t, all);
+      if (anomalies.length) reasons.push("anomalous");
+      if (reasons.length) items.push({ transaction: t, reasons, anomalies });
+    }
+    items.sort((a, b) =>
+      a.transaction.createdAt.localeCompare(b.transaction.createdAt),
+    );
+
+    const count = (r: ReviewReason) =>
+      items.filter((i) => i.reasons.includes(r)).length;
+    const review = monthReviewRepository.findByMonth(period.month);
+    return {
+      month: period,
+      status: !review ? "open" : items.length ? "reopened" : "reviewed",
+      review,
+      transactionCount: inMonth.length,
+      counts: {
+        untagged: count("untagged"),
+        unreviewed: count("unreviewed"),
+        anomalous: count("anomalous"),
+      },
+      items,
+    };
+  }
+
+  /** Unusual amounts and likely duplicates among `all` transactions. */
+  anomalies(t: Transaction, all: Transaction[]): string[] {
+    const found: string[] = [];
+    const usd = Math.abs(t.usdAmount || 0);
+
+    const key = t.category || t.counterparty;
+    if (key && (t.type === "INCOME" || t.type === "EXPENSE") && usd > 0) {
+      const since = new Date(
+        Date.parse(t.createdAt) - LOOKBACK_DAYS * DAY_MS,
+      ).toISOString();
+      const history = all
+        .filter(
+          (o) =>
+            o.id !== t.id &&
+            o.type === t.type &&
+            (o.category || o.counterparty) === key &&
+            o.createdAt >= since &&
+            o.createdAt < t.createdAt,
+        )
+        .map((o) => Math.abs(o.usdAmount || 0));
+      const usual = history.length >= MIN_HISTORY ? median(history) : 0;
+      if (usual > 0 && usd >= UNUSUAL_FACTOR * usual) {
+        found.push(`${(usd / usual).toFixed(1)}x the usual ${key} amount`);
+      }
+    }
+
+    if (!isInternalFlow(t)) {
+      const day = t.createdAt.slice(0, 10);
+      const twin = all.find(
+        (o) =>
+          o.id !== t.id &&
+          o.type === t.type &&
+          o.createdAt.slice(0, 10) === day &&
+          o.amount === t.amount &&
+          o.asset.symbol === t.asset.symbol &&
+          (o.account || "") === (t.account || "") &&
+          (o.counterparty || "") === (t.counterparty || ""),
+      );
+      if (twin) found.push(`possible duplicate of ${twin.id}`);
+    }
+    return found;
+  }
+
+  /** Mark transactions of `month` reviewed; returns the updated list. */
+  markReviewed(
+    month: string,
+    ids: string[],
+    now: Date = new Date(),
+  ): MonthReviewWorklist {
+    const period = budgetMonth(month, now);
+    const outside = ids.filter((id) => {
+      const t = transactionRepository.findById(id);
+      return !t || t.createdAt < period.start || t.createdAt > period.end;
+    });
+    if (outside.length) {
+      throw new ValidationError(
+        `not transactions of ${period.month}: ${outside.join(", ")}`,
+      );
+    }
+    const at = now.toISOString();
+    for (const id of ids) {
+      transactionRepository.update(id, { reviewedAt: at, updatedAt: at });
+    }
+    return this.worklist(month, now);
+  }
+
+  /** Sign off `month`; refused while its list is not empty. */
+  complete(
+    month: string,
+    options: { note?: string; now?: Date } = {},
+  ): MonthReview {
+    const now = options.now ?? new Date();
+    const list = this.worklist(month, now);
+    if (list.month.start > now.toISOString()) {
+      throw new ValidationError(`${list.month.month} has not started`);
+    }
+    if (list.items.length) {
+      throw new ConflictError(
+        `${list.month.month} has ${list.items.length} open items`,
+        { counts: list.counts },
+      );
+    }
+    return monthReviewRepository.save({
+      month: list.month.month,
+      reviewedAt: now.toISOString(),
+      transactionCount: list.transactionCount,
+      note: options.note,
+    });
+  }
+}
+
+export const monthReviewService = new MonthReviewService();
@@ -0,0 +1,282 @@
+import { Asset, Transaction, assetKey } from "../types";
+import { reportingRepository } from "../repositories";
+import { priceCacheRepository } from "../repositories/price-cache.repository";
+import { ValidationError } from "../core/errors";
+import { priceOverride } from "../utils/price-override.util";
+import { JobContext } from "./job.service";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const MAX_POINTS = 3660;
+const VND: Asset = { type: "FIAT", symbol: "VND" };
+
+export type NetWorthInterval = "day" | "month";
+
+export interface NetWorthPoint {
+  date: string; // YYYY-MM-DD, valued at the end of that day (UTC)
+  assets_usd: number; // positive balances plus money lent out
+  receivables_usd: number; // LOAN outstanding, included in assets_usd
+  liabilities_usd: number;
+  borrowings_usd: number; // BORROW outstanding
+  credit_usd: number; // negative account balances, e.g. credit cards
+  net_worth_usd: number;
+  net_worth_vnd: number; // at that day's USD/VND rate
+}
+
+export interface NetWorthSeries {
+  start: string;
+  end: string;
+  interval: NetWorthInterval;
+  points: NetWorthPoint[];
+  unpriced: string[]; // assets held without any known rate
+}
+
+export interface NetWorthParams {
+  start?: string;
+  end?: string;
+  interval?: string;
+}
+
+// Points valued so far, yielded while a series is built
+interface NetWorthStep {
+  done: number;
+  total: number;
+  date: string;
+}
+
+// Units added to the account (holding) and to borrowed/lent per type
+function deltas(t: Transaction) {
+  const n = Number(t.amount) || 0;
+  switch (t.type) {
+    case "INITIAL":
+    case "INCOME":
+    case "TRANSFER_IN":
+      return { holding: n, borrowed: 0, lent: 0 };
+    case "EXPENSE":
+    case "TRANSFER_OUT":
+      return { holding: -n, borrowed: 0, lent: 0 };
+    case "BORROW":
+      return { holding: n, borrowed: n, lent: 0 };
+    case "LOAN":
+      return { holding: -n, borrowed: 0, lent: n };
+    case "REPAY":
+      return String(t.direction).toUpperCase() === "LOAN"
+        ? { holding: n, borrowed: 0, lent: -n }
+        : { holding: -n, borrowed: -n, lent: 0 };
+  }
+  return { holding: 0, borrowed: 0, lent: 0 };
+}
+
+/**
+ * USD rate per asset as of a point in time: the latest cached provider
+ * rate (price_cache holds FX rates as FIAT rows), else the rate recorded
+ * on the latest transaction in that asset. Points are asked in order.
+ */
+class RateTimeline {
+  private cached = new Map<string, Array<{ at: string; usd: number }>>();
+  private cursor = new Map<string, number>();
+  private fromLedger = new Map<string, number>();
+
+  constructor(private until: Date) {}
+
+  // Rate carried by a transaction, the fallback for uncached assets
+  record(t: Transaction): void {
+    const usd = t.rate?.rateUSD;
+    if (usd > 0 && !t.rate.missing) this.fromLedger.set(assetKey(t.asset), usd);
+  }
+
+  rateAt(asset: Asset, at: string): number | undefined {
+    if (asset.symbol.toUpperCase() === "USD") return 1;
+    const override = priceOverride(asset.symbol);
+    if (override !== undefined) return override;
+    const key = assetKey(asset);
+    let rates = this.cached.get(key);
+    if (!rates) {
+      rates = priceCacheRepository
+        .getRatesInRange(asset, new Date(0), this.until)
+        .filter((r) => r.source !== "FIXED" && r.rateUSD > 0)
+        .map((r) => ({ at: r.timestamp, usd: r.rateUSD }));
+      this.cached.set(key, rates);
+    }
+    let i = this.cursor.get(key) ?? -1;
+    while (i + 1 < rates.length && rates[i + 1].at <= at) i++;
+    this.cursor.set(key, i);
+    return i >= 0 ? rates[i].usd : this.fromLedger.get(key);
+  }
+}
+
+/**
+ * Net worth over time: assets minus liabilities at the end of each day
+ * (or month) between two dates. Balances are rebuilt by walking the
+ * transaction ledger from the start, so the series reflects backdated
+ * entries and edits.
+ */
+export class NetWorthService {
+  series(params: NetWorthParams): NetWorthSeries {
+    const steps = this.build(params);
+    for (;;) {
+      const step = steps.next();
+      if (step.done) return step.value;
+    }
+  }
+
+  /** The same series built as a job, reporting progress per point. */
+  async seriesJob(
+    params: NetWorthParams,
+    job: JobContext,
+  ): Promise<NetWorthSeries> {
+    job.progress(0, "Loading ledger");
+    const steps = this.build(params);
+    for (;;) {
+      const step = steps.next();
+      if (step.done) return step.value;
+      const { done, total, date } = step.value;
+      if (done % 50 === 0 || done === total) {
+        job.progress((done / total) * 100, `Valuing ${date}`);
+        await job.checkpoint();
+      }
+    }
+  }
+
+  private *build(
+    params: NetWorthParams,
+  ): Generator<NetWorthStep, NetWorthSeries, void> {
+    const interval = (params.interval || "day") as NetWorthInterval;
+    if (interval !== "day" && interval !== "month") {
+      throw new ValidationError("interval must be day or month");
+    }
+    const end = this.day(params.end, "end") ?? this.today();
+    const endOfRange = `${end}T23:59:59.999Z`;
+    const ledger = reportingRepository.findLedgerUntil(endOfRange);
+    const start =
+      this.day(params.start, "start") ??
+      (ledger[0]?.createdAt.slice(0, 10) || end);
+    if (start > end) throw new ValidationError("start is after end");
+
+    const dates = this.dates(start, end, interval);
+    const rates = new RateTimeline(new Date(endOfRange));
+    const holdings = new Map<string, { asset: Asset; units: number }>();
+    const borrowed = new Map<string, { asset: Asset; units: number }>();
+    const lent = new Map<string, { asset: Asset; units: number }>();
+    const add = (
+      map: Map<string, { asset: Asset; units: number }>,
+      key: string,
+      asset: Asset,
+      units: number,
+    ) => {
+      if (!units) return;
+      const cur = map.get(key) || { asset, units: 0 };
+      cur.units += units;
+      map.set(key, cur);
+    };
+
+    const unpriced = new Set<string>();
+    const points: NetWorthPoint[] = [];
+    let next = 0;
+    for (const date of dates) {
+      const at = `${date}T23:59:59.999Z`;
+      for (; next < ledger.length && ledger[next].createdAt <= at; next++) {
+        const t = ledger[next];
+        const d = deltas(t);
+        const key = assetKey(t.asset);
+        rates.record(t);
+        add(holdings, `${key}|${t.account || ""}`, t.asset, d.holding);
+        add(borrowed, key, t.asset, d.borrowed);
+        add(lent, key, t.asset, d.lent);
+      }
+
+      const value = (asset: Asset, units: number) => {
+        const rate = rates.rateAt(asset, at);
+        if (rate === undefined) unpriced.add(asset.symbol.toUpperCase());
+        return units * (rate ?? 0);
+      };
+      let positive = 0;
+      let credit = 0;
+      for (const h of holdings.values()) {
+        if (Math.abs(h.units) < 1e-12) continue;
+        const usd = value(h.asset, h.units);
+        if (usd >= 0) positive += usd;
+        else credit -= usd;
+      }
+      let borrowings = 0;
+      for (const b of borrowed.values()) {
+        if (b.units > 1e-12) borrowings += value(b.asset, b.units);
+      }
+      let receivables = 0;
+      for (const l of lent.values()) {
+        if (l.units > 1e-12) receivables += value(l.asset, l.units);
+      }
+
+      const assets = positive + receivables;
+      const liabilities = borrowings + credit;
+      const netWorth = assets - liabilities;
+      const vndUSD = rates.rateAt(VND, at);
+      points.push({
+        date,
+        assets_usd: assets,
+        receivables_usd: receivables,
+        liabilities_usd: liabilities,
+        borrowings_usd: borrowings,
+        credit_usd: credit,
+        net_worth_usd: netWorth,
+        net_worth_vnd: vndUSD ? netWorth / vndUSD : 0,
+      });
+      yield { done: points.length, total: dates.length, date };
+    }
+
+    return {
+      start,
+      end,
+      interval,
+      points,
+      unpriced: Array.from(unpriced).sort(),
+    };
+  }
+
+  // Day ends between start and end; monthly points are month ends, with
+  // the end date itself as the last one
+  private dates(
+    start: string,
+    end: string,
+    interval: NetWorthInterval,
+  ): string[] {
+    const out: string[] = [];
+    const from = Date.parse(`${start}T00:00:00Z`);
+    const to = Date.parse(`${end}T00:00:00Z`);
+    if (interval === "day") {
+      for (let t = from; t <= to; t += DAY_MS) {
+        out.push(new Date(t).toISOString().slice(0, 10));
+        if (out.length > MAX_POINTS) break;
+      }
+    } else {
+      const d = new Date(from);
+      for (let m = 1; ; m++) {
+        const monthEnd = Date.UTC(d.getUTCFullYear(), d.getUTCMonth() + m, 0);
+        if (monthEnd >= to) break;
+        out.push(new Date(monthEnd).toISOString().slice(0, 10));
+        if (out.length > MAX_POINTS) break;
+      }
+      out.push(end);
+    }
+    if (out.length > MAX_POINTS) {
+      throw new ValidationError(
+        `At most ${MAX_POINTS} points; use interval=month or a shorter range`,
+      );
+    }
+    return out;
+  }
+
+  private day(value: string | undefined, field: string): string | undefined {
+    if (!value) return undefined;
+    const at = new Date(value);
+    if (Number.isNaN(at.getTime())) {
+      throw new ValidationError(`${field} must be a date (YYYY-MM-DD)`);
+    }
+    return at.toISOString().slice(0, 10);
+  }
+
+  private today(): string {
+    return new Date().toISOString().slice(0, 10);
+  }
+}
+
+export const netWorthService = new NetWorthService();
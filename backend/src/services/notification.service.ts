@@ -0,0 +1,36 @@
+import axios from "axios";
+import { config } from "../core/config";
+import { logger } from "../utils/logger";
+
+const WEBHOOK_TIMEOUT_MS = 10000;
+
+export interface Notification {
+  kind: string; // e.g. "report"
+  title: string;
+  body?: string;
+  data?: unknown;
+}
+
+/**
+ * Outbound notifications. Every notification is logged; when
+ * NOTIFY_WEBHOOK_URL is set it is also POSTed there as JSON, so delivery
+ * to chat or email can be handled by whatever sits behind the webhook.
+ */
+export class NotificationService {
+  async send(notification: Notification): Promise<void> {
+    logger.info(
+      { kind: notification.kind, title: notification.title },
+      "Notification",
+    );
+
+    const url = config.notifyWebhookUrl;
+    if (!url) return;
+    await axios.post(
+      url,
+      { ...notification, sentAt: new Date().toISOString() },
+      { timeout: WEBHOOK_TIMEOUT_MS },
+    );
+  }
+}
+
+export const notificationService = new NotificationService();
@@ -0,0 +1,336 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  Asset,
+  OptionOpenRequest,
+  OptionPosition,
+  OptionSettleRequest,
+  Transaction,
+  TransactionType,
+} from "../types";
+import { optionRepository, transactionRepository } from "../repositories";
+import { priceService } from "./price.service";
+import { eventBus } from "./event-bus.service";
+import { logger } from "../utils/logger";
+
+const EXPIRY_CHECK_INTERVAL_MS = 6 * 60 * 60 * 1000; // every 6 hours
+let schedulerStarted = false;
+
+export interface OptionsPnL {
+  realizedUSD: number;
+  openPositions: number;
+  byUnderlying: Record<string, number>;
+}
+
+/** Synthetic asset for the contracts themselves, e.g. BTC-20250627-60000-C */
+export function contractAsset(p: OptionPosition): Asset {
+  const date = p.expiry.slice(0, 10).replace(/-/g, "");
+  const kind = p.optionType === "CALL" ? "C" : "P";
+  return {
+    type: "CRYPTO",
+    symbol: `${p.underlying.symbol}-${date}-${p.strike}-${kind}`,
+  };
+}
+
+/** Per-unit value if exercised at `price`; 0 when out of the money */
+export function intrinsicValue(p: OptionPosition, price: number): number {
+  const v = p.optionType === "CALL" ? price - p.strike : p.strike - price;
+  return Math.max(0, v);
+}
+
+function leg(
+  p: OptionPosition,
+  type: TransactionType,
+  asset: Asset,
+  amount: number,
+  usdAmount: number,
+  at: string,
+  note: string,
+): Transaction {
+  return {
+    id: uuidv4(),
+    type,
+    asset,
+    amount,
+    createdAt: at,
+    account: p.account,
+    note,
+    category: "option",
+    transferId: p.id,
+    rate: {
+      asset,
+      rateUSD: amount > 0 ? usdAmount / amount : 0,
+      timestamp: at,
+      source: "FIXED",
+    },
+    usdAmount,
+  } as Transaction;
+}
+
+// Publish a position leaving OPEN (expired or exercised)
+function emitClosed(p: OptionPosition): void {
+  eventBus.emit("investment.closed", {
+    kind: "option",
+    id: p.id,
+    name: contractAsset(p).symbol,
+    status: p.status,
+    at: p.settledAt,
+    realized_pnl_usd: p.realizedPnLUSD,
+  });
+}
+
+export class OptionService {
+  /**
+   * Open a position. Buying pays the premium out of the account and books
+   * the contracts at cost; writing does the reverse. Every transaction of
+   * the position shares its id as transferId.
+   */
+  async openPosition(
+    data: OptionOpenRequest,
+  ): Promise<{ position: OptionPosition; transactions: Transaction[] }> {
+    const at = data.at ?? new Date().toISOString();
+    if (new Date(data.expiry) <= new Date(at)) {
+      throw new Error("expiry must be after the trade date");
+    }
+
+    const units = data.contracts * data.multiplier;
+    const cash = data.premium * units;
+    const rate = await priceService.getRateUSD(data.premiumAsset, at);
+    const position: OptionPosition = {
+      id: uuidv4(),
+      underlying: {
+        ...data.underlying,
+        symbol: data.underlying.symbol.toUpperCase(),
+      },
+      optionType: data.optionType,
+      side: data.side,
+      contracts: data.contracts,
+      multiplier: data.multiplier,
+      strike: data.strike,
+      expiry: data.expiry,
+      premium: data.premium,
+      premiumAsset: data.premiumAsset,
+      premiumUSD: cash * rate.rateUSD,
+      account: data.account,
+      status: "OPEN",
+      openedAt: at,
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    };
+
+    const contract = contractAsset(position);
+    const long = position.side === "LONG";
+    const verb = long ? "Buy" : "Write";
+    const label = `${verb} ${data.contracts} ${contract.symbol}`;
+    const transactions = [
+      leg(
+        position,
+        long ? "TRANSFER_OUT" : "TRANSFER_IN",
+        data.premiumAsset,
+        cash,
+        position.premiumUSD,
+        at,
+        `${label}: premium`,
+      ),
+      leg(
+        position,
+        long ? "TRANSFER_IN" : "TRANSFER_OUT",
+        contract,
+        data.contracts,
+        position.premiumUSD,
+        at,
+        label,
+      ),
+    ];
+
+    optionRepository.create(position);
+    for (const tx of transactions) transactionRepository.create(tx);
+    return { position, transactions };
+  }
+
+  listPositions(status?: string): OptionPosition[] {
+    return status
+      ? optionRepository.findByStatus(status)
+      : optionRepository.findAll();
+  }
+
+  getPosition(id: string): OptionPosition | undefined {
+    return optionRepository.findById(id);
+  }
+
+  /** Let the contracts lapse; the whole premium becomes realized PnL. */
+  expire(id: string, params: OptionSettleRequest = {}): OptionPosition {
+    const p = this.requireOpen(id);
+    const at = params.at ?? p.expiry;
+    const long = p.side === "LONG";
+    const contract = contractAsset(p);
+
+    transactionRepository.create(
+      leg(
+        p,
+        long ? "TRANSFER_OUT" : "TRANSFER_IN",
+        contract,
+        p.contracts,
+        0,
+        at,
+        `${contract.symbol} expired worthless`,
+      ),
+    );
+    const position = optionRepository.update(p.id, {
+      status: "EXPIRED",
+      settledAt: at,
+      settlementPrice: params.settlementPrice,
+      realizedPnLUSD: long ? -p.premiumUSD : p.premiumUSD,
+    })!;
+    emitClosed(position);
+    return position;
+  }
+
+  /**
+   * Exercise (or be assigned): close the contracts at intrinsic value and
+   * record the underlying changing hands at the strike, valued at the
+   * settlement price. Without an explicit price the underlying's price at
+   * `at` is used.
+   */
+  async exercise(
+    id: string,
+    params: OptionSettleRequest = {},
+  ): Promise<{ position: OptionPosition; transactions: Transaction[] }> {
+    const p = this.requireOpen(id);
+    const at = params.at ?? p.expiry;
+    const cashRate = (await priceService.getRateUSD(p.premiumAsset, at))
+      .rateUSD;
+    const price =
+      params.settlementPrice ??
+      (await priceService.getRateUSD(p.underlying, at)).rateUSD / cashRate;
+    const intrinsic = intrinsicValue(p, price);
+    if (intrinsic <= 0) {
+      throw new Error("option is out of the money; expire it instead");
+    }
+
+    const units = p.contracts * p.multiplier;
+    const intrinsicUSD = intrinsic * units * cashRate;
+    const strikeCash = p.strike * units;
+    const long = p.side === "LONG";
+    const buysUnderlying = (p.optionType === "CALL") === long;
+    const contract = contractAsset(p);
+    const label = `${contract.symbol} ${long ? "exercised" : "assigned"}`;
+
+    const transactions = [
+      leg(
+        p,
+        long ? "TRANSFER_OUT" : "TRANSFER_IN",
+        contract,
+        p.contracts,
+        intrinsicUSD,
+        at,
+        label,
+      ),
+      leg(
+        p,
+        buysUnderlying ? "TRANSFER_OUT" : "TRANSFER_IN",
+        p.premiumAsset,
+        strikeCash,
+        strikeCash * cashRate,
+        at,
+        `${label}: strike`,
+      ),
+      leg(
+        p,
+        buysUnderlying ? "TRANSFER_IN" : "TRANSFER_OUT",
+        p.underlying,
+        units,
+        units * price * cashRate,
+        at,
+        `${label}: ${units} ${p.underlying.symbol} @ ${p.strike}`,
+      ),
+    ];
+    for (const tx of transactions) transactionRepository.create(tx);
+
+    const position = optionRepository.update(p.id, {
+      status: "EXERCISED",
+      settledAt: at,
+      settlementPrice: price,
+      realizedPnLUSD: long
+        ? intrinsicUSD - p.premiumUSD
+        : p.premiumUSD - intrinsicUSD,
+    })!;
+    emitClosed(position);
+    return { position, transactions };
+  }
+
+  /**
+   * Settle open positions past expiry: in-the-money ones are exercised,
+   * the rest expire. Positions whose underlying price can't be fetched are
+   * left open for manual settlement.
+   */
+  async processExpired(now: Date = new Date()): Promise<OptionPosition[]> {
+    const settled: OptionPosition[] = [];
+    for (const p of optionRepository.findByStatus("OPEN")) {
+      if (new Date(p.expiry) > now) continue;
+      try {
+        const [cash, underlying] = await Promise.all([
+          priceService.getRateUSD(p.premiumAsset, p.expiry),
+          priceService.getRateUSD(p.underlying, p.expiry),
+        ]);
+        // The price service answers 1 with source FIXED when no provider
+        // knows the asset; settling on that would book a bogus exercise
+        if (underlying.source === "FIXED") {
+          throw new Error(`no price for ${p.underlying.symbol}`);
+        }
+        const price = underlying.rateUSD / cash.rateUSD;
+        settled.push(
+          intrinsicValue(p, price) > 0
+            ? (await this.exercise(p.id, { settlementPrice: price })).position
+            : this.expire(p.id, { settlementPrice: price }),
+        );
+      } catch (e: any) {
+        logger.warn(
+          { positionId: p.id, error: e?.message },
+          "Could not settle expired option",
+        );
+      }
+    }
+    return settled;
+  }
+
+  realizedPnL(): OptionsPnL {
+    const out: OptionsPnL = {
+      realizedUSD: 0,
+      openPositions: 0,
+      byUnderlying: {},
+    };
+    for (const p of optionRepository.findAll()) {
+      if (p.status === "OPEN") {
+        out.openPositions++;
+        continue;
+      }
+      const pnl = p.realizedPnLUSD || 0;
+      out.realizedUSD += pnl;
+      const sym = p.underlying.symbol;
+      out.byUnderlying[sym] = (out.byUnderlying[sym] || 0) + pnl;
+    }
+    return out;
+  }
+
+  startExpiryScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processExpired().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Option expiry check failed"),
+      );
+    };
+    run();
+    setInterval(run, EXPIRY_CHECK_INTERVAL_MS);
+  }
+
+  private requireOpen(id: string): OptionPosition {
+    const p = optionRepository.findById(id);
+    if (!p) throw new Error("Option position not found");
+    if (p.status !== "OPEN") throw new Error(`Option position is ${p.status}`);
+    return p;
+  }
+}
+
+export const optionService = new OptionService();
@@ -0,0 +1,52 @@
+import { Asset } from "../types";
+import { adminRepository } from "../repositories";
+import { roundTo } from "../utils/number.util";
+
+// Currencies without minor units
+const ZERO_DECIMAL_FIAT = new Set(["VND", "JPY", "KRW", "IDR"]);
+// Beyond this a double can't tell whether a quantity is on the step
+const MAX_CHECKED_DECIMALS = 12;
+
+/**
+ * Quantity precision per asset. The admin assets table (`decimals`) is the
+ * registry; assets missing from it fall back to 0 decimals for currencies
+ * without minor units, 2 for other fiat and 8 for everything else.
+ */
+export class PrecisionService {
+  getDecimals(asset: Asset): number {
+    const symbol = asset.symbol.toUpperCase();
+    let registered: number | undefined;
+    try {
+      registered = adminRepository
+        .findAllAssets()
+        .find((a) => a.symbol.toUpperCase() === symbol)?.decimals;
+    } catch {
+      // registry unavailable; use the defaults
+    }
+    if (Number.isInteger(registered) && registered! >= 0) return registered!;
+    if (asset.type === "FIAT") return ZERO_DECIMAL_FIAT.has(symbol) ? 0 : 2;
+    return 8;
+  }
+
+  /** Round a derived quantity to the asset's step size. */
+  round(asset: Asset, amount: number): number {
+    return roundTo(amount, Math.min(this.getDecimals(asset), 15));
+  }
+
+  /** Reject input quantities finer than the asset's step size. */
+  validate(asset: Asset, amount: number, field = "amount"): void {
+    const decimals = this.getDecimals(asset);
+    if (decimals > MAX_CHECKED_DECIMALS || !Number.isFinite(amount)) return;
+    const scaled = amount * Math.pow(10, decimals);
+    // Allow for the representation error of the scaled value
+    const tolerance = Math.max(1e-6, Math.abs(scaled) * Number.EPSILON * 4);
+    if (Math.abs(scaled - Math.round(scaled)) > tolerance) {
+      throw new Error(
+        `${field} ${amount} ${asset.symbol} has more than ${decimals} ` +
+          `decimal places`,
+      );
+    }
+  }
+}
+
+export const precisionService = new PrecisionService();
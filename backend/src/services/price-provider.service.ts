@@ -0,0 +1,248 @@
+import { Asset, AssetKind, PriceProvider } from "../types";
+import { config } from "../core/config";
+import { logger } from "../utils/logger";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// What a provider is asked for; `get` goes through the caller's rate limits
+export interface PriceRequest {
+  asset: Asset;
+  at: Date;
+  historical: boolean;
+  get: <T>(url: string, timeout?: number) => Promise<T>;
+  // USD value of an amount quoted in another currency, when known
+  toUSD: (amount: number, currency: string) => Promise<number | null>;
+}
+
+/**
+ * A source of USD rates. `assetKinds` are the classes it is asked for by
+ * default; a configured priority may still name it for any asset.
+ */
+export interface PriceProviderAdapter {
+  name: PriceProvider;
+  assetKinds: AssetKind[];
+  available(): boolean; // e.g. false without an API key
+  fetch(req: PriceRequest): Promise<number | null>;
+}
+
+const providers = new Map<PriceProvider, PriceProviderAdapter>();
+
+/** Add or replace a provider; later lookups by name use it. */
+export function registerPriceProvider(adapter: PriceProviderAdapter): void {
+  providers.set(adapter.name, adapter);
+}
+
+export function getPriceProvider(
+  name: PriceProvider,
+): PriceProviderAdapter | undefined {
+  return providers.get(name);
+}
+
+export function listPriceProviders(): PriceProviderAdapter[] {
+  return Array.from(providers.values());
+}
+
+export function cryptoIdForSymbol(symbol: string): string {
+  const sym = symbol.toUpperCase();
+  const map: Record<string, string> = {
+    BTC: "bitcoin",
+    ETH: "ethereum",
+    SOL: "solana",
+    USDT: "tether",
+    USDC: "usd-coin",
+    BNB: "binancecoin",
+    XRP: "ripple",
+    ADA: "cardano",
+    DOGE: "dogecoin",
+    TRX: "tron",
+    DOT: "polkadot",
+    MATIC: "matic-network",
+    AVAX: "avalanche-2",
+    XAU: "pax-gold", // PAXG - gold-backed token for accurate gold price
+  };
+  return map[sym] || sym.toLowerCase();
+}
+
+const dayOf = (d: Date) => d.toISOString().split("T")[0];
+
+async function historicalCryptoPrice(
+  req: PriceRequest,
+  id: string,
+): Promise<number | null> {
+  const { at } = req;
+  try {
+    const days = Math.ceil((Date.now() - at.getTime()) / DAY_MS) || 1;
+
+    if (days < 0 || days > 365) return null;
+
+    const data: any = await req.get(
+      `https://api.coingecko.com/api/v3/coins/${id}/market_chart?vs_currency=usd&days=${days}&interval=daily`,
+      10000,
+    );
+
+    const prices = data?.prices;
+    if (!Array.isArray(prices)) return null;
+
+    const target = at.getTime();
+    let closest = prices[0][1];
+    let minDiff = Math.abs(prices[0][0] - target);
+
+    for (const [ts, price] of prices) {
+      const diff = Math.abs(ts - target);
+      if (diff < minDiff) {
+        minDiff = diff;
+        closest = price;
+      }
+    }
+
+    return closest > 0 ? closest : null;
+  } catch (err: any) {
+    logger.warn(
+      { id, at: at.toISOString(), error: err.message },
+      "Historical crypto price fetch failed",
+    );
+    return null;
+  }
+}
+
+const always = () => true;
+
+registerPriceProvider({
+  name: "COINGECKO",
+  assetKinds: ["CRYPTO", "STABLECOIN"],
+  available: always,
+  async fetch(req) {
+    const id = cryptoIdForSymbol(req.asset.symbol);
+    if (req.historical) return historicalCryptoPrice(req, id);
+    const data: any = await req.get(
+      `https://api.coingecko.com/api/v3/simple/price?ids=${id}&vs_currencies=usd`,
+    );
+    return data?.[id]?.usd ?? null;
+  },
+});
+
+registerPriceProvider({
+  name: "EXCHANGE_RATE_HOST",
+  assetKinds: ["FIAT"],
+  available: always,
+  async fetch(req) {
+    const symbol = req.asset.symbol.toUpperCase();
+    const data: any = await req.get(
+      `https://api.exchangerate.host/latest?base=${symbol}&symbols=USD`,
+    );
+    return data?.rates?.USD ?? null;
+  },
+});
+
+registerPriceProvider({
+  name: "FRANKFURTER",
+  assetKinds: ["FIAT"],
+  available: always,
+  async fetch(req) {
+    const symbol = req.asset.symbol.toUpperCase();
+    const date = req.historical ? dayOf(req.at) : "latest";
+    const data: any = await req.get(
+      `https://api.frankfurter.app/${date}?from=${symbol}&to=USD`,
+    );
+    return data?.rates?.USD ?? null;
+  },
+});
+
+registerPriceProvider({
+  name: "ER_API",
+  assetKinds: ["FIAT"],
+  available: always,
+  async fetch(req) {
+    const symbol = req.asset.symbol.toUpperCase();
+    const data: any = await req.get(
+      `https://open.er-api.com/v6/latest/${symbol}`,
+    );
+    return data?.rates?.USD ?? null;
+  },
+});
+
+registerPriceProvider({
+  name: "EXCHANGE_RATE_API",
+  assetKinds: ["FIAT"],
+  available: always,
+  async fetch(req) {
+    const symbol = req.asset.symbol.toUpperCase();
+    const apiKey = config.exchangeRateApiKey || "ce0562d3379ec1b87fd2d324";
+    const data: any = await req.get(
+      `https://v6.exchangerate-api.com/v6/${apiKey}/latest/USD`,
+    );
+    // Quoted as USD -> symbol, we need symbol -> USD
+    const v = data?.conversion_rates?.[symbol];
+    return v > 0 ? 1 / v : null;
+  },
+});
+
+// Daily closes from the chart API; quotes are in the listing's currency
+registerPriceProvider({
+  name: "YAHOO_FINANCE",
+  assetKinds: ["EQUITY"],
+  available: always,
+  async fetch(req) {
+    const symbol = encodeURIComponent(req.asset.symbol.toUpperCase());
+    const range = req.historical
+      ? `period1=${Math.floor((req.at.getTime() - 7 * DAY_MS) / 1000)}` +
+        `&period2=${Math.floor((req.at.getTime() + DAY_MS) / 1000)}`
+      : "range=5d";
+    const data: any = await req.get(
+      `https://query1.finance.yahoo.com/v8/finance/chart/${symbol}?interval=1d&${range}`,
+    );
+    const result = data?.chart?.result?.[0];
+    if (!result) return null;
+
+    let price: number | undefined = result.meta?.regularMarketPrice;
+    if (req.historical) {
+      // Last close on or before the day (markets close on weekends)
+      const closes: Array<number | null> =
+        result.indicators?.quote?.[0]?.close || [];
+      const at = req.at;
+      const until = Date.UTC(
+        at.getUTCFullYear(),
+        at.getUTCMonth(),
+        at.getUTCDate() + 1,
+      );
+      price = undefined;
+      (result.timestamp || []).forEach((ts: number, i: number) => {
+        const close = closes[i];
+        if (ts * 1000 < until && close && close > 0) price = close;
+      });
+    }
+    if (!price || !(price > 0)) return null;
+
+    const currency = String(result.meta?.currency || "USD").toUpperCase();
+    return currency === "USD" ? price : req.toUSD(price, currency);
+  },
+});
+
+// Needs ALPHA_VANTAGE_API_KEY; the free tier serves about 100 days back
+registerPriceProvider({
+  name: "ALPHA_VANTAGE",
+  assetKinds: ["EQUITY"],
+  available: () => !!config.alphaVantageApiKey,
+  async fetch(req) {
+    const key = config.alphaVantageApiKey;
+    if (!key) return null;
+    const symbol = encodeURIComponent(req.asset.symbol.toUpperCase());
+    const base = `https://www.alphavantage.co/query?symbol=${symbol}&apikey=${key}`;
+
+    if (!req.historical) {
+      const data: any = await req.get(`${base}&function=GLOBAL_QUOTE`);
+      const price = Number(data?.["Global Quote"]?.["05. price"]);
+      return price > 0 ? price : null;
+    }
+    const data: any = await req.get(
+      `${base}&function=TIME_SERIES_DAILY&outputsize=compact`,
+    );
+    const series: Record<string, any> = data?.["Time Series (Daily)"] || {};
+    const day = Object.keys(series)
+      .filter((d) => d <= dayOf(req.at))
+      .sort()
+      .pop();
+    const price = day ? Number(series[day]["4. close"]) : 0;
+    return price > 0 ? price : null;
+  },
+});
@@ -0,0 +1,119 @@
+import { Asset, Rate, assetKey } from "../types";
+import { borrowingRepository, settingsRepository } from "../repositories";
+import { config } from "../core/config";
+import { logger } from "../utils/logger";
+import { priceService } from "./price.service";
+import { vaultService } from "./vault.service";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const CHECK_INTERVAL_MS = 60 * 60 * 1000; // hourly
+let schedulerStarted = false;
+
+export interface PriceSnapshotResult {
+  day: string; // YYYY-MM-DD
+  assets: number;
+  saved: Rate[];
+  failed: Asset[];
+}
+
+/** The UTC day that closed most recently before `now`. */
+export function lastClosedDay(now: Date): string {
+  return new Date(now.getTime() - DAY_MS).toISOString().slice(0, 10);
+}
+
+/**
+ * End-of-day prices for everything held. After each UTC day closes, the
+ * rate of every held asset and of the currencies reports convert to is
+ * stored in the price cache as that day's rate. Reports valuing a day
+ * then read its close, and when a provider is down the last cached rate
+ * they fall back on is at most a day old.
+ */
+export class PriceSnapshotService {
+  /** Held vault assets, active borrowings and VND, without USD. */
+  snapshotAssets(): Asset[] {
+    const out = new Map<string, Asset>();
+    const add = (a: Asset) => {
+      if (a.symbol.toUpperCase() !== "USD") out.set(assetKey(a), a);
+    };
+    vaultService.heldAssets().forEach(add);
+    borrowingRepository.findByStatus("ACTIVE").forEach((b) => add(b.asset));
+    add({ type: "FIAT", symbol: "VND" }); // reports also show VND
+    return Array.from(out.values());
+  }
+
+  /**
+   * Snapshot the closing rates of `day`. The day is recorded as done once
+   * any rate was saved; assets that failed keep their earlier rate.
+   */
+  async takeSnapshot(
+    day: string = lastClosedDay(new Date()),
+    now: Date = new Date(),
+  ): Promise<PriceSnapshotResult> {
+    const assets = this.snapshotAssets();
+    const saved: Rate[] = [];
+    const failed: Asset[] = [];
+
+    for (const asset of assets) {
+      try {
+        const rate = await priceService.snapshotRate(
+          asset,
+          new Date(`${day}T00:00:00.000Z`),
+          now,
+        );
+        if (rate) saved.push(rate);
+        else failed.push(asset);
+      } catch (e: any) {
+        logger.warn(
+          { asset: assetKey(asset), day, error: e?.message },
+          "Price snapshot failed",
+        );
+        failed.push(asset);
+      }
+    }
+
+    const last = settingsRepository.getLastPriceSnapshotDay();
+    if ((saved.length || !assets.length) && (!last || day > last)) {
+      settingsRepository.setLastPriceSnapshotDay(day);
+    }
+    logger.info(
+      {
+        day,
+        assets: assets.length,
+        saved: saved.length,
+        failed: failed.length,
+      },
+      "Price snapshot taken",
+    );
+    return { day, assets: assets.length, saved, failed };
+  }
+
+  /** Snapshot the last closed day unless it was already taken. */
+  async processDue(
+    now: Date = new Date(),
+  ): Promise<PriceSnapshotResult | undefined> {
+    if (config.noExternalRates) return undefined;
+    const day = lastClosedDay(now);
+    const last = settingsRepository.getLastPriceSnapshotDay();
+    if (last && last >= day) return undefined;
+    return this.takeSnapshot(day, now);
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = async () => {
+      try {
+        await this.processDue();
+      } catch (e: any) {
+        logger.warn({ error: e?.message }, "Price snapshot check failed");
+      }
+    };
+    void run();
+    setInterval(() => {
+      void run();
+    }, CHECK_INTERVAL_MS);
+  }
+}
+
+export const priceSnapshotService = new PriceSnapshotService();
@@ -1,12 +1,27 @@
 import axios from "axios";
-import { Asset, Rate, assetKey } from "../types";
+import { Asset, PriceProvider, Rate, assetKey } from "../types";
 import { config } from "../core/config";
 import { priceCacheRepository } from "../repositories/price-cache.repository";
+import { settingsRepository } from "../repositories";
+import { notificationService } from "./notification.service";
 import { logger } from "../utils/logger";
 import pLimit from "p-limit";
 import { createAssetFromSymbol } from "../utils/asset.util";
-
-const limit = pLimit(1); // 🔒 sequential requests to avoid rate limits
+import { priceOverride } from "../utils/price-override.util";
+import { JobContext } from "./job.service";
+import { assetPriceService } from "./asset-price.service";
+import {
+  PriceRequest,
+  cryptoIdForSymbol,
+  getPriceProvider,
+} from "./price-provider.service";
+
+const BATCH_CONCURRENCY = 4; // parallel misses; HTTP still one per host
+const WARMUP_INTERVAL_MS = 24 * 60 * 60 * 1000; // daily
+let warmupStarted = false;
+const DAY_MS = 24 * 60 * 60 * 1000;
+// How long after a day closes the live rate still stands for its close
+const SNAPSHOT_LIVE_WINDOW_MS = 6 * 60 * 60 * 1000;
 
 function toDayISO(date: Date): string {
   const d = new Date(date); // Don't mutate the original
@@ -18,235 +33,370 @@ async function delay(ms: number): Promise<void> {
   return new Promise((resolve) => setTimeout(resolve, ms));
 }
 
-export function cryptoIdForSymbol(symbol: string): string {
-  const sym = symbol.toUpperCase();
-  const map: Record<string, string> = {
-    BTC: "bitcoin",
-    ETH: "ethereum",
-    SOL: "solana",
-    USDT: "tether",
-    USDC: "usd-coin",
-    BNB: "binancecoin",
-    XRP: "ripple",
-    ADA: "cardano",
-    DOGE: "dogecoin",
-    TRX: "tron",
-    DOT: "polkadot",
-    MATIC: "matic-network",
-    AVAX: "avalanche-2",
-    XAU: "pax-gold", // PAXG - gold-backed token for accurate gold price
-  };
-  return map[sym] || sym.toLowerCase();
+// Provider protection: per-host spacing, retry with jitter, circuit breaker
+const MIN_INTERVAL_MS: Record<string, number> = {
+  "api.coingecko.com": 1500, // free tier allows ~30 calls/min
+  "www.alphavantage.co": 12000, // free tier allows 5 calls/min
+};
+const DEFAULT_MIN_INTERVAL_MS = 200;
+const RETRY_BASE_MS = 1000;
+const RETRY_MAX_MS = 30000;
+const BREAKER_THRESHOLD = 5; // consecutive failures before opening
+const BREAKER_COOLDOWN_MS = 60000;
+
+const lastRequestAt = new Map<string, number>();
+const breakers = new Map<string, { failures: number; openUntil: number }>();
+// 🔒 sequential requests per host to avoid rate limits; a slow or dead host
+// only holds up its own queue
+const limiters = new Map<string, ReturnType<typeof pLimit>>();
+
+function limiterFor(host: string): ReturnType<typeof pLimit> {
+  let limit = limiters.get(host);
+  if (!limit) {
+    limit = pLimit(1);
+    limiters.set(host, limit);
+  }
+  return limit;
+}
+
+function circuitOpen(host: string): boolean {
+  const breaker = breakers.get(host);
+  return !!breaker && breaker.openUntil > Date.now();
+}
+
+export class CircuitOpenError extends Error {
+  constructor(public host: string) {
+    super(`Price provider ${host} unavailable (circuit open)`);
+    this.name = "CircuitOpenError";
+  }
+}
+
+function hostOf(url: string): string {
+  try {
+    return new URL(url).host;
+  } catch {
+    return url;
+  }
+}
+
+// 429, 5xx and network/timeouts are worth retrying; other 4xx are not
+function isRetryable(err: any): boolean {
+  const status = err?.response?.status;
+  return !status || status === 429 || status >= 500;
+}
+
+// Exponential backoff with "equal jitter"; honours Retry-After when present
+function backoffMs(attempt: number, err: any): number {
+  const retryAfter = Number(err?.response?.headers?.["retry-after"]);
+  if (retryAfter > 0) return Math.min(RETRY_MAX_MS, retryAfter * 1000);
+  const exp = Math.min(RETRY_MAX_MS, RETRY_BASE_MS * Math.pow(2, attempt));
+  return exp / 2 + Math.random() * (exp / 2);
+}
+
+function recordProviderResult(host: string, ok: boolean): void {
+  if (ok) {
+    breakers.delete(host);
+    return;
+  }
+  const state = breakers.get(host) || { failures: 0, openUntil: 0 };
+  state.failures += 1;
+  if (state.failures >= BREAKER_THRESHOLD) {
+    state.openUntil = Date.now() + BREAKER_COOLDOWN_MS;
+    logger.warn(
+      { host, failures: state.failures, cooldownMs: BREAKER_COOLDOWN_MS },
+      "Price provider circuit opened",
+    );
+  }
+  breakers.set(host, state);
+}
+
+export interface PriceQuote {
+  source: PriceProvider;
+  rateUSD: number;
+}
+
+export interface PriceDiscrepancy {
+  asset: string; // asset key, e.g. CRYPTO:BTC
+  day: string;
+  primary: PriceQuote; // the rate that was used
+  quotes: Array<PriceQuote & { deviationPercent: number }>;
+  thresholdPercent: number;
+  detectedAt: string;
 }
 
+const MAX_DISCREPANCIES = 100;
+const discrepancies: PriceDiscrepancy[] = [];
+
 const cache = new Map<string, Rate>();
 
 export class PriceService {
   private async limitedGet<T>(
     url: string,
     timeout = 8000,
-    retries = 5,
+    retries = 4,
   ): Promise<T> {
-    return limit(async () => {
-      let lastError: Error | null = null;
+    const host = hostOf(url);
+    // Fail fast while open; after the cooldown one request probes the host
+    if (circuitOpen(host)) throw new CircuitOpenError(host);
+
+    return limiterFor(host)(async () => {
+      // Queued before the circuit opened: do not retry a host now known dead
+      if (circuitOpen(host)) throw new CircuitOpenError(host);
+      let lastError: any = null;
       for (let attempt = 0; attempt < retries; attempt++) {
+        const spacing = MIN_INTERVAL_MS[host] ?? DEFAULT_MIN_INTERVAL_MS;
+        const wait = (lastRequestAt.get(host) || 0) + spacing - Date.now();
+        if (wait > 0) await delay(wait);
+        lastRequestAt.set(host, Date.now());
+
         try {
-          // Add base delay between all requests to CoinGecko
-          if (url.includes("coingecko.com") && attempt === 0) {
-            await delay(1500); // 1.5s delay before each CoinGecko request
-          }
           const res = await axios.get<T>(url, { timeout });
+          recordProviderResult(host, true);
           return res.data;
         } catch (err: any) {
           lastError = err;
-          if (err.response?.status === 429) {
-            const waitTime = Math.pow(2, attempt + 2) * 1000; // 4s, 8s, 16s, 32s, 64s
-            logger.warn(
-              { url, attempt, waitTime },
-              "Rate limited, waiting before retry",
-            );
-            await delay(waitTime);
-          } else {
-            throw err;
-          }
+          if (!isRetryable(err)) throw err;
+          if (attempt === retries - 1) break;
+          const waitTime = backoffMs(attempt, err);
+          logger.warn(
+            { url, attempt, waitTime, status: err.response?.status },
+            "Price provider error, retrying",
+          );
+          await delay(waitTime);
         }
       }
+      recordProviderResult(host, false);
       throw lastError;
     });
   }
 
-  private async fetchFiatUsdRate(
-    sym: string,
-  ): Promise<{ rate: number; source: Rate["source"] } | null> {
-    const symbol = sym.toUpperCase();
-
-    try {
-      const data: any = await this.limitedGet(
-        `https://api.exchangerate.host/latest?base=${symbol}&symbols=USD`,
-      );
-      const v = data?.rates?.USD;
-      if (v > 0) return { rate: v, source: "EXCHANGE_RATE_HOST" };
-    } catch (err: any) {
-      logger.debug({ symbol, error: err.message }, "exchangerate.host failed");
-    }
+  /**
+   * Ask the asset's providers in priority order. The default order stops at
+   * the first answer; a configured list with several providers asks them
+   * all and cross-checks the answers against the first.
+   */
+  private async fetchRate(
+    asset: Asset,
+    at: Date,
+    historical: boolean,
+  ): Promise<PriceQuote | null> {
+    const { providers, configured } = assetPriceService.providersFor(
+      asset,
+      historical,
+    );
+    const crossCheck = configured && providers.length > 1;
+    const request: PriceRequest = {
+      asset,
+      at,
+      historical,
+      get: (url, timeout) => this.limitedGet(url, timeout),
+      toUSD: async (amount, currency) => {
+        const fx = await this.getRateUSD(
+          { type: "FIAT", symbol: currency },
+          historical ? at.toISOString() : undefined,
+        );
+        return fx.missing ? null : amount * fx.rateUSD;
+      },
+    };
 
-    try {
-      const data: any = await this.limitedGet(
-        `https://api.frankfurter.app/latest?from=${symbol}&to=USD`,
-      );
-      const v = data?.rates?.USD;
-      if (v > 0) return { rate: v, source: "FRANKFURTER" };
-    } catch (err: any) {
-      logger.debug({ symbol, error: err.message }, "frankfurter.app failed");
+    const quotes: PriceQuote[] = [];
+    for (const source of providers) {
+      try {
+        const v = await getPriceProvider(source)?.fetch(request);
+        if (v && v > 0) quotes.push({ source, rateUSD: v });
+      } catch (err: any) {
+        logger.debug(
+          { asset: assetKey(asset), source, error: err.message },
+          "Price provider failed",
+        );
+      }
+      if (quotes.length && !crossCheck) break;
     }
 
-    try {
-      const data: any = await this.limitedGet(
-        `https://open.er-api.com/v6/latest/${symbol}`,
-      );
-      const v = data?.rates?.USD;
-      if (v > 0) return { rate: v, source: "ER_API" };
-    } catch (err: any) {
-      logger.debug({ symbol, error: err.message }, "er-api.com failed");
+    if (!quotes.length) {
+      if (providers.length) {
+        logger.warn(
+          { asset: assetKey(asset), providers },
+          "All price sources failed",
+        );
+      }
+      return null;
     }
-
-    logger.warn({ symbol }, "All fiat rate sources failed");
-    return null;
+    if (quotes.length > 1) this.recordDiscrepancy(asset, at, quotes);
+    return quotes[0];
   }
 
-  private async fetchHistoricalCryptoPrice(
-    id: string,
+  private recordDiscrepancy(
+    asset: Asset,
     at: Date,
-  ): Promise<number | null> {
-    try {
-      const days =
-        Math.ceil((Date.now() - at.getTime()) / (1000 * 60 * 60 * 24)) || 1;
-
-      if (days < 0 || days > 365) return null;
+    [primary, ...others]: PriceQuote[],
+  ): void {
+    const thresholdPercent =
+      settingsRepository.getPriceDiscrepancyThresholdPercent();
+    const quotes = others.map((q) => ({
+      ...q,
+      deviationPercent:
+        (Math.abs(q.rateUSD - primary.rateUSD) / primary.rateUSD) * 100,
+    }));
+    if (!quotes.some((q) => q.deviationPercent > thresholdPercent)) return;
+
+    const discrepancy: PriceDiscrepancy = {
+      asset: assetKey(asset),
+      day: toDayISO(at),
+      primary,
+      quotes,
+      thresholdPercent,
+      detectedAt: new Date().toISOString(),
+    };
+    discrepancies.unshift(discrepancy);
+    if (discrepancies.length > MAX_DISCREPANCIES) discrepancies.pop();
 
-      const data: any = await this.limitedGet(
-        `https://api.coingecko.com/api/v3/coins/${id}/market_chart?vs_currency=usd&days=${days}&interval=daily`,
-        10000,
+    logger.warn(
+      { asset: discrepancy.asset, primary, quotes, thresholdPercent },
+      "Price providers disagree",
+    );
+    const summary = quotes
+      .map(
+        (q) => `${q.source} ${q.rateUSD} (${q.deviationPercent.toFixed(2)}%)`,
+      )
+      .join(", ");
+    notificationService
+      .send({
+        kind: "price_discrepancy",
+        title: `${asset.symbol} price sources disagree`,
+        body: `${primary.source} ${primary.rateUSD} vs ${summary}`,
+        data: discrepancy,
+      })
+      .catch((err: any) =>
+        logger.warn(
+          { error: err?.message },
+          "Price discrepancy notification failed",
+        ),
       );
+  }
 
-      const prices = data?.prices;
-      if (!Array.isArray(prices)) return null;
-
-      const target = at.getTime();
-      let closest = prices[0][1];
-      let minDiff = Math.abs(prices[0][0] - target);
-
-      for (const [ts, price] of prices) {
-        const diff = Math.abs(ts - target);
-        if (diff < minDiff) {
-          minDiff = diff;
-          closest = price;
-        }
-      }
+  /** Disagreements found while cross-checking providers, newest first. */
+  getDiscrepancies(): PriceDiscrepancy[] {
+    return [...discrepancies];
+  }
 
-      return closest > 0 ? closest : null;
-    } catch (err: any) {
-      logger.warn(
-        { id, at: at.toISOString(), error: err.message },
-        "Historical crypto price fetch failed",
-      );
-      return null;
+  /**
+   * Live CoinGecko quotes for several symbols in one request, bypassing the
+   * daily cache. Symbols the provider doesn't return are left out.
+   */
+  async fetchCurrentCryptoPrices(
+    symbols: string[],
+  ): Promise<Record<string, number>> {
+    const out: Record<string, number> = {};
+    if (config.noExternalRates || !symbols.length) return out;
+
+    const ids = new Map(symbols.map((s) => [cryptoIdForSymbol(s), s]));
+    const data: any = await this.limitedGet(
+      "https://api.coingecko.com/api/v3/simple/price?ids=" +
+        `${Array.from(ids.keys()).join(",")}&vs_currencies=usd`,
+    );
+    for (const [id, symbol] of ids) {
+      const v = data?.[id]?.usd;
+      if (v > 0) out[symbol.toUpperCase()] = v;
     }
+    return out;
   }
 
-  private async fetchHistoricalFiatPrice(
-    symbol: string,
-    at: Date,
-  ): Promise<{ rate: number; source: Rate["source"] } | null> {
-    // VND is not supported by Frankfurter, use ExchangeRate-API current rate
-    if (symbol.toUpperCase() === "VND") {
-      const apiKey = config.exchangeRateApiKey || "ce0562d3379ec1b87fd2d324";
-      try {
-        const data: any = await this.limitedGet(
-          `https://v6.exchangerate-api.com/v6/${apiKey}/latest/USD`,
-        );
-        const vndRate = data?.conversion_rates?.VND;
-        if (vndRate > 0) {
-          // VND rate is USD->VND, we need VND->USD
-          return { rate: 1 / vndRate, source: "EXCHANGE_RATE_API" };
-        }
-      } catch (err: any) {
-        logger.warn(
-          { symbol, at: at.toISOString(), error: err.message },
-          "VND price fetch from ExchangeRate-API failed",
-        );
+  /**
+   * Drop placeholder rates (no provider answered) cached before `before`,
+   * in memory and on disk, so they are fetched again. Returns rows deleted.
+   */
+  pruneCache(before: Date): number {
+    for (const [key, rate] of cache) {
+      if (rate.source === "FIXED" && rate.asset.symbol !== "USD") {
+        cache.delete(key);
       }
-      return null;
     }
+    return priceCacheRepository.deletePlaceholdersBefore(before);
+  }
 
-    try {
-      const date = at.toISOString().split("T")[0];
-      const data: any = await this.limitedGet(
-        `https://api.frankfurter.app/${date}?from=${symbol}&to=USD`,
-      );
-      const v = data?.rates?.USD;
-      if (v > 0) return { rate: v, source: "FRANKFURTER" };
-    } catch (err: any) {
-      logger.warn(
-        { symbol, at: at.toISOString(), error: err.message },
-        "Historical fiat price fetch failed",
-      );
-    }
-    return null;
+  /** Cached rate for the asset/day, without hitting any provider. */
+  getCachedRate(asset: Asset, atISO?: string): Rate | null {
+    const at = atISO ? new Date(atISO) : new Date();
+    const key = `${assetKey(asset)}:${toDayISO(new Date(at))}`;
+
+    const cached = cache.get(key) ?? priceCacheRepository.getByCacheKey(key);
+    if (cached) cache.set(key, cached);
+    return cached ?? null;
   }
 
   async getRateUSD(asset: Asset, atISO?: string): Promise<Rate> {
     const at = atISO ? new Date(atISO) : new Date();
     const key = `${assetKey(asset)}:${toDayISO(new Date(at))}`;
 
-    const cached = cache.get(key) ?? priceCacheRepository.getByCacheKey(key);
-    if (cached) {
-      cache.set(key, cached);
-      return cached;
+    // A report's ?prices= override wins over cache and providers, and is
+    // never cached
+    const override = priceOverride(asset.symbol);
+    if (override !== undefined) {
+      return {
+        asset,
+        rateUSD: override,
+        timestamp: at.toISOString(),
+        source: "FIXED",
+        override: true,
+      };
+    }
+
+    // Manual prices can change at any time, so they aren't cached either
+    const manual = assetPriceService.manualPrice(asset, at);
+    if (manual) {
+      return {
+        asset,
+        rateUSD: manual.priceUSD,
+        timestamp: manual.at,
+        source: "MANUAL",
+      };
     }
 
+    const cached = this.getCachedRate(asset, atISO);
+    if (cached) return cached;
+
     let rateUSD = 1;
     let source: Rate["source"] = "FIXED";
 
-    if (!config.noExternalRates) {
+    if (!config.noExternalRates && asset.symbol !== "USD") {
       const isHistorical = !!atISO && at < new Date();
+      const quote = await this.fetchRate(asset, at, isHistorical);
+      if (quote) {
+        rateUSD = quote.rateUSD;
+        source = quote.source;
+      }
+    }
 
-      if (asset.symbol === "USD") {
-        rateUSD = 1;
-      } else if (asset.type === "FIAT") {
-        const res = isHistorical
-          ? await this.fetchHistoricalFiatPrice(asset.symbol, at)
-          : await this.fetchFiatUsdRate(asset.symbol);
-        if (res) {
-          rateUSD = res.rate;
-          source = res.source;
-        }
-      } else {
-        const id = cryptoIdForSymbol(asset.symbol);
-        if (isHistorical) {
-          const v = await this.fetchHistoricalCryptoPrice(id, at);
-          if (v) {
-            rateUSD = v;
-            source = "COINGECKO";
-          }
-        } else {
-          try {
-            const data: any = await this.limitedGet(
-              `https://api.coingecko.com/api/v3/simple/price?ids=${id}&vs_currencies=usd`,
-            );
-            const v = data?.[id]?.usd;
-            if (v > 0) {
-              rateUSD = v;
-              source = "COINGECKO";
-            }
-          } catch (err: any) {
-            logger.warn(
-              { id, error: err.message },
-              "Current crypto price fetch failed",
-            );
-          }
-        }
+    // Provider down or rate limited: serve the last known rate, flagged stale
+    // and not cached, so the next lookup retries the provider
+    const unresolved =
+      source === "FIXED" && asset.symbol !== "USD" && !config.noExternalRates;
+    if (unresolved) {
+      const last = priceCacheRepository.getLatestRate(asset, at);
+      if (last) {
+        logger.warn(
+          {
+            asset: assetKey(asset),
+            at: at.toISOString(),
+            from: last.timestamp,
+          },
+          "Price provider unavailable, serving last cached rate",
+        );
+        return { ...last, asset, stale: true };
       }
+      // Nothing to fall back on: a placeholder, flagged and not cached either
+      logger.warn(
+        { asset: assetKey(asset), at: at.toISOString() },
+        "Price provider unavailable and no cached rate",
+      );
+      return {
+        asset,
+        rateUSD,
+        timestamp: toDayISO(at),
+        source,
+        missing: true,
+      };
     }
 
     const rate: Rate = {
@@ -261,7 +411,120 @@ export class PriceService {
     return rate;
   }
 
-  async syncHistoricalPrices(days: number): Promise<void> {
+  /**
+   * Resolve many asset/day rates at once. Duplicates are collapsed, cache
+   * hits are served directly and misses are fetched concurrently.
+   */
+  async getRatesBatch(
+    requests: Array<{ asset: Asset; atISO?: string }>,
+  ): Promise<Array<{ rate: Rate; cached: boolean }>> {
+    const keyOf = (r: { asset: Asset; atISO?: string }) => {
+      const at = r.atISO ? new Date(r.atISO) : new Date();
+      return `${assetKey(r.asset)}:${toDayISO(at)}`;
+    };
+
+    const resolved = new Map<string, { rate: Rate; cached: boolean }>();
+    const misses = new Map<string, { asset: Asset; atISO?: string }>();
+    for (const r of requests) {
+      const key = keyOf(r);
+      if (resolved.has(key) || misses.has(key)) continue;
+      const hit = this.getCachedRate(r.asset, r.atISO);
+      if (hit) resolved.set(key, { rate: hit, cached: true });
+      else misses.set(key, r);
+    }
+
+    const batchLimit = pLimit(BATCH_CONCURRENCY);
+    await Promise.all(
+      Array.from(misses.entries()).map(([key, r]) =>
+        batchLimit(async () => {
+          const rate = await this.getRateUSD(r.asset, r.atISO);
+          resolved.set(key, { rate, cached: false });
+        }),
+      ),
+    );
+
+    return requests.map((r) => resolved.get(keyOf(r))!);
+  }
+
+  /**
+   * Store the end-of-day rate of `day` (UTC midnight), replacing what was
+   * cached for it, such as the morning warm-up. Shortly after the day
+   * closes that is the live rate; later on it is the provider's historical
+   * rate. Returns null, leaving the cache as is, when no provider answers.
+   */
+  async snapshotRate(
+    asset: Asset,
+    day: Date,
+    now: Date = new Date(),
+  ): Promise<Rate | null> {
+    if (config.noExternalRates || asset.symbol === "USD") return null;
+
+    const start = toDayISO(day);
+    const close = new Date(new Date(start).getTime() + DAY_MS - 1);
+    const live = now.getTime() - close.getTime() <= SNAPSHOT_LIVE_WINDOW_MS;
+    const quote = live
+      ? await this.fetchRate(asset, now, false)
+      : await this.fetchRate(asset, close, true);
+    if (!quote) return null;
+
+    const key = `${assetKey(asset)}:${start}`;
+    const rate: Rate = {
+      asset,
+      rateUSD: quote.rateUSD,
+      timestamp: start,
+      source: quote.source,
+    };
+    cache.set(key, rate);
+    priceCacheRepository.save(rate, key);
+    return rate;
+  }
+
+  /** Fetch today's rate for each asset so later lookups hit the cache. */
+  async warmCache(assets: Asset[]): Promise<void> {
+    if (config.noExternalRates || assets.length === 0) return;
+
+    const started = Date.now();
+    const results = await this.getRatesBatch(
+      assets.map((asset) => ({ asset })),
+    );
+    logger.info(
+      {
+        assets: assets.length,
+        fetched: results.filter((r) => !r.cached).length,
+        stale: results.filter((r) => r.rate.stale).length,
+        durationMs: Date.now() - started,
+      },
+      "Price cache warmed",
+    );
+  }
+
+  /**
+   * Warm the cache for held assets now and then daily; resolves once the
+   * first pass completes.
+   */
+  async startCacheWarmup(getAssets: () => Asset[]): Promise<void> {
+    if (warmupStarted) return;
+    warmupStarted = true;
+
+    const run = async () => {
+      try {
+        await this.warmCache(getAssets());
+      } catch (err: any) {
+        logger.warn({ error: err.message }, "Price cache warm-up failed");
+      }
+    };
+    setInterval(() => {
+      void run();
+    }, WARMUP_INTERVAL_MS);
+    await run();
+  }
+
+  /**
+   * Fetch missing daily rates for the last `days` days of every provider
+   * priced asset. As a job it reports progress per asset and date and can
+   * be cancelled between requests.
+   */
+  async syncHistoricalPrices(days: number, job?: JobContext): Promise<void> {
     if (config.noExternalRates) return;
 
     const db = priceCacheRepository["db"];
@@ -269,15 +532,16 @@ export class PriceService {
       .prepare(`SELECT symbol as asset_symbol FROM admin_assets`)
       .all();
 
-    const assets: Asset[] = assetRows.map((r: any) =>
-      createAssetFromSymbol(r.asset_symbol),
-    );
+    // OTHER assets have no provider; they're priced manually
+    const assets: Asset[] = assetRows
+      .map((r: any) => createAssetFromSymbol(r.asset_symbol))
+      .filter((a) => assetPriceService.defaultProviders(a, true).length > 0);
 
     const end = new Date();
     const start = new Date();
     start.setDate(start.getDate() - days);
 
-    for (const asset of assets) {
+    for (const [index, asset] of assets.entries()) {
       const existing = new Set(
         priceCacheRepository.getTimestampsForAsset(asset),
       );
@@ -301,7 +565,11 @@ export class PriceService {
       // Process sequentially with delays to avoid rate limiting
       let successCount = 0;
       let failCount = 0;
-      for (const date of dates) {
+      for (const [i, date] of dates.entries()) {
+        job?.progress(
+          ((index + i / dates.length) / assets.length) * 100,
+          `${assetKey(asset)} ${toDayISO(date).slice(0, 10)}`,
+        );
         try {
           const rate = await this.getRateUSD(asset, date.toISOString());
           if (rate.source !== "FIXED") {
@@ -322,6 +590,7 @@ export class PriceService {
         }
         // Add delay between requests to respect rate limits
         await delay(5000);
+        await job?.checkpoint();
       }
 
       logger.info(
@@ -0,0 +1,158 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  Project,
+  ProjectCreateRequest,
+  ProjectUpdateRequest,
+  Transaction,
+} from "../types";
+import { projectRepository } from "../repositories";
+import { transactionRepository } from "../repositories";
+import { dateDiffInDays } from "./financial.service";
+
+export interface ProjectReport {
+  project: Project;
+  totalUSD: number;
+  refundsUSD: number;
+  netUSD: number;
+  days: number;
+  dailyBurnUSD: number;
+  byAsset: Record<string, { amount: number; usd: number; count: number }>;
+  byCategory: Record<string, { usd: number; count: number }>;
+  byAccount: Record<string, { usd: number; count: number }>;
+  daily: Array<{ date: string; usd: number }>;
+  transactions: Transaction[];
+}
+
+export class ProjectService {
+  createProject(data: ProjectCreateRequest): Project {
+    const project: Project = {
+      id: uuidv4(),
+      name: data.name.trim(),
+      kind: data.kind,
+      startAt: data.startAt,
+      endAt: data.endAt,
+      note: data.note,
+      status: "ACTIVE",
+      createdAt: new Date().toISOString(),
+    };
+    return projectRepository.create(project);
+  }
+
+  listProjects(status?: string): Project[] {
+    return status
+      ? projectRepository.findByStatus(status)
+      : projectRepository.findAll();
+  }
+
+  getProject(id: string): Project | undefined {
+    return projectRepository.findById(id);
+  }
+
+  updateProject(
+    id: string,
+    updates: ProjectUpdateRequest,
+  ): Project | undefined {
+    return projectRepository.update(id, updates);
+  }
+
+  deleteProject(id: string): boolean {
+    return projectRepository.delete(id);
+  }
+
+  assignTransactions(projectId: string, ids: string[]): Transaction[] {
+    if (!projectRepository.findById(projectId)) {
+      throw new Error("Project not found");
+    }
+    const missing = ids.filter((id) => !transactionRepository.findById(id));
+    if (missing.length) {
+      throw new Error(`Transactions not found: ${missing.join(", ")}`);
+    }
+    return ids.map(
+      (id) => transactionRepository.update(id, { projectId }) as Transaction,
+    );
+  }
+
+  unassignTransaction(projectId: string, txId: string): boolean {
+    const tx = transactionRepository.findById(txId);
+    if (!tx || tx.projectId !== projectId) return false;
+    transactionRepository.update(txId, { projectId: undefined });
+    return true;
+  }
+
+  /**
+   * Cost rollup for a trip/project: expenses across all accounts and
+   * currencies in USD, refunds (income) netted separately, daily burn over
+   * the project window (or first..last transaction when dates are unset).
+   */
+  buildReport(id: string): ProjectReport | undefined {
+    const project = projectRepository.findById(id);
+    if (!project) return undefined;
+
+    const txs = transactionRepository
+      .findByProjectId(id)
+      .sort((a, b) => String(a.createdAt).localeCompare(String(b.createdAt)));
+    const expenses = txs.filter((t) => t.type === "EXPENSE");
+    const refunds = txs.filter((t) => t.type === "INCOME");
+
+    const byAsset: ProjectReport["byAsset"] = {};
+    const byCategory: ProjectReport["byCategory"] = {};
+    const byAccount: ProjectReport["byAccount"] = {};
+    const byDay = new Map<string, number>();
+    let totalUSD = 0;
+
+    for (const t of expenses) {
+      const usd = t.usdAmount || 0;
+      totalUSD += usd;
+
+      const symbol = t.asset.symbol.toUpperCase();
+      const a = (byAsset[symbol] ||= { amount: 0, usd: 0, count: 0 });
+      a.amount += t.amount;
+      a.usd += usd;
+      a.count += 1;
+
+      const category = t.category || "uncategorized";
+      const c = (byCategory[category] ||= { usd: 0, count: 0 });
+      c.usd += usd;
+      c.count += 1;
+
+      const account = t.account || "unknown";
+      const acc = (byAccount[account] ||= { usd: 0, count: 0 });
+      acc.usd += usd;
+      acc.count += 1;
+
+      const day = String(t.createdAt).slice(0, 10);
+      byDay.set(day, (byDay.get(day) || 0) + usd);
+    }
+
+    const refundsUSD = refunds.reduce((s, t) => s + (t.usdAmount || 0), 0);
+
+    const first = project.startAt ?? expenses[0]?.createdAt;
+    const last =
+      project.endAt ??
+      (project.status === "CLOSED"
+        ? expenses[expenses.length - 1]?.createdAt
+        : undefined) ??
+      new Date().toISOString();
+    const days = first
+      ? Math.max(1, dateDiffInDays(new Date(first), new Date(last)) + 1)
+      : 0;
+
+    return {
+      project,
+      totalUSD,
+      refundsUSD,
+      netUSD: totalUSD - refundsUSD,
+      days,
+      dailyBurnUSD: days > 0 ? totalUSD / days : 0,
+      byAsset,
+      byCategory,
+      byAccount,
+      daily: Array.from(byDay.entries())
+        .sort((a, b) => a[0].localeCompare(b[0]))
+        .map(([date, usd]) => ({ date, usd })),
+      transactions: txs,
+    };
+  }
+}
+
+export const projectService = new ProjectService();
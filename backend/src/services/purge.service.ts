@@ -0,0 +1,183 @@
+import { createHash } from "crypto";
+import { RegistryItem, Transaction, VaultEntry, assetKey } from "../types";
+import {
+  transactionRepository,
+  vaultRepository,
+  registryRepository,
+  priceCacheRepository,
+} from "../repositories";
+import { logger } from "../utils/logger";
+
+export interface PurgeCriteria {
+  before?: string; // YYYY-MM-DD; rows dated strictly before are purged
+  account?: string; // purge this account's full history (within `before`)
+}
+
+export interface PurgePlan {
+  criteria: PurgeCriteria;
+  transactions: Transaction[];
+  vaultEntries: VaultEntry[];
+  // Opening balances replacing purged vault entries so holdings still add up
+  carryForward: VaultEntry[];
+  registryItems: RegistryItem[];
+  // Surviving transactions whose reimbursement/transfer link is cleared
+  unlinkedTransactions: string[];
+  priceCacheRows: number;
+  token: string; // must be echoed back to execute this exact plan
+}
+
+export interface PurgeResult {
+  transactions: number;
+  vaultEntries: number;
+  carryForward: number;
+  registryItems: number;
+  unlinkedTransactions: number;
+  priceCacheRows: number;
+}
+
+export class PurgeService {
+  /**
+   * Dry run: everything a purge would delete or rewrite. Nothing changes.
+   */
+  plan(criteria: PurgeCriteria): PurgePlan {
+    const { before, account } = criteria;
+    if (!before && !account) {
+      throw new Error("before or account is required");
+    }
+    if (before && !/^\d{4}-\d{2}-\d{2}$/.test(before)) {
+      throw new Error("before must be a YYYY-MM-DD date");
+    }
+
+    const inScope = (at: string, acct?: string) =>
+      (!before || String(at) < before) && (!account || acct === account);
+
+    const all = transactionRepository.findAll();
+    const transactions = all.filter((t) => inScope(t.createdAt, t.account));
+    const purgedIds = new Set(transactions.map((t) => t.id));
+    const purgedTransfers = new Set(
+      transactions.map((t) => t.transferId).filter(Boolean) as string[],
+    );
+    const unlinkedTransactions = all
+      .filter((t) => !purgedIds.has(t.id))
+      .filter(
+        (t) =>
+          (t.reimbursesId && purgedIds.has(t.reimbursesId)) ||
+          (t.transferId && purgedTransfers.has(t.transferId)),
+      )
+      .map((t) => t.id);
+
+    const vaultEntries = vaultRepository
+      .findAll()
+      .filter((v) => !account || v.name === account)
+      .flatMap((v) => vaultRepository.findAllEntries(v.name))
+      .filter((e) => inScope(e.at, e.vault));
+
+    const registryItems = registryRepository
+      .findAll()
+      .filter((i) => i.transactionId && purgedIds.has(i.transactionId));
+
+    const plan = {
+      criteria,
+      transactions,
+      vaultEntries,
+      carryForward: before ? this.carryForward(vaultEntries, before) : [],
+      registryItems,
+      unlinkedTransactions,
+      // Price history isn't per account, so only date purges touch it
+      priceCacheRows:
+        before && !account
+          ? priceCacheRepository.countBefore(new Date(before))
+          : 0,
+    };
+    return { ...plan, token: this.tokenFor(plan) };
+  }
+
+  /**
+   * Apply a purge. The token from a dry run is required and must still
+   * match, so data added or changed since the dry run is never purged
+   * unseen.
+   */
+  execute(criteria: PurgeCriteria, token: string): PurgeResult {
+    const plan = this.plan(criteria);
+    if (!token || token !== plan.token) {
+      throw new Error("confirm token does not match the current dry run");
+    }
+
+    const purgedIds = new Set(plan.transactions.map((t) => t.id));
+    const purgedTransfers = new Set(plan.transactions.map((t) => t.transferId));
+    for (const id of plan.unlinkedTransactions) {
+      const t = transactionRepository.findById(id);
+      if (!t) continue;
+      const updates: Partial<Transaction> = {};
+      if (t.reimbursesId && purgedIds.has(t.reimbursesId)) {
+        updates.reimbursesId = undefined;
+      }
+      if (t.transferId && purgedTransfers.has(t.transferId)) {
+        updates.transferId = undefined;
+      }
+      transactionRepository.update(id, updates);
+    }
+    for (const item of plan.registryItems) registryRepository.delete(item.id);
+    for (const t of plan.transactions) transactionRepository.delete(t.id);
+
+    const { before, account } = criteria;
+    const vaultEntries = vaultRepository.deleteEntries({
+      vault: account,
+      before,
+    });
+    for (const e of plan.carryForward) vaultRepository.createEntry(e);
+
+    const priceCacheRows = plan.priceCacheRows
+      ? priceCacheRepository.deleteBefore(new Date(before!))
+      : 0;
+
+    const result = {
+      transactions: plan.transactions.length,
+      vaultEntries,
+      carryForward: plan.carryForward.length,
+      registryItems: plan.registryItems.length,
+      unlinkedTransactions: plan.unlinkedTransactions.length,
+      priceCacheRows,
+    };
+    logger.info({ criteria, result }, "Purged historical data");
+    return result;
+  }
+
+  // One opening DEPOSIT per vault and asset with the net units and cost
+  // of the purged deposits/withdrawals, dated at the cutoff
+  private carryForward(entries: VaultEntry[], before: string): VaultEntry[] {
+    const net = new Map<string, VaultEntry>();
+    for (const e of entries) {
+      if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+      const k = `${e.vault}|${assetKey(e.asset)}`;
+      const sign = e.type === "DEPOSIT" ? 1 : -1;
+      const cur: VaultEntry = net.get(k) || {
+        vault: e.vault,
+        type: "DEPOSIT",
+        asset: e.asset,
+        amount: 0,
+        usdValue: 0,
+        at: `${before}T00:00:00.000Z`,
+        note: `Opening balance (history before ${before} purged)`,
+      };
+      cur.amount += sign * e.amount;
+      cur.usdValue += sign * (e.usdValue || 0);
+      net.set(k, cur);
+    }
+    return Array.from(net.values()).filter((e) => e.amount > 1e-12);
+  }
+
+  private tokenFor(plan: Omit<PurgePlan, "token">): string {
+    const fingerprint = JSON.stringify({
+      criteria: plan.criteria,
+      transactions: plan.transactions.map((t) => t.id).sort(),
+      vaultEntries: plan.vaultEntries.length,
+      registryItems: plan.registryItems.map((i) => i.id).sort(),
+      unlinked: [...plan.unlinkedTransactions].sort(),
+      priceCacheRows: plan.priceCacheRows,
+    });
+    return createHash("sha1").update(fingerprint).digest("hex").slice(0, 16);
+  }
+}
+
+export const purgeService = new PurgeService();
@@ -0,0 +1,239 @@
+import { v4 as uuidv4 } from "uuid";
+import { Transaction } from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { ConflictError, NotFoundError } from "../core/errors";
+import { logger } from "../utils/logger";
+import { fxMarginFactor } from "../utils/fx.util";
+import { priceService } from "./price.service";
+import { enrichmentService } from "./enrichment.service";
+import {
+  Job,
+  JobCancelledError,
+  JobContext,
+  jobService,
+} from "./job.service";
+
+const RUNS_KEY = "recalcRuns";
+const KEEP_RUNS = 20;
+
+// fx: reprice every transaction at its date, except rates entered by
+//     hand (FIXED)
+// usd: recompute usdAmount from the stored rate, without pricing
+// enrichment: reprice those still waiting for a provider (missing or
+//     stale rates), like POST /transactions/pending-enrichment/retry
+export const RECALC_KINDS = ["fx", "usd", "enrichment"] as const;
+export type RecalcKind = (typeof RECALC_KINDS)[number];
+
+export type RecalcStatus =
+  | "running"
+  | "succeeded"
+  | "failed"
+  | "cancelled"
+  | "interrupted"; // its job is gone, e.g. the server restarted
+
+/** A recalculation, kept across restarts so it can be resumed. */
+export interface RecalcRun {
+  id: string;
+  kind: RecalcKind;
+  status: RecalcStatus;
+  jobId: string; // latest job; a resume starts a new one
+  batchSize: number;
+  pauseMs: number;
+  total: number;
+  processed: number;
+  changed: number;
+  cursor?: string; // sort key of the last transaction processed
+  error?: string;
+  startedAt: string;
+  updatedAt: string;
+  finishedAt?: string;
+}
+
+// Transactions are processed oldest first; the key orders them stably
+const sortKey = (t: Transaction) => `${t.createdAt}|${t.id}`;
+const sleep = (ms: number) => new Promise((r) => setTimeout(r, ms));
+const signOf = (t: Transaction) => ((t.usdAmount || 0) < 0 ? -1 : 1);
+const same = (a: number, b: number) => Math.abs(a - b) < 1e-9;
+
+/**
+ * Bulk recalculation of transaction values as throttled background jobs.
+ * Work goes in batches of `batchSize` with a `pauseMs` pause in between,
+ * so other requests and writes get their turn, and the position is saved
+ * after every batch: a cancelled, failed or interrupted run resumes from
+ * there instead of from the start. One run goes at a time.
+ */
+export class RecalcService {
+  /** Newest first. */
+  list(): RecalcRun[] {
+    let runs: RecalcRun[] = [];
+    try {
+      runs = JSON.parse(settingsRepository.getSetting(RUNS_KEY) || "[]");
+    } catch {
+      runs = [];
+    }
+    if (!Array.isArray(runs)) return [];
+    // Jobs live in memory, so a restart leaves their runs behind
+    return runs.map((r): RecalcRun =>
+      r.status === "running" && !this.isLive(r)
+        ? { ...r, status: "interrupted" }
+        : r,
+    );
+  }
+
+  get(id: string): RecalcRun | undefined {
+    return this.list().find((r) => r.id === id);
+  }
+
+  start(
+    kind: RecalcKind,
+    opts: { batchSize: number; pauseMs: number },
+  ): { run: RecalcRun; job: Job } {
+    this.assertIdle();
+    const now = new Date().toISOString();
+    const run: RecalcRun = {
+      id: uuidv4(),
+      kind,
+      status: "running",
+      jobId: "",
+      batchSize: opts.batchSize,
+      pauseMs: opts.pauseMs,
+      total: 0,
+      processed: 0,
+      changed: 0,
+      startedAt: now,
+      updatedAt: now,
+    };
+    return this.launch(run);
+  }
+
+  /** Carry on after the last saved batch. */
+  resume(id: string): { run: RecalcRun; job: Job } {
+    const run = this.get(id);
+    if (!run) throw new NotFoundError("recalc run", id);
+    if (run.status === "running" || run.status === "succeeded") {
+      throw new ConflictError(`The run is ${run.status}; nothing to resume`);
+    }
+    this.assertIdle();
+    return this.launch({
+      ...run,
+      status: "running",
+      error: undefined,
+      finishedAt: undefined,
+    });
+  }
+
+  private launch(run: RecalcRun): { run: RecalcRun; job: Job } {
+    const job = jobService.start(`recalc-${run.kind}`, (ctx) =>
+      this.execute(run, ctx),
+    );
+    run.jobId = job.id;
+    this.save(run);
+    return { run, job };
+  }
+
+  private async execute(run: RecalcRun, ctx: JobContext) {
+    try {
+      const rows = this.candidates(run.kind).filter(
+        (t) => !run.cursor || sortKey(t) > run.cursor,
+      );
+      run.total = run.processed + rows.length;
+      for (let i = 0; i < rows.length; i += run.batchSize) {
+        const batch = rows.slice(i, i + run.batchSize);
+        for (const t of batch) {
+          if (await this.recalc(run.kind, t)) run.changed++;
+        }
+        run.processed += batch.length;
+        run.cursor = sortKey(batch[batch.length - 1]);
+        this.save(run);
+        ctx.progress(
+          (run.processed / run.total) * 100,
+          `${run.processed} of ${run.total} transactions`,
+        );
+        await ctx.checkpoint();
+        if (run.pauseMs && i + run.batchSize < rows.length) {
+          await sleep(run.pauseMs);
+        }
+      }
+      this.finish(run, "succeeded");
+      logger.info(
+        { kind: run.kind, processed: run.processed, changed: run.changed },
+        "Recalculation finished",
+      );
+      return {
+        runId: run.id,
+        processed: run.processed,
+        changed: run.changed,
+      };
+    } catch (e: any) {
+      if (e instanceof JobCancelledError) this.finish(run, "cancelled");
+      else this.finish(run, "failed", e?.message || String(e));
+      throw e;
+    }
+  }
+
+  private candidates(kind: RecalcKind): Transaction[] {
+    const rows =
+      kind === "enrichment"
+        ? enrichmentService.pending().map((p) => p.transaction)
+        : transactionRepository.findAll();
+    return rows.sort((a, b) => sortKey(a).localeCompare(sortKey(b)));
+  }
+
+  // Whether the transaction was updated
+  private async recalc(kind: RecalcKind, t: Transaction): Promise<boolean> {
+    if (kind === "usd") {
+      // Mid-market; the repository adds the account's FX margin
+      const usdAmount = signOf(t) * t.amount * (t.rate?.rateUSD || 0);
+      if (same(usdAmount * fxMarginFactor(t), t.usdAmount)) return false;
+      return !!transactionRepository.update(t.id, { usdAmount });
+    }
+
+    if (kind === "fx" && t.rate?.source === "FIXED") return false;
+    const rate = await priceService.getRateUSD(t.asset, t.createdAt);
+    // A placeholder never replaces a rate; a stale one only a missing one
+    if (rate.missing || (rate.stale && !t.rate?.missing)) return false;
+    if (kind === "enrichment" && rate.stale) return false;
+    const usdAmount = signOf(t) * t.amount * rate.rateUSD;
+    const unchanged =
+      same(rate.rateUSD, t.rate?.rateUSD ?? NaN) &&
+      same(usdAmount * fxMarginFactor(t), t.usdAmount) &&
+      !t.rate?.stale;
+    if (unchanged) return false;
+    return !!transactionRepository.update(t.id, { rate, usdAmount });
+  }
+
+  private finish(run: RecalcRun, status: RecalcStatus, error?: string) {
+    run.status = status;
+    run.error = error;
+    run.finishedAt = new Date().toISOString();
+    this.save(run);
+  }
+
+  private isLive(run: RecalcRun): boolean {
+    const job = jobService.get(run.jobId);
+    return job?.status === "queued" || job?.status === "running";
+  }
+
+  private assertIdle(): void {
+    const running = this.list().find((r) => r.status === "running");
+    if (running) {
+      throw new ConflictError("A recalculation is already running", {
+        runId: running.id,
+      });
+    }
+  }
+
+  private save(run: RecalcRun): void {
+    run.updatedAt = new Date().toISOString();
+    const runs = this.list();
+    const i = runs.findIndex((r) => r.id === run.id);
+    if (i >= 0) runs[i] = run;
+    else runs.unshift(run);
+    settingsRepository.setSetting(
+      RUNS_KEY,
+      JSON.stringify(runs.slice(0, KEEP_RUNS)),
+    );
+  }
+}
+
+export const recalcService = new RecalcService();
@@ -0,0 +1,412 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  RecurringCreateRequest,
+  RecurringDrift,
+  RecurringTransaction,
+  RecurringUpdateRequest,
+  Transaction,
+} from "../types";
+import { recurringRepository, transactionRepository } from "../repositories";
+import { transactionService } from "./transaction.service";
+import { precisionService } from "./precision.service";
+import { notificationService } from "./notification.service";
+import {
+  nextOccurrence,
+  occurrences,
+  parseRule,
+} from "../utils/rrule.util";
+import { NotFoundError, ValidationError } from "../core/errors";
+import { logger } from "../utils/logger";
+
+const SCHEDULER_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const DAY_MS = 24 * 60 * 60 * 1000;
+// A charge by the same counterparty this close to a due date is taken as
+// that occurrence's charge
+const MATCH_WINDOW_DAYS = 3;
+// Charges closer than this to the amount are rounding or FX noise
+const DRIFT_THRESHOLD_PERCENT = 1;
+let schedulerStarted = false;
+
+// One handled occurrence and what was actually charged for it
+export interface RecurringCharge {
+  dueAt: string;
+  at: string;
+  amount: number;
+  transactionId: string;
+  matched: boolean; // a separate transaction (e.g. imported), not created here
+}
+
+export interface RecurringRun {
+  recurring: RecurringTransaction;
+  created: Transaction[];
+  queued: string[]; // due dates added to pendingDates
+  error?: string;
+}
+
+function toIso(value: string, field: string): string {
+  const at = new Date(value);
+  if (Number.isNaN(at.getTime())) {
+    throw new ValidationError(`${field} must be a date`);
+  }
+  return at.toISOString();
+}
+
+// Occurrences become transactions with this source ref, so one that was
+// already created (a retry, a confirm racing the scheduler) is not doubled
+function sourceRefFor(r: RecurringTransaction, at: string): string {
+  return `recurring:${r.id}:${at.slice(0, 10)}`;
+}
+
+function sameName(a?: string, b?: string): boolean {
+  return !!a && !!b && a.trim().toLowerCase() === b.trim().toLowerCase();
+}
+
+/**
+ * Income and expenses that repeat on a schedule (rent, salary,
+ * subscriptions). The scheduler creates each occurrence on its due date
+ * through the transaction service, so FX rates are filled in the same way
+ * as for manual entries. With `confirm` set, due occurrences wait in
+ * pendingDates until they are confirmed or skipped.
+ */
+export class RecurringTransactionService {
+  list(): RecurringTransaction[] {
+    return recurringRepository.findAll();
+  }
+
+  get(id: string): RecurringTransaction | undefined {
+    return recurringRepository.findById(id);
+  }
+
+  create(req: RecurringCreateRequest): RecurringTransaction {
+    const rule = parseRule(req.rule);
+    precisionService.validate(req.asset, req.amount);
+    const now = new Date().toISOString();
+    const startAt = req.startAt ? toIso(req.startAt, "startAt") : now;
+    return recurringRepository.create({
+      id: uuidv4(),
+      name: req.name,
+      type: req.type,
+      asset: req.asset,
+      amount: req.amount,
+      account: req.account,
+      note: req.note,
+      category: req.category,
+      tags: req.tags,
+      counterparty: req.counterparty,
+      rule: req.rule,
+      startAt,
+      nextRunAt: nextOccurrence(rule, startAt),
+      occurrences: 0,
+      confirm: req.confirm,
+      active: req.active,
+      createdAt: now,
+    });
+  }
+
+  /**
+   * Changing the rule or start date reschedules from the latest occurrence
+   * already handled, so nothing is created twice or backfilled.
+   */
+  update(
+    id: string,
+    req: RecurringUpdateRequest,
+  ): RecurringTransaction | undefined {
+    const existing = recurringRepository.findById(id);
+    if (!existing) return undefined;
+
+    const updates: Partial<RecurringTransaction> = {
+      ...req,
+      updatedAt: new Date().toISOString(),
+    };
+    if (req.startAt) updates.startAt = toIso(req.startAt, "startAt");
+    if (req.asset || req.amount !== undefined) {
+      precisionService.validate(
+        req.asset ?? existing.asset,
+        req.amount ?? existing.amount,
+      );
+    }
+    // A new amount settles any drift found against the old one
+    if (req.amount !== undefined) updates.drift = undefined;
+    if (req.rule !== undefined || req.startAt !== undefined) {
+      const rule = parseRule(req.rule ?? existing.rule);
+      updates.nextRunAt = nextOccurrence(
+        rule,
+        updates.startAt ?? existing.startAt,
+        existing.lastOccurrenceAt,
+      );
+    }
+    return recurringRepository.update(id, updates);
+  }
+
+  delete(id: string): boolean {
+    return recurringRepository.delete(id);
+  }
+
+  /**
+   * Create the transaction for the occurrence due at `at`, for `amount`
+   * when the charge differed from the entry's amount.
+   */
+  async materialize(
+    r: RecurringTransaction,
+    at: string,
+    amount: number = r.amount,
+  ): Promise<Transaction> {
+    const sourceRef = sourceRefFor(r, at);
+    // Deleted by the user counts as handled: it is not created again
+    const existing = transactionRepository.findBySourceRef(sourceRef, {
+      includeDeleted: true,
+    });
+    if (existing) return existing;
+
+    const params = {
+      asset: r.asset,
+      amount,
+      at,
+      account: r.account,
+      note: r.note ?? r.name,
+      category: r.category,
+      tags: r.tags,
+      counterparty: r.counterparty,
+      sourceRef,
+    };
+    return r.type === "INCOME"
+      ? transactionService.createIncomeTransaction(params)
+      : transactionService.createExpenseTransaction(params);
+  }
+
+  /**
+   * Handle every occurrence due by `now`, catching up on missed ones. A
+   * failure stops that entry at the failed occurrence, which is retried on
+   * the next run.
+   */
+  async processDue(now: Date = new Date()): Promise<RecurringRun[]> {
+    const nowIso = now.toISOString();
+    const runs: RecurringRun[] = [];
+    for (const r of recurringRepository.findDue(nowIso)) {
+      const rule = parseRule(r.rule);
+      const created: Transaction[] = [];
+      const queued: string[] = [];
+      let next = r.nextRunAt;
+      let last = r.lastOccurrenceAt;
+      let error: string | undefined;
+
+      while (next && next <= nowIso) {
+        try {
+          if (r.confirm) queued.push(next);
+          else created.push(await this.materialize(r, next));
+        } catch (e: any) {
+          error = e?.message || String(e);
+          logger.warn(
+            { recurringId: r.id, at: next, error },
+            "Recurring transaction failed",
+          );
+          break;
+        }
+        last = next;
+        next = nextOccurrence(rule, r.startAt, next);
+      }
+
+      const updated = recurringRepository.update(r.id, {
+        nextRunAt: next,
+        lastOccurrenceAt: last,
+        occurrences: r.occurrences + created.length + queued.length,
+        pendingDates: [...(r.pendingDates || []), ...queued],
+        lastError: error,
+      });
+      runs.push({ recurring: updated || r, created, queued, error });
+    }
+    return runs;
+  }
+
+  /**
+   * Create the transaction for a pending occurrence, for `amount` when
+   * the bill came in at a different amount.
+   */
+  async confirm(
+    id: string,
+    date: string,
+    amount?: number,
+  ): Promise<Transaction> {
+    const { r, at } = this.pending(id, date);
+    if (amount !== undefined) {
+      if (!(amount > 0)) throw new ValidationError("amount must be positive");
+      precisionService.validate(r.asset, amount);
+    }
+    const tx = await this.materialize(r, at, amount);
+    const updated = recurringRepository.update(id, {
+      pendingDates: r.pendingDates!.filter((d) => d !== at),
+    });
+    if (updated) await this.checkDrift(updated);
+    return tx;
+  }
+
+  /**
+   * What each handled occurrence actually charged, oldest first. A
+   * transaction by the same counterparty within MATCH_WINDOW_DAYS of the
+   * due date (an imported bank or card charge) wins over the transaction
+   * the schedule created; occurrences with neither are left out.
+   */
+  history(r: RecurringTransaction): RecurringCharge[] {
+    const last = r.lastOccurrenceAt;
+    if (!last) return [];
+    const ownPrefix = `recurring:${r.id}:`;
+    const own = new Map<string, Transaction>();
+    const others: Transaction[] = [];
+    for (const t of transactionRepository.findAll()) {
+      if (t.type !== r.type) continue;
+      if (t.asset.symbol.toUpperCase() !== r.asset.symbol.toUpperCase()) {
+        continue;
+      }
+      if (t.sourceRef?.startsWith(ownPrefix)) {
+        own.set(t.sourceRef.slice(ownPrefix.length), t);
+      } else if (
+        !t.sourceRef?.startsWith("recurring:") &&
+        sameName(t.counterparty, r.counterparty)
+      ) {
+        others.push(t);
+      }
+    }
+
+    const charges: RecurringCharge[] = [];
+    const window = MATCH_WINDOW_DAYS * DAY_MS;
+    for (const dueAt of occurrences(parseRule(r.rule), r.startAt)) {
+      if (dueAt > last) break;
+      const due = new Date(dueAt).getTime();
+      const gap = (t: Transaction) =>
+        Math.abs(new Date(t.createdAt).getTime() - due);
+      const match = others
+        .filter((t) => gap(t) <= window)
+        .sort((a, b) => gap(a) - gap(b))[0];
+      const tx = match ?? own.get(dueAt.slice(0, 10));
+      if (!tx) continue;
+      charges.push({
+        dueAt,
+        at: tx.createdAt,
+        amount: tx.amount,
+        transactionId: tx.id,
+        matched: !!match,
+      });
+    }
+    return charges;
+  }
+
+  /** Drift of the latest charge from the entry's amount, if any. */
+  detectDrift(
+    r: RecurringTransaction,
+    now: Date = new Date(),
+  ): RecurringDrift | undefined {
+    const charges = this.history(r);
+    const latest = charges[charges.length - 1];
+    if (!latest || !(r.amount > 0)) return undefined;
+    const changePercent = ((latest.amount - r.amount) / r.amount) * 100;
+    if (Math.abs(changePercent) <= DRIFT_THRESHOLD_PERCENT) return undefined;
+    return {
+      amount: latest.amount,
+      previousAmount: r.amount,
+      changePercent,
+      chargedAt: latest.at,
+      transactionId: latest.transactionId,
+      detectedAt: now.toISOString(),
+    };
+  }
+
+  /**
+   * Record the entry's drift and notify once per drifted charge; a drift
+   * that went away (the charge went back, or was deleted) is cleared.
+   */
+  async checkDrift(
+    r: RecurringTransaction,
+    now: Date = new Date(),
+  ): Promise<RecurringTransaction> {
+    const drift = this.detectDrift(r, now);
+    if (!drift) {
+      if (!r.drift) return r;
+      return recurringRepository.update(r.id, { drift: undefined }) || r;
+    }
+    if (
+      r.drift?.transactionId === drift.transactionId &&
+      r.drift.amount === drift.amount
+    ) {
+      return r;
+    }
+
+    const updated = recurringRepository.update(r.id, { drift }) || r;
+    const sign = drift.changePercent > 0 ? "+" : "";
+    try {
+      await notificationService.send({
+        kind: "recurring_drift",
+        title:
+          `${r.name} charged ${drift.amount} ${r.asset.symbol} instead of ` +
+          `${r.amount} (${sign}${drift.changePercent.toFixed(1)}%)`,
+        body: `Apply it with POST /api/recurring/${r.id}/drift/apply`,
+        data: { recurringId: r.id, ...drift },
+      });
+    } catch (e: any) {
+      logger.warn(
+        { recurringId: r.id, error: e?.message },
+        "Recurring drift notification failed",
+      );
+    }
+    return updated;
+  }
+
+  /** Check every active entry, e.g. after charges were imported. */
+  async checkDrifts(now: Date = new Date()): Promise<RecurringTransaction[]> {
+    const drifted: RecurringTransaction[] = [];
+    for (const r of recurringRepository.findAll()) {
+      if (!r.active) continue;
+      const checked = await this.checkDrift(r, now);
+      if (checked.drift) drifted.push(checked);
+    }
+    return drifted;
+  }
+
+  /** Take the drifted charge as the entry's new amount. */
+  applyDrift(id: string): RecurringTransaction {
+    const r = recurringRepository.findById(id);
+    if (!r) throw new NotFoundError("Recurring transaction", id);
+    if (!r.drift) {
+      throw new ValidationError(`"${r.name}" has no amount drift to apply`);
+    }
+    return this.update(id, { amount: r.drift.amount })!;
+  }
+
+  /** Drop a pending occurrence without creating a transaction. */
+  skip(id: string, date: string): RecurringTransaction | undefined {
+    const { r, at } = this.pending(id, date);
+    return recurringRepository.update(id, {
+      pendingDates: r.pendingDates!.filter((d) => d !== at),
+    });
+  }
+
+  // The entry and its pending occurrence on `date` (a day or ISO time)
+  private pending(id: string, date: string) {
+    const r = recurringRepository.findById(id);
+    if (!r) throw new NotFoundError("Recurring transaction", id);
+    const day = String(date || "").slice(0, 10);
+    const at = r.pendingDates?.find((d) => d.slice(0, 10) === day);
+    if (!at) {
+      throw new ValidationError(`No pending occurrence on ${day || "?"}`, {
+        pendingDates: r.pendingDates || [],
+      });
+    }
+    return { r, at };
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDue()
+        .then(() => this.checkDrifts())
+        .catch((e: any) =>
+          logger.warn({ error: e?.message }, "Recurring transactions failed"),
+        );
+    };
+    run();
+    setInterval(run, SCHEDULER_INTERVAL_MS);
+  }
+}
+
+export const recurringTransactionService = new RecurringTransactionService();
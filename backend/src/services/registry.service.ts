@@ -0,0 +1,253 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  RegistryCreateRequest,
+  RegistryItem,
+  RegistryUpdateRequest,
+  RenewalCadence,
+} from "../types";
+import { registryRepository, transactionRepository } from "../repositories";
+import { logger } from "../utils/logger";
+import { notificationService } from "./notification.service";
+
+const REMINDER_INTERVAL_MS = 6 * 60 * 60 * 1000; // every 6 hours
+const REMINDER_WINDOW_DAYS = 7;
+const DAY_MS = 24 * 60 * 60 * 1000;
+let schedulerStarted = false;
+
+export type RegistryEventType = "RENEWAL" | "CANCEL_BY" | "WARRANTY_EXPIRY";
+
+export interface RegistryEvent {
+  item: RegistryItem;
+  event: RegistryEventType;
+  at: string;
+  daysUntil: number;
+}
+
+const REMINDER_LABELS: Record<RegistryEventType, string> = {
+  RENEWAL: "renews",
+  CANCEL_BY: "cancellation deadline",
+  WARRANTY_EXPIRY: "warranty expires",
+};
+
+const CADENCE_MONTHS: Record<Exclude<RenewalCadence, "WEEKLY">, number> = {
+  MONTHLY: 1,
+  QUARTERLY: 3,
+  YEARLY: 12,
+};
+
+export function advanceByCadence(
+  iso: string,
+  cadence: RenewalCadence,
+): string {
+  const d = new Date(iso);
+  if (Number.isNaN(d.getTime())) return iso;
+  if (cadence === "WEEKLY") {
+    return new Date(d.getTime() + 7 * DAY_MS).toISOString();
+  }
+  const next = new Date(d);
+  next.setUTCMonth(d.getUTCMonth() + CADENCE_MONTHS[cadence]);
+  return next.toISOString();
+}
+
+function eventsFor(item: RegistryItem): Array<[RegistryEventType, string]> {
+  const events: Array<[RegistryEventType, string]> = [];
+  if (item.kind === "SUBSCRIPTION") {
+    if (item.cancelBy) events.push(["CANCEL_BY", item.cancelBy]);
+    if (item.nextRenewalAt) events.push(["RENEWAL", item.nextRenewalAt]);
+  } else if (item.warrantyExpiresAt) {
+    events.push(["WARRANTY_EXPIRY", item.warrantyExpiresAt]);
+  }
+  return events;
+}
+
+export class RegistryService {
+  /**
+   * Register a subscription or warranty. When linked to an expense, name,
+   * asset and amount default from the transaction and the first renewal is
+   * one cadence after the charge.
+   */
+  createItem(data: RegistryCreateRequest): RegistryItem {
+    const tx = data.transactionId
+      ? transactionRepository.findById(data.transactionId)
+      : undefined;
+    if (data.transactionId && !tx) throw new Error("Transaction not found");
+    if (tx && tx.type !== "EXPENSE") {
+      throw new Error("Only EXPENSE transactions can be registered");
+    }
+    if (data.kind === "SUBSCRIPTION" && !data.cadence) {
+      throw new Error("cadence is required for subscriptions");
+    }
+    if (data.kind === "WARRANTY" && !data.warrantyExpiresAt) {
+      throw new Error("warrantyExpiresAt is required for warranties");
+    }
+
+    const name = data.name || tx?.note || tx?.counterparty || tx?.category;
+    if (!name) throw new Error("name is required");
+
+    let nextRenewalAt = data.nextRenewalAt;
+    if (data.kind === "SUBSCRIPTION" && !nextRenewalAt && tx) {
+      nextRenewalAt = advanceByCadence(tx.createdAt, data.cadence!);
+    }
+
+    const item: RegistryItem = {
+      id: uuidv4(),
+      kind: data.kind,
+      name,
+      transactionId: data.transactionId,
+      asset: data.asset ?? tx?.asset,
+      amount: data.amount ?? tx?.amount,
+      cadence: data.kind === "SUBSCRIPTION" ? data.cadence : undefined,
+      nextRenewalAt,
+      cancelBy: data.cancelBy,
+      warrantyExpiresAt:
+        data.kind === "WARRANTY" ? data.warrantyExpiresAt : undefined,
+      status: "ACTIVE",
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    };
+    return registryRepository.create(item);
+  }
+
+  listItems(filters: { status?: string; kind?: string } = {}): RegistryItem[] {
+    const items = filters.status
+      ? registryRepository.findByStatus(filters.status)
+      : registryRepository.findAll();
+    return filters.kind ? items.filter((i) => i.kind === filters.kind) : items;
+  }
+
+  getItem(id: string): RegistryItem | undefined {
+    return registryRepository.findById(id);
+  }
+
+  findByTransaction(transactionId: string): RegistryItem[] {
+    return registryRepository.findByTransactionId(transactionId);
+  }
+
+  updateItem(
+    id: string,
+    updates: RegistryUpdateRequest,
+  ): RegistryItem | undefined {
+    return registryRepository.update(id, updates);
+  }
+
+  deleteItem(id: string): boolean {
+    return registryRepository.delete(id);
+  }
+
+  /**
+   * Roll past-due subscription renewals forward by their cadence (keeping
+   * the cancel-by offset) and mark lapsed warranties EXPIRED.
+   */
+  advanceRenewals(now: Date = new Date()): number {
+    let changed = 0;
+    for (const item of registryRepository.findByStatus("ACTIVE")) {
+      if (item.kind === "WARRANTY") {
+        const expires = new Date(String(item.warrantyExpiresAt));
+        if (!Number.isNaN(expires.getTime()) && expires < now) {
+          registryRepository.update(item.id, { status: "EXPIRED" });
+          changed += 1;
+        }
+        continue;
+      }
+
+      if (!item.cadence || !item.nextRenewalAt) continue;
+      let next = item.nextRenewalAt;
+      let cancelBy = item.cancelBy;
+      while (new Date(next) < now) {
+        const advanced = advanceByCadence(next, item.cadence);
+        if (advanced === next) break;
+        if (cancelBy) {
+          const offset =
+            new Date(next).getTime() - new Date(cancelBy).getTime();
+          cancelBy = new Date(
+            new Date(advanced).getTime() - offset,
+          ).toISOString();
+        }
+        next = advanced;
+      }
+      if (next !== item.nextRenewalAt) {
+        registryRepository.update(item.id, { nextRenewalAt: next, cancelBy });
+        changed += 1;
+      }
+    }
+    return changed;
+  }
+
+  /** Renewals, cancel-by deadlines and warranty expiries within `days`. */
+  upcoming(days: number, now: Date = new Date()): RegistryEvent[] {
+    const until = now.getTime() + days * DAY_MS;
+    const events: RegistryEvent[] = [];
+    for (const item of registryRepository.findByStatus("ACTIVE")) {
+      for (const [event, at] of eventsFor(item)) {
+        const t = new Date(at).getTime();
+        if (Number.isNaN(t) || t < now.getTime() || t > until) continue;
+        events.push({
+          item,
+          event,
+          at,
+          daysUntil: Math.ceil((t - now.getTime()) / DAY_MS),
+        });
+      }
+    }
+    return events.sort((a, b) => a.at.localeCompare(b.at));
+  }
+
+  /**
+   * Send a registry_reminder notification for each item with an event
+   * inside the reminder window, once per renewal cycle (tracked via
+   * lastNotifiedAt).
+   */
+  async notifyUpcoming(now: Date = new Date()): Promise<RegistryEvent[]> {
+    const notified: RegistryEvent[] = [];
+    const windowMs = REMINDER_WINDOW_DAYS * DAY_MS;
+    for (const ev of this.upcoming(REMINDER_WINDOW_DAYS, now)) {
+      const last = ev.item.lastNotifiedAt
+        ? new Date(ev.item.lastNotifiedAt).getTime()
+        : 0;
+      if (last >= new Date(ev.at).getTime() - windowMs) continue;
+      if (notified.some((n) => n.item.id === ev.item.id)) continue;
+
+      registryRepository.update(ev.item.id, {
+        lastNotifiedAt: now.toISOString(),
+      });
+      notified.push(ev);
+      const days = `${ev.daysUntil} day${ev.daysUntil === 1 ? "" : "s"}`;
+      try {
+        await notificationService.send({
+          kind: "registry_reminder",
+          title: `${ev.item.name}: ${REMINDER_LABELS[ev.event]} in ${days}`,
+          data: {
+            registryId: ev.item.id,
+            event: ev.event,
+            at: ev.at,
+            daysUntil: ev.daysUntil,
+          },
+        });
+      } catch (e: any) {
+        logger.warn(
+          { registryId: ev.item.id, error: e?.message },
+          "Registry reminder notification failed",
+        );
+      }
+    }
+    return notified;
+  }
+
+  startReminderScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = async () => {
+      try {
+        this.advanceRenewals();
+        await this.notifyUpcoming();
+      } catch (e: any) {
+        logger.warn({ error: e?.message }, "Registry reminder run failed");
+      }
+    };
+    run();
+    setInterval(run, REMINDER_INTERVAL_MS);
+  }
+}
+
+export const registryService = new RegistryService();
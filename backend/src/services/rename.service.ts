@@ -0,0 +1,161 @@
+import { adminRepository, settingsRepository } from "../repositories";
+import {
+  EPOCH,
+  NAME_HISTORY_KEY,
+  NameResolver,
+  readNameHistory,
+} from "../repositories/stable-names.repository";
+import { NameHistoryEntry, RenameKind } from "../types";
+import { ConflictError, NotFoundError, ValidationError } from "../core/errors";
+import { accountBalanceService } from "./account-balance.service";
+import { logger } from "../utils/logger";
+
+// Admin entry of `kind` by id, as the label transactions refer to it by
+function labelOf(kind: RenameKind, id: number): string | undefined {
+  if (kind === "account") return adminRepository.findAccountById(id)?.name;
+  return adminRepository.findAssetById(id)?.symbol;
+}
+
+function labels(kind: RenameKind): Array<{ id: number; label: string }> {
+  return kind === "account"
+    ? adminRepository
+        .findAllAccounts()
+        .map((a) => ({ id: a.id, label: a.name }))
+    : adminRepository
+        .findAllAssets()
+        .map((a) => ({ id: a.id, label: a.symbol }));
+}
+
+function resolver(): NameResolver {
+  return new NameResolver(
+    adminRepository.findAllAccounts(),
+    adminRepository.findAllAssets(),
+    readNameHistory(settingsRepository),
+  );
+}
+
+function parseAt(value: string | undefined, field: string): string {
+  if (value === undefined) return new Date().toISOString();
+  const at = new Date(value);
+  if (isNaN(at.getTime())) {
+    throw new ValidationError(`${field} must be an ISO date`);
+  }
+  return at.toISOString();
+}
+
+/**
+ * Date-effective renames of admin accounts and assets (e.g. "Techcombank"
+ * to "TCB"). Transactions keep the name they were written under and are
+ * read back under the current one, so a rename rewrites nothing and
+ * reports keep old and new names together.
+ */
+export class RenameService {
+  rename(
+    kind: RenameKind,
+    id: number,
+    name: string,
+    effectiveFrom?: string,
+    now = new Date(),
+  ): NameHistoryEntry[] {
+    const field = kind === "account" ? "name" : "symbol";
+    const current = labelOf(kind, id);
+    if (!current) throw new NotFoundError(kind, String(id));
+    const next = typeof name === "string" ? name.trim() : "";
+    if (!next) throw new ValidationError(`${field} is required`);
+    if (next === current) return this.history(kind, id);
+    const taken = labels(kind).find(
+      (e) => e.id !== id && e.label.toLowerCase() === next.toLowerCase(),
+    );
+    if (taken) {
+      throw new ConflictError(
+        `${kind} ${next} already exists; merge ${current} into it instead`,
+        { id: taken.id },
+      );
+    }
+
+    const at = parseAt(effectiveFrom, "effective_from");
+    if (at > now.toISOString()) {
+      throw new ValidationError("effective_from cannot be in the future");
+    }
+    const history = readNameHistory(settingsRepository);
+    const own = history.filter((h) => h.kind === kind && h.entityId === id);
+    const last = own.reduce(
+      (max, h) => (h.effectiveFrom > max ? h.effectiveFrom : max),
+      EPOCH,
+    );
+    if (own.length && at <= last) {
+      throw new ValidationError(
+        `effective_from must be after the last rename (${last})`,
+      );
+    }
+    const createdAt = now.toISOString();
+    const entries: NameHistoryEntry[] = [];
+    // The name it had before its first rename ran from the start
+    if (!own.length) {
+      entries.push({
+        kind,
+        entityId: id,
+        name: current,
+        effectiveFrom: EPOCH,
+        createdAt,
+      });
+    }
+    entries.push({
+      kind,
+      entityId: id,
+      name: next,
+      effectiveFrom: at,
+      createdAt,
+    });
+    this.save([...history, ...entries]);
+
+    if (kind === "account") {
+      adminRepository.updateAccount(id, { name: next });
+      // Balances are keyed by account name
+      accountBalanceService.rebuild();
+    } else {
+      adminRepository.updateAsset(id, { symbol: next });
+    }
+    logger.info(
+      { kind, id, from: current, to: next, effectiveFrom: at },
+      `Renamed ${kind} ${current} to ${next}`,
+    );
+    return this.history(kind, id);
+  }
+
+  /** Every name of an entry, oldest first; empty if never renamed. */
+  history(kind: RenameKind, id: number): NameHistoryEntry[] {
+    if (!labelOf(kind, id)) throw new NotFoundError(kind, String(id));
+    return readNameHistory(settingsRepository)
+      .filter((h) => h.kind === kind && h.entityId === id)
+      .sort((a, b) => a.effectiveFrom.localeCompare(b.effectiveFrom));
+  }
+
+  /** The name an entry went by at `at`. */
+  nameAt(kind: RenameKind, id: number, at: string): string {
+    if (!labelOf(kind, id)) throw new NotFoundError(kind, String(id));
+    return resolver().nameAt(kind, id, parseAt(at, "at"))!;
+  }
+
+  /**
+   * Point the names of a merged entry at the one it was merged into, so
+   * transactions written under any of them follow the merge. Call before
+   * the merged entry is removed.
+   */
+  reassign(kind: RenameKind, fromId: number, intoId: number): void {
+    const history = readNameHistory(settingsRepository);
+    // Its own names, and those of entries merged into it before
+    const moves = (h: NameHistoryEntry) =>
+      h.kind === kind && (h.entityId === fromId || h.mergedInto === fromId);
+    if (!history.some(moves)) return;
+    this.save(
+      history.map((h) => (moves(h) ? { ...h, mergedInto: intoId } : h)),
+    );
+  }
+
+  private save(history: NameHistoryEntry[]): void {
+    settingsRepository.setSetting(NAME_HISTORY_KEY, JSON.stringify(history));
+  }
+}
+
+export const renameService = new RenameService();
@@ -0,0 +1,307 @@
+import axios from "axios";
+import { v4 as uuidv4 } from "uuid";
+import {
+  FiscalYearSettings,
+  ReportPeriodMode,
+  ReportSubscription,
+  ReportSubscriptionCreateRequest,
+  ReportSubscriptionUpdateRequest,
+} from "../types";
+import {
+  reportSubscriptionRepository,
+  settingsRepository,
+} from "../repositories";
+import { config } from "../core/config";
+import { advanceByCadence } from "./registry.service";
+import { notificationService } from "./notification.service";
+import { logger } from "../utils/logger";
+import { fiscalYearOf, fiscalYearStart } from "../utils/fiscal-year.util";
+
+const SCHEDULER_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const RENDER_TIMEOUT_MS = 30000;
+const DAY_MS = 24 * 60 * 60 * 1000;
+let schedulerStarted = false;
+
+// Reports that can be subscribed to. `range` names the query parameters
+// the report reads its period from; reports without one ignore the period.
+export const REPORT_TYPES: Record<
+  string,
+  { path: string; range?: [string, string] }
+> = {
+  holdings: { path: "/reports/holdings" },
+  holdings_summary: { path: "/reports/holdings/summary" },
+  pnl: { path: "/reports/pnl" },
+  risk: { path: "/reports/risk" },
+  maturities: { path: "/reports/maturities" },
+  reimbursements: { path: "/reports/reimbursements" },
+  allocation_drift: { path: "/reports/allocation/drift" },
+  transfers_consistency: { path: "/reports/transfers/consistency" },
+  spending: { path: "/reports/spending", range: ["start", "end"] },
+  series: { path: "/reports/series", range: ["start", "end"] },
+  diff: { path: "/reports/diff", range: ["from", "to"] },
+  cashflow: {
+    path: "/reports/cashflow",
+    range: ["start_date", "end_date"],
+  },
+  gas_fees: {
+    path: "/reports/gas-fees",
+    range: ["start_date", "end_date"],
+  },
+};
+
+export interface ReportPeriod {
+  start: string;
+  end: string;
+}
+
+export interface ReportRun {
+  subscription: ReportSubscription;
+  period?: ReportPeriod;
+  status: "OK" | "FAILED";
+  error?: string;
+}
+
+function utcDay(y: number, m: number, d: number): Date {
+  return new Date(Date.UTC(y, m, d));
+}
+
+/**
+ * Resolve a period mode against `now` to whole UTC days. The end is the
+ * last millisecond of the final day so every report treats it inclusively.
+ * Year modes follow the configured fiscal year.
+ */
+export function periodRange(
+  mode: ReportPeriodMode,
+  now: Date = new Date(),
+  fiscal: FiscalYearSettings = settingsRepository.getFiscalYear(),
+): ReportPeriod | undefined {
+  const y = now.getUTCFullYear();
+  const m = now.getUTCMonth();
+  const today = utcDay(y, m, now.getUTCDate());
+
+  let start: Date;
+  let last: Date;
+  switch (mode) {
+    case "last_7_days":
+      start = new Date(today.getTime() - 6 * DAY_MS);
+      last = today;
+      break;
+    case "last_30_days":
+      start = new Date(today.getTime() - 29 * DAY_MS);
+      last = today;
+      break;
+    case "this_month":
+      start = utcDay(y, m, 1);
+      last = today;
+      break;
+    case "last_month":
+      start = utcDay(y, m - 1, 1);
+      last = utcDay(y, m, 0);
+      break;
+    case "year_to_date":
+      start = fiscalYearStart(fiscalYearOf(today, fiscal), fiscal);
+      last = today;
+      break;
+    case "last_year": {
+      const fy = fiscalYearOf(today, fiscal);
+      start = fiscalYearStart(fy - 1, fiscal);
+      last = new Date(fiscalYearStart(fy, fiscal).getTime() - DAY_MS);
+      break;
+    }
+    default: // "none"
+      return undefined;
+  }
+  return {
+    start: start.toISOString(),
+    end: new Date(last.getTime() + DAY_MS - 1).toISOString(),
+  };
+}
+
+/** Query parameters for one run; the resolved period wins over filters. */
+export function buildQuery(
+  sub: ReportSubscription,
+  now: Date = new Date(),
+): Record<string, string> {
+  const params: Record<string, string> = { ...(sub.filters || {}) };
+  const range = REPORT_TYPES[sub.report]?.range;
+  const period = periodRange(sub.periodMode, now);
+  if (range && period) {
+    params[range[0]] = period.start;
+    params[range[1]] = period.end;
+  }
+  return params;
+}
+
+// Reports carry both currencies (total_usd / total_vnd, valueUSD / ...);
+// keep only the subscription's one
+export function pickCurrency(data: any, currency: "USD" | "VND"): any {
+  const other = currency === "USD" ? "vnd" : "usd";
+  if (Array.isArray(data)) return data.map((d) => pickCurrency(d, currency));
+  if (!data || typeof data !== "object") return data;
+
+  const out: Record<string, unknown> = {};
+  for (const [key, value] of Object.entries(data)) {
+    if (key.endsWith(`_${other}`) || key.endsWith(other.toUpperCase())) {
+      continue;
+    }
+    out[key] = pickCurrency(value, currency);
+  }
+  return out;
+}
+
+function assertKnownReport(report: string): void {
+  if (!REPORT_TYPES[report]) {
+    const allowed = Object.keys(REPORT_TYPES).join(", ");
+    throw new Error(`Unknown report "${report}"; allowed: ${allowed}`);
+  }
+}
+
+export class ReportSubscriptionService {
+  list(): ReportSubscription[] {
+    return reportSubscriptionRepository.findAll();
+  }
+
+  get(id: string): ReportSubscription | undefined {
+    return reportSubscriptionRepository.findById(id);
+  }
+
+  create(req: ReportSubscriptionCreateRequest): ReportSubscription {
+    assertKnownReport(req.report);
+    const now = new Date().toISOString();
+    return reportSubscriptionRepository.create({
+      id: uuidv4(),
+      name: req.name,
+      report: req.report,
+      periodMode: req.periodMode,
+      filters: req.filters,
+      currency: req.currency,
+      cadence: req.cadence,
+      nextRunAt: req.nextRunAt ? new Date(req.nextRunAt).toISOString() : now,
+      active: req.active,
+      createdAt: now,
+    });
+  }
+
+  update(
+    id: string,
+    req: ReportSubscriptionUpdateRequest,
+  ): ReportSubscription | undefined {
+    if (req.report !== undefined) assertKnownReport(req.report);
+    const updates: Partial<ReportSubscription> = {
+      ...req,
+      updatedAt: new Date().toISOString(),
+    };
+    if (req.nextRunAt) {
+      updates.nextRunAt = new Date(req.nextRunAt).toISOString();
+    }
+    return reportSubscriptionRepository.update(id, updates);
+  }
+
+  delete(id: string): boolean {
+    return reportSubscriptionRepository.delete(id);
+  }
+
+  /**
+   * Render the report through this server's own API so the output matches
+   * what clients see, trimmed to the subscription's currency.
+   */
+  async render(sub: ReportSubscription, now: Date = new Date()) {
+    assertKnownReport(sub.report);
+    const { path } = REPORT_TYPES[sub.report];
+    const res = await axios.get(`http://127.0.0.1:${config.port}/api${path}`, {
+      params: buildQuery(sub, now),
+      timeout: RENDER_TIMEOUT_MS,
+    });
+    return pickCurrency(res.data, sub.currency);
+  }
+
+  /**
+   * Render and deliver one subscription now and record the outcome.
+   * Running by hand leaves the schedule alone.
+   */
+  async run(
+    id: string,
+    now: Date = new Date(),
+  ): Promise<ReportRun | undefined> {
+    const sub = reportSubscriptionRepository.findById(id);
+    if (!sub) return undefined;
+
+    const period = REPORT_TYPES[sub.report]?.range
+      ? periodRange(sub.periodMode, now)
+      : undefined;
+    let status: ReportRun["status"] = "OK";
+    let error: string | undefined;
+    try {
+      const report = await this.render(sub, now);
+      const span = period
+        ? ` (${period.start.slice(0, 10)} to ${period.end.slice(0, 10)})`
+        : "";
+      await notificationService.send({
+        kind: "report",
+        title: `${sub.name}: ${sub.report}${span}`,
+        data: {
+          subscriptionId: sub.id,
+          report: sub.report,
+          currency: sub.currency,
+          period,
+          result: report,
+        },
+      });
+    } catch (e: any) {
+      status = "FAILED";
+      error = e?.message || String(e);
+      logger.warn(
+        { subscriptionId: sub.id, report: sub.report, error },
+        "Report subscription run failed",
+      );
+    }
+
+    const updated = reportSubscriptionRepository.update(sub.id, {
+      lastRunAt: now.toISOString(),
+      lastStatus: status,
+      lastError: error,
+    });
+    return { subscription: updated || sub, period, status, error };
+  }
+
+  /**
+   * Run every active subscription that is due and move it to its next
+   * slot after `now`. Failed runs move on too; the failure is recorded
+   * on the subscription rather than retried every hour.
+   */
+  async processDue(now: Date = new Date()): Promise<ReportRun[]> {
+    const runs: ReportRun[] = [];
+    const nowIso = now.toISOString();
+    for (const sub of reportSubscriptionRepository.findDue(nowIso)) {
+      const run = await this.run(sub.id, now);
+      if (!run) continue;
+
+      let next = sub.nextRunAt;
+      while (next <= nowIso) {
+        const advanced = advanceByCadence(next, sub.cadence);
+        if (advanced === next) break; // unparseable date; leave it be
+        next = advanced;
+      }
+      const updated = reportSubscriptionRepository.update(sub.id, {
+        nextRunAt: next,
+      });
+      runs.push({ ...run, subscription: updated || run.subscription });
+    }
+    return runs;
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDue().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Report subscription run failed"),
+      );
+    };
+    run();
+    setInterval(run, SCHEDULER_INTERVAL_MS);
+  }
+}
+
+export const reportSubscriptionService = new ReportSubscriptionService();
@@ -0,0 +1,167 @@
+import { Asset, VaultEntry, assetKey } from "../types";
+import { vaultRepository, priceCacheRepository } from "../repositories";
+import {
+  RiskMetrics,
+  addDays,
+  computeRiskMetrics,
+  toISODate,
+} from "./financial.service";
+
+export interface AssetRisk extends RiskMetrics {
+  asset: Asset;
+  currentValueUSD: number;
+}
+
+export interface RiskReport {
+  start: string;
+  end: string;
+  days: number;
+  riskFreeRate: number;
+  portfolio: RiskMetrics;
+  byAsset: AssetRisk[];
+}
+
+function isUSD(asset: Asset): boolean {
+  return asset.type === "FIAT" && asset.symbol.toUpperCase() === "USD";
+}
+
+export class RiskService {
+  /**
+   * Daily USD prices for an asset over the window, forward-filled from the
+   * last stored price. Days before the first known price are null.
+   */
+  private dailyPrices(asset: Asset, days: string[]): (number | null)[] {
+    if (isUSD(asset)) return days.map(() => 1);
+
+    const start = new Date(`${days[0]}T00:00:00.000Z`);
+    const end = new Date(`${days[days.length - 1]}T23:59:59.999Z`);
+    const byDay = new Map<string, number>();
+    for (const r of priceCacheRepository.getRatesInRange(asset, start, end)) {
+      if (!r.timestamp || !(r.rateUSD > 0)) continue;
+      byDay.set(String(r.timestamp).slice(0, 10), r.rateUSD);
+    }
+
+    let last: number | null =
+      priceCacheRepository.getLatestRate(asset, start)?.rateUSD ?? null;
+    return days.map((d) => {
+      const p = byDay.get(d);
+      if (p !== undefined) last = p;
+      return last;
+    });
+  }
+
+  /**
+   * Units held per asset at the end of each day, rebuilt from vault
+   * deposits and withdrawals
+   */
+  private dailyUnits(
+    entries: VaultEntry[],
+    days: string[],
+  ): Map<string, { asset: Asset; units: number[] }> {
+    const sorted = [...entries].sort((a, b) =>
+      String(a.at).localeCompare(String(b.at)),
+    );
+    const running = new Map<string, { asset: Asset; units: number }>();
+    const series = new Map<string, { asset: Asset; units: number[] }>();
+    let i = 0;
+
+    days.forEach((day, idx) => {
+      while (
+        i < sorted.length &&
+        String(sorted[i].at).slice(0, 10) <= day
+      ) {
+        const e = sorted[i++];
+        const k = assetKey(e.asset);
+        const cur = running.get(k) || { asset: e.asset, units: 0 };
+        cur.units += e.type === "DEPOSIT" ? e.amount : -e.amount;
+        running.set(k, cur);
+      }
+      for (const [k, cur] of running) {
+        if (!series.has(k)) {
+          series.set(k, { asset: cur.asset, units: new Array(days.length) });
+          series.get(k)!.units.fill(0, 0, idx);
+        }
+        series.get(k)!.units[idx] = Math.max(0, cur.units);
+      }
+    });
+
+    return series;
+  }
+
+  /**
+   * Volatility, max drawdown and Sharpe ratio over the trailing window.
+   * Per-asset metrics use price returns. Portfolio returns weight each
+   * asset's daily return by its share of the previous day's value, so
+   * deposits and withdrawals don't show up as gains or losses.
+   * @param riskFreeRate Annual risk-free rate as decimal
+   */
+  getRiskReport(days = 90, riskFreeRate = 0, now = new Date()): RiskReport {
+    const end = toISODate(now);
+    const start = toISODate(addDays(now, -days));
+    const dayList: string[] = [];
+    let cursor = new Date(`${start}T00:00:00.000Z`);
+    while (toISODate(cursor) <= end) {
+      dayList.push(toISODate(cursor));
+      cursor = addDays(cursor, 1);
+    }
+
+    const entries = vaultRepository
+      .findAll()
+      .flatMap((v) => vaultRepository.findAllEntries(v.name))
+      .filter((e) => e.type === "DEPOSIT" || e.type === "WITHDRAW");
+    const units = this.dailyUnits(entries, dayList);
+
+    const assets: {
+      asset: Asset;
+      units: number[];
+      prices: (number | null)[];
+    }[] = [];
+    for (const u of units.values()) {
+      if (!u.units.some((n) => n > 1e-12)) continue;
+      assets.push({ ...u, prices: this.dailyPrices(u.asset, dayList) });
+    }
+
+    const byAsset: AssetRisk[] = [];
+    for (const a of assets) {
+      const returns: number[] = [];
+      for (let i = 1; i < dayList.length; i++) {
+        const prev = a.prices[i - 1];
+        const cur = a.prices[i];
+        if (prev === null || cur === null || prev <= 0) continue;
+        if (a.units[i - 1] <= 1e-12) continue;
+        returns.push(cur / prev - 1);
+      }
+      const last = dayList.length - 1;
+      byAsset.push({
+        asset: a.asset,
+        currentValueUSD: a.units[last] * (a.prices[last] ?? 0),
+        ...computeRiskMetrics(returns, riskFreeRate),
+      });
+    }
+
+    const portfolioReturns: number[] = [];
+    for (let i = 1; i < dayList.length; i++) {
+      let value = 0;
+      let pnl = 0;
+      for (const a of assets) {
+        const prev = a.prices[i - 1];
+        const cur = a.prices[i];
+        if (prev === null || cur === null) continue;
+        value += prev * a.units[i - 1];
+        pnl += a.units[i - 1] * (cur - prev);
+      }
+      if (value > 1e-9) portfolioReturns.push(pnl / value);
+    }
+
+    return {
+      start,
+      end,
+      days,
+      riskFreeRate,
+      portfolio: computeRiskMetrics(portfolioReturns, riskFreeRate),
+      byAsset: byAsset.sort((a, b) => b.currentValueUSD - a.currentValueUSD),
+    };
+  }
+}
+
+export const riskService = new RiskService();
@@ -0,0 +1,127 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  Transaction,
+  TransactionRule,
+  TransactionRuleCreateRequest,
+  TransactionRuleUpdateRequest,
+} from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { NotFoundError } from "../core/errors";
+import { applyRules } from "../utils/transaction-rules.util";
+import { logger } from "../utils/logger";
+
+/** A transaction the rules would change, and how. */
+export interface RuleMatch {
+  transactionId: string;
+  ruleIds: string[];
+  before: Partial<Transaction>;
+  changes: Partial<Transaction>;
+}
+
+export interface RuleApplyResult {
+  dryRun: boolean;
+  scanned: number;
+  changed: RuleMatch[];
+}
+
+/**
+ * Auto-tagging and categorization rules (e.g. counterparty contains
+ * "Starbucks" and amount < 10: tag Coffee). Every new transaction goes
+ * through them (see repositories/transaction-rules.repository); existing
+ * ones only when applied here.
+ */
+export class RuleService {
+  list(): TransactionRule[] {
+    return settingsRepository.getTransactionRules();
+  }
+
+  get(id: string): TransactionRule | undefined {
+    return this.list().find((r) => r.id === id);
+  }
+
+  create(data: TransactionRuleCreateRequest): TransactionRule {
+    const rule: TransactionRule = {
+      ...data,
+      id: uuidv4(),
+      createdAt: new Date().toISOString(),
+    };
+    settingsRepository.setTransactionRules([...this.list(), rule]);
+    return rule;
+  }
+
+  update(
+    id: string,
+    data: TransactionRuleUpdateRequest,
+  ): TransactionRule | undefined {
+    const rules = this.list();
+    const i = rules.findIndex((r) => r.id === id);
+    if (i < 0) return undefined;
+    const defined = Object.fromEntries(
+      Object.entries(data).filter(([, v]) => v !== undefined),
+    );
+    rules[i] = {
+      ...rules[i],
+      ...defined,
+      id,
+      updatedAt: new Date().toISOString(),
+    };
+    settingsRepository.setTransactionRules(rules);
+    return rules[i];
+  }
+
+  delete(id: string): boolean {
+    const rules = this.list();
+    const kept = rules.filter((r) => r.id !== id);
+    if (kept.length === rules.length) return false;
+    settingsRepository.setTransactionRules(kept);
+    return true;
+  }
+
+  /**
+   * Run rules over the transactions already recorded. A dry run only
+   * reports what would change. `ruleIds` picks rules, enabled or not;
+   * by default every enabled rule runs.
+   */
+  applyToExisting(params: {
+    dryRun: boolean;
+    ruleIds?: string[];
+  }): RuleApplyResult {
+    let rules = this.list();
+    if (params.ruleIds) {
+      const missing = params.ruleIds.find((id) => !this.get(id));
+      if (missing) throw new NotFoundError("rule", missing);
+      rules = rules
+        .filter((r) => params.ruleIds!.includes(r.id))
+        .map((r) => ({ ...r, enabled: true }));
+    }
+
+    const transactions = transactionRepository.findAll();
+    const changed: RuleMatch[] = [];
+    for (const t of transactions) {
+      const copy = { ...t, tags: t.tags && [...t.tags] };
+      const { ruleIds, changes } = applyRules(copy, rules);
+      if (!Object.keys(changes).length) continue;
+      const before = Object.fromEntries(
+        Object.keys(changes).map((k) => [k, t[k as keyof Transaction]]),
+      );
+      changed.push({ transactionId: t.id, ruleIds, before, changes });
+    }
+
+    if (!params.dryRun) {
+      for (const c of changed) {
+        transactionRepository.update(c.transactionId, c.changes);
+      }
+      logger.info(
+        { changed: changed.length, scanned: transactions.length },
+        "Applied transaction rules to existing transactions",
+      );
+    }
+    return {
+      dryRun: params.dryRun,
+      scanned: transactions.length,
+      changed,
+    };
+  }
+}
+
+export const ruleService = new RuleService();
@@ -0,0 +1,213 @@
+import { Transaction, VaultEntry } from "../types";
+import {
+  settingsRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import { budgetMonth } from "./budget.service";
+import { ValidationError } from "../core/errors";
+
+const DEFAULT_MONTHS = 12;
+const MAX_MONTHS = 120;
+
+export interface SavingsRateFigures {
+  income_usd: number;
+  income_vnd: number;
+  spending_usd: number;
+  spending_vnd: number;
+  investing_usd: number;
+  investing_vnd: number;
+  debt_repayment_usd: number;
+  debt_repayment_vnd: number;
+  cash_buildup_usd: number;
+  cash_buildup_vnd: number;
+  savings_usd: number;
+  savings_vnd: number;
+  savings_rate: number | null; // percent of income; null without income
+  allocation: {
+    spending_pct: number;
+    investing_pct: number;
+    debt_repayment_pct: number;
+    cash_buildup_pct: number;
+  };
+}
+
+export interface SavingsRateMonth extends SavingsRateFigures {
+  month: string; // YYYY-MM
+}
+
+interface Flows {
+  income_usd: number;
+  spending_usd: number;
+  investing_usd: number;
+  debt_repayment_usd: number;
+}
+
+const emptyFlows = (): Flows => ({
+  income_usd: 0,
+  spending_usd: 0,
+  investing_usd: 0,
+  debt_repayment_usd: 0,
+});
+
+// ISO form of a stored date, or "" when it does not parse
+function iso(value: string): string {
+  const at = new Date(value);
+  return Number.isNaN(at.getTime()) ? "" : at.toISOString();
+}
+
+const pct = (part: number, whole: number) =>
+  whole > 0 ? (part / whole) * 100 : 0;
+
+// Savings figures derived from a month's (or a period's) flows
+function summarize(flows: Flows, rateVND: number): SavingsRateFigures {
+  const income = flows.income_usd;
+  const savings = income - flows.spending_usd;
+  const cash = savings - flows.investing_usd - flows.debt_repayment_usd;
+  return {
+    income_usd: income,
+    income_vnd: income * rateVND,
+    spending_usd: flows.spending_usd,
+    spending_vnd: flows.spending_usd * rateVND,
+    investing_usd: flows.investing_usd,
+    investing_vnd: flows.investing_usd * rateVND,
+    debt_repayment_usd: flows.debt_repayment_usd,
+    debt_repayment_vnd: flows.debt_repayment_usd * rateVND,
+    cash_buildup_usd: cash,
+    cash_buildup_vnd: cash * rateVND,
+    savings_usd: savings,
+    savings_vnd: savings * rateVND,
+    savings_rate: income > 0 ? pct(savings, income) : null,
+    allocation: {
+      spending_pct: pct(flows.spending_usd, income),
+      investing_pct: pct(flows.investing_usd, income),
+      debt_repayment_pct: pct(flows.debt_repayment_usd, income),
+      cash_buildup_pct: pct(cash, income),
+    },
+  };
+}
+
+/**
+ * Savings rate (income minus spending, over income) per month, and where
+ * each month's income went. Buckets follow the cash flow report's type
+ * breakdown:
+ *
+ *   income          INCOME, except refunds of reimbursable expenses
+ *   spending        EXPENSE, less refunds received that month
+ *   investing       net transfers from the cash vaults (spending and
+ *                   income) into other vaults
+ *   debt repayment  REPAY of a borrowing
+ *   cash buildup    the rest, kept as cash (negative when drawing down)
+ */
+export class SavingsRateService {
+  /** Months `start` to `end` (YYYY-MM); default the last 12 months. */
+  report(
+    params: { start?: string; end?: string },
+    rateVND: number,
+    now: Date = new Date(),
+  ) {
+    const months = this.months(params.start, params.end, now);
+    const from = budgetMonth(months[0]).start;
+    const to = budgetMonth(months[months.length - 1]).end;
+    const inRange = (at: string) => !!at && at >= from && at <= to;
+
+    const flows = new Map(months.map((m) => [m, emptyFlows()]));
+    const add = (at: string, key: keyof Flows, usd: number) => {
+      const bucket = flows.get(at.slice(0, 7));
+      if (bucket) bucket[key] += usd;
+    };
+
+    for (const t of transactionRepository.findAll()) {
+      if (inRange(iso(t.createdAt))) this.addTransaction(t, add);
+    }
+    for (const e of this.cashVaultTransfers()) {
+      const at = iso(e.at);
+      if (!inRange(at)) continue;
+      const usd = Number(e.usdValue || 0);
+      add(at, "investing_usd", e.type === "WITHDRAW" ? usd : -usd);
+    }
+
+    const total = emptyFlows();
+    for (const f of flows.values()) {
+      for (const key of Object.keys(total) as (keyof Flows)[]) {
+        total[key] += f[key];
+      }
+    }
+
+    return {
+      start: months[0],
+      end: months[months.length - 1],
+      months: months.map(
+        (month): SavingsRateMonth => ({
+          month,
+          ...summarize(flows.get(month)!, rateVND),
+        }),
+      ),
+      totals: summarize(total, rateVND),
+    };
+  }
+
+  private addTransaction(
+    t: Transaction,
+    add: (at: string, key: keyof Flows, usd: number) => void,
+  ): void {
+    const at = iso(t.createdAt);
+    const usd = Number(t.usdAmount || 0);
+    if (t.type === "INCOME" && t.reimbursesId) add(at, "spending_usd", -usd);
+    else if (t.type === "INCOME") add(at, "income_usd", usd);
+    else if (t.type === "EXPENSE") add(at, "spending_usd", usd);
+    else if (
+      t.type === "REPAY" &&
+      String((t as any).direction || "").toUpperCase() === "BORROW"
+    ) {
+      add(at, "debt_repayment_usd", usd);
+    }
+  }
+
+  // Cash vault entries moving money to or from an investment vault; the
+  // borrowing vault is debt, counted through REPAY instead
+  private cashVaultTransfers(): VaultEntry[] {
+    const cash = new Set([
+      settingsRepository.getDefaultSpendingVaultName(),
+      settingsRepository.getDefaultIncomeVaultName(),
+    ]);
+    const skip = new Set([
+      ...cash,
+      settingsRepository.getBorrowingSettings().name,
+    ]);
+    const out: VaultEntry[] = [];
+    for (const name of cash) {
+      for (const e of vaultRepository.findAllEntries(name)) {
+        if (e.type === "VALUATION" || !e.account || skip.has(e.account)) {
+          continue;
+        }
+        if (vaultRepository.findByName(e.account)) out.push(e);
+      }
+    }
+    return out;
+  }
+
+  private months(start?: string, end?: string, now = new Date()): string[] {
+    const last = budgetMonth(end, now).month;
+    let first = start ? budgetMonth(start).month : undefined;
+    if (!first) {
+      const [y, m] = last.split("-").map(Number);
+      first = new Date(Date.UTC(y, m - DEFAULT_MONTHS, 1))
+        .toISOString()
+        .slice(0, 7);
+    }
+    if (first > last) throw new ValidationError("start is after end");
+
+    const out: string[] = [];
+    const [y, m] = first.split("-").map(Number);
+    for (let i = 0; out[out.length - 1] !== last; i++) {
+      if (i >= MAX_MONTHS) {
+        throw new ValidationError(`At most ${MAX_MONTHS} months at a time`);
+      }
+      out.push(new Date(Date.UTC(y, m - 1 + i, 1)).toISOString().slice(0, 7));
+    }
+    return out;
+  }
+}
+
+export const savingsRateService = new SavingsRateService();
@@ -0,0 +1,347 @@
+import axios from "axios";
+import crypto from "crypto";
+import { SheetsExport, SheetsExportConfigRequest } from "../types";
+import { settingsRepository } from "../repositories";
+import { config } from "../core/config";
+import { BusinessError, ValidationError } from "../core/errors";
+import { decryptSecret, encryptSecret } from "../utils/secret.util";
+import { logger } from "../utils/logger";
+
+const TOKEN_URL = "https://oauth2.googleapis.com/token";
+const SHEETS_API = "https://sheets.googleapis.com/v4/spreadsheets";
+const SHEETS_SCOPE = "https://www.googleapis.com/auth/spreadsheets";
+const SCHEDULER_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const REQUEST_TIMEOUT_MS = 30000;
+const HOUR_MS = 60 * 60 * 1000;
+let schedulerStarted = false;
+
+// Fields of the service account JSON key that are used
+export interface ServiceAccountKey {
+  client_email: string;
+  private_key: string;
+  token_uri?: string;
+}
+
+export type SheetCell = string | number;
+
+export interface SheetsExportRun {
+  export: SheetsExport;
+  status: "OK" | "FAILED";
+  error?: string;
+  holdingsRows: number;
+  summaryRows: number;
+}
+
+const base64url = (v: string) => Buffer.from(v).toString("base64url");
+
+/**
+ * Signed JWT a service account exchanges for an access token (OAuth 2.0
+ * JWT bearer grant), valid for an hour.
+ */
+export function serviceAccountAssertion(
+  key: ServiceAccountKey,
+  now: Date = new Date(),
+): string {
+  const iat = Math.floor(now.getTime() / 1000);
+  const header = base64url(JSON.stringify({ alg: "RS256", typ: "JWT" }));
+  const claims = base64url(
+    JSON.stringify({
+      iss: key.client_email,
+      scope: SHEETS_SCOPE,
+      aud: key.token_uri || TOKEN_URL,
+      iat,
+      exp: iat + 3600,
+    }),
+  );
+  const signature = crypto
+    .createSign("RSA-SHA256")
+    .update(`${header}.${claims}`)
+    .sign(key.private_key, "base64url");
+  return `${header}.${claims}.${signature}`;
+}
+
+// A1 range of a whole tab; names with spaces or quotes must be quoted
+function sheetRange(name: string): string {
+  return `'${name.replace(/'/g, "''")}'`;
+}
+
+function monthKey(d: Date): string {
+  return d.toISOString().slice(0, 7);
+}
+
+/** Rows for the holdings tab from /reports/holdings, header first. */
+export function holdingsRows(
+  holdings: any[],
+  currency: "USD" | "VND",
+): SheetCell[][] {
+  const value = `value_${currency.toLowerCase()}`;
+  return [
+    [
+      "Asset",
+      "Account",
+      "Institution",
+      "Quantity",
+      `Value ${currency}`,
+      "Share %",
+      "Updated at",
+    ],
+    ...holdings.map((h) => [
+      h.asset,
+      h.account,
+      h.institution ?? "",
+      h.quantity,
+      h[value] ?? 0,
+      h.percentage ?? 0,
+      h.last_updated ?? "",
+    ]),
+  ];
+}
+
+/** Rows for the monthly tab from /reports/savings-rate, header first. */
+export function summaryRows(
+  report: { months?: any[] },
+  currency: "USD" | "VND",
+): SheetCell[][] {
+  const cur = currency.toLowerCase();
+  const buckets = [
+    "income",
+    "spending",
+    "investing",
+    "debt_repayment",
+    "cash_buildup",
+    "savings",
+  ];
+  return [
+    [
+      "Month",
+      `Income ${currency}`,
+      `Spending ${currency}`,
+      `Investing ${currency}`,
+      `Debt repayment ${currency}`,
+      `Cash buildup ${currency}`,
+      `Savings ${currency}`,
+      "Savings rate %",
+    ],
+    ...(report.months || []).map((m) => [
+      m.month,
+      ...buckets.map((b) => m[`${b}_${cur}`] ?? 0),
+      m.savings_rate ?? "",
+    ]),
+  ];
+}
+
+// Google APIs put the reason in the response body
+function describeError(e: any): string {
+  const data = e?.response?.data;
+  return (
+    data?.error?.message ||
+    data?.error_description ||
+    (typeof data?.error === "string" ? data.error : undefined) ||
+    e?.message ||
+    String(e)
+  );
+}
+
+/**
+ * Keeps a Google Sheet in sync with the current holdings and the monthly
+ * summary, for users who still track their money in a spreadsheet. A
+ * service account with edit access to the sheet writes both tabs on a
+ * schedule; its key is stored encrypted with SETTINGS_ENCRYPTION_KEY.
+ */
+export class SheetsExportService {
+  get(): SheetsExport | undefined {
+    return settingsRepository.getSheetsExport();
+  }
+
+  /**
+   * Create or replace the export. Without new credentials the stored key
+   * is kept; the schedule carries on from the existing next run.
+   */
+  configure(req: SheetsExportConfigRequest): SheetsExport {
+    const existing = settingsRepository.getSheetsExport();
+    let credentials = existing?.credentials;
+    let clientEmail = existing?.clientEmail;
+    if (req.credentials) {
+      credentials = encryptSecret(
+        JSON.stringify(req.credentials),
+        this.passphrase(),
+      );
+      clientEmail = req.credentials.client_email;
+    }
+    if (!credentials || !clientEmail) {
+      throw new ValidationError(
+        "credentials (the service account's JSON key) are required",
+      );
+    }
+
+    const now = new Date().toISOString();
+    const exp: SheetsExport = {
+      spreadsheetId: req.spreadsheetId,
+      holdingsSheet: req.holdingsSheet,
+      summarySheet: req.summarySheet,
+      months: req.months,
+      currency: req.currency,
+      intervalHours: req.intervalHours,
+      clientEmail,
+      credentials,
+      active: req.active,
+      nextRunAt: existing?.nextRunAt ?? now,
+      lastRunAt: existing?.lastRunAt,
+      lastStatus: existing?.lastStatus,
+      lastError: existing?.lastError,
+      updatedAt: now,
+    };
+    settingsRepository.setSheetsExport(exp);
+    return exp;
+  }
+
+  remove(): boolean {
+    if (!settingsRepository.getSheetsExport()) return false;
+    settingsRepository.setSheetsExport(undefined);
+    return true;
+  }
+
+  /**
+   * Write both tabs now and record the outcome. Reports are rendered
+   * through this server's own API so the sheet matches what clients see.
+   * Running by hand leaves the schedule alone.
+   */
+  async push(now: Date = new Date()): Promise<SheetsExportRun | undefined> {
+    const exp = settingsRepository.getSheetsExport();
+    if (!exp) return undefined;
+
+    let status: SheetsExportRun["status"] = "OK";
+    let error: string | undefined;
+    let holdings: SheetCell[][] = [];
+    let summary: SheetCell[][] = [];
+    try {
+      const key: ServiceAccountKey = JSON.parse(
+        decryptSecret(exp.credentials, this.passphrase()),
+      );
+      const first = new Date(
+        Date.UTC(now.getUTCFullYear(), now.getUTCMonth() - exp.months + 1, 1),
+      );
+      const [token, holdingsReport, monthly] = await Promise.all([
+        this.accessToken(key, now),
+        this.render("/reports/holdings"),
+        this.render("/reports/savings-rate", {
+          start: monthKey(first),
+          end: monthKey(now),
+        }),
+      ]);
+      holdings = holdingsRows(holdingsReport, exp.currency);
+      summary = summaryRows(monthly, exp.currency);
+
+      const id = encodeURIComponent(exp.spreadsheetId);
+      const values = `${SHEETS_API}/${id}/values`;
+      const tabs = [exp.holdingsSheet, exp.summarySheet].map(sheetRange);
+      const opts = {
+        headers: { Authorization: `Bearer ${token}` },
+        timeout: REQUEST_TIMEOUT_MS,
+      };
+      await axios.post(`${values}:batchClear`, { ranges: tabs }, opts);
+      await axios.post(
+        `${values}:batchUpdate`,
+        {
+          valueInputOption: "RAW",
+          data: [
+            { range: `${tabs[0]}!A1`, values: holdings },
+            { range: `${tabs[1]}!A1`, values: summary },
+          ],
+        },
+        opts,
+      );
+    } catch (e: any) {
+      status = "FAILED";
+      error = describeError(e);
+      logger.warn(
+        { spreadsheetId: exp.spreadsheetId, error },
+        "Google Sheets export failed",
+      );
+    }
+
+    const updated: SheetsExport = {
+      ...exp,
+      lastRunAt: now.toISOString(),
+      lastStatus: status,
+      lastError: error,
+    };
+    settingsRepository.setSheetsExport(updated);
+    return {
+      export: updated,
+      status,
+      error,
+      holdingsRows: Math.max(0, holdings.length - 1),
+      summaryRows: Math.max(0, summary.length - 1),
+    };
+  }
+
+  /**
+   * Push when the export is active and due, then move the next run past
+   * `now`. A failed push moves on too; the error stays on the export.
+   */
+  async processDue(now: Date = new Date()): Promise<SheetsExportRun | null> {
+    const exp = settingsRepository.getSheetsExport();
+    if (!exp?.active || exp.nextRunAt > now.toISOString()) return null;
+
+    const run = await this.push(now);
+    if (!run) return null;
+    const step = exp.intervalHours * HOUR_MS;
+    let next = Date.parse(exp.nextRunAt) || now.getTime();
+    while (next <= now.getTime()) next += step;
+    run.export = { ...run.export, nextRunAt: new Date(next).toISOString() };
+    settingsRepository.setSheetsExport(run.export);
+    return run;
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDue().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Google Sheets export failed"),
+      );
+    };
+    run();
+    setInterval(run, SCHEDULER_INTERVAL_MS);
+  }
+
+  private async accessToken(
+    key: ServiceAccountKey,
+    now: Date,
+  ): Promise<string> {
+    const res = await axios.post(
+      key.token_uri || TOKEN_URL,
+      new URLSearchParams({
+        grant_type: "urn:ietf:params:oauth:grant-type:jwt-bearer",
+        assertion: serviceAccountAssertion(key, now),
+      }).toString(),
+      {
+        headers: { "Content-Type": "application/x-www-form-urlencoded" },
+        timeout: REQUEST_TIMEOUT_MS,
+      },
+    );
+    return res.data.access_token;
+  }
+
+  private async render(path: string, params?: Record<string, string>) {
+    const res = await axios.get(`http://127.0.0.1:${config.port}/api${path}`, {
+      params,
+      timeout: REQUEST_TIMEOUT_MS,
+    });
+    return res.data;
+  }
+
+  private passphrase(): string {
+    const key = config.settingsEncryptionKey;
+    if (!key) {
+      throw new BusinessError(
+        "SETTINGS_ENCRYPTION_KEY must be set to store Google credentials",
+      );
+    }
+    return key;
+  }
+}
+
+export const sheetsExportService = new SheetsExportService();
@@ -0,0 +1,188 @@
+import { Asset, assetKey } from "../types";
+import { vaultRepository } from "../repositories";
+import { priceService } from "./price.service";
+import { toISODate } from "./financial.service";
+
+export interface SnapshotPosition {
+  asset: Asset;
+  quantity: number;
+  rateUSD: number;
+  valueUSD: number;
+}
+
+export interface Snapshot {
+  date: string;
+  positions: SnapshotPosition[];
+  totalUSD: number;
+}
+
+export type PositionChange = "ADDED" | "REMOVED" | "CHANGED" | "UNCHANGED";
+
+export interface PositionDiff {
+  asset: Asset;
+  change: PositionChange;
+  quantityFrom: number;
+  quantityTo: number;
+  quantityChange: number;
+  valueFromUSD: number;
+  valueToUSD: number;
+  valueChangeUSD: number;
+  netFlowUSD: number; // deposits - withdrawals of this asset
+  marketChangeUSD: number; // valueChangeUSD - netFlowUSD
+}
+
+export interface SnapshotDiff {
+  from: Snapshot;
+  to: Snapshot;
+  positions: PositionDiff[];
+  netWorthChangeUSD: number;
+  netContributionsUSD: number; // deposits - withdrawals between the dates
+  marketMovementUSD: number; // value change not explained by flows
+  // marketMovementUSD split by what moved: non-USD fiat revaluation is an
+  // FX effect, everything else an investment gain/loss
+  investmentGainsUSD: number;
+  fxEffectUSD: number;
+}
+
+const EPS = 1e-12;
+
+function endOfDay(date: string): string {
+  return `${date}T23:59:59.999Z`;
+}
+
+function isForeignFiat(asset: Asset): boolean {
+  return asset.type === "FIAT" && asset.symbol.toUpperCase() !== "USD";
+}
+
+export class SnapshotService {
+  /**
+   * Holdings across all vaults as of the end of a day, valued with that
+   * day's prices (live quote when the date is today)
+   */
+  async snapshotAt(date: string): Promise<Snapshot> {
+    const units = new Map<string, { asset: Asset; quantity: number }>();
+    for (const e of vaultRepository.findAllEntriesUntil(endOfDay(date))) {
+      if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+      const k = assetKey(e.asset);
+      const cur = units.get(k) || { asset: e.asset, quantity: 0 };
+      cur.quantity += e.type === "DEPOSIT" ? e.amount : -e.amount;
+      units.set(k, cur);
+    }
+
+    const live = date >= toISODate(new Date());
+    const positions: SnapshotPosition[] = [];
+    for (const u of units.values()) {
+      if (Math.abs(u.quantity) <= EPS) continue;
+      const rate = await priceService.getRateUSD(
+        u.asset,
+        live ? undefined : endOfDay(date),
+      );
+      positions.push({
+        asset: u.asset,
+        quantity: u.quantity,
+        rateUSD: rate.rateUSD,
+        valueUSD: u.quantity * rate.rateUSD,
+      });
+    }
+
+    return {
+      date,
+      positions,
+      totalUSD: positions.reduce((s, p) => s + p.valueUSD, 0),
+    };
+  }
+
+  /**
+   * Vault deposits minus withdrawals per asset after the end of `from` up
+   * to the end of `to`, at their recorded USD value. Transfers between
+   * vaults cancel out, leaving income, spending and other external flows.
+   */
+  netFlowsByAsset(
+    from: string,
+    to: string,
+  ): Map<string, { asset: Asset; usd: number }> {
+    const start = endOfDay(from);
+    const flows = new Map<string, { asset: Asset; usd: number }>();
+    for (const e of vaultRepository.findAllEntriesUntil(endOfDay(to))) {
+      if (e.at <= start) continue;
+      if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+      const usd = (e.usdValue || 0) * (e.type === "DEPOSIT" ? 1 : -1);
+      const k = assetKey(e.asset);
+      const cur = flows.get(k) || { asset: e.asset, usd: 0 };
+      cur.usd += usd;
+      flows.set(k, cur);
+    }
+    return flows;
+  }
+
+  async diff(from: string, to: string): Promise<SnapshotDiff> {
+    const [a, b] = await Promise.all([
+      this.snapshotAt(from),
+      this.snapshotAt(to),
+    ]);
+
+    const flows = this.netFlowsByAsset(from, to);
+    const keys = new Set<string>(flows.keys());
+    const before = new Map(a.positions.map((p) => [assetKey(p.asset), p]));
+    const after = new Map(b.positions.map((p) => [assetKey(p.asset), p]));
+    before.forEach((_p, k) => keys.add(k));
+    after.forEach((_p, k) => keys.add(k));
+
+    const positions: PositionDiff[] = [];
+    let fxEffectUSD = 0;
+    for (const k of keys) {
+      const p0 = before.get(k);
+      const p1 = after.get(k);
+      const quantityFrom = p0?.quantity || 0;
+      const quantityTo = p1?.quantity || 0;
+      const quantityChange = quantityTo - quantityFrom;
+      const valueChangeUSD = (p1?.valueUSD || 0) - (p0?.valueUSD || 0);
+      const flow = flows.get(k);
+      const netFlowUSD = flow?.usd || 0;
+      const asset = (p1 || p0 || flow)!.asset;
+      if (isForeignFiat(asset)) fxEffectUSD += valueChangeUSD - netFlowUSD;
+      // Bought and fully sold within the period: not a position, but the
+      // gap between its flows still counts toward the market/FX split
+      if (!p0 && !p1) continue;
+
+      let change: PositionChange = "UNCHANGED";
+      if (!p0) change = "ADDED";
+      else if (!p1) change = "REMOVED";
+      else if (Math.abs(quantityChange) > EPS) change = "CHANGED";
+
+      positions.push({
+        asset,
+        change,
+        quantityFrom,
+        quantityTo,
+        quantityChange,
+        valueFromUSD: p0?.valueUSD || 0,
+        valueToUSD: p1?.valueUSD || 0,
+        valueChangeUSD,
+        netFlowUSD,
+        marketChangeUSD: valueChangeUSD - netFlowUSD,
+      });
+    }
+    positions.sort(
+      (x, y) => Math.abs(y.valueChangeUSD) - Math.abs(x.valueChangeUSD),
+    );
+
+    let netContributionsUSD = 0;
+    flows.forEach((f) => (netContributionsUSD += f.usd));
+
+    const netWorthChangeUSD = b.totalUSD - a.totalUSD;
+    const marketMovementUSD = netWorthChangeUSD - netContributionsUSD;
+    return {
+      from: a,
+      to: b,
+      positions,
+      netWorthChangeUSD,
+      netContributionsUSD,
+      marketMovementUSD,
+      investmentGainsUSD: marketMovementUSD - fxEffectUSD,
+      fxEffectUSD,
+    };
+  }
+}
+
+export const snapshotService = new SnapshotService();
@@ -0,0 +1,151 @@
+import { Asset } from "../types";
+import { settingsRepository } from "../repositories";
+import { priceService } from "./price.service";
+import { getAssetKind, stablecoinSymbols } from "../utils/asset.util";
+import { logger } from "../utils/logger";
+
+const PEG_CHECK_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const DEFAULT_DEPEG_THRESHOLD_PERCENT = 1;
+const MAX_ALERTS = 100;
+let schedulerStarted = false;
+
+export interface PegStatus {
+  symbol: string;
+  priceUSD: number;
+  deviationPercent: number;
+  depegged: boolean;
+  checkedAt: string;
+  depeggedSince?: string;
+}
+
+export interface PegAlert {
+  symbol: string;
+  kind: "DEPEG" | "RECOVERED";
+  priceUSD: number;
+  deviationPercent: number;
+  at: string;
+}
+
+// Latest check per symbol; valuation reads this between checks
+const statuses = new Map<string, PegStatus>();
+// Recent peg transitions, newest last
+const alerts: PegAlert[] = [];
+
+function recordAlert(alert: PegAlert): void {
+  alerts.push(alert);
+  if (alerts.length > MAX_ALERTS) alerts.shift();
+}
+
+function deviationPercent(priceUSD: number): number {
+  return Math.abs(priceUSD - 1) * 100;
+}
+
+/**
+ * Stablecoins are assumed to track USD. They're valued at $1 so provider
+ * noise doesn't move balances, but the monitor keeps fetching real quotes
+ * and switches valuation to the market price once a coin drifts past the
+ * depeg threshold.
+ */
+export class StablecoinService {
+  getThresholdPercent(): number {
+    try {
+      return settingsRepository.getDepegThresholdPercent();
+    } catch {
+      return DEFAULT_DEPEG_THRESHOLD_PERCENT;
+    }
+  }
+
+  isStablecoin(asset: Asset): boolean {
+    return getAssetKind(asset.symbol) === "STABLECOIN";
+  }
+
+  /**
+   * USD rate to value `asset` at. Non-stablecoins keep the market rate.
+   * Before the first check of a coin, the market rate passed in decides
+   * whether it is off peg.
+   */
+  valuationRate(asset: Asset, marketRateUSD: number): number {
+    if (!this.isStablecoin(asset)) return marketRateUSD;
+    const status = statuses.get(asset.symbol.toUpperCase());
+    if (status) return status.depegged ? status.priceUSD : 1;
+    const off = deviationPercent(marketRateUSD) > this.getThresholdPercent();
+    return off ? marketRateUSD : 1;
+  }
+
+  getStatuses(): PegStatus[] {
+    return Array.from(statuses.values()).sort((a, b) =>
+      a.symbol.localeCompare(b.symbol),
+    );
+  }
+
+  getAlerts(): PegAlert[] {
+    return [...alerts];
+  }
+
+  /**
+   * Fetch live quotes for every known stablecoin and update peg status.
+   * A coin crossing the threshold raises a depeg alert once, and another
+   * when it recovers.
+   */
+  async checkPegs(now: Date = new Date()): Promise<PegStatus[]> {
+    const threshold = this.getThresholdPercent();
+    const prices = await priceService.fetchCurrentCryptoPrices(
+      stablecoinSymbols(),
+    );
+
+    const checked: PegStatus[] = [];
+    for (const [symbol, priceUSD] of Object.entries(prices)) {
+      const prev = statuses.get(symbol);
+      const deviation = deviationPercent(priceUSD);
+      const depegged = deviation > threshold;
+      const status: PegStatus = {
+        symbol,
+        priceUSD,
+        deviationPercent: deviation,
+        depegged,
+        checkedAt: now.toISOString(),
+        depeggedSince: depegged
+          ? (prev?.depeggedSince ?? now.toISOString())
+          : undefined,
+      };
+      statuses.set(symbol, status);
+      checked.push(status);
+
+      const alert = {
+        symbol,
+        priceUSD,
+        deviationPercent: deviation,
+        at: status.checkedAt,
+      };
+      if (depegged && !prev?.depegged) {
+        recordAlert({ ...alert, kind: "DEPEG" });
+        logger.warn(
+          { symbol, priceUSD, deviationPercent: deviation, threshold },
+          "Stablecoin depeg detected; valuing at market price",
+        );
+      } else if (!depegged && prev?.depegged) {
+        recordAlert({ ...alert, kind: "RECOVERED" });
+        logger.info(
+          { symbol, priceUSD, depeggedSince: prev.depeggedSince },
+          "Stablecoin back at peg",
+        );
+      }
+    }
+    return checked;
+  }
+
+  startPegMonitor(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.checkPegs().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Stablecoin peg check failed"),
+      );
+    };
+    run();
+    setInterval(run, PEG_CHECK_INTERVAL_MS);
+  }
+}
+
+export const stablecoinService = new StablecoinService();
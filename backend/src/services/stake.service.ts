@@ -0,0 +1,206 @@
+import { Asset, CostBasisMethod, assetKey } from "../types";
+import { vaultRepository } from "../repositories";
+import {
+  LotBook,
+  costBasisService,
+  isRewardDistribution,
+} from "./cost-basis.service";
+import {
+  AnnualizationMethod,
+  annualizeReturn,
+  dateDiffInDays,
+} from "./financial.service";
+
+// Part of a deposit (stake) consumed by one withdrawal (unstake)
+export interface StakeLot {
+  stakedAt: string;
+  amount: number;
+  costUSD: number;
+}
+
+// One unstake paired with the stakes it closes (FIFO unless configured)
+export interface StakeCycle {
+  vault: string;
+  asset: Asset;
+  method: CostBasisMethod;
+  unstakedAt: string;
+  amount: number;
+  unmatchedAmount: number; // withdrawn beyond what was ever deposited
+  proceedsUSD: number; // for the matched amount
+  costUSD: number;
+  realizedUSD: number;
+  roiPercent: number;
+  holdingDays: number; // cost-weighted across the matched stakes
+  annualizedPercent: number;
+  stakes: StakeLot[];
+}
+
+export interface StakeCycleGroup {
+  key: string; // asset symbol or vault name
+  cycles: number;
+  amount: number;
+  costUSD: number;
+  proceedsUSD: number;
+  realizedUSD: number;
+  roiPercent: number;
+  holdingDays: number;
+  annualizedPercent: number;
+}
+
+export interface StakeCycleQuery {
+  vault?: string;
+  asset?: string;
+  start?: string;
+  end?: string;
+  annualization?: AnnualizationMethod;
+}
+
+function cyclesForVault(
+  vault: string,
+  method: AnnualizationMethod,
+): StakeCycle[] {
+  const entries = [...vaultRepository.findAllEntries(vault)].sort((a, b) =>
+    String(a.at).localeCompare(String(b.at)),
+  );
+  const books = new Map<string, LotBook>();
+  const cycles: StakeCycle[] = [];
+
+  for (const e of entries) {
+    // USD balances move with valuations, not units; there's no lot to close
+    if (e.asset.symbol === "USD") continue;
+    const k = assetKey(e.asset);
+    const book = books.get(k) || new LotBook();
+    books.set(k, book);
+
+    if (e.type === "DEPOSIT" && e.amount > 0) {
+      book.add(e.at, e.amount, Number(e.usdValue || 0));
+      continue;
+    }
+    if (e.type !== "WITHDRAW" || isRewardDistribution(e) || !(e.amount > 0)) {
+      continue;
+    }
+
+    const basis = costBasisService.methodFor(vault, e.asset.symbol, "FIFO");
+    const { released, unmatched } = book.release(e.amount, basis, e.lots);
+    const stakes: StakeLot[] = released.map((r) => ({
+      stakedAt: r.acquiredAt,
+      amount: r.units,
+      costUSD: r.costUSD,
+    }));
+
+    const matched = e.amount - unmatched;
+    const proceedsUSD = Number(e.usdValue || 0) * (matched / e.amount);
+    const costUSD = stakes.reduce((s, l) => s + l.costUSD, 0);
+    const days = stakes.length
+      ? stakes.reduce(
+          (s, l) =>
+            s +
+            dateDiffInDays(new Date(l.stakedAt), new Date(e.at)) *
+              (costUSD > 0 ? l.costUSD / costUSD : l.amount / matched),
+          0,
+        )
+      : 0;
+    const roi = costUSD > 0 ? (proceedsUSD - costUSD) / costUSD : 0;
+    cycles.push({
+      vault,
+      asset: e.asset,
+      method: basis,
+      unstakedAt: e.at,
+      amount: e.amount,
+      unmatchedAmount: unmatched,
+      proceedsUSD,
+      costUSD,
+      realizedUSD: proceedsUSD - costUSD,
+      roiPercent: roi * 100,
+      holdingDays: days,
+      annualizedPercent: annualizeReturn(roi, days, method),
+      stakes,
+    });
+  }
+  return cycles;
+}
+
+function summarize(
+  key: string,
+  cycles: StakeCycle[],
+  method: AnnualizationMethod,
+): StakeCycleGroup {
+  const costUSD = cycles.reduce((s, c) => s + c.costUSD, 0);
+  const proceedsUSD = cycles.reduce((s, c) => s + c.proceedsUSD, 0);
+  const days =
+    costUSD > 0
+      ? cycles.reduce((s, c) => s + c.holdingDays * (c.costUSD / costUSD), 0)
+      : 0;
+  const roi = costUSD > 0 ? (proceedsUSD - costUSD) / costUSD : 0;
+  return {
+    key,
+    cycles: cycles.length,
+    amount: cycles.reduce((s, c) => s + c.amount, 0),
+    costUSD,
+    proceedsUSD,
+    realizedUSD: proceedsUSD - costUSD,
+    roiPercent: roi * 100,
+    holdingDays: days,
+    annualizedPercent: annualizeReturn(roi, days, method),
+  };
+}
+
+function groupCycles(
+  cycles: StakeCycle[],
+  key: (c: StakeCycle) => string,
+  method: AnnualizationMethod,
+): StakeCycleGroup[] {
+  const groups = new Map<string, StakeCycle[]>();
+  for (const c of cycles) {
+    groups.set(key(c), [...(groups.get(key(c)) || []), c]);
+  }
+  return Array.from(groups.entries())
+    .map(([k, cs]) => summarize(k, cs, method))
+    .sort((a, b) => a.key.localeCompare(b.key));
+}
+
+export class StakeService {
+  /**
+   * Completed stake/unstake cycles: every vault withdrawal matched
+   * against the deposits of the same asset it closes (FIFO unless another
+   * cost basis method is configured), with realized gain
+   * and annualized return. Lots are matched over the vault's whole
+   * history; the date range only selects which unstakes are reported.
+   */
+  getCycles(query: StakeCycleQuery = {}): {
+    cycles: StakeCycle[];
+    byAsset: StakeCycleGroup[];
+    byVault: StakeCycleGroup[];
+    totals: StakeCycleGroup;
+  } {
+    const method = query.annualization ?? "APR";
+    const asset = query.asset?.toUpperCase();
+    // A date-only end covers the whole day
+    const end =
+      query.end && /^\d{4}-\d{2}-\d{2}$/.test(query.end)
+        ? `${query.end}T23:59:59.999Z`
+        : query.end;
+    const vaults = query.vault
+      ? [query.vault]
+      : vaultRepository.findAll().map((v) => v.name);
+
+    const cycles = vaults
+      .flatMap((v) => cyclesForVault(v, method))
+      .filter(
+        (c) =>
+          (!asset || c.asset.symbol.toUpperCase() === asset) &&
+          (!query.start || c.unstakedAt >= query.start) &&
+          (!end || c.unstakedAt <= end),
+      )
+      .sort((a, b) => a.unstakedAt.localeCompare(b.unstakedAt));
+
+    return {
+      cycles,
+      byAsset: groupCycles(cycles, (c) => c.asset.symbol, method),
+      byVault: groupCycles(cycles, (c) => c.vault, method),
+      totals: summarize("total", cycles, method),
+    };
+  }
+}
+
+export const stakeService = new StakeService();
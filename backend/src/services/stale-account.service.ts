@@ -0,0 +1,202 @@
+import { Asset, assetKey } from "../types";
+import { adminRepository, transactionRepository } from "../repositories";
+import { accountBalanceService } from "./account-balance.service";
+import { priceService } from "./price.service";
+import { ValidationError } from "../core/errors";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const DEFAULT_MONTHS = 6;
+const MAX_MONTHS = 120;
+const DEFAULT_DUST_USD = 10;
+
+// ARCHIVE: only dust is left, write it off and archive the account.
+// CONSOLIDATE: move the balance into an account still in use.
+// REVIEW: it can't be valued, or it owes money.
+export type StaleAccountAction = "ARCHIVE" | "CONSOLIDATE" | "REVIEW";
+
+export interface StaleAccountHolding {
+  asset: Asset;
+  quantity: number;
+  valueUSD?: number; // undefined without a price
+}
+
+export interface StaleAccount {
+  account: string;
+  accountId?: number; // the admin account, when registered
+  institution?: string;
+  lastTransactionAt: string | null; // null when only balances remain
+  idleDays: number | null;
+  holdings: StaleAccountHolding[]; // largest value first
+  valueUSD: number; // priced holdings only
+  unpriced: string[];
+  action: StaleAccountAction;
+  consolidateInto?: string; // active account holding the largest asset
+  reason: string;
+}
+
+export interface StaleAccountReport {
+  months: number;
+  dustUSD: number;
+  cutoff: string; // accounts idle since before this are stale
+  accounts: StaleAccount[]; // largest value first
+  totalValueUSD: number;
+}
+
+/** Months without transactions before an account is stale; default 6. */
+export function staleMonths(months?: string): number {
+  if (months === undefined || months === "") return DEFAULT_MONTHS;
+  const n = Number(months);
+  if (!Number.isInteger(n) || n < 1 || n > MAX_MONTHS) {
+    throw new ValidationError(`months must be 1-${MAX_MONTHS}`);
+  }
+  return n;
+}
+
+/** USD value below which a stale balance is dust; default 10. */
+export function dustThreshold(dustUSD?: string): number {
+  if (dustUSD === undefined || dustUSD === "") return DEFAULT_DUST_USD;
+  const n = Number(dustUSD);
+  if (!Number.isFinite(n) || n < 0) {
+    throw new ValidationError("dust_usd must be a non-negative number");
+  }
+  return n;
+}
+
+const usd = (n: number) => `$${n.toFixed(2)}`;
+
+export class StaleAccountService {
+  /**
+   * Accounts holding a non-zero balance without a transaction in the
+   * last `months`, such as exchange dust or a dormant bank account.
+   * Holdings are valued at today's prices. Accounts archived in admin
+   * (inactive) are left out, having been dealt with already.
+   */
+  async report(
+    months: number = DEFAULT_MONTHS,
+    dustUSD: number = DEFAULT_DUST_USD,
+    now: Date = new Date(),
+  ): Promise<StaleAccountReport> {
+    const cutoff = new Date(now);
+    cutoff.setUTCMonth(cutoff.getUTCMonth() - months);
+    const cutoffISO = cutoff.toISOString();
+
+    const lastAt = new Map<string, string>();
+    for (const t of transactionRepository.findAll()) {
+      const key = (t.account || "").toLowerCase();
+      if (key && t.createdAt > (lastAt.get(key) ?? "")) {
+        lastAt.set(key, t.createdAt);
+      }
+    }
+    const admin = new Map(
+      adminRepository.findAllAccounts().map((a) => [a.name.toLowerCase(), a]),
+    );
+
+    const rates = new Map<string, Promise<number | undefined>>();
+    const rateOf = (asset: Asset) => {
+      const key = assetKey(asset);
+      if (!rates.has(key)) {
+        rates.set(
+          key,
+          priceService
+            .getRateUSD(asset)
+            .then((r) => (r.rateUSD > 0 ? r.rateUSD : undefined))
+            .catch(() => undefined),
+        );
+      }
+      return rates.get(key)!;
+    };
+
+    const byAccount = new Map<string, StaleAccountHolding[]>();
+    const names = new Map<string, string>();
+    // Active accounts per asset, most recently used first
+    const active = new Map<string, string[]>();
+    const balances = accountBalanceService
+      .getBalances({}, now)
+      .sort((a, b) =>
+        (lastAt.get(b.account.toLowerCase()) ?? "").localeCompare(
+          lastAt.get(a.account.toLowerCase()) ?? "",
+        ),
+      );
+    for (const b of balances) {
+      const key = b.account.toLowerCase();
+      if (admin.get(key)?.is_active === false) continue;
+      const last = lastAt.get(key);
+      if (last && last >= cutoffISO) {
+        const k = assetKey(b.asset);
+        const holders = active.get(k) ?? [];
+        if (b.quantity > 0 && !holders.includes(b.account)) {
+          active.set(k, [...holders, b.account]);
+        }
+        continue;
+      }
+      const rate = await rateOf(b.asset);
+      names.set(key, names.get(key) ?? b.account);
+      byAccount.set(key, [
+        ...(byAccount.get(key) || []),
+        {
+          asset: b.asset,
+          quantity: b.quantity,
+          valueUSD: rate === undefined ? undefined : b.quantity * rate,
+        },
+      ]);
+    }
+
+    const accounts: StaleAccount[] = [];
+    for (const [key, holdings] of byAccount) {
+      holdings.sort((a, b) => (b.valueUSD ?? 0) - (a.valueUSD ?? 0));
+      const valueUSD = holdings.reduce((s, h) => s + (h.valueUSD ?? 0), 0);
+      const unpriced = holdings
+        .filter((h) => h.valueUSD === undefined)
+        .map((h) => assetKey(h.asset));
+      const last = lastAt.get(key) ?? null;
+      const a = admin.get(key);
+
+      let action: StaleAccountAction;
+      let consolidateInto: string | undefined;
+      let reason: string;
+      if (unpriced.length === holdings.length) {
+        action = "REVIEW";
+        reason = `No price for ${unpriced.join(", ")}; check what it holds`;
+      } else if (valueUSD < 0) {
+        action = "REVIEW";
+        reason = `Owes ${usd(-valueUSD)}; settle it before archiving`;
+      } else if (valueUSD < dustUSD && !unpriced.length) {
+        action = "ARCHIVE";
+        reason = `Dust worth ${usd(valueUSD)}; write it off and archive`;
+      } else {
+        action = "CONSOLIDATE";
+        consolidateInto = active.get(assetKey(holdings[0].asset))?.[0];
+        reason = consolidateInto
+          ? `Move ${usd(valueUSD)} into ${consolidateInto}`
+          : `Move ${usd(valueUSD)} into an account still in use`;
+      }
+
+      accounts.push({
+        account: names.get(key)!,
+        accountId: a?.id,
+        institution: a?.institution,
+        lastTransactionAt: last,
+        idleDays: last
+          ? Math.floor((now.getTime() - Date.parse(last)) / DAY_MS)
+          : null,
+        holdings,
+        valueUSD,
+        unpriced,
+        action,
+        consolidateInto,
+        reason,
+      });
+    }
+    accounts.sort((a, b) => b.valueUSD - a.valueUSD);
+
+    return {
+      months,
+      dustUSD,
+      cutoff: cutoffISO,
+      accounts,
+      totalValueUSD: accounts.reduce((s, a) => s + a.valueUSD, 0),
+    };
+  }
+}
+
+export const staleAccountService = new StaleAccountService();
@@ -0,0 +1,267 @@
+import { Asset, assetKey } from "../types";
+import { vaultService } from "./vault.service";
+import { priceService } from "./price.service";
+import { stablecoinService } from "./stablecoin.service";
+import { VaultBenchmark, benchmarkService } from "./benchmark.service";
+import { PAGE_WIDTH, PdfColor, PdfDocument } from "../utils/pdf.util";
+
+export interface StatementHolding {
+  asset: Asset;
+  quantity: number;
+  costBasisUSD: number;
+  valueUSD: number;
+  pnlUSD: number;
+}
+
+// One day of the vault series; share price follows the tokenized view
+export interface StatementPoint {
+  date: string;
+  aumUSD: number;
+  netContributedUSD: number;
+}
+
+export interface VaultStatement {
+  vault: string;
+  investor?: string;
+  asOf: string;
+  generatedAt: string;
+  holdings: StatementHolding[];
+  depositedUSD: number;
+  withdrawnUSD: number;
+  costBasisUSD: number;
+  valueUSD: number;
+  pnlUSD: number;
+  roiPercent: number;
+  priceHistory: Array<{ date: string; sharePrice: number }>;
+  benchmark?: VaultBenchmark;
+}
+
+const MARGIN = 50;
+const BOTTOM = 790;
+const MUTED: PdfColor = [0.4, 0.4, 0.4];
+const ACCENT: PdfColor = [0.13, 0.4, 0.75];
+
+function usd(n: number): string {
+  return n.toLocaleString("en-US", {
+    minimumFractionDigits: 2,
+    maximumFractionDigits: 2,
+  });
+}
+
+function pct(n: number | null): string {
+  return n === null ? "n/a" : `${n.toFixed(2)}%`;
+}
+
+function qty(n: number): string {
+  return n.toLocaleString("en-US", { maximumFractionDigits: 8 });
+}
+
+// Shares are issued at $1 per net contributed dollar (see /vaults)
+function sharePrices(
+  points: StatementPoint[],
+): Array<{ date: string; sharePrice: number }> {
+  let last = 1;
+  return points.map((p) => {
+    if (p.netContributedUSD > 1e-8) last = p.aumUSD / p.netContributedUSD;
+    return { date: p.date, sharePrice: last };
+  });
+}
+
+function drawChart(
+  doc: PdfDocument,
+  y: number,
+  history: VaultStatement["priceHistory"],
+): void {
+  const w = PAGE_WIDTH - MARGIN * 2;
+  const h = 150;
+  doc.text(MARGIN, y, "Share price history", { size: 12, bold: true });
+  y += 12;
+  doc.rect(MARGIN, y, w, h, [0.96, 0.96, 0.96]);
+  if (history.length < 2) {
+    doc.text(MARGIN + 10, y + h / 2, "Not enough history to chart", {
+      color: MUTED,
+    });
+    return;
+  }
+
+  const prices = history.map((p) => p.sharePrice);
+  const min = Math.min(...prices);
+  const max = Math.max(...prices);
+  const span = max - min || 1;
+  const points = history.map((p, i): [number, number] => [
+    MARGIN + (i / (history.length - 1)) * w,
+    y + h - 8 - ((p.sharePrice - min) / span) * (h - 16),
+  ]);
+  doc.polyline(points, ACCENT, 1.2);
+
+  const label = { size: 8, color: MUTED };
+  doc.text(MARGIN + w - 4, y + 10, max.toFixed(4), {
+    ...label,
+    align: "right",
+  });
+  doc.text(MARGIN + w - 4, y + h - 4, min.toFixed(4), {
+    ...label,
+    align: "right",
+  });
+  doc.text(MARGIN, y + h + 10, history[0].date, label);
+  doc.text(MARGIN + w, y + h + 10, history[history.length - 1].date, {
+    ...label,
+    align: "right",
+  });
+}
+
+export class StatementService {
+  /**
+   * Position summary of a vault as of now: holdings with average cost
+   * basis, current value and PnL, plus the share price per day of
+   * `points`. `investor` only labels the statement; vault entries are
+   * not split per person.
+   */
+  async vaultStatement(
+    name: string,
+    points: StatementPoint[],
+    investor?: string,
+    now: Date = new Date(),
+  ): Promise<VaultStatement> {
+    const stats = await vaultService.vaultStats(name);
+
+    // Last event per asset carries its running quantity and cost basis
+    const latest = new Map<string, StatementHolding>();
+    for (const e of vaultService.getVaultTimeline(name)) {
+      if (e.type === "VALUATION") continue;
+      latest.set(assetKey(e.asset), {
+        asset: e.asset,
+        quantity: e.runningQuantity,
+        costBasisUSD: e.runningCostBasisUSD,
+        valueUSD: 0,
+        pnlUSD: 0,
+      });
+    }
+
+    const holdings: StatementHolding[] = [];
+    for (const h of latest.values()) {
+      if (Math.abs(h.quantity) < 1e-12) continue;
+      if (h.asset.symbol === "USD") {
+        // USD follows manual valuations rather than units
+        h.valueUSD = stats.aumUSDManual;
+      } else {
+        const rate = await priceService.getRateUSD(h.asset);
+        h.valueUSD =
+          h.quantity * stablecoinService.valuationRate(h.asset, rate.rateUSD);
+      }
+      h.pnlUSD = h.valueUSD - h.costBasisUSD;
+      holdings.push(h);
+    }
+    holdings.sort((a, b) => b.valueUSD - a.valueUSD);
+
+    const vault = vaultService.getVault(name);
+    const benchmark = vault
+      ? await benchmarkService.compare(vault, stats)
+      : undefined;
+
+    const netContributed = stats.totalDepositedUSD - stats.totalWithdrawnUSD;
+    const pnlUSD =
+      stats.aumUSD + stats.totalWithdrawnUSD - stats.totalDepositedUSD;
+    return {
+      vault: name,
+      investor,
+      asOf: now.toISOString().slice(0, 10),
+      generatedAt: now.toISOString(),
+      holdings,
+      depositedUSD: stats.totalDepositedUSD,
+      withdrawnUSD: stats.totalWithdrawnUSD,
+      costBasisUSD: holdings.reduce((s, h) => s + h.costBasisUSD, 0),
+      valueUSD: stats.aumUSD,
+      pnlUSD,
+      roiPercent: netContributed > 1e-8 ? (pnlUSD / netContributed) * 100 : 0,
+      priceHistory: sharePrices(points),
+      benchmark,
+    };
+  }
+
+  /** Render a statement as a printable A4 PDF. */
+  renderPdf(s: VaultStatement): Buffer {
+    const doc = new PdfDocument();
+    const right = PAGE_WIDTH - MARGIN;
+    let y = 60;
+
+    doc.text(MARGIN, y, `Vault statement: ${s.vault}`, {
+      size: 18,
+      bold: true,
+    });
+    y += 22;
+    if (s.investor) {
+      doc.text(MARGIN, y, `Prepared for ${s.investor}`, { size: 11 });
+      y += 16;
+    }
+    doc.text(MARGIN, y, `As of ${s.asOf}`, { size: 9, color: MUTED });
+    y += 28;
+
+    const summary: Array<[string, string]> = [
+      ["Deposited (USD)", usd(s.depositedUSD)],
+      ["Withdrawn (USD)", usd(s.withdrawnUSD)],
+      ["Cost basis (USD)", usd(s.costBasisUSD)],
+      ["Current value (USD)", usd(s.valueUSD)],
+      ["PnL (USD)", usd(s.pnlUSD)],
+      ["ROI", `${s.roiPercent.toFixed(2)}%`],
+    ];
+    // Benchmark ROI and alpha are over deposits, see benchmark.service.ts
+    const b = s.benchmark;
+    if (b) {
+      summary.push(
+        [`${b.symbol} return since inception`, pct(b.priceReturnPercent)],
+        [`Same deposits in ${b.symbol}, ROI`, pct(b.roiPercent)],
+        [`Alpha vs ${b.symbol}`, pct(b.alphaPercent)],
+      );
+    }
+    for (const [label, value] of summary) {
+      doc.text(MARGIN, y, label);
+      doc.text(MARGIN + 220, y, value, { align: "right", bold: true });
+      y += 15;
+    }
+    y += 20;
+
+    drawChart(doc, y, s.priceHistory);
+    y += 200;
+
+    const cols = [MARGIN, 250, 340, 430, right];
+    const header = ["Asset", "Quantity", "Cost basis", "Value", "PnL"];
+    const row = (values: string[], bold = false) => {
+      values.forEach((v, i) =>
+        doc.text(cols[i], y, v, { bold, align: i ? "right" : "left" }),
+      );
+      y += 15;
+    };
+
+    doc.text(MARGIN, y, "Holdings (USD)", { size: 12, bold: true });
+    y += 18;
+    row(header, true);
+    doc.line(MARGIN, y - 10, right, y - 10, MUTED);
+    if (!s.holdings.length) {
+      doc.text(MARGIN, y, "No open positions", { color: MUTED });
+      y += 15;
+    }
+    for (const h of s.holdings) {
+      if (y > BOTTOM) {
+        doc.addPage();
+        y = 60;
+        row(header, true);
+      }
+      row([
+        h.asset.symbol,
+        qty(h.quantity),
+        usd(h.costBasisUSD),
+        usd(h.valueUSD),
+        usd(h.pnlUSD),
+      ]);
+    }
+
+    doc.text(MARGIN, Math.min(y + 20, 820), `Generated ${s.generatedAt}`, {
+      size: 8,
+      color: MUTED,
+    });
+    return doc.toBuffer();
+  }
+}
+
+export const statementService = new StatementService();
@@ -0,0 +1,287 @@
+import { Asset, CostBasisMethod, VaultEntry, assetKey } from "../types";
+import {
+  settingsRepository,
+  transactionRepository,
+  vaultRepository,
+} from "../repositories";
+import {
+  LotBook,
+  LotRelease,
+  costBasisService,
+  isRewardDistribution,
+} from "./cost-basis.service";
+import { transactionService } from "./transaction.service";
+import { dateDiffInDays } from "./financial.service";
+import { fiscalYearRange } from "../utils/fiscal-year.util";
+
+export type HoldingTerm = "SHORT" | "LONG";
+export type TaxIncomeKind = "STAKING" | "DIVIDEND" | "INTEREST";
+
+// One lot (or part of one) closed by a withdrawal
+export interface TaxDisposal {
+  vault: string;
+  asset: Asset;
+  method: CostBasisMethod;
+  acquiredAt: string;
+  disposedAt: string;
+  units: number;
+  proceedsUSD: number;
+  costUSD: number;
+  gainUSD: number;
+  holdingDays: number;
+  term: HoldingTerm;
+}
+
+// Units withdrawn beyond the open lots; they have no known cost basis
+export interface TaxUnmatched {
+  vault: string;
+  asset: Asset;
+  disposedAt: string;
+  units: number;
+  proceedsUSD: number;
+}
+
+export interface TaxIncome {
+  kind: TaxIncomeKind;
+  at: string;
+  source: string; // vault or account it was received in
+  asset: Asset;
+  amount: number;
+  usd: number;
+  note?: string;
+}
+
+export interface TaxGainTotals {
+  count: number;
+  proceedsUSD: number;
+  costUSD: number;
+  gainUSD: number;
+}
+
+export interface TaxReport {
+  year: number; // fiscal year, named by the year it starts in
+  start: string;
+  end: string;
+  longTermDays: number;
+  disposals: TaxDisposal[];
+  unmatched: TaxUnmatched[];
+  gains: { short: TaxGainTotals; long: TaxGainTotals; total: TaxGainTotals };
+  income: TaxIncome[];
+  incomeByKind: Record<TaxIncomeKind, number>;
+  incomeUSD: number;
+  fees: {
+    networkUSD: number;
+    tradingUSD: number;
+    fxUSD: number;
+    totalUSD: number;
+  };
+}
+
+const EPSILON = 1e-12;
+
+/** Income kind from a transaction category, if it is investment income. */
+export function incomeKind(category?: string): TaxIncomeKind | undefined {
+  const c = (category || "").toLowerCase();
+  if (c.includes("dividend")) return "DIVIDEND";
+  if (c.includes("stak") || c.includes("reward")) return "STAKING";
+  if (c.includes("interest")) return "INTEREST";
+  return undefined;
+}
+
+function gainTotals(disposals: TaxDisposal[]): TaxGainTotals {
+  return disposals.reduce(
+    (t, d) => ({
+      count: t.count + 1,
+      proceedsUSD: t.proceedsUSD + d.proceedsUSD,
+      costUSD: t.costUSD + d.costUSD,
+      gainUSD: t.gainUSD + d.gainUSD,
+    }),
+    { count: 0, proceedsUSD: 0, costUSD: 0, gainUSD: 0 },
+  );
+}
+
+export class TaxService {
+  /**
+   * Realized gains, investment income and fees for one fiscal year: the
+   * calendar year (UTC) unless a fiscal year start is configured.
+   *
+   * Every vault is replayed from its first entry so lots bought in earlier
+   * years carry their cost and acquisition date. Each withdrawal closes
+   * lots by the vault's cost basis method; a lot held longer than
+   * `longTermDays` is long-term. Transfers between vaults are not
+   * disposals: the lots move to the destination vault unchanged. Cash
+   * (fiat) entries and reward distributions close no lots; the rewards
+   * are staking income instead.
+   */
+  report(
+    year: number,
+    longTermDays: number = settingsRepository.getLongTermHoldingDays(),
+  ): TaxReport {
+    const { start, end } = fiscalYearRange(
+      year,
+      settingsRepository.getFiscalYear(),
+    );
+    const inYear = (at: string) => at >= start && at <= end;
+
+    const vaults = new Set(vaultRepository.findAll().map((v) => v.name));
+    const entries: VaultEntry[] = [];
+    for (const name of vaults) {
+      entries.push(...vaultRepository.findAllEntries(name));
+    }
+    // A transfer's withdrawal goes before its deposit at the same time
+    entries.sort(
+      (a, b) =>
+        String(a.at).localeCompare(String(b.at)) ||
+        (a.type === "WITHDRAW" ? -1 : 0) - (b.type === "WITHDRAW" ? -1 : 0),
+    );
+
+    const books = new Map<string, LotBook>();
+    const bookFor = (vault: string, asset: Asset) => {
+      const k = `${vault}|${assetKey(asset)}`;
+      const book = books.get(k) || new LotBook();
+      books.set(k, book);
+      return book;
+    };
+    // Lots in transit, keyed by destination, source, asset and time
+    const moving = new Map<string, { lots: LotRelease[]; left: number }>();
+    const transitKey = (to: string, from: string, e: VaultEntry) =>
+      `${to}|${from}|${assetKey(e.asset)}|${e.at}`;
+
+    const disposals: TaxDisposal[] = [];
+    const unmatched: TaxUnmatched[] = [];
+    const income: TaxIncome[] = [];
+
+    for (const e of entries) {
+      if (e.type === "WITHDRAW" && isRewardDistribution(e)) {
+        if (inYear(e.at)) {
+          income.push({
+            kind: "STAKING",
+            at: e.at,
+            source: e.vault,
+            asset: e.asset,
+            amount: e.amount,
+            usd: Number(e.usdValue || 0),
+            note: e.note,
+          });
+        }
+        continue;
+      }
+      if (e.asset.type === "FIAT" || !(e.amount > 0)) continue;
+      const book = bookFor(e.vault, e.asset);
+      const peer =
+        e.account && e.account !== e.vault && vaults.has(e.account)
+          ? e.account
+          : undefined;
+
+      if (e.type === "DEPOSIT") {
+        const carried = peer && moving.get(transitKey(e.vault, peer, e));
+        if (carried) {
+          moving.delete(transitKey(e.vault, peer, e));
+          for (const r of carried.lots) {
+            book.add(r.acquiredAt, r.units, r.costUSD);
+          }
+          // Units the source never held arrive as a new lot at market value
+          if (carried.left > EPSILON) {
+            const usd = Number(e.usdValue || 0) * (carried.left / e.amount);
+            book.add(e.at, carried.left, usd);
+          }
+        } else {
+          book.add(e.at, e.amount, Number(e.usdValue || 0));
+        }
+        continue;
+      }
+      if (e.type !== "WITHDRAW") continue;
+
+      const method = costBasisService.methodFor(e.vault, e.asset.symbol);
+      const { released, unmatched: left } = book.release(
+        e.amount,
+        method,
+        e.lots,
+      );
+      if (peer) {
+        moving.set(transitKey(peer, e.vault, e), { lots: released, left });
+        continue;
+      }
+      if (!inYear(e.at)) continue;
+
+      const unitUSD = Number(e.usdValue || 0) / e.amount;
+      for (const r of released) {
+        const holdingDays = dateDiffInDays(
+          new Date(r.acquiredAt),
+          new Date(e.at),
+        );
+        const proceedsUSD = r.units * unitUSD;
+        disposals.push({
+          vault: e.vault,
+          asset: e.asset,
+          method,
+          acquiredAt: r.acquiredAt,
+          disposedAt: e.at,
+          units: r.units,
+          proceedsUSD,
+          costUSD: r.costUSD,
+          gainUSD: proceedsUSD - r.costUSD,
+          holdingDays,
+          term: holdingDays > longTermDays ? "LONG" : "SHORT",
+        });
+      }
+      if (left > EPSILON) {
+        unmatched.push({
+          vault: e.vault,
+          asset: e.asset,
+          disposedAt: e.at,
+          units: left,
+          proceedsUSD: left * unitUSD,
+        });
+      }
+    }
+
+    for (const t of transactionRepository.findAll()) {
+      const kind = t.type === "INCOME" ? incomeKind(t.category) : undefined;
+      if (!kind || !inYear(t.createdAt)) continue;
+      income.push({
+        kind,
+        at: t.createdAt,
+        source: t.account || "",
+        asset: t.asset,
+        amount: t.amount,
+        usd: t.usdAmount || 0,
+        note: t.note,
+      });
+    }
+    income.sort((a, b) => a.at.localeCompare(b.at));
+
+    const incomeByKind: Record<TaxIncomeKind, number> = {
+      STAKING: 0,
+      DIVIDEND: 0,
+      INTEREST: 0,
+    };
+    for (const i of income) incomeByKind[i.kind] += i.usd;
+
+    const fees = transactionService.getNetworkFees({ start, end });
+    return {
+      year,
+      start,
+      end,
+      longTermDays,
+      disposals,
+      unmatched,
+      gains: {
+        short: gainTotals(disposals.filter((d) => d.term === "SHORT")),
+        long: gainTotals(disposals.filter((d) => d.term === "LONG")),
+        total: gainTotals(disposals),
+      },
+      income,
+      incomeByKind,
+      incomeUSD: income.reduce((s, i) => s + i.usd, 0),
+      fees: {
+        networkUSD: fees.totalUSD,
+        tradingUSD: fees.tradingFeesUSD,
+        fxUSD: fees.fxFees.totalUSD,
+        totalUSD: fees.totalUSD + fees.tradingFeesUSD + fees.fxFees.totalUSD,
+      },
+    };
+  }
+}
+
+export const taxService = new TaxService();
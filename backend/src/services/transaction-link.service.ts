@@ -0,0 +1,179 @@
+import { Transaction } from "../types";
+import {
+  pendingActionsRepository,
+  transactionRepository,
+} from "../repositories";
+
+// transfer: TRANSFER_OUT/IN pair; loan: borrow/loan and its repayments;
+// reimbursement: refund income and the expense it repays; action_batch:
+// transactions created from one import batch
+export type TransactionLinkType =
+  | "transfer"
+  | "loan"
+  | "reimbursement"
+  | "action_batch";
+
+// "from": the linked transaction came first (e.g. the borrow a repay
+// settles); "to": it follows from this one; "sibling": neither
+export type TransactionLinkDirection = "from" | "to" | "sibling";
+
+export interface TransactionSummary {
+  id: string;
+  type: Transaction["type"];
+  asset: string;
+  amount: number;
+  usdAmount: number;
+  createdAt: string;
+  account?: string;
+}
+
+export interface TransactionLink {
+  type: TransactionLinkType;
+  direction: TransactionLinkDirection;
+  transaction: TransactionSummary;
+}
+
+export interface RelatedTransactions {
+  root: string;
+  transactions: Array<Transaction & { depth: number }>;
+  links: Array<{ type: TransactionLinkType; from: string; to: string }>;
+}
+
+function summarize(tx: Transaction): TransactionSummary {
+  return {
+    id: tx.id,
+    type: tx.type,
+    asset: tx.asset.symbol,
+    amount: tx.amount,
+    usdAmount: tx.usdAmount,
+    createdAt: tx.createdAt,
+    account: tx.account,
+  };
+}
+
+function groupBy(
+  txs: Transaction[],
+  key: (t: Transaction) => string | undefined,
+): Map<string, Transaction[]> {
+  const groups = new Map<string, Transaction[]>();
+  for (const t of txs) {
+    const k = key(t);
+    if (!k) continue;
+    groups.set(k, [...(groups.get(k) || []), t]);
+  }
+  return groups;
+}
+
+// Lookups over every transaction, built once per request
+class LinkIndex {
+  readonly byId: Map<string, Transaction>;
+  private byTransfer: Map<string, Transaction[]>;
+  private byLoan: Map<string, Transaction[]>;
+  private refundsOf: Map<string, Transaction[]>;
+  private batchOf = new Map<string, string[]>();
+
+  constructor(txs: Transaction[]) {
+    this.byId = new Map(txs.map((t) => [t.id, t]));
+    this.byTransfer = groupBy(txs, (t) => t.transferId);
+    this.byLoan = groupBy(txs, (t) => t.loanId);
+    this.refundsOf = groupBy(txs, (t) => t.reimbursesId);
+
+    const batches = new Map<string, string[]>();
+    for (const p of pendingActionsRepository.findAll()) {
+      if (!p.batch_id || !p.created_tx_ids?.length) continue;
+      batches.set(p.batch_id, [
+        ...(batches.get(p.batch_id) || []),
+        ...p.created_tx_ids,
+      ]);
+    }
+    for (const ids of batches.values()) {
+      for (const id of ids) this.batchOf.set(id, ids);
+    }
+  }
+
+  linksFor(tx: Transaction): TransactionLink[] {
+    const links: TransactionLink[] = [];
+    const add = (
+      type: TransactionLinkType,
+      direction: TransactionLinkDirection,
+      other: Transaction | undefined,
+    ) => {
+      if (!other || other.id === tx.id) return;
+      links.push({ type, direction, transaction: summarize(other) });
+    };
+
+    if (tx.transferId) {
+      for (const o of this.byTransfer.get(tx.transferId) || []) {
+        add("transfer", tx.type === "TRANSFER_OUT" ? "to" : "from", o);
+      }
+    }
+    if (tx.loanId) {
+      const isRepay = (t: Transaction) => t.type === "REPAY";
+      for (const o of this.byLoan.get(tx.loanId) || []) {
+        const direction =
+          isRepay(tx) === isRepay(o) ? "sibling" : isRepay(tx) ? "from" : "to";
+        add("loan", direction, o);
+      }
+    }
+    if (tx.reimbursesId) {
+      add("reimbursement", "from", this.byId.get(tx.reimbursesId));
+    }
+    for (const o of this.refundsOf.get(tx.id) || []) {
+      add("reimbursement", "to", o);
+    }
+    for (const id of this.batchOf.get(tx.id) || []) {
+      add("action_batch", "sibling", this.byId.get(id));
+    }
+    return links;
+  }
+}
+
+export class TransactionLinkService {
+  /** Transactions directly linked to `id`; undefined if it doesn't exist. */
+  getLinks(id: string): TransactionLink[] | undefined {
+    const index = new LinkIndex(transactionRepository.findAll());
+    const tx = index.byId.get(id);
+    return tx ? index.linksFor(tx) : undefined;
+  }
+
+  /**
+   * Everything reachable from `id` through links, breadth first, with
+   * each transaction's distance from the root and every link once.
+   */
+  getRelated(id: string): RelatedTransactions | undefined {
+    const index = new LinkIndex(transactionRepository.findAll());
+    if (!index.byId.has(id)) return undefined;
+
+    const depth = new Map<string, number>([[id, 0]]);
+    const seen = new Set<string>();
+    const result: RelatedTransactions = {
+      root: id,
+      transactions: [],
+      links: [],
+    };
+    const queue = [id];
+    while (queue.length) {
+      const current = queue.shift()!;
+      for (const link of index.linksFor(index.byId.get(current)!)) {
+        const other = link.transaction.id;
+        const [from, to] =
+          link.direction === "from" ? [other, current] : [current, other];
+        const key = [link.type, ...[from, to].sort()].join(":");
+        if (!seen.has(key)) {
+          seen.add(key);
+          result.links.push({ type: link.type, from, to });
+        }
+        if (depth.has(other)) continue;
+        depth.set(other, depth.get(current)! + 1);
+        queue.push(other);
+        result.transactions.push({
+          ...index.byId.get(other)!,
+          depth: depth.get(other)!,
+        });
+      }
+    }
+    return result;
+  }
+}
+
+export const transactionLinkService = new TransactionLinkService();
@@ -0,0 +1,206 @@
+import { ReportPeriodMode, Transaction, TransactionType } from "../types";
+import { adminRepository, transactionRepository } from "../repositories";
+import { ValidationError } from "../core/errors";
+import { decodeCursor, encodeCursor } from "../utils/cursor.util";
+import { periodRange } from "./report-subscription.service";
+
+export const DEFAULT_SEARCH_LIMIT = 50;
+export const MAX_SEARCH_LIMIT = 500;
+export const SEARCH_PRESETS: ReportPeriodMode[] = [
+  "last_7_days",
+  "last_30_days",
+  "this_month",
+  "last_month",
+  "year_to_date",
+  "last_year",
+];
+
+export interface TransactionSearchParams {
+  q?: string; // every word must appear in note, counterparty, category or tags
+  types?: TransactionType[];
+  accounts?: string[];
+  members?: string[];
+  institutions?: string[]; // of the account, from the admin accounts table
+  minAmount?: number; // asset units
+  maxAmount?: number;
+  minUSD?: number; // |usdAmount|
+  maxUSD?: number;
+  internalFlow?: boolean; // transfer legs between our own accounts
+  start?: string; // ISO, inclusive
+  end?: string; // ISO, inclusive
+  preset?: ReportPeriodMode; // instead of start/end
+  limit?: number;
+  cursor?: string; // nextCursor of the previous page
+}
+
+const FILTER_KEYS: (keyof TransactionSearchParams)[] = [
+  "q",
+  "types",
+  "accounts",
+  "members",
+  "institutions",
+  "minAmount",
+  "maxAmount",
+  "minUSD",
+  "maxUSD",
+  "internalFlow",
+  "start",
+  "end",
+  "preset",
+];
+
+export interface TransactionSearchResult {
+  items: Transaction[];
+  total: number; // matches across all pages
+  nextCursor?: string; // absent on the last page
+}
+
+// Lower case without Vietnamese (and other) diacritics, so "pho" finds
+// "Phở" and "do" finds "Đô"
+export function foldText(text: string): string {
+  return text
+    .normalize("NFD")
+    .replace(/[\u0300-\u036f]/g, "")
+    .replace(/đ/g, "d")
+    .replace(/Đ/g, "D")
+    .toLowerCase();
+}
+
+/**
+ * Both legs of a transfer between our own accounts move no money, nor
+ * does anything a rule marked as internal (e.g. a card payoff).
+ */
+export function isInternalFlow(t: Transaction): boolean {
+  if (t.internalFlow !== undefined) return t.internalFlow;
+  return (
+    (t.type === "TRANSFER_IN" || t.type === "TRANSFER_OUT") && !!t.transferId
+  );
+}
+
+function newestFirst(a: Transaction, b: Transaction): number {
+  return (
+    String(b.createdAt).localeCompare(String(a.createdAt)) ||
+    String(b.id).localeCompare(String(a.id))
+  );
+}
+
+/**
+ * Server-side transaction search: words of `q` over the text fields plus
+ * structured filters, newest first, one page at a time.
+ */
+export class TransactionSearchService {
+  search(params: TransactionSearchParams): TransactionSearchResult {
+    const limit = params.limit ?? DEFAULT_SEARCH_LIMIT;
+    if (!Number.isInteger(limit) || limit < 1 || limit > MAX_SEARCH_LIMIT) {
+      throw new ValidationError(
+        `limit must be between 1 and ${MAX_SEARCH_LIMIT}`,
+      );
+    }
+
+    const filtered = FILTER_KEYS.some((k) => {
+      const v = params[k];
+      return Array.isArray(v) ? v.length > 0 : v !== undefined && v !== "";
+    });
+    // Plain paging seeks in the repository instead of loading every row
+    if (!filtered) {
+      const page = transactionRepository.findPage({
+        limit,
+        cursor: params.cursor,
+      });
+      return { ...page, total: transactionRepository.count() };
+    }
+
+    let { start, end } = params;
+    const period = params.preset ? periodRange(params.preset) : undefined;
+    if (period) {
+      start = period.start;
+      end = period.end;
+    }
+    const words = foldText(params.q || "")
+      .split(/\s+/)
+      .filter(Boolean);
+    const types = params.types?.length ? new Set(params.types) : undefined;
+    const accounts = params.accounts?.length
+      ? new Set(params.accounts.map((a) => a.trim().toLowerCase()))
+      : undefined;
+    const members = params.members?.length
+      ? new Set(params.members.map((m) => m.trim().toLowerCase()))
+      : undefined;
+    const institutions = params.institutions?.length
+      ? new Set(params.institutions.map((i) => i.trim().toLowerCase()))
+      : undefined;
+    const institutionOf = new Map<string, string>();
+    if (institutions) {
+      for (const a of adminRepository.findAllAccounts()) {
+        if (a.institution) {
+          institutionOf.set(a.name.toLowerCase(), a.institution.toLowerCase());
+        }
+      }
+    }
+
+    const matches = transactionRepository.findAll().filter((t) => {
+      if (types && !types.has(t.type)) return false;
+      if (accounts && !accounts.has((t.account || "").toLowerCase())) {
+        return false;
+      }
+      if (members && !members.has((t.member || "").toLowerCase())) {
+        return false;
+      }
+      if (
+        institutions &&
+        !institutions.has(
+          institutionOf.get((t.account || "").toLowerCase()) ?? "",
+        )
+      ) {
+        return false;
+      }
+      if (start && t.createdAt < start) return false;
+      if (end && t.createdAt > end) return false;
+      const amount = Math.abs(Number(t.amount) || 0);
+      if (params.minAmount !== undefined && amount < params.minAmount) {
+        return false;
+      }
+      if (params.maxAmount !== undefined && amount > params.maxAmount) {
+        return false;
+      }
+      const usd = Math.abs(Number(t.usdAmount) || 0);
+      if (params.minUSD !== undefined && usd < params.minUSD) return false;
+      if (params.maxUSD !== undefined && usd > params.maxUSD) return false;
+      if (
+        params.internalFlow !== undefined &&
+        isInternalFlow(t) !== params.internalFlow
+      ) {
+        return false;
+      }
+      if (!words.length) return true;
+      const text = foldText(
+        [t.note, t.counterparty, t.category, ...(t.tags || [])]
+          .filter(Boolean)
+          .join(" "),
+      );
+      return words.every((w) => text.includes(w));
+    });
+    matches.sort(newestFirst);
+
+    let from = 0;
+    if (params.cursor) {
+      const { at, id } = decodeCursor(params.cursor);
+      from = matches.findIndex(
+        (t) => t.createdAt < at || (t.createdAt === at && t.id < String(id)),
+      );
+      if (from === -1) from = matches.length;
+    }
+    const items = matches.slice(from, from + limit);
+    const last = items[items.length - 1];
+    return {
+      items,
+      total: matches.length,
+      nextCursor:
+        from + limit < matches.length
+          ? encodeCursor(last.createdAt, last.id)
+          : undefined,
+    };
+  }
+}
+
+export const transactionSearchService = new TransactionSearchService();
@@ -1,6 +1,7 @@
 import { v4 as uuidv4 } from "uuid";
 import {
   Asset,
+  Rate,
   Transaction,
   PortfolioReport,
   PortfolioReportItem,
@@ -10,8 +11,16 @@ import { transactionRepository } from "../repositories";
 import { vaultRepository } from "../repositories";
 import { settingsRepository } from "../repositories";
 import { borrowingRepository } from "../repositories";
+import { adminRepository } from "../repositories";
 import { priceService } from "./price.service";
 import { vaultService } from "./vault.service";
+import { stablecoinService } from "./stablecoin.service";
+import { ValidationError } from "../core/errors";
+
+// A reward price dated further than this from the reward is flagged stale
+const REWARD_PRICE_MAX_AGE_MS = 24 * 60 * 60 * 1000;
+// Cards are billed in VND; spending in any other fiat pays the FX markup
+const CARD_BILLING_CURRENCY = "VND";
 
 export interface TransactionBase {
   asset: Asset;
@@ -22,6 +31,88 @@ export interface TransactionBase {
   usdAmount: number;
 }
 
+// Gas paid on one chain in one month, per native fee asset
+export interface NetworkFeeBucket {
+  chain: string;
+  month: string; // YYYY-MM
+  count: number;
+  native: Record<string, number>;
+  usd: number;
+}
+
+export interface NetworkFeeReport {
+  buckets: NetworkFeeBucket[];
+  byChain: Record<string, { count: number; usd: number }>;
+  totalUSD: number;
+  // Exchange trading/withdrawal fees: fee expenses not tied to a chain
+  tradingFeesUSD: number;
+  fxFees: CardFxFeeReport;
+}
+
+// FX fees of foreign-currency card expenses (feeUSD), by month and card
+export interface CardFxFeeReport {
+  byMonth: Record<string, { count: number; usd: number }>;
+  byCard: Record<string, { count: number; usd: number }>;
+  totalUSD: number;
+}
+
+// A transferId group whose legs don't add up
+export type TransferIssueKind =
+  | "AMOUNT_MISMATCH"
+  | "MISSING_IN"
+  | "MISSING_OUT";
+export interface TransferPairIssue {
+  transferId: string;
+  kind: TransferIssueKind;
+  out?: Transaction;
+  in?: Transaction;
+  fee: number; // recorded fee, in the out asset (USD for cross-asset pairs)
+  // Sent minus received, beyond what the fee explains; negative when more
+  // arrived than was sent. In out-asset units, or USD for cross-asset pairs.
+  discrepancy: number;
+  discrepancyUSD: number;
+}
+
+export interface ReimbursementStatus {
+  expense: Transaction;
+  refunds: Transaction[];
+  reimbursedUSD: number;
+  outstandingUSD: number;
+}
+
+// Spending at one place, or in one ~1 km cell when only coordinates are known
+export interface SpendingLocation {
+  key: string;
+  place?: string;
+  latitude?: number; // centroid of the geotagged transactions
+  longitude?: number;
+  count: number;
+  usd: number;
+}
+
+export interface SpendingByLocationReport {
+  locations: SpendingLocation[]; // largest first
+  unlocated: { count: number; usd: number };
+  totalUSD: number;
+}
+
+// What one household member spent and brought in over a period
+export interface MemberTotals {
+  member?: string; // absent for transactions nobody is attributed to
+  spending: { count: number; usd: number };
+  contribution: { count: number; usd: number }; // income
+}
+
+export interface MemberReport {
+  members: MemberTotals[]; // biggest spender first
+  unassigned: MemberTotals;
+  totalSpendingUSD: number;
+  totalContributionUSD: number;
+}
+
+// 2 decimal places of a degree is about 1.1 km: a neighbourhood
+const LOCATION_GRID_DECIMALS = 2;
+
 export class TransactionService {
   /**
    * Validates that a transaction has either note or counterparty.
@@ -44,6 +135,15 @@ export class TransactionService {
     }
   }
 
+  private validateLocation(params: {
+    latitude?: number;
+    longitude?: number;
+  }): void {
+    if ((params.latitude === undefined) !== (params.longitude === undefined)) {
+      throw new Error("latitude and longitude must be given together");
+    }
+  }
+
   async buildTransactionBase(
     asset: Asset,
     amount: number,
@@ -51,7 +151,19 @@ export class TransactionService {
     account?: string,
     transactionType?: string,
   ): Promise<TransactionBase> {
-    const rate = await priceService.getRateUSD(asset, at);
+    // Record the transaction even when pricing fails; the enrichment queue
+    // fills the rate in once a provider answers
+    const rate: Rate = await priceService
+      .getRateUSD(asset, at)
+      .catch(
+        (): Rate => ({
+          asset,
+          rateUSD: 0,
+          timestamp: at ?? new Date().toISOString(),
+          source: "FIXED",
+          missing: true,
+        }),
+      );
     const acc =
       account && String(account).trim().length
         ? String(account).trim()
@@ -118,8 +230,10 @@ export class TransactionService {
     category?: string;
     tags?: string[];
     counterparty?: string;
+    member?: string;
     dueDate?: string;
     sourceRef?: string;
+    projectId?: string;
   }): Promise<Transaction> {
     // Validate description
     this.validateDescription({
@@ -143,8 +257,10 @@ export class TransactionService {
       category: params.category,
       tags: params.tags,
       counterparty: params.counterparty,
+      member: params.member?.trim() || undefined,
       dueDate: params.dueDate,
       sourceRef: params.sourceRef,
+      projectId: params.projectId,
       ...base,
     } as Transaction;
 
@@ -152,6 +268,104 @@ export class TransactionService {
     return tx;
   }
 
+  /**
+   * Reward received in kind (staking, savings interest, airdrops). Without
+   * a price the asset is valued at its price on the reward date; when only
+   * an older price is available the rate is flagged stale so the reward
+   * can be revalued once the provider has that day's price.
+   */
+  async createRewardTransaction(params: {
+    asset: Asset;
+    amount: number;
+    at?: string;
+    account?: string;
+    note?: string;
+    counterparty?: string;
+    priceLocal?: number; // USD per unit, when known at entry
+    sourceRef?: string;
+  }): Promise<Transaction> {
+    const at = params.at ?? new Date().toISOString();
+    const rate = await this.rewardRate(params.asset, at, params.priceLocal);
+    const account =
+      params.account && params.account.trim().length
+        ? params.account.trim()
+        : settingsRepository.getDefaultSpendingVaultName();
+
+    const tx: Transaction = {
+      id: uuidv4(),
+      type: "INCOME",
+      note: params.note ?? `Reward: ${params.amount} ${params.asset.symbol}`,
+      category: "reward",
+      counterparty: params.counterparty,
+      sourceRef: params.sourceRef,
+      asset: params.asset,
+      amount: params.amount,
+      createdAt: at,
+      account,
+      rate,
+      usdAmount: params.amount * rate.rateUSD,
+    } as Transaction;
+
+    transactionRepository.create(tx);
+    return tx;
+  }
+
+  /**
+   * Revalue rewards that were never priced or were priced stale. Rewards
+   * whose provider still only has an older price stay flagged.
+   */
+  async revalueRewards(): Promise<{
+    revalued: Transaction[];
+    stillStale: Transaction[];
+  }> {
+    const revalued: Transaction[] = [];
+    const stillStale: Transaction[] = [];
+    const rewards = transactionRepository
+      .findAll()
+      .filter((t) => t.type === "INCOME" && t.category === "reward")
+      .filter((t) => !(t.rate?.rateUSD > 0) || t.rate?.stale);
+
+    for (const t of rewards) {
+      let rate: Rate;
+      try {
+        rate = await this.rewardRate(t.asset, t.createdAt);
+      } catch {
+        stillStale.push(t);
+        continue;
+      }
+      const updated = transactionRepository.update(t.id, {
+        rate,
+        usdAmount: t.amount * rate.rateUSD,
+      }) as Transaction;
+      (rate.stale ? stillStale : revalued).push(updated);
+    }
+    return { revalued, stillStale };
+  }
+
+  private async rewardRate(
+    asset: Asset,
+    at: string,
+    priceLocal?: number,
+  ): Promise<Rate> {
+    if (priceLocal && priceLocal > 0) {
+      return { asset, rateUSD: priceLocal, timestamp: at, source: "FIXED" };
+    }
+
+    const rate = await priceService.getRateUSD(asset, at);
+    // FIXED without a cached fallback means no provider priced the asset
+    if (rate.source === "FIXED" && !rate.stale && asset.symbol !== "USD") {
+      throw new Error(
+        `No price for ${asset.symbol} on ${at.slice(0, 10)}; pass price_local`,
+      );
+    }
+    const age = Math.abs(
+      new Date(rate.timestamp).getTime() - new Date(at).getTime(),
+    );
+    return rate.stale || age > REWARD_PRICE_MAX_AGE_MS
+      ? { ...rate, stale: true }
+      : rate;
+  }
+
   async createExpenseTransaction(params: {
     asset: Asset;
     amount: number;
@@ -161,8 +375,16 @@ export class TransactionService {
     category?: string;
     tags?: string[];
     counterparty?: string;
+    member?: string;
     dueDate?: string;
     sourceRef?: string;
+    reimbursable?: boolean;
+    projectId?: string;
+    card?: string;
+    feeUSD?: number;
+    place?: string;
+    latitude?: number;
+    longitude?: number;
   }): Promise<Transaction> {
     // Validate description
     this.validateDescription({
@@ -170,6 +392,7 @@ export class TransactionService {
       counterparty: params.counterparty,
       category: params.category,
     });
+    this.validateLocation(params);
 
     const base = await this.buildTransactionBase(
       params.asset,
@@ -186,8 +409,23 @@ export class TransactionService {
       category: params.category,
       tags: params.tags,
       counterparty: params.counterparty,
+      member: params.member?.trim() || undefined,
       dueDate: params.dueDate,
       sourceRef: params.sourceRef,
+      reimbursable: params.reimbursable || undefined,
+      projectId: params.projectId,
+      card: params.card,
+      feeUSD:
+        params.feeUSD ??
+        this.cardFxFee(
+          params.asset,
+          base.usdAmount,
+          params.card,
+          params.account,
+        ),
+      place: params.place?.trim() || undefined,
+      latitude: params.latitude,
+      longitude: params.longitude,
       ...(params.category ? { tag: params.category } : ({} as any)),
       ...base,
     } as Transaction;
@@ -218,6 +456,115 @@ export class TransactionService {
     return tx;
   }
 
+  /**
+   * FX fee of a foreign-currency card expense from the configured card
+   * markup. Undefined when no card, no markup, or the billing currency,
+   * and when the account has an FX margin of its own: that one is in
+   * `usdAmount` already (see repositories/fx-snapshot.repository).
+   */
+  private cardFxFee(
+    asset: Asset,
+    usdAmount: number,
+    card?: string,
+    account?: string,
+  ): number | undefined {
+    if (!card || asset.type !== "FIAT") return undefined;
+    if (asset.symbol.toUpperCase() === CARD_BILLING_CURRENCY) return undefined;
+    const name = account?.trim().toLowerCase();
+    const own = adminRepository
+      .findAllAccounts()
+      .find((a) => a.name.trim().toLowerCase() === name);
+    if (Number(own?.fx_margin_percent) > 0) return undefined;
+    const markup = settingsRepository.getCardFxMarkupPercent();
+    return markup > 0 ? (Math.abs(usdAmount) * markup) / 100 : undefined;
+  }
+
+  /**
+   * Dividend reinvestment: records the cash dividend and the reinvestment
+   * purchase as one linked group. The purchase is a same-account
+   * conversion (TRANSFER_OUT cash, TRANSFER_IN units) like a cross-asset
+   * transfer; the units carry the dividend as their cost basis, and when
+   * the account is a vault they are deposited at that cost.
+   */
+  async createDripTransactions(params: {
+    asset: Asset; // fund whose dividend is reinvested
+    units: number; // units bought with the dividend
+    dividend: number; // gross dividend in dividendAsset units
+    dividendAsset: Asset;
+    account: string;
+    at?: string;
+    note?: string;
+  }): Promise<Transaction[]> {
+    const { asset, units, dividend, dividendAsset } = params;
+    if (!(units > 0) || !(dividend > 0)) {
+      throw new Error("units and dividend must be positive");
+    }
+
+    const cash = await this.buildTransactionBase(
+      dividendAsset,
+      dividend,
+      params.at,
+      params.account,
+      "INCOME",
+    );
+    const costUSD = cash.usdAmount;
+    const transferId = uuidv4();
+    const suffix = params.note ? `: ${params.note}` : "";
+
+    const dividendTx = {
+      id: uuidv4(),
+      type: "INCOME",
+      category: "dividend",
+      note: `Dividend from ${asset.symbol}${suffix}`,
+      transferId,
+      ...cash,
+    } as Transaction;
+
+    const cashOut = {
+      id: uuidv4(),
+      type: "TRANSFER_OUT",
+      note: `DRIP: ${dividend} ${dividendAsset.symbol} reinvested${suffix}`,
+      transferId,
+      ...cash,
+    } as Transaction;
+
+    const unitsIn = {
+      id: uuidv4(),
+      type: "TRANSFER_IN",
+      note: `DRIP: ${units} ${asset.symbol} @ ${costUSD / units} USD${suffix}`,
+      transferId,
+      asset,
+      amount: units,
+      createdAt: cash.createdAt,
+      account: cash.account,
+      rate: {
+        asset,
+        rateUSD: costUSD / units,
+        timestamp: cash.createdAt,
+        source: "FIXED",
+      },
+      usdAmount: costUSD,
+    } as Transaction;
+
+    const txs = [dividendTx, cashOut, unitsIn];
+    for (const tx of txs) transactionRepository.create(tx);
+
+    if (vaultRepository.findByName(cash.account)) {
+      vaultService.addVaultEntry({
+        vault: cash.account,
+        type: "DEPOSIT",
+        asset,
+        amount: units,
+        usdValue: costUSD,
+        at: cash.createdAt,
+        account: cash.account,
+        note: `DRIP reinvestment of ${asset.symbol} dividend`,
+      });
+    }
+
+    return txs;
+  }
+
   async createBorrowTransaction(params: {
     asset: Asset;
     amount: number;
@@ -309,15 +656,418 @@ export class TransactionService {
     return transactionRepository.findAll();
   }
 
+  /** Account name -> institution, from admin account metadata. */
+  getAccountInstitutions(): Map<string, string> {
+    const map = new Map<string, string>();
+    for (const a of adminRepository.findAllAccounts()) {
+      if (a.institution) map.set(a.name, a.institution);
+    }
+    return map;
+  }
+
+  /**
+   * Country exposure of each holding: the asset's jurisdiction (issuer or
+   * market) wins over the jurisdiction of the account it is held in.
+   */
+  getHoldingJurisdictions(
+    holdings: PortfolioReportItem[],
+  ): Array<{ holding: PortfolioReportItem; jurisdiction?: string }> {
+    const byAccount = new Map<string, string>();
+    for (const a of adminRepository.findAllAccounts()) {
+      if (a.jurisdiction) byAccount.set(a.name, a.jurisdiction);
+    }
+    const byAsset = new Map<string, string>();
+    for (const a of adminRepository.findAllAssets()) {
+      if (a.jurisdiction) byAsset.set(a.symbol.toUpperCase(), a.jurisdiction);
+    }
+    return holdings.map((holding) => ({
+      holding,
+      jurisdiction:
+        byAsset.get(holding.asset.symbol.toUpperCase()) ??
+        (holding.account ? byAccount.get(holding.account) : undefined),
+    }));
+  }
+
   getTransactionById(id: string): Transaction | undefined {
     return transactionRepository.findById(id);
   }
 
-  deleteTransaction(id: string): boolean {
-    return transactionRepository.delete(id);
+  /** Soft delete: the transaction leaves every report until restored. */
+  deleteTransaction(id: string, now = new Date()): boolean {
+    return transactionRepository.softDelete(id, now.toISOString());
+  }
+
+  restoreTransaction(id: string): Transaction | undefined {
+    return transactionRepository.restore(id);
+  }
+
+  getDeletedTransactions(): Transaction[] {
+    return transactionRepository.findDeleted();
+  }
+
+  /** Permanently remove transactions deleted at least `days` ago. */
+  purgeDeletedTransactions(days: number, now = new Date()): number {
+    if (!Number.isInteger(days) || days < 0) {
+      throw new ValidationError("days must be a non-negative integer");
+    }
+    const before = new Date(now.getTime() - days * 24 * 60 * 60 * 1000);
+    return transactionRepository.purgeDeleted(before.toISOString());
+  }
+
+  markReimbursable(id: string, reimbursable: boolean): Transaction {
+    const tx = transactionRepository.findById(id);
+    if (!tx) throw new Error("Transaction not found");
+    if (tx.type !== "EXPENSE") {
+      throw new Error("Only expenses can be marked reimbursable");
+    }
+    return transactionRepository.update(id, { reimbursable }) as Transaction;
+  }
+
+  /**
+   * Link an incoming refund (INCOME) to the expense it repays.
+   * The expense is marked reimbursable if it wasn't already.
+   */
+  matchReimbursement(
+    expenseId: string,
+    incomeId: string,
+  ): { expense: Transaction; income: Transaction } {
+    const expense = transactionRepository.findById(expenseId);
+    if (!expense || expense.type !== "EXPENSE") {
+      throw new Error("Expense not found");
+    }
+    const income = transactionRepository.findById(incomeId);
+    if (!income || income.type !== "INCOME") {
+      throw new Error("Income not found");
+    }
+    if (income.reimbursesId && income.reimbursesId !== expenseId) {
+      throw new Error(
+        `Income already matched to expense ${income.reimbursesId}`,
+      );
+    }
+
+    const updatedExpense = expense.reimbursable
+      ? expense
+      : (transactionRepository.update(expenseId, {
+          reimbursable: true,
+        }) as Transaction);
+    const updatedIncome = transactionRepository.update(incomeId, {
+      reimbursesId: expenseId,
+    }) as Transaction;
+
+    return { expense: updatedExpense, income: updatedIncome };
+  }
+
+  unmatchReimbursement(incomeId: string): Transaction | undefined {
+    const income = transactionRepository.findById(incomeId);
+    if (!income || !income.reimbursesId) return undefined;
+    return transactionRepository.update(incomeId, { reimbursesId: undefined });
+  }
+
+  // USD refunded per expense id, from INCOME linked via reimbursesId
+  getReimbursedUSDByExpense(txs?: Transaction[]): Map<string, number> {
+    const all = txs ?? transactionRepository.findAll();
+    const out = new Map<string, number>();
+    for (const t of all) {
+      if (t.type !== "INCOME" || !t.reimbursesId) continue;
+      out.set(
+        t.reimbursesId,
+        (out.get(t.reimbursesId) || 0) + (t.usdAmount || 0),
+      );
+    }
+    return out;
+  }
+
+  getReimbursements(): ReimbursementStatus[] {
+    const all = transactionRepository.findAll();
+    const refundsByExpense = new Map<string, Transaction[]>();
+    for (const t of all) {
+      if (t.type !== "INCOME" || !t.reimbursesId) continue;
+      const list = refundsByExpense.get(t.reimbursesId) || [];
+      list.push(t);
+      refundsByExpense.set(t.reimbursesId, list);
+    }
+
+    return all
+      .filter((t) => t.type === "EXPENSE" && t.reimbursable)
+      .map((expense) => {
+        const refunds = refundsByExpense.get(expense.id) || [];
+        const reimbursedUSD = refunds.reduce(
+          (s, r) => s + (r.usdAmount || 0),
+          0,
+        );
+        return {
+          expense,
+          refunds,
+          reimbursedUSD,
+          outstandingUSD: Math.max(0, (expense.usdAmount || 0) - reimbursedUSD),
+        };
+      });
+  }
+
+  /**
+   * Network (gas) fees grouped by chain and month. Only expenses carrying a
+   * chain and the network_fee category count; other fee expenses are summed
+   * separately as exchange trading fees. Card FX fees (feeUSD on expenses)
+   * are grouped by month and card; the chain filter doesn't apply to them.
+   */
+  getNetworkFees(
+    params: { start?: string; end?: string; chain?: string } = {},
+  ): NetworkFeeReport {
+    const chain = params.chain?.toLowerCase();
+    const buckets = new Map<string, NetworkFeeBucket>();
+    const byChain: NetworkFeeReport["byChain"] = {};
+    let totalUSD = 0;
+    let tradingFeesUSD = 0;
+    const fxFees: CardFxFeeReport = { byMonth: {}, byCard: {}, totalUSD: 0 };
+    const addFx = (
+      groups: Record<string, { count: number; usd: number }>,
+      key: string,
+      usd: number,
+    ) => {
+      const g = groups[key] || { count: 0, usd: 0 };
+      groups[key] = g;
+      g.count++;
+      g.usd += usd;
+    };
+
+    for (const t of transactionRepository.findAll()) {
+      if (t.type !== "EXPENSE") continue;
+      if (params.start && t.createdAt < params.start) continue;
+      if (params.end && t.createdAt > params.end) continue;
+      const usd = t.usdAmount || 0;
+
+      if (t.feeUSD && t.feeUSD > 0) {
+        addFx(fxFees.byMonth, t.createdAt.slice(0, 7), t.feeUSD);
+        addFx(fxFees.byCard, t.card || "Unassigned", t.feeUSD);
+        fxFees.totalUSD += t.feeUSD;
+      }
+
+      if (!t.chain || t.category !== "network_fee") {
+        if (!t.chain && /fee/i.test(t.category || "")) tradingFeesUSD += usd;
+        continue;
+      }
+      if (chain && t.chain !== chain) continue;
+
+      const month = t.createdAt.slice(0, 7);
+      const key = `${t.chain}|${month}`;
+      const bucket = buckets.get(key) || {
+        chain: t.chain,
+        month,
+        count: 0,
+        native: {},
+        usd: 0,
+      };
+      const sym = t.asset.symbol;
+      bucket.native[sym] = (bucket.native[sym] || 0) + t.amount;
+      bucket.count++;
+      bucket.usd += usd;
+      buckets.set(key, bucket);
+
+      const c = byChain[t.chain] || { count: 0, usd: 0 };
+      byChain[t.chain] = c;
+      c.count++;
+      c.usd += usd;
+      totalUSD += usd;
+    }
+
+    return {
+      buckets: Array.from(buckets.values()).sort(
+        (a, b) =>
+          a.month.localeCompare(b.month) || a.chain.localeCompare(b.chain),
+      ),
+      byChain,
+      totalUSD,
+      tradingFeesUSD,
+      fxFees,
+    };
+  }
+
+  /**
+   * Check internal transfer pairs (one TRANSFER_OUT and one TRANSFER_IN
+   * sharing a transferId). The amount received may fall short of the
+   * amount sent by at most the fee recorded in the same group; anything
+   * beyond that, or a missing leg, is reported. Same-asset pairs are
+   * compared in units; cross-asset pairs in USD, allowing
+   * `fxTolerancePercent` for conversion spreads. Groups with more legs
+   * (option exercises and the like) aren't simple transfers and are
+   * skipped.
+   */
+  checkTransferPairs(
+    params: { tolerancePercent?: number; fxTolerancePercent?: number } = {},
+  ): { checked: number; issues: TransferPairIssue[] } {
+    const tolerance = (params.tolerancePercent ?? 0) / 100;
+    const fxTolerance = (params.fxTolerancePercent ?? 2) / 100;
+    const groups = new Map<string, Transaction[]>();
+    for (const t of transactionRepository.findAll()) {
+      if (!t.transferId) continue;
+      const list = groups.get(t.transferId) || [];
+      list.push(t);
+      groups.set(t.transferId, list);
+    }
+
+    let checked = 0;
+    const issues: TransferPairIssue[] = [];
+    for (const [transferId, txs] of groups) {
+      const outs = txs.filter((t) => t.type === "TRANSFER_OUT");
+      const ins = txs.filter((t) => t.type === "TRANSFER_IN");
+      const fees = txs.filter((t) => t.type === "EXPENSE");
+      if (!outs.length && !ins.length) continue;
+      checked++;
+
+      if (!ins.length || !outs.length) {
+        const leg = outs[0] || ins[0];
+        const sign = outs.length ? 1 : -1;
+        issues.push({
+          transferId,
+          kind: outs.length ? "MISSING_IN" : "MISSING_OUT",
+          out: outs[0],
+          in: ins[0],
+          fee: 0,
+          discrepancy: sign * leg.amount,
+          discrepancyUSD: sign * (leg.usdAmount || 0),
+        });
+        continue;
+      }
+      if (outs.length !== 1 || ins.length !== 1) continue;
+
+      const [out, inn] = [outs[0], ins[0]];
+      const sameAsset = assetKey(out.asset) === assetKey(inn.asset);
+      const fee = sameAsset
+        ? fees
+            .filter((f) => assetKey(f.asset) === assetKey(out.asset))
+            .reduce((sum, f) => sum + f.amount, 0)
+        : fees.reduce((sum, f) => sum + (f.usdAmount || 0), 0);
+      const sent = sameAsset ? out.amount : out.usdAmount || 0;
+      const received = sameAsset ? inn.amount : inn.usdAmount || 0;
+      const slack = sent * (sameAsset ? tolerance : fxTolerance) + 1e-9;
+      const diff = sent - received;
+      if (diff >= -slack && diff <= fee + slack) continue;
+
+      const discrepancy = diff > 0 ? diff - fee : diff;
+      const unitUSD = sameAsset ? out.rate?.rateUSD || 0 : 1;
+      issues.push({
+        transferId,
+        kind: "AMOUNT_MISMATCH",
+        out,
+        in: inn,
+        fee,
+        discrepancy,
+        discrepancyUSD: discrepancy * unitUSD,
+      });
+    }
+
+    issues.sort((a, b) =>
+      (b.out || b.in)!.createdAt.localeCompare((a.out || a.in)!.createdAt),
+    );
+    return { checked, issues };
   }
 
   // Generate portfolio report
+  /**
+   * Group spending by place name, falling back to a coordinate grid for
+   * transactions that only carry latitude/longitude. Callers pass the
+   * spending basis (exclusions, reimbursements) they report on.
+   */
+  getSpendingByLocation(txs: Transaction[]): SpendingByLocationReport {
+    const groups = new Map<
+      string,
+      SpendingLocation & { latSum: number; lngSum: number; tagged: number }
+    >();
+    const unlocated = { count: 0, usd: 0 };
+    let totalUSD = 0;
+
+    for (const t of txs) {
+      const usd = t.usdAmount || 0;
+      totalUSD += usd;
+      const hasCoords = t.latitude !== undefined && t.longitude !== undefined;
+      const place = t.place?.trim();
+      if (!place && !hasCoords) {
+        unlocated.count++;
+        unlocated.usd += usd;
+        continue;
+      }
+
+      const key = place
+        ? place.toLowerCase()
+        : `${t.latitude!.toFixed(LOCATION_GRID_DECIMALS)},` +
+          `${t.longitude!.toFixed(LOCATION_GRID_DECIMALS)}`;
+      const g = groups.get(key) || {
+        key,
+        place,
+        count: 0,
+        usd: 0,
+        latSum: 0,
+        lngSum: 0,
+        tagged: 0,
+      };
+      g.count++;
+      g.usd += usd;
+      if (hasCoords) {
+        g.latSum += t.latitude!;
+        g.lngSum += t.longitude!;
+        g.tagged++;
+      }
+      groups.set(key, g);
+    }
+
+    const locations = Array.from(groups.values())
+      .map(({ latSum, lngSum, tagged, ...g }) => ({
+        ...g,
+        latitude: tagged ? latSum / tagged : undefined,
+        longitude: tagged ? lngSum / tagged : undefined,
+      }))
+      .sort((a, b) => b.usd - a.usd || a.key.localeCompare(b.key));
+    return { locations, unlocated, totalUSD };
+  }
+
+  /**
+   * Spending and income per household member, for shared accounts where
+   * the account alone doesn't say who spent it. Members match case
+   * insensitively and keep the spelling first seen.
+   */
+  getMemberReport(
+    spending: Transaction[],
+    income: Transaction[],
+  ): MemberReport {
+    const empty = (member?: string): MemberTotals => ({
+      member,
+      spending: { count: 0, usd: 0 },
+      contribution: { count: 0, usd: 0 },
+    });
+    const groups = new Map<string, MemberTotals>();
+    const unassigned = empty();
+    const totalsOf = (t: Transaction) => {
+      const member = t.member?.trim();
+      if (!member) return unassigned;
+      const key = member.toLowerCase();
+      if (!groups.has(key)) groups.set(key, empty(member));
+      return groups.get(key)!;
+    };
+
+    let totalSpendingUSD = 0;
+    let totalContributionUSD = 0;
+    for (const t of spending) {
+      const g = totalsOf(t).spending;
+      g.count++;
+      g.usd += t.usdAmount || 0;
+      totalSpendingUSD += t.usdAmount || 0;
+    }
+    for (const t of income) {
+      const g = totalsOf(t).contribution;
+      g.count++;
+      g.usd += t.usdAmount || 0;
+      totalContributionUSD += t.usdAmount || 0;
+    }
+
+    const members = Array.from(groups.values()).sort(
+      (a, b) =>
+        b.spending.usd - a.spending.usd ||
+        b.contribution.usd - a.contribution.usd ||
+        a.member!.localeCompare(b.member!),
+    );
+    return { members, unassigned, totalSpendingUSD, totalContributionUSD };
+  }
+
   async generateReport(): Promise<PortfolioReport> {
     const vaultEntries = vaultRepository.findAll();
     const balances = new Map<
@@ -352,8 +1102,10 @@ export class TransactionService {
 
     for (const h of holdings) {
       const rate = await priceService.getRateUSD(h.asset);
-      h.rateUSD = rate.rateUSD;
-      h.valueUSD = h.balance * rate.rateUSD;
+      h.rateUSD = stablecoinService.valuationRate(h.asset, rate.rateUSD);
+      h.valueUSD = h.balance * h.rateUSD;
+      h.priceAsOf = rate.timestamp;
+      h.priceStale = !!(rate.stale || rate.missing);
     }
 
     const holdingsUSD = holdings.reduce((s, i) => s + i.valueUSD, 0);
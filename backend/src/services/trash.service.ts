@@ -0,0 +1,116 @@
+import { v4 as uuidv4 } from "uuid";
+import { TrashItem, TrashKind, Vault, VaultEntry } from "../types";
+import {
+  settingsRepository,
+  trashRepository,
+  vaultRepository,
+} from "../repositories";
+import { logger } from "../utils/logger";
+
+export const TRASH_RETENTION_DAYS = 7;
+const DAY_MS = 24 * 60 * 60 * 1000;
+const CLEANUP_INTERVAL_MS = DAY_MS;
+let schedulerStarted = false;
+
+export class TrashService {
+  list(): TrashItem[] {
+    return trashRepository
+      .findAll()
+      .sort((a, b) => b.deletedAt.localeCompare(a.deletedAt));
+  }
+
+  get(id: string): TrashItem | undefined {
+    return trashRepository.findById(id);
+  }
+
+  /**
+   * Delete a vault, keeping it and its entries in the trash for
+   * TRASH_RETENTION_DAYS so the deletion can be undone.
+   */
+  trashVault(name: string): TrashItem | undefined {
+    const vault = vaultRepository.findByName(name);
+    if (!vault) return undefined;
+
+    const entries = vaultRepository.findAllEntries(name);
+    const item = this.put("VAULT", name, { vault, entries });
+    vaultRepository.delete(name);
+    return item;
+  }
+
+  /** Keep the current values of `keys` before they are overwritten. */
+  trashSettings(label: string, keys: string[]): TrashItem {
+    const previous: Record<string, string | null> = {};
+    for (const key of keys) {
+      previous[key] = settingsRepository.getSetting(key) ?? null;
+    }
+    return this.put("SETTINGS", label, previous);
+  }
+
+  /** Put a trashed item back and remove it from the trash. */
+  restore(id: string): TrashItem | undefined {
+    const item = trashRepository.findById(id);
+    if (!item) return undefined;
+
+    if (item.kind === "VAULT") {
+      const { vault, entries } = item.payload as {
+        vault: Vault;
+        entries: VaultEntry[];
+      };
+      if (vaultRepository.findByName(vault.name)) {
+        throw new Error(`Vault "${vault.name}" exists again; rename it first`);
+      }
+      vaultRepository.create(vault);
+      for (const e of entries) vaultRepository.createEntry(e);
+    } else {
+      const previous = item.payload as Record<string, string | null>;
+      for (const [key, value] of Object.entries(previous)) {
+        if (value === null) settingsRepository.deleteSetting(key);
+        else settingsRepository.setSetting(key, value);
+      }
+    }
+
+    trashRepository.delete(id);
+    return item;
+  }
+
+  /** Drop an item for good before it expires. */
+  discard(id: string): boolean {
+    return trashRepository.delete(id);
+  }
+
+  purgeExpired(now: Date = new Date()): number {
+    return trashRepository.deleteExpired(now.toISOString());
+  }
+
+  startCleanupScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      try {
+        const removed = this.purgeExpired();
+        if (removed > 0) logger.info({ removed }, "Expired trash removed");
+      } catch (e: any) {
+        logger.warn({ error: e?.message }, "Trash cleanup failed");
+      }
+    };
+    run();
+    setInterval(run, CLEANUP_INTERVAL_MS);
+  }
+
+  private put(kind: TrashKind, label: string, payload: unknown): TrashItem {
+    const now = new Date();
+    return trashRepository.create({
+      id: uuidv4(),
+      kind,
+      label,
+      payload,
+      deletedAt: now.toISOString(),
+      expiresAt: new Date(
+        now.getTime() + TRASH_RETENTION_DAYS * DAY_MS,
+      ).toISOString(),
+    });
+  }
+}
+
+export const trashService = new TrashService();
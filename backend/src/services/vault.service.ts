@@ -3,6 +3,20 @@ import { vaultRepository } from "../repositories";
 import { settingsRepository } from "../repositories";
 import { transactionRepository } from "../repositories";
 import { priceService } from "./price.service";
+import { stablecoinService } from "./stablecoin.service";
+import { eventBus } from "./event-bus.service";
+import {
+  LotBook,
+  costBasisService,
+  isRewardDistribution,
+} from "./cost-basis.service";
+import { logger } from "../utils/logger";
+import { BusinessError } from "../core/errors";
+import {
+  AnnualizationMethod,
+  annualizeReturn,
+  dateDiffInDays,
+} from "./financial.service";
 
 export interface VaultStats {
   totalDepositedUSD: number;
@@ -12,8 +26,62 @@ export interface VaultStats {
   aumUSDMarket: number;
 }
 
+export interface VaultPerformance extends VaultStats {
+  roiPercent: number;
+  annualizedPercent: number;
+  annualization: AnnualizationMethod;
+  holdingPeriodDays: number;
+}
+
+export interface ManualValuationCheck {
+  previousUSD: number;
+  newUSD: number;
+  changePercent: number;
+  maxChangePercent: number;
+  exceeded: boolean;
+}
+
+export type VaultTimelineEventType =
+  | "DEPOSIT"
+  | "WITHDRAW"
+  | "VALUATION"
+  | "REWARD";
+
+export interface VaultTimelineEvent extends VaultEntry {
+  event: VaultTimelineEventType;
+  runningQuantity: number; // units of entry asset held after this event
+  runningCostBasisUSD: number; // cost basis of entry asset after this event
+  totalCostBasisUSD: number; // cost basis across all assets after this event
+  realizedPnLUSD?: number; // WITHDRAW: value withdrawn minus cost released
+  lastValuationUSD?: number;
+}
+
+export function normalizeStrategyTags(tags: unknown): string[] {
+  const list = Array.isArray(tags)
+    ? tags
+    : typeof tags === "string"
+      ? tags.split(",")
+      : [];
+  const out = new Set<string>();
+  for (const t of list) {
+    const tag = String(t ?? "")
+      .trim()
+      .toLowerCase();
+    if (tag) out.add(tag);
+  }
+  return [...out];
+}
+
+// Rounding slack when comparing a withdrawal with the remaining quantity
+const OVERDRAW_EPSILON = 1e-9;
+
 export class VaultService {
-  ensureVault(name: string): boolean {
+  ensureVault(
+    name: string,
+    tags?: string[],
+    account?: string,
+    benchmark?: string,
+  ): boolean {
     const existing = vaultRepository.findByName(name);
     if (existing) return false;
 
@@ -21,6 +89,9 @@ export class VaultService {
       name,
       status: "ACTIVE",
       createdAt: new Date().toISOString(),
+      tags: tags && tags.length ? tags : undefined,
+      account: account || undefined,
+      benchmark: benchmark ? benchmark.toUpperCase() : undefined,
     };
 
     vaultRepository.create(vault);
@@ -40,11 +111,33 @@ export class VaultService {
     if (!vault) return false;
 
     vaultRepository.update(name, { status: "CLOSED" });
+    eventBus.emit("vault.ended", { name });
     return true;
   }
 
+  setVaultTags(name: string, tags: string[]): Vault | undefined {
+    if (!vaultRepository.findByName(name)) return undefined;
+    return vaultRepository.update(name, { tags });
+  }
+
+  /** Set the account a vault is held in; an empty name clears it. */
+  setVaultAccount(name: string, account: string): Vault | undefined {
+    if (!vaultRepository.findByName(name)) return undefined;
+    return vaultRepository.update(name, { account: account.trim() });
+  }
+
+  /** Set the symbol a vault is benchmarked against; empty clears it. */
+  setVaultBenchmark(name: string, symbol: string): Vault | undefined {
+    if (!vaultRepository.findByName(name)) return undefined;
+    return vaultRepository.update(name, {
+      benchmark: symbol.trim().toUpperCase(),
+    });
+  }
+
   deleteVault(name: string): boolean {
-    return vaultRepository.delete(name);
+    const ok = vaultRepository.delete(name);
+    if (ok) eventBus.emit("vault.deleted", { name });
+    return ok;
   }
 
   addVaultEntry(entry: VaultEntry): VaultEntry {
@@ -55,6 +148,95 @@ export class VaultService {
     return vaultRepository.findAllEntries(name);
   }
 
+  /** Distinct assets with a positive balance in any active vault. */
+  heldAssets(): Asset[] {
+    const units = new Map<string, { asset: Asset; units: number }>();
+    for (const vault of vaultRepository.findAll()) {
+      if (vault.status !== "ACTIVE") continue;
+      for (const e of vaultRepository.findAllEntries(vault.name)) {
+        if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+        const k = assetKey(e.asset);
+        const cur = units.get(k) || { asset: e.asset, units: 0 };
+        cur.units += e.type === "DEPOSIT" ? e.amount : -e.amount;
+        units.set(k, cur);
+      }
+    }
+    return Array.from(units.values())
+      .filter((u) => u.units > 1e-12)
+      .map((u) => u.asset);
+  }
+
+  /**
+   * Ordered vault entries with running quantity and cost basis after each
+   * event, using the vault's cost basis method (average cost unless
+   * configured). Reward distributions are reported as REWARD and don't
+   * reduce cost basis since they are profit withdrawals.
+   */
+  getVaultTimeline(name: string): VaultTimelineEvent[] {
+    const entries = [...vaultRepository.findAllEntries(name)].sort((a, b) =>
+      String(a.at).localeCompare(String(b.at)),
+    );
+
+    const positions = new Map<
+      string,
+      { units: number; costUSD: number; book: LotBook }
+    >();
+    let lastValuationUSD: number | undefined = undefined;
+    const timeline: VaultTimelineEvent[] = [];
+
+    for (const e of entries) {
+      const k = assetKey(e.asset);
+      const cur = positions.get(k) || {
+        units: 0,
+        costUSD: 0,
+        book: new LotBook(),
+      };
+      let event: VaultTimelineEventType = e.type;
+      let realizedPnLUSD: number | undefined = undefined;
+
+      if (e.type === "DEPOSIT") {
+        cur.units += e.amount;
+        cur.book.add(e.at, e.amount, Number(e.usdValue || 0));
+        cur.costUSD = cur.book.costUSD;
+      } else if (e.type === "WITHDRAW") {
+        if (isRewardDistribution(e)) {
+          event = "REWARD";
+        } else if (cur.units > 0) {
+          const method = costBasisService.methodFor(name, e.asset.symbol);
+          const { released } = cur.book.release(e.amount, method, e.lots);
+          const releasedUSD = released.reduce((s, r) => s + r.costUSD, 0);
+          realizedPnLUSD = Number(e.usdValue || 0) - releasedUSD;
+          cur.costUSD = cur.book.costUSD;
+        }
+        cur.units -= e.amount;
+        if (Math.abs(cur.units) < 1e-12) cur.units = 0;
+        if (cur.units <= 0) {
+          cur.costUSD = 0;
+          cur.book.clear();
+        }
+      } else if (e.type === "VALUATION") {
+        if (typeof e.usdValue === "number") lastValuationUSD = e.usdValue;
+      }
+
+      if (e.type !== "VALUATION") positions.set(k, cur);
+
+      let totalCostBasisUSD = 0;
+      for (const p of positions.values()) totalCostBasisUSD += p.costUSD;
+
+      timeline.push({
+        ...e,
+        event,
+        runningQuantity: cur.units,
+        runningCostBasisUSD: cur.costUSD,
+        totalCostBasisUSD,
+        realizedPnLUSD,
+        lastValuationUSD,
+      });
+    }
+
+    return timeline;
+  }
+
   async vaultStats(name: string): Promise<VaultStats> {
     const entries = vaultRepository.findAllEntries(name);
 
@@ -124,7 +306,8 @@ export class VaultService {
       if (Math.abs(v.units) < 1e-12) continue;
       if (v.asset.symbol === "USD") continue;
       const rate = await priceService.getRateUSD(v.asset);
-      aumMarket += v.units * rate.rateUSD;
+      aumMarket +=
+        v.units * stablecoinService.valuationRate(v.asset, rate.rateUSD);
     }
 
     return {
@@ -136,6 +319,137 @@ export class VaultService {
     };
   }
 
+  /**
+   * Quantity of `asset` left in the vault. USD follows the manual
+   * valuation, so gains marked on a USD vault can be withdrawn; other
+   * assets count deposited minus withdrawn units.
+   */
+  async remainingQuantity(name: string, asset: Asset): Promise<number> {
+    if (asset.symbol === "USD") {
+      return (await this.vaultStats(name)).aumUSDManual;
+    }
+    const k = assetKey(asset);
+    let units = 0;
+    for (const e of vaultRepository.findAllEntries(name)) {
+      if (assetKey(e.asset) !== k) continue;
+      if (e.type === "DEPOSIT") units += e.amount;
+      else if (e.type === "WITHDRAW") units -= e.amount;
+    }
+    return units;
+  }
+
+  /**
+   * Check a withdrawal against what is left of `asset` in the vault. One
+   * larger than that is rejected, or under the overdraw policy setting
+   * recorded with a warning (returned) or as is. allowOverdraw skips the
+   * check for an edge case (e.g. a deposit that was never recorded).
+   * `remaining` stands in for what is left when a valuation about to be
+   * recorded changes it.
+   */
+  async checkWithdrawable(
+    name: string,
+    asset: Asset,
+    amount: number,
+    allowOverdraw = false,
+    remaining?: number,
+  ): Promise<string[]> {
+    if (allowOverdraw) return [];
+    const policy = settingsRepository.getOverdrawPolicy();
+    if (policy === "ALLOW") return [];
+    if (remaining === undefined) {
+      remaining = await this.remainingQuantity(name, asset);
+    }
+    if (amount <= remaining + OVERDRAW_EPSILON) return [];
+    if (policy === "WARN") {
+      return [
+        `Withdrew ${amount} ${asset.symbol} from "${name}" with only ` +
+          `${Math.max(0, remaining)} remaining`,
+      ];
+    }
+    throw new BusinessError(
+      `Cannot withdraw ${amount} ${asset.symbol} from "${name}": only ` +
+        `${Math.max(0, remaining)} remaining (pass allow_overdraw=true ` +
+        "to override)",
+      { vault: name, asset: asset.symbol, requested: amount, remaining },
+    );
+  }
+
+  /**
+   * ROI plus annualized return (APR or APY) over the holding period,
+   * measured from the first entry to the last entry for closed vaults
+   * or to now for active ones.
+   */
+  async vaultPerformance(
+    name: string,
+    method: AnnualizationMethod = "APR",
+  ): Promise<VaultPerformance> {
+    const stats = await this.vaultStats(name);
+    const vault = vaultRepository.findByName(name);
+    const entries = vaultRepository.findAllEntries(name);
+
+    const start = entries.length
+      ? entries.reduce((m, e) => (m < e.at ? m : e.at), entries[0].at)
+      : vault?.createdAt;
+    const end =
+      vault?.status === "CLOSED" && entries.length
+        ? entries.reduce((m, e) => (m > e.at ? m : e.at), entries[0].at)
+        : new Date().toISOString();
+    const holdingPeriodDays = start
+      ? Math.max(0, dateDiffInDays(new Date(start), new Date(end)))
+      : 0;
+
+    const roi =
+      stats.totalDepositedUSD > 0
+        ? (stats.aumUSD + stats.totalWithdrawnUSD - stats.totalDepositedUSD) /
+          stats.totalDepositedUSD
+        : 0;
+
+    return {
+      ...stats,
+      roiPercent: roi * 100,
+      annualizedPercent: annualizeReturn(roi, holdingPeriodDays, method),
+      annualization: method,
+      holdingPeriodDays,
+    };
+  }
+
+  /**
+   * Guard manual valuations against fat-fingered values: flags changes
+   * beyond the configured max percentage vs the current manual AUM.
+   * Every flagged attempt is logged, whether or not it is forced through.
+   */
+  async checkManualValuation(
+    name: string,
+    newUSD: number,
+    force = false,
+  ): Promise<ManualValuationCheck> {
+    const stats = await this.vaultStats(name);
+    const previousUSD = stats.aumUSDManual;
+    const maxChangePercent =
+      settingsRepository.getMaxManualPriceChangePercent();
+    const changePercent =
+      previousUSD > 0 ? ((newUSD - previousUSD) / previousUSD) * 100 : 0;
+    const exceeded = Math.abs(changePercent) > maxChangePercent;
+
+    if (exceeded) {
+      logger.warn(
+        {
+          vault: name,
+          previousUSD,
+          newUSD,
+          changePercent,
+          maxChangePercent,
+          force,
+        },
+        force
+          ? "Manual valuation outlier forced through"
+          : "Manual valuation outlier rejected",
+      );
+    }
+
+    return { previousUSD, newUSD, changePercent, maxChangePercent, exceeded };
+  }
+
   async recordIncomeTx(params: {
     asset: Asset;
     amount: number;
@@ -182,13 +496,24 @@ export class VaultService {
     usdValue: number;
     at?: string;
     note?: string;
-  }): Promise<{ withdrawEntry: VaultEntry; depositEntry: VaultEntry }> {
+    allowOverdraw?: boolean;
+  }): Promise<{
+    withdrawEntry: VaultEntry;
+    depositEntry: VaultEntry;
+    warnings: string[]; // overdraw warning under the WARN policy
+  }> {
     const { fromVault, toVault, asset, amount, usdValue, note } = params;
     const at = params.at ?? new Date().toISOString();
 
     // Ensure both vaults exist
     this.ensureVault(fromVault);
     this.ensureVault(toVault);
+    const warnings = await this.checkWithdrawable(
+      fromVault,
+      asset,
+      amount,
+      params.allowOverdraw,
+    );
 
     // Create WITHDRAW entry from source vault
     const withdrawEntry: VaultEntry = {
@@ -221,6 +546,7 @@ export class VaultService {
     return {
       withdrawEntry: createdWithdraw,
       depositEntry: createdDeposit,
+      warnings,
     };
   }
 }
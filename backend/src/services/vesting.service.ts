@@ -0,0 +1,307 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  Transaction,
+  VestEvent,
+  VestEventCreateRequest,
+  VestingGrant,
+  VestingGrantCreateRequest,
+} from "../types";
+import {
+  transactionRepository,
+  vaultRepository,
+  vestingRepository,
+} from "../repositories";
+import { priceService } from "./price.service";
+import { precisionService } from "./precision.service";
+import { transactionService } from "./transaction.service";
+import { vaultService } from "./vault.service";
+import { logger } from "../utils/logger";
+
+const VEST_CHECK_INTERVAL_MS = 6 * 60 * 60 * 1000; // every 6 hours
+let schedulerStarted = false;
+
+function addMonths(iso: string, months: number): string {
+  const d = new Date(iso);
+  d.setUTCMonth(d.getUTCMonth() + months);
+  return d.toISOString();
+}
+
+/**
+ * RSU vest dates and units: everything accrued up to the cliff vests at
+ * the cliff, then every frequency step until the end of vesting.
+ */
+export function vestSchedule(
+  grant: VestingGrant,
+): Array<{ vestAt: string; units: number }> {
+  const months: number[] = [];
+  const step = grant.frequencyMonths;
+  const first = grant.cliffMonths > 0 ? grant.cliffMonths : step;
+  for (let m = first; m < grant.vestingMonths; m += step) months.push(m);
+  months.push(grant.vestingMonths);
+
+  let prev = 0;
+  return months.map((m) => {
+    const units = (grant.totalUnits * (m - prev)) / grant.vestingMonths;
+    prev = m;
+    return { vestAt: addMonths(grant.vestStartAt, m), units };
+  });
+}
+
+export class VestingService {
+  createGrant(data: VestingGrantCreateRequest): {
+    grant: VestingGrant;
+    events: VestEvent[];
+  } {
+    if (data.kind === "RSU" && !(data.totalUnits > 0)) {
+      throw new Error("totalUnits is required for RSU grants");
+    }
+
+    const grant: VestingGrant = {
+      id: uuidv4(),
+      kind: data.kind,
+      employer: data.employer,
+      asset: { ...data.asset, symbol: data.asset.symbol.toUpperCase() },
+      cashAsset: data.cashAsset,
+      account: data.account,
+      totalUnits: data.totalUnits,
+      grantAt: data.grantAt,
+      vestStartAt: data.vestStartAt ?? data.grantAt,
+      cliffMonths: data.cliffMonths,
+      vestingMonths: data.vestingMonths,
+      frequencyMonths: data.frequencyMonths,
+      withholdingRate: data.withholdingRate,
+      discount: data.kind === "ESPP" ? data.discount : 0,
+      status: "ACTIVE",
+      note: data.note,
+      createdAt: new Date().toISOString(),
+    };
+    vestingRepository.create(grant);
+
+    // ESPP purchases depend on contributions, so they're added one by one
+    const events =
+      grant.kind === "RSU"
+        ? vestSchedule(grant).map((v) =>
+            vestingRepository.createEvent({
+              id: uuidv4(),
+              grantId: grant.id,
+              vestAt: v.vestAt,
+              units: v.units,
+              status: "SCHEDULED",
+            }),
+          )
+        : [];
+    return { grant, events };
+  }
+
+  listGrants(): VestingGrant[] {
+    return vestingRepository.findAll();
+  }
+
+  getGrant(
+    id: string,
+  ): { grant: VestingGrant; events: VestEvent[] } | undefined {
+    const grant = vestingRepository.findById(id);
+    if (!grant) return undefined;
+    return { grant, events: vestingRepository.findEvents(id) };
+  }
+
+  /**
+   * Add a vest or ESPP purchase. Events already due are processed right
+   * away.
+   */
+  async addEvent(
+    grantId: string,
+    data: VestEventCreateRequest,
+  ): Promise<VestEvent> {
+    const grant = vestingRepository.findById(grantId);
+    if (!grant) throw new Error("Grant not found");
+    if (grant.status === "CANCELLED") throw new Error("Grant is cancelled");
+
+    const event = vestingRepository.createEvent({
+      id: uuidv4(),
+      grantId,
+      vestAt: data.vestAt,
+      units: data.units,
+      status: "SCHEDULED",
+      fmv: data.fmv,
+      purchasePrice: data.purchasePrice,
+    });
+    if (new Date(event.vestAt) > new Date()) return event;
+    return (await this.vest(grant, event)).event;
+  }
+
+  cancelGrant(id: string): VestingGrant | undefined {
+    const grant = vestingRepository.findById(id);
+    if (!grant) return undefined;
+    for (const e of vestingRepository.findEvents(id)) {
+      if (e.status === "SCHEDULED") {
+        vestingRepository.updateEvent(e.id, { status: "CANCELLED" });
+      }
+    }
+    return vestingRepository.update(id, { status: "CANCELLED" });
+  }
+
+  /**
+   * Post a vest: compensation income at the vest-date price (for ESPP the
+   * discount to that price) and a buy of the units kept after withholding,
+   * linked by one transferId. The units go into the account's vault at
+   * the vest-date price, which becomes their cost basis.
+   */
+  async vest(
+    grant: VestingGrant,
+    event: VestEvent,
+  ): Promise<{ event: VestEvent; transactions: Transaction[] }> {
+    const cashRate = (
+      await priceService.getRateUSD(grant.cashAsset, event.vestAt)
+    ).rateUSD;
+    let fmv = event.fmv;
+    if (fmv === undefined) {
+      const rate = await priceService.getRateUSD(grant.asset, event.vestAt);
+      // A FIXED rate here means no provider knows the stock
+      if (rate.source === "FIXED") {
+        throw new Error(`no price for ${grant.asset.symbol}; pass fmv`);
+      }
+      fmv = rate.rateUSD / cashRate;
+    }
+
+    const purchasePrice =
+      grant.kind === "ESPP"
+        ? (event.purchasePrice ?? fmv * (1 - grant.discount))
+        : 0;
+    const withheldUnits = precisionService.round(
+      grant.asset,
+      event.units * grant.withholdingRate,
+    );
+    const netUnits = event.units - withheldUnits;
+    const income = netUnits * (fmv - purchasePrice);
+    const costUSD = netUnits * fmv * cashRate;
+    const transferId = uuidv4();
+    const units = `${event.units} ${grant.asset.symbol}`;
+    const label =
+      grant.kind === "RSU"
+        ? `RSU vest: ${units} @ ${fmv}`
+        : `ESPP purchase: ${units} @ ${purchasePrice}`;
+    const withheld = withheldUnits
+      ? `; ${withheldUnits} withheld for tax`
+      : "";
+
+    const transactions: Transaction[] = [];
+    if (income > 0) {
+      const base = await transactionService.buildTransactionBase(
+        grant.cashAsset,
+        income,
+        event.vestAt,
+        grant.account,
+        "INCOME",
+      );
+      transactions.push({
+        id: uuidv4(),
+        type: "INCOME",
+        category: grant.kind === "RSU" ? "rsu_vest" : "espp_discount",
+        counterparty: grant.employer,
+        note: `${label}${withheld}`,
+        transferId,
+        ...base,
+      } as Transaction);
+    }
+
+    const cashOut = await transactionService.buildTransactionBase(
+      grant.cashAsset,
+      netUnits * fmv,
+      event.vestAt,
+      grant.account,
+      "TRANSFER_OUT",
+    );
+    transactions.push(
+      {
+        id: uuidv4(),
+        type: "TRANSFER_OUT",
+        note: label,
+        transferId,
+        ...cashOut,
+      } as Transaction,
+      {
+        id: uuidv4(),
+        type: "TRANSFER_IN",
+        note: label,
+        transferId,
+        asset: grant.asset,
+        amount: netUnits,
+        createdAt: cashOut.createdAt,
+        account: cashOut.account,
+        rate: {
+          asset: grant.asset,
+          rateUSD: fmv * cashRate,
+          timestamp: cashOut.createdAt,
+          source: "FIXED",
+        },
+        usdAmount: costUSD,
+      } as Transaction,
+    );
+    for (const tx of transactions) transactionRepository.create(tx);
+
+    if (vaultRepository.findByName(grant.account)) {
+      vaultService.addVaultEntry({
+        vault: grant.account,
+        type: "DEPOSIT",
+        asset: grant.asset,
+        amount: netUnits,
+        usdValue: costUSD,
+        at: cashOut.createdAt,
+        account: grant.account,
+        note: label,
+      });
+    }
+
+    const vested = vestingRepository.updateEvent(event.id, {
+      status: "VESTED",
+      fmv,
+      purchasePrice: grant.kind === "ESPP" ? purchasePrice : undefined,
+      withheldUnits,
+      netUnits,
+      incomeUSD: income * cashRate,
+      transferId,
+    })!;
+
+    const pending = vestingRepository
+      .findEvents(grant.id)
+      .some((e) => e.status === "SCHEDULED");
+    if (grant.kind === "RSU" && !pending) {
+      vestingRepository.update(grant.id, { status: "COMPLETED" });
+    }
+    return { event: vested, transactions };
+  }
+
+  /** Post every scheduled vest that has come due. */
+  async processDueVests(now: Date = new Date()): Promise<VestEvent[]> {
+    const vested: VestEvent[] = [];
+    for (const event of vestingRepository.findDueEvents(now.toISOString())) {
+      const grant = vestingRepository.findById(event.grantId);
+      if (!grant || grant.status !== "ACTIVE") continue;
+      try {
+        vested.push((await this.vest(grant, event)).event);
+      } catch (e: any) {
+        logger.warn(
+          { grantId: grant.id, eventId: event.id, error: e?.message },
+          "Could not post vest event",
+        );
+      }
+    }
+    return vested;
+  }
+
+  startVestingScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDueVests().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Vesting check failed"),
+      );
+    };
+    run();
+    setInterval(run, VEST_CHECK_INTERVAL_MS);
+  }
+}
+
+export const vestingService = new VestingService();
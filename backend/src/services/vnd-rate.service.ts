@@ -0,0 +1,28 @@
+import { priceService } from "./price.service";
+import { logger } from "../utils/logger";
+
+// Used when no VND rate can be had, so VND figures still render
+export const FALLBACK_USD_VND = 24000;
+
+/**
+ * VND per USD at today's rate, for the _vnd figures of reports and
+ * lists. Falls back to FALLBACK_USD_VND, with a warning, when the rate
+ * is unknown or the lookup fails.
+ */
+export async function usdToVnd(): Promise<number> {
+  try {
+    const vnd = await priceService.getRateUSD({ type: "FIAT", symbol: "VND" });
+    // vnd.rateUSD is 1 VND -> USD, so USD->VND = 1 / rateUSD
+    if (vnd.rateUSD > 0) return 1 / vnd.rateUSD;
+    logger.warn(
+      { fallback: FALLBACK_USD_VND },
+      "No VND rate, using the fallback",
+    );
+  } catch (err: any) {
+    logger.warn(
+      { error: err?.message, fallback: FALLBACK_USD_VND },
+      "VND rate lookup failed, using the fallback",
+    );
+  }
+  return FALLBACK_USD_VND;
+}
@@ -0,0 +1,314 @@
+import { v4 as uuidv4 } from "uuid";
+import {
+  EvmWallet,
+  Transaction,
+  TransactionType,
+  WalletCreateRequest,
+  WalletUpdateRequest,
+} from "../types";
+import { settingsRepository, transactionRepository } from "../repositories";
+import { ConflictError, NotFoundError } from "../core/errors";
+import { createAssetFromSymbol } from "../utils/asset.util";
+import { logger } from "../utils/logger";
+import { transactionService } from "./transaction.service";
+import { addressBookService } from "./address-book.service";
+import {
+  CHAIN_NAMES,
+  EvmTransfer,
+  WALLET_STREAMS,
+  evmExplorerClient,
+} from "./evm-explorer.service";
+
+const SCHEDULER_INTERVAL_MS = 60 * 60 * 1000; // hourly
+const HOUR_MS = 60 * 60 * 1000;
+let schedulerStarted = false;
+const syncing = new Set<string>(); // wallet ids with a sync running
+
+export interface WalletSyncRun {
+  wallet: EvmWallet;
+  status: "OK" | "FAILED";
+  error?: string;
+  created: { received: number; sent: number; fees: number }; // transactions
+  skipped: number; // transfers recorded by an earlier sync
+}
+
+// A transaction a transfer turns into; `ref` is appended to its own
+interface Leg {
+  ref: string;
+  type: TransactionType;
+  amount: number;
+  note: string;
+  counterparty?: string; // the other address
+  category?: string;
+}
+
+const short = (a: string) => (a ? `${a.slice(0, 6)}…${a.slice(-4)}` : "?");
+
+/** The transactions a transfer is recorded as, seen from `address`. */
+export function transferLegs(
+  t: EvmTransfer,
+  address: string,
+  chainName: string,
+): Leg[] {
+  const incoming = t.to === address;
+  const outgoing = t.from === address;
+  const legs: Leg[] = [];
+  // Sending to yourself moves nothing; only the gas is spent
+  if (!t.failed && t.amount > 0 && incoming !== outgoing) {
+    const other = incoming ? t.from : t.to;
+    legs.push({
+      ref: incoming ? ":in" : ":out",
+      type: incoming ? "TRANSFER_IN" : "TRANSFER_OUT",
+      amount: t.amount,
+      note: `${chainName}: ${incoming ? "received from" : "sent to"} ${short(
+        other,
+      )}`,
+      counterparty: other,
+    });
+  }
+  // Only native transfers carry a fee, so it is in their asset
+  if (outgoing && t.fee > 0) {
+    legs.push({
+      ref: ":fee",
+      type: "EXPENSE",
+      amount: t.fee,
+      note: `${chainName}: network fee${t.failed ? " (failed)" : ""}`,
+      category: "network_fee",
+    });
+  }
+  return legs;
+}
+
+/**
+ * Watches EVM addresses and syncs their native, internal and ERC-20
+ * transfers into transfer_in/transfer_out transactions of the wallet's
+ * account, priced like any other transaction, plus the gas it paid.
+ * Every synced transaction has a `sourceRef` of the chain, transaction
+ * hash and leg, so syncing again never records anything twice.
+ */
+export class WalletService {
+  list(): EvmWallet[] {
+    return settingsRepository.getEvmWallets();
+  }
+
+  get(id: string): EvmWallet | undefined {
+    return this.list().find((w) => w.id === id);
+  }
+
+  /** Account names with a tracked wallet, and their addresses. */
+  addressesByAccount(): Map<string, string[]> {
+    const map = new Map<string, string[]>();
+    for (const w of this.list()) {
+      const addresses = map.get(w.account) ?? [];
+      if (!addresses.includes(w.address)) addresses.push(w.address);
+      map.set(w.account, addresses);
+    }
+    return map;
+  }
+
+  create(req: WalletCreateRequest, now: Date = new Date()): EvmWallet {
+    const all = this.list();
+    if (all.some((w) => w.chain === req.chain && w.address === req.address)) {
+      throw new ConflictError(
+        `${req.address} is already tracked on ${req.chain}`,
+      );
+    }
+    const wallet: EvmWallet = {
+      id: uuidv4(),
+      chain: req.chain,
+      address: req.address,
+      name: req.name,
+      account: req.account ?? req.name,
+      tokens: req.tokens,
+      since: req.since ? new Date(req.since).toISOString() : undefined,
+      intervalHours: req.intervalHours,
+      active: req.active,
+      cursors: {},
+      nextSyncAt: now.toISOString(),
+      createdAt: now.toISOString(),
+    };
+    settingsRepository.setEvmWallets([...all, wallet]);
+    return wallet;
+  }
+
+  update(id: string, req: WalletUpdateRequest): EvmWallet | undefined {
+    const existing = this.get(id);
+    if (!existing) return undefined;
+    const defined = Object.fromEntries(
+      Object.entries(req).filter(([, v]) => v !== undefined),
+    );
+    const updated: EvmWallet = {
+      ...existing,
+      ...defined,
+      since: req.since ? new Date(req.since).toISOString() : existing.since,
+      id,
+      updatedAt: new Date().toISOString(),
+    };
+    this.save(updated);
+    return updated;
+  }
+
+  /** Synced transactions stay. */
+  delete(id: string): boolean {
+    const all = this.list();
+    const kept = all.filter((w) => w.id !== id);
+    if (kept.length === all.length) return false;
+    settingsRepository.setEvmWallets(kept);
+    return true;
+  }
+
+  /**
+   * Fetch the transfers since the last sync and record what is new. The
+   * lists that finished keep their position when a later one fails.
+   * Syncing by hand leaves the schedule alone.
+   */
+  async sync(id: string, now: Date = new Date()): Promise<WalletSyncRun> {
+    const w = this.get(id);
+    if (!w) throw new NotFoundError("wallet", id);
+    if (syncing.has(id)) {
+      throw new ConflictError("A sync of this wallet is already running");
+    }
+    syncing.add(id);
+
+    const created = { received: 0, sent: 0, fees: 0 };
+    const cursors = { ...w.cursors };
+    let skipped = 0;
+    let status: WalletSyncRun["status"] = "OK";
+    let error: string | undefined;
+    try {
+      for (const stream of WALLET_STREAMS) {
+        const res = await evmExplorerClient.transfers(
+          w.chain,
+          w.address,
+          stream,
+          cursors[stream],
+        );
+        for (const t of res.transfers) {
+          if (!this.wanted(w, t)) continue;
+          const n = await this.record(w, t);
+          if (n.existing && !(n.received + n.sent + n.fees)) skipped++;
+          created.received += n.received;
+          created.sent += n.sent;
+          created.fees += n.fees;
+        }
+        if (res.cursor !== undefined) cursors[stream] = res.cursor;
+      }
+    } catch (e: any) {
+      status = "FAILED";
+      error = e?.response?.data?.message || e?.message || String(e);
+      logger.warn(
+        { wallet: w.name, chain: w.chain, error },
+        "Wallet sync failed",
+      );
+    } finally {
+      syncing.delete(id);
+    }
+
+    const total = created.received + created.sent + created.fees;
+    // The wallet may have been edited while the sync ran
+    const updated: EvmWallet = {
+      ...(this.get(id) ?? w),
+      cursors,
+      lastSyncAt: now.toISOString(),
+      lastStatus: status,
+      lastError: error,
+      lastCreated: total,
+    };
+    this.save(updated);
+    if (total) {
+      logger.info(
+        { wallet: w.name, chain: w.chain, created, skipped },
+        "Synced wallet transfers",
+      );
+    }
+    return { wallet: updated, status, error, created, skipped };
+  }
+
+  /** Sync the active wallets that are due, then move them past `now`. */
+  async processDue(now: Date = new Date()): Promise<WalletSyncRun[]> {
+    const runs: WalletSyncRun[] = [];
+    for (const w of this.list()) {
+      if (!w.active || w.nextSyncAt > now.toISOString()) continue;
+      if (syncing.has(w.id)) continue;
+      const run = await this.sync(w.id, now);
+      const step = w.intervalHours * HOUR_MS;
+      let next = Date.parse(w.nextSyncAt) || now.getTime();
+      while (next <= now.getTime()) next += step;
+      run.wallet = { ...run.wallet, nextSyncAt: new Date(next).toISOString() };
+      this.save(run.wallet);
+      runs.push(run);
+    }
+    return runs;
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = () => {
+      this.processDue().catch((e: any) =>
+        logger.warn({ error: e?.message }, "Wallet sync failed"),
+      );
+    };
+    run();
+    setInterval(run, SCHEDULER_INTERVAL_MS);
+  }
+
+  // Outside `since`, or a token the wallet does not follow
+  private wanted(w: EvmWallet, t: EvmTransfer): boolean {
+    if (w.since && t.at < w.since) return false;
+    if (!t.contract) return true;
+    return !w.tokens.length || w.tokens.includes(t.contract);
+  }
+
+  // Transactions created by kind, and legs recorded by an earlier sync
+  private async record(w: EvmWallet, t: EvmTransfer) {
+    const chainName = CHAIN_NAMES[w.chain];
+    const n = { received: 0, sent: 0, fees: 0, existing: 0 };
+    for (const leg of transferLegs(t, w.address, chainName)) {
+      const sourceRef = `evm:${w.chain}:${t.ref}${leg.ref}`;
+      if (
+        transactionRepository.findBySourceRef(sourceRef, {
+          includeDeleted: true,
+        })
+      ) {
+        n.existing++;
+        continue;
+      }
+      const base = await transactionService.buildTransactionBase(
+        createAssetFromSymbol(t.asset),
+        leg.amount,
+        t.at,
+        w.account,
+      );
+      // Named after its address book entry when the address has one
+      const known = leg.counterparty
+        ? addressBookService.resolve(leg.counterparty)
+        : undefined;
+      transactionRepository.create({
+        id: uuidv4(),
+        type: leg.type,
+        note: leg.note,
+        category: leg.category,
+        counterparty: known?.name ?? leg.counterparty,
+        chain: w.chain,
+        sourceRef,
+        ...base,
+      } as Transaction);
+      if (leg.type === "TRANSFER_IN") n.received++;
+      else if (leg.type === "TRANSFER_OUT") n.sent++;
+      else n.fees++;
+    }
+    return n;
+  }
+
+  private save(w: EvmWallet): void {
+    const all = this.list();
+    const i = all.findIndex((x) => x.id === w.id);
+    if (i < 0) return; // deleted meanwhile
+    all[i] = w;
+    settingsRepository.setEvmWallets(all);
+  }
+}
+
+export const walletService = new WalletService();
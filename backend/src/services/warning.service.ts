@@ -0,0 +1,102 @@
+import { Transaction } from "../types";
+import {
+  transactionRepository,
+  vaultRepository,
+  yearCloseRepository,
+} from "../repositories";
+import { vaultService } from "./vault.service";
+import { logger } from "../utils/logger";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const RATE_MAX_AGE_DAYS = 3;
+const EPSILON = 1e-9;
+
+// Transactions that take units out of their account
+function isOutflow(t: Transaction): boolean {
+  if (t.type === "REPAY") return t.direction === "BORROW";
+  return ["EXPENSE", "LOAN", "TRANSFER_OUT"].includes(t.type);
+}
+
+/**
+ * Non-blocking checks on a transaction that was just written. Create
+ * endpoints return these alongside the entity so the UI can flag them;
+ * they never reject the write.
+ */
+export class WarningService {
+  async forTransaction(tx: Transaction): Promise<string[]> {
+    try {
+      return [
+        ...this.rateWarnings(tx),
+        ...this.counterpartyWarnings(tx),
+        ...this.closedPeriodWarnings(tx.createdAt),
+        ...(await this.balanceWarnings(tx)),
+      ];
+    } catch (e: any) {
+      logger.warn({ error: e?.message, id: tx.id }, "Warning checks failed");
+      return [];
+    }
+  }
+
+  private rateWarnings(tx: Transaction): string[] {
+    const { rate, asset } = tx;
+    if (!rate) return [];
+    if (rate.missing) {
+      return [
+        `No FX rate for ${asset.symbol}; recorded at a placeholder until ` +
+          "a provider answers",
+      ];
+    }
+    // FIXED without stale is a fixed peg (USD) or a price given by hand
+    if (rate.source === "FIXED" && !rate.stale) return [];
+    const days = Math.floor(
+      Math.abs(
+        new Date(tx.createdAt).getTime() - new Date(rate.timestamp).getTime(),
+      ) / DAY_MS,
+    );
+    if (days < RATE_MAX_AGE_DAYS) return [];
+    return [
+      `FX rate for ${asset.symbol} is ${days} days off the transaction date`,
+    ];
+  }
+
+  private counterpartyWarnings(tx: Transaction): string[] {
+    const name = tx.counterparty?.trim().toLowerCase();
+    if (!name) return [];
+    const known = transactionRepository
+      .findAll()
+      .some(
+        (t) =>
+          t.id !== tx.id && t.counterparty?.trim().toLowerCase() === name,
+      );
+    return known ? [] : [`"${tx.counterparty}" is a new counterparty`];
+  }
+
+  /** Writes dated in a closed fiscal year leave its archive unchanged. */
+  closedPeriodWarnings(at: string): string[] {
+    const closed = yearCloseRepository
+      .findAll()
+      .find((c) => at >= c.start && at <= c.end);
+    if (!closed) return [];
+    return [
+      `Dated in fiscal year ${closed.year}, which is closed; its archived ` +
+        "year-end summary does not include this change",
+    ];
+  }
+
+  // Only vault-backed accounts keep a unit balance to check
+  private async balanceWarnings(tx: Transaction): Promise<string[]> {
+    if (!isOutflow(tx) || !tx.account) return [];
+    if (!vaultRepository.findByName(tx.account)) return [];
+    const remaining = await vaultService.remainingQuantity(
+      tx.account,
+      tx.asset,
+    );
+    if (remaining >= -EPSILON) return [];
+    return [
+      `${tx.asset.symbol} balance of "${tx.account}" goes negative ` +
+        `(${remaining})`,
+    ];
+  }
+}
+
+export const warningService = new WarningService();
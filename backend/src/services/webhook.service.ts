@@ -0,0 +1,173 @@
+import axios from "axios";
+import crypto from "crypto";
+import { v4 as uuidv4 } from "uuid";
+import { Webhook, WebhookCreateRequest, WebhookUpdateRequest } from "../types";
+import { webhookRepository } from "../repositories";
+import { ValidationError } from "../core/errors";
+import { logger } from "../utils/logger";
+import { DOMAIN_EVENT_TYPES, eventBus } from "./event-bus.service";
+
+const DELIVERY_TIMEOUT_MS = 10000;
+const RETRY_DELAYS_MS = [1000, 5000]; // after the first and second attempt
+let subscribed = false;
+
+const delay = (ms: number) => new Promise((r) => setTimeout(r, ms));
+
+export interface WebhookDelivery {
+  status: number; // HTTP status, 0 when no response was received
+  attempts: number;
+  error?: string;
+}
+
+/**
+ * Signature of a delivery: hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+ * with the webhook's secret, sent as "sha256=<hex>".
+ */
+export function signPayload(
+  secret: string,
+  timestamp: number,
+  body: string,
+): string {
+  const hmac = crypto.createHmac("sha256", secret);
+  return `sha256=${hmac.update(`${timestamp}.${body}`).digest("hex")}`;
+}
+
+/**
+ * Registered HTTP endpoints receiving domain events (new expenses, closed
+ * investments, ...), e.g. a Telegram bot or an n8n workflow. Each event
+ * is POSTed as JSON with a signature the receiver can verify.
+ */
+export class WebhookService {
+  list(): Webhook[] {
+    return webhookRepository.findAll();
+  }
+
+  get(id: string): Webhook | undefined {
+    return webhookRepository.findById(id);
+  }
+
+  create(req: WebhookCreateRequest): Webhook {
+    return webhookRepository.create({
+      id: uuidv4(),
+      url: req.url,
+      events: this.checkEvents(req.events),
+      secret: req.secret || crypto.randomBytes(24).toString("hex"),
+      description: req.description,
+      active: req.active,
+      createdAt: new Date().toISOString(),
+    });
+  }
+
+  update(id: string, req: WebhookUpdateRequest): Webhook | undefined {
+    if (!webhookRepository.findById(id)) return undefined;
+    const updates: Partial<Webhook> = {
+      ...req,
+      updatedAt: new Date().toISOString(),
+    };
+    if (req.events) updates.events = this.checkEvents(req.events);
+    return webhookRepository.update(id, updates);
+  }
+
+  delete(id: string): boolean {
+    return webhookRepository.delete(id);
+  }
+
+  /** Deliver events from the bus to matching webhooks from now on. */
+  start(): void {
+    if (subscribed) return;
+    subscribed = true;
+    eventBus.subscribe((event) => {
+      for (const hook of this.matching(event.type)) {
+        void this.deliver(hook, event);
+      }
+    });
+  }
+
+  /** Send a "ping" event to one webhook to check the endpoint. */
+  async test(id: string): Promise<WebhookDelivery | undefined> {
+    const hook = webhookRepository.findById(id);
+    if (!hook) return undefined;
+    return this.deliver(hook, {
+      id: uuidv4(),
+      type: "ping",
+      at: new Date().toISOString(),
+      data: { webhook_id: hook.id },
+    });
+  }
+
+  /**
+   * POST one event, retrying on network errors and 5xx responses. The
+   * outcome is kept on the webhook (last_status, last_error).
+   */
+  async deliver(
+    hook: Webhook,
+    event: { id: string; type: string; at: string; data: unknown },
+  ): Promise<WebhookDelivery> {
+    const body = JSON.stringify(event);
+    const result: WebhookDelivery = { status: 0, attempts: 0 };
+    for (;;) {
+      result.attempts++;
+      const timestamp = Math.floor(Date.now() / 1000);
+      try {
+        const res = await axios.post(hook.url, body, {
+          timeout: DELIVERY_TIMEOUT_MS,
+          validateStatus: () => true,
+          headers: {
+            "Content-Type": "application/json",
+            "X-Nami-Event": event.type,
+            "X-Nami-Delivery": event.id,
+            "X-Nami-Timestamp": String(timestamp),
+            "X-Nami-Signature": signPayload(hook.secret, timestamp, body),
+          },
+        });
+        result.status = res.status;
+        result.error =
+          res.status >= 200 && res.status < 300
+            ? undefined
+            : `HTTP ${res.status}`;
+      } catch (err: any) {
+        result.status = 0;
+        result.error = err?.message || "delivery failed";
+      }
+
+      const retry =
+        result.error && (result.status === 0 || result.status >= 500);
+      if (!retry || result.attempts > RETRY_DELAYS_MS.length) break;
+      await delay(RETRY_DELAYS_MS[result.attempts - 1]);
+    }
+
+    if (result.error) {
+      logger.warn(
+        { webhook: hook.id, event: event.type, error: result.error },
+        "Webhook delivery failed",
+      );
+    }
+    webhookRepository.update(hook.id, {
+      lastDeliveryAt: new Date().toISOString(),
+      lastStatus: result.status,
+      lastError: result.error,
+    });
+    return result;
+  }
+
+  private matching(type: string): Webhook[] {
+    return webhookRepository
+      .findAll()
+      .filter(
+        (w) => w.active && (w.events.includes("*") || w.events.includes(type)),
+      );
+  }
+
+  private checkEvents(events: string[]): string[] {
+    const known = new Set<string>(["*", ...DOMAIN_EVENT_TYPES]);
+    const unknown = events.filter((e) => !known.has(e));
+    if (unknown.length) {
+      throw new ValidationError(`Unknown event types: ${unknown.join(", ")}`, {
+        known: DOMAIN_EVENT_TYPES,
+      });
+    }
+    return Array.from(new Set(events));
+  }
+}
+
+export const webhookService = new WebhookService();
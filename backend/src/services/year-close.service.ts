@@ -0,0 +1,166 @@
+import { Asset, YearClose, YearCloseSnapshot, assetKey } from "../types";
+import {
+  settingsRepository,
+  vaultRepository,
+  yearCloseRepository,
+} from "../repositories";
+import { ConflictError, ValidationError } from "../core/errors";
+import { fiscalYearOf, fiscalYearRange } from "../utils/fiscal-year.util";
+import { logger } from "../utils/logger";
+import { netWorthService } from "./networth.service";
+import { snapshotService } from "./snapshot.service";
+import { taxService } from "./tax.service";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+// Late statements and backdated entries get a month before the scheduler
+// freezes the year
+const AUTO_CLOSE_AFTER_DAYS = 30;
+const CHECK_INTERVAL_MS = 6 * 60 * 60 * 1000;
+const EPSILON = 1e-12;
+let schedulerStarted = false;
+
+/**
+ * Year-end close. Closing a fiscal year values it once at its last day and
+ * archives the result, so prior-year summaries are read back instead of
+ * recomputed. A closed year stays editable; writes dated inside it get a
+ * warning (see warning.service) and never change the archive.
+ */
+export class YearCloseService {
+  list(): YearClose[] {
+    return yearCloseRepository.findAll();
+  }
+
+  get(year: number): YearClose | undefined {
+    return yearCloseRepository.findByYear(year);
+  }
+
+  async close(
+    year: number,
+    options: { note?: string; auto?: boolean; now?: Date } = {},
+  ): Promise<YearClose> {
+    const now = options.now ?? new Date();
+    if (yearCloseRepository.findByYear(year)) {
+      throw new ConflictError(`Fiscal year ${year} is already closed`);
+    }
+    const { start, end } = fiscalYearRange(
+      year,
+      settingsRepository.getFiscalYear(),
+    );
+    if (now.toISOString() <= end) {
+      throw new ValidationError(
+        `Fiscal year ${year} runs until ${end.slice(0, 10)}`,
+      );
+    }
+
+    const close = yearCloseRepository.create({
+      year,
+      start,
+      end,
+      closedAt: now.toISOString(),
+      auto: !!options.auto,
+      note: options.note,
+      snapshot: await this.snapshot(year, start, end),
+    });
+    logger.info({ year, auto: close.auto }, "Fiscal year closed");
+    return close;
+  }
+
+  /** Year-end figures of fiscal year `year`, computed from the ledger. */
+  async snapshot(
+    year: number,
+    start: string,
+    end: string,
+  ): Promise<YearCloseSnapshot> {
+    const endDay = end.slice(0, 10);
+    const openingDay = new Date(new Date(start).getTime() - DAY_MS)
+      .toISOString()
+      .slice(0, 10);
+
+    const diff = await snapshotService.diff(openingDay, endDay);
+    const series = netWorthService.series({ start: endDay, end: endDay });
+    const point = series.points[series.points.length - 1];
+    const tax = taxService.report(year);
+
+    return {
+      netWorthUSD: point?.net_worth_usd ?? 0,
+      netWorthVND: point?.net_worth_vnd ?? 0,
+      assetsUSD: point?.assets_usd ?? 0,
+      liabilitiesUSD: point?.liabilities_usd ?? 0,
+      holdings: diff.to.positions,
+      holdingsUSD: diff.to.totalUSD,
+      balances: this.balancesAt(end),
+      pnl: {
+        openingUSD: diff.from.totalUSD,
+        netContributionsUSD: diff.netContributionsUSD,
+        marketMovementUSD: diff.marketMovementUSD,
+        investmentGainsUSD: diff.investmentGainsUSD,
+        fxEffectUSD: diff.fxEffectUSD,
+        realizedGainUSD: tax.gains.total.gainUSD,
+        incomeUSD: tax.incomeUSD,
+        feesUSD: tax.fees.totalUSD,
+      },
+      unpriced: series.unpriced,
+    };
+  }
+
+  // Units per vault and asset at `end`, by vault then asset
+  private balancesAt(
+    end: string,
+  ): { vault: string; asset: Asset; quantity: number }[] {
+    const units = new Map<
+      string,
+      { vault: string; asset: Asset; quantity: number }
+    >();
+    for (const e of vaultRepository.findAllEntriesUntil(end)) {
+      if (e.type !== "DEPOSIT" && e.type !== "WITHDRAW") continue;
+      const k = `${e.vault}|${assetKey(e.asset)}`;
+      const cur = units.get(k) || {
+        vault: e.vault,
+        asset: e.asset,
+        quantity: 0,
+      };
+      cur.quantity += e.type === "DEPOSIT" ? e.amount : -e.amount;
+      units.set(k, cur);
+    }
+    return Array.from(units.values())
+      .filter((b) => Math.abs(b.quantity) > EPSILON)
+      .sort(
+        (a, b) =>
+          a.vault.localeCompare(b.vault) ||
+          a.asset.symbol.localeCompare(b.asset.symbol),
+      );
+  }
+
+  /**
+   * Close the previous fiscal year once it has been over for
+   * AUTO_CLOSE_AFTER_DAYS, unless it is closed or has no entries.
+   */
+  async processDue(now: Date = new Date()): Promise<YearClose | undefined> {
+    const fiscal = settingsRepository.getFiscalYear();
+    const settled = new Date(now.getTime() - AUTO_CLOSE_AFTER_DAYS * DAY_MS);
+    const year = fiscalYearOf(settled, fiscal) - 1;
+    if (yearCloseRepository.findByYear(year)) return undefined;
+    const { end } = fiscalYearRange(year, fiscal);
+    if (!vaultRepository.findAllEntriesUntil(end).length) return undefined;
+    return this.close(year, { auto: true, now });
+  }
+
+  startScheduler(): void {
+    if (schedulerStarted) return;
+    schedulerStarted = true;
+
+    const run = async () => {
+      try {
+        await this.processDue();
+      } catch (e: any) {
+        logger.warn({ error: e?.message }, "Automatic year close failed");
+      }
+    };
+    void run();
+    setInterval(() => {
+      void run();
+    }, CHECK_INTERVAL_MS);
+  }
+}
+
+export const yearCloseService = new YearCloseService();
@@ -16,9 +16,30 @@ export type TransactionType =
   | "REPAY"
   | "TRANSFER_OUT"
   | "TRANSFER_IN";
+export const TRANSACTION_TYPES: TransactionType[] = [
+  "INITIAL",
+  "INCOME",
+  "EXPENSE",
+  "BORROW",
+  "LOAN",
+  "REPAY",
+  "TRANSFER_OUT",
+  "TRANSFER_IN",
+];
 
 export type RepayDirection = "BORROW" | "LOAN";
 
+// Actions handled by POST /api/actions
+export const ACTION_NAMES = [
+  "spot_buy",
+  "init_balance",
+  "transfer",
+  "drip",
+  "network_fee",
+  "atm_withdrawal",
+  "cash_count",
+] as const;
+
 export interface Rate {
   asset: Asset;
   rateUSD: number; // 1 asset -> USD
@@ -29,8 +50,36 @@ export interface Rate {
     | "FRANKFURTER"
     | "ER_API"
     | "EXCHANGE_RATE_API"
+    | "YAHOO_FINANCE"
+    | "ALPHA_VANTAGE"
+    | "MANUAL"
     | "FALLBACK"
     | "FIXED";
+  stale?: boolean; // last cached value served while the provider is unavailable
+  missing?: boolean; // no provider and no cached value; rateUSD is a placeholder
+  override?: boolean; // supplied by the request (?prices=), not a market rate
+}
+
+// Rate sources backed by an external API, in default fallback order
+export const PRICE_PROVIDERS = [
+  "COINGECKO",
+  "EXCHANGE_RATE_HOST",
+  "FRANKFURTER",
+  "ER_API",
+  "EXCHANGE_RATE_API",
+  "YAHOO_FINANCE",
+  "ALPHA_VANTAGE",
+] as const;
+export type PriceProvider = (typeof PRICE_PROVIDERS)[number];
+
+// Price set by hand for an asset no provider covers (or covers badly). It
+// applies from `at` until a later manual price or its removal.
+export interface ManualPrice {
+  symbol: string; // upper-cased
+  priceUSD: number;
+  at: string; // ISO, effective from
+  note?: string;
+  updatedAt: string;
 }
 
 export interface TransactionBase {
@@ -39,17 +88,39 @@ export interface TransactionBase {
   asset: Asset;
   amount: number; // positive value in asset units provided by the request
   createdAt: string; // ISO date
+  updatedAt?: string; // last edit, stamped by the repository
   account?: string; // optional account/source of funds
+  accountId?: number; // admin account id; survives renames of `account`
+  assetId?: number; // admin asset id; survives renames of the symbol
   note?: string;
   category?: string; // primary category or tag
   tags?: string[]; // additional tags
   counterparty?: string; // merchant, payee, or related party
+  member?: string; // household member who spent or earned it
   dueDate?: string; // ISO date for due/billing date if applicable
   transferId?: string; // Links TRANSFER_OUT and TRANSFER_IN pairs
   loanId?: string; // optional link to a specific loan agreement (for LOAN, REPAY principal, interest income)
   sourceRef?: string; // external reference ID for deduplication (e.g., bank transaction number)
+  reimbursable?: boolean; // EXPENSE expected to be paid back (e.g., work expense)
+  reimbursesId?: string; // INCOME refund linked to the reimbursable expense it repays
+  projectId?: string; // optional trip/project grouping, independent of tags
+  chain?: string; // network of an on-chain transaction (e.g., ethereum, solana)
+  card?: string; // payment card of a card expense (e.g., "Visa 1234")
+  feeUSD?: number; // card FX fee charged on top of usdAmount
+  place?: string; // where it happened, e.g. "Ben Thanh Market"
+  latitude?: number; // WGS84, set together with longitude
+  longitude?: number;
+  reviewedAt?: string; // last marked reviewed; a later updatedAt undoes it
+  internalFlow?: boolean; // set by a rule; else a linked transfer leg is one
+  deletedAt?: string; // soft-deleted; restorable until purged
   rate: Rate; // rate used at the time of transaction
   usdAmount: number; // amount * rateUSD (may be negative based on delta sign)
+  // Value of one unit of `asset` in other currencies when recorded, by
+  // currency code, e.g. { VND: 25000 } for 1 USD; USD itself is `rate`
+  fx?: Record<string, number>;
+  // Percent over those rates the account charged, when it was a foreign
+  // currency there; `usdAmount` includes it (see utils/fx.util)
+  fxMargin?: number;
 }
 
 export interface CounterpartyTxn {
@@ -71,12 +142,25 @@ export type Transaction =
   | (TransactionBase & { type: "REPAY" } & RepayMeta &
       Partial<CounterpartyTxn>);
 
+// Keyset pagination: newest first, `cursor` is the previous page's
+// nextCursor, which is absent on the last page
+export interface PageRequest {
+  limit: number;
+  cursor?: string;
+}
+export interface Page<T> {
+  items: T[];
+  nextCursor?: string;
+}
+
 export interface PortfolioReportItem {
   asset: Asset;
   account?: string; // account that holds this asset
   balance: number; // current units
   rateUSD: number;
   valueUSD: number;
+  priceAsOf?: string; // day of the rate used, ISO
+  priceStale?: boolean; // provider down; last cached rate or none
 }
 
 export interface ObligationItem {
@@ -101,10 +185,18 @@ export interface PortfolioReport {
 
 // Vaults
 export type VaultStatus = "ACTIVE" | "CLOSED";
+export const VAULT_STATUSES: VaultStatus[] = ["ACTIVE", "CLOSED"];
+// A withdrawal of more than the vault holds: REJECT fails it, WARN records
+// it with a warning and ALLOW records it as is
+export type OverdrawPolicy = "REJECT" | "WARN" | "ALLOW";
+export const OVERDRAW_POLICIES: OverdrawPolicy[] = ["REJECT", "WARN", "ALLOW"];
 export interface Vault {
   name: string;
   status: VaultStatus;
   createdAt: string;
+  tags?: string[]; // strategy labels, e.g. DCA, yield-farming, long-term-hold
+  account?: string; // account it is held in, e.g. "Crypto Exchange"
+  benchmark?: string; // symbol it is measured against, e.g. BTC or SPY
 }
 export type VaultEntryType = "DEPOSIT" | "WITHDRAW" | "VALUATION";
 export interface VaultEntry {
@@ -116,6 +208,18 @@ export interface VaultEntry {
   at: string; // ISO
   account?: string;
   note?: string;
+  lots?: LotSelection[]; // WITHDRAW under SPECIFIC: the deposit lots closed
+}
+
+// How withdrawals release the cost of deposited units: AVERAGE spreads it
+// over all open lots, FIFO/LIFO close the oldest/newest first, SPECIFIC
+// closes the lots named on the withdrawal
+export type CostBasisMethod = "AVERAGE" | "FIFO" | "LIFO" | "SPECIFIC";
+// Units taken from one deposit lot, identified by the deposit's timestamp
+// ("2025-01-01T00:00:00.000Z", "...#2" for a second deposit at that time)
+export interface LotSelection {
+  lot: string;
+  amount: number;
 }
 
 // Loans
@@ -154,6 +258,581 @@ export interface BorrowingAgreement {
   createdAt: string;
 }
 
+// Trips / projects (cost grouping across accounts and currencies)
+export type ProjectKind = "TRIP" | "PROJECT";
+export type ProjectStatus = "ACTIVE" | "CLOSED";
+export interface Project {
+  id: string;
+  name: string; // e.g., "Japan trip March 2025"
+  kind: ProjectKind;
+  startAt?: string; // ISO date
+  endAt?: string; // ISO date
+  note?: string;
+  status: ProjectStatus;
+  createdAt: string;
+}
+
+// Subscription / warranty registry (tied to expense transactions)
+export type RegistryKind = "SUBSCRIPTION" | "WARRANTY";
+export type RenewalCadence = "WEEKLY" | "MONTHLY" | "QUARTERLY" | "YEARLY";
+export type RegistryStatus = "ACTIVE" | "CANCELLED" | "EXPIRED";
+export interface RegistryItem {
+  id: string;
+  kind: RegistryKind;
+  name: string; // e.g., "Netflix", "MacBook Pro"
+  transactionId?: string; // expense that started the subscription / purchase
+  asset?: Asset;
+  amount?: number; // renewal price (SUBSCRIPTION) or purchase price (WARRANTY)
+  cadence?: RenewalCadence; // SUBSCRIPTION only
+  nextRenewalAt?: string; // ISO date of the next expected charge
+  cancelBy?: string; // last day to cancel before the next charge
+  warrantyExpiresAt?: string; // WARRANTY only
+  status: RegistryStatus;
+  note?: string;
+  lastNotifiedAt?: string; // last reminder sent, avoids repeat notifications
+  createdAt: string;
+}
+
+// Fixed income (bank term deposits, bonds)
+export type FixedIncomeKind = "TERM_DEPOSIT" | "BOND";
+export type CouponFrequency =
+  | "MONTHLY"
+  | "QUARTERLY"
+  | "SEMIANNUAL"
+  | "ANNUAL"
+  | "AT_MATURITY";
+export type FixedIncomeStatus = "ACTIVE" | "MATURED" | "CLOSED";
+export interface FixedIncomeInstrument {
+  id: string;
+  kind: FixedIncomeKind;
+  name: string; // e.g., "VCB 12M deposit", "VN Gov 2030"
+  issuer?: string; // bank or bond issuer
+  asset: Asset; // currency of face value and coupons
+  faceValue: number; // principal in asset units
+  couponRate: number; // annual rate as decimal (e.g., 0.055 means 5.5%)
+  couponFrequency: CouponFrequency;
+  startAt: string; // ISO date the instrument starts accruing
+  maturityAt: string; // ISO date principal is repaid
+  account?: string; // account coupons and principal are paid into
+  nextCouponAt?: string; // next scheduled coupon; unset once matured
+  status: FixedIncomeStatus;
+  note?: string;
+  createdAt: string;
+}
+
+// Options (basic long/short calls and puts)
+export type OptionType = "CALL" | "PUT";
+export type OptionSide = "LONG" | "SHORT"; // bought or written
+export type OptionStatus = "OPEN" | "EXPIRED" | "EXERCISED";
+export interface OptionPosition {
+  id: string; // also the transferId linking all of its transactions
+  underlying: Asset;
+  optionType: OptionType;
+  side: OptionSide;
+  contracts: number;
+  multiplier: number; // underlying units per contract
+  strike: number; // per underlying unit, in premiumAsset
+  expiry: string; // ISO date
+  premium: number; // per underlying unit, in premiumAsset
+  premiumAsset: Asset; // currency of premium and strike
+  premiumUSD: number; // total premium paid (LONG) or received (SHORT)
+  account: string;
+  status: OptionStatus;
+  openedAt: string;
+  settledAt?: string;
+  settlementPrice?: number; // underlying price used at expiry/exercise
+  realizedPnLUSD?: number; // set once EXPIRED or EXERCISED
+  note?: string;
+  createdAt: string;
+}
+
+// Employer equity (RSU grants, ESPP plans)
+export type VestingKind = "RSU" | "ESPP";
+export type VestingGrantStatus = "ACTIVE" | "COMPLETED" | "CANCELLED";
+export type VestEventStatus = "SCHEDULED" | "VESTED" | "CANCELLED";
+export interface VestingGrant {
+  id: string;
+  kind: VestingKind;
+  employer: string;
+  asset: Asset; // the employer's stock
+  cashAsset: Asset; // currency of prices and income (usually USD)
+  account: string; // brokerage account/vault the shares land in
+  totalUnits: number; // RSU: units granted; ESPP: 0 (set per purchase)
+  grantAt: string; // ISO date
+  vestStartAt: string; // ISO date vesting starts counting from
+  cliffMonths: number;
+  vestingMonths: number;
+  frequencyMonths: number; // months between vests after the cliff
+  withholdingRate: number; // share of vested units withheld for tax
+  discount: number; // ESPP purchase discount (e.g., 0.15); 0 for RSU
+  status: VestingGrantStatus;
+  note?: string;
+  createdAt: string;
+}
+export interface VestEvent {
+  id: string;
+  grantId: string;
+  vestAt: string; // ISO date
+  units: number; // gross units vesting or purchased
+  status: VestEventStatus;
+  fmv?: number; // price per unit on vest date, in the grant's cashAsset
+  purchasePrice?: number; // ESPP price paid per unit
+  withheldUnits?: number;
+  netUnits?: number;
+  incomeUSD?: number;
+  transferId?: string; // links the income and buy transactions
+}
+
+// Address book: known external destinations for transfers
+export type AddressBookKind =
+  | "EXCHANGE_DEPOSIT"
+  | "BANK_ACCOUNT"
+  | "WALLET"
+  | "OTHER";
+export interface AddressBookEntry {
+  id: string;
+  name: string; // label used as the transfer's to_account
+  kind: AddressBookKind;
+  address: string; // deposit address, bank account number, etc.
+  owner?: string; // person or institution holding it
+  chain?: string; // network for on-chain addresses
+  asset?: string; // symbol the destination accepts, if restricted
+  account?: string; // internal account credited, if it is one of ours
+  note?: string;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// Classification of an asset in the admin assets table; decides how it is
+// priced. Asset.type stays the coarse FIAT/CRYPTO split.
+export type AssetKind = "FIAT" | "STABLECOIN" | "CRYPTO" | "EQUITY" | "OTHER";
+export const ASSET_KINDS: AssetKind[] = [
+  "FIAT",
+  "STABLECOIN",
+  "CRYPTO",
+  "EQUITY",
+  "OTHER",
+];
+
+// An account in the admin accounts table
+export interface AdminAccount {
+  id: number;
+  name: string;
+  type?: string;
+  institution?: string; // provider, e.g. Vietcombank, Binance, Interactive Brokers
+  jurisdiction?: string; // ISO 3166-1 alpha-2 country where the account is held
+  default_asset?: string; // symbol quick entry falls back to, e.g. VND
+  // Percent over mid-market the provider charges on foreign-currency
+  // expenses, e.g. 2.5 for a card (see utils/fx.util)
+  fx_margin_percent?: number;
+  is_active: boolean;
+  created_at: string;
+}
+
+// Target share of the portfolio for one asset class. The target moves
+// linearly from startPercent at startAt to endPercent at endAt and holds
+// at either end; equal percents make it a fixed target.
+export interface AllocationGlidepath {
+  id: string;
+  assetClass: AssetKind;
+  startPercent: number;
+  endPercent: number;
+  startAt: string;
+  endAt: string;
+  note?: string;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// Reference VND term-deposit rate offered by a bank, in effect from
+// effectiveDate until that bank's next entry for the same term
+export interface DepositRate {
+  id: string;
+  effectiveDate: string; // YYYY-MM-DD
+  termMonths: number;
+  ratePercent: number; // annual, e.g. 4.7
+  bank?: string;
+  note?: string;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// A saved report configuration delivered on a schedule. The period is
+// resolved relative to each run ("last_month" on 3 March is February);
+// filters are passed to the report as query parameters.
+export type ReportPeriodMode =
+  | "none"
+  | "last_7_days"
+  | "last_30_days"
+  | "this_month"
+  | "last_month"
+  | "year_to_date"
+  | "last_year";
+export interface ReportSubscription {
+  id: string;
+  name: string;
+  report: string; // key of REPORT_TYPES in report-subscription.service
+  periodMode: ReportPeriodMode;
+  filters?: Record<string, string>;
+  currency: "USD" | "VND";
+  cadence: RenewalCadence;
+  nextRunAt: string;
+  active: boolean;
+  lastRunAt?: string;
+  lastStatus?: "OK" | "FAILED";
+  lastError?: string;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// A transaction that repeats on an RRULE-like schedule (rent, salary,
+// subscriptions). Due occurrences are created as transactions, or queued
+// in pendingDates for confirmation when confirm is set.
+export interface RecurringTransaction {
+  id: string;
+  name: string;
+  type: "INCOME" | "EXPENSE";
+  asset: Asset;
+  amount: number;
+  account?: string;
+  note?: string;
+  category?: string;
+  tags?: string[];
+  counterparty?: string;
+  rule: string; // e.g. FREQ=MONTHLY;BYMONTHDAY=1, see utils/rrule.util
+  startAt: string; // first possible occurrence
+  nextRunAt?: string; // undefined once the schedule has ended
+  occurrences: number; // occurrences materialized so far
+  confirm: boolean;
+  pendingDates?: string[]; // due occurrences awaiting confirmation
+  active: boolean;
+  lastOccurrenceAt?: string; // due date of the latest occurrence handled
+  lastError?: string; // why the latest run stopped early, if it did
+  drift?: RecurringDrift; // the latest charge no longer matches amount
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// The latest charge of a recurring entry differs from its amount, e.g. a
+// rent increase or a subscription price change
+export interface RecurringDrift {
+  amount: number; // the charged amount, proposed as the new amount
+  previousAmount: number; // the entry's amount when the drift was found
+  changePercent: number;
+  chargedAt: string;
+  transactionId: string;
+  detectedAt: string;
+}
+
+// Monthly spending limit for one spending tag (e.g. Groceries $400)
+export interface Budget {
+  id: string;
+  tag: string; // matches the tag used by /reports/spending
+  amount: number; // limit per calendar month
+  currency: "USD" | "VND";
+  note?: string;
+  active: boolean;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// Savings goal, funded by the balances of its accounts and by
+// transactions carrying one of its tags
+export interface Goal {
+  id: string;
+  name: string;
+  targetAmount: number;
+  currency: "USD" | "VND";
+  targetDate?: string; // YYYY-MM-DD
+  accounts: string[]; // account names, as transactions name them
+  tags: string[]; // category or tags of contributions made elsewhere
+  note?: string;
+  active: boolean;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// External HTTP endpoint receiving signed domain events
+export interface Webhook {
+  id: string;
+  url: string;
+  events: string[]; // event types, e.g. "transaction.created"; "*" for all
+  secret: string; // HMAC-SHA256 key for the X-Nami-Signature header
+  description?: string;
+  active: boolean;
+  createdAt: string;
+  updatedAt?: string;
+  lastDeliveryAt?: string;
+  lastStatus?: number; // HTTP status of the last delivery, 0 if none
+  lastError?: string;
+}
+
+// Figures frozen when a fiscal year is closed, valued at its last day
+export interface YearCloseSnapshot {
+  netWorthUSD: number;
+  netWorthVND: number;
+  assetsUSD: number;
+  liabilitiesUSD: number;
+  holdings: {
+    asset: Asset;
+    quantity: number;
+    rateUSD: number;
+    valueUSD: number;
+  }[];
+  holdingsUSD: number;
+  balances: { vault: string; asset: Asset; quantity: number }[];
+  pnl: {
+    openingUSD: number; // holdings at the end of the previous year
+    netContributionsUSD: number;
+    marketMovementUSD: number;
+    investmentGainsUSD: number;
+    fxEffectUSD: number;
+    realizedGainUSD: number;
+    incomeUSD: number;
+    feesUSD: number;
+  };
+  unpriced: string[];
+}
+
+// Archived fiscal year; edits dated inside it only raise warnings
+export interface YearClose {
+  year: number; // fiscal year, named by the year it starts in
+  start: string;
+  end: string;
+  closedAt: string;
+  auto: boolean; // closed by the scheduler
+  note?: string;
+  snapshot: YearCloseSnapshot;
+}
+
+// A calendar month signed off in the monthly review, kept as a record of
+// when it was checked; signing off again after it reopens replaces it
+export interface MonthReview {
+  month: string; // YYYY-MM
+  reviewedAt: string;
+  transactionCount: number; // transactions in the month when signed off
+  note?: string;
+}
+
+// Audit log: every create, update and delete of an audited record, with
+// the record as stored before and after the write
+export const AUDIT_ENTITIES = [
+  "transaction",
+  "vault",
+  "vault_entry",
+  "fixed_income",
+  "option",
+  "admin_type",
+  "admin_account",
+  "admin_asset",
+  "admin_tag",
+] as const;
+export type AuditEntity = (typeof AUDIT_ENTITIES)[number];
+export type AuditAction = "create" | "update" | "delete";
+export interface AuditEntry {
+  id: number;
+  at: string;
+  entity: AuditEntity;
+  entityId: string;
+  action: AuditAction;
+  actor: string; // X-Nami-User of the request, or "system"
+  before?: unknown; // absent on create
+  after?: unknown; // absent on delete
+}
+export interface AuditFilter {
+  entity?: AuditEntity;
+  entityId?: string;
+  actor?: string;
+  start?: string;
+  end?: string;
+}
+
+// Units of one asset held in one account, kept current on every
+// transaction write (see repositories/account-balance.repository)
+export interface AccountBalance {
+  account: string;
+  asset: Asset;
+  quantity: number;
+  transactionCount: number;
+  lastTransactionAt?: string;
+}
+
+// Admin accounts and assets keep their id through a rename; every name
+// they went by is kept with the time it took effect (see
+// repositories/stable-names.repository)
+export type RenameKind = "account" | "asset";
+export interface NameHistoryEntry {
+  kind: RenameKind;
+  entityId: number; // admin account or asset id
+  name: string; // account name or asset symbol
+  effectiveFrom: string; // ISO; a first name runs from the epoch
+  createdAt: string;
+  mergedInto?: number; // set once the entity was merged into another
+}
+
+// Two-phase action (see services/action.service): prepared before an
+// external side effect such as an exchange order, then confirmed once it
+// happened, which records the action's transactions exactly once, or
+// cancelled. PREPARED intents lapse to EXPIRED at `expiresAt`
+export const ACTION_INTENT_STATUSES = [
+  "PREPARED",
+  "CONFIRMING", // the action is being recorded
+  "CONFIRMED",
+  "CANCELLED",
+  "EXPIRED",
+] as const;
+export type ActionIntentStatus = (typeof ACTION_INTENT_STATUSES)[number];
+export interface ActionIntent {
+  id: string;
+  action: string; // e.g. "spot_buy"
+  params: Record<string, unknown>;
+  status: ActionIntentStatus;
+  clientRef?: string; // caller's key; preparing it again returns this one
+  externalRef?: string; // e.g. the exchange order id, given on confirm
+  transactionIds: string[]; // while CONFIRMING, those booked so far
+  vaultEntries?: VaultEntry[]; // while CONFIRMING, vault entries booked
+  // The recorded action's response, replayed to a repeated confirm
+  response?: { status: number; body: Record<string, unknown> };
+  error?: string; // why the last confirm failed; the intent stays PREPARED
+  cancelReason?: string;
+  createdAt: string;
+  updatedAt?: string;
+  expiresAt: string;
+  confirmedAt?: string;
+  cancelledAt?: string;
+}
+
+
+export const CONSISTENCY_ISSUE_KINDS = [
+  "orphaned_transfer", // a transfer leg whose other leg is missing
+  "vault_mismatch", // vault entries don't net to the vault's transactions
+  "negative_balance", // an account's running balance dips below zero
+  "missing_fx_rate", // no usable USD rate, so no usdAmount
+] as const;
+export type ConsistencyIssueKind = (typeof CONSISTENCY_ISSUE_KINDS)[number];
+
+// Scheduled push of holdings and the monthly summary to a Google Sheet
+export interface SheetsExport {
+  spreadsheetId: string;
+  holdingsSheet: string; // tab names; their contents are replaced
+  summarySheet: string;
+  months: number; // monthly summary rows, ending with the current month
+  currency: "USD" | "VND";
+  intervalHours: number;
+  clientEmail: string; // service account, shown instead of the key
+  credentials: string; // service account key JSON, encrypted
+  active: boolean;
+  nextRunAt: string;
+  lastRunAt?: string;
+  lastStatus?: "OK" | "FAILED";
+  lastError?: string;
+  updatedAt: string;
+}
+
+// Exchanges whose history can be synced into transactions
+export const CONNECTOR_PROVIDERS = ["binance"] as const;
+export type ConnectorProvider = (typeof CONNECTOR_PROVIDERS)[number];
+
+// Read-only exchange API keys whose activity is synced on a schedule
+export interface ExchangeConnector {
+  id: string;
+  provider: ConnectorProvider;
+  name: string;
+  account: string; // account the synced transactions are recorded in
+  apiKeyHint: string; // end of the API key, shown instead of the key
+  credentials: string; // { apiKey, apiSecret } JSON, encrypted
+  pairs: string[]; // spot pairs whose trades are synced, e.g. "BTC/USDT"
+  since: string; // activity before this is not synced
+  intervalHours: number;
+  active: boolean;
+  cursors: Record<string, number>; // per stream, where the next sync resumes
+  nextSyncAt: string;
+  lastSyncAt?: string;
+  lastStatus?: "OK" | "FAILED";
+  lastError?: string;
+  lastCreated?: number; // transactions the last sync recorded
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// EVM networks whose wallets can be synced from a block explorer
+export const EVM_CHAINS = [
+  "ethereum",
+  "bsc",
+  "polygon",
+  "arbitrum",
+  "optimism",
+  "base",
+] as const;
+export type EvmChain = (typeof EVM_CHAINS)[number];
+
+// A watched on-chain address whose transfers are synced on a schedule
+export interface EvmWallet {
+  id: string;
+  chain: EvmChain;
+  address: string; // lowercase 0x… address
+  name: string;
+  account: string; // account the synced transactions are recorded in
+  tokens: string[]; // ERC-20 contracts to sync; empty syncs every token
+  since?: string; // transfers before this are not synced
+  intervalHours: number;
+  active: boolean;
+  cursors: Record<string, number>; // per stream, the next block to read
+  nextSyncAt: string;
+  lastSyncAt?: string;
+  lastStatus?: "OK" | "FAILED";
+  lastError?: string;
+  lastCreated?: number; // transactions the last sync recorded
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// One entry of exchange history as a connector reports it; `ref` is
+// unique within the provider and keeps syncs from recording it twice
+export type ExchangeActivity =
+  | {
+      kind: "TRADE";
+      ref: string;
+      at: string;
+      base: string;
+      quote: string;
+      side: "BUY" | "SELL";
+      quantity: number; // base units
+      quoteQuantity: number;
+      fee: number;
+      feeAsset?: string;
+    }
+  | {
+      kind: "DEPOSIT" | "WITHDRAWAL";
+      ref: string;
+      at: string;
+      asset: string;
+      amount: number; // received, or sent net of the fee
+      fee: number; // network fee of a withdrawal, in `asset`
+      network?: string;
+      txId?: string;
+    }
+  | {
+      kind: "REWARD";
+      ref: string;
+      at: string;
+      asset: string;
+      amount: number;
+      note?: string;
+    };
+
+// Deleted or overwritten data kept for a while so it can be restored.
+// VAULT holds { vault, entries }; SETTINGS holds the previous key/values.
+export type TrashKind = "VAULT" | "SETTINGS";
+export interface TrashItem {
+  id: string;
+  kind: TrashKind;
+  label: string; // what was removed, e.g. the vault name
+  payload: any;
+  deletedAt: string;
+  expiresAt: string;
+}
+
 // Zod Schemas
 export const AssetSchema = z.object({
   type: z.enum(["CRYPTO", "FIAT"]),
@@ -183,7 +862,15 @@ export const IncomeExpenseSchema = z.object({
   category: z.string().optional(),
   tags: z.array(z.string()).optional(),
   counterparty: z.string().optional(),
+  member: z.string().max(100).optional(),
   dueDate: z.string().datetime().optional(),
+  reimbursable: z.boolean().optional(),
+  projectId: z.string().optional(),
+  card: z.string().optional(),
+  feeUSD: z.number().nonnegative().optional(),
+  place: z.string().optional(),
+  latitude: z.number().min(-90).max(90).optional(),
+  longitude: z.number().min(-180).max(180).optional(),
 });
 
 export const BorrowLoanSchema = z.object({
@@ -205,10 +892,23 @@ export const RepaySchema = z.object({
   at: z.string().datetime().optional(),
 });
 
+// Rewards received in kind; priceLocal is USD per unit when known
+export const RewardSchema = z.object({
+  asset: AssetSchema,
+  amount: z.number().positive(),
+  account: z.string().optional(),
+  counterparty: z.string().optional(),
+  note: z.string().optional(),
+  at: z.string().datetime().optional(),
+  priceLocal: z.number().positive().optional(),
+  sourceRef: z.string().optional(),
+});
+
 export type InitialRequest = z.infer<typeof InitialRequestSchema>;
 export type IncomeExpenseRequest = z.infer<typeof IncomeExpenseSchema>;
 export type BorrowLoanRequest = z.infer<typeof BorrowLoanSchema>;
 export type RepayRequest = z.infer<typeof RepaySchema>;
+export type RewardRequest = z.infer<typeof RewardSchema>;
 
 // Loan Schemas
 export const LoanCreateSchema = z.object({
@@ -241,6 +941,681 @@ export const BorrowingCreateSchema = z.object({
 });
 export type BorrowingCreateRequest = z.infer<typeof BorrowingCreateSchema>;
 
+// Spending exclusion rules (e.g. ignore tag=Reimbursable, counterparty=Employer)
+export const SpendingExclusionRuleSchema = z.object({
+  field: z.enum(["tag", "counterparty", "account", "note"]),
+  value: z.string().min(1),
+});
+export const SpendingExclusionRulesSchema = z.object({
+  rules: z.array(SpendingExclusionRuleSchema),
+});
+export type SpendingExclusionRule = z.infer<typeof SpendingExclusionRuleSchema>;
+
+// Transaction rules: when every condition matches a new transaction, the
+// actions apply. Text matches ignore case; amount compares asset units.
+const TEXT_OPS = ["contains", "equals", "starts_with"];
+const NUMBER_OPS = ["lt", "lte", "gt", "gte", "equals"];
+export const RuleConditionSchema = z
+  .object({
+    field: z.enum([
+      "counterparty",
+      "note",
+      "category",
+      "tag",
+      "account",
+      "asset",
+      "type",
+      "amount",
+    ]),
+    op: z.enum(["contains", "equals", "starts_with", "lt", "lte", "gt", "gte"]),
+    value: z.union([z.string().min(1), z.number()]),
+  })
+  .refine(
+    (c) =>
+      c.field === "amount"
+        ? typeof c.value === "number" && NUMBER_OPS.includes(c.op)
+        : typeof c.value === "string" && TEXT_OPS.includes(c.op),
+    {
+      message:
+        "amount takes a number with lt, lte, gt, gte or equals; other " +
+        "fields take text with contains, equals or starts_with",
+    },
+  );
+export const RuleActionSchema = z.discriminatedUnion("type", [
+  z.object({ type: z.literal("add_tag"), value: z.string().min(1) }),
+  z.object({ type: z.literal("set_category"), value: z.string().min(1) }),
+  z.object({ type: z.literal("set_counterparty"), value: z.string().min(1) }),
+  z.object({ type: z.literal("set_internal_flow"), value: z.boolean() }),
+]);
+export const TransactionRuleCreateSchema = z.object({
+  name: z.string().min(1),
+  conditions: z.array(RuleConditionSchema).min(1),
+  actions: z.array(RuleActionSchema).min(1),
+  priority: z.number().int().default(0), // lower runs first
+  enabled: z.boolean().default(true),
+});
+export const TransactionRuleUpdateSchema =
+  TransactionRuleCreateSchema.partial();
+export const RuleApplySchema = z.object({
+  dryRun: z.boolean().default(true),
+  ruleIds: z.array(z.string()).optional(), // default: every enabled rule
+});
+export type RuleCondition = z.infer<typeof RuleConditionSchema>;
+export type RuleAction = z.infer<typeof RuleActionSchema>;
+export type TransactionRuleCreateRequest = z.infer<
+  typeof TransactionRuleCreateSchema
+>;
+export type TransactionRuleUpdateRequest = z.infer<
+  typeof TransactionRuleUpdateSchema
+>;
+export interface TransactionRule extends TransactionRuleCreateRequest {
+  id: string;
+  createdAt: string;
+  updatedAt?: string;
+}
+
+// Cost basis method overall, per asset symbol and per vault (vault wins)
+const CostBasisMethodSchema = z.enum(["AVERAGE", "FIFO", "LIFO", "SPECIFIC"]);
+export const CostBasisSettingsSchema = z.object({
+  default: CostBasisMethodSchema.optional(),
+  byAsset: z.record(CostBasisMethodSchema).default({}),
+  byVault: z.record(CostBasisMethodSchema).default({}),
+});
+export type CostBasisSettings = z.infer<typeof CostBasisSettingsSchema>;
+
+// Fiscal year start as MM-DD. `starts` pins the start of given years for
+// calendars that move, e.g. { "2025": "2025-01-29" } to follow Tet. A
+// fiscal year is named by the calendar year it starts in.
+export const FiscalYearSettingsSchema = z.object({
+  start: z
+    .string()
+    .regex(/^(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])$/, "start must be MM-DD")
+    .default("01-01"),
+  starts: z
+    .record(z.string().regex(/^\d{4}-\d{2}-\d{2}$/, "must be YYYY-MM-DD"))
+    .default({})
+    .refine(
+      (s) =>
+        Object.entries(s).every(
+          ([year, day]) =>
+            /^\d{4}$/.test(year) &&
+            day.startsWith(`${year}-`) &&
+            !Number.isNaN(new Date(`${day}T00:00:00.000Z`).getTime()),
+        ),
+      "starts maps a year to a date in that year",
+    ),
+});
+export type FiscalYearSettings = z.infer<typeof FiscalYearSettingsSchema>;
+
+// One bracket of a progressive tax: the rate on annual taxable income (VND)
+// up to `upTo`; the top bracket has no cap
+export const TaxBracketSchema = z.object({
+  upTo: z.number().positive().nullable(),
+  ratePercent: z.number().min(0).max(100),
+});
+export type TaxBracket = z.infer<typeof TaxBracketSchema>;
+
+// Vietnam's personal income tax on employment income, annualized: 5% to
+// 35% over seven brackets, after 11M VND a month for the taxpayer and
+// 4.4M per dependent
+export const VN_PIT_BRACKETS: TaxBracket[] = [
+  { upTo: 60_000_000, ratePercent: 5 },
+  { upTo: 120_000_000, ratePercent: 10 },
+  { upTo: 216_000_000, ratePercent: 15 },
+  { upTo: 384_000_000, ratePercent: 20 },
+  { upTo: 624_000_000, ratePercent: 25 },
+  { upTo: 960_000_000, ratePercent: 30 },
+  { upTo: null, ratePercent: 35 },
+];
+
+// Income tax estimate. Income is INCOME that is not investment income or
+// a refund, limited to `incomeTags` when given; tax paid is EXPENSE
+// tagged `taxTag` (withholding or settlement)
+export const IncomeTaxSettingsSchema = z.object({
+  brackets: z
+    .array(TaxBracketSchema)
+    .min(1)
+    .default(VN_PIT_BRACKETS)
+    .refine(
+      (b) =>
+        b.every(
+          (x, i) =>
+            (x.upTo === null) === (i === b.length - 1) &&
+            (i === 0 || x.upTo === null || x.upTo > b[i - 1].upTo!),
+        ),
+      "brackets rise by upTo and only the last is uncapped (upTo: null)",
+    ),
+  personalDeduction: z.number().min(0).default(132_000_000),
+  dependentDeduction: z.number().min(0).default(52_800_000),
+  dependents: z.number().int().min(0).default(0),
+  incomeTags: z.array(z.string().min(1)).default([]),
+  taxTag: z.string().min(1).default("tax"),
+});
+export type IncomeTaxSettings = z.infer<typeof IncomeTaxSettingsSchema>;
+
+export const LotSelectionSchema = z.object({
+  lot: z.string().min(1),
+  amount: z.number().positive(),
+});
+
+// Project Schemas
+export const ProjectCreateSchema = z.object({
+  name: z.string().min(1),
+  kind: z.enum(["TRIP", "PROJECT"]).default("TRIP"),
+  startAt: z.string().datetime().optional(),
+  endAt: z.string().datetime().optional(),
+  note: z.string().optional(),
+});
+export const ProjectUpdateSchema = ProjectCreateSchema.partial().extend({
+  status: z.enum(["ACTIVE", "CLOSED"]).optional(),
+});
+export const ProjectAssignSchema = z.object({
+  transaction_ids: z.array(z.string().min(1)).min(1),
+});
+export type ProjectCreateRequest = z.infer<typeof ProjectCreateSchema>;
+export type ProjectUpdateRequest = z.infer<typeof ProjectUpdateSchema>;
+
+// Registry Schemas
+export const RegistryCreateSchema = z.object({
+  kind: z.enum(["SUBSCRIPTION", "WARRANTY"]),
+  name: z.string().min(1).optional(),
+  transactionId: z.string().optional(),
+  asset: AssetSchema.optional(),
+  amount: z.number().positive().optional(),
+  cadence: z.enum(["WEEKLY", "MONTHLY", "QUARTERLY", "YEARLY"]).optional(),
+  nextRenewalAt: z.string().datetime().optional(),
+  cancelBy: z.string().datetime().optional(),
+  warrantyExpiresAt: z.string().datetime().optional(),
+  note: z.string().optional(),
+});
+export const RegistryUpdateSchema = RegistryCreateSchema.omit({ kind: true })
+  .partial()
+  .extend({
+    status: z.enum(["ACTIVE", "CANCELLED", "EXPIRED"]).optional(),
+  });
+export type RegistryCreateRequest = z.infer<typeof RegistryCreateSchema>;
+export type RegistryUpdateRequest = z.infer<typeof RegistryUpdateSchema>;
+
+// Fixed income schemas
+export const FixedIncomeCreateSchema = z.object({
+  kind: z.enum(["TERM_DEPOSIT", "BOND"]),
+  name: z.string().min(1),
+  issuer: z.string().optional(),
+  asset: AssetSchema,
+  faceValue: z.number().positive(),
+  couponRate: z.number().min(0),
+  couponFrequency: z
+    .enum(["MONTHLY", "QUARTERLY", "SEMIANNUAL", "ANNUAL", "AT_MATURITY"])
+    .default("AT_MATURITY"),
+  startAt: z.string().datetime(),
+  maturityAt: z.string().datetime(),
+  account: z.string().optional(),
+  note: z.string().optional(),
+});
+export const FixedIncomeUpdateSchema = z.object({
+  name: z.string().min(1).optional(),
+  issuer: z.string().optional(),
+  account: z.string().optional(),
+  note: z.string().optional(),
+  status: z.enum(["ACTIVE", "MATURED", "CLOSED"]).optional(),
+});
+export type FixedIncomeCreateRequest = z.infer<typeof FixedIncomeCreateSchema>;
+export type FixedIncomeUpdateRequest = z.infer<typeof FixedIncomeUpdateSchema>;
+
+// Options schemas
+export const OptionOpenSchema = z.object({
+  underlying: AssetSchema,
+  optionType: z.enum(["CALL", "PUT"]),
+  side: z.enum(["LONG", "SHORT"]),
+  contracts: z.number().positive(),
+  multiplier: z.number().positive().default(1),
+  strike: z.number().positive(),
+  expiry: z.string().datetime(),
+  premium: z.number().min(0),
+  premiumAsset: AssetSchema.default({ type: "FIAT", symbol: "USD" }),
+  account: z.string().min(1),
+  at: z.string().datetime().optional(),
+  note: z.string().optional(),
+});
+export const OptionSettleSchema = z.object({
+  settlementPrice: z.number().positive().optional(),
+  at: z.string().datetime().optional(),
+});
+export type OptionOpenRequest = z.infer<typeof OptionOpenSchema>;
+export type OptionSettleRequest = z.infer<typeof OptionSettleSchema>;
+
+// Vesting schemas
+export const VestingGrantCreateSchema = z.object({
+  kind: z.enum(["RSU", "ESPP"]),
+  employer: z.string().min(1),
+  asset: AssetSchema,
+  cashAsset: AssetSchema.default({ type: "FIAT", symbol: "USD" }),
+  account: z.string().min(1),
+  totalUnits: z.number().min(0).default(0),
+  grantAt: z.string().datetime(),
+  vestStartAt: z.string().datetime().optional(),
+  cliffMonths: z.number().int().min(0).default(12),
+  vestingMonths: z.number().int().positive().default(48),
+  frequencyMonths: z.number().int().positive().default(3),
+  withholdingRate: z.number().min(0).max(1).default(0),
+  discount: z.number().min(0).max(1).default(0),
+  note: z.string().optional(),
+});
+export const VestEventCreateSchema = z.object({
+  vestAt: z.string().datetime(),
+  units: z.number().positive(),
+  fmv: z.number().positive().optional(),
+  purchasePrice: z.number().min(0).optional(),
+});
+export type VestingGrantCreateRequest = z.infer<
+  typeof VestingGrantCreateSchema
+>;
+export type VestEventCreateRequest = z.infer<typeof VestEventCreateSchema>;
+
+// Address book schemas
+export const AddressBookCreateSchema = z.object({
+  name: z.string().min(1),
+  kind: z
+    .enum(["EXCHANGE_DEPOSIT", "BANK_ACCOUNT", "WALLET", "OTHER"])
+    .default("OTHER"),
+  address: z.string().min(1),
+  owner: z.string().optional(),
+  chain: z.string().optional(),
+  asset: z.string().optional(),
+  account: z.string().optional(),
+  note: z.string().optional(),
+});
+export const AddressBookUpdateSchema = AddressBookCreateSchema.partial();
+export type AddressBookCreateRequest = z.infer<typeof AddressBookCreateSchema>;
+export type AddressBookUpdateRequest = z.infer<typeof AddressBookUpdateSchema>;
+
+// Allocation glidepath schemas
+const percentSchema = z.number().min(0).max(100);
+export const GlidepathCreateSchema = z.object({
+  assetClass: z.enum(["FIAT", "STABLECOIN", "CRYPTO", "EQUITY", "OTHER"]),
+  startPercent: percentSchema,
+  endPercent: percentSchema.optional(), // defaults to startPercent
+  startAt: z.string().optional(), // defaults to now
+  endAt: z.string().optional(), // defaults to startAt
+  note: z.string().optional(),
+});
+export const GlidepathUpdateSchema = GlidepathCreateSchema.partial();
+export type GlidepathCreateRequest = z.infer<typeof GlidepathCreateSchema>;
+export type GlidepathUpdateRequest = z.infer<typeof GlidepathUpdateSchema>;
+
+// Allocation constraints: hard limits on the current mix. ASSET limits
+// one symbol, or every non-cash asset when symbol is omitted; ASSET_CLASS
+// limits a class, where CASH is FIAT and STABLECOIN together
+export const AllocationConstraintSchema = z
+  .object({
+    scope: z.enum(["ASSET", "ASSET_CLASS"]),
+    symbol: z.string().min(1).optional(),
+    assetClass: z
+      .enum(["FIAT", "STABLECOIN", "CRYPTO", "EQUITY", "OTHER", "CASH"])
+      .optional(),
+    minPercent: percentSchema.optional(),
+    maxPercent: percentSchema.optional(),
+    note: z.string().optional(),
+  })
+  .refine((c) => c.minPercent !== undefined || c.maxPercent !== undefined, {
+    message: "minPercent or maxPercent is required",
+  })
+  .refine((c) => (c.minPercent ?? 0) <= (c.maxPercent ?? 100), {
+    message: "minPercent must not be above maxPercent",
+  })
+  .refine((c) => c.scope === "ASSET" || c.assetClass !== undefined, {
+    message: "assetClass is required for ASSET_CLASS constraints",
+  });
+export const AllocationConstraintsSchema = z.object({
+  constraints: z.array(AllocationConstraintSchema),
+});
+export type AllocationConstraint = z.infer<typeof AllocationConstraintSchema>;
+
+// Deposit rate schemas
+export const DepositRateCreateSchema = z.object({
+  effectiveDate: z.string().regex(/^\d{4}-\d{2}-\d{2}$/, "use YYYY-MM-DD"),
+  termMonths: z.number().int().positive().default(12),
+  ratePercent: percentSchema,
+  bank: z.string().optional(),
+  note: z.string().optional(),
+});
+export const DepositRateUpdateSchema = DepositRateCreateSchema.partial();
+export type DepositRateCreateRequest = z.infer<typeof DepositRateCreateSchema>;
+export type DepositRateUpdateRequest = z.infer<typeof DepositRateUpdateSchema>;
+
+// Transaction import schemas. Columns map a field to a header in the file;
+// amount is signed (negative = expense) unless debit/credit are mapped.
+export const ImportProfileSchema = z.object({
+  columns: z
+    .object({
+      date: z.string().min(1),
+      amount: z.string().optional(),
+      debit: z.string().optional(),
+      credit: z.string().optional(),
+      type: z.string().optional(), // INCOME or EXPENSE
+      asset: z.string().optional(),
+      account: z.string().optional(),
+      note: z.string().optional(),
+      category: z.string().optional(),
+      counterparty: z.string().optional(),
+      member: z.string().optional(), // household member
+      tags: z.string().optional(), // comma or semicolon separated
+      reference: z.string().optional(), // stored as sourceRef
+    })
+    .refine((c) => c.amount || c.debit || c.credit, {
+      message: "map amount or debit/credit",
+    }),
+  dateFormat: z
+    .enum(["YYYY-MM-DD", "DD/MM/YYYY", "MM/DD/YYYY"])
+    .default("YYYY-MM-DD"),
+  decimalSeparator: z.enum([".", ","]).default("."),
+  delimiter: z.string().length(1).default(","), // CSV only
+  sheet: z.string().optional(), // XLSX only; defaults to the first sheet
+  headerRow: z.number().int().positive().default(1),
+  asset: z.string().optional(), // no asset column; else account's default
+  account: z.string().optional(), // when there is no account column
+});
+const TableImportSchema = z.object({
+  format: z.enum(["csv", "xlsx"]),
+  content: z.string().min(1), // CSV text or base64-encoded XLSX
+  profile: ImportProfileSchema,
+  dryRun: z.boolean().optional(),
+});
+// OFX/QIF bank exports; the format fixes the fields, so no profile
+const StatementImportSchema = z.object({
+  format: z.enum(["ofx", "qif"]),
+  content: z.string().min(1), // file text as the bank exported it
+  account: z.string().optional(), // else Spend/Income by direction
+  asset: z.string().optional(), // else OFX CURDEF or account's default
+  dateFormat: z.enum(["DD/MM/YYYY", "MM/DD/YYYY"]).default("MM/DD/YYYY"),
+  applySuggestedTags: z.boolean().optional(),
+  dryRun: z.boolean().optional(),
+});
+export const TransactionImportSchema = z.discriminatedUnion("format", [
+  TableImportSchema,
+  StatementImportSchema,
+]);
+export type ImportProfile = z.infer<typeof ImportProfileSchema>;
+export type TransactionImportRequest = z.infer<typeof TransactionImportSchema>;
+export type TableImportRequest = z.infer<typeof TableImportSchema>;
+export type StatementImportRequest = z.infer<typeof StatementImportSchema>;
+
+// Report subscription schemas
+const reportPeriodSchema = z.enum([
+  "none",
+  "last_7_days",
+  "last_30_days",
+  "this_month",
+  "last_month",
+  "year_to_date",
+  "last_year",
+]);
+const reportCadenceSchema = z.enum([
+  "WEEKLY",
+  "MONTHLY",
+  "QUARTERLY",
+  "YEARLY",
+]);
+export const ReportSubscriptionCreateSchema = z.object({
+  name: z.string().min(1),
+  report: z.string().min(1),
+  periodMode: reportPeriodSchema.default("none"),
+  filters: z.record(z.string()).optional(),
+  currency: z.enum(["USD", "VND"]).default("USD"),
+  cadence: reportCadenceSchema,
+  nextRunAt: z.string().optional(), // defaults to now
+  active: z.boolean().default(true),
+});
+export const ReportSubscriptionUpdateSchema = z.object({
+  name: z.string().min(1).optional(),
+  report: z.string().min(1).optional(),
+  periodMode: reportPeriodSchema.optional(),
+  filters: z.record(z.string()).optional(),
+  currency: z.enum(["USD", "VND"]).optional(),
+  cadence: reportCadenceSchema.optional(),
+  nextRunAt: z.string().optional(),
+  active: z.boolean().optional(),
+});
+export type ReportSubscriptionCreateRequest = z.infer<
+  typeof ReportSubscriptionCreateSchema
+>;
+export type ReportSubscriptionUpdateRequest = z.infer<
+  typeof ReportSubscriptionUpdateSchema
+>;
+
+// Recurring transaction schemas
+export const RecurringCreateSchema = z.object({
+  name: z.string().min(1),
+  type: z.enum(["INCOME", "EXPENSE"]),
+  asset: AssetSchema,
+  amount: z.number().positive(),
+  account: z.string().optional(),
+  note: z.string().optional(),
+  category: z.string().optional(),
+  tags: z.array(z.string()).optional(),
+  counterparty: z.string().optional(),
+  rule: z.string().min(1),
+  startAt: z.string().optional(), // defaults to now
+  confirm: z.boolean().default(false),
+  active: z.boolean().default(true),
+});
+// Omitted fields are left as they are; defaults only apply on create
+export const RecurringUpdateSchema = RecurringCreateSchema.partial();
+export type RecurringCreateRequest = z.infer<typeof RecurringCreateSchema>;
+export type RecurringUpdateRequest = z.infer<typeof RecurringUpdateSchema>;
+
+// Budget schemas
+export const BudgetCreateSchema = z.object({
+  tag: z.string().min(1),
+  amount: z.number().positive(),
+  currency: z.enum(["USD", "VND"]).default("USD"),
+  note: z.string().optional(),
+  active: z.boolean().default(true),
+});
+export const BudgetUpdateSchema = BudgetCreateSchema.partial();
+export type BudgetCreateRequest = z.infer<typeof BudgetCreateSchema>;
+export type BudgetUpdateRequest = z.infer<typeof BudgetUpdateSchema>;
+
+// Savings goal schemas
+export const GoalCreateSchema = z.object({
+  name: z.string().min(1),
+  target_amount: z.number().positive(),
+  currency: z.enum(["USD", "VND"]).default("USD"),
+  target_date: z
+    .string()
+    .regex(/^\d{4}-\d{2}-\d{2}$/, "target_date must be YYYY-MM-DD")
+    .optional(),
+  accounts: z.array(z.string().min(1)).default([]),
+  tags: z.array(z.string().min(1)).default([]),
+  note: z.string().optional(),
+  active: z.boolean().default(true),
+});
+export const GoalUpdateSchema = GoalCreateSchema.partial();
+export type GoalCreateRequest = z.infer<typeof GoalCreateSchema>;
+export type GoalUpdateRequest = z.infer<typeof GoalUpdateSchema>;
+
+// Monthly review schemas
+export const MonthReviewMarkSchema = z.object({
+  ids: z.array(z.string().min(1)).min(1),
+});
+export const MonthReviewCompleteSchema = z.object({
+  note: z.string().optional(),
+});
+
+// Consistency check schemas
+export const ConsistencyFixSchema = z.object({
+  fix: z.array(z.enum(CONSISTENCY_ISSUE_KINDS)).min(1),
+});
+
+// Throttling of a bulk recalculation: transactions per batch and the
+// pause between batches
+export const RecalcStartSchema = z.object({
+  batch_size: z.number().int().min(1).max(5000).default(500),
+  pause_ms: z.number().int().min(0).max(10000).default(50),
+});
+
+// Webhook schemas
+export const WebhookCreateSchema = z.object({
+  url: z.string().url(),
+  events: z.array(z.string().min(1)).min(1).default(["*"]),
+  secret: z.string().min(16).optional(),
+  description: z.string().optional(),
+  active: z.boolean().default(true),
+});
+export const WebhookUpdateSchema = WebhookCreateSchema.partial();
+export type WebhookCreateRequest = z.infer<typeof WebhookCreateSchema>;
+export type WebhookUpdateRequest = z.infer<typeof WebhookUpdateSchema>;
+
+// `credentials` is the service account's JSON key; it may be left out
+// when updating an export that already has one
+export const SheetsExportConfigSchema = z.object({
+  spreadsheetId: z.string().trim().min(1),
+  credentials: z
+    .object({
+      client_email: z.string().email(),
+      private_key: z.string().min(1),
+    })
+    .passthrough()
+    .optional(),
+  holdingsSheet: z.string().trim().min(1).default("Holdings"),
+  summarySheet: z.string().trim().min(1).default("Monthly"),
+  months: z.number().int().min(1).max(120).default(12),
+  currency: z.enum(["USD", "VND"]).default("USD"),
+  intervalHours: z.number().int().min(1).max(744).default(24),
+  active: z.boolean().default(true),
+});
+export type SheetsExportConfigRequest = z.infer<
+  typeof SheetsExportConfigSchema
+>;
+
+// The keys must be read-only; an update may leave them out
+export const ConnectorCreateSchema = z.object({
+  provider: z.enum(CONNECTOR_PROVIDERS),
+  name: z.string().trim().min(1).max(100),
+  account: z.string().trim().min(1).optional(), // defaults to the name
+  apiKey: z.string().trim().min(1),
+  apiSecret: z.string().trim().min(1),
+  pairs: z
+    .array(
+      z
+        .string()
+        .trim()
+        .toUpperCase()
+        .regex(/^[A-Z0-9]+\/[A-Z0-9]+$/, "pairs look like BTC/USDT"),
+    )
+    .max(100)
+    .default([]),
+  since: z.string().datetime().optional(), // defaults to 90 days ago
+  intervalHours: z.number().int().min(1).max(744).default(6),
+  active: z.boolean().default(true),
+});
+export const ConnectorUpdateSchema = ConnectorCreateSchema.omit({
+  provider: true,
+}).partial();
+export type ConnectorCreateRequest = z.infer<typeof ConnectorCreateSchema>;
+export type ConnectorUpdateRequest = z.infer<typeof ConnectorUpdateSchema>;
+
+const evmAddress = z
+  .string()
+  .trim()
+  .regex(/^0x[0-9a-fA-F]{40}$/, "addresses look like 0x followed by 40 hex")
+  .transform((a) => a.toLowerCase());
+
+// The chain and address identify a wallet and cannot be changed
+export const WalletCreateSchema = z.object({
+  chain: z.enum(EVM_CHAINS),
+  address: evmAddress,
+  name: z.string().trim().min(1).max(100),
+  account: z.string().trim().min(1).optional(), // defaults to the name
+  tokens: z.array(evmAddress).max(200).default([]),
+  since: z.string().datetime().optional(), // defaults to the first block
+  intervalHours: z.number().int().min(1).max(744).default(6),
+  active: z.boolean().default(true),
+});
+export const WalletUpdateSchema = WalletCreateSchema.omit({
+  chain: true,
+  address: true,
+}).partial();
+export type WalletCreateRequest = z.infer<typeof WalletCreateSchema>;
+export type WalletUpdateRequest = z.infer<typeof WalletUpdateSchema>;
+
+// Batch price quotes
+export const PriceBatchSchema = z.object({
+  quotes: z
+    .array(
+      z.object({
+        asset: z.union([z.string().min(1), AssetSchema]),
+        currency: z.string().min(1).default("USD"),
+        date: z.string().optional(), // YYYY-MM-DD or ISO; omitted = latest
+      }),
+    )
+    .min(1)
+    .max(500),
+});
+export type PriceBatchRequest = z.infer<typeof PriceBatchSchema>;
+
+export const ManualPriceSchema = z.object({
+  symbol: z.string().trim().min(1).max(20),
+  price_usd: z.number().positive(),
+  at: z.string().datetime().optional(), // defaults to now
+  note: z.string().max(200).optional(),
+});
+export type ManualPriceRequest = z.infer<typeof ManualPriceSchema>;
+
+// Empty `sources` clears the override and restores the default order
+export const PriceSourcePrioritySchema = z.object({
+  symbol: z.string().trim().min(1),
+  sources: z.array(z.enum(PRICE_PROVIDERS)).max(PRICE_PROVIDERS.length),
+});
+
+export const ReimbursementMatchSchema = z.object({
+  income_id: z.string().min(1),
+});
+
+// Two-phase action schemas
+export const ActionPrepareSchema = z.object({
+  action: z.string().trim().min(1),
+  params: z.record(z.unknown()).default({}),
+  client_ref: z.string().trim().min(1).max(200).optional(),
+  expires_in_seconds: z
+    .number()
+    .int()
+    .positive()
+    .max(7 * 24 * 60 * 60)
+    .optional(), // default 15 minutes
+});
+export type ActionPrepareRequest = z.infer<typeof ActionPrepareSchema>;
+
+// `params` override the prepared ones, e.g. with the fill price
+export const ActionConfirmSchema = z.object({
+  params: z.record(z.unknown()).optional(),
+  external_ref: z.string().trim().min(1).max(200).optional(),
+});
+export type ActionConfirmRequest = z.infer<typeof ActionConfirmSchema>;
+
+export const ActionCancelSchema = z.object({
+  reason: z.string().max(500).optional(),
+});
+
 export function assetKey(a: Asset): string {
   return `${a.type}:${a.symbol.toUpperCase()}`;
 }
+
+// Units a transaction adds to (or takes from) its account
+export function unitsIn(t: Transaction): number {
+  const n = Number(t.amount) || 0;
+  switch (t.type) {
+    case "INITIAL":
+    case "INCOME":
+    case "TRANSFER_IN":
+    case "BORROW":
+      return n;
+    case "EXPENSE":
+    case "TRANSFER_OUT":
+    case "LOAN":
+      return -n;
+    case "REPAY":
+      return String(t.direction).toUpperCase() === "LOAN" ? n : -n;
+  }
+  return 0;
+}
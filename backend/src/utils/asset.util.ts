@@ -1,8 +1,9 @@
-import { Asset } from "../types";
+import { Asset, AssetKind } from "../types";
+import { adminRepository } from "../repositories";
 
 /**
  * Set of known crypto asset symbols.
- * This list should be kept in sync with the cryptoIdForSymbol mapping in price.service.ts
+ * This list should be kept in sync with the cryptoIdForSymbol mapping in price-provider.service.ts
  */
 const CRYPTO_SET = new Set([
   "BTC",
@@ -24,17 +25,93 @@ const CRYPTO_SET = new Set([
   "XAG", // Silver (using PAXG as fallback)
 ]);
 
+const STABLECOIN_SET = new Set(["USDT", "USDC", "DAI", "BUSD", "FDUSD"]);
+
+// Common stock and ETF tickers; others are registered with kind EQUITY
+const EQUITY_SET = new Set([
+  "AAPL",
+  "MSFT",
+  "NVDA",
+  "GOOGL",
+  "AMZN",
+  "TSLA",
+  "SPY",
+  "VOO",
+  "VTI",
+  "QQQ",
+  "VNQ",
+  "VT",
+]);
+
 /**
- * Determines if a symbol represents a crypto asset.
+ * Classifies a symbol that isn't in the admin assets table.
  *
  * Logic:
+ * - Known stablecoins are STABLECOIN
+ * - Known stock/ETF tickers are EQUITY
  * - If the symbol is in the known crypto set, it's crypto
- * - If the symbol has more than 3 characters, it's crypto (e.g., USDT, MATIC)
+ * - If the symbol has more than 3 characters, it's crypto (e.g., MATIC)
  * - Otherwise, it's assumed to be a FIAT currency (e.g., USD, EUR, VND)
  */
-export function isCryptoSymbol(symbol: string): boolean {
+export function defaultAssetKind(symbol: string): AssetKind {
   const sym = symbol.toUpperCase();
-  return CRYPTO_SET.has(sym) || sym.length > 3;
+  if (STABLECOIN_SET.has(sym)) return "STABLECOIN";
+  if (EQUITY_SET.has(sym)) return "EQUITY";
+  return CRYPTO_SET.has(sym) || sym.length > 3 ? "CRYPTO" : "FIAT";
+}
+
+// Kinds set in the admin assets table by symbol, read once; asset writes
+// drop it (see invalidateAssetKinds)
+let kinds: Map<string, AssetKind> | undefined;
+
+function registeredKinds(): Map<string, AssetKind> {
+  if (!kinds) {
+    const bySymbol = new Map<string, AssetKind>();
+    for (const a of adminRepository.findAllAssets()) {
+      if (a.kind) bySymbol.set(a.symbol.toUpperCase(), a.kind);
+    }
+    kinds = bySymbol;
+  }
+  return kinds;
+}
+
+/** Forgets the registered kinds, after a write to the admin assets. */
+export function invalidateAssetKinds(): void {
+  kinds = undefined;
+}
+
+/**
+ * Asset kind from the admin assets table, so new tokens can be classified
+ * without a code change; falls back to defaultAssetKind.
+ */
+export function getAssetKind(symbol: string): AssetKind {
+  const sym = symbol.toUpperCase();
+  try {
+    const kind = registeredKinds().get(sym);
+    if (kind) return kind;
+  } catch {
+    // registry unavailable; classify from the symbol
+  }
+  return defaultAssetKind(sym);
+}
+
+/** Built-in stablecoins plus any registered with kind STABLECOIN. */
+export function stablecoinSymbols(): string[] {
+  const out = new Set(STABLECOIN_SET);
+  try {
+    for (const [sym, kind] of registeredKinds()) {
+      if (kind === "STABLECOIN") out.add(sym);
+      else out.delete(sym);
+    }
+  } catch {
+    // registry unavailable; built-in list only
+  }
+  return Array.from(out);
+}
+
+/** Determines if a symbol represents a non-fiat asset. */
+export function isCryptoSymbol(symbol: string): boolean {
+  return getAssetKind(symbol) !== "FIAT";
 }
 
 /**
@@ -0,0 +1,19 @@
+import { AsyncLocalStorage } from "async_hooks";
+
+/**
+ * Who is making the writes of the current request, for the audit log.
+ * Requests name themselves with the X-Nami-User header; writes outside a
+ * request (schedulers, startup) are the system's.
+ */
+export const SYSTEM_ACTOR = "system";
+
+const storage = new AsyncLocalStorage<string>();
+
+/** Run `fn` (and everything it awaits) on behalf of `actor`. */
+export function withActor<T>(actor: string, fn: () => T): T {
+  return storage.run(actor, fn);
+}
+
+export function currentActor(): string {
+  return storage.getStore() ?? SYSTEM_ACTOR;
+}
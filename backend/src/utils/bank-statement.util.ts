@@ -0,0 +1,128 @@
+/** One entry of an OFX or QIF bank statement. */
+export interface StatementEntry {
+  date?: string; // ISO at 00:00 UTC; undefined when unreadable
+  rawDate: string;
+  amount: number; // signed: negative leaves the account
+  payee?: string;
+  memo?: string;
+  category?: string; // QIF L field
+  fitid?: string; // OFX transaction id, unique per bank account
+  reference?: string; // check or reference number
+  currency?: string; // OFX statement currency (CURDEF)
+}
+
+export type QifDateFormat = "DD/MM/YYYY" | "MM/DD/YYYY";
+
+function utcDate(y: number, m: number, d: number): string | undefined {
+  const at = new Date(Date.UTC(y, m - 1, d));
+  return at.getUTCMonth() === m - 1 && at.getUTCDate() === d
+    ? at.toISOString()
+    : undefined;
+}
+
+// Accepts 1,234.56 and -1234.56; banks do not localise OFX/QIF amounts
+function parseAmount(s: string): number {
+  const n = Number(s.replace(/[\s,]/g, ""));
+  return s.trim() ? n : NaN;
+}
+
+const field = (block: string, tag: string): string | undefined => {
+  // OFX 1.x (SGML) leaves leaf tags unclosed; 2.x (XML) closes them
+  const m = new RegExp(`<${tag}>([^<\\r\\n]*)`, "i").exec(block);
+  const v = m?.[1].trim();
+  return v ? decodeEntities(v) : undefined;
+};
+
+function decodeEntities(s: string): string {
+  return s
+    .replace(/&lt;/g, "<")
+    .replace(/&gt;/g, ">")
+    .replace(/&quot;/g, '"')
+    .replace(/&apos;/g, "'")
+    .replace(/&amp;/g, "&");
+}
+
+/**
+ * Parse the bank and credit card transactions of an OFX file, 1.x (SGML)
+ * or 2.x (XML). DTPOSTED keeps its date only, since banks disagree on the
+ * time zone of the time part.
+ */
+export function parseOfx(text: string): StatementEntry[] {
+  const entries: StatementEntry[] = [];
+  const statements = text.split(/<\/?(?:STMTRS|CCSTMTRS)>/i);
+  for (const stmt of statements) {
+    const currency = field(stmt, "CURDEF");
+    const blocks = stmt.match(/<STMTTRN>[\s\S]*?<\/STMTTRN>/gi) || [];
+    for (const block of blocks) {
+      const rawDate = field(block, "DTPOSTED") || "";
+      const d = /^(\d{4})(\d{2})(\d{2})/.exec(rawDate);
+      entries.push({
+        date: d ? utcDate(+d[1], +d[2], +d[3]) : undefined,
+        rawDate,
+        amount: parseAmount(field(block, "TRNAMT") || ""),
+        payee: field(block, "NAME") || field(block, "PAYEE"),
+        memo: field(block, "MEMO"),
+        fitid: field(block, "FITID"),
+        reference: field(block, "CHECKNUM") || field(block, "REFNUM"),
+        currency,
+      });
+    }
+  }
+  return entries;
+}
+
+// QIF dates: 01/31/2025, 1/31/25, 1/31'25 (Quicken's 2000s), or ISO
+function parseQifDate(s: string, format: QifDateFormat): string | undefined {
+  const iso = /^(\d{4})-(\d{2})-(\d{2})$/.exec(s);
+  if (iso) return utcDate(+iso[1], +iso[2], +iso[3]);
+  const m = /^(\d{1,2})[/.-](\d{1,2})(?:[/.-]|')\s*(\d{2}|\d{4})$/.exec(s);
+  if (!m) return undefined;
+  const [a, b] = [+m[1], +m[2]];
+  const y = m[3].length === 2 ? 2000 + +m[3] : +m[3];
+  return format === "DD/MM/YYYY" ? utcDate(y, b, a) : utcDate(y, a, b);
+}
+
+/**
+ * Parse the transactions of a QIF file. Investment and split details are
+ * ignored; a transfer category such as [Savings] is dropped, since the
+ * other side is imported from its own account.
+ */
+export function parseQif(
+  text: string,
+  format: QifDateFormat = "MM/DD/YYYY",
+): StatementEntry[] {
+  const entries: StatementEntry[] = [];
+  let cur: Partial<StatementEntry> = {};
+  let started = false;
+  for (const raw of text.replace(/^\uFEFF/, "").split(/\r?\n/)) {
+    const line = raw.trim();
+    if (!line || line.startsWith("!")) continue;
+    const code = line[0];
+    const value = line.slice(1).trim();
+    if (code === "^") {
+      if (started) {
+        entries.push({ rawDate: "", amount: NaN, ...cur } as StatementEntry);
+      }
+      cur = {};
+      started = false;
+      continue;
+    }
+    started = true;
+    if (code === "D") {
+      cur.rawDate = value;
+      cur.date = parseQifDate(value, format);
+    } else if (code === "T" || (code === "U" && cur.amount === undefined)) {
+      cur.amount = parseAmount(value);
+    } else if (code === "P") cur.payee = value || undefined;
+    else if (code === "M") cur.memo = value || undefined;
+    else if (code === "N") cur.reference = value || undefined;
+    else if (code === "L" && !/^\[.*\]$/.test(value)) {
+      cur.category = value || undefined;
+    }
+  }
+  // Some exporters omit the final ^
+  if (started) {
+    entries.push({ rawDate: "", amount: NaN, ...cur } as StatementEntry);
+  }
+  return entries;
+}
@@ -0,0 +1,60 @@
+/**
+ * Parse CSV text into rows of cells (RFC 4180: quoted cells may hold the
+ * delimiter, newlines and "" for a quote). A leading BOM is dropped.
+ */
+export function parseCsv(text: string, delimiter = ","): string[][] {
+  const rows: string[][] = [];
+  let row: string[] = [];
+  let cell = "";
+  let quoted = false;
+  const src = text.replace(/^\uFEFF/, "");
+
+  for (let i = 0; i < src.length; i++) {
+    const c = src[i];
+    if (quoted) {
+      if (c === '"' && src[i + 1] === '"') {
+        cell += '"';
+        i++;
+      } else if (c === '"') {
+        quoted = false;
+      } else {
+        cell += c;
+      }
+    } else if (c === '"' && cell === "") {
+      quoted = true;
+    } else if (c === delimiter) {
+      row.push(cell);
+      cell = "";
+    } else if (c === "\n" || c === "\r") {
+      if (c === "\r" && src[i + 1] === "\n") i++;
+      row.push(cell);
+      rows.push(row);
+      row = [];
+      cell = "";
+    } else {
+      cell += c;
+    }
+  }
+  if (cell !== "" || row.length) {
+    row.push(cell);
+    rows.push(row);
+  }
+  return rows;
+}
+
+// Quote cells holding the delimiter, a quote or a line break
+function csvCell(value: unknown, delimiter: string): string {
+  const s = value === undefined || value === null ? "" : String(value);
+  return s.includes(delimiter) || /["\r\n]/.test(s)
+    ? `"${s.replace(/"/g, '""')}"`
+    : s;
+}
+
+/** Render rows as CSV text (RFC 4180), one line per row. */
+export function toCsv(rows: unknown[][], delimiter = ","): string {
+  return (
+    rows
+      .map((r) => r.map((c) => csvCell(c, delimiter)).join(delimiter))
+      .join("\r\n") + "\r\n"
+  );
+}
@@ -0,0 +1,91 @@
+/**
+ * Report figures in a reporting currency other than USD and VND. Reports
+ * give every amount as <name>_usd (and most as <name>_vnd); these helpers
+ * add a <name>_<code> figure beside each USD one, converted at the FX rate
+ * of the row's date, so a new currency needs no new columns.
+ */
+
+export const ISO_CURRENCY = /^[A-Z]{3}$/;
+
+// Currencies every report already has columns for
+export const BUILTIN_REPORT_CURRENCIES = ["USD", "VND"];
+
+const USD_KEY = /_usd$/i;
+
+function isPlainObject(data: unknown): data is Record<string, unknown> {
+  if (!data || typeof data !== "object" || Array.isArray(data)) return false;
+  const proto = Object.getPrototypeOf(data);
+  return proto === Object.prototype || proto === null;
+}
+
+// Day a row is dated by its `date` (YYYY-MM-DD...) or `month` (YYYY-MM),
+// whose first day stands for the month
+function rowDay(obj: Record<string, unknown>): string | undefined {
+  if (typeof obj.date === "string" && /^\d{4}-\d{2}-\d{2}/.test(obj.date)) {
+    return obj.date.slice(0, 10);
+  }
+  if (typeof obj.month === "string" && /^\d{4}-\d{2}$/.test(obj.month)) {
+    return `${obj.month}-01`;
+  }
+  return undefined;
+}
+
+/** Days of the dated rows in a report, to look their FX rates up. */
+export function reportDays(data: unknown, days = new Set<string>()) {
+  if (Array.isArray(data)) {
+    for (const d of data) reportDays(d, days);
+  } else if (isPlainObject(data)) {
+    const day = rowDay(data);
+    if (day) days.add(day);
+    for (const value of Object.values(data)) reportDays(value, days);
+  }
+  return days;
+}
+
+/**
+ * The rate on each of `days` from a rate history: the day's own, else the
+ * nearest earlier one. Days before the first rate get none.
+ */
+export function ratesOnDays(
+  days: string[],
+  history: Array<{ day: string; rate: number }>,
+): Map<string, number> {
+  const sorted = [...history].sort((a, b) => a.day.localeCompare(b.day));
+  const out = new Map<string, number>();
+  let i = 0;
+  let rate: number | undefined;
+  for (const day of [...days].sort()) {
+    while (i < sorted.length && sorted[i].day <= day) rate = sorted[i++].rate;
+    if (rate !== undefined) out.set(day, rate);
+  }
+  return out;
+}
+
+/**
+ * Copy of `data` with a <name>_<code> figure after each numeric
+ * <name>_usd one. `perUSD(day)` gives units of `currency` per USD on a
+ * row's day; undated rows (and everything in them) pass no day and take
+ * today's rate. Existing <name>_<code> fields are left as they are.
+ */
+export function addCurrencyFigures(
+  data: unknown,
+  currency: string,
+  perUSD: (day?: string) => number,
+  day?: string,
+): unknown {
+  if (Array.isArray(data)) {
+    return data.map((d) => addCurrencyFigures(d, currency, perUSD, day));
+  }
+  if (!isPlainObject(data)) return data;
+
+  const at = rowDay(data) ?? day;
+  const suffix = `_${currency.toLowerCase()}`;
+  const out: Record<string, unknown> = {};
+  for (const [key, value] of Object.entries(data)) {
+    out[key] = addCurrencyFigures(value, currency, perUSD, at);
+    if (typeof value !== "number" || !USD_KEY.test(key)) continue;
+    const converted = key.replace(USD_KEY, suffix);
+    if (!(converted in data)) out[converted] = value * perUSD(at);
+  }
+  return out;
+}
@@ -0,0 +1,28 @@
+import { ValidationError } from "../core/errors";
+
+// Keyset cursors: the sort key of the last row of a page, opaque to clients
+
+/** Cursor pointing at the row with timestamp `at` and tie-breaker `id`. */
+export function encodeCursor(at: string, id: string | number): string {
+  return Buffer.from(JSON.stringify([at, id])).toString("base64url");
+}
+
+export function decodeCursor(cursor: string): {
+  at: string;
+  id: string | number;
+} {
+  try {
+    const [at, id] = JSON.parse(
+      Buffer.from(cursor, "base64url").toString("utf8"),
+    );
+    if (
+      typeof at === "string" &&
+      (typeof id === "string" || typeof id === "number")
+    ) {
+      return { at, id };
+    }
+  } catch {
+    // fall through
+  }
+  throw new ValidationError("cursor is invalid");
+}
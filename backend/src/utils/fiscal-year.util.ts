@@ -0,0 +1,37 @@
+import { FiscalYearSettings } from "../types";
+
+export const CALENDAR_YEAR: FiscalYearSettings = { start: "01-01", starts: {} };
+
+/** First moment (UTC) of fiscal year `year`, named by the year it starts. */
+export function fiscalYearStart(
+  year: number,
+  fy: FiscalYearSettings = CALENDAR_YEAR,
+): Date {
+  const pinned = fy.starts?.[String(year)];
+  if (pinned) return new Date(`${pinned}T00:00:00.000Z`);
+  const [month, day] = (fy.start || "01-01").split("-").map(Number);
+  // A start past the month's end (02-30) falls on its last day
+  const last = new Date(Date.UTC(year, month, 0)).getUTCDate();
+  return new Date(Date.UTC(year, month - 1, Math.min(day, last)));
+}
+
+/** Fiscal year `year` as ISO bounds; `end` is its last millisecond. */
+export function fiscalYearRange(
+  year: number,
+  fy: FiscalYearSettings = CALENDAR_YEAR,
+): { start: string; end: string } {
+  return {
+    start: fiscalYearStart(year, fy).toISOString(),
+    end: new Date(fiscalYearStart(year + 1, fy).getTime() - 1).toISOString(),
+  };
+}
+
+/** The fiscal year a moment falls in. */
+export function fiscalYearOf(
+  at: Date,
+  fy: FiscalYearSettings = CALENDAR_YEAR,
+): number {
+  const y = at.getUTCFullYear();
+  if (at < fiscalYearStart(y, fy)) return y - 1;
+  return at < fiscalYearStart(y + 1, fy) ? y : y + 1;
+}
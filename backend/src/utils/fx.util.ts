@@ -0,0 +1,143 @@
+import { AsyncLocalStorage } from "async_hooks";
+import { AdminAccount, Asset, Transaction, TransactionType } from "../types";
+
+/**
+ * A transaction's amount in any currency. It is stored in its own asset,
+ * with `rate` giving USD and `fx` the other currencies captured when it was
+ * recorded, so a currency needs a snapshot rather than a column of its own.
+ */
+
+// Currencies every new transaction records a snapshot for, besides USD
+export const DEFAULT_FX_CURRENCIES = ["VND"];
+
+// Home currency of the jurisdictions accounts are usually held in
+export const JURISDICTION_CURRENCY: Record<string, string> = {
+  VN: "VND",
+  US: "USD",
+  SG: "SGD",
+  JP: "JPY",
+  KR: "KRW",
+  ID: "IDR",
+  TH: "THB",
+  HK: "HKD",
+  CN: "CNY",
+  AU: "AUD",
+  CA: "CAD",
+  GB: "GBP",
+  CH: "CHF",
+  DE: "EUR",
+  FR: "EUR",
+  NL: "EUR",
+  IE: "EUR",
+};
+
+/**
+ * Margin (percent) `account` charges over mid-market on a transaction in
+ * `asset`: its configured margin when the asset is a fiat currency other
+ * than the account's own (its default asset, else the currency of its
+ * jurisdiction or of `homeJurisdiction`), otherwise 0.
+ */
+export function fxMarginPercent(
+  asset: Asset,
+  account: AdminAccount | undefined,
+  homeJurisdiction: string,
+): number {
+  const margin = Number(account?.fx_margin_percent) || 0;
+  if (!account || margin <= 0 || asset.type !== "FIAT") return 0;
+  const home =
+    account.default_asset ||
+    JURISDICTION_CURRENCY[account.jurisdiction || homeJurisdiction];
+  if (!home) return 0;
+  return asset.symbol.toUpperCase() === home.toUpperCase() ? 0 : margin;
+}
+
+// Types an account's FX margin is charged on: money spent in a foreign
+// currency. Income and transfers are converted at mid-market.
+export const FX_MARGIN_TYPES: TransactionType[] = ["EXPENSE"];
+
+const marginsSuspended = new AsyncLocalStorage<boolean>();
+
+/**
+ * Run `fn` (and everything it awaits) without applying FX margins, for
+ * writes whose amounts already include the margin, such as a backup
+ * restore.
+ */
+export function withoutFxMargins<T>(fn: () => T): T {
+  return marginsSuspended.run(true, fn);
+}
+
+export function fxMarginsSuspended(): boolean {
+  return marginsSuspended.getStore() === true;
+}
+
+/** What mid-market amounts of `t` are multiplied by for its FX margin. */
+export function fxMarginFactor(t: Pick<Transaction, "fxMargin">): number {
+  return 1 + (t.fxMargin || 0) / 100;
+}
+
+/**
+ * Value of one unit of the transaction's asset in `currency`, if known,
+ * with the margin the account charged on it.
+ */
+export function fxRate(
+  t: Pick<Transaction, "asset" | "rate" | "fx" | "fxMargin">,
+  currency: string,
+): number | undefined {
+  const code = currency.toUpperCase();
+  if (t.asset.type === "FIAT" && t.asset.symbol.toUpperCase() === code) {
+    return 1;
+  }
+  const mid = code === "USD" ? t.rate?.rateUSD : t.fx?.[code];
+  return mid === undefined ? undefined : mid * fxMarginFactor(t);
+}
+
+/** Amount in `currency` at the rate it was recorded at, if known. */
+export function amountIn(
+  t: Pick<Transaction, "asset" | "amount" | "rate" | "fx" | "fxMargin">,
+  currency: string,
+): number | undefined {
+  const rate = fxRate(t, currency);
+  return rate === undefined ? undefined : t.amount * rate;
+}
+
+/** USD amount as recorded (`usdAmount`). */
+export function amountUSD(t: Pick<Transaction, "usdAmount">): number {
+  return t.usdAmount || 0;
+}
+
+/**
+ * VND amount: the recorded VND snapshot, else the USD amount at
+ * `vndPerUSD` (today's rate, as reports convert).
+ */
+export function amountVND(
+  t: Pick<
+    Transaction,
+    "asset" | "amount" | "rate" | "fx" | "fxMargin" | "usdAmount"
+  >,
+  vndPerUSD: number,
+): number {
+  return amountIn(t, "VND") ?? amountUSD(t) * vndPerUSD;
+}
+
+/**
+ * Snapshot of a transaction's asset in `currencies`, from `usdPerUnit`
+ * (USD per unit of a currency on a day; undefined when unknown).
+ * Currencies without a rate are left out, as are USD and the asset itself.
+ */
+export function fxSnapshot(
+  t: Pick<Transaction, "asset" | "rate" | "createdAt">,
+  currencies: string[],
+  usdPerUnit: (currency: Asset, atISO: string) => number | undefined,
+): Record<string, number> | undefined {
+  const assetUSD = t.rate?.rateUSD ?? 0;
+  if (!(assetUSD > 0)) return undefined;
+  const own = t.asset.type === "FIAT" ? t.asset.symbol.toUpperCase() : "";
+  const fx: Record<string, number> = {};
+  for (const c of currencies) {
+    const code = c.toUpperCase();
+    if (code === "USD" || code === own) continue;
+    const usd = usdPerUnit({ type: "FIAT", symbol: code }, t.createdAt);
+    if (usd && usd > 0) fx[code] = assetUSD / usd;
+  }
+  return Object.keys(fx).length ? fx : undefined;
+}
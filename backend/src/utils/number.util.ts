@@ -13,3 +13,89 @@ export function coerceNumber(value: unknown, fallback = 0): number {
   return Number.isFinite(parsed) ? parsed : fallback;
 }
 
+
+// Display decimals per currency/asset symbol used when serializing reports
+export const DEFAULT_DISPLAY_PRECISION: Record<string, number> = {
+  VND: 0,
+  USD: 2,
+  BTC: 8,
+};
+
+const QUANTITY_KEYS = new Set([
+  "quantity",
+  "amount",
+  "balance",
+  "units",
+  "qty",
+]);
+
+export function roundTo(value: number, decimals: number): number {
+  if (!Number.isFinite(value)) return value;
+  const factor = Math.pow(10, decimals);
+  return Math.round(value * factor) / factor;
+}
+
+// Decimals of a reporting currency with no precision of its own
+const REPORTING_CURRENCY_DECIMALS = 2;
+
+// total_usd / valueVND -> USD / VND, and total_eur -> EUR when EUR is the
+// reporting currency; prices and rates keep full precision
+function currencyForKey(key: string, extra?: string): string | undefined {
+  if (/price|rate/i.test(key)) return undefined;
+  const m = /(vnd|usd)$/i.exec(key);
+  if (m) return m[1].toUpperCase();
+  return extra && key.toUpperCase().endsWith(`_${extra}`) ? extra : undefined;
+}
+
+function symbolOf(obj: Record<string, unknown>): string | undefined {
+  const asset = obj.asset as any;
+  const symbol =
+    typeof asset === "string"
+      ? asset
+      : typeof asset?.symbol === "string"
+        ? asset.symbol
+        : typeof obj.symbol === "string"
+          ? obj.symbol
+          : typeof obj.asset_symbol === "string"
+            ? obj.asset_symbol
+            : undefined;
+  return symbol ? symbol.toUpperCase() : undefined;
+}
+
+/**
+ * Round numeric fields recursively using per-currency precision.
+ * Currency is inferred from the key suffix (_usd, _vnd, or that of the
+ * `reporting` currency) or, for quantity fields, from the asset symbol of
+ * the enclosing object.
+ */
+export function applyDisplayPrecision(
+  data: unknown,
+  precision: Record<string, number>,
+  reporting?: string,
+): unknown {
+  if (reporting && precision[reporting] === undefined) {
+    precision = { ...precision, [reporting]: REPORTING_CURRENCY_DECIMALS };
+  }
+  if (Array.isArray(data)) {
+    return data.map((d) => applyDisplayPrecision(d, precision, reporting));
+  }
+  if (!data || typeof data !== "object") return data;
+  const proto = Object.getPrototypeOf(data);
+  if (proto !== Object.prototype && proto !== null) return data;
+
+  const obj = data as Record<string, unknown>;
+  const symbol = symbolOf(obj);
+  const out: Record<string, unknown> = {};
+  for (const [key, value] of Object.entries(obj)) {
+    if (typeof value !== "number") {
+      out[key] = applyDisplayPrecision(value, precision, reporting);
+      continue;
+    }
+    const currency =
+      currencyForKey(key, reporting) ??
+      (symbol && QUANTITY_KEYS.has(key) ? symbol : undefined);
+    const decimals = currency ? precision[currency] : undefined;
+    out[key] = typeof decimals === "number" ? roundTo(value, decimals) : value;
+  }
+  return out;
+}
@@ -0,0 +1,149 @@
+/**
+ * Minimal PDF writer for printable statements: text in the standard
+ * Helvetica fonts, lines and rectangles on A4 pages. Coordinates are
+ * points from the top-left corner. No embedded fonts or images, so text
+ * outside Latin-1 is replaced with "?".
+ */
+
+export const PAGE_WIDTH = 595; // A4
+export const PAGE_HEIGHT = 842;
+
+export type PdfColor = [number, number, number]; // 0..1 RGB
+
+export interface TextOptions {
+  size?: number;
+  bold?: boolean;
+  align?: "left" | "right";
+  color?: PdfColor;
+}
+
+// Helvetica averages about half an em per glyph; enough to right-align
+const AVG_GLYPH_WIDTH = 0.5;
+
+function num(n: number): string {
+  return Number.isFinite(n) ? String(Math.round(n * 100) / 100) : "0";
+}
+
+function color(c: PdfColor): string {
+  return c.map(num).join(" ");
+}
+
+function escapeText(s: string): string {
+  return s
+    .replace(/[^\x20-\x7e\xa0-\xff]/g, "?")
+    .replace(/([\\()])/g, "\\$1");
+}
+
+export class PdfDocument {
+  private pages: string[][] = [];
+
+  constructor() {
+    this.addPage();
+  }
+
+  addPage(): this {
+    this.pages.push([]);
+    return this;
+  }
+
+  text(x: number, y: number, value: string, opts: TextOptions = {}): this {
+    const size = opts.size ?? 10;
+    const left =
+      opts.align === "right"
+        ? x - value.length * size * AVG_GLYPH_WIDTH
+        : x;
+    this.ops.push(
+      `${color(opts.color ?? [0, 0, 0])} rg`,
+      `BT /${opts.bold ? "F2" : "F1"} ${num(size)} Tf ` +
+        `${num(left)} ${num(PAGE_HEIGHT - y)} Td ` +
+        `(${escapeText(value)}) Tj ET`,
+    );
+    return this;
+  }
+
+  line(
+    x1: number,
+    y1: number,
+    x2: number,
+    y2: number,
+    stroke: PdfColor = [0, 0, 0],
+    width = 0.5,
+  ): this {
+    return this.polyline(
+      [
+        [x1, y1],
+        [x2, y2],
+      ],
+      stroke,
+      width,
+    );
+  }
+
+  polyline(
+    points: Array<[number, number]>,
+    stroke: PdfColor = [0, 0, 0],
+    width = 1,
+  ): this {
+    if (points.length < 2) return this;
+    const path = points
+      .map(([x, y], i) => `${num(x)} ${num(PAGE_HEIGHT - y)} ${i ? "l" : "m"}`)
+      .join(" ");
+    this.ops.push(`${color(stroke)} RG ${num(width)} w ${path} S`);
+    return this;
+  }
+
+  rect(x: number, y: number, w: number, h: number, fill: PdfColor): this {
+    this.ops.push(
+      `${color(fill)} rg ${num(x)} ${num(PAGE_HEIGHT - y - h)} ` +
+        `${num(w)} ${num(h)} re f`,
+    );
+    return this;
+  }
+
+  toBuffer(): Buffer {
+    // 1 catalog, 2 page tree, 3-4 fonts, then a page + content per page
+    const objects: string[] = [];
+    const kids = this.pages.map((_, i) => `${5 + i * 2} 0 R`).join(" ");
+    objects.push("<< /Type /Catalog /Pages 2 0 R >>");
+    objects.push(
+      `<< /Type /Pages /Kids [${kids}] /Count ${this.pages.length} >>`,
+    );
+    for (const font of ["Helvetica", "Helvetica-Bold"]) {
+      objects.push(
+        `<< /Type /Font /Subtype /Type1 /BaseFont /${font} ` +
+          "/Encoding /WinAnsiEncoding >>",
+      );
+    }
+    this.pages.forEach((ops, i) => {
+      const content = ops.join("\n");
+      objects.push(
+        `<< /Type /Page /Parent 2 0 R ` +
+          `/MediaBox [0 0 ${PAGE_WIDTH} ${PAGE_HEIGHT}] ` +
+          "/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> " +
+          `/Contents ${6 + i * 2} 0 R >>`,
+      );
+      objects.push(
+        `<< /Length ${Buffer.byteLength(content, "latin1")} >>\n` +
+          `stream\n${content}\nendstream`,
+      );
+    });
+
+    let out = "%PDF-1.4\n";
+    const offsets: number[] = [];
+    objects.forEach((body, i) => {
+      offsets.push(Buffer.byteLength(out, "latin1"));
+      out += `${i + 1} 0 obj\n${body}\nendobj\n`;
+    });
+    const xref = Buffer.byteLength(out, "latin1");
+    out += `xref\n0 ${objects.length + 1}\n0000000000 65535 f \n`;
+    for (const o of offsets) out += `${String(o).padStart(10, "0")} 00000 n \n`;
+    out +=
+      `trailer\n<< /Size ${objects.length + 1} /Root 1 0 R >>\n` +
+      `startxref\n${xref}\n%%EOF\n`;
+    return Buffer.from(out, "latin1");
+  }
+
+  private get ops(): string[] {
+    return this.pages[this.pages.length - 1];
+  }
+}
@@ -0,0 +1,41 @@
+import { AsyncLocalStorage } from "async_hooks";
+
+/**
+ * Temporary price overrides for one request ("value BTC at 50k"), used to
+ * stress-test reports without touching stored prices. Overrides hold USD
+ * per unit by upper-case symbol and apply to every date in the request.
+ */
+export type PriceOverrides = Map<string, number>;
+
+const storage = new AsyncLocalStorage<PriceOverrides>();
+
+/**
+ * Parse "BTC:50000,ETH:2500" (":" or "=" between symbol and USD price).
+ * Throws on a malformed pair or a price that is not positive.
+ */
+export function parsePriceOverrides(text: string): PriceOverrides {
+  const overrides: PriceOverrides = new Map();
+  for (const pair of text.split(",")) {
+    if (!pair.trim()) continue;
+    const m = /^\s*([A-Za-z0-9._-]+)\s*[:=]\s*([0-9.eE+-]+)\s*$/.exec(pair);
+    const price = m ? Number(m[2]) : NaN;
+    if (!m || !Number.isFinite(price) || price <= 0) {
+      throw new Error(`Invalid price override "${pair.trim()}"`);
+    }
+    overrides.set(m[1].toUpperCase(), price);
+  }
+  return overrides;
+}
+
+/** Run `fn` (and everything it awaits) with `overrides` in effect. */
+export function withPriceOverrides<T>(
+  overrides: PriceOverrides,
+  fn: () => T,
+): T {
+  return storage.run(overrides, fn);
+}
+
+/** USD price override for `symbol` in the current request, if any. */
+export function priceOverride(symbol: string): number | undefined {
+  return storage.getStore()?.get(symbol.toUpperCase());
+}
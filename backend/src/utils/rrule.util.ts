@@ -0,0 +1,155 @@
+/**
+ * A subset of iCalendar RRULE (RFC 5545) for recurring transactions:
+ *
+ *   FREQ=DAILY|WEEKLY|MONTHLY|YEARLY   required
+ *   INTERVAL=n                         every n periods (default 1)
+ *   BYDAY=MO,WE,FR                     WEEKLY only; weeks start on Monday
+ *   BYMONTHDAY=n                       MONTHLY only; 1-31, or -1 for last
+ *   COUNT=n                            stop after n occurrences
+ *   UNTIL=YYYYMMDD                     stop after this day (inclusive)
+ *
+ * Unlike RFC 5545, a day past the end of a month (BYMONTHDAY=31, or a
+ * start on the 31st) falls on the month's last day instead of skipping
+ * the month, which is what rent and salary dates mean.
+ */
+
+export type RuleFrequency = "DAILY" | "WEEKLY" | "MONTHLY" | "YEARLY";
+
+export interface Rule {
+  freq: RuleFrequency;
+  interval: number;
+  byDay?: number[]; // 0 = Monday ... 6 = Sunday, sorted
+  byMonthDay?: number;
+  count?: number;
+  until?: string; // ISO; last millisecond of the UNTIL day
+}
+
+const FREQUENCIES: RuleFrequency[] = ["DAILY", "WEEKLY", "MONTHLY", "YEARLY"];
+const WEEKDAYS = ["MO", "TU", "WE", "TH", "FR", "SA", "SU"];
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+function positiveInt(key: string, value: string): number {
+  const n = Number(value);
+  if (!Number.isInteger(n) || n < 1) {
+    throw new Error(`${key} must be a positive integer`);
+  }
+  return n;
+}
+
+function parseUntil(value: string): string {
+  const m = /^(\d{4})-?(\d{2})-?(\d{2})(?:T\d{6}Z?)?$/.exec(value);
+  const at = m ? Date.UTC(+m[1], +m[2] - 1, +m[3]) : NaN;
+  if (Number.isNaN(at)) throw new Error("UNTIL must be a date (YYYYMMDD)");
+  return new Date(at + DAY_MS - 1).toISOString();
+}
+
+/** Parse a rule such as "FREQ=MONTHLY;BYMONTHDAY=1"; throws if invalid. */
+export function parseRule(text: string): Rule {
+  const parts = new Map<string, string>();
+  for (const part of text.trim().replace(/^RRULE:/i, "").split(";")) {
+    if (!part) continue;
+    const [key, value = ""] = part.split("=");
+    parts.set(key.trim().toUpperCase(), value.trim().toUpperCase());
+  }
+
+  const freq = parts.get("FREQ") as RuleFrequency;
+  if (!FREQUENCIES.includes(freq)) {
+    throw new Error(`FREQ must be one of ${FREQUENCIES.join(", ")}`);
+  }
+  const rule: Rule = { freq, interval: 1 };
+
+  for (const [key, value] of parts) {
+    switch (key) {
+      case "FREQ":
+        break;
+      case "INTERVAL":
+        rule.interval = positiveInt(key, value);
+        break;
+      case "COUNT":
+        rule.count = positiveInt(key, value);
+        break;
+      case "UNTIL":
+        rule.until = parseUntil(value);
+        break;
+      case "BYDAY": {
+        if (freq !== "WEEKLY") throw new Error("BYDAY needs FREQ=WEEKLY");
+        const days = value.split(",").map((d) => WEEKDAYS.indexOf(d));
+        if (days.some((d) => d === -1)) {
+          throw new Error(`BYDAY takes ${WEEKDAYS.join(", ")}`);
+        }
+        rule.byDay = Array.from(new Set(days)).sort((a, b) => a - b);
+        break;
+      }
+      case "BYMONTHDAY": {
+        if (freq !== "MONTHLY") {
+          throw new Error("BYMONTHDAY needs FREQ=MONTHLY");
+        }
+        const day = Number(value);
+        if (day !== -1 && !(Number.isInteger(day) && day >= 1 && day <= 31)) {
+          throw new Error("BYMONTHDAY must be 1-31 or -1");
+        }
+        rule.byMonthDay = day;
+        break;
+      }
+      default:
+        throw new Error(`Unsupported rule part ${key}`);
+    }
+  }
+  return rule;
+}
+
+// Day `day` of the month `months` after start's, at start's time of day,
+// clamped to the month's last day
+function monthDay(start: Date, months: number, day: number): Date {
+  const y = start.getUTCFullYear();
+  const m = start.getUTCMonth() + months;
+  const last = new Date(Date.UTC(y, m + 1, 0)).getUTCDate();
+  const at = new Date(Date.UTC(y, m, day === -1 ? last : Math.min(day, last)));
+  return new Date(at.getTime() + (start.getTime() % DAY_MS));
+}
+
+// Candidate dates in the k-th period (day, week, month or year) after start
+function period(rule: Rule, start: Date, k: number): Date[] {
+  const step = k * rule.interval;
+  switch (rule.freq) {
+    case "DAILY":
+      return [new Date(start.getTime() + step * DAY_MS)];
+    case "WEEKLY": {
+      if (!rule.byDay) return [new Date(start.getTime() + step * 7 * DAY_MS)];
+      const monday = start.getTime() - ((start.getUTCDay() + 6) % 7) * DAY_MS;
+      return rule.byDay.map((d) => new Date(monday + (step * 7 + d) * DAY_MS));
+    }
+    case "MONTHLY":
+      return [monthDay(start, step, rule.byMonthDay ?? start.getUTCDate())];
+    case "YEARLY":
+      return [monthDay(start, step * 12, start.getUTCDate())];
+  }
+}
+
+/** Occurrences of the rule from startAt on, in order, as ISO strings. */
+export function* occurrences(rule: Rule, startAt: string): Generator<string> {
+  const start = new Date(startAt);
+  if (Number.isNaN(start.getTime())) return;
+  const until = rule.until ? new Date(rule.until).getTime() : Infinity;
+  let n = 0;
+  for (let k = 0; ; k++) {
+    for (const at of period(rule, start, k)) {
+      if (at.getTime() < start.getTime()) continue;
+      if (at.getTime() > until || (rule.count && n >= rule.count)) return;
+      n++;
+      yield at.toISOString();
+    }
+  }
+}
+
+/** First occurrence after `after` (or the very first), if any is left. */
+export function nextOccurrence(
+  rule: Rule,
+  startAt: string,
+  after?: string,
+): string | undefined {
+  for (const at of occurrences(rule, startAt)) {
+    if (!after || at > after) return at;
+  }
+  return undefined;
+}
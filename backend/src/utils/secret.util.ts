@@ -0,0 +1,45 @@
+import crypto from "crypto";
+
+/**
+ * Encryption of secrets kept in settings (API keys, service account keys)
+ * with AES-256-GCM. The key is derived from a passphrase such as
+ * SETTINGS_ENCRYPTION_KEY, so a copied database or backup doesn't expose
+ * the secrets on its own.
+ */
+const PREFIX = "enc:v1:";
+
+function deriveKey(passphrase: string): Buffer {
+  return crypto.createHash("sha256").update(passphrase).digest();
+}
+
+/** "enc:v1:<iv>:<tag>:<ciphertext>", each part base64. */
+export function encryptSecret(plaintext: string, passphrase: string): string {
+  const iv = crypto.randomBytes(12);
+  const key = deriveKey(passphrase);
+  const cipher = crypto.createCipheriv("aes-256-gcm", key, iv);
+  const data = Buffer.concat([
+    cipher.update(plaintext, "utf8"),
+    cipher.final(),
+  ]);
+  const parts = [iv, cipher.getAuthTag(), data];
+  return PREFIX + parts.map((b) => b.toString("base64")).join(":");
+}
+
+/** Throws when the value is not encrypted or the passphrase is wrong. */
+export function decryptSecret(value: string, passphrase: string): string {
+  const parts = value.startsWith(PREFIX)
+    ? value.slice(PREFIX.length).split(":")
+    : [];
+  if (parts.length !== 3) throw new Error("Value is not an encrypted secret");
+  const [iv, tag, data] = parts.map((p) => Buffer.from(p, "base64"));
+  const key = deriveKey(passphrase);
+  const decipher = crypto.createDecipheriv("aes-256-gcm", key, iv);
+  decipher.setAuthTag(tag);
+  try {
+    return Buffer.concat([decipher.update(data), decipher.final()]).toString(
+      "utf8",
+    );
+  } catch {
+    throw new Error("Secret could not be decrypted; check the key");
+  }
+}
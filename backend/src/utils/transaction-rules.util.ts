@@ -0,0 +1,128 @@
+import { AsyncLocalStorage } from "async_hooks";
+import {
+  RuleAction,
+  RuleCondition,
+  Transaction,
+  TransactionRule,
+} from "../types";
+
+/** What the rules did to one transaction. */
+export interface RuleOutcome {
+  ruleIds: string[]; // rules that matched, in the order they ran
+  changes: Partial<Transaction>; // fields that ended up different
+}
+
+const lower = (s: string) => s.trim().toLowerCase();
+
+function textOf(t: Transaction, field: RuleCondition["field"]): string[] {
+  switch (field) {
+    case "tag":
+      return t.tags || [];
+    case "asset":
+      return [t.asset.symbol];
+    case "type":
+      return [t.type];
+    case "counterparty":
+    case "note":
+    case "category":
+    case "account": {
+      const v = t[field];
+      return v ? [v] : [];
+    }
+    default:
+      return [];
+  }
+}
+
+export function matchesCondition(t: Transaction, c: RuleCondition): boolean {
+  if (c.field === "amount") {
+    const limit = Number(c.value);
+    if (c.op === "lt") return t.amount < limit;
+    if (c.op === "lte") return t.amount <= limit;
+    if (c.op === "gt") return t.amount > limit;
+    if (c.op === "gte") return t.amount >= limit;
+    return t.amount === limit;
+  }
+  const want = lower(String(c.value));
+  return textOf(t, c.field).some((v) => {
+    const have = lower(v);
+    if (c.op === "contains") return have.includes(want);
+    if (c.op === "starts_with") return have.startsWith(want);
+    return have === want;
+  });
+}
+
+export function matchesRule(t: Transaction, rule: TransactionRule): boolean {
+  return rule.conditions.every((c) => matchesCondition(t, c));
+}
+
+/** Enabled rules in the order they run: priority, then age. */
+export function orderRules(rules: TransactionRule[]): TransactionRule[] {
+  return rules
+    .filter((r) => r.enabled)
+    .sort(
+      (a, b) =>
+        a.priority - b.priority || a.createdAt.localeCompare(b.createdAt),
+    );
+}
+
+function act(t: Transaction, a: RuleAction): void {
+  if (a.type === "add_tag") {
+    const tags = t.tags || [];
+    if (!tags.some((x) => lower(x) === lower(a.value))) {
+      t.tags = [...tags, a.value];
+    }
+  } else if (a.type === "set_category") t.category = a.value;
+  else if (a.type === "set_counterparty") t.counterparty = a.value;
+  else t.internalFlow = a.value;
+}
+
+/**
+ * Run `rules` over `t`, changing it in place. Each rule sees the changes
+ * of the ones before it, so a rule that cleans up a counterparty can feed
+ * one that tags by it; when two rules set a field the later one wins.
+ */
+export function applyRules(
+  t: Transaction,
+  rules: TransactionRule[],
+): RuleOutcome {
+  const before = {
+    tags: t.tags,
+    category: t.category,
+    counterparty: t.counterparty,
+    internalFlow: t.internalFlow,
+  };
+  const ruleIds: string[] = [];
+  for (const rule of orderRules(rules)) {
+    if (!matchesRule(t, rule)) continue;
+    ruleIds.push(rule.id);
+    for (const a of rule.actions) act(t, a);
+  }
+
+  const changes: Partial<Transaction> = {};
+  if (JSON.stringify(t.tags) !== JSON.stringify(before.tags)) {
+    changes.tags = t.tags;
+  }
+  if (t.category !== before.category) changes.category = t.category;
+  if (t.counterparty !== before.counterparty) {
+    changes.counterparty = t.counterparty;
+  }
+  if (t.internalFlow !== before.internalFlow) {
+    changes.internalFlow = t.internalFlow;
+  }
+  return { ruleIds, changes };
+}
+
+const suspended = new AsyncLocalStorage<boolean>();
+
+/**
+ * Run `fn` (and everything it awaits) with rules off, for writes that
+ * must land exactly as given, such as a backup restore.
+ */
+export function withoutRules<T>(fn: () => T): T {
+  return suspended.run(true, fn);
+}
+
+export function rulesSuspended(): boolean {
+  return suspended.getStore() === true;
+}
@@ -0,0 +1,170 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Account balances
+ *
+ * - Every transaction write moves the materialized balance of its account
+ * - Edits that change the account or amount move the balance both ways
+ * - Past balances are summed from the ledger up to `as_of`
+ * - Accounts resolve by admin id or name
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+const vnd = { type: "FIAT" as const, symbol: "VND" };
+
+const tx = (
+  id: string,
+  type: string,
+  account: string,
+  amount: number,
+  createdAt: string,
+  asset = usd,
+) =>
+  ({
+    id,
+    type,
+    asset,
+    amount,
+    account,
+    createdAt,
+    rate: { asset, rateUSD: 1, timestamp: createdAt },
+    usdAmount: amount,
+  }) as Transaction;
+
+describe("Account balance ledger (JSON repository)", () => {
+  let store: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { transactions: [], accountBalances: [] };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+  });
+
+  it("follows creates, edits, deletes and restores", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { AccountBalanceRepositoryJson, withAccountBalances } = await import(
+      "../src/repositories/account-balance.repository"
+    );
+    const balances = new AccountBalanceRepositoryJson();
+    const repo = withAccountBalances(
+      new TransactionRepositoryJson(),
+      () => balances,
+    );
+    const units = () =>
+      balances
+        .findAll()
+        .map((b) => [
+          b.account,
+          b.asset.symbol,
+          b.quantity,
+          b.transactionCount,
+        ]);
+
+    repo.createMany([
+      tx("open", "INITIAL", "Bank", 1000, "2025-01-01T00:00:00.000Z"),
+      tx("rent", "EXPENSE", "Bank", 400, "2025-01-05T00:00:00.000Z"),
+      tx("tip", "EXPENSE", "Cash", 50_000, "2025-01-06T00:00:00.000Z", vnd),
+    ]);
+    expect(units()).toEqual([
+      ["Bank", "USD", 600, 2],
+      ["Cash", "VND", -50_000, 1],
+    ]);
+    expect(balances.findByAccount("bank")[0].lastTransactionAt).toBe(
+      "2025-01-05T00:00:00.000Z",
+    );
+
+    repo.update("rent", { account: "Card", amount: 450 });
+    expect(units()).toEqual([
+      ["Bank", "USD", 1000, 1],
+      ["Card", "USD", -450, 1],
+      ["Cash", "VND", -50_000, 1],
+    ]);
+
+    repo.softDelete("open", "2025-02-01T00:00:00.000Z");
+    expect(balances.findByAccount("Bank")[0].quantity).toBe(0);
+    repo.restore("open");
+    expect(balances.findByAccount("Bank")[0].quantity).toBe(1000);
+  });
+});
+
+describe("Account Balance Service", () => {
+  const ledger = [
+    tx("open", "INITIAL", "Bank", 1000, "2025-01-01T00:00:00.000Z"),
+    tx("rent", "EXPENSE", "Bank", 400, "2025-02-05T00:00:00.000Z"),
+    tx("pay", "INCOME", "Broker", 250, "2025-01-20T00:00:00.000Z"),
+  ];
+  let replaced: any[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    replaced = [];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => ledger },
+      reportingRepository: {
+        findLedgerUntil: (end: string) =>
+          ledger.filter((t) => t.createdAt <= end),
+      },
+      accountBalanceRepository: {
+        findAll: () => replaced,
+        findByAccount: (a: string) =>
+          replaced.filter((b) => b.account.toLowerCase() === a.toLowerCase()),
+        replaceAll: (rows: any[]) => {
+          replaced = rows;
+        },
+      },
+      adminRepository: {
+        findAllAccounts: () => [{ id: 7, name: "Bank" }],
+        findAllAssets: () => [],
+      },
+    }));
+  });
+
+  it("rebuilds current balances and sums past ones", async () => {
+    const { accountBalanceService } = await import(
+      "../src/services/account-balance.service"
+    );
+    expect(accountBalanceService.rebuild()).toBe(2);
+    const now = new Date("2025-03-01T00:00:00.000Z");
+
+    expect(
+      accountBalanceService
+        .getBalances({}, now)
+        .map((b) => [b.account, b.quantity]),
+    ).toEqual([
+      ["Bank", 600],
+      ["Broker", 250],
+    ]);
+    expect(
+      accountBalanceService
+        .getBalances({ account: "bank", asOf: "2025-01-31" }, now)
+        .map((b) => [b.account, b.quantity, b.transactionCount]),
+    ).toEqual([["Bank", 1000, 1]]);
+    expect(() =>
+      accountBalanceService.getBalances({ asOf: "end of January" }, now),
+    ).toThrow(/as_of/);
+  });
+
+  it("resolves an account by admin id or name", async () => {
+    const { accountBalanceService } = await import(
+      "../src/services/account-balance.service"
+    );
+    accountBalanceService.rebuild();
+
+    expect(accountBalanceService.resolveAccount("7")).toBe("Bank");
+    expect(accountBalanceService.resolveAccount("bank")).toBe("Bank");
+    // Used on transactions but never registered as an admin account
+    expect(accountBalanceService.resolveAccount("Broker")).toBe("Broker");
+    expect(() => accountBalanceService.resolveAccount("42")).toThrow(
+      /not found/,
+    );
+  });
+});
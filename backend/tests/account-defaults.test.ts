@@ -0,0 +1,63 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Per-account default asset
+ *
+ * - A configured default_asset wins over the jurisdiction's currency
+ * - An explicit symbol always wins over the account's default
+ * - Unknown accounts and accounts with neither have no default
+ */
+
+describe("Account Defaults Service", () => {
+  beforeEach(() => {
+    vi.resetModules();
+    const account = (
+      name: string,
+      extra: { default_asset?: string; jurisdiction?: string } = {},
+      is_active = true,
+    ) => ({ id: 1, name, is_active, created_at: "2025-01-01", ...extra });
+
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAllAccounts: () => [
+          account("Bank Account", { jurisdiction: "VN" }),
+          account("IBKR", { default_asset: "USD", jurisdiction: "IE" }),
+          account("Binance"),
+          account("Old Bank", { default_asset: "VND" }, false),
+        ],
+      },
+    }));
+  });
+
+  it("prefers default_asset, then the jurisdiction currency", async () => {
+    const { accountDefaultsService } = await import(
+      "../src/services/account-defaults.service"
+    );
+
+    expect(accountDefaultsService.defaultAsset("bank account")).toEqual({
+      type: "FIAT",
+      symbol: "VND",
+    });
+    expect(accountDefaultsService.defaultAsset("IBKR")?.symbol).toBe("USD");
+    expect(accountDefaultsService.defaultAsset("Binance")).toBeUndefined();
+    expect(accountDefaultsService.defaultAsset("Nowhere")).toBeUndefined();
+  });
+
+  it("keeps an explicit symbol and lists active defaults", async () => {
+    const { accountDefaultsService } = await import(
+      "../src/services/account-defaults.service"
+    );
+
+    expect(accountDefaultsService.resolveAsset("btc", "IBKR")).toEqual({
+      type: "CRYPTO",
+      symbol: "BTC",
+    });
+    expect(accountDefaultsService.resolveAsset("", "IBKR")?.symbol).toBe(
+      "USD",
+    );
+    expect(accountDefaultsService.defaults()).toEqual({
+      "Bank Account": "VND",
+      IBKR: "USD",
+    });
+  });
+});
@@ -0,0 +1,112 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Account performance
+ *
+ * - Vaults roll up to the account they are held in
+ * - Transfers between vaults of one account are not flows
+ * - Cash vaults are left out; the return is money-weighted
+ */
+
+describe("Account Performance Service", () => {
+  const usd = { type: "FIAT", symbol: "USD" };
+  const entry = (
+    vault: string,
+    type: string,
+    usdValue: number,
+    at: string,
+    account?: string,
+  ) => ({ vault, type, asset: usd, amount: usdValue, usdValue, at, account });
+
+  beforeEach(() => {
+    vi.resetModules();
+
+    const entries: Record<string, any[]> = {
+      Spot: [
+        entry("Spot", "DEPOSIT", 1000, "2025-01-01T00:00:00.000Z", "Bank"),
+        entry("Spot", "WITHDRAW", 400, "2025-02-01T00:00:00.000Z", "Earn"),
+      ],
+      Earn: [
+        entry("Earn", "DEPOSIT", 400, "2025-02-01T00:00:00.000Z", "Spot"),
+        entry("Earn", "WITHDRAW", 100, "2025-06-01T00:00:00.000Z", "Bank"),
+      ],
+      Brokerage: [
+        entry("Brokerage", "DEPOSIT", 500, "2025-03-01T00:00:00.000Z"),
+      ],
+      Spend: [entry("Spend", "DEPOSIT", 50, "2025-01-01T00:00:00.000Z")],
+    };
+    const aum: Record<string, number> = {
+      Spot: 700,
+      Earn: 400,
+      Brokerage: 450,
+      Spend: 50,
+    };
+
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAll: () => [
+          { name: "Spot", account: "Crypto Exchange" },
+          { name: "Earn", account: "Crypto Exchange" },
+          { name: "Brokerage" },
+          { name: "Spend" },
+        ],
+        findAllEntries: (name: string) => entries[name] || [],
+      },
+      settingsRepository: {
+        getDefaultSpendingVaultName: () => "Spend",
+        getDefaultIncomeVaultName: () => "Income",
+      },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        vaultStats: async (name: string) => ({ aumUSD: aum[name] }),
+      },
+    }));
+  });
+
+  it("rolls vaults up to accounts and skips internal transfers", async () => {
+    const { accountPerformanceService } = await import(
+      "../src/services/account-performance.service"
+    );
+    const now = new Date("2026-01-01T00:00:00.000Z");
+    const r = await accountPerformanceService.report({}, now);
+
+    expect(r.accounts.map((a) => a.account)).toEqual([
+      "Crypto Exchange",
+      "Brokerage",
+    ]);
+    const exchange = r.accounts[0];
+    expect(exchange).toMatchObject({
+      vaults: ["Earn", "Spot"],
+      depositsUSD: 1000,
+      withdrawalsUSD: 100,
+      netContributedUSD: 900,
+      valueUSD: 1100,
+      valueChangeUSD: 200,
+      roiPercent: 20,
+      firstFlowAt: "2025-01-01T00:00:00.000Z",
+    });
+    // Gained 20% in a year, and the withdrawal came late
+    expect(exchange.moneyWeightedPercent).toBeGreaterThan(19);
+    expect(exchange.moneyWeightedPercent).toBeLessThan(22);
+
+    expect(r.totals).toMatchObject({
+      depositsUSD: 1500,
+      withdrawalsUSD: 100,
+      valueUSD: 1550,
+      valueChangeUSD: 150,
+      roiPercent: 10,
+    });
+  });
+
+  it("filters to one account", async () => {
+    const { accountPerformanceService } = await import(
+      "../src/services/account-performance.service"
+    );
+    const r = await accountPerformanceService.report({
+      account: "crypto exchange",
+    });
+    expect(r.accounts).toHaveLength(1);
+    expect(r.totals.valueUSD).toBe(1100);
+  });
+});
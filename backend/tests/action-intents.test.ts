@@ -0,0 +1,340 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Two-phase actions
+ *
+ * - Preparing books nothing; confirming books the action exactly once,
+ *   and a repeated confirm replays the first response
+ * - A confirm in flight blocks a second one
+ * - client_ref makes preparing idempotent
+ * - A rejected confirm leaves the intent prepared, to retry with fixed
+ *   params; cancelled and expired intents can't be confirmed
+ * - A confirm that fails part way, or is interrupted, takes back what it
+ *   booked, vault entries included
+ * - A running confirm beats, so another process does not take it for an
+ *   interrupted one however long it runs
+ */
+
+describe("Action intents", () => {
+  let store: any;
+  let executed: { action: string; params: any }[] = [];
+  let transactions: any[] = [];
+  let entries: any[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { actionIntents: [] };
+    executed = [];
+    transactions = [];
+    entries = [];
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+    vi.doMock("../src/repositories", async () => {
+      const { ActionIntentRepositoryJson } = await import(
+        "../src/repositories/action-intent.repository"
+      );
+      const { withTransactionEvents, withVaultEntryEvents } = await import(
+        "../src/services/event-bus.service"
+      );
+      return {
+        actionIntentRepository: new ActionIntentRepositoryJson(),
+        transactionRepository: withTransactionEvents({
+          create: (tx: any) => (transactions.push(tx), tx),
+          findById: (id: string) => transactions.find((t) => t.id === id),
+          delete: (id: string) => {
+            const before = transactions.length;
+            transactions = transactions.filter((t) => t.id !== id);
+            return transactions.length < before;
+          },
+        } as any),
+        vaultRepository: withVaultEntryEvents({
+          createEntry: (e: any) => (entries.push(e), e),
+          deleteEntry: (e: any) => {
+            const before = entries.length;
+            entries = entries.filter((x) => x.note !== e.note);
+            return entries.length < before;
+          },
+        } as any),
+      };
+    });
+    for (const m of [
+      "price.service",
+      "transaction.service",
+      "address-book.service",
+      "precision.service",
+      "account-defaults.service",
+      "cash.service",
+    ]) {
+      vi.doMock(`../src/services/${m}`, () => ({}));
+    }
+  });
+
+  // The service, with actions that book one transaction per call unless
+  // the quantity is missing
+  const load = async () => {
+    const { actionService } = await import("../src/services/action.service");
+    vi.spyOn(actionService, "execute").mockImplementation(
+      async (action, params) => {
+        await new Promise((r) => setImmediate(r));
+        if (!(params?.quantity > 0)) {
+          return { status: 400, body: { error: "Invalid spot_buy params" } };
+        }
+        executed.push({ action, params });
+        const id = `tx${executed.length}`;
+        return {
+          status: 201,
+          body: { ok: true, created: 1, transactions: [{ id }] },
+        };
+      },
+    );
+    return actionService;
+  };
+
+  const buildApp = async () => {
+    await load();
+    const { actionsRouter } = await import("../src/handlers/actions.handler");
+    const app = express();
+    app.use(express.json());
+    app.use("/api", actionsRouter);
+    return app;
+  };
+
+  it("books a confirmed action once", async () => {
+    const app = await buildApp();
+    const prepared = await request(app)
+      .post("/api/actions/prepare")
+      .send({ action: "spot_buy", params: { base_asset: "BTC", quantity: 1 } });
+    expect(prepared.status).toBe(201);
+    expect(prepared.body.status).toBe("PREPARED");
+    expect(executed).toHaveLength(0);
+
+    const id = prepared.body.id;
+    const first = await request(app)
+      .post(`/api/actions/intents/${id}/confirm`)
+      .send({ external_ref: "order-1", params: { price_quote: 42000 } });
+    expect(first.status).toBe(201);
+    expect(first.body.created).toBe(1);
+    expect(first.body.replayed).toBe(false);
+    expect(first.body.intent).toMatchObject({
+      status: "CONFIRMED",
+      external_ref: "order-1",
+      transaction_ids: ["tx1"],
+    });
+    expect(executed[0].params).toEqual({
+      base_asset: "BTC",
+      quantity: 1,
+      price_quote: 42000,
+    });
+
+    const again = await request(app)
+      .post(`/api/actions/intents/${id}/confirm`)
+      .send({ external_ref: "order-1" });
+    expect(again.status).toBe(200);
+    expect(again.body.replayed).toBe(true);
+    expect(again.body.transactions).toEqual([{ id: "tx1" }]);
+    expect(executed).toHaveLength(1);
+
+    const other = await request(app)
+      .post(`/api/actions/intents/${id}/confirm`)
+      .send({ external_ref: "order-2" });
+    expect(other.status).toBe(409);
+
+    const cancel = await request(app).post(`/api/actions/intents/${id}/cancel`);
+    expect(cancel.status).toBe(409);
+  });
+
+  it("lets only one of two concurrent confirms through", async () => {
+    const service = await load();
+    const { intent } = service.prepare({
+      action: "spot_buy",
+      params: { quantity: 1 },
+    });
+
+    const results = await Promise.allSettled([
+      service.confirm(intent.id),
+      service.confirm(intent.id),
+    ]);
+    expect(results.map((r) => r.status)).toEqual(["fulfilled", "rejected"]);
+    expect((results[1] as PromiseRejectedResult).reason.statusCode).toBe(409);
+    expect(executed).toHaveLength(1);
+  });
+
+  it("returns the same intent for a repeated client_ref", async () => {
+    const service = await load();
+    const input = { action: "spot_buy", params: {}, client_ref: "k1" };
+
+    const a = service.prepare(input);
+    const b = service.prepare(input);
+    expect(a.created).toBe(true);
+    expect(b.created).toBe(false);
+    expect(b.intent.id).toBe(a.intent.id);
+    expect(service.list()).toHaveLength(1);
+
+    expect(() =>
+      service.prepare({ ...input, action: "transfer" }),
+    ).toThrow(/k1/);
+    expect(() => service.prepare({ action: "teleport", params: {} })).toThrow(
+      /Unknown action/,
+    );
+  });
+
+  it("keeps a rejected intent prepared for a retry", async () => {
+    const app = await buildApp();
+    const { body } = await request(app)
+      .post("/api/actions/prepare")
+      .send({ action: "spot_buy", params: {} });
+
+    const rejected = await request(app)
+      .post(`/api/actions/intents/${body.id}/confirm`)
+      .send({});
+    expect(rejected.status).toBe(400);
+    expect(rejected.body.intent).toMatchObject({
+      status: "PREPARED",
+      error: "Invalid spot_buy params",
+    });
+
+    const retried = await request(app)
+      .post(`/api/actions/intents/${body.id}/confirm`)
+      .send({ params: { quantity: 2 } });
+    expect(retried.status).toBe(201);
+    expect(retried.body.intent.error).toBeNull();
+  });
+
+  it("refuses to confirm cancelled or expired intents", async () => {
+    const service = await load();
+    const now = new Date("2025-01-05T12:00:00.000Z");
+    const later = new Date("2025-01-05T12:30:00.000Z");
+
+    const cancelled = service.prepare({ action: "drip", params: {} }, now);
+    const c = service.cancel(cancelled.intent.id, "Order rejected", now);
+    expect(c).toMatchObject({
+      status: "CANCELLED",
+      cancelReason: "Order rejected",
+    });
+    expect(service.cancel(c.id).status).toBe("CANCELLED");
+    await expect(service.confirm(c.id)).rejects.toThrow(/CANCELLED/);
+
+    const lapsed = service.prepare(
+      { action: "drip", params: { quantity: 1 }, expires_in_seconds: 60 },
+      now,
+    );
+    expect(service.get(lapsed.intent.id, later).status).toBe("EXPIRED");
+    await expect(
+      service.confirm(lapsed.intent.id, {}, later),
+    ).rejects.toThrow(/EXPIRED/);
+    expect(service.list("EXPIRED", later).map((i) => i.id)).toEqual([
+      lapsed.intent.id,
+    ]);
+    expect(executed).toHaveLength(0);
+  });
+
+  it("deletes what a failed confirm booked", async () => {
+    const service = await load();
+    const { transactionRepository } = await import("../src/repositories");
+    let down = true;
+    let during: string[] = [];
+    const { vaultRepository } = await import("../src/repositories");
+    vi.mocked(service.execute).mockImplementation(async (_action, params) => {
+      // The first leg and its vault entry are booked before the second
+      // leg's price fails
+      transactionRepository.create({ id: "out", params } as any);
+      vaultRepository.createEntry({ vault: "Spend", note: "out" } as any);
+      await new Promise((r) => setImmediate(r));
+      during = service.get(intent.id).transactionIds;
+      if (down) throw new Error("Price service unavailable");
+      transactionRepository.create({ id: "in" } as any);
+      const txs = [{ id: "out" }, { id: "in" }];
+      return { status: 201, body: { ok: true, created: 2, transactions: txs } };
+    });
+    const { intent } = service.prepare({ action: "transfer", params: {} });
+
+    const failed = await service.confirm(intent.id);
+    expect(during).toEqual(["out"]);
+    expect(failed.response.status).toBe(400);
+    expect(failed.intent).toMatchObject({
+      status: "PREPARED",
+      transactionIds: [],
+      error: "Price service unavailable",
+    });
+    expect(transactions).toEqual([]);
+    expect(entries).toEqual([]);
+
+    down = false;
+    const retried = await service.confirm(intent.id);
+    expect(retried.intent.status).toBe("CONFIRMED");
+    expect(transactions.map((t) => t.id)).toEqual(["out", "in"]);
+    // Once, not once per attempt
+    expect(entries.map((e) => e.note)).toEqual(["out"]);
+  });
+
+  it("rolls back a confirm that was interrupted", async () => {
+    const service = await load();
+    const at = "2025-01-05T12:00:00.000Z";
+    store.actionIntents.push({
+      id: "stuck",
+      action: "transfer",
+      params: {},
+      status: "CONFIRMING",
+      transactionIds: ["out"],
+      createdAt: at,
+      updatedAt: at,
+      expiresAt: "2025-01-05T12:15:00.000Z",
+    });
+    transactions = [{ id: "out" }];
+
+    // Still within the timeout it might be running elsewhere
+    const soon = new Date("2025-01-05T12:03:00.000Z");
+    expect(service.get("stuck", soon).status).toBe("CONFIRMING");
+    expect(() => service.cancel("stuck", undefined, soon)).toThrow(
+      /CONFIRMING/,
+    );
+
+    const later = new Date("2025-01-05T12:10:00.000Z");
+    expect(service.get("stuck", later)).toMatchObject({
+      status: "PREPARED",
+      transactionIds: [],
+      error: "Confirm was interrupted",
+    });
+    expect(transactions).toEqual([]);
+    expect(service.cancel("stuck", undefined, later).status).toBe(
+      "CANCELLED",
+    );
+  });
+
+  it("keeps a long confirm alive for other processes", async () => {
+    vi.useFakeTimers({ toFake: ["setInterval", "clearInterval", "Date"] });
+    vi.setSystemTime(new Date("2025-01-05T12:00:00.000Z"));
+    try {
+      const service = await load();
+      let finish!: () => void;
+      vi.mocked(service.execute).mockImplementation(async () => {
+        await new Promise<void>((r) => (finish = r));
+        return {
+          status: 201,
+          body: { ok: true, created: 0, transactions: [] },
+        };
+      });
+      const { intent } = service.prepare({ action: "transfer", params: {} });
+      const running = service.confirm(intent.id);
+
+      // Slow price lookups: well past the timeout, still beating
+      vi.advanceTimersByTime(10 * 60 * 1000);
+
+      // A second process, which has no confirm of its own in flight
+      vi.resetModules();
+      const other = await load();
+      expect(other.get(intent.id).status).toBe("CONFIRMING");
+
+      finish();
+      expect((await running).intent.status).toBe("CONFIRMED");
+    } finally {
+      vi.useRealTimers();
+    }
+  });
+});
@@ -0,0 +1,131 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Activity feed
+ *
+ * - Items from every source are merged newest first
+ * - Import pending actions are grouped per batch
+ * - Kind and date filters apply before pagination
+ */
+
+describe("Activity Service", () => {
+  beforeEach(() => {
+    vi.resetModules();
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => [
+          {
+            id: "t1",
+            type: "EXPENSE",
+            asset: { type: "FIAT", symbol: "VND" },
+            amount: 120000,
+            usdAmount: 4.8,
+            createdAt: "2025-03-11T09:30:00.000Z",
+            account: "Spend",
+          },
+        ],
+      },
+      vaultRepository: {
+        findAll: () => [
+          {
+            name: "Spend",
+            status: "ACTIVE",
+            createdAt: "2025-01-01T00:00:00.000Z",
+          },
+        ],
+        findAllEntries: () => [
+          {
+            vault: "Spend",
+            type: "DEPOSIT",
+            asset: { type: "FIAT", symbol: "VND" },
+            amount: 1000000,
+            usdValue: 40,
+            at: "2025-03-10T00:00:00.000Z",
+          },
+        ],
+      },
+      pendingActionsRepository: {
+        findAll: () => [
+          {
+            id: "p1",
+            source: "bank_statement_excel",
+            batch_id: "b1",
+            status: "accepted",
+            created_at: "2025-03-11T08:00:01.000Z",
+          },
+          {
+            id: "p2",
+            source: "bank_statement_excel",
+            batch_id: "b1",
+            status: "pending",
+            created_at: "2025-03-11T08:00:00.000Z",
+          },
+        ],
+      },
+      adminRepository: {
+        findAllTypes: () => [],
+        findAllAccounts: () => [],
+        findAllAssets: () => [
+          { id: 1, symbol: "BTC", created_at: "2024-12-01T00:00:00.000Z" },
+        ],
+        findAllTags: () => [],
+      },
+    }));
+    vi.doMock("../src/services/stablecoin.service", () => ({
+      stablecoinService: {
+        getAlerts: () => [
+          {
+            symbol: "USDC",
+            kind: "DEPEG",
+            priceUSD: 0.95,
+            deviationPercent: 5,
+            at: "2025-03-11T10:00:00.000Z",
+          },
+        ],
+      },
+    }));
+    vi.doMock("../src/services/merge.service", () => ({
+      mergeService: { log: () => [] },
+    }));
+  });
+
+  it("merges every source newest first", async () => {
+    const { activityService } = await import(
+      "../src/services/activity.service"
+    );
+    const { items, total } = activityService.list();
+
+    expect(total).toBe(6);
+    expect(items.map((i) => i.kind)).toEqual([
+      "price_alert",
+      "transaction",
+      "import",
+      "vault_entry",
+      "vault",
+      "admin",
+    ]);
+    expect(items[2]).toMatchObject({
+      id: "import:b1",
+      at: "2025-03-11T08:00:00.000Z",
+      meta: { total: 2, accepted: 1, pending: 1 },
+    });
+  });
+
+  it("filters by kind and date before paging", async () => {
+    const { activityService } = await import(
+      "../src/services/activity.service"
+    );
+    const r = activityService.list({
+      kinds: ["transaction", "vault_entry", "admin"],
+      start: "2025-03-01",
+      end: "2025-03-11",
+      limit: 1,
+      offset: 1,
+    });
+
+    expect(r.total).toBe(2);
+    expect(r.items).toHaveLength(1);
+    expect(r.items[0].kind).toBe("vault_entry");
+  });
+});
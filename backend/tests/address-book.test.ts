@@ -0,0 +1,89 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Address book
+ *
+ * - Entries resolve by id, name or address
+ * - Unknown transfer destinations produce a warning
+ * - Asset/chain mismatches against an entry produce a warning
+ */
+
+type AddressBookEntry = import("../src/types").AddressBookEntry;
+
+describe("Address Book Service", () => {
+  let entries: AddressBookEntry[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    entries = [];
+
+    vi.doMock("../src/repositories", () => ({
+      addressBookRepository: {
+        findAll: () => entries,
+        findById: (id: string) => entries.find((e) => e.id === id),
+        findByName: (name: string) =>
+          entries.find((e) => e.name.toLowerCase() === name.toLowerCase()),
+        create: (e: AddressBookEntry) => {
+          entries.push(e);
+          return e;
+        },
+      },
+      vaultRepository: {
+        findByName: (name: string) =>
+          name === "Savings" ? { name, status: "ACTIVE" } : undefined,
+      },
+      adminRepository: {
+        findAllAccounts: () => [{ id: 1, name: "Bank" }],
+      },
+    }));
+  });
+
+  it("resolves entries by name or address", async () => {
+    const { addressBookService } = await import(
+      "../src/services/address-book.service"
+    );
+    const entry = addressBookService.createEntry({
+      name: "Binance ETH",
+      kind: "EXCHANGE_DEPOSIT",
+      address: "0xAbC123",
+      chain: "Ethereum",
+      asset: "eth",
+    });
+
+    expect(entry).toMatchObject({ chain: "ethereum", asset: "ETH" });
+    expect(addressBookService.resolve("binance eth")?.id).toBe(entry.id);
+    expect(addressBookService.resolve("0xabc123")?.id).toBe(entry.id);
+    expect(() =>
+      addressBookService.createEntry({
+        name: "BINANCE ETH",
+        kind: "OTHER",
+        address: "x",
+      }),
+    ).toThrow(/already exists/);
+  });
+
+  it("warns about unknown or mismatched destinations", async () => {
+    const { addressBookService } = await import(
+      "../src/services/address-book.service"
+    );
+    addressBookService.createEntry({
+      name: "Binance ETH",
+      kind: "EXCHANGE_DEPOSIT",
+      address: "0xabc123",
+      chain: "ethereum",
+      asset: "ETH",
+    });
+
+    const check = (toAccount: string, asset?: string, chain?: string) =>
+      addressBookService.checkDestination({ toAccount, asset, chain })
+        .warnings;
+
+    expect(check("Savings")).toEqual([]);
+    expect(check("bank")).toEqual([]);
+    expect(check("Binance ETH", "ETH", "ethereum")).toEqual([]);
+    expect(check("Binanse")).toEqual([
+      'Unknown transfer destination "Binanse"',
+    ]);
+    expect(check("Binance ETH", "USDT", "tron")).toHaveLength(2);
+  });
+});
@@ -0,0 +1,79 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Admin assets registry updates
+ *
+ * - Kinds and decimals are validated like on create
+ * - Rejected updates leave the asset untouched
+ */
+
+describe("PUT /admin/assets/:id", () => {
+  let assets: any[] = [];
+  const updateAsset = vi.fn((id: number, updates: Record<string, unknown>) => {
+    const a = assets.find((x) => x.id === id);
+    return a ? Object.assign(a, updates) : undefined;
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    assets = [{ id: 1, symbol: "VNM", kind: "EQUITY", decimals: 0 }];
+    updateAsset.mockClear();
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAssetById: (id: number) => assets.find((a) => a.id === id),
+        findAllAssets: () => assets,
+        updateAsset,
+      },
+    }));
+  });
+
+  const app = async () => {
+    const { adminRouter } = await import("../src/handlers/admin.handler");
+    const a = express();
+    a.use(express.json());
+    a.use("/api", adminRouter);
+    return a;
+  };
+
+  it("normalizes the kind", async () => {
+    const res = await request(await app())
+      .put("/api/admin/assets/1")
+      .send({ kind: " crypto " });
+
+    expect(res.status).toBe(200);
+    expect(res.body.kind).toBe("CRYPTO");
+  });
+
+  it("rejects an unknown kind", async () => {
+    const res = await request(await app())
+      .put("/api/admin/assets/1")
+      .send({ kind: "bogus" });
+
+    expect(res.status).toBe(400);
+    expect(res.body.error).toMatch(/kind must be one of/);
+    expect(updateAsset).not.toHaveBeenCalled();
+    expect(assets[0].kind).toBe("EQUITY");
+  });
+
+  it("rejects decimals outside 0-18", async () => {
+    const a = await app();
+    for (const decimals of [19, -1, 2.5, "8"]) {
+      const res = await request(a)
+        .put("/api/admin/assets/1")
+        .send({ decimals });
+      expect(res.status).toBe(400);
+      expect(res.body.error).toBe(
+        "decimals must be an integer between 0 and 18",
+      );
+    }
+    expect(updateAsset).not.toHaveBeenCalled();
+
+    const ok = await request(a)
+      .put("/api/admin/assets/1")
+      .send({ decimals: 4 });
+    expect(ok.status).toBe(200);
+    expect(ok.body.decimals).toBe(4);
+  });
+});
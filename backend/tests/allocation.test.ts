@@ -0,0 +1,221 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Allocation glidepaths
+ *
+ * - Targets move linearly between the endpoints and hold outside them
+ * - Targets may not add up to more than 100% at any point
+ * - Drift compares holdings by asset class with the scheduled target
+ * - Constraints flag single assets and classes past their limits
+ * - A breach is notified once, and again only after it cleared
+ */
+
+type AllocationGlidepath = import("../src/types").AllocationGlidepath;
+
+describe("Allocation Service", () => {
+  let paths: AllocationGlidepath[] = [];
+  const settings = new Map<string, string>();
+  const send = vi.fn(async () => undefined);
+
+  const crypto = {
+    assetClass: "CRYPTO" as const,
+    startPercent: 40,
+    endPercent: 20,
+    startAt: "2025-01-01T00:00:00.000Z",
+    endAt: "2028-01-01T00:00:00.000Z",
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    paths = [];
+    settings.clear();
+    send.mockClear();
+
+    vi.doMock("../src/repositories", () => ({
+      glidepathRepository: {
+        findAll: () => paths,
+        findById: (id: string) => paths.find((g) => g.id === id),
+        create: (g: AllocationGlidepath) => {
+          paths.push(g);
+          return g;
+        },
+        update: (id: string, updates: Partial<AllocationGlidepath>) => {
+          const g = paths.find((x) => x.id === id);
+          if (g) Object.assign(g, updates);
+          return g;
+        },
+      },
+      adminRepository: { findAllAssets: () => [] },
+      settingsRepository: {
+        getSetting: (k: string) => settings.get(k),
+        setSetting: (k: string, v: string) => settings.set(k, v),
+        getAllocationConstraints: () =>
+          JSON.parse(settings.get("allocationConstraints") || "[]"),
+        setAllocationConstraints: (c: unknown) =>
+          settings.set("allocationConstraints", JSON.stringify(c)),
+      },
+    }));
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        generateReport: async () => ({
+          holdings: [
+            {
+              asset: { type: "CRYPTO", symbol: "BTC" },
+              balance: 0.5,
+              rateUSD: 70000,
+              valueUSD: 35000,
+            },
+            {
+              asset: { type: "FIAT", symbol: "USD" },
+              balance: 65000,
+              rateUSD: 1,
+              valueUSD: 65000,
+            },
+          ],
+          totals: { holdingsUSD: 100000 },
+        }),
+      },
+    }));
+  });
+
+  it("interpolates the target along the glidepath", async () => {
+    const { allocationService, targetPercentAt } = await import(
+      "../src/services/allocation.service"
+    );
+    const g = allocationService.createGlidepath(crypto);
+
+    expect(targetPercentAt(g, new Date("2024-06-01"))).toBe(40);
+    expect(targetPercentAt(g, new Date("2026-07-02T12:00:00Z"))).toBeCloseTo(
+      30,
+      1,
+    );
+    expect(targetPercentAt(g, new Date("2030-01-01"))).toBe(20);
+  });
+
+  it("rejects targets adding up to more than 100%", async () => {
+    const { allocationService } = await import(
+      "../src/services/allocation.service"
+    );
+    allocationService.createGlidepath(crypto);
+
+    expect(() =>
+      allocationService.createGlidepath({ ...crypto, startPercent: 10 }),
+    ).toThrow(/already exists/);
+    // 40% crypto + 60% fiat on the start date is fully allocated
+    expect(() =>
+      allocationService.createGlidepath({
+        assetClass: "FIAT",
+        startPercent: 60,
+      }),
+    ).not.toThrow();
+    expect(() =>
+      allocationService.createGlidepath({
+        assetClass: "EQUITY",
+        startPercent: 5,
+        startAt: "2030-01-01T00:00:00.000Z",
+      }),
+    ).toThrow(/add up to 105%/);
+  });
+
+  it("reports drift against the scheduled target", async () => {
+    const { allocationService } = await import(
+      "../src/services/allocation.service"
+    );
+    allocationService.createGlidepath(crypto);
+
+    const r = await allocationService.getDrift(new Date("2028-06-01"));
+    const byClass = Object.fromEntries(r.rows.map((x) => [x.assetClass, x]));
+    expect(byClass.CRYPTO).toMatchObject({
+      currentPercent: 35,
+      targetPercent: 20,
+      driftPercent: 15,
+      driftUSD: 15000,
+    });
+    expect(byClass.FIAT.targetPercent).toBeUndefined();
+    expect(r.maxAbsDriftPercent).toBe(15);
+  });
+
+  const report = (rows: Array<[string, number]>) =>
+    ({
+      holdings: rows.map(([symbol, valueUSD]) => ({
+        asset: { type: "CRYPTO", symbol },
+        balance: 1,
+        rateUSD: valueUSD,
+        valueUSD,
+      })),
+      liabilities: [],
+      receivables: [],
+      totals: { holdingsUSD: rows.reduce((s, [, v]) => s + v, 0) },
+    }) as any;
+
+  it("flags holdings past their constraints", async () => {
+    const { allocationService, constraintViolations } = await import(
+      "../src/services/allocation.service"
+    );
+    allocationService.setConstraints([
+      { scope: "ASSET", maxPercent: 10 },
+      { scope: "ASSET_CLASS", assetClass: "CASH", minPercent: 20 },
+      { scope: "ASSET", symbol: "eth", minPercent: 5 },
+    ]);
+
+    const v = constraintViolations(
+      report([
+        ["BTC", 40000],
+        ["SOL", 10000],
+        ["USDT", 15000],
+        ["USD", 35000],
+      ]),
+      allocationService.getConstraints(),
+    );
+    // SOL at exactly 10% meets the maximum; cash is 50%
+    expect(v.map((x) => [x.key, x.currentPercent, x.gapUSD])).toEqual([
+      ["ASSET:BTC:max", 40, 30000],
+      ["ASSET:ETH:min", 0, 5000],
+    ]);
+    expect(
+      constraintViolations(
+        report([
+          ["BTC", 9],
+          ["ETH", 81],
+          ["USD", 10],
+        ]),
+        [{ scope: "ASSET_CLASS", assetClass: "CASH", minPercent: 20 }],
+      ).map((x) => [x.key, x.gapUSD]),
+    ).toEqual([["ASSET_CLASS:CASH:min", 10]]);
+  });
+
+  it("notifies a breach once per occurrence", async () => {
+    const { allocationService } = await import(
+      "../src/services/allocation.service"
+    );
+    allocationService.setConstraints([{ scope: "ASSET", maxPercent: 40 }]);
+    const before = report([
+      ["BTC", 35],
+      ["USD", 65],
+    ]);
+    // BTC rallies from 35% to 50%
+    const after = report([
+      ["BTC", 65],
+      ["USD", 65],
+    ]);
+
+    await allocationService.monitorConstraints(before);
+    expect(send).not.toHaveBeenCalled();
+    await allocationService.monitorConstraints(after);
+    await allocationService.monitorConstraints(after);
+    expect(send).toHaveBeenCalledTimes(1);
+    expect(send.mock.calls[0]).toMatchObject([
+      {
+        kind: "allocation_constraint",
+        data: { key: "ASSET:BTC:max", limitPercent: 40 },
+      },
+    ]);
+
+    await allocationService.monitorConstraints(before);
+    await allocationService.monitorConstraints(after);
+    expect(send).toHaveBeenCalledTimes(2);
+  });
+});
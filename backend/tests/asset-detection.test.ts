@@ -1,5 +1,27 @@
-import { describe, it, expect } from "vitest";
-import { createAssetFromSymbol, isCryptoSymbol } from "../src/utils/asset.util";
+import { describe, it, expect, vi } from "vitest";
+import {
+  createAssetFromSymbol,
+  getAssetKind,
+  invalidateAssetKinds,
+  isCryptoSymbol,
+} from "../src/utils/asset.util";
+
+// Admin assets registry: kinds here override the symbol heuristics
+const registry = vi.hoisted(() => ({
+  assets: [
+    { id: 1, symbol: "VNM", kind: "EQUITY" },
+    { id: 2, symbol: "XYZ", kind: "CRYPTO" },
+  ],
+  reads: 0,
+}));
+vi.mock("../src/repositories", () => ({
+  adminRepository: {
+    findAllAssets: () => {
+      registry.reads++;
+      return registry.assets;
+    },
+  },
+}));
 
 describe("Asset Type Detection", () => {
   describe("isCryptoSymbol", () => {
@@ -58,4 +80,37 @@ describe("Asset Type Detection", () => {
       });
     });
   });
+
+  describe("getAssetKind", () => {
+    it("should classify stablecoins and fiat without the registry", () => {
+      expect(getAssetKind("USDC")).toBe("STABLECOIN");
+      expect(getAssetKind("BTC")).toBe("CRYPTO");
+      expect(getAssetKind("EUR")).toBe("FIAT");
+      expect(getAssetKind("spy")).toBe("EQUITY");
+    });
+
+    it("should prefer the kind stored in the assets table", () => {
+      expect(getAssetKind("vnm")).toBe("EQUITY");
+      expect(createAssetFromSymbol("XYZ")).toEqual({
+        type: "CRYPTO",
+        symbol: "XYZ",
+      });
+    });
+
+    it("reads the registry once until an asset is written", () => {
+      invalidateAssetKinds();
+      registry.reads = 0;
+      for (const sym of ["BTC", "EUR", "VNM", "XYZ", "SPY"]) {
+        getAssetKind(sym);
+      }
+      expect(registry.reads).toBe(1);
+
+      registry.assets = [{ id: 1, symbol: "VNM", kind: "CRYPTO" }];
+      expect(getAssetKind("VNM")).toBe("EQUITY");
+      invalidateAssetKinds();
+      expect(getAssetKind("VNM")).toBe("CRYPTO");
+      expect(getAssetKind("XYZ")).toBe("FIAT");
+      expect(registry.reads).toBe(2);
+    });
+  });
 });
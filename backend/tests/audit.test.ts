@@ -0,0 +1,205 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Audit log
+ *
+ * - Creates, updates and deletes are recorded with before/after snapshots
+ * - Each entry names the user of the request that made the write
+ * - The log is read newest first, filtered and paged by cursor
+ * - A bulk write is recorded in one write of the JSON store, which keeps
+ *   the newest entries only; ids keep counting up past dropped ones
+ */
+
+describe("Audit Log", () => {
+  let store: any;
+  let writes = 0;
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { auditLog: [] };
+    writes = 0;
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        writes += 1;
+        store = s;
+      },
+    }));
+  });
+
+  const tagRepo = () => {
+    const tags = new Map<number, { id: number; name: string }>();
+    return {
+      findById: (id: number) => tags.get(id),
+      create: (name: string) => {
+        const tag = { id: tags.size + 1, name };
+        tags.set(tag.id, tag);
+        return tag;
+      },
+      update: (id: number, name: string) => {
+        if (!tags.has(id)) return undefined;
+        tags.set(id, { id, name });
+        return tags.get(id);
+      },
+      delete: (id: number) => tags.delete(id),
+    };
+  };
+
+  it("records each write with its snapshots and user", async () => {
+    const { withAudit, AuditRepositoryJson } = await import(
+      "../src/repositories/audit.repository"
+    );
+    const { withActor } = await import("../src/utils/audit-context.util");
+    const log = new AuditRepositoryJson();
+    const repo = withAudit(
+      tagRepo(),
+      [
+        {
+          entity: "admin_tag",
+          find: (r, id) => r.findById(id),
+          idOf: (t) => t.id,
+          create: ["create"],
+          update: ["update"],
+          remove: ["delete"],
+        },
+      ],
+      (entries) => log.appendMany(entries),
+    );
+
+    repo.create("Food");
+    withActor("alice", () => repo.update(1, "Groceries"));
+    expect(repo.update(9, "Missing")).toBeUndefined();
+    repo.delete(1);
+
+    const entries = log.findPage({}, { limit: 10 }).items.reverse();
+    expect(
+      entries.map((e) => [e.action, e.entityId, e.actor, e.before, e.after]),
+    ).toEqual([
+      ["create", "1", "system", undefined, { id: 1, name: "Food" }],
+      [
+        "update",
+        "1",
+        "alice",
+        { id: 1, name: "Food" },
+        { id: 1, name: "Groceries" },
+      ],
+      ["delete", "1", "system", { id: 1, name: "Groceries" }, undefined],
+    ]);
+  });
+
+  it("never fails a write because the log could not be written", async () => {
+    const { withAudit } = await import("../src/repositories/audit.repository");
+    const repo = withAudit(
+      tagRepo(),
+      [
+        {
+          entity: "admin_tag",
+          find: (r, id) => r.findById(id),
+          idOf: (t) => t.id,
+          create: ["create"],
+        },
+      ],
+      () => {
+        throw new Error("disk full");
+      },
+    );
+    expect(repo.create("Food")).toEqual({ id: 1, name: "Food" });
+  });
+
+  it("filters and pages the log newest first", async () => {
+    const { AuditRepositoryJson } = await import(
+      "../src/repositories/audit.repository"
+    );
+    const log = new AuditRepositoryJson();
+    for (let day = 1; day <= 5; day++) {
+      log.append({
+        at: `2025-03-0${day}T10:00:00.000Z`,
+        entity: day % 2 ? "transaction" : "vault",
+        entityId: `e${day}`,
+        action: "update",
+        actor: "api",
+      });
+    }
+
+    const first = log.findPage({ entity: "transaction" }, { limit: 2 });
+    expect(first.items.map((e) => e.entityId)).toEqual(["e5", "e3"]);
+    const second = log.findPage(
+      { entity: "transaction" },
+      { limit: 2, cursor: first.nextCursor },
+    );
+    expect(second.items.map((e) => e.entityId)).toEqual(["e1"]);
+    expect(second.nextCursor).toBeUndefined();
+
+    const march2to3 = log.findPage(
+      { start: "2025-03-02", end: "2025-03-03T23:59:59.999Z" },
+      { limit: 10 },
+    );
+    expect(march2to3.items.map((e) => e.entityId)).toEqual(["e3", "e2"]);
+  });
+
+  it("records a bulk create in one write", async () => {
+    const { withAudit, AuditRepositoryJson } = await import(
+      "../src/repositories/audit.repository"
+    );
+    const log = new AuditRepositoryJson();
+    const repo = withAudit(
+      {
+        createMany: (names: string[]) =>
+          names.map((name, i) => ({ id: i + 1, name })),
+      },
+      [
+        {
+          entity: "admin_tag",
+          find: () => undefined,
+          idOf: (t) => t.id,
+          create: ["createMany"],
+        },
+      ],
+      (entries) => log.appendMany(entries),
+    );
+
+    repo.createMany(["Food", "Rent", "Travel"]);
+    expect(writes).toBe(1);
+    expect(store.auditLog.map((e: any) => [e.id, e.entityId])).toEqual([
+      [1, "1"],
+      [2, "2"],
+      [3, "3"],
+    ]);
+    expect(store.auditNextId).toBe(4);
+
+    repo.createMany([]);
+    expect(writes).toBe(1);
+  });
+
+  it("keeps the newest entries in the JSON store", async () => {
+    const { AuditRepositoryJson, JSON_AUDIT_LOG_LIMIT } = await import(
+      "../src/repositories/audit.repository"
+    );
+    const entry = (entityId: string) => ({
+      at: "2025-03-01T10:00:00.000Z",
+      entity: "transaction" as const,
+      entityId,
+      action: "update" as const,
+      actor: "api",
+    });
+    // A log from before the counter: the next id follows the highest
+    store.auditLog = Array.from({ length: JSON_AUDIT_LOG_LIMIT }, (_, i) => ({
+      ...entry(`old${i + 1}`),
+      id: i + 1,
+    }));
+    const log = new AuditRepositoryJson();
+
+    const [a, b] = log.appendMany([entry("a"), entry("b")]);
+    expect([a.id, b.id]).toEqual([
+      JSON_AUDIT_LOG_LIMIT + 1,
+      JSON_AUDIT_LOG_LIMIT + 2,
+    ]);
+    expect(store.auditLog).toHaveLength(JSON_AUDIT_LOG_LIMIT);
+    expect(store.auditLog[0].entityId).toBe("old3");
+    expect(store.auditLog[JSON_AUDIT_LOG_LIMIT - 1].entityId).toBe("b");
+
+    // Ids are not reused once older entries are gone
+    store.auditLog = [];
+    expect(log.append(entry("c")).id).toBe(JSON_AUDIT_LOG_LIMIT + 3);
+  });
+});
@@ -0,0 +1,280 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Restore modes for transactions in a backup
+ *
+ * - Conflicts are keyed by id and compared by content hash
+ * - Content already stored under another id is reported as a duplicate
+ * - skip-existing / overwrite / merge-newer resolve conflicts differently
+ * - Transactions in the trash conflict by id too; overwriting restores them
+ * - Sealed backups are checked against their version and checksums
+ * - Restored transactions get the vaults, vault entries and balances they
+ *   imply; links to missing loans, projects and transfer legs are reported
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+function tx(id: string, overrides: Partial<Transaction> = {}): Transaction {
+  return {
+    id,
+    type: "EXPENSE",
+    asset: { type: "FIAT", symbol: "USD" },
+    amount: 10,
+    createdAt: "2025-01-01T00:00:00.000Z",
+    account: "Spend",
+    rate: {
+      asset: { type: "FIAT", symbol: "USD" },
+      rateUSD: 1,
+      timestamp: "2025-01-01T00:00:00.000Z",
+      source: "FIXED",
+    },
+    usdAmount: 10,
+    ...overrides,
+  } as Transaction;
+}
+
+describe("Backup Service", () => {
+  let txs: Transaction[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    txs = [
+      tx("same"),
+      tx("edited", { note: "local", updatedAt: "2025-02-01T00:00:00.000Z" }),
+      tx("stale", { note: "local", updatedAt: "2025-02-01T00:00:00.000Z" }),
+      tx("local-copy", { amount: 99, usdAmount: 99 }),
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => txs.filter((t) => !t.deletedAt),
+        findDeleted: () => txs.filter((t) => t.deletedAt),
+        create: (t: Transaction) => {
+          if (txs.some((x) => x.id === t.id)) {
+            throw new Error("UNIQUE constraint failed: transactions.id");
+          }
+          txs.push(t);
+          return t;
+        },
+        update: (id: string, updates: Partial<Transaction>) => {
+          const t = txs.find((x) => x.id === id && !x.deletedAt);
+          if (t) Object.assign(t, updates);
+          return t;
+        },
+        restore: (id: string) => {
+          const t = txs.find((x) => x.id === id && x.deletedAt);
+          if (t) delete t.deletedAt;
+          return t;
+        },
+      },
+    }));
+  });
+
+  const backup = () => [
+    tx("same", { note: undefined }),
+    tx("edited", { note: "backup", updatedAt: "2025-03-01T00:00:00.000Z" }),
+    tx("stale", { note: "backup", updatedAt: "2025-01-15T00:00:00.000Z" }),
+    tx("copy-of-local", { amount: 99, usdAmount: 99 }),
+    tx("brand-new", { amount: 5, usdAmount: 5 }),
+  ];
+
+  it("reports conflicts by id and hash without writing", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    const plan = backupService.planTransactions(backup(), "skip-existing");
+
+    expect(plan.insert).toEqual(["brand-new"]);
+    expect(plan.identical).toEqual(["same"]);
+    expect(plan.duplicates).toEqual([
+      expect.objectContaining({
+        id: "copy-of-local",
+        existingId: "local-copy",
+      }),
+    ]);
+    expect(plan.conflicts.map((c) => [c.id, c.resolution])).toEqual([
+      ["edited", "skip"],
+      ["stale", "skip"],
+    ]);
+    expect(plan.conflicts[0].existingHash).not.toBe(
+      plan.conflicts[0].incomingHash,
+    );
+    expect(txs).toHaveLength(4);
+  });
+
+  it("merge-newer only overwrites transactions edited later", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    const r = backupService.restoreTransactions(backup(), "merge-newer");
+
+    expect(r).toMatchObject({ inserted: 1, overwritten: 1, skipped: 3 });
+    expect(txs.find((t) => t.id === "edited")?.note).toBe("backup");
+    expect(txs.find((t) => t.id === "stale")?.note).toBe("local");
+    expect(txs.some((t) => t.id === "copy-of-local")).toBe(false);
+  });
+
+  it("overwrite replaces every conflicting transaction", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    const r = backupService.restoreTransactions(backup(), "overwrite");
+
+    expect(r).toMatchObject({ inserted: 1, overwritten: 2, skipped: 2 });
+    expect(txs.find((t) => t.id === "stale")?.note).toBe("backup");
+  });
+
+  it("restores a backup copy of a transaction in the trash", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    txs.push(
+      tx("trashed", {
+        note: "local",
+        updatedAt: "2025-02-01T00:00:00.000Z",
+        deletedAt: "2025-04-01T00:00:00.000Z",
+      }),
+    );
+    const incoming = [
+      tx("trashed", { note: "backup", updatedAt: "2025-03-01T00:00:00.000Z" }),
+    ];
+
+    const plan = backupService.planTransactions(incoming, "merge-newer");
+    expect(plan.insert).toEqual([]);
+    // Deleted after the backup's edit, so the deletion stands
+    expect(plan.conflicts).toEqual([
+      expect.objectContaining({
+        id: "trashed",
+        existingUpdatedAt: "2025-04-01T00:00:00.000Z",
+        existingDeleted: true,
+        resolution: "skip",
+      }),
+    ]);
+
+    const r = backupService.restoreTransactions(incoming, "overwrite");
+    expect(r).toMatchObject({ inserted: 0, overwritten: 1, failed: [] });
+    expect(txs.filter((t) => t.id === "trashed")).toEqual([
+      expect.objectContaining({ note: "backup" }),
+    ]);
+    expect(txs.find((t) => t.id === "trashed")!.deletedAt).toBeUndefined();
+  });
+
+  it("verifies checksums and migrates unversioned backups", async () => {
+    const { backupService, BACKUP_VERSION } = await import(
+      "../src/services/backup.service"
+    );
+    const sealed = backupService.seal({
+      transactions: txs,
+      vaults: [],
+      loans: [],
+      projects: [],
+      types: [],
+      accounts: [],
+      assets: [],
+      tags: [],
+      pending_actions: [],
+      settings: { default_spending_vault: "Spend" },
+    });
+    // Survives a JSON round trip with keys in another order
+    const copy = JSON.parse(JSON.stringify(sealed));
+    copy.transactions[0] = Object.fromEntries(
+      Object.entries(copy.transactions[0]).reverse(),
+    );
+    expect(backupService.open(copy)).toMatchObject({
+      version: BACKUP_VERSION,
+      verified: true,
+    });
+
+    copy.transactions[0].amount = 1000;
+    expect(() => backupService.open(copy)).toThrow(
+      /Checksum mismatch in transactions/,
+    );
+    expect(backupService.open(copy, { verify: false }).verified).toBe(false);
+
+    expect(() => backupService.open({ ...copy, version: 99 })).toThrow(
+      /newer than this server supports/,
+    );
+    const legacy = backupService.open({ transactions: [] });
+    expect(legacy.version).toBe(1);
+    expect(legacy.warnings[0]).toMatch(/no checksums/);
+  });
+});
+
+describe("Backup relink", () => {
+  const fix = vi.fn();
+  const ensureVault = vi.fn();
+  let txs: Transaction[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    fix.mockReset().mockResolvedValue({ vault_mismatch: 1 });
+    ensureVault.mockReset().mockImplementation((n: string) => n === "Spend");
+    txs = [
+      tx("lunch"),
+      tx("salary", { type: "INCOME", account: "Income" }),
+      tx("repay", { type: "REPAY", account: "Bank", loanId: "loan-1" }),
+      tx("trip", { account: "Bank", projectId: "gone" }),
+      tx("out", { type: "TRANSFER_OUT", account: "Bank", transferId: "t1" }),
+      tx("untouched", { projectId: "gone" }),
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => txs },
+      vaultRepository: { findAll: () => [{ name: "Income" }] },
+      loanRepository: { findById: () => undefined },
+      projectRepository: { findById: () => undefined },
+      settingsRepository: {
+        getDefaultSpendingVaultName: () => "Spend",
+        getDefaultIncomeVaultName: () => "Income",
+      },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: { ensureVault },
+    }));
+    vi.doMock("../src/services/consistency.service", () => ({
+      consistencyService: { fix },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        checkTransferPairs: () => ({
+          checked: 1,
+          issues: [
+            {
+              transferId: "t1",
+              kind: "MISSING_IN",
+              out: txs.find((t) => t.id === "out"),
+            },
+          ],
+        }),
+      },
+    }));
+    vi.doMock("../src/services/account-balance.service", () => ({
+      accountBalanceService: { rebuild: () => 4 },
+    }));
+  });
+
+  it("rebuilds vaults a transactions-only backup implies", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    const ids = ["lunch", "salary", "repay", "trip", "out"];
+    const r = await backupService.relink(ids, { transactions: [] });
+
+    expect(r.vaultsCreated).toEqual(["Spend"]);
+    expect(fix).toHaveBeenCalledWith(
+      ["vault_mismatch"],
+      expect.any(Date),
+      ["Spend", "Income"],
+    );
+    expect(r.vaultEntriesAdded).toBe(1);
+    expect(r.accountBalances).toBe(4);
+    expect(r.orphans).toEqual([
+      { transactionId: "repay", link: "loan", ref: "loan-1" },
+      { transactionId: "trip", link: "project", ref: "gone" },
+      { transactionId: "out", link: "transfer", ref: "t1" },
+    ]);
+  });
+
+  it("trusts vault entries that came with the backup", async () => {
+    const { backupService } = await import("../src/services/backup.service");
+    await backupService.relink(["lunch", "salary"], {
+      vaults: [{ name: "Income", entries: [{ type: "DEPOSIT" }] }],
+    });
+
+    expect(fix).toHaveBeenCalledWith(
+      ["vault_mismatch"],
+      expect.any(Date),
+      ["Spend"],
+    );
+  });
+});
@@ -0,0 +1,112 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Vault benchmarks
+ *
+ * - The vault's deposits and withdrawals are replayed in the benchmark
+ * - Alpha is the vault's ROI over deposits minus the benchmark's
+ * - Without a price for a flow day the comparison is left open
+ */
+
+describe("Benchmark Service", () => {
+  const usd = { type: "FIAT", symbol: "USD" };
+  let btcPrices: Record<string, number> = {};
+
+  const vault = {
+    name: "Growth",
+    status: "ACTIVE",
+    createdAt: "",
+    benchmark: "btc",
+  };
+  const entry = (type: string, usdValue: number, at: string) => ({
+    vault: "Growth",
+    type,
+    asset: usd,
+    amount: usdValue,
+    usdValue,
+    at,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    btcPrices = {
+      "2025-01-01": 50000,
+      "2025-02-01": 100000,
+      "2025-03-01": 60000,
+      now: 80000,
+    };
+
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAllEntries: () => [
+          entry("DEPOSIT", 1000, "2025-01-01T00:00:00.000Z"),
+          entry("VALUATION", 1200, "2025-01-15T00:00:00.000Z"),
+          entry("DEPOSIT", 1000, "2025-02-01T00:00:00.000Z"),
+          entry("WITHDRAW", 600, "2025-03-01T00:00:00.000Z"),
+        ],
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (_: unknown, at?: string) => {
+          const price = btcPrices[at ? at.slice(0, 10) : "now"];
+          return price ? { rateUSD: price } : { rateUSD: 1, missing: true };
+        },
+      },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        vaultStats: async () => ({
+          totalDepositedUSD: 2000,
+          totalWithdrawnUSD: 600,
+          aumUSD: 1900,
+          aumUSDManual: 1900,
+          aumUSDMarket: 0,
+        }),
+      },
+    }));
+  });
+
+  it("replays the vault's flows in the benchmark", async () => {
+    const { benchmarkService } = await import(
+      "../src/services/benchmark.service"
+    );
+    const b = (await benchmarkService.compare(vault as any))!;
+
+    // 0.02 + 0.01 BTC bought, 0.01 sold, 0.02 left at 80k
+    expect(b.symbol).toBe("BTC");
+    expect(b.inceptionDate).toBe("2025-01-01T00:00:00.000Z");
+    expect(b.priceReturnPercent).toBeCloseTo(60);
+    expect(b.valueUSD).toBeCloseTo(1600);
+    expect(b.roiPercent).toBeCloseTo(10);
+    expect(b.vaultRoiPercent).toBeCloseTo(25);
+    expect(b.alphaPercent).toBeCloseTo(15);
+    expect(b.excessPnlUSD).toBeCloseTo(300);
+    expect(b.missingDates).toEqual([]);
+  });
+
+  it("leaves the comparison open when a price is missing", async () => {
+    delete btcPrices["2025-02-01"];
+    const { benchmarkService } = await import(
+      "../src/services/benchmark.service"
+    );
+    const b = (await benchmarkService.compare(vault as any))!;
+
+    expect(b.missingDates).toEqual(["2025-02-01"]);
+    expect(b.valueUSD).toBeNull();
+    expect(b.alphaPercent).toBeNull();
+    expect(b.priceReturnPercent).toBeCloseTo(60);
+    expect(b.vaultRoiPercent).toBeCloseTo(25);
+  });
+
+  it("skips vaults without a benchmark", async () => {
+    const { benchmarkService } = await import(
+      "../src/services/benchmark.service"
+    );
+    const b = await benchmarkService.compare({
+      ...vault,
+      benchmark: undefined,
+    } as any);
+    expect(b).toBeUndefined();
+  });
+});
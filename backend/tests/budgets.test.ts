@@ -0,0 +1,131 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Budgets
+ *
+ * - One monthly limit per spending tag, in USD or VND
+ * - The report compares the month's spending by tag with each limit and
+ *   projects it to the month's end at the current burn rate
+ */
+
+describe("Budget Service", () => {
+  let budgets: any[];
+
+  beforeEach(() => {
+    vi.resetModules();
+    budgets = [
+      {
+        id: "b1",
+        tag: "groceries",
+        amount: 400,
+        currency: "USD",
+        active: true,
+        createdAt: "2025-01-01T00:00:00.000Z",
+      },
+      {
+        id: "b2",
+        tag: "dining",
+        amount: 2400000,
+        currency: "VND",
+        active: true,
+        createdAt: "2025-01-01T00:00:00.000Z",
+      },
+      {
+        id: "b3",
+        tag: "travel",
+        amount: 1000,
+        currency: "USD",
+        active: false,
+        createdAt: "2025-01-01T00:00:00.000Z",
+      },
+    ];
+    vi.doMock("../src/repositories", () => ({
+      budgetRepository: {
+        findAll: () => budgets,
+        findByTag: (tag: string) => budgets.find((b) => b.tag === tag),
+        create: (b: any) => b,
+      },
+    }));
+  });
+
+  it("reports actual vs. budget with the burn rate", async () => {
+    const { budgetService, budgetMonth } = await import(
+      "../src/services/budget.service"
+    );
+    const month = budgetMonth("2025-03");
+    expect(month).toMatchObject({
+      start: "2025-03-01T00:00:00.000Z",
+      end: "2025-03-31T23:59:59.999Z",
+      days: 31,
+    });
+
+    const spent = new Map([
+      ["groceries", 150],
+      ["dining", 120],
+      ["fuel", 30],
+    ]);
+    const report = budgetService.report(
+      month,
+      spent,
+      24000,
+      new Date("2025-03-10T12:00:00Z"),
+    );
+
+    expect(report.days_elapsed).toBe(10);
+    expect(report.budgets.map((b) => b.tag)).toEqual(["groceries", "dining"]);
+    const [groceries, dining] = report.budgets;
+    expect(groceries).toMatchObject({
+      budget_usd: 400,
+      remaining_usd: 250,
+      used_percentage: 37.5,
+      daily_burn_usd: 15,
+      projected_usd: 465,
+      over_budget: false,
+      projected_over_budget: true,
+    });
+    expect(dining).toMatchObject({
+      budget_usd: 100,
+      remaining_usd: -20,
+      over_budget: true,
+    });
+    expect(report.totals).toMatchObject({
+      budget_usd: 500,
+      actual_usd: 270,
+      unbudgeted_usd: 30,
+    });
+
+    // A past month spreads spending over all of its days
+    const past = budgetService.report(
+      budgetMonth("2025-02"),
+      new Map([["groceries", 280]]),
+      24000,
+      new Date("2025-03-10T12:00:00Z"),
+    );
+    expect(past.days_elapsed).toBe(28);
+    expect(past.budgets[0].daily_burn_usd).toBe(10);
+  });
+
+  it("rejects a second budget for a tag and bad months", async () => {
+    const { budgetService, budgetMonth } = await import(
+      "../src/services/budget.service"
+    );
+
+    expect(() =>
+      budgetService.create({
+        tag: " groceries ",
+        amount: 500,
+        currency: "USD",
+        active: true,
+      }),
+    ).toThrow(/already exists/);
+    expect(
+      budgetService.create({
+        tag: "fuel",
+        amount: 50,
+        currency: "USD",
+        active: true,
+      }).tag,
+    ).toBe("fuel");
+    expect(() => budgetMonth("2025-13")).toThrow(/YYYY-MM/);
+  });
+});
@@ -0,0 +1,129 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Cash drag against VND deposit rates
+ *
+ * - The benchmark is each bank's latest rate for the term, averaged
+ * - Fiat and stablecoin balances earn the rate in effect each day
+ * - Days holding cash with no rate on file are counted, not charged
+ */
+
+type DepositRate = import("../src/types").DepositRate;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Cash Drag", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const USDT = { type: "CRYPTO" as const, symbol: "USDT" };
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  let rates: DepositRate[] = [];
+
+  const rate = (
+    effectiveDate: string,
+    ratePercent: number,
+    bank: string,
+    termMonths = 12,
+  ): DepositRate => ({
+    id: `${bank}-${effectiveDate}-${termMonths}`,
+    effectiveDate,
+    termMonths,
+    ratePercent,
+    bank,
+    createdAt: "2025-01-01T00:00:00.000Z",
+  });
+
+  const entry = (
+    type: "DEPOSIT" | "WITHDRAW",
+    asset: VaultEntry["asset"],
+    amount: number,
+    at: string,
+  ): VaultEntry => ({
+    vault: "Cash",
+    type,
+    asset,
+    amount,
+    usdValue: 0,
+    at,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    rates = [
+      rate("2025-01-01", 5, "VCB"),
+      rate("2025-01-16", 6, "VCB"),
+      rate("2025-01-01", 4, "BIDV"),
+      rate("2025-01-01", 3, "VCB", 6),
+    ];
+    const entries = [
+      entry("DEPOSIT", VND, 100_000_000, "2025-01-01T09:00:00.000Z"),
+      entry("DEPOSIT", BTC, 1, "2025-01-05T09:00:00.000Z"),
+      entry("DEPOSIT", USDT, 1000, "2025-01-11T09:00:00.000Z"),
+      entry("WITHDRAW", VND, 50_000_000, "2025-02-01T09:00:00.000Z"),
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      depositRateRepository: { findAll: () => rates },
+      vaultRepository: {
+        findAllEntriesUntil: (end: string) =>
+          entries.filter((e) => e.at <= end),
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }) => ({
+          asset,
+          rateUSD: asset.symbol === "VND" ? 0.00004 : 1,
+          timestamp: "2025-01-01T00:00:00.000Z",
+          source: "FIXED",
+        }),
+      },
+    }));
+  });
+
+  it("averages each bank's latest rate for the term", async () => {
+    const { depositRateService } = await import(
+      "../src/services/deposit-rate.service"
+    );
+
+    expect(depositRateService.rateAt("2024-12-31")).toBeUndefined();
+    expect(depositRateService.rateAt("2025-01-10")).toBe(4.5);
+    expect(depositRateService.rateAt("2025-01-16")).toBe(5);
+    expect(depositRateService.rateAt("2025-01-16", 6)).toBe(3);
+  });
+
+  it("charges daily cash balances at the rate in effect", async () => {
+    const { cashDragService } = await import(
+      "../src/services/cash-drag.service"
+    );
+    const r = await cashDragService.report("2025-01-01", "2025-02-10");
+
+    const daily = (cash: number, pct: number) => (cash * pct) / 100 / 365;
+    const jan =
+      10 * daily(4000, 4.5) + 5 * daily(5000, 4.5) + 16 * daily(5000, 5);
+    const feb = 10 * daily(3000, 5);
+
+    expect(r.months.map((m) => [m.month, m.days])).toEqual([
+      ["2025-01", 31],
+      ["2025-02", 10],
+    ]);
+    expect(r.months[0].opportunityCostUSD).toBeCloseTo(jan, 10);
+    expect(r.months[0].benchmarkRatePercent).toBeCloseTo(
+      (15 * 4.5 + 16 * 5) / 31,
+      10,
+    );
+    expect(r.months[1].avgCashUSD).toBeCloseTo(3000, 10);
+    expect(r.opportunityCostUSD).toBeCloseTo(jan + feb, 10);
+    expect(r.uncoveredDays).toBe(0);
+  });
+
+  it("counts cash days without a rate on file", async () => {
+    rates = [];
+    const { cashDragService } = await import(
+      "../src/services/cash-drag.service"
+    );
+    const r = await cashDragService.report("2024-12-30", "2025-01-05");
+
+    expect(r.uncoveredDays).toBe(5);
+    expect(r.opportunityCostUSD).toBe(0);
+    expect(r.months[0].benchmarkRatePercent).toBeUndefined();
+  });
+});
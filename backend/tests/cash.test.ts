@@ -0,0 +1,117 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Physical cash
+ *
+ * - An ATM withdrawal moves cash from the bank into the cash wallet
+ * - A cash count books the gap to the ledger under the cash leakage tag
+ * - A count that matches the ledger records nothing
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Cash Service", () => {
+  const vnd = { type: "FIAT" as const, symbol: "VND" };
+  let transactions: Transaction[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    transactions = [];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => transactions,
+        create: (t: Transaction) => {
+          transactions.push(t);
+          return t;
+        },
+        createMany: (txs: Transaction[]) => {
+          transactions.push(...txs);
+          return txs;
+        },
+      },
+      settingsRepository: {
+        getCashLeakageTag: () => "pocket_money",
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async () => ({
+          asset: vnd,
+          rateUSD: 0.00004,
+          timestamp: "2025-03-01T00:00:00.000Z",
+        }),
+      },
+    }));
+  });
+
+  it("withdraws cash from the bank into the cash wallet", async () => {
+    const { cashService } = await import("../src/services/cash.service");
+    const txs = await cashService.withdraw({
+      fromAccount: "VCB",
+      asset: vnd,
+      amount: 2_000_000,
+      fee: 3_300,
+      at: "2025-03-01T09:00:00.000Z",
+    });
+
+    expect(txs.map((t) => [t.type, t.account, t.amount])).toEqual([
+      ["TRANSFER_OUT", "VCB", 2_000_000],
+      ["TRANSFER_IN", "Cash", 2_000_000],
+      ["EXPENSE", "VCB", 3_300],
+    ]);
+    expect(new Set(txs.map((t) => t.transferId)).size).toBe(1);
+    expect(cashService.balance("cash", vnd)).toBe(2_000_000);
+    expect(cashService.balance("VCB", vnd)).toBe(-2_003_300);
+  });
+
+  it("books untracked cash spending as leakage at the count", async () => {
+    const { cashService } = await import("../src/services/cash.service");
+    await cashService.withdraw({
+      fromAccount: "VCB",
+      asset: vnd,
+      amount: 1_000_000,
+      at: "2025-03-01T09:00:00.000Z",
+    });
+    transactions.push({
+      id: "pho",
+      type: "EXPENSE",
+      asset: vnd,
+      amount: 60_000,
+      account: "Cash",
+      note: "Pho",
+      createdAt: "2025-03-02T12:00:00.000Z",
+    } as Transaction);
+
+    const r = await cashService.count({
+      asset: vnd,
+      counted: 900_000,
+      at: "2025-03-05T20:00:00.000Z",
+    });
+    expect(r.recorded).toBe(940_000);
+    expect(r.difference).toBe(-40_000);
+    expect(r.transaction).toMatchObject({
+      type: "EXPENSE",
+      account: "Cash",
+      amount: 40_000,
+      category: "pocket_money",
+      createdAt: "2025-03-05T20:00:00.000Z",
+    });
+    expect(cashService.balance("Cash", vnd)).toBe(900_000);
+
+    const again = await cashService.count({ asset: vnd, counted: 900_000 });
+    expect(again.difference).toBe(0);
+    expect(again.transaction).toBeUndefined();
+  });
+
+  it("rejects a withdrawal into the same account", async () => {
+    const { cashService } = await import("../src/services/cash.service");
+    await expect(
+      cashService.withdraw({
+        fromAccount: "cash",
+        asset: vnd,
+        amount: 100_000,
+      }),
+    ).rejects.toThrow(/the same/);
+    expect(transactions).toHaveLength(0);
+  });
+});
@@ -0,0 +1,224 @@
+import crypto from "crypto";
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Exchange connectors
+ *
+ * - Keys that can trade or withdraw are refused; keys are stored encrypted
+ * - A sync records trades, deposits, withdrawals and rewards, each with a
+ *   sourceRef, and resumes where the last one stopped
+ * - Syncing again records nothing twice
+ */
+
+const ms = (iso: string) => Date.parse(iso);
+
+describe("Connector Service (Binance)", () => {
+  let stored: any[] = [];
+  let ledger: any[] = [];
+  const get = vi.fn();
+  const restrictions: Record<string, boolean> = {};
+
+  const binance = (path: string) => {
+    if (path === "/sapi/v1/account/apiRestrictions") return restrictions;
+    if (path === "/api/v3/myTrades") {
+      return [
+        {
+          symbol: "BTCUSDT",
+          id: 7,
+          qty: "0.01",
+          quoteQty: "650",
+          commission: "0.00001",
+          commissionAsset: "BTC",
+          time: ms("2025-03-02T09:00:00.000Z"),
+          isBuyer: true,
+        },
+      ];
+    }
+    if (path === "/sapi/v1/capital/deposit/hisrec") {
+      return [
+        {
+          id: "d1",
+          coin: "USDT",
+          amount: "1000",
+          network: "TRX",
+          txId: "0xabc",
+          status: 1,
+          insertTime: ms("2025-03-01T08:00:00.000Z"),
+        },
+        // Not credited yet; the next sync looks again from here
+        {
+          id: "d2",
+          coin: "USDT",
+          amount: "5",
+          status: 0,
+          insertTime: ms("2025-03-09T00:00:00.000Z"),
+        },
+      ];
+    }
+    if (path === "/sapi/v1/capital/withdraw/history") {
+      return [
+        {
+          id: "w1",
+          coin: "USDT",
+          amount: "99",
+          transactionFee: "1",
+          network: "ETH",
+          status: 6,
+          applyTime: "2025-03-03 10:00:00",
+        },
+      ];
+    }
+    return {
+      rows: [
+        {
+          id: 55,
+          asset: "BNB",
+          amount: "0.1",
+          enInfo: "BNB Vault",
+          divTime: ms("2025-03-04T00:00:00.000Z"),
+        },
+      ],
+      total: 1,
+    };
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    process.env.SETTINGS_ENCRYPTION_KEY = "test-passphrase";
+    stored = [];
+    ledger = [];
+    for (const k of Object.keys(restrictions)) delete restrictions[k];
+    restrictions.enableReading = true;
+    get.mockReset().mockImplementation(async (url: string) => ({
+      data: binance(new URL(url).pathname),
+    }));
+    vi.doMock("axios", () => ({ default: { get } }));
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getExchangeConnectors: () => stored,
+        setExchangeConnectors: (c: any[]) => (stored = c),
+      },
+      transactionRepository: {
+        findBySourceRef: (ref: string) =>
+          ledger.find((t) => t.sourceRef === ref),
+        create: (t: any) => ledger.push(t),
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        buildTransactionBase: async (
+          asset: any,
+          amount: number,
+          at: string,
+          account: string,
+        ) => ({
+          asset,
+          amount,
+          createdAt: at,
+          account,
+          rate: { asset, rateUSD: 1, timestamp: at },
+          usdAmount: amount,
+        }),
+        createRewardTransaction: async (p: any) => {
+          ledger.push({ type: "INCOME", category: "reward", ...p });
+        },
+      },
+    }));
+  });
+
+  afterEach(() => {
+    delete process.env.SETTINGS_ENCRYPTION_KEY;
+  });
+
+  const create = async () => {
+    const { connectorService } = await import(
+      "../src/services/connector.service"
+    );
+    const c = await connectorService.create(
+      {
+        provider: "binance",
+        name: "Binance",
+        apiKey: "key-1234",
+        apiSecret: "s3cret",
+        pairs: ["BTC/USDT"],
+        since: "2025-03-01T00:00:00.000Z",
+        intervalHours: 6,
+        active: true,
+      },
+      new Date("2025-03-10T00:00:00.000Z"),
+    );
+    return { connectorService, c };
+  };
+
+  it("refuses keys that can trade or withdraw", async () => {
+    restrictions.enableWithdrawals = true;
+    await expect(create()).rejects.toThrow(/read-only.*enableWithdrawals/);
+    expect(stored).toEqual([]);
+
+    delete restrictions.enableWithdrawals;
+    const { c } = await create();
+    expect(c).toMatchObject({ account: "Binance", apiKeyHint: "…1234" });
+    expect(c.credentials).toMatch(/^enc:v1:/);
+    expect(JSON.stringify(stored)).not.toContain("s3cret");
+
+    // Signed with the secret, keyed by the API key
+    const [url, opts] = get.mock.calls[0];
+    const query = new URL(url).search.slice(1);
+    const [unsigned, signature] = query.split("&signature=");
+    expect(signature).toBe(
+      crypto.createHmac("sha256", "s3cret").update(unsigned).digest("hex"),
+    );
+    expect(opts.headers["X-MBX-APIKEY"]).toBe("key-1234");
+  });
+
+  it("records each kind of activity once", async () => {
+    const { connectorService, c } = await create();
+    const now = new Date("2025-03-10T00:00:00.000Z");
+
+    const run = await connectorService.sync(c.id, now);
+    expect(run).toMatchObject({
+      status: "OK",
+      created: { TRADE: 3, DEPOSIT: 1, WITHDRAWAL: 2, REWARD: 1 },
+      skipped: 0,
+    });
+    expect(
+      ledger.map((t) => [t.sourceRef, t.type, t.asset.symbol, t.amount]),
+    ).toEqual([
+      ["binance:trade:BTCUSDT:7:in", "INCOME", "BTC", 0.01],
+      ["binance:trade:BTCUSDT:7:out", "EXPENSE", "USDT", 650],
+      ["binance:trade:BTCUSDT:7:fee", "EXPENSE", "BTC", 0.00001],
+      ["binance:deposit:d1", "TRANSFER_IN", "USDT", 1000],
+      ["binance:withdrawal:w1", "TRANSFER_OUT", "USDT", 99],
+      ["binance:withdrawal:w1:fee", "EXPENSE", "USDT", 1],
+      ["binance:reward:55", "INCOME", "BNB", 0.1],
+    ]);
+    expect(ledger[0]).toMatchObject({ account: "Binance", internalFlow: true });
+    expect(ledger[5]).toMatchObject({ category: "network_fee", chain: "eth" });
+    expect(ledger[4].createdAt).toBe("2025-03-03T10:00:00.000Z");
+
+    get.mockClear();
+    const later = new Date("2025-03-11T00:00:00.000Z");
+    const again = await connectorService.sync(c.id, later);
+    expect(again.created).toEqual({
+      TRADE: 0,
+      DEPOSIT: 0,
+      WITHDRAWAL: 0,
+      REWARD: 0,
+    });
+    expect(again.skipped).toBe(4);
+    expect(ledger).toHaveLength(7);
+
+    const params = (path: string) =>
+      new URL(
+        get.mock.calls.find(([u]) => new URL(u).pathname === path)![0],
+      ).searchParams;
+    expect(params("/api/v3/myTrades").get("fromId")).toBe("8");
+    // Held back to the deposit still in progress
+    expect(params("/sapi/v1/capital/deposit/hisrec").get("startTime")).toBe(
+      String(ms("2025-03-09T00:00:00.000Z")),
+    );
+    expect(params("/sapi/v1/asset/assetDividend").get("startTime")).toBe(
+      String(now.getTime() + 1),
+    );
+  });
+});
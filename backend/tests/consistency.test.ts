@@ -0,0 +1,187 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Ledger consistency check
+ *
+ * - Finds orphaned transfer legs, vaults out of step with their
+ *   transactions, negative balances and missing FX rates
+ * - Liability accounts may go below zero
+ * - Each fix clears its issues, and fixes only the kinds asked for
+ */
+
+type Transaction = import("../src/types").Transaction;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Consistency Service", () => {
+  const vnd = { type: "FIAT" as const, symbol: "VND" };
+  const usd = { type: "FIAT" as const, symbol: "USD" };
+  let transactions: Transaction[] = [];
+  let entries: VaultEntry[] = [];
+
+  const tx = (
+    id: string,
+    type: string,
+    account: string,
+    amount: number,
+    day: number,
+    extra: Partial<Transaction> = {},
+  ) => {
+    const asset = extra.asset || vnd;
+    const rateUSD = asset.symbol === "VND" ? 0.00004 : 1;
+    return {
+      id,
+      type,
+      asset,
+      amount,
+      account,
+      createdAt: `2025-01-0${day}T00:00:00.000Z`,
+      rate: { asset, rateUSD, timestamp: "2025-01-01T00:00:00.000Z" },
+      usdAmount: amount * rateUSD,
+      ...extra,
+    } as Transaction;
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    transactions = [
+      tx("initial", "INITIAL", "VCB", 1_000_000, 1),
+      tx("orphan", "TRANSFER_OUT", "VCB", 400_000, 2, { transferId: "x" }),
+      tx("pho", "EXPENSE", "Cash", 50_000, 3),
+      tx("norate", "EXPENSE", "VCB", 10_000, 4, {
+        rate: undefined,
+        usdAmount: undefined,
+      } as any),
+      tx("bank", "INITIAL", "Bank", 1_000, 1, { asset: usd }),
+      tx("out", "TRANSFER_OUT", "Bank", 500, 2, {
+        asset: usd,
+        transferId: "y",
+      }),
+      tx("in", "TRANSFER_IN", "Savings", 500, 2, {
+        asset: usd,
+        transferId: "y",
+      }),
+      tx("card", "EXPENSE", "Visa", 100, 3, { asset: usd }),
+    ];
+    entries = [
+      {
+        vault: "Savings",
+        type: "DEPOSIT",
+        asset: usd,
+        amount: 300,
+        usdValue: 300,
+        at: "2025-01-02T00:00:00.000Z",
+      },
+    ];
+    const live = () => transactions.filter((t) => !t.deletedAt);
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: live,
+        findById: (id: string) => live().find((t) => t.id === id),
+        create: (t: Transaction) => {
+          transactions.push(t);
+          return t;
+        },
+        update: (id: string, updates: Partial<Transaction>) => {
+          const t = transactions.find((x) => x.id === id)!;
+          Object.assign(t, updates);
+          return t;
+        },
+        softDelete: (id: string, at: string) => {
+          transactions.find((t) => t.id === id)!.deletedAt = at;
+          return true;
+        },
+      },
+      vaultRepository: {
+        findAll: () => [{ name: "Savings" }, { name: "Untracked" }],
+        findAllEntries: (name: string) =>
+          entries.filter((e) => e.vault === name),
+        createEntry: (e: VaultEntry) => {
+          entries.push(e);
+          return e;
+        },
+      },
+      adminRepository: {
+        findAllAccounts: () => [{ id: 1, name: "Visa", type: "credit_card" }],
+        findAllAssets: () => [],
+      },
+      settingsRepository: {},
+      reportSubscriptionRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: { ensureVault: vi.fn(), addVaultEntry: vi.fn() },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }, at?: string) => ({
+          asset,
+          rateUSD: asset.symbol === "VND" ? 0.00004 : 1,
+          timestamp: at || "2025-03-01T00:00:00.000Z",
+        }),
+      },
+    }));
+  });
+
+  it("reports each kind of issue", async () => {
+    const { consistencyService } = await import(
+      "../src/services/consistency.service"
+    );
+    const r = consistencyService.check();
+
+    expect(r.counts).toEqual({
+      orphaned_transfer: 1,
+      vault_mismatch: 1,
+      negative_balance: 1,
+      missing_fx_rate: 1,
+    });
+    expect(
+      r.issues.map((i) => [i.kind, i.account, i.amount, i.transactionIds]),
+    ).toEqual([
+      ["orphaned_transfer", "VCB", 400_000, ["orphan"]],
+      ["vault_mismatch", "Savings", 200, []],
+      ["negative_balance", "Cash", 50_000, ["pho"]],
+      ["missing_fx_rate", "VCB", undefined, ["norate"]],
+    ]);
+  });
+
+  it("fixes every issue it found", async () => {
+    const { consistencyService } = await import(
+      "../src/services/consistency.service"
+    );
+    const fixed = await consistencyService.fix([
+      "missing_fx_rate",
+      "negative_balance",
+      "vault_mismatch",
+      "orphaned_transfer",
+    ]);
+
+    expect(fixed).toEqual({
+      orphaned_transfer: 1,
+      vault_mismatch: 1,
+      negative_balance: 1,
+      missing_fx_rate: 1,
+    });
+    expect(consistencyService.check().issues).toEqual([]);
+    const byId = (id: string) => transactions.find((t) => t.id === id)!;
+    expect(byId("orphan").deletedAt).toBeTruthy();
+    expect(byId("norate").usdAmount).toBeCloseTo(0.4);
+    expect(entries[1]).toMatchObject({ type: "DEPOSIT", amount: 200 });
+    expect(
+      transactions.find((t) => t.type === "INITIAL" && t.account === "Cash"),
+    ).toMatchObject({ amount: 50_000, createdAt: "2025-01-02T23:59:59.999Z" });
+  });
+
+  it("fixes only the kinds asked for", async () => {
+    const { consistencyService } = await import(
+      "../src/services/consistency.service"
+    );
+    expect(await consistencyService.fix(["missing_fx_rate"])).toEqual({
+      missing_fx_rate: 1,
+    });
+    expect(consistencyService.check().counts).toEqual({
+      orphaned_transfer: 1,
+      vault_mismatch: 1,
+      negative_balance: 1,
+      missing_fx_rate: 0,
+    });
+  });
+});
@@ -0,0 +1,118 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Cost basis methods
+ *
+ * - Withdrawals close deposit lots by AVERAGE, FIFO, LIFO or SPECIFIC
+ * - Vault settings win over asset settings, then the default
+ * - Picked lots must be open and only apply under SPECIFIC
+ */
+
+describe("Cost Basis Service", () => {
+  const btc = { type: "CRYPTO", symbol: "BTC" };
+  let settings: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings = { byAsset: {}, byVault: {} };
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: { getCostBasisSettings: () => settings },
+      vaultRepository: {
+        findAllEntries: () => [
+          {
+            vault: "Cold",
+            type: "DEPOSIT",
+            asset: btc,
+            amount: 1,
+            usdValue: 30000,
+            at: "2025-01-01T00:00:00.000Z",
+          },
+          {
+            vault: "Cold",
+            type: "DEPOSIT",
+            asset: btc,
+            amount: 1,
+            usdValue: 60000,
+            at: "2025-03-01T00:00:00.000Z",
+          },
+          {
+            vault: "Cold",
+            type: "WITHDRAW",
+            asset: btc,
+            amount: 0.5,
+            usdValue: 40000,
+            at: "2025-04-01T00:00:00.000Z",
+            lots: [{ lot: "2025-03-01T00:00:00.000Z", amount: 0.5 }],
+          },
+        ],
+      },
+    }));
+  });
+
+  it("releases cost by the chosen method", async () => {
+    const { LotBook } = await import("../src/services/cost-basis.service");
+    const book = () => {
+      const b = new LotBook();
+      b.add("2025-01-01", 1, 30000);
+      b.add("2025-01-01", 1, 60000);
+      return b;
+    };
+    const cost = (r: { released: { costUSD: number }[] }) =>
+      r.released.reduce((s, l) => s + l.costUSD, 0);
+
+    expect(cost(book().release(1, "AVERAGE"))).toBe(45000);
+    expect(cost(book().release(1.5, "FIFO"))).toBe(60000);
+    expect(cost(book().release(1.5, "LIFO"))).toBe(75000);
+
+    const specific = book();
+    const r = specific.release(1.5, "SPECIFIC", [
+      { lot: "2025-01-01#2", amount: 1 },
+    ]);
+    expect(r.released.map((l) => [l.lot, l.units])).toEqual([
+      ["2025-01-01#2", 1],
+      ["2025-01-01", 0.5],
+    ]);
+    expect(specific.open()).toEqual([
+      {
+        id: "2025-01-01",
+        acquiredAt: "2025-01-01",
+        units: 0.5,
+        costUSD: 15000,
+      },
+    ]);
+    expect(specific.release(2, "FIFO").unmatched).toBe(1.5);
+  });
+
+  it("resolves the method and replays picked lots", async () => {
+    const { costBasisService } = await import(
+      "../src/services/cost-basis.service"
+    );
+    expect(costBasisService.methodFor("Cold", "btc")).toBe("AVERAGE");
+    expect(costBasisService.methodFor("Cold", "btc", "FIFO")).toBe("FIFO");
+
+    settings = {
+      default: "FIFO",
+      byAsset: { BTC: "LIFO" },
+      byVault: { Cold: "SPECIFIC" },
+    };
+    expect(costBasisService.methodFor("Hot", "ETH")).toBe("FIFO");
+    expect(costBasisService.methodFor("Hot", "btc")).toBe("LIFO");
+    expect(costBasisService.methodFor("Cold", "BTC")).toBe("SPECIFIC");
+
+    const [lots] = costBasisService.openLots("Cold");
+    expect(lots.method).toBe("SPECIFIC");
+    expect(lots.lots.map((l) => [l.id, l.units, l.costUSD])).toEqual([
+      ["2025-01-01T00:00:00.000Z", 1, 30000],
+      ["2025-03-01T00:00:00.000Z", 0.5, 30000],
+    ]);
+
+    const pick = (lot: string, amount: number) =>
+      costBasisService.checkLots("Cold", btc as any, 1, [{ lot, amount }]);
+    expect(() => pick("2025-01-01T00:00:00.000Z", 1)).not.toThrow();
+    expect(() => pick("2025-03-01T00:00:00.000Z", 1)).toThrow(/holds 0.5/);
+    expect(() => pick("2025-02-01T00:00:00.000Z", 0.1)).toThrow(/not open/);
+
+    settings = { byAsset: {}, byVault: {} };
+    expect(() => pick("2025-01-01T00:00:00.000Z", 1)).toThrow(/SPECIFIC/);
+  });
+});
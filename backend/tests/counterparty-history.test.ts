@@ -0,0 +1,120 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Counterparty history
+ *
+ * - Matches the counterparty ignoring case and diacritics
+ * - Averages payments in USD, VND and each asset's own units
+ * - Reads a cadence from the gaps between payment days
+ * - Other types are listed but not counted unless asked for
+ */
+
+describe("Counterparty history", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  let transactions: any[] = [];
+
+  const tx = (
+    id: string,
+    day: string,
+    amount: number,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type: "EXPENSE",
+    asset: VND,
+    amount,
+    usdAmount: amount / 25000,
+    counterparty: "Chị Lan",
+    createdAt: `${day}T09:00:00.000Z`,
+    ...extra,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    transactions = [
+      tx("jan", "2025-01-05", 10_000_000, { counterparty: "chi lan" }),
+      tx("feb", "2025-02-05", 10_000_000),
+      tx("mar", "2025-03-05", 11_000_000),
+      // A second payment the same day doesn't count as a gap
+      tx("mar2", "2025-03-05", 500_000),
+      tx("apr", "2025-04-04", 200, { asset: USD, usdAmount: 200 }),
+      tx("deposit", "2025-01-02", 20_000_000, { type: "INCOME" }),
+      tx("other", "2025-02-01", 1_000, { counterparty: "Clinic" }),
+    ];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => transactions },
+    }));
+  });
+
+  const load = async () =>
+    (await import("../src/services/counterparty.service")).counterpartyService;
+
+  it("sums and averages what was paid", async () => {
+    const service = await load();
+    const h = service.history("CHI LAN", 26000);
+
+    expect(h.counterparty).toBe("Chị Lan");
+    expect(h.count).toBe(5);
+    expect(h.transactions.map((t) => t.id)).toEqual([
+      "apr",
+      "mar",
+      "mar2",
+      "feb",
+      "jan",
+      "deposit",
+    ]);
+    expect(h.totalUSD).toBeCloseTo(31_500_000 / 25000 + 200, 6);
+    // VND rows as recorded; the USD one at today's rate
+    expect(h.totalVND).toBeCloseTo(31_500_000 + 200 * 26000, 6);
+    expect(h.averageVND).toBeCloseTo(h.totalVND / 5, 6);
+    expect(h.byAsset).toEqual([
+      {
+        asset: VND,
+        count: 4,
+        total: 31_500_000,
+        average: 7_875_000,
+        last: 11_000_000,
+      },
+      { asset: USD, count: 1, total: 200, average: 200, last: 200 },
+    ]);
+    expect(h.firstAt).toBe("2025-01-05T09:00:00.000Z");
+    expect(h.last?.id).toBe("apr");
+  });
+
+  it("reads a monthly cadence from the payment days", async () => {
+    const service = await load();
+    const { frequency } = service.history("Chi Lan", 26000);
+
+    expect(frequency.cadence).toBe("MONTHLY");
+    expect(frequency.medianIntervalDays).toBe(30);
+    expect(frequency.perMonth).toBeCloseTo(3 / (89 / (365.25 / 12)), 6);
+    expect(frequency.nextExpectedAt).toBe("2025-05-04");
+
+    transactions = transactions.filter((t) => t.id === "jan");
+    const single = service.history("chi lan", 26000).frequency;
+    expect(single.cadence).toBeNull();
+    expect(single.perMonth).toBeNull();
+  });
+
+  it("counts other types only when asked", async () => {
+    const { counterpartyService, parsePaymentTypes } = await import(
+      "../src/services/counterparty.service"
+    );
+
+    const income = counterpartyService.history(
+      "Chị Lan",
+      26000,
+      parsePaymentTypes("income"),
+    );
+    expect(income.count).toBe(1);
+    expect(income.totalVND).toBe(20_000_000);
+    expect(income.frequency.cadence).toBeNull();
+
+    expect(parsePaymentTypes("all")).toContain("TRANSFER_IN");
+    expect(() => parsePaymentTypes("EXPENSE,GIFT")).toThrow(/GIFT/);
+    expect(() => counterpartyService.history("Nobody", 26000)).toThrow(
+      /not found/,
+    );
+  });
+});
@@ -0,0 +1,45 @@
+import { describe, it, expect } from "vitest";
+import {
+  applyDisplayPrecision,
+  DEFAULT_DISPLAY_PRECISION,
+} from "../src/utils/number.util";
+
+describe("Display precision", () => {
+  it("should round currency fields by key suffix", () => {
+    const out = applyDisplayPrecision(
+      { total_usd: 12.34567, total_vnd: 296296.08, aumUSD: 1.005 },
+      DEFAULT_DISPLAY_PRECISION,
+    ) as any;
+
+    expect(out.total_usd).toBe(12.35);
+    expect(out.total_vnd).toBe(296296);
+    expect(out.aumUSD).toBe(1);
+  });
+
+  it("should round quantities by the asset symbol of the enclosing object", () => {
+    const out = applyDisplayPrecision(
+      [{ asset: "BTC", quantity: 0.123456789123, value_usd: 6172.839 }],
+      DEFAULT_DISPLAY_PRECISION,
+    ) as any[];
+
+    expect(out[0].quantity).toBe(0.12345679);
+    expect(out[0].value_usd).toBe(6172.84);
+  });
+
+  it("should keep prices, rates and unknown fields untouched", () => {
+    const out = applyDisplayPrecision(
+      {
+        price_usd: 0.0000123456,
+        rate_vnd: 26315.789,
+        roi_percent: 1.23456,
+        by_asset: { ETH: { asset: "ETH", quantity: 1.123456789 } },
+      },
+      DEFAULT_DISPLAY_PRECISION,
+    ) as any;
+
+    expect(out.price_usd).toBe(0.0000123456);
+    expect(out.rate_vnd).toBe(26315.789);
+    expect(out.roi_percent).toBe(1.23456);
+    expect(out.by_asset.ETH.quantity).toBe(1.123456789);
+  });
+});
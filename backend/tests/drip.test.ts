@@ -0,0 +1,85 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Dividend reinvestment (DRIP)
+ *
+ * - Dividend and reinvestment are recorded as one linked group
+ * - Reinvested units carry the dividend as cost basis
+ */
+
+type Transaction = import("../src/types").Transaction;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("DRIP transactions", () => {
+  let txs: Transaction[] = [];
+  let entries: VaultEntry[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    txs = [];
+    entries = [];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        create: (t: Transaction) => {
+          txs.push(t);
+          return t;
+        },
+      },
+      vaultRepository: {
+        findByName: (name: string) =>
+          name === "Brokerage" ? { name, status: "ACTIVE" } : undefined,
+      },
+      settingsRepository: { getDefaultSpendingVaultName: () => "Spend" },
+      borrowingRepository: {},
+      adminRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        addVaultEntry: (e: VaultEntry) => {
+          entries.push(e);
+          return e;
+        },
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: any) => ({ asset, rateUSD: 1 }),
+      },
+    }));
+  });
+
+  it("links dividend and reinvestment with the dividend as cost", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const created = await transactionService.createDripTransactions({
+      asset: { type: "CRYPTO", symbol: "VTI" },
+      units: 0.2,
+      dividend: 50,
+      dividendAsset: { type: "FIAT", symbol: "USD" },
+      account: "Brokerage",
+      at: "2025-03-31T00:00:00.000Z",
+    });
+
+    expect(created.map((t) => t.type)).toEqual([
+      "INCOME",
+      "TRANSFER_OUT",
+      "TRANSFER_IN",
+    ]);
+    expect(new Set(created.map((t) => t.transferId)).size).toBe(1);
+
+    const unitsIn = created[2];
+    expect(unitsIn.amount).toBe(0.2);
+    expect(unitsIn.usdAmount).toBe(50);
+    expect(unitsIn.rate.rateUSD).toBe(250);
+
+    expect(entries).toHaveLength(1);
+    expect(entries[0]).toMatchObject({
+      vault: "Brokerage",
+      type: "DEPOSIT",
+      amount: 0.2,
+      usdValue: 50,
+    });
+  });
+});
@@ -0,0 +1,106 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Rate enrichment queue
+ *
+ * - Transactions with a missing or stale rate are queued; rewards are not
+ * - A fresh provider rate updates rate and usdAmount
+ * - Transactions the provider still can't price stay queued
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Enrichment Service", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  let txs: Transaction[] = [];
+
+  const tx = (
+    id: string,
+    asset: typeof VND | typeof BTC,
+    rate: Partial<Transaction["rate"]>,
+    extra: Partial<Transaction> = {},
+  ) =>
+    ({
+      id,
+      type: "EXPENSE",
+      asset,
+      amount: 100000,
+      createdAt: "2025-01-05T10:00:00.000Z",
+      account: "Spend",
+      rate: {
+        asset,
+        rateUSD: 1,
+        timestamp: "2025-01-01T00:00:00.000Z",
+        source: "FIXED",
+        ...rate,
+      },
+      usdAmount: 100000,
+      ...extra,
+    }) as Transaction;
+
+  beforeEach(() => {
+    vi.resetModules();
+    txs = [
+      tx("a", VND, { missing: true }),
+      tx(
+        "b",
+        BTC,
+        { stale: true, source: "COINGECKO" },
+        { createdAt: "2025-01-06T10:00:00.000Z" },
+      ),
+      tx("c", VND, { stale: true }, { category: "reward" }),
+      tx("d", VND, { rateUSD: 0.00004, source: "ER_API" }),
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => txs,
+        update: (id: string, updates: Partial<Transaction>) => {
+          const t = txs.find((x) => x.id === id)!;
+          Object.assign(t, updates);
+          return t;
+        },
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }, at: string) =>
+          asset.symbol === "VND"
+            ? { asset, rateUSD: 0.00004, timestamp: at, source: "ER_API" }
+            : {
+                asset,
+                rateUSD: 1,
+                timestamp: at,
+                source: "FIXED",
+                missing: true,
+              },
+      },
+    }));
+  });
+
+  it("queues missing and stale rates except rewards", async () => {
+    const { enrichmentService } = await import(
+      "../src/services/enrichment.service"
+    );
+    const pending = enrichmentService.pending();
+
+    expect(pending.map((p) => [p.transaction.id, p.reason])).toEqual([
+      ["a", "missing"],
+      ["b", "stale"],
+    ]);
+  });
+
+  it("reprices what the provider answers and keeps the rest", async () => {
+    const { enrichmentService } = await import(
+      "../src/services/enrichment.service"
+    );
+    const r = await enrichmentService.retry();
+
+    expect(r.enriched.map((t) => t.id)).toEqual(["a"]);
+    expect(r.enriched[0].usdAmount).toBeCloseTo(4, 10);
+    expect(r.enriched[0].rate.missing).toBeUndefined();
+    expect(r.stillPending.map((p) => p.transaction.id)).toEqual(["b"]);
+    expect(enrichmentService.pending()).toHaveLength(1);
+  });
+});
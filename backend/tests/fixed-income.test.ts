@@ -0,0 +1,111 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Term deposits and bonds
+ *
+ * - Coupon schedule accrues Actual/365 with a final payment at maturity
+ * - Accrual posts each due coupon once and matures the instrument
+ */
+
+type FixedIncomeInstrument = import("../src/types").FixedIncomeInstrument;
+
+describe("Fixed income", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  let instruments: FixedIncomeInstrument[] = [];
+  let posted: any[] = [];
+
+  const deposit = (extra: Partial<FixedIncomeInstrument> = {}) =>
+    ({
+      id: "td-1",
+      kind: "TERM_DEPOSIT",
+      name: "VCB 6M",
+      issuer: "Vietcombank",
+      asset: VND,
+      faceValue: 100_000_000,
+      couponRate: 0.0365,
+      couponFrequency: "AT_MATURITY",
+      startAt: "2025-01-01T00:00:00.000Z",
+      maturityAt: "2025-07-01T00:00:00.000Z",
+      account: "Bank",
+      status: "ACTIVE",
+      createdAt: "2025-01-01T00:00:00.000Z",
+      ...extra,
+    }) as FixedIncomeInstrument;
+
+  beforeEach(() => {
+    vi.resetModules();
+    instruments = [];
+    posted = [];
+
+    vi.doMock("../src/repositories", () => ({
+      fixedIncomeRepository: {
+        findByStatus: (status: string) =>
+          instruments.filter((i) => i.status === status),
+        update: (id: string, updates: Partial<FixedIncomeInstrument>) => {
+          const inst = instruments.find((i) => i.id === id);
+          if (inst) Object.assign(inst, updates);
+          return inst;
+        },
+      },
+      transactionRepository: {
+        findBySourceRef: (ref: string) =>
+          posted.find((t) => t.sourceRef === ref),
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        createIncomeTransaction: async (params: any) => {
+          const tx = { id: `tx-${posted.length}`, type: "INCOME", ...params };
+          posted.push(tx);
+          return tx;
+        },
+      },
+    }));
+  });
+
+  it("builds an Actual/365 schedule ending at maturity", async () => {
+    const { couponSchedule } = await import(
+      "../src/services/fixed-income.service"
+    );
+
+    const once = couponSchedule(deposit());
+    expect(once).toHaveLength(1);
+    // 181 days at 3.65%
+    expect(once[0].amount).toBeCloseTo(1_810_000, 6);
+    expect(once[0].final).toBe(true);
+
+    const quarterly = couponSchedule(
+      deposit({ couponFrequency: "QUARTERLY" }),
+    );
+    expect(quarterly.map((c) => c.paymentAt.slice(0, 10))).toEqual([
+      "2025-04-01",
+      "2025-07-01",
+    ]);
+    expect(quarterly[0].amount).toBeCloseTo(900_000, 6); // 90 days
+    expect(quarterly[1].amount).toBeCloseTo(910_000, 6); // 91 days
+  });
+
+  it("posts due coupons once and marks the instrument matured", async () => {
+    const { fixedIncomeService } = await import(
+      "../src/services/fixed-income.service"
+    );
+    instruments = [deposit({ couponFrequency: "QUARTERLY" })];
+
+    await fixedIncomeService.accrueDue(new Date("2025-05-01T00:00:00Z"));
+    expect(posted).toHaveLength(1);
+    expect(posted[0]).toMatchObject({
+      category: "INTEREST_INCOME",
+      account: "Bank",
+      at: "2025-04-01T00:00:00.000Z",
+    });
+    expect(instruments[0].nextCouponAt).toBe("2025-07-01T00:00:00.000Z");
+
+    // Rerun for the same date posts nothing new
+    await fixedIncomeService.accrueDue(new Date("2025-05-01T00:00:00Z"));
+    expect(posted).toHaveLength(1);
+
+    await fixedIncomeService.accrueDue(new Date("2025-07-02T00:00:00Z"));
+    expect(posted).toHaveLength(2);
+    expect(instruments[0].status).toBe("MATURED");
+  });
+});
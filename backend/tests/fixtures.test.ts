@@ -0,0 +1,137 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Fixture scenarios
+ *
+ * - Scenarios are built through the services with fixed dates and prices
+ * - A scenario loads once per label; reruns conflict instead of doubling
+ */
+
+describe("Fixtures Service", () => {
+  const settings = new Map<string, string>();
+  const entries: any[] = [];
+  const vaults = new Set<string>();
+  const createExpenseTransaction = vi.fn();
+  const createIncomeTransaction = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings.clear();
+    entries.length = 0;
+    vaults.clear();
+    createExpenseTransaction.mockReset();
+    createIncomeTransaction.mockReset();
+
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getSetting: (k: string) => settings.get(k),
+        setSetting: (k: string, v: string) => settings.set(k, v),
+        getDefaultSpendingVaultName: () => "Spend",
+      },
+      borrowingRepository: { findByStatus: () => [] },
+      transactionRepository: { findBySourceRef: () => undefined },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        ensureVault: (name: string) => {
+          if (vaults.has(name)) return false;
+          vaults.add(name);
+          return true;
+        },
+        addVaultEntry: (e: any) => (entries.push(e), e),
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        createExpenseTransaction,
+        createIncomeTransaction,
+      },
+    }));
+    vi.doMock("../src/services/borrowing.service", () => ({
+      borrowingService: {},
+    }));
+  });
+
+  it("dates fixtures by months and days from the start", async () => {
+    const { fixtureDate } = await import("../src/services/fixtures.service");
+
+    expect(fixtureDate("2025-01-31", 0)).toBe("2025-01-31T12:00:00.000Z");
+    expect(fixtureDate("2025-01-01", 2, 14)).toBe("2025-03-15T12:00:00.000Z");
+  });
+
+  it("builds a DCA vault deterministically and refuses a rerun", async () => {
+    const { fixturesService } = await import(
+      "../src/services/fixtures.service"
+    );
+
+    const run = await fixturesService.create("dca-vault", { months: 2 });
+
+    expect(run.vaultEntries).toBe(5);
+    expect(run.vaults).toEqual(["Fixture DCA", "Spend"]);
+    const buys = entries.filter((e) => e.vault === "Fixture DCA");
+    expect(buys.map((e) => [e.type, e.at, e.usdValue])).toEqual([
+      ["DEPOSIT", "2025-01-01T12:00:00.000Z", 500],
+      ["DEPOSIT", "2025-02-01T12:00:00.000Z", 500],
+      ["VALUATION", "2025-03-01T12:00:00.000Z", expect.any(Number)],
+    ]);
+    expect(buys[0].amount).toBeCloseTo(500 / 42000, 12);
+    expect(buys[2].amount).toBeCloseTo(buys[0].amount + buys[1].amount, 12);
+
+    await expect(
+      fixturesService.create("dca-vault", { months: 2 }),
+    ).rejects.toMatchObject({ statusCode: 409 });
+    const other = await fixturesService.create("dca-vault", {
+      months: 2,
+      label: "Demo",
+    });
+    expect(other.label).toBe("Demo");
+    expect(fixturesService.log()).toHaveLength(2);
+  });
+
+  it("creates the month's transactions at fixed prices", async () => {
+    const { priceOverride } = await import("../src/utils/price-override.util");
+    const seen: Array<number | undefined> = [];
+    let n = 0;
+    const create = async (p: any) => {
+      seen.push(priceOverride(p.asset.symbol));
+      return { id: `tx-${++n}`, ...p };
+    };
+    createIncomeTransaction.mockImplementation(create);
+    createExpenseTransaction.mockImplementation(create);
+    const { fixturesService } = await import(
+      "../src/services/fixtures.service"
+    );
+
+    const run = await fixturesService.create("multi-currency-month", {
+      start: "2025-03-01",
+    });
+
+    expect(run.transactionIds).toHaveLength(10);
+    expect(createExpenseTransaction).toHaveBeenCalledWith(
+      expect.objectContaining({
+        asset: { type: "FIAT", symbol: "EUR" },
+        amount: 240,
+        at: "2025-03-12T12:00:00.000Z",
+        counterparty: "Fixture",
+      }),
+    );
+    expect(seen).toContain(1.08);
+    expect(seen).toContain(1 / 25000);
+  });
+
+  it("rejects unknown scenarios and bad options", async () => {
+    const { fixturesService } = await import(
+      "../src/services/fixtures.service"
+    );
+
+    await expect(fixturesService.create("nope", {})).rejects.toMatchObject({
+      statusCode: 404,
+    });
+    await expect(
+      fixturesService.create("dca-vault", { start: "01/02/2025" }),
+    ).rejects.toThrow(/YYYY-MM-DD/);
+    await expect(
+      fixturesService.create("borrow-cycle", { months: 0 }),
+    ).rejects.toThrow(/months/);
+  });
+});
@@ -0,0 +1,188 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * FX margins per account
+ *
+ * - Only fiat in a currency other than the account's own carries the
+ *   account's margin
+ * - New transactions record it and include it in usdAmount; snapshots
+ *   stay mid-market, and converted amounts include it too
+ * - Repricing or moving a transaction to another account re-applies it
+ * - Only spending carries it; income, transfers and restored backups keep
+ *   their amounts
+ * - A batch of new transactions reads the accounts once
+ */
+
+type Transaction = import("../src/types").Transaction;
+type Asset = import("../src/types").Asset;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+const vnd = { type: "FIAT" as const, symbol: "VND" };
+const eur = { type: "FIAT" as const, symbol: "EUR" };
+const btc = { type: "CRYPTO" as const, symbol: "BTC" };
+
+const tx = (id: string, account: string, asset: Asset, amount: number) =>
+  ({
+    id,
+    type: "EXPENSE",
+    asset,
+    amount,
+    account,
+    createdAt: "2025-03-01T00:00:00.000Z",
+    rate: {
+      asset: usd,
+      rateUSD: asset === vnd ? 1 / 25000 : asset === eur ? 1.1 : 1,
+      timestamp: "2025-03-01T00:00:00.000Z",
+    },
+    usdAmount: amount * (asset === vnd ? 1 / 25000 : asset === eur ? 1.1 : 1),
+  }) as Transaction;
+
+describe("FX margins", () => {
+  let store: any;
+  // 2% on the VND card, 1% at the bank abroad
+  const accounts: any[] = [
+    { id: 1, name: "Visa", default_asset: "VND", fx_margin_percent: 2 },
+    { id: 2, name: "Wise", jurisdiction: "US", fx_margin_percent: 1 },
+    { id: 3, name: "Cash", default_asset: "VND" },
+  ];
+  const findAllAccounts = vi.fn(() => accounts);
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { transactions: [] };
+    findAllAccounts.mockClear();
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: { findAllAccounts, findAllAssets: () => [] },
+      settingsRepository: { getHomeJurisdiction: () => "VN" },
+    }));
+  });
+
+  const build = async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { withFxSnapshots } = await import(
+      "../src/repositories/fx-snapshot.repository"
+    );
+    const { accountDefaultsService } = await import(
+      "../src/services/account-defaults.service"
+    );
+    return withFxSnapshots(
+      new TransactionRepositoryJson(),
+      () => ["VND"],
+      (c) => (c.symbol === "VND" ? 1 / 25000 : undefined),
+      () => accountDefaultsService.fxMargins(),
+    );
+  };
+
+  it("applies only to foreign fiat", async () => {
+    const { fxMarginPercent } = await import("../src/utils/fx.util");
+    const [visa, wise, cash] = accounts;
+
+    expect(fxMarginPercent(usd, visa, "VN")).toBe(2);
+    expect(fxMarginPercent(vnd, visa, "VN")).toBe(0);
+    expect(fxMarginPercent(btc, visa, "VN")).toBe(0);
+    // Without a default asset, the jurisdiction gives the home currency
+    expect(fxMarginPercent(usd, wise, "VN")).toBe(0);
+    expect(fxMarginPercent(vnd, wise, "VN")).toBe(1);
+    expect(fxMarginPercent(usd, { ...wise, jurisdiction: "" }, "VN")).toBe(1);
+    expect(fxMarginPercent(usd, cash, "VN")).toBe(0);
+    expect(fxMarginPercent(usd, undefined, "VN")).toBe(0);
+  });
+
+  it("includes the margin in new foreign-currency amounts", async () => {
+    const repo = await build();
+    repo.create(tx("card", "Visa", usd, 100));
+    repo.create(tx("home", "Visa", vnd, 250000));
+
+    const card = repo.findById("card")!;
+    expect(card.fxMargin).toBe(2);
+    expect(card.usdAmount).toBeCloseTo(102, 9);
+    expect(card.rate.rateUSD).toBe(1);
+    // The snapshot is mid-market; amounts converted with it are not
+    expect(card.fx!.VND).toBeCloseTo(25000, 6);
+    const { amountIn, amountVND } = await import("../src/utils/fx.util");
+    expect(amountVND(card, 26000)).toBeCloseTo(100 * 25000 * 1.02, 3);
+    expect(amountIn(card, "USD")).toBeCloseTo(102, 9);
+
+    const home = repo.findById("home")!;
+    expect(home.fxMargin).toBeUndefined();
+    expect(home.usdAmount).toBeCloseTo(10, 9);
+    expect(amountVND(home, 26000)).toBe(250000);
+  });
+
+  it("reads the accounts once per batch", async () => {
+    const repo = await build();
+    repo.createMany([
+      tx("b1", "Visa", usd, 10),
+      tx("b2", "visa ", eur, 10),
+      tx("b3", "Wise", vnd, 250000),
+      tx("b4", "Cash", usd, 10),
+    ]);
+
+    expect(findAllAccounts).toHaveBeenCalledTimes(1);
+    expect(repo.findAll().map((t) => t.fxMargin)).toEqual([
+      2,
+      2,
+      1,
+      undefined,
+    ]);
+  });
+
+  it("re-applies the margin on repricing and moves", async () => {
+    const repo = await build();
+    repo.create(tx("a", "Visa", eur, 100));
+    expect(repo.findById("a")!.usdAmount).toBeCloseTo(112.2, 9);
+
+    // A recalc gives the mid-market amount at the new rate
+    repo.update("a", { usdAmount: 120 });
+    expect(repo.findById("a")!.usdAmount).toBeCloseTo(122.4, 9);
+
+    repo.update("a", { account: "Wise" });
+    expect(repo.findById("a")).toMatchObject({
+      fxMargin: 1,
+      usdAmount: expect.closeTo(121.2, 9),
+    });
+
+    repo.update("a", { account: "Cash", asset: vnd, amount: 3_000_000 });
+    expect(repo.findById("a")!.fxMargin).toBeUndefined();
+
+    // A margin given with the write is kept as it is
+    repo.update("a", { usdAmount: 50, fxMargin: 3 });
+    expect(repo.findById("a")).toMatchObject({ usdAmount: 50, fxMargin: 3 });
+  });
+
+  it("leaves income, transfers and restores at mid-market", async () => {
+    const repo = await build();
+    const { withoutFxMargins } = await import("../src/utils/fx.util");
+    const typed = (type: string, t: Transaction) => ({ ...t, type }) as any;
+
+    repo.create(typed("INCOME", tx("refund", "Visa", usd, 100)));
+    repo.create(typed("TRANSFER_OUT", tx("out", "Visa", usd, 100)));
+    repo.create(typed("TRANSFER_IN", tx("in", "Wise", vnd, 2_500_000)));
+    for (const id of ["refund", "out", "in"]) {
+      expect(repo.findById(id)!.fxMargin).toBeUndefined();
+    }
+    expect(repo.findById("refund")!.usdAmount).toBe(100);
+
+    // Turning the expense into income drops its margin
+    repo.create(tx("a", "Visa", usd, 100));
+    repo.update("a", { type: "INCOME" });
+    expect(repo.findById("a")).toMatchObject({ usdAmount: 100 });
+    expect(repo.findById("a")!.fxMargin).toBeUndefined();
+
+    // A backup from before margins already holds the amount charged
+    withoutFxMargins(() => {
+      repo.create(tx("restored", "Visa", usd, 102));
+      repo.update("restored", { usdAmount: 103 });
+    });
+    expect(repo.findById("restored")).toMatchObject({ usdAmount: 103 });
+    expect(repo.findById("restored")!.fxMargin).toBeUndefined();
+  });
+});
@@ -0,0 +1,124 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Per-transaction FX snapshots
+ *
+ * - New transactions record the value of their asset in VND and the
+ *   reporting currency, from the rates at hand on their day
+ * - Currencies without a rate are left out rather than guessed
+ * - Editing the asset, date or rate re-takes the snapshot; amounts keep it
+ * - Amounts in a currency use the snapshot, else today's rate
+ */
+
+type Transaction = import("../src/types").Transaction;
+type Asset = import("../src/types").Asset;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+const vnd = { type: "FIAT" as const, symbol: "VND" };
+const btc = { type: "CRYPTO" as const, symbol: "BTC" };
+
+const tx = (id: string, asset: Asset, amount: number, rateUSD: number) =>
+  ({
+    id,
+    type: "INCOME",
+    asset,
+    amount,
+    account: "Bank",
+    createdAt: "2025-03-01T00:00:00.000Z",
+    rate: { asset: usd, rateUSD, timestamp: "2025-03-01T00:00:00.000Z" },
+    usdAmount: amount * rateUSD,
+  }) as Transaction;
+
+describe("FX snapshots", () => {
+  let store: any;
+  // USD per unit of a currency, by day
+  const rates: Record<string, Record<string, number>> = {
+    "2025-03-01": { VND: 1 / 25000, EUR: 1.1 },
+    "2025-04-01": { VND: 1 / 26000 },
+  };
+  const usdPerUnit = (c: Asset, at: string) =>
+    rates[at.slice(0, 10)]?.[c.symbol];
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { transactions: [] };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+  });
+
+  const build = async (currencies = ["VND", "EUR"]) => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { withFxSnapshots } = await import(
+      "../src/repositories/fx-snapshot.repository"
+    );
+    return withFxSnapshots(
+      new TransactionRepositoryJson(),
+      () => currencies,
+      usdPerUnit,
+    );
+  };
+
+  it("stamps new transactions with the rates of their day", async () => {
+    const repo = await build();
+
+    repo.create(tx("b", btc, 0.5, 80000));
+    repo.createMany([tx("v", vnd, 250000, 1 / 25000), tx("u", usd, 10, 1)]);
+
+    expect(repo.findById("b")!.fx).toEqual({
+      VND: expect.closeTo(80000 * 25000, 3),
+      EUR: expect.closeTo(80000 / 1.1, 6),
+    });
+    // The asset's own currency and USD are not repeated
+    expect(Object.keys(repo.findById("v")!.fx!)).toEqual(["EUR"]);
+    expect(repo.findById("u")!.fx).toEqual({
+      VND: expect.closeTo(25000, 6),
+      EUR: expect.closeTo(1 / 1.1, 6),
+    });
+    // A snapshot given by the caller is kept
+    repo.create({ ...tx("k", usd, 1, 1), fx: { VND: 24000 } });
+    expect(repo.findById("k")!.fx).toEqual({ VND: 24000 });
+  });
+
+  it("leaves out currencies without a rate", async () => {
+    const repo = await build(["VND", "JPY"]);
+    repo.create({ ...tx("a", usd, 10, 1), createdAt: "2025-05-01T00:00Z" });
+    repo.create(tx("b", usd, 10, 1));
+
+    expect(repo.findById("a")!.fx).toBeUndefined();
+    expect(repo.findById("b")!.fx!.VND).toBeCloseTo(25000, 6);
+  });
+
+  it("re-takes the snapshot when the date or rate changes", async () => {
+    const repo = await build(["VND"]);
+    repo.create(tx("a", usd, 10, 1));
+
+    repo.update("a", { amount: 20, usdAmount: 20 });
+    expect(repo.findById("a")!.fx!.VND).toBeCloseTo(25000, 6);
+
+    repo.update("a", { createdAt: "2025-04-01T00:00:00.000Z" });
+    expect(repo.findById("a")!.fx!.VND).toBeCloseTo(26000, 6);
+
+    // No rate on the new day: the stale snapshot goes
+    repo.update("a", { createdAt: "2025-05-01T00:00:00.000Z" });
+    expect(repo.findById("a")!.fx).toBeUndefined();
+  });
+
+  it("converts amounts with the snapshot, else today's rate", async () => {
+    const { amountIn, amountVND } = await import("../src/utils/fx.util");
+    const b = { ...tx("b", btc, 0.5, 80000), fx: { VND: 2e9 } };
+    const u = tx("u", usd, 10, 1);
+
+    expect(amountIn(b, "USD")).toBe(40000);
+    expect(amountIn(b, "VND")).toBe(1e9);
+    expect(amountIn(b, "EUR")).toBeUndefined();
+    expect(amountVND(b, 26000)).toBe(1e9);
+    expect(amountVND(u, 26000)).toBe(260000);
+    expect(amountVND(tx("v", vnd, 5000, 1 / 25000), 26000)).toBe(5000);
+  });
+});
@@ -0,0 +1,120 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Network fee report
+ *
+ * - Gas fees grouped by chain and month in native units and USD
+ * - Exchange fees without a chain are reported separately
+ * - Card FX fees are summed by month and card
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Network fees", () => {
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  const SOL = { type: "CRYPTO" as const, symbol: "SOL" };
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+
+  const fee = (
+    asset: { type: "CRYPTO" | "FIAT"; symbol: string },
+    amount: number,
+    usdAmount: number,
+    createdAt: string,
+    extra: Partial<Transaction> = {},
+  ) =>
+    ({
+      id: `${asset.symbol}-${createdAt}-${amount}`,
+      type: "EXPENSE",
+      asset,
+      amount,
+      createdAt,
+      account: "Wallet",
+      category: "network_fee",
+      rate: { asset, rateUSD: usdAmount / amount, timestamp: createdAt },
+      usdAmount,
+      ...extra,
+    }) as Transaction;
+
+  const txs: Transaction[] = [
+    fee(ETH, 0.002, 6, "2025-01-05T00:00:00.000Z", { chain: "ethereum" }),
+    fee(ETH, 0.001, 3.5, "2025-01-20T00:00:00.000Z", { chain: "ethereum" }),
+    fee(ETH, 0.003, 9, "2025-02-02T00:00:00.000Z", { chain: "ethereum" }),
+    fee(SOL, 0.01, 2, "2025-01-07T00:00:00.000Z", { chain: "solana" }),
+    // Exchange trading fee: no chain
+    fee(USD, 4, 4, "2025-01-08T00:00:00.000Z", { category: "trading_fee" }),
+    // Ordinary spending is neither
+    fee(USD, 50, 50, "2025-01-09T00:00:00.000Z", { category: "food" }),
+    // Foreign-currency card spending with its FX fee
+    fee(USD, 40, 40, "2025-01-12T00:00:00.000Z", {
+      category: "shopping",
+      card: "Visa 1234",
+      feeUSD: 1,
+    }),
+    fee(USD, 20, 20, "2025-02-03T00:00:00.000Z", {
+      category: "travel",
+      feeUSD: 0.5,
+    }),
+  ];
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => txs },
+      vaultRepository: {},
+      settingsRepository: {},
+      borrowingRepository: {},
+      adminRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({ vaultService: {} }));
+    vi.doMock("../src/services/price.service", () => ({ priceService: {} }));
+  });
+
+  it("groups gas by chain and month", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const r = transactionService.getNetworkFees();
+
+    expect(r.buckets.map((b) => [b.chain, b.month, b.count])).toEqual([
+      ["ethereum", "2025-01", 2],
+      ["solana", "2025-01", 1],
+      ["ethereum", "2025-02", 1],
+    ]);
+    expect(r.buckets[0].native.ETH).toBeCloseTo(0.003, 12);
+    expect(r.buckets[0].usd).toBe(9.5);
+    expect(r.byChain.ethereum).toEqual({ count: 3, usd: 18.5 });
+    expect(r.totalUSD).toBe(20.5);
+    expect(r.tradingFeesUSD).toBe(4);
+  });
+
+  it("filters by chain and date range", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const r = transactionService.getNetworkFees({
+      chain: "Ethereum",
+      start: "2025-01-10T00:00:00.000Z",
+    });
+
+    expect(r.buckets.map((b) => b.month)).toEqual(["2025-01", "2025-02"]);
+    expect(r.totalUSD).toBe(12.5);
+    expect(Object.keys(r.byChain)).toEqual(["ethereum"]);
+  });
+
+  it("sums card FX fees by month and card", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const { fxFees } = transactionService.getNetworkFees({ chain: "solana" });
+
+    expect(fxFees.totalUSD).toBe(1.5);
+    expect(fxFees.byMonth).toEqual({
+      "2025-01": { count: 1, usd: 1 },
+      "2025-02": { count: 1, usd: 0.5 },
+    });
+    expect(fxFees.byCard).toEqual({
+      "Visa 1234": { count: 1, usd: 1 },
+      Unassigned: { count: 1, usd: 0.5 },
+    });
+  });
+});
@@ -0,0 +1,193 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Geographic exposure
+ *
+ * - A holding's country is its asset's jurisdiction, else its account's
+ * - Holdings split into domestic (the home jurisdiction), foreign and
+ *   unknown, with the assets behind each country
+ * - Jurisdictions are 2-letter ISO codes, upper-cased; an empty one clears
+ *   the account's or asset's value
+ */
+
+type Asset = import("../src/types").Asset;
+type PortfolioReportItem = import("../src/types").PortfolioReportItem;
+
+describe("Geographic exposure", () => {
+  let accounts: any[] = [];
+  let assets: any[] = [];
+  let home = "VN";
+  const createAccount = vi.fn((a: any) => ({ id: 9, ...a }));
+  const updateAsset = vi.fn((id: number, updates: any) => {
+    const a = assets.find((x) => x.id === id);
+    return a ? Object.assign(a, updates) : undefined;
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    home = "VN";
+    accounts = [
+      { id: 1, name: "SSI", jurisdiction: "VN" },
+      { id: 2, name: "Wise", jurisdiction: "US" },
+      { id: 3, name: "Binance" },
+    ];
+    assets = [
+      { id: 1, symbol: "VNM" },
+      { id: 2, symbol: "AAPL", jurisdiction: "US" },
+    ];
+    createAccount.mockClear();
+    updateAsset.mockClear();
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAllAccounts: () => accounts,
+        findAllAssets: () => assets,
+        findAssetById: (id: number) => assets.find((a) => a.id === id),
+        createAccount,
+        updateAsset,
+      },
+      settingsRepository: {
+        getHomeJurisdiction: () => home,
+        setHomeJurisdiction: (c: string) => {
+          home = c;
+        },
+        getReportingCurrency: () => "USD",
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: Asset) => ({
+          asset,
+          rateUSD: asset.symbol === "VND" ? 1 / 25000 : 1,
+          timestamp: new Date().toISOString(),
+          source: "FIXED",
+        }),
+      },
+    }));
+  });
+
+  const app = async () => {
+    const { reportsRouter } = await import("../src/handlers/reports.handler");
+    const { adminRouter } = await import("../src/handlers/admin.handler");
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const holding = (
+      symbol: string,
+      account: string,
+      valueUSD: number,
+    ): PortfolioReportItem => ({
+      asset: { type: symbol === "BTC" ? "CRYPTO" : "FIAT", symbol },
+      account,
+      balance: 1,
+      rateUSD: valueUSD,
+      valueUSD,
+    });
+    vi.spyOn(transactionService, "generateReport").mockResolvedValue({
+      holdings: [
+        holding("VNM", "SSI", 1000),
+        // The issuer's country wins over the broker's
+        holding("AAPL", "SSI", 3000),
+        holding("USD", "Wise", 500),
+        holding("BTC", "Binance", 500),
+      ],
+      liabilities: [],
+      receivables: [],
+      totals: {
+        holdingsUSD: 5000,
+        liabilitiesUSD: 0,
+        receivablesUSD: 0,
+        netWorthUSD: 5000,
+      },
+    });
+    const a = express();
+    a.use(express.json());
+    a.use("/api", reportsRouter);
+    a.use("/api", adminRouter);
+    return a;
+  };
+
+  it("splits holdings into domestic, foreign and unknown", async () => {
+    const res = await request(await app()).get(
+      "/api/reports/exposure/geographic",
+    );
+
+    expect(res.status).toBe(200);
+    expect(res.body).toMatchObject({
+      home_jurisdiction: "VN",
+      total_value_usd: 5000,
+      total_value_vnd: 125000000,
+      domestic_usd: 1000,
+      domestic_percentage: 20,
+      foreign_usd: 3500,
+      foreign_vnd: 87500000,
+      foreign_percentage: 70,
+      unknown_usd: 500,
+      unknown_percentage: 10,
+    });
+    expect(res.body.by_country).toEqual({
+      VN: {
+        value_usd: 1000,
+        value_vnd: 25000000,
+        percentage: 20,
+        assets: ["VNM"],
+      },
+      US: {
+        value_usd: 3500,
+        value_vnd: 87500000,
+        percentage: 70,
+        assets: ["AAPL", "USD"],
+      },
+      UNKNOWN: {
+        value_usd: 500,
+        value_vnd: 12500000,
+        percentage: 10,
+        assets: ["BTC"],
+      },
+    });
+  });
+
+  it("follows a change of home jurisdiction", async () => {
+    const a = await app();
+    const set = await request(a)
+      .post("/api/admin/settings/home-jurisdiction")
+      .send({ country: " us " });
+    expect(set.body).toEqual({ home_jurisdiction: "US" });
+
+    const res = await request(a).get("/api/reports/exposure/geographic");
+    expect(res.body).toMatchObject({ domestic_usd: 3500, foreign_usd: 1000 });
+
+    for (const country of [undefined, "USA", "1A"]) {
+      const bad = await request(a)
+        .post("/api/admin/settings/home-jurisdiction")
+        .send({ country });
+      expect(bad.status).toBe(400);
+    }
+    expect(home).toBe("US");
+  });
+
+  it("validates jurisdictions on accounts and assets", async () => {
+    const a = await app();
+    const created = await request(a)
+      .post("/api/admin/accounts")
+      .send({ name: "IBKR", jurisdiction: "us" });
+    expect(created.status).toBe(201);
+    expect(created.body.jurisdiction).toBe("US");
+
+    const bad = await request(a)
+      .post("/api/admin/accounts")
+      .send({ name: "IBKR", jurisdiction: "United States" });
+    expect(bad.status).toBe(400);
+    expect(bad.body.error).toBe(
+      "jurisdiction must be a 2-letter ISO country code",
+    );
+    expect(createAccount).toHaveBeenCalledTimes(1);
+
+    const cleared = await request(a)
+      .put("/api/admin/assets/2")
+      .send({ jurisdiction: "" });
+    expect(cleared.status).toBe(200);
+    expect(updateAsset).toHaveBeenCalledWith(2, { jurisdiction: "" });
+  });
+});
@@ -0,0 +1,226 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Savings goals
+ *
+ * - Funded by the linked accounts at today's prices plus tagged
+ *   transactions elsewhere; unpriced holdings are listed, not guessed
+ * - Contributions are averaged over the trailing complete months, from
+ *   the first one; opening balances don't count
+ * - The projection is compared with the target date
+ */
+
+describe("Savings goals", () => {
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  const XYZ = { type: "CRYPTO" as const, symbol: "XYZ" };
+  const now = new Date("2025-07-15T00:00:00.000Z");
+  const DAY_MS = 24 * 60 * 60 * 1000;
+  let store: any;
+  let transactions: any[] = [];
+
+  const tx = (
+    id: string,
+    type: string,
+    day: string,
+    amount: number,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type,
+    asset: USD,
+    amount,
+    usdAmount: amount,
+    account: "Savings",
+    createdAt: `${day}T09:00:00.000Z`,
+    ...extra,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { goals: [] };
+    transactions = [
+      tx("opening", "INITIAL", "2024-12-01", 2000),
+      ...["01", "02", "03", "04", "05", "06"].map((m) =>
+        tx(`in-${m}`, "TRANSFER_IN", `2025-${m}-01`, 500),
+      ),
+      tx("out", "TRANSFER_OUT", "2025-05-10", 200, { account: "savings" }),
+      tx("tagged", "EXPENSE", "2025-03-03", 400, {
+        account: "Checking",
+        category: "House",
+      }),
+      tx("this-month", "INCOME", "2025-07-02", 100),
+      tx("unrelated", "EXPENSE", "2025-04-04", 50, { account: "Checking" }),
+    ];
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+    vi.doMock("../src/repositories", async () => {
+      const { GoalRepositoryJson } = await import(
+        "../src/repositories/goal.repository"
+      );
+      return {
+        goalRepository: new GoalRepositoryJson(),
+        transactionRepository: { findAll: () => transactions },
+      };
+    });
+    vi.doMock("../src/services/account-balance.service", () => ({
+      accountBalanceService: {
+        getBalances: () => [
+          { account: "Savings", asset: USD, quantity: 3000 },
+          { account: "Savings", asset: BTC, quantity: 0.01 },
+          { account: "Savings", asset: XYZ, quantity: 5 },
+          { account: "Checking", asset: USD, quantity: 900 },
+        ],
+      },
+    }));
+    const rates: Record<string, number> = {
+      USD: 1,
+      BTC: 60000,
+      VND: 1 / 26000,
+    };
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }) => {
+          const rateUSD = rates[asset.symbol];
+          if (rateUSD === undefined) throw new Error("no price");
+          return { asset, rateUSD };
+        },
+      },
+    }));
+  });
+
+  const load = async () =>
+    (await import("../src/services/goal.service")).goalService;
+
+  const house = {
+    name: "House deposit",
+    target_amount: 10000,
+    currency: "USD" as const,
+    target_date: "2026-06-30",
+    accounts: ["Savings"],
+    tags: ["house"],
+    active: true,
+  };
+
+  it("adds up the accounts and tagged contributions", async () => {
+    const service = await load();
+    const goal = service.create(house);
+    const p = await service.progress(goal, 26000, 6, now);
+
+    expect(p.accounts_usd).toBeCloseTo(3600, 6);
+    expect(p.unpriced).toEqual(["CRYPTO:XYZ"]);
+    expect(p.contributions_usd).toBe(400);
+    expect(p.funded_usd).toBeCloseTo(4000, 6);
+    expect(p.funded_percentage).toBeCloseTo(40, 6);
+    expect(p.remaining_vnd).toBeCloseTo(6000 * 26000, 3);
+    expect(p.funded).toBe(false);
+  });
+
+  it("projects completion from the trailing average", async () => {
+    const service = await load();
+    const goal = service.create(house);
+
+    const p = await service.progress(goal, 26000, 6, now);
+    // Six transfers in, one out, one tagged; July is not complete yet
+    expect(p.avg_monthly_contribution_usd).toBeCloseTo(3200 / 6, 6);
+    expect(p.projected_completion_date).toBe("2026-06-23");
+    expect(p.on_track).toBe(true);
+    const monthsLeft =
+      (Date.parse("2026-06-30T23:59:59.999Z") - now.getTime()) /
+      DAY_MS /
+      (365.25 / 12);
+    expect(p.required_monthly_usd).toBeCloseTo(6000 / monthsLeft, 6);
+
+    const recent = await service.progress(goal, 26000, 3, now);
+    expect(recent.avg_monthly_contribution_usd).toBeCloseTo(1300 / 3, 6);
+    expect(recent.on_track).toBe(false);
+
+    // Twelve months back, but contributions only began in January
+    const year = await service.progress(goal, 26000, 12, now);
+    expect(year.avg_monthly_contribution_usd).toBeCloseTo(3200 / 6, 6);
+  });
+
+  it("reports active goals, converting VND targets", async () => {
+    const service = await load();
+    service.create(house);
+    service.create({
+      ...house,
+      name: "Car",
+      target_amount: 260_000_000,
+      currency: "VND",
+      target_date: undefined,
+      accounts: [],
+      tags: ["car"],
+    });
+    service.create({ ...house, name: "Old", active: false });
+    transactions.push(
+      tx("car", "EXPENSE", "2025-07-01", 1000, {
+        account: "Checking",
+        tags: ["CAR"],
+      }),
+    );
+
+    const report = await service.report(26000, 6, now);
+    expect(report.goals.map((g) => g.name)).toEqual(["House deposit", "Car"]);
+    const car = report.goals[1];
+    expect(car.target_usd).toBe(10000);
+    expect(car.funded_usd).toBe(1000);
+    // Only contributed this month: nothing to project from yet
+    expect(car.avg_monthly_contribution_usd).toBe(0);
+    expect(car.projected_completion_date).toBeNull();
+    expect(car.on_track).toBeNull();
+    expect(car.required_monthly_usd).toBeNull();
+    expect(report.totals.target_usd).toBe(20000);
+    expect(report.totals.funded_percentage).toBeCloseTo(25, 6);
+  });
+
+  it("serves goals over HTTP", async () => {
+    const { goalsRouter } = await import("../src/handlers/goals.handler");
+    const app = express();
+    app.use(express.json());
+    app.use("/api", goalsRouter);
+
+    const bad = await request(app)
+      .post("/api/goals")
+      .send({ ...house, target_date: "June" });
+    expect(bad.status).toBe(400);
+
+    const created = await request(app)
+      .post("/api/goals")
+      .send({ name: " Trip ", target_amount: 500, tags: ["trip"] });
+    expect(created.status).toBe(201);
+    expect(created.body).toMatchObject({
+      name: "Trip",
+      targetAmount: 500,
+      currency: "USD",
+      accounts: [],
+      active: true,
+    });
+
+    const id = created.body.id;
+    const updated = await request(app)
+      .put(`/api/goals/${id}`)
+      .send({ accounts: ["Savings"] });
+    expect(updated.body.accounts).toEqual(["Savings"]);
+    expect(updated.body.tags).toEqual(["trip"]);
+
+    const progress = await request(app).get(`/api/goals/${id}/progress`);
+    expect(progress.status).toBe(200);
+    expect(progress.body.funded).toBe(true);
+    expect(progress.body.remaining_usd).toBe(0);
+
+    expect(
+      (await request(app).get(`/api/goals/${id}/progress?months=0`)).status,
+    ).toBe(400);
+    expect((await request(app).get("/api/goals/nope/progress")).status).toBe(
+      404,
+    );
+    expect((await request(app).delete(`/api/goals/${id}`)).status).toBe(200);
+  });
+});
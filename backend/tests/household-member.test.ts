@@ -0,0 +1,120 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Household member attribution
+ *
+ * - Expenses and income record who spent or earned them
+ * - Spending and contributions are totalled per member
+ * - Transactions are searchable by member
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Household members", () => {
+  let transactions: Transaction[] = [];
+
+  const tx = (
+    id: string,
+    type: "INCOME" | "EXPENSE",
+    usdAmount: number,
+    member?: string,
+  ) =>
+    ({
+      id,
+      type,
+      asset: { type: "FIAT", symbol: "USD" },
+      amount: usdAmount,
+      usdAmount,
+      account: "Joint",
+      note: id,
+      member,
+      createdAt: `2025-03-0${id.slice(-1)}T00:00:00.000Z`,
+      rate: { asset: { type: "FIAT", symbol: "USD" }, rateUSD: 1 },
+    }) as unknown as Transaction;
+
+  beforeEach(() => {
+    vi.resetModules();
+    transactions = [];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => transactions,
+        create: (t: Transaction) => {
+          transactions.push(t);
+          return t;
+        },
+      },
+      settingsRepository: {
+        getDefaultSpendingVaultName: () => "Spend",
+        getDefaultIncomeVaultName: () => "Income",
+        getCardFxMarkupPercent: () => 2.5,
+      },
+      reportSubscriptionRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: { ensureVault: vi.fn(), addVaultEntry: vi.fn() },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async () => ({
+          symbol: "USD",
+          rateUSD: 1,
+          timestamp: new Date().toISOString(),
+        }),
+      },
+    }));
+  });
+
+  it("stores the member of an expense, trimmed", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const t = await transactionService.createExpenseTransaction({
+      asset: { type: "FIAT", symbol: "USD" },
+      amount: 12,
+      account: "Joint",
+      note: "Groceries",
+      member: "  An ",
+    });
+    expect(t.member).toBe("An");
+    expect(t.account).toBe("Joint");
+  });
+
+  it("totals spending and income per member", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const spending = [
+      tx("e1", "EXPENSE", 30, "An"),
+      tx("e2", "EXPENSE", 50, "binh"),
+      tx("e3", "EXPENSE", 20, "an"),
+      tx("e4", "EXPENSE", 5),
+    ];
+    const income = [tx("i1", "INCOME", 1000, "Binh"), tx("i2", "INCOME", 10)];
+    const r = transactionService.getMemberReport(spending, income);
+
+    expect(
+      r.members.map((m) => [m.member, m.spending.usd, m.contribution.usd]),
+    ).toEqual([
+      ["binh", 50, 1000],
+      ["An", 50, 0],
+    ]);
+    expect(r.members[1].spending.count).toBe(2);
+    expect(r.unassigned.spending).toEqual({ count: 1, usd: 5 });
+    expect(r.unassigned.contribution).toEqual({ count: 1, usd: 10 });
+    expect(r.totalSpendingUSD).toBe(105);
+    expect(r.totalContributionUSD).toBe(1010);
+  });
+
+  it("finds transactions by member", async () => {
+    transactions = [
+      tx("e1", "EXPENSE", 30, "An"),
+      tx("e2", "EXPENSE", 50, "Binh"),
+      tx("e3", "EXPENSE", 5),
+    ];
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    const r = transactionSearchService.search({ members: ["an"] });
+    expect(r.items.map((t) => t.id)).toEqual(["e1"]);
+  });
+});
@@ -0,0 +1,283 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Transaction import
+ *
+ * - A profile maps columns; debit rows become expenses, credit rows income
+ * - Any failing row is reported by line and nothing is written
+ * - References already imported or repeated in the file are rejected
+ * - OFX/QIF statements skip entries already imported (FITID or hash)
+ * - Statement payees map to known counterparties, whose past tags are
+ *   suggested
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Import Service", () => {
+  const createMany = vi.fn();
+  const addVaultEntry = vi.fn();
+  let imported: string[] = [];
+  let history: Transaction[] = [];
+
+  const csv = [
+    "Date,Description,Debit,Credit,Ref",
+    '05/01/2025,"Grab, airport",52000,,FT001',
+    "06/01/2025,Salary,,\"30,000,000\",FT002",
+    "",
+  ].join("\n");
+
+  const run = async (content: string, extra: object = {}) => {
+    const { TransactionImportSchema } = await import("../src/types");
+    const { importService } = await import("../src/services/import.service");
+    return importService.import(
+      TransactionImportSchema.parse({
+        format: "csv",
+        content,
+        profile: {
+          columns: {
+            date: "Date",
+            note: "description",
+            debit: "Debit",
+            credit: "Credit",
+            reference: "Ref",
+          },
+          dateFormat: "DD/MM/YYYY",
+          asset: "VND",
+        },
+        ...extra,
+      }),
+    );
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    createMany.mockReset();
+    addVaultEntry.mockReset();
+    imported = [];
+    history = [];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findBySourceRef: (ref: string) =>
+          imported.includes(ref) ? ({ id: ref } as Transaction) : undefined,
+        findAll: () => history,
+        createMany,
+      },
+      settingsRepository: {
+        getDefaultSpendingVaultName: () => "Spend",
+        getDefaultIncomeVaultName: () => "Income",
+        getTransactionRules: () => [],
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        buildTransactionBase: async (
+          asset: { symbol: string },
+          amount: number,
+          at: string,
+          account: string,
+        ) => ({
+          asset,
+          amount,
+          createdAt: at,
+          account,
+          rate: { asset, rateUSD: 0.00004, timestamp: at, source: "ER_API" },
+          usdAmount: amount * 0.00004,
+        }),
+      },
+    }));
+    vi.doMock("../src/services/precision.service", () => ({
+      precisionService: {
+        validate: (_asset: unknown, amount: number) => {
+          if (!Number.isInteger(amount)) {
+            throw new Error(`amount ${amount} VND has more than 0 decimals`);
+          }
+        },
+      },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: { ensureVault: vi.fn(), addVaultEntry },
+    }));
+    vi.doMock("../src/services/address-book.service", () => ({
+      addressBookService: {
+        resolve: (ref: string) =>
+          ref === "NGUYEN VAN A" ? { name: "Landlord" } : undefined,
+      },
+    }));
+  });
+
+  it("maps debit and credit rows and writes them in one batch", async () => {
+    const r = await run(csv);
+
+    expect(r.errors).toEqual([]);
+    expect(r.created).toBe(2);
+    expect(createMany).toHaveBeenCalledTimes(1);
+
+    const [expense, income] = createMany.mock.calls[0][0] as Transaction[];
+    expect(expense).toMatchObject({
+      type: "EXPENSE",
+      amount: 52000,
+      note: "Grab, airport",
+      account: "Spend",
+      sourceRef: "FT001",
+      createdAt: "2025-01-05T00:00:00.000Z",
+    });
+    expect(income).toMatchObject({
+      type: "INCOME",
+      amount: 30_000_000,
+      account: "Income",
+    });
+    expect(addVaultEntry).toHaveBeenCalledTimes(1);
+  });
+
+  it("reports every failing row and writes nothing", async () => {
+    const r = await run(
+      [
+        "Date,Description,Debit,Credit,Ref",
+        "31/02/2025,Grab,52000,,FT001",
+        "06/01/2025,,10.5,,FT002",
+        "07/01/2025,Both,100,200,FT003",
+      ].join("\n"),
+    );
+
+    expect(r.created).toBe(0);
+    expect(createMany).not.toHaveBeenCalled();
+    expect(r.errors).toEqual([
+      { row: 2, errors: ['invalid date "31/02/2025"'] },
+      {
+        row: 3,
+        errors: [
+          "amount 10.5 VND has more than 0 decimals",
+          "note or counterparty is required",
+        ],
+      },
+      { row: 4, errors: ["expected exactly one of debit or credit"] },
+    ]);
+  });
+
+  it("rejects imported and repeated references", async () => {
+    imported = ["FT001"];
+    const r = await run(csv + "07/01/2025,Grab again,52000,,FT002\n");
+
+    expect(r.errors).toEqual([
+      { row: 2, errors: ["reference FT001 was already imported"] },
+      { row: 4, errors: ["reference FT002 repeats row 3"] },
+    ]);
+    expect(createMany).not.toHaveBeenCalled();
+  });
+
+  it("validates without writing on a dry run", async () => {
+    const r = await run(csv, { dryRun: true });
+
+    expect(r.transactions).toHaveLength(2);
+    expect(r.created).toBe(0);
+    expect(createMany).not.toHaveBeenCalled();
+  });
+
+  const statement = async (format: string, content: string, extra = {}) => {
+    const { TransactionImportSchema } = await import("../src/types");
+    const { importService } = await import("../src/services/import.service");
+    return importService.import(
+      TransactionImportSchema.parse({
+        format,
+        content,
+        asset: "VND",
+        ...extra,
+      }),
+    );
+  };
+
+  const ofx = `OFXHEADER:100
+DATA:OFXSGML
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>VND
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250105120000[+7:ICT]
+<TRNAMT>-52000<FITID>FT001<NAME>GRAB*FOOD 0412<MEMO>Lunch</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250106<TRNAMT>-8000000
+<FITID>FT002<NAME>NGUYEN VAN A</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20250110<TRNAMT>30000000
+<FITID>FT003<NAME>ACME CORP</STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`;
+
+  it("imports OFX, skipping FITIDs already imported", async () => {
+    imported = ["FT003"];
+    history = [
+      { counterparty: "Grab Food", tags: ["food", "delivery"] },
+      { counterparty: "Grab Food", tags: ["food"] },
+      { counterparty: "Grab Food", tags: ["food", "delivery", "trip"] },
+      { counterparty: "Grab Food", tags: [] },
+    ] as unknown as Transaction[];
+    const r = await statement("ofx", ofx, { asset: undefined });
+
+    expect(r.errors).toEqual([]);
+    expect(r.duplicates).toEqual([
+      { row: 3, sourceRef: "FT003", transactionId: "FT003" },
+    ]);
+    const [lunch, rent] = createMany.mock.calls[0][0] as Transaction[];
+    expect(lunch).toMatchObject({
+      type: "EXPENSE",
+      amount: 52000,
+      asset: { symbol: "VND" },
+      counterparty: "Grab Food",
+      note: "Lunch",
+      sourceRef: "FT001",
+      createdAt: "2025-01-05T00:00:00.000Z",
+    });
+    expect(lunch.tags).toBeUndefined();
+    expect(r.suggestedTags).toEqual({ [lunch.id]: ["food", "delivery"] });
+    // Not a past counterparty, but in the address book
+    expect(rent.counterparty).toBe("Landlord");
+  });
+
+  it("applies suggested tags when asked", async () => {
+    history = [
+      { counterparty: "Grab Food", tags: ["food"] },
+    ] as unknown as Transaction[];
+    const r = await statement("ofx", ofx, {
+      applySuggestedTags: true,
+      dryRun: true,
+    });
+
+    expect(r.transactions[0].tags).toEqual(["food"]);
+    expect(createMany).not.toHaveBeenCalled();
+  });
+
+  it("imports QIF and recognises a re-import by content hash", async () => {
+    const qif = [
+      "!Type:Bank",
+      "D31/01'25",
+      "T-45,000",
+      "PCircle K",
+      "LGroceries",
+      "^",
+      "D31/01'25",
+      "T-45,000",
+      "PCircle K",
+      "LGroceries",
+      "^",
+      "D01/02/2025",
+      "T-1,000,000",
+      "PTransfer",
+      "L[Savings]",
+      "^",
+    ].join("\n");
+    const opts = { dateFormat: "DD/MM/YYYY" };
+    const first = await statement("qif", qif, opts);
+
+    expect(first.created).toBe(3);
+    const refs = first.transactions.map((t) => t.sourceRef);
+    // Identical entries in one file are both kept
+    expect(new Set(refs).size).toBe(3);
+    expect(first.transactions[0]).toMatchObject({
+      amount: 45_000,
+      category: "Groceries",
+      createdAt: "2025-01-31T00:00:00.000Z",
+    });
+    expect(first.transactions[2].category).toBeUndefined();
+
+    imported = refs as string[];
+    const again = await statement("qif", qif, opts);
+    expect(again.created).toBe(0);
+    expect(again.duplicates).toHaveLength(3);
+  });
+});
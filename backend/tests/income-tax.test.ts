@@ -0,0 +1,154 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import { IncomeTaxSettingsSchema } from "../src/types";
+
+/**
+ * Income tax estimate
+ *
+ * - VN PIT brackets apply to income less the personal and dependent
+ *   deductions
+ * - Investment income, refunds and other years are left out
+ * - Expenses tagged tax are the tax paid; the gap is over- or
+ *   under-withholding
+ */
+
+describe("Income tax estimate", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  let transactions: any[] = [];
+  let settings = IncomeTaxSettingsSchema.parse({});
+
+  const tx = (
+    id: string,
+    type: "INCOME" | "EXPENSE",
+    amount: number,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type,
+    asset: VND,
+    amount,
+    usdAmount: amount / 25000,
+    createdAt: "2025-03-25T00:00:00.000Z",
+    ...extra,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings = IncomeTaxSettingsSchema.parse({});
+    transactions = [
+      // 30M a month for a year
+      ...Array.from({ length: 12 }, (_, m) =>
+        tx(`salary${m}`, "INCOME", 30_000_000, {
+          category: "salary",
+          createdAt: `2025-${String(m + 1).padStart(2, "0")}-25T00:00:00.000Z`,
+        }),
+      ),
+      tx("tax", "EXPENSE", 24_000_000, { tags: ["Tax"] }),
+      tx("div", "INCOME", 5_000_000, { category: "dividend" }),
+      tx("refund", "INCOME", 1_000_000, { reimbursesId: "e1" }),
+      tx("move", "INCOME", 9_000_000, { internalFlow: true }),
+      tx("old", "INCOME", 50_000_000, {
+        createdAt: "2024-12-31T00:00:00.000Z",
+      }),
+      tx("food", "EXPENSE", 3_000_000, { category: "food" }),
+    ];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => transactions },
+      settingsRepository: {
+        getIncomeTaxSettings: () => settings,
+        getFiscalYear: () => ({ start: "01-01", starts: {} }),
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {},
+    }));
+  });
+
+  it("taxes income above the deductions bracket by bracket", async () => {
+    const { incomeTaxService } = await import(
+      "../src/services/income-tax.service"
+    );
+    const r = incomeTaxService.estimate(2025, 25000);
+
+    expect(r.income).toHaveLength(12);
+    expect(r.incomeVND).toBe(360_000_000);
+    expect(r.taxableVND).toBe(360_000_000 - 132_000_000);
+    // 5% of 60M + 10% of 60M + 15% of 96M + 20% of 12M
+    expect(r.expectedTaxVND).toBeCloseTo(3e6 + 6e6 + 14.4e6 + 2.4e6, 6);
+    expect(r.brackets.map((b) => b.taxable)).toEqual([
+      60e6, 60e6, 96e6, 12e6, 0, 0, 0,
+    ]);
+    expect(r.effectiveRatePercent).toBeCloseTo((25.8 / 360) * 100, 6);
+  });
+
+  it("compares the tax paid with the tax due", async () => {
+    const { incomeTaxService } = await import(
+      "../src/services/income-tax.service"
+    );
+    const r = incomeTaxService.estimate(2025, 25000);
+
+    expect(r.payments.map((p) => p.id)).toEqual(["tax"]);
+    expect(r.paidTaxVND).toBe(24_000_000);
+    expect(r.differenceVND).toBeCloseTo(-1_800_000, 6);
+    expect(r.status).toBe("UNDER_WITHHELD");
+
+    // Two dependents take 105.6M off; the 24M paid is too much
+    const d = incomeTaxService.estimate(2025, 25000, 2);
+    expect(d.taxableVND).toBe(360e6 - 132e6 - 2 * 52.8e6);
+    expect(d.expectedTaxVND).toBeCloseTo(3e6 + 6e6 + 0.15 * 2.4e6, 6);
+    expect(d.status).toBe("OVER_WITHHELD");
+  });
+
+  it("uses the configured brackets, tags and currency rate", async () => {
+    settings = IncomeTaxSettingsSchema.parse({
+      brackets: [
+        { upTo: 100_000_000, ratePercent: 0 },
+        { upTo: null, ratePercent: 10 },
+      ],
+      personalDeduction: 0,
+      incomeTags: ["bonus"],
+      taxTag: "pit",
+    });
+    transactions.push(
+      tx("bonus", "INCOME", 0, {
+        asset: USD,
+        amount: 6000,
+        usdAmount: 6000,
+        category: "bonus",
+      }),
+      tx("pit", "EXPENSE", 5_000_000, { category: "PIT" }),
+    );
+    const { incomeTaxService } = await import(
+      "../src/services/income-tax.service"
+    );
+    const r = incomeTaxService.estimate(2025, 25000);
+
+    // Only the bonus counts: 6000 USD at 25,000 VND
+    expect(r.income.map((i) => i.id)).toEqual(["bonus"]);
+    expect(r.incomeVND).toBe(150_000_000);
+    expect(r.expectedTaxVND).toBeCloseTo(5_000_000, 6);
+    expect(r.paidTaxVND).toBe(5_000_000);
+    expect(r.status).toBe("SETTLED");
+  });
+
+  it("rejects brackets out of order or uncapped early", () => {
+    const parse = (brackets: unknown) =>
+      IncomeTaxSettingsSchema.safeParse({ brackets }).success;
+
+    expect(parse([{ upTo: null, ratePercent: 5 }])).toBe(true);
+    expect(
+      parse([
+        { upTo: 10, ratePercent: 5 },
+        { upTo: 5, ratePercent: 10 },
+        { upTo: null, ratePercent: 20 },
+      ]),
+    ).toBe(false);
+    expect(
+      parse([
+        { upTo: null, ratePercent: 5 },
+        { upTo: 10, ratePercent: 10 },
+      ]),
+    ).toBe(false);
+    expect(parse([{ upTo: 10, ratePercent: 5 }])).toBe(false);
+  });
+});
@@ -0,0 +1,81 @@
+import { describe, it, expect } from "vitest";
+import { JobService, JobContext } from "../src/services/job.service";
+
+/**
+ * Background jobs
+ *
+ * - Jobs report a percentage and stage while running and keep the result
+ * - Cancelling stops a job at its next checkpoint
+ */
+
+const settle = async (svc: JobService, id: string) => {
+  for (let i = 0; i < 100; i++) {
+    const job = svc.get(id)!;
+    if (job.status !== "queued" && job.status !== "running") return job;
+    await new Promise((resolve) => setImmediate(resolve));
+  }
+  throw new Error("job did not finish");
+};
+
+describe("Job Service", () => {
+  it("tracks progress and keeps the result", async () => {
+    const svc = new JobService();
+    const seen: number[] = [];
+    const job = svc.start("test", async (ctx: JobContext) => {
+      for (let i = 1; i <= 4; i++) {
+        ctx.progress(i * 25, `step ${i}`);
+        seen.push(svc.get(job.id)!.progress);
+        await ctx.checkpoint();
+      }
+      return { ok: true };
+    });
+    expect(job.status).toBe("queued");
+
+    const done = await settle(svc, job.id);
+
+    expect(seen).toEqual([25, 50, 75, 100]);
+    expect(done).toMatchObject({
+      status: "succeeded",
+      progress: 100,
+      stage: "step 4",
+      result: { ok: true },
+    });
+    expect(svc.list("test").map((j) => j.id)).toEqual([job.id]);
+  });
+
+  it("stops a cancelled job at its next checkpoint", async () => {
+    const svc = new JobService();
+    let steps = 0;
+    const job = svc.start("test", async (ctx: JobContext) => {
+      for (;;) {
+        steps++;
+        if (steps === 3) svc.cancel(job.id);
+        await ctx.checkpoint();
+      }
+    });
+
+    const done = await settle(svc, job.id);
+
+    expect(done.status).toBe("cancelled");
+    expect(done.cancelRequested).toBe(true);
+    expect(steps).toBe(3);
+    // Finished jobs are left as they are
+    expect(svc.cancel(job.id)!.status).toBe("cancelled");
+  });
+
+  it("records failures and skips jobs cancelled while queued", async () => {
+    const svc = new JobService();
+    const failed = svc.start("test", async () => {
+      throw new Error("provider down");
+    });
+    let ran = false;
+    const queued = svc.start("test", async () => {
+      ran = true;
+    });
+    svc.cancel(queued.id);
+
+    expect((await settle(svc, failed.id)).error).toBe("provider down");
+    expect((await settle(svc, queued.id)).status).toBe("cancelled");
+    expect(ran).toBe(false);
+  });
+});
@@ -0,0 +1,97 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Database maintenance
+ *
+ * - Placeholder prices older than a day are pruned
+ * - Hot tables are analyzed; VACUUM only runs once the file is bloated
+ * - A failing step is recorded on the run instead of thrown
+ */
+
+describe("Maintenance Service", () => {
+  let freePages = 0;
+  let pruneBefore: Date | undefined;
+  const vacuum = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    freePages = 0;
+    pruneBefore = undefined;
+    vacuum.mockReset();
+
+    vi.doMock("../src/repositories/maintenance.repository", () => ({
+      maintenanceRepository: {
+        // price_cache doesn't exist in this database
+        analyze: (tables: string[]) =>
+          tables.filter((t) => t !== "price_cache"),
+        databaseSize: () => ({
+          pageSize: 4096,
+          pageCount: 100,
+          freelistCount: freePages,
+          sizeBytes: 4096 * 100,
+        }),
+        vacuum: () => {
+          vacuum();
+          freePages = 0;
+        },
+        tableSizes: () => [],
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        pruneCache: (before: Date) => {
+          pruneBefore = before;
+          return 3;
+        },
+      },
+    }));
+  });
+
+  it("prunes placeholders and analyzes hot tables", async () => {
+    const { maintenanceService } = await import(
+      "../src/services/maintenance.service"
+    );
+    const r = maintenanceService.run(new Date("2025-01-05T03:00:00.000Z"));
+
+    expect(pruneBefore?.toISOString()).toBe("2025-01-04T03:00:00.000Z");
+    expect(r.prunedPriceRows).toBe(3);
+    expect(r.analyzedTables).toEqual([
+      "transactions",
+      "vault_entries",
+      "pending_actions",
+    ]);
+    expect(r.vacuumed).toBe(false);
+    expect(vacuum).not.toHaveBeenCalled();
+    expect(maintenanceService.getLastRun()).toBe(r);
+  });
+
+  it("vacuums once a fifth of the file is free pages", async () => {
+    const { maintenanceService } = await import(
+      "../src/services/maintenance.service"
+    );
+    freePages = 19;
+    expect(maintenanceService.run().vacuumed).toBe(false);
+
+    freePages = 20;
+    const r = maintenanceService.run();
+    expect(r.vacuumed).toBe(true);
+    expect(vacuum).toHaveBeenCalledTimes(1);
+  });
+
+  it("records a failing step on the run", async () => {
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        pruneCache: () => {
+          throw new Error("database is locked");
+        },
+      },
+    }));
+    const { maintenanceService } = await import(
+      "../src/services/maintenance.service"
+    );
+    const r = maintenanceService.run();
+
+    expect(r.error).toBe("database is locked");
+    expect(r.analyzedTables).toEqual([]);
+  });
+});
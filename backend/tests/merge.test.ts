@@ -0,0 +1,130 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Merging duplicate admin entries
+ *
+ * - References move onto the surviving entry and the duplicate is removed
+ * - Merged tags are not repeated on a transaction that had both
+ * - Each merge is logged; unknown or identical ids are rejected
+ */
+
+describe("Merge Service", () => {
+  const settings = new Map<string, string>();
+  const mergeAccount = vi.fn(() => ({ transactions: 3, vault_entries: 1 }));
+  const accounts = [
+    { id: 1, name: "Bank" },
+    { id: 2, name: "Bank Account" },
+  ];
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings.clear();
+    mergeAccount.mockClear();
+
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAccountById: (id: number) => accounts.find((a) => a.id === id),
+      },
+      mergeRepository: { mergeAccount },
+      transactionRepository: { findAll: () => [] },
+      accountBalanceRepository: { replaceAll: vi.fn() },
+      settingsRepository: {
+        getSetting: (k: string) => settings.get(k),
+        setSetting: (k: string, v: string) => settings.set(k, v),
+      },
+    }));
+  });
+
+  it("merges an account and logs it", async () => {
+    const { mergeService } = await import("../src/services/merge.service");
+
+    const record = mergeService.merge("account", 1, 2);
+
+    expect(mergeAccount).toHaveBeenCalledWith(1, "Bank", "Bank Account");
+    expect(record).toMatchObject({
+      kind: "account",
+      from: "Bank",
+      into: "Bank Account",
+      counts: { transactions: 3, vault_entries: 1 },
+    });
+    expect(mergeService.log()).toEqual([record]);
+  });
+
+  it("rejects unknown and identical ids", async () => {
+    const { mergeService } = await import("../src/services/merge.service");
+
+    expect(() => mergeService.merge("account", 1, 1)).toThrow(/itself/);
+    expect(() => mergeService.merge("account", 9, 2)).toThrow(/not found/);
+    expect(mergeAccount).not.toHaveBeenCalled();
+    expect(mergeService.log()).toEqual([]);
+  });
+});
+
+describe("Merge Repository (JSON)", () => {
+  const writeStore = vi.fn();
+  let store: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    writeStore.mockReset();
+    const asset = (symbol: string) => ({ type: "CRYPTO", symbol });
+    store = {
+      transactions: [
+        {
+          id: "t1",
+          asset: asset("XBT"),
+          rate: { asset: asset("XBT") },
+          account: "Bank",
+          tags: ["trip", "travel"],
+        },
+        { id: "t2", asset: asset("ETH"), account: "Cash", tags: ["trip"] },
+      ],
+      vaultEntries: [{ asset: asset("XBT"), account: "Bank" }],
+      loans: [],
+      borrowings: [],
+      registry: [],
+      fixedIncome: [],
+      options: [],
+      vestingGrants: [],
+      addressBook: [{ name: "Ledger", asset: "XBT" }],
+      adminAccounts: [],
+      adminAssets: [{ id: 7, symbol: "XBT" }],
+      adminTags: [{ id: 3, name: "trip" }],
+    };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore,
+    }));
+  });
+
+  it("renames an asset everywhere in a single write", async () => {
+    const { MergeRepositoryJson } = await import(
+      "../src/repositories/merge.repository"
+    );
+
+    const counts = new MergeRepositoryJson().mergeAsset(7, "XBT", "BTC");
+
+    expect(counts).toEqual({
+      transactions: 1,
+      vault_entries: 1,
+      address_book: 1,
+    });
+    expect(store.transactions[0].asset.symbol).toBe("BTC");
+    expect(store.transactions[0].rate.asset.symbol).toBe("BTC");
+    expect(store.adminAssets).toEqual([]);
+    expect(writeStore).toHaveBeenCalledTimes(1);
+  });
+
+  it("merges tags without duplicating them", async () => {
+    const { MergeRepositoryJson } = await import(
+      "../src/repositories/merge.repository"
+    );
+
+    const counts = new MergeRepositoryJson().mergeTag(3, "trip", "travel");
+
+    expect(counts).toEqual({ transactions: 2 });
+    expect(store.transactions[0].tags).toEqual(["travel"]);
+    expect(store.transactions[1].tags).toEqual(["travel"]);
+    expect(store.adminTags).toEqual([]);
+  });
+});
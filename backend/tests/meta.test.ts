@@ -0,0 +1,71 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Client metadata
+ *
+ * - Enums come from the backend's own constants
+ * - Only active accounts, assets and tags are listed
+ * - The ETag changes as soon as an admin edit does
+ */
+
+describe("GET /api/meta", () => {
+  let tags: { id: number; name: string; is_active: boolean }[] = [];
+
+  const buildApp = async () => {
+    const { metaRouter } = await import("../src/handlers/meta.handler");
+    const app = express();
+    app.use("/api", metaRouter);
+    return app;
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    tags = [
+      { id: 1, name: "food", is_active: true },
+      { id: 2, name: "old", is_active: false },
+    ];
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAllAccounts: () => [
+          { id: 1, name: "Spend", type: "bank", is_active: true },
+        ],
+        findAllAssets: () => [
+          { id: 1, symbol: "VND", decimals: 0, kind: "FIAT", is_active: true },
+        ],
+        findAllTags: () => tags,
+      },
+    }));
+  });
+
+  it("lists enums, active lookups and constraints", async () => {
+    const res = await request(await buildApp()).get("/api/meta");
+
+    expect(res.status).toBe(200);
+    expect(res.body.transaction_types).toContain("TRANSFER_IN");
+    expect(res.body.actions).toContain("spot_buy");
+    expect(res.body.vault_statuses).toEqual(["ACTIVE", "CLOSED"]);
+    expect(res.body.accounts).toEqual([{ name: "Spend", type: "bank" }]);
+    expect(res.body.tags).toEqual(["food"]);
+    expect(res.body.constraints.latitude).toEqual({ min: -90, max: 90 });
+  });
+
+  it("answers 304 until the metadata changes", async () => {
+    const app = await buildApp();
+    const first = await request(app).get("/api/meta");
+    const etag = first.headers.etag;
+    expect(etag).toBeTruthy();
+
+    const same = await request(app).get("/api/meta").set("If-None-Match", etag);
+    expect(same.status).toBe(304);
+
+    tags.push({ id: 3, name: "travel", is_active: true });
+    const changed = await request(app)
+      .get("/api/meta")
+      .set("If-None-Match", etag);
+    expect(changed.status).toBe(200);
+    expect(changed.headers.etag).not.toBe(etag);
+    expect(changed.body.tags).toEqual(["food", "travel"]);
+  });
+});
@@ -0,0 +1,50 @@
+import { describe, it, expect } from "vitest";
+import express from "express";
+import request from "supertest";
+import { etagCache } from "../src/core/middleware";
+
+describe("ETag caching for mobile summaries", () => {
+  const buildApp = () => {
+    let total = 12.5;
+    const app = express();
+    app.use(express.json());
+    app.use("/mobile", etagCache());
+    app.get("/mobile/summary", (_req, res) => {
+      res.json({ total_usd: total });
+    });
+    app.post("/mobile/spend", (req, res) => {
+      total += Number(req.body.usd);
+      res.status(201).json({ total_usd: total });
+    });
+    return app;
+  };
+
+  it("should answer If-None-Match with 304 and an empty body", async () => {
+    const app = buildApp();
+    const first = await request(app).get("/mobile/summary");
+    expect(first.status).toBe(200);
+    expect(first.headers.etag).toBeTruthy();
+
+    const second = await request(app)
+      .get("/mobile/summary")
+      .set("If-None-Match", first.headers.etag);
+    expect(second.status).toBe(304);
+    expect(second.text).toBe("");
+  });
+
+  it("should serve fresh data and a new ETag after a write", async () => {
+    const app = buildApp();
+    const before = await request(app).get("/mobile/summary");
+
+    const write = await request(app).post("/mobile/spend").send({ usd: 5 });
+    expect(write.status).toBe(201);
+    expect(write.headers.etag).toBeUndefined();
+
+    const after = await request(app)
+      .get("/mobile/summary")
+      .set("If-None-Match", before.headers.etag);
+    expect(after.status).toBe(200);
+    expect(after.body).toEqual({ total_usd: 17.5 });
+    expect(after.headers.etag).not.toBe(before.headers.etag);
+  });
+});
@@ -0,0 +1,146 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Monthly review
+ *
+ * - Untagged, unreviewed and anomalous transactions of the month are listed
+ * - Reviewing clears a transaction; editing it afterwards brings it back
+ * - A month is signed off only once its list is empty
+ */
+
+type MonthReview = import("../src/types").MonthReview;
+
+describe("Month Review Service", () => {
+  const vnd = { type: "FIAT", symbol: "VND" };
+  let transactions: any[] = [];
+  let reviews: MonthReview[] = [];
+  const now = new Date("2025-04-02T00:00:00.000Z");
+
+  const tx = (
+    id: string,
+    createdAt: string,
+    usdAmount: number,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type: "EXPENSE",
+    asset: vnd,
+    amount: usdAmount * 25000,
+    usdAmount,
+    account: "Spend",
+    category: "Food",
+    createdAt,
+    ...extra,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    reviews = [];
+    transactions = [
+      // February history for the unusual-amount check
+      tx("h1", "2025-02-03T00:00:00.000Z", 10),
+      tx("h2", "2025-02-10T00:00:00.000Z", 12),
+      tx("h3", "2025-02-17T00:00:00.000Z", 11),
+      tx("m1", "2025-03-02T00:00:00.000Z", 11),
+      tx("m2", "2025-03-05T00:00:00.000Z", 60),
+      tx("m3", "2025-03-09T00:00:00.000Z", 5, { category: undefined }),
+      {
+        ...tx("m4", "2025-03-12T00:00:00.000Z", 100, { category: undefined }),
+        type: "TRANSFER_OUT",
+        transferId: "x1",
+      },
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => transactions,
+        findById: (id: string) => transactions.find((t) => t.id === id),
+        update: (id: string, updates: any) => {
+          const i = transactions.findIndex((t) => t.id === id);
+          transactions[i] = { ...transactions[i], ...updates };
+          return transactions[i];
+        },
+      },
+      monthReviewRepository: {
+        findAll: () => reviews,
+        findByMonth: (m: string) => reviews.find((r) => r.month === m),
+        save: (r: MonthReview) => {
+          reviews = [...reviews.filter((x) => x.month !== r.month), r];
+          return r;
+        },
+      },
+      settingsRepository: {},
+      reportSubscriptionRepository: {},
+    }));
+  });
+
+  it("lists the month's open items with their reasons", async () => {
+    const { monthReviewService } = await import(
+      "../src/services/month-review.service"
+    );
+    const list = monthReviewService.worklist("2025-03", now);
+
+    expect(list.status).toBe("open");
+    expect(list.transactionCount).toBe(4);
+    expect(list.items.map((i) => [i.transaction.id, i.reasons])).toEqual([
+      ["m1", ["unreviewed"]],
+      ["m2", ["unreviewed", "anomalous"]],
+      ["m3", ["untagged", "unreviewed"]],
+      ["m4", ["unreviewed"]],
+    ]);
+    expect(list.items[1].anomalies).toEqual(["5.5x the usual Food amount"]);
+    expect(list.counts).toEqual({ untagged: 1, unreviewed: 4, anomalous: 1 });
+  });
+
+  it("signs the month off once everything is reviewed and tagged", async () => {
+    const { monthReviewService } = await import(
+      "../src/services/month-review.service"
+    );
+    expect(() => monthReviewService.complete("2025-03", { now })).toThrow(
+      /4 open items/,
+    );
+
+    let list = monthReviewService.markReviewed(
+      "2025-03",
+      ["m1", "m2", "m3", "m4"],
+      now,
+    );
+    expect(list.items.map((i) => [i.transaction.id, i.reasons])).toEqual([
+      ["m3", ["untagged"]],
+    ]);
+
+    transactions = transactions.map((t) =>
+      t.id === "m3" ? { ...t, category: "Coffee" } : t,
+    );
+    const review = monthReviewService.complete("2025-03", {
+      note: "All good",
+      now,
+    });
+    expect(review).toEqual({
+      month: "2025-03",
+      reviewedAt: now.toISOString(),
+      transactionCount: 4,
+      note: "All good",
+    });
+    list = monthReviewService.worklist("2025-03", now);
+    expect(list.status).toBe("reviewed");
+
+    // An edit after the sign-off reopens the month
+    transactions = transactions.map((t) =>
+      t.id === "m1" ? { ...t, updatedAt: "2025-04-05T00:00:00.000Z" } : t,
+    );
+    list = monthReviewService.worklist("2025-03", now);
+    expect(list.status).toBe("reopened");
+    expect(list.items.map((i) => i.transaction.id)).toEqual(["m1"]);
+  });
+
+  it("only marks transactions of the chosen month", async () => {
+    const { monthReviewService } = await import(
+      "../src/services/month-review.service"
+    );
+    expect(() =>
+      monthReviewService.markReviewed("2025-03", ["m1", "h1", "nope"], now),
+    ).toThrow(/not transactions of 2025-03: h1, nope/);
+    expect(transactions.find((t) => t.id === "m1").reviewedAt).toBeUndefined();
+  });
+});
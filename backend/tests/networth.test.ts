@@ -0,0 +1,127 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Net worth series
+ *
+ * - Balances are rebuilt from the ledger; negative balances (credit
+ *   cards) and outstanding borrowings are liabilities, loans receivables
+ * - Each point uses the latest cached rate at or before it
+ */
+
+const USD = { type: "FIAT", symbol: "USD" };
+const BTC = { type: "CRYPTO", symbol: "BTC" };
+const XYZ = { type: "CRYPTO", symbol: "XYZ" };
+
+const tx = (
+  type: string,
+  createdAt: string,
+  asset: any,
+  amount: number,
+  account: string,
+  extra: any = {},
+) =>
+  ({
+    id: `${type}-${createdAt}-${account}`,
+    type,
+    createdAt,
+    asset,
+    amount,
+    account,
+    rate: { asset, rateUSD: asset === USD ? 1 : 0, missing: asset !== USD },
+    ...extra,
+  }) as any;
+
+describe("Net Worth Service", () => {
+  beforeEach(() => {
+    vi.resetModules();
+    const ledger = [
+      tx("INITIAL", "2025-01-01T08:00:00Z", USD, 1000, "Bank"),
+      tx("INITIAL", "2025-01-01T08:00:00Z", BTC, 1, "Exchange"),
+      tx("INITIAL", "2025-01-01T09:00:00Z", XYZ, 10, "Exchange"),
+      tx("BORROW", "2025-01-02T08:00:00Z", USD, 500, "Bank"),
+      tx("EXPENSE", "2025-01-02T09:00:00Z", USD, 200, "Card"),
+      tx("LOAN", "2025-01-03T08:00:00Z", USD, 100, "Bank"),
+      tx("REPAY", "2025-01-03T09:00:00Z", USD, 300, "Bank", {
+        direction: "BORROW",
+      }),
+    ];
+    const rates: Record<string, any[]> = {
+      BTC: [
+        { rateUSD: 40000, timestamp: "2025-01-01T00:00:00Z", source: "X" },
+        { rateUSD: 1, timestamp: "2025-01-02T00:00:00Z", source: "FIXED" },
+        { rateUSD: 50000, timestamp: "2025-01-03T00:00:00Z", source: "X" },
+      ],
+      VND: [
+        { rateUSD: 0.00004, timestamp: "2025-01-01T00:00:00Z", source: "X" },
+      ],
+    };
+    vi.doMock("../src/repositories", () => ({
+      reportingRepository: {
+        findLedgerUntil: (end: string) =>
+          ledger.filter((t) => t.createdAt <= end),
+      },
+    }));
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: {
+        getRatesInRange: (asset: any) => rates[asset.symbol] || [],
+      },
+    }));
+  });
+
+  it("values assets and liabilities at the end of each day", async () => {
+    const { netWorthService } = await import(
+      "../src/services/networth.service"
+    );
+
+    const series = netWorthService.series({
+      start: "2025-01-01",
+      end: "2025-01-03",
+    });
+
+    expect(series.points.map((p) => p.date)).toEqual([
+      "2025-01-01",
+      "2025-01-02",
+      "2025-01-03",
+    ]);
+    const [d1, d2, d3] = series.points;
+    expect(d1).toMatchObject({ assets_usd: 41000, liabilities_usd: 0 });
+    expect(d1.net_worth_vnd).toBeCloseTo(41000 / 0.00004);
+    // the FIXED placeholder is ignored; BTC stays at 40000
+    expect(d2).toMatchObject({
+      assets_usd: 41500,
+      borrowings_usd: 500,
+      credit_usd: 200,
+      net_worth_usd: 40800,
+    });
+    expect(d3).toMatchObject({
+      assets_usd: 51200,
+      receivables_usd: 100,
+      borrowings_usd: 200,
+      credit_usd: 200,
+      net_worth_usd: 50800,
+    });
+    expect(series.unpriced).toEqual(["XYZ"]);
+  });
+
+  it("returns month ends plus the end date for interval=month", async () => {
+    const { netWorthService } = await import(
+      "../src/services/networth.service"
+    );
+
+    const series = netWorthService.series({
+      end: "2025-03-10",
+      interval: "month",
+    });
+
+    expect(series.start).toBe("2025-01-01");
+    expect(series.points.map((p) => p.date)).toEqual([
+      "2025-01-31",
+      "2025-02-28",
+      "2025-03-10",
+    ]);
+    expect(series.points[2].net_worth_usd).toBe(50800);
+    expect(() => netWorthService.series({ interval: "week" })).toThrow(
+      /interval/,
+    );
+  });
+});
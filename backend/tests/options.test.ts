@@ -0,0 +1,123 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Options positions
+ *
+ * - Opening books premium and contracts as one linked group
+ * - Worthless expiry realizes the premium
+ * - Exercise exchanges the underlying at the strike and realizes intrinsic
+ */
+
+type OptionPosition = import("../src/types").OptionPosition;
+type Transaction = import("../src/types").Transaction;
+
+describe("Option Service", () => {
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  let positions: OptionPosition[] = [];
+  let txs: Transaction[] = [];
+
+  const open = {
+    underlying: BTC,
+    optionType: "CALL" as const,
+    side: "LONG" as const,
+    contracts: 2,
+    multiplier: 1,
+    strike: 60000,
+    expiry: "2025-06-27T08:00:00.000Z",
+    premium: 500,
+    premiumAsset: USD,
+    account: "Deribit",
+    at: "2025-06-01T00:00:00.000Z",
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    positions = [];
+    txs = [];
+
+    vi.doMock("../src/repositories", () => ({
+      optionRepository: {
+        findAll: () => positions,
+        findById: (id: string) => positions.find((p) => p.id === id),
+        findByStatus: (status: string) =>
+          positions.filter((p) => p.status === status),
+        create: (p: OptionPosition) => {
+          positions.push(p);
+          return p;
+        },
+        update: (id: string, updates: Partial<OptionPosition>) => {
+          const p = positions.find((x) => x.id === id);
+          if (p) Object.assign(p, updates);
+          return p;
+        },
+      },
+      transactionRepository: {
+        create: (t: Transaction) => {
+          txs.push(t);
+          return t;
+        },
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: any) => ({
+          asset,
+          rateUSD: asset.symbol === "BTC" ? 63000 : 1,
+          source: asset.symbol === "BTC" ? "COINGECKO" : "FIXED",
+        }),
+      },
+    }));
+  });
+
+  it("links premium and contracts when opening", async () => {
+    const { optionService } = await import("../src/services/option.service");
+    const { position, transactions } = await optionService.openPosition(open);
+
+    expect(position.premiumUSD).toBe(1000);
+    expect(transactions.map((t) => t.type)).toEqual([
+      "TRANSFER_OUT",
+      "TRANSFER_IN",
+    ]);
+    expect(transactions.every((t) => t.transferId === position.id)).toBe(true);
+    expect(transactions[1].asset.symbol).toBe("BTC-20250627-60000-C");
+  });
+
+  it("realizes the premium on worthless expiry", async () => {
+    const { optionService } = await import("../src/services/option.service");
+    const { position } = await optionService.openPosition({
+      ...open,
+      side: "SHORT",
+    });
+
+    const expired = optionService.expire(position.id);
+    expect(expired.status).toBe("EXPIRED");
+    expect(expired.realizedPnLUSD).toBe(1000);
+    expect(() => optionService.expire(position.id)).toThrow(/EXPIRED/);
+  });
+
+  it("exercises in the money at the settlement price", async () => {
+    const { optionService } = await import("../src/services/option.service");
+    const { position } = await optionService.openPosition(open);
+
+    const settled = await optionService.processExpired(
+      new Date("2025-06-28T00:00:00Z"),
+    );
+    expect(settled).toHaveLength(1);
+    expect(settled[0].status).toBe("EXERCISED");
+    // (63000 - 60000) * 2 - 1000 premium
+    expect(settled[0].realizedPnLUSD).toBe(5000);
+
+    const legs = txs.slice(2);
+    expect(legs.map((t) => [t.type, t.asset.symbol, t.amount])).toEqual([
+      ["TRANSFER_OUT", "BTC-20250627-60000-C", 2],
+      ["TRANSFER_OUT", "USD", 120000],
+      ["TRANSFER_IN", "BTC", 2],
+    ]);
+    expect(legs.every((t) => t.transferId === position.id)).toBe(true);
+    expect(optionService.realizedPnL()).toMatchObject({
+      realizedUSD: 5000,
+      openPositions: 0,
+    });
+  });
+});
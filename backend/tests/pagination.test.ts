@@ -0,0 +1,100 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Keyset pagination
+ *
+ * - Transactions page newest first, breaking ties on id
+ * - Vault entries page newest first, breaking ties on insertion order
+ * - The last page has no cursor, and a malformed cursor is rejected
+ */
+
+describe("Keyset pagination (JSON repositories)", () => {
+  const usd = { type: "FIAT", symbol: "USD" };
+  let store: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    const tx = (id: string, createdAt: string) => ({
+      id,
+      type: "EXPENSE",
+      asset: usd,
+      amount: 1,
+      createdAt,
+    });
+    const entry = (vault: string, at: string, amount: number) => ({
+      vault,
+      type: "DEPOSIT",
+      asset: usd,
+      amount,
+      usdValue: amount,
+      at,
+    });
+    store = {
+      transactions: [
+        tx("a", "2025-01-01T00:00:00.000Z"),
+        tx("c", "2025-01-03T00:00:00.000Z"),
+        tx("b", "2025-01-03T00:00:00.000Z"),
+        tx("d", "2025-01-02T00:00:00.000Z"),
+      ],
+      vaultEntries: [
+        entry("Cold", "2025-01-01T00:00:00.000Z", 1),
+        entry("Hot", "2025-01-02T00:00:00.000Z", 2),
+        entry("Cold", "2025-01-05T00:00:00.000Z", 3),
+        entry("Cold", "2025-01-05T00:00:00.000Z", 4),
+      ],
+    };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => ({
+        ...store,
+        transactions: [...store.transactions],
+      }),
+      writeStore: vi.fn(),
+    }));
+  });
+
+  it("pages transactions newest first", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const repo = new TransactionRepositoryJson();
+
+    const first = repo.findPage({ limit: 3 });
+    expect(first.items.map((t) => t.id)).toEqual(["c", "b", "d"]);
+    const second = repo.findPage({ limit: 3, cursor: first.nextCursor });
+    expect(second.items.map((t) => t.id)).toEqual(["a"]);
+    expect(second.nextCursor).toBeUndefined();
+    expect(repo.count()).toBe(4);
+
+    // Rows created after the first page was read do not shift the second
+    store.transactions.push({
+      ...store.transactions[0],
+      id: "e",
+      createdAt: "2025-01-09T00:00:00.000Z",
+    });
+    expect(
+      repo
+        .findPage({ limit: 3, cursor: first.nextCursor })
+        .items.map((t) => t.id),
+    ).toEqual(["a"]);
+
+    expect(() => repo.findPage({ limit: 3, cursor: "bogus" })).toThrow(
+      /cursor is invalid/,
+    );
+  });
+
+  it("pages one vault's entries newest first", async () => {
+    const { VaultRepositoryJson } = await import(
+      "../src/repositories/vault.repository"
+    );
+    const repo = new VaultRepositoryJson();
+
+    const first = repo.findEntriesPage("Cold", { limit: 2 });
+    expect(first.items.map((e) => e.amount)).toEqual([4, 3]);
+    const second = repo.findEntriesPage("Cold", {
+      limit: 2,
+      cursor: first.nextCursor,
+    });
+    expect(second.items.map((e) => e.amount)).toEqual([1]);
+    expect(second.nextCursor).toBeUndefined();
+  });
+});
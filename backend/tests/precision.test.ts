@@ -0,0 +1,66 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Per-asset quantity precision
+ *
+ * - Registered decimals win over the defaults
+ * - Input finer than the step is rejected
+ * - Derived quantities round to the step
+ */
+
+describe("Precision Service", () => {
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAllAssets: () => [
+          { id: 1, symbol: "BTC", decimals: 8 },
+          { id: 2, symbol: "VNM", decimals: 4 },
+        ],
+      },
+    }));
+  });
+
+  it("uses the registry, then defaults by asset type", async () => {
+    const { precisionService } = await import(
+      "../src/services/precision.service"
+    );
+    const fiat = (symbol: string) => ({ type: "FIAT" as const, symbol });
+    const crypto = (symbol: string) => ({ type: "CRYPTO" as const, symbol });
+
+    expect(precisionService.getDecimals(crypto("vnm"))).toBe(4);
+    expect(precisionService.getDecimals(fiat("VND"))).toBe(0);
+    expect(precisionService.getDecimals(fiat("EUR"))).toBe(2);
+    expect(precisionService.getDecimals(crypto("SOL"))).toBe(8);
+  });
+
+  it("rejects impossible quantities", async () => {
+    const { precisionService } = await import(
+      "../src/services/precision.service"
+    );
+    const VND = { type: "FIAT" as const, symbol: "VND" };
+    const VNM = { type: "CRYPTO" as const, symbol: "VNM" };
+    const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+
+    expect(() => precisionService.validate(VND, 0.123)).toThrow(
+      "amount 0.123 VND has more than 0 decimal places",
+    );
+    expect(() => precisionService.validate(VNM, 1.23456789012)).toThrow();
+    expect(() => precisionService.validate(VND, 25_000_000)).not.toThrow();
+    expect(() => precisionService.validate(BTC, 0.1)).not.toThrow();
+    expect(() =>
+      precisionService.validate(BTC, 20_999_999.12345678),
+    ).not.toThrow();
+  });
+
+  it("rounds derived quantities to the step", async () => {
+    const { precisionService } = await import(
+      "../src/services/precision.service"
+    );
+    const VND = { type: "FIAT" as const, symbol: "VND" };
+    const USD = { type: "FIAT" as const, symbol: "USD" };
+
+    expect(precisionService.round(VND, 2_330_684.93)).toBe(2_330_685);
+    expect(precisionService.round(USD, 0.1 + 0.2)).toBe(0.3);
+  });
+});
@@ -0,0 +1,73 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import {
+  parsePriceOverrides,
+  priceOverride,
+  withPriceOverrides,
+} from "../src/utils/price-override.util";
+
+/**
+ * Report price overrides (?prices=BTC:50000)
+ *
+ * - Overrides apply to every rate lookup made while handling the request,
+ *   across awaits, and are never cached
+ * - Outside the request, lookups see market rates again
+ */
+
+describe("Price overrides", () => {
+  const mockGet = vi.fn();
+  const mockSave = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    mockGet.mockReset();
+    mockSave.mockReset();
+
+    vi.doMock("axios", () => ({ default: { get: mockGet } }));
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: {
+        getByCacheKey: () => ({
+          asset: { type: "CRYPTO", symbol: "BTC" },
+          rateUSD: 90000,
+          timestamp: "2025-01-01T00:00:00.000Z",
+          source: "COINGECKO",
+        }),
+        save: mockSave,
+      },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: { findAllAssets: () => [] },
+      settingsRepository: {
+        getPriceSourcePriority: () => ({}),
+        getManualPrices: () => [],
+      },
+    }));
+  });
+
+  it("parses symbol:price pairs and rejects bad ones", () => {
+    expect(Array.from(parsePriceOverrides("btc:50000, ETH=2500"))).toEqual([
+      ["BTC", 50000],
+      ["ETH", 2500],
+    ]);
+    expect(() => parsePriceOverrides("BTC")).toThrow(/BTC/);
+    expect(() => parsePriceOverrides("BTC:-1")).toThrow(/BTC:-1/);
+  });
+
+  it("replaces cached rates only inside the request", async () => {
+    const { priceService } = await import("../src/services/price.service");
+    const btc = { type: "CRYPTO" as const, symbol: "BTC" };
+
+    const inside = await withPriceOverrides(
+      parsePriceOverrides("BTC:50000"),
+      async () => {
+        await new Promise((r) => setTimeout(r, 0));
+        return priceService.getRateUSD(btc, "2025-01-01T00:00:00Z");
+      },
+    );
+    expect(inside).toMatchObject({ rateUSD: 50000, override: true });
+    expect(mockSave).not.toHaveBeenCalled();
+
+    const outside = await priceService.getRateUSD(btc, "2025-01-01T00:00:00Z");
+    expect(outside.rateUSD).toBe(90000);
+    expect(priceOverride("BTC")).toBeUndefined();
+  });
+});
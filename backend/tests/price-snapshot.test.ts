@@ -0,0 +1,132 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * End-of-day price snapshots
+ *
+ * - Held assets, borrowed assets and VND are snapshotted once per day
+ * - The rate is stored as the closed day's rate, replacing the cached one
+ * - Live rates stand for the close only shortly after it
+ */
+
+describe("Price snapshots", () => {
+  const btc = { type: "CRYPTO" as const, symbol: "BTC" };
+  const usd = { type: "FIAT" as const, symbol: "USD" };
+  const eur = { type: "FIAT" as const, symbol: "EUR" };
+
+  describe("scheduler", () => {
+    const snapshotRate = vi.fn();
+    let lastDay: string | undefined;
+
+    beforeEach(() => {
+      vi.resetModules();
+      lastDay = undefined;
+      snapshotRate.mockReset().mockImplementation(async (asset: any) =>
+        asset.symbol === "EUR"
+          ? null
+          : { asset, rateUSD: 2, timestamp: "", source: "COINGECKO" },
+      );
+
+      vi.doMock("../src/services/price.service", () => ({
+        priceService: { snapshotRate },
+      }));
+      vi.doMock("../src/services/vault.service", () => ({
+        vaultService: { heldAssets: () => [btc, usd] },
+      }));
+      vi.doMock("../src/repositories", () => ({
+        borrowingRepository: { findByStatus: () => [{ asset: eur }] },
+        settingsRepository: {
+          getLastPriceSnapshotDay: () => lastDay,
+          setLastPriceSnapshotDay: (d: string) => (lastDay = d),
+        },
+      }));
+    });
+
+    it("snapshots the last closed day once", async () => {
+      const { priceSnapshotService } = await import(
+        "../src/services/price-snapshot.service"
+      );
+      const now = new Date("2025-03-11T01:00:00.000Z");
+      const r = (await priceSnapshotService.processDue(now))!;
+
+      expect(r.day).toBe("2025-03-10");
+      expect(snapshotRate.mock.calls.map((c) => c[0].symbol)).toEqual([
+        "BTC",
+        "EUR",
+        "VND",
+      ]);
+      expect(snapshotRate.mock.calls[0][1].toISOString()).toBe(
+        "2025-03-10T00:00:00.000Z",
+      );
+      expect(r.failed.map((a) => a.symbol)).toEqual(["EUR"]);
+      expect(lastDay).toBe("2025-03-10");
+
+      expect(await priceSnapshotService.processDue(now)).toBeUndefined();
+      expect(snapshotRate).toHaveBeenCalledTimes(3);
+    });
+  });
+
+  describe("snapshotRate", () => {
+    const mockGet = vi.fn();
+    const mockSave = vi.fn();
+
+    beforeEach(() => {
+      vi.resetModules();
+      vi.useFakeTimers();
+      mockGet.mockReset().mockResolvedValue({ data: { rates: { USD: 1.08 } } });
+      mockSave.mockReset();
+
+      vi.doMock("axios", () => ({ default: { get: mockGet } }));
+      vi.doMock("../src/repositories/price-cache.repository", () => ({
+        priceCacheRepository: {
+          getByCacheKey: () => null,
+          save: mockSave,
+          getLatestRate: () => null,
+        },
+      }));
+      vi.doMock("../src/repositories", () => ({
+        settingsRepository: {
+          getPriceSourcePriority: () => ({}),
+          getManualPrices: () => [],
+          getPriceDiscrepancyThresholdPercent: () => 2,
+        },
+      }));
+    });
+
+    afterEach(() => {
+      vi.useRealTimers();
+    });
+
+    const snapshot = async (nowISO: string) => {
+      const { priceService } = await import("../src/services/price.service");
+      const pending = priceService.snapshotRate(
+        eur,
+        new Date("2025-03-10T00:00:00.000Z"),
+        new Date(nowISO),
+      );
+      await vi.runAllTimersAsync();
+      return pending;
+    };
+
+    it("stores the live rate as the day's close", async () => {
+      const rate = await snapshot("2025-03-11T01:00:00.000Z");
+
+      expect(rate).toMatchObject({
+        rateUSD: 1.08,
+        timestamp: "2025-03-10T00:00:00.000Z",
+      });
+      expect(mockGet.mock.calls[0][0]).toContain("latest");
+      expect(mockSave).toHaveBeenCalledWith(
+        expect.objectContaining({ rateUSD: 1.08 }),
+        "FIAT:EUR:2025-03-10T00:00:00.000Z",
+      );
+    });
+
+    it("asks for the historical rate once the day is long closed", async () => {
+      await snapshot("2025-03-12T09:00:00.000Z");
+
+      expect(mockGet.mock.calls[0][0]).toContain(
+        "api.frankfurter.app/2025-03-10?",
+      );
+    });
+  });
+});
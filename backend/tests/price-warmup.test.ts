@@ -0,0 +1,145 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Price cache warm-up
+ *
+ * - Held assets are those with units left in an active vault
+ * - Start-up warms today's rate for each of them and resolves once done,
+ *   then repeats daily with whatever is held by then
+ * - A failed pass is logged and retried the next day; nothing is fetched
+ *   when external rates are off
+ */
+
+type Asset = import("../src/types").Asset;
+type Vault = import("../src/types").Vault;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Price cache warm-up", () => {
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  const SOL = { type: "CRYPTO" as const, symbol: "SOL" };
+  const DAY_MS = 24 * 60 * 60 * 1000;
+  let vaults: Vault[] = [];
+  let entries: VaultEntry[] = [];
+
+  const entry = (
+    vault: string,
+    type: VaultEntry["type"],
+    asset: Asset,
+    amount: number,
+  ): VaultEntry => ({
+    vault,
+    type,
+    asset,
+    amount,
+    usdValue: 100,
+    at: "2025-01-01T00:00:00.000Z",
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date("2025-03-01T06:00:00.000Z"));
+    vaults = [
+      { name: "Crypto", status: "ACTIVE", createdAt: "2025-01-01" },
+      { name: "Old", status: "CLOSED", createdAt: "2025-01-01" },
+    ];
+    entries = [
+      entry("Crypto", "DEPOSIT", BTC, 0.5),
+      entry("Crypto", "WITHDRAW", BTC, 0.2),
+      entry("Crypto", "DEPOSIT", ETH, 2),
+      entry("Crypto", "WITHDRAW", ETH, 2),
+      entry("Crypto", "VALUATION", SOL, 1),
+      entry("Old", "DEPOSIT", SOL, 10),
+    ];
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: { getByCacheKey: () => null, save: vi.fn() },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAll: () => vaults,
+        findAllEntries: (name: string) =>
+          entries.filter((e) => e.vault === name),
+      },
+      adminRepository: { findAllAssets: () => [] },
+      settingsRepository: {},
+      transactionRepository: {},
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+    vi.restoreAllMocks();
+  });
+
+  const setup = async () => {
+    const { priceService } = await import("../src/services/price.service");
+    const { vaultService } = await import("../src/services/vault.service");
+    const { config } = await import("../src/core/config");
+    const getRateUSD = vi
+      .spyOn(priceService, "getRateUSD")
+      .mockImplementation(async (asset) => ({
+        asset,
+        rateUSD: 1,
+        timestamp: new Date().toISOString(),
+        source: "COINGECKO",
+      }));
+    vi.spyOn(priceService, "getCachedRate").mockReturnValue(null);
+    const noExternalRates = vi
+      .spyOn(config, "noExternalRates", "get")
+      .mockReturnValue(false);
+    const fetched = () => getRateUSD.mock.calls.map(([a]) => a.symbol);
+    return { priceService, vaultService, noExternalRates, getRateUSD, fetched };
+  };
+
+  it("lists assets still held in active vaults", async () => {
+    const { vaultService } = await setup();
+    expect(vaultService.heldAssets()).toEqual([BTC]);
+
+    entries.push(entry("Crypto", "DEPOSIT", ETH, 0.1));
+    vaults[1].status = "ACTIVE";
+    expect(vaultService.heldAssets().map((a) => a.symbol)).toEqual([
+      "BTC",
+      "ETH",
+      "SOL",
+    ]);
+  });
+
+  it("warms held assets at start-up and again every day", async () => {
+    const { priceService, vaultService, getRateUSD, fetched } = await setup();
+
+    await priceService.startCacheWarmup(() => vaultService.heldAssets());
+    expect(fetched()).toEqual(["BTC"]);
+    // Today's rate, not a historical one
+    expect(getRateUSD.mock.calls[0][1]).toBeUndefined();
+
+    // A second start does not schedule another round
+    await priceService.startCacheWarmup(() => [SOL]);
+    expect(fetched()).toEqual(["BTC"]);
+
+    entries.push(entry("Crypto", "DEPOSIT", ETH, 1));
+    await vi.advanceTimersByTimeAsync(DAY_MS);
+    expect(fetched()).toEqual(["BTC", "BTC", "ETH"]);
+  });
+
+  it("survives a failed pass and skips external rates when off", async () => {
+    const { priceService, noExternalRates, getRateUSD } = await setup();
+    let fail = true;
+    const held = () => {
+      if (fail) throw new Error("vaults unavailable");
+      return [BTC];
+    };
+
+    await expect(priceService.startCacheWarmup(held)).resolves.toBeUndefined();
+    expect(getRateUSD).not.toHaveBeenCalled();
+
+    fail = false;
+    await vi.advanceTimersByTimeAsync(DAY_MS);
+    expect(getRateUSD).toHaveBeenCalledTimes(1);
+
+    noExternalRates.mockReturnValue(true);
+    await vi.advanceTimersByTimeAsync(DAY_MS);
+    await priceService.warmCache([BTC, ETH]);
+    expect(getRateUSD).toHaveBeenCalledTimes(1);
+  });
+});
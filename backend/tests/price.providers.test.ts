@@ -0,0 +1,105 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Price providers by asset class
+ *
+ * - Stocks and ETFs are priced by Yahoo Finance, not CoinGecko
+ * - Quotes in another currency are converted to USD
+ * - A manual price wins over providers and is never cached
+ */
+
+describe("Asset price providers", () => {
+  const SPY = { type: "CRYPTO" as const, symbol: "SPY" };
+  const mockGet = vi.fn();
+  const mockSave = vi.fn();
+  let manual: any[] = [];
+
+  const chart = (currency: string, closes: number[], timestamps: number[]) => ({
+    data: {
+      chart: {
+        result: [
+          {
+            meta: { currency, regularMarketPrice: closes[closes.length - 1] },
+            timestamp: timestamps,
+            indicators: { quote: [{ close: closes }] },
+          },
+        ],
+      },
+    },
+  });
+
+  const rateOf = async (asset: any, atISO?: string) => {
+    const { priceService } = await import("../src/services/price.service");
+    const pending = priceService.getRateUSD(asset, atISO);
+    await vi.runAllTimersAsync();
+    return pending;
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    mockGet.mockReset();
+    mockSave.mockReset();
+    manual = [];
+
+    vi.doMock("axios", () => ({ default: { get: mockGet } }));
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: {
+        getByCacheKey: () => null,
+        save: mockSave,
+        getLatestRate: () => null,
+      },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAllAssets: () => [{ id: 1, symbol: "E1VFVN30", kind: "EQUITY" }],
+      },
+      settingsRepository: {
+        getPriceSourcePriority: () => ({}),
+        getManualPrices: () => manual,
+        getPriceDiscrepancyThresholdPercent: () => 2,
+      },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it("prices an ETF from Yahoo Finance", async () => {
+    mockGet.mockResolvedValue(chart("USD", [560.1], [1735689600]));
+    const rate = await rateOf(SPY);
+
+    expect(rate).toMatchObject({ source: "YAHOO_FINANCE", rateUSD: 560.1 });
+    expect(mockGet.mock.calls[0][0]).toContain("/v8/finance/chart/SPY?");
+    expect(mockGet.mock.calls[0][0]).not.toContain("coingecko");
+  });
+
+  it("takes the last close on or before the day and converts it", async () => {
+    // 2025-01-02 and 2025-01-03 closes in VND; the day asked is 01-04
+    mockGet.mockImplementation(async (url: string) =>
+      url.includes("exchangerate-api")
+        ? { data: { conversion_rates: { VND: 25000 } } }
+        : chart("VND", [25000, 26000], [1735808400, 1735894800]),
+    );
+    const rate = await rateOf(
+      { type: "CRYPTO", symbol: "E1VFVN30" },
+      "2025-01-04T12:00:00.000Z",
+    );
+
+    expect(rate.source).toBe("YAHOO_FINANCE");
+    expect(rate.rateUSD).toBeCloseTo(26000 * 0.00004, 9);
+  });
+
+  it("prefers a manual price in effect and does not cache it", async () => {
+    manual = [
+      { symbol: "SPY", priceUSD: 500, at: "2025-01-01T00:00:00.000Z" },
+      { symbol: "SPY", priceUSD: 520, at: "2025-02-01T00:00:00.000Z" },
+    ];
+    const rate = await rateOf(SPY, "2025-01-15T00:00:00.000Z");
+
+    expect(rate).toMatchObject({ source: "MANUAL", rateUSD: 500 });
+    expect(mockGet).not.toHaveBeenCalled();
+    expect(mockSave).not.toHaveBeenCalled();
+  });
+});
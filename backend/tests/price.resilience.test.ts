@@ -0,0 +1,124 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Price provider resilience
+ *
+ * - Retries retryable provider errors, then serves the last cached rate
+ *   flagged as stale (and does not persist it)
+ * - Opens the circuit after repeated failures and stops calling the provider,
+ *   lookups already queued for it included
+ * - Each host has its own queue, so a host that is down does not hold up
+ *   lookups from another
+ */
+
+describe("Price provider resilience", () => {
+  const mockGet = vi.fn();
+  const mockSave = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    mockGet.mockReset();
+    mockSave.mockReset();
+
+    vi.doMock("axios", () => ({ default: { get: mockGet } }));
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: {
+        getByCacheKey: () => null,
+        save: mockSave,
+        getLatestRate: () => ({
+          asset: { type: "CRYPTO", symbol: "BTC" },
+          rateUSD: 50000,
+          timestamp: "2025-01-01T00:00:00.000Z",
+          source: "COINGECKO",
+        }),
+      },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: { findAllAssets: () => [] },
+      settingsRepository: {
+        getPriceSourcePriority: () => ({}),
+        getManualPrices: () => [],
+        getPriceDiscrepancyThresholdPercent: () => 2,
+      },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it("should serve the last cached rate as stale when rate limited", async () => {
+    mockGet.mockRejectedValue({ response: { status: 429, headers: {} } });
+    const { priceService } = await import("../src/services/price.service");
+
+    const pending = priceService.getRateUSD({ type: "CRYPTO", symbol: "BTC" });
+    await vi.runAllTimersAsync();
+    const rate = await pending;
+
+    expect(mockGet).toHaveBeenCalledTimes(4);
+    expect(rate.rateUSD).toBe(50000);
+    expect(rate.stale).toBe(true);
+    expect(mockSave).not.toHaveBeenCalled();
+  });
+
+  it("should stop calling a provider once its circuit is open", async () => {
+    mockGet.mockRejectedValue({ response: { status: 503, headers: {} } });
+    const { priceService } = await import("../src/services/price.service");
+
+    for (let i = 0; i < 6; i++) {
+      const pending = priceService.getRateUSD({
+        type: "CRYPTO",
+        symbol: "ETH",
+      });
+      await vi.runAllTimersAsync();
+      await pending;
+    }
+
+    // 5 failed lookups x 4 attempts; the 6th is short-circuited
+    expect(mockGet).toHaveBeenCalledTimes(20);
+  });
+
+  it("should fail lookups queued before the circuit opened fast", async () => {
+    mockGet.mockRejectedValue({ response: { status: 503, headers: {} } });
+    const { priceService } = await import("../src/services/price.service");
+
+    const symbols = ["BTC", "ETH", "SOL", "ADA", "DOT", "XRP", "DOGE"];
+    const pending = Promise.all(
+      symbols.map((symbol) =>
+        priceService.getRateUSD({ type: "CRYPTO", symbol }),
+      ),
+    );
+    await vi.runAllTimersAsync();
+    await pending;
+
+    // The circuit opens after the 5th; the last two never reach the host
+    expect(mockGet).toHaveBeenCalledTimes(20);
+  });
+
+  it("should not hold up another host while one is down", async () => {
+    mockGet.mockImplementation(async (url: string) => {
+      if (url.includes("coingecko")) {
+        throw { response: { status: 503, headers: {} } };
+      }
+      return { data: { rates: { USD: 1.1 } } };
+    });
+    const { priceService } = await import("../src/services/price.service");
+
+    const btc = priceService.getRateUSD({ type: "CRYPTO", symbol: "BTC" });
+    let eur: number | undefined;
+    priceService
+      .getRateUSD({ type: "FIAT", symbol: "EUR" })
+      .then((r) => (eur = r.rateUSD));
+
+    // Well inside CoinGecko's first backoff
+    await vi.advanceTimersByTimeAsync(100);
+    expect(eur).toBe(1.1);
+    expect(
+      mockGet.mock.calls.filter(([url]) => url.includes("coingecko")),
+    ).toHaveLength(1);
+
+    await vi.runAllTimersAsync();
+    expect((await btc).stale).toBe(true);
+  });
+});
@@ -0,0 +1,103 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Price source priority
+ *
+ * - Without a configured priority the first provider that answers wins
+ * - A configured priority sets the rate from its first provider and
+ *   cross-checks the others
+ * - Providers disagreeing beyond the threshold are recorded and notified
+ */
+
+describe("Price source priority", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const mockGet = vi.fn();
+  const mockSend = vi.fn();
+  let priority: Record<string, string[]> = {};
+
+  // USD -> VND from exchangerate-api, VND -> USD from the others
+  const respond = (exchangeRateApiVnd: number, erApiUsd: number) =>
+    mockGet.mockImplementation(async (url: string) => {
+      if (url.includes("v6.exchangerate-api.com")) {
+        return { data: { conversion_rates: { VND: exchangeRateApiVnd } } };
+      }
+      if (url.includes("open.er-api.com")) {
+        return { data: { rates: { USD: erApiUsd } } };
+      }
+      return { data: { rates: { USD: 0.00004 } } };
+    });
+
+  const rateAt = async (atISO?: string) => {
+    const { priceService } = await import("../src/services/price.service");
+    const pending = priceService.getRateUSD(VND, atISO);
+    await vi.runAllTimersAsync();
+    return { rate: await pending, priceService };
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    mockGet.mockReset();
+    mockSend.mockReset().mockResolvedValue(undefined);
+    priority = {};
+
+    vi.doMock("axios", () => ({ default: { get: mockGet } }));
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: {
+        getByCacheKey: () => null,
+        save: vi.fn(),
+        getLatestRate: () => null,
+      },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getPriceSourcePriority: () => priority,
+        getManualPrices: () => [],
+        getPriceDiscrepancyThresholdPercent: () => 2,
+      },
+    }));
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send: mockSend },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it("stops at the first answering provider by default", async () => {
+    respond(25000, 0.00004);
+    const { rate } = await rateAt();
+
+    expect(rate.source).toBe("EXCHANGE_RATE_HOST");
+    expect(mockGet).toHaveBeenCalledTimes(1);
+  });
+
+  it("uses the configured order and records disagreement", async () => {
+    priority = { VND: ["EXCHANGE_RATE_API", "ER_API"] };
+    respond(25000, 0.000042); // 0.00004 vs 0.000042: 5% apart
+    const { rate, priceService } = await rateAt("2025-01-05T08:00:00.000Z");
+
+    expect(rate.source).toBe("EXCHANGE_RATE_API");
+    expect(rate.rateUSD).toBeCloseTo(0.00004, 12);
+    expect(mockGet).toHaveBeenCalledTimes(2);
+
+    const [d] = priceService.getDiscrepancies();
+    expect(d.asset).toBe("FIAT:VND");
+    expect(d.day).toBe("2025-01-05T00:00:00.000Z");
+    expect(d.quotes[0].source).toBe("ER_API");
+    expect(d.quotes[0].deviationPercent).toBeCloseTo(5, 6);
+    expect(mockSend).toHaveBeenCalledWith(
+      expect.objectContaining({ kind: "price_discrepancy" }),
+    );
+  });
+
+  it("stays quiet when providers agree within the threshold", async () => {
+    priority = { VND: ["EXCHANGE_RATE_API", "ER_API"] };
+    respond(25000, 0.0000404); // 1% apart
+    const { priceService } = await rateAt();
+
+    expect(priceService.getDiscrepancies()).toEqual([]);
+    expect(mockSend).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,136 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Batch price quotes
+ *
+ * - Results keep request order, whatever came from the cache
+ * - Each asset and day is looked up once, however often it is asked for
+ * - Cache misses are fetched concurrently, a few at a time
+ * - Malformed batches are rejected before any lookup
+ */
+
+type Asset = import("../src/types").Asset;
+type Rate = import("../src/types").Rate;
+
+describe("POST /prices/batch", () => {
+  const usdPer: Record<string, number> = {
+    BTC: 50000,
+    ETH: 3000,
+    VND: 1 / 25000,
+  };
+  const rate = (asset: Asset, atISO?: string): Rate => ({
+    asset,
+    rateUSD: usdPer[asset.symbol] ?? 1,
+    timestamp: atISO ?? new Date().toISOString(),
+    source: "COINGECKO",
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories/price-cache.repository", () => ({
+      priceCacheRepository: { getByCacheKey: () => null, save: vi.fn() },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: { findAllAssets: () => [] },
+      settingsRepository: {},
+    }));
+    for (const m of ["stablecoin", "job", "price-snapshot"]) {
+      vi.doMock(`../src/services/${m}.service`, () => ({}));
+    }
+  });
+
+  // BTC and USD are cached; anything else goes to the provider
+  const setup = async () => {
+    const { priceService } = await import("../src/services/price.service");
+    const { pricesRouter } = await import("../src/handlers/prices.handler");
+    vi.spyOn(priceService, "getCachedRate").mockImplementation(
+      (asset, atISO) =>
+        ["BTC", "USD"].includes(asset.symbol) ? rate(asset, atISO) : null,
+    );
+    const getRateUSD = vi
+      .spyOn(priceService, "getRateUSD")
+      .mockImplementation(async (asset, atISO) => rate(asset, atISO));
+    const app = express();
+    app.use(express.json());
+    app.use("/api", pricesRouter);
+    return { app, getRateUSD };
+  };
+
+  it("keeps request order and looks each quote up once", async () => {
+    const { app, getRateUSD } = await setup();
+    const res = await request(app)
+      .post("/api/prices/batch")
+      .send({
+        quotes: [
+          { asset: "btc", currency: "VND", date: "2025-01-02" },
+          { asset: "ETH", date: "2025-01-02" },
+          { asset: "BTC", date: "2025-01-02" },
+          { asset: { type: "CRYPTO", symbol: "eth" }, date: "2025-01-02" },
+        ],
+      });
+
+    expect(res.status).toBe(200);
+    expect(res.body.map((q: any) => [q.symbol, q.currency])).toEqual([
+      ["BTC", "VND"],
+      ["ETH", "USD"],
+      ["BTC", "USD"],
+      ["ETH", "USD"],
+    ]);
+    expect(res.body[0]).toMatchObject({
+      date: "2025-01-02",
+      price: expect.closeTo(50000 * 25000, 3),
+      rate_usd: 50000,
+      cached: false,
+      stale: false,
+    });
+    expect(res.body[1]).toMatchObject({ price: 3000, cached: false });
+    expect(res.body[2]).toMatchObject({ price: 50000, cached: true });
+
+    // Only the misses, once each
+    const fetched = getRateUSD.mock.calls.map(([a]) => a.symbol).sort();
+    expect(fetched).toEqual(["ETH", "VND"]);
+  });
+
+  it("fetches misses concurrently", async () => {
+    const { app, getRateUSD } = await setup();
+    let inFlight = 0;
+    let peak = 0;
+    getRateUSD.mockImplementation(async (asset, atISO) => {
+      inFlight += 1;
+      peak = Math.max(peak, inFlight);
+      await new Promise((resolve) => setTimeout(resolve, 5));
+      inFlight -= 1;
+      return rate(asset, atISO);
+    });
+
+    const days = ["01", "02", "03", "04", "05", "06", "07", "08"];
+    const res = await request(app)
+      .post("/api/prices/batch")
+      .send({
+        quotes: days.map((d) => ({ asset: "ETH", date: `2025-01-${d}` })),
+      });
+
+    expect(res.status).toBe(200);
+    expect(res.body.map((q: any) => q.date.slice(8))).toEqual(days);
+    // ETH for each day, four at a time
+    expect(getRateUSD).toHaveBeenCalledTimes(8);
+    expect(peak).toBe(4);
+  });
+
+  it("rejects an empty batch or a bad date", async () => {
+    const { app, getRateUSD } = await setup();
+    const empty = await request(app)
+      .post("/api/prices/batch")
+      .send({ quotes: [] });
+    expect(empty.status).toBe(400);
+
+    const bad = await request(app)
+      .post("/api/prices/batch")
+      .send({ quotes: [{ asset: "BTC" }, { asset: "ETH", date: "soon" }] });
+    expect(bad.status).toBe(400);
+    expect(bad.body.error).toBe("Invalid date: soon");
+    expect(getRateUSD).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,128 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Price and FX history
+ *
+ * - One row per day and symbol, as an array whatever the symbol count
+ * - days_per_page/offset page over days; X-Total-Days is the days in the
+ *   range
+ * - Bad ranges are rejected
+ */
+
+describe("Price history", () => {
+  // USD per unit; BTC gains 1000 a day from January 1st
+  const usdPer = (symbol: string, at?: string) => {
+    const day = at ? Number(at.slice(8, 10)) : 0;
+    if (symbol === "BTC") return 40000 + 1000 * (day - 1);
+    if (symbol === "ETH") return 3000;
+    if (symbol === "VND") return 1 / 25000;
+    return 1;
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: { findAllAssets: () => [] },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }, at?: string) => ({
+          asset,
+          rateUSD: usdPer(asset.symbol, at),
+          timestamp: at ?? new Date().toISOString(),
+          source: "FIXED",
+        }),
+      },
+    }));
+    for (const m of ["stablecoin", "job", "price-snapshot"]) {
+      vi.doMock(`../src/services/${m}.service`, () => ({}));
+    }
+  });
+
+  const buildApp = async () => {
+    const { pricesRouter } = await import("../src/handlers/prices.handler");
+    const app = express();
+    app.use(express.json());
+    app.use("/api", pricesRouter);
+    return app;
+  };
+
+  it("returns an array for one symbol or several", async () => {
+    const app = await buildApp();
+    const one = await request(app).get(
+      "/api/prices/daily?symbol=btc&start=2025-01-01&end=2025-01-02",
+    );
+    expect(one.status).toBe(200);
+    expect(one.body).toEqual([
+      { date: "2025-01-01", symbol: "BTC", price: 40000 },
+      { date: "2025-01-02", symbol: "BTC", price: 41000 },
+    ]);
+
+    const two = await request(app).get(
+      "/api/prices/daily?symbols=ETH,BTC&start=2025-01-01&end=2025-01-02",
+    );
+    expect(two.body.map((r: any) => [r.date, r.symbol])).toEqual([
+      ["2025-01-01", "ETH"],
+      ["2025-01-01", "BTC"],
+      ["2025-01-02", "ETH"],
+      ["2025-01-02", "BTC"],
+    ]);
+  });
+
+  it("pages over the days of the range", async () => {
+    const app = await buildApp();
+    const page = await request(app).get(
+      "/api/prices/daily?symbols=BTC,ETH&start=2025-01-01&end=2025-01-10" +
+        "&days_per_page=3&offset=4",
+    );
+    expect(page.status).toBe(200);
+    expect(page.headers["x-total-days"]).toBe("10");
+    expect(page.headers["x-total-count"]).toBeUndefined();
+    expect([...new Set(page.body.map((r: any) => r.date))]).toEqual([
+      "2025-01-05",
+      "2025-01-06",
+      "2025-01-07",
+    ]);
+    expect(page.body).toHaveLength(6);
+
+    const past = await request(app).get(
+      "/api/prices/daily?symbol=BTC&start=2025-01-01&end=2025-01-10&offset=10",
+    );
+    expect(past.headers["x-total-days"]).toBe("10");
+    expect(past.body).toEqual([]);
+  });
+
+  it("returns FX history as one array across targets", async () => {
+    const app = await buildApp();
+    const res = await request(app).get(
+      "/api/fx/history?from=USD&to=VND,USD&start=2025-01-01&end=2025-01-03" +
+        "&days_per_page=2",
+    );
+    expect(res.status).toBe(200);
+    expect(res.headers["x-total-days"]).toBe("3");
+    const row = (date: string, to: string, rate: number) => ({
+      date,
+      rate: expect.closeTo(rate, 6),
+      from: "USD",
+      to,
+    });
+    expect(res.body).toEqual([
+      row("2025-01-01", "VND", 25000),
+      row("2025-01-01", "USD", 1),
+      row("2025-01-02", "VND", 25000),
+      row("2025-01-02", "USD", 1),
+    ]);
+  });
+
+  it("rejects a missing symbol or an inverted range", async () => {
+    const app = await buildApp();
+    expect((await request(app).get("/api/prices/daily")).status).toBe(400);
+    const inverted = await request(app).get(
+      "/api/fx/history?to=VND&start=2025-02-01&end=2025-01-01",
+    );
+    expect(inverted.status).toBe(400);
+    expect(inverted.body.error).toBe("start must be before or equal to end");
+  });
+});
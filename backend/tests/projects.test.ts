@@ -0,0 +1,184 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import express from "express";
+import request from "supertest";
+
+/**
+ * Trips and projects
+ *
+ * - Transactions are assigned by id; an unknown id assigns nothing
+ * - The report adds up expenses across currencies and accounts in USD,
+ *   nets refunds separately and spreads the cost over the trip's days
+ * - Unassigning or deleting the project leaves the transactions in place
+ */
+
+type Asset = import("../src/types").Asset;
+
+describe("Projects", () => {
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  const JPY = { type: "FIAT" as const, symbol: "JPY" };
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  let store: any;
+
+  const tx = (
+    id: string,
+    type: string,
+    day: string,
+    asset: Asset,
+    amount: number,
+    usdAmount: number,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type,
+    asset,
+    amount,
+    usdAmount,
+    createdAt: `2025-03-${day}T09:00:00.000Z`,
+    ...extra,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = {
+      projects: [],
+      transactions: [
+        tx("ramen", "EXPENSE", "10", JPY, 10000, 66, {
+          account: "Cash",
+          category: "Food",
+        }),
+        tx("hotel", "EXPENSE", "10", USD, 200, 200, {
+          account: "Card",
+          category: "Lodging",
+        }),
+        tx("sushi", "EXPENSE", "12", BTC, 0.001, 60, {
+          account: "Wallet",
+          category: "Food",
+        }),
+        tx("refund", "INCOME", "13", USD, 20, 20, { account: "Card" }),
+        tx("rent", "EXPENSE", "01", USD, 900, 900, { account: "Bank" }),
+      ],
+    };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+    vi.doMock("../src/repositories", async () => {
+      const { ProjectRepositoryJson } = await import(
+        "../src/repositories/project.repository"
+      );
+      const { TransactionRepositoryJson } = await import(
+        "../src/repositories/transaction.repository"
+      );
+      return {
+        projectRepository: new ProjectRepositoryJson(),
+        transactionRepository: new TransactionRepositoryJson(),
+      };
+    });
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }) => ({
+          asset,
+          rateUSD: asset.symbol === "VND" ? 1 / 25000 : 1,
+          timestamp: new Date().toISOString(),
+          source: "FIXED",
+        }),
+      },
+    }));
+  });
+
+  const buildApp = async () => {
+    const { projectsRouter } = await import("../src/handlers/project.handler");
+    const app = express();
+    app.use(express.json());
+    app.use("/api", projectsRouter);
+    return app;
+  };
+
+  const createTrip = async (app: express.Express) => {
+    const res = await request(app).post("/api/projects").send({
+      name: "Japan trip March 2025",
+      startAt: "2025-03-10T00:00:00.000Z",
+      endAt: "2025-03-14T00:00:00.000Z",
+    });
+    expect(res.status).toBe(201);
+    expect(res.body).toMatchObject({ kind: "TRIP", status: "ACTIVE" });
+    return res.body.id as string;
+  };
+
+  const assign = (app: express.Express, id: string, ids: string[]) =>
+    request(app)
+      .post(`/api/projects/${id}/transactions`)
+      .send({ transaction_ids: ids });
+
+  it("assigns transactions only when they all exist", async () => {
+    const app = await buildApp();
+    const id = await createTrip(app);
+
+    const bad = await assign(app, id, ["ramen", "nope"]);
+    expect(bad.status).toBe(400);
+    expect(bad.body.error).toBe("Transactions not found: nope");
+    expect(store.transactions[0].projectId).toBeUndefined();
+
+    const ok = await assign(app, id, ["ramen", "hotel"]);
+    expect(ok.body).toEqual({ ok: true, assigned: 2 });
+    expect((await assign(app, "missing", ["ramen"])).status).toBe(404);
+  });
+
+  it("reports the cost across currencies, categories and days", async () => {
+    const app = await buildApp();
+    const id = await createTrip(app);
+    await assign(app, id, ["ramen", "hotel", "sushi", "refund"]);
+
+    const res = await request(app).get(`/api/projects/${id}/report`);
+    expect(res.status).toBe(200);
+    expect(res.body).toMatchObject({
+      total_usd: 326,
+      total_vnd: 326 * 25000,
+      refunds_usd: 20,
+      net_usd: 306,
+      days: 5,
+      daily_burn_usd: expect.closeTo(65.2, 9),
+    });
+    expect(res.body.by_asset.JPY).toMatchObject({
+      amount: 10000,
+      total_usd: 66,
+      count: 1,
+    });
+    expect(res.body.by_category).toEqual({
+      Food: { count: 2, total_usd: 126, total_vnd: 126 * 25000 },
+      Lodging: { count: 1, total_usd: 200, total_vnd: 200 * 25000 },
+    });
+    expect(Object.keys(res.body.by_account)).toEqual([
+      "Cash",
+      "Card",
+      "Wallet",
+    ]);
+    expect(res.body.daily.map((d: any) => [d.date, d.total_usd])).toEqual([
+      ["2025-03-10", 266],
+      ["2025-03-12", 60],
+    ]);
+    expect(res.body.transactions.map((t: any) => t.id)).not.toContain("rent");
+  });
+
+  it("leaves transactions in place when unassigned or deleted", async () => {
+    const app = await buildApp();
+    const id = await createTrip(app);
+    await assign(app, id, ["ramen", "hotel"]);
+
+    await request(app)
+      .delete(`/api/projects/${id}/transactions/hotel`)
+      .expect(200);
+    await request(app)
+      .delete(`/api/projects/${id}/transactions/rent`)
+      .expect(404);
+    const report = await request(app).get(`/api/projects/${id}/report`);
+    expect(report.body.total_usd).toBe(66);
+
+    await request(app).delete(`/api/projects/${id}`).expect(200);
+    expect((await request(app).get(`/api/projects/${id}`)).status).toBe(404);
+    expect(store.transactions).toHaveLength(5);
+    expect(store.transactions.some((t: any) => t.projectId)).toBe(false);
+  });
+});
@@ -0,0 +1,133 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Data retention purge
+ *
+ * - Dry run lists affected rows without changing anything
+ * - Purge requires the dry-run token
+ * - Vault history is replaced by opening balances; links are cleared
+ */
+
+type Transaction = import("../src/types").Transaction;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Purge Service", () => {
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  let txs: Transaction[] = [];
+  let entries: VaultEntry[] = [];
+
+  const tx = (id: string, createdAt: string, extra = {}) =>
+    ({
+      id,
+      type: "EXPENSE",
+      asset: USD,
+      amount: 10,
+      createdAt,
+      account: "Spend",
+      rate: { asset: USD, rateUSD: 1 },
+      usdAmount: 10,
+      ...extra,
+    }) as Transaction;
+
+  beforeEach(() => {
+    vi.resetModules();
+    txs = [
+      tx("old-expense", "2021-05-01T00:00:00.000Z"),
+      tx("refund", "2022-02-01T00:00:00.000Z", {
+        type: "INCOME",
+        reimbursesId: "old-expense",
+      }),
+    ];
+    entries = [
+      {
+        vault: "Spend",
+        type: "DEPOSIT",
+        asset: USD,
+        amount: 100,
+        usdValue: 100,
+        at: "2021-01-01T00:00:00.000Z",
+      },
+      {
+        vault: "Spend",
+        type: "WITHDRAW",
+        asset: USD,
+        amount: 10,
+        usdValue: 10,
+        at: "2021-05-01T00:00:00.000Z",
+      },
+      {
+        vault: "Spend",
+        type: "DEPOSIT",
+        asset: USD,
+        amount: 5,
+        usdValue: 5,
+        at: "2022-02-01T00:00:00.000Z",
+      },
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => txs,
+        findById: (id: string) => txs.find((t) => t.id === id),
+        update: (id: string, updates: Partial<Transaction>) => {
+          const t = txs.find((x) => x.id === id);
+          if (t) Object.assign(t, updates);
+          return t;
+        },
+        delete: (id: string) => {
+          txs = txs.filter((t) => t.id !== id);
+          return true;
+        },
+      },
+      vaultRepository: {
+        findAll: () => [{ name: "Spend", status: "ACTIVE" }],
+        findAllEntries: (name: string) =>
+          entries.filter((e) => e.vault === name),
+        deleteEntries: (p: { before?: string }) => {
+          const len = entries.length;
+          entries = entries.filter((e) => !(!p.before || e.at < p.before));
+          return len - entries.length;
+        },
+        createEntry: (e: VaultEntry) => {
+          entries.push(e);
+          return e;
+        },
+      },
+      registryRepository: { findAll: () => [], delete: () => true },
+      priceCacheRepository: { countBefore: () => 0, deleteBefore: () => 0 },
+    }));
+  });
+
+  it("lists affected rows without changing anything on dry run", async () => {
+    const { purgeService } = await import("../src/services/purge.service");
+    const plan = purgeService.plan({ before: "2022-01-01" });
+
+    expect(plan.transactions.map((t) => t.id)).toEqual(["old-expense"]);
+    expect(plan.vaultEntries).toHaveLength(2);
+    expect(plan.unlinkedTransactions).toEqual(["refund"]);
+    expect(plan.carryForward[0].amount).toBe(90);
+    expect(txs).toHaveLength(2);
+    expect(entries).toHaveLength(3);
+  });
+
+  it("requires the dry-run token and carries balances forward", async () => {
+    const { purgeService } = await import("../src/services/purge.service");
+    expect(() =>
+      purgeService.execute({ before: "2022-01-01" }, "wrong"),
+    ).toThrow(/confirm token/);
+
+    const { token } = purgeService.plan({ before: "2022-01-01" });
+    const result = purgeService.execute({ before: "2022-01-01" }, token);
+
+    expect(result.transactions).toBe(1);
+    expect(result.vaultEntries).toBe(2);
+    expect(txs.map((t) => t.id)).toEqual(["refund"]);
+    expect(txs[0].reimbursesId).toBeUndefined();
+    // 100 - 10 carried forward, plus the later 5
+    const balance = entries.reduce(
+      (s, e) => s + (e.type === "DEPOSIT" ? e.amount : -e.amount),
+      0,
+    );
+    expect(balance).toBe(95);
+  });
+});
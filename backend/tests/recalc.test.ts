@@ -0,0 +1,144 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Bulk recalculation
+ *
+ * - Runs as a background job, a batch at a time, saving its position
+ * - Rates entered by hand are kept; usdAmount keeps its sign
+ * - A cancelled or interrupted run resumes after its last batch
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+const eur = { type: "FIAT" as const, symbol: "EUR" };
+
+const tx = (id: string, day: number, usdAmount = 10) =>
+  ({
+    id,
+    type: "INCOME",
+    asset: eur,
+    amount: 10,
+    createdAt: `2025-03-0${day}T00:00:00.000Z`,
+    rate: {
+      asset: eur,
+      rateUSD: 1,
+      timestamp: `2025-03-0${day}T00:00:00.000Z`,
+      source: "FRANKFURTER",
+    },
+    usdAmount,
+  }) as Transaction;
+
+describe("Recalc Service", () => {
+  const settings = new Map<string, string>();
+  let ledger: Transaction[] = [];
+  const update = vi.fn();
+  const getRateUSD = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings.clear();
+    ledger = [tx("e", 5), tx("a", 1), tx("c", 3, -10), tx("b", 2), tx("d", 4)];
+    ledger[3].rate = { ...ledger[3].rate, source: "FIXED" };
+    update.mockReset().mockImplementation((id: string) => ({ id }));
+    getRateUSD.mockReset().mockImplementation(async (asset: any) => ({
+      asset,
+      rateUSD: 1.5,
+      timestamp: "2025-03-01T00:00:00.000Z",
+      source: "FRANKFURTER",
+    }));
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getSetting: (k: string) => settings.get(k),
+        setSetting: (k: string, v: string) => settings.set(k, v),
+      },
+      transactionRepository: { findAll: () => [...ledger], update },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: { getRateUSD },
+    }));
+    vi.doMock("../src/services/enrichment.service", () => ({
+      enrichmentService: { pending: () => [] },
+    }));
+  });
+
+  const settle = async (id: string) => {
+    const { jobService } = await import("../src/services/job.service");
+    for (let i = 0; i < 100; i++) {
+      const status = jobService.get(id)?.status;
+      if (status !== "queued" && status !== "running") return status;
+      await new Promise((r) => setImmediate(r));
+    }
+  };
+
+  it("reprices in batches, keeping fixed rates", async () => {
+    const { recalcService } = await import("../src/services/recalc.service");
+
+    const { run, job } = recalcService.start("fx", {
+      batchSize: 2,
+      pauseMs: 0,
+    });
+    expect(job.kind).toBe("recalc-fx");
+    expect(await settle(job.id)).toBe("succeeded");
+
+    expect(recalcService.get(run.id)).toMatchObject({
+      status: "succeeded",
+      total: 5,
+      processed: 5,
+      changed: 4,
+    });
+    // Oldest first; b has a rate entered by hand
+    expect(update.mock.calls.map(([id, u]) => [id, u.usdAmount])).toEqual([
+      ["a", 15],
+      ["c", -15],
+      ["d", 15],
+      ["e", 15],
+    ]);
+  });
+
+  it("resumes a cancelled or interrupted run", async () => {
+    const { recalcService } = await import("../src/services/recalc.service");
+    const { jobService } = await import("../src/services/job.service");
+
+    const { run, job } = recalcService.start("usd", {
+      batchSize: 2,
+      pauseMs: 0,
+    });
+    // Starting another while one runs is refused
+    expect(() =>
+      recalcService.start("fx", { batchSize: 2, pauseMs: 0 }),
+    ).toThrow(/already running/);
+    ledger.forEach((t) => (t.usdAmount = 0));
+    update.mockImplementationOnce((id: string) => {
+      jobService.cancel(job.id);
+      return { id };
+    });
+    expect(await settle(job.id)).toBe("cancelled");
+    expect(recalcService.get(run.id)).toMatchObject({
+      status: "cancelled",
+      processed: 2,
+    });
+
+    // As if the server had stopped mid-run: its job is gone
+    const saved = JSON.parse(settings.get("recalcRuns")!);
+    saved[0].status = "running";
+    saved[0].jobId = "gone";
+    settings.set("recalcRuns", JSON.stringify(saved));
+    expect(recalcService.get(run.id)!.status).toBe("interrupted");
+
+    const resumed = recalcService.resume(run.id);
+    expect(await settle(resumed.job.id)).toBe("succeeded");
+    expect(recalcService.get(run.id)).toMatchObject({
+      status: "succeeded",
+      processed: 5,
+      changed: 5,
+    });
+    expect(update.mock.calls.map(([id]) => id)).toEqual([
+      "a",
+      "b",
+      "c",
+      "d",
+      "e",
+    ]);
+    expect(() => recalcService.resume(run.id)).toThrow(/succeeded/);
+  });
+});
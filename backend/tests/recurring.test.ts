@@ -0,0 +1,233 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+import {
+  nextOccurrence,
+  occurrences,
+  parseRule,
+} from "../src/utils/rrule.util";
+
+/**
+ * Recurring transactions
+ *
+ * - Rules follow RRULE; month days past the month's end clamp to its end
+ * - Due occurrences are created (or queued when confirm is set) and the
+ *   schedule moves past them; a failure is retried from that occurrence
+ * - A latest charge off the entry's amount is flagged once as drift
+ */
+
+const take = (rule: string, start: string, n: number) => {
+  const out: string[] = [];
+  for (const at of occurrences(parseRule(rule), start)) {
+    out.push(at.slice(0, 10));
+    if (out.length === n) break;
+  }
+  return out;
+};
+
+describe("RRULE subset", () => {
+  it("clamps month days and honours BYDAY, INTERVAL and COUNT", () => {
+    expect(take("FREQ=MONTHLY", "2025-01-31T00:00:00Z", 3)).toEqual([
+      "2025-01-31",
+      "2025-02-28",
+      "2025-03-31",
+    ]);
+    expect(
+      take("FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-15T00:00:00Z", 2),
+    ).toEqual(["2024-01-31", "2024-02-29"]);
+    // 2025-01-01 is a Wednesday
+    expect(
+      take("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2025-01-01T00:00:00Z", 3),
+    ).toEqual(["2025-01-03", "2025-01-13", "2025-01-17"]);
+    expect(take("FREQ=DAILY;COUNT=2", "2025-01-01T00:00:00Z", 5)).toEqual([
+      "2025-01-01",
+      "2025-01-02",
+    ]);
+  });
+
+  it("stops after UNTIL and rejects unsupported parts", () => {
+    const rule = parseRule("FREQ=YEARLY;UNTIL=20260101");
+    const start = "2025-01-01T00:00:00.000Z";
+    expect(nextOccurrence(rule, start, start)).toBe(
+      "2026-01-01T00:00:00.000Z",
+    );
+    expect(
+      nextOccurrence(rule, start, "2026-01-01T00:00:00.000Z"),
+    ).toBeUndefined();
+
+    expect(() => parseRule("FREQ=HOURLY")).toThrow(/FREQ/);
+    expect(() => parseRule("FREQ=DAILY;BYDAY=MO")).toThrow(/WEEKLY/);
+    expect(() => parseRule("FREQ=MONTHLY;BYSETPOS=1")).toThrow(/BYSETPOS/);
+  });
+});
+
+describe("Recurring Transaction Service", () => {
+  const updates: any[] = [];
+  const createExpenseTransaction = vi.fn();
+  const send = vi.fn();
+  let entry: any;
+  let txs: any[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    updates.length = 0;
+    createExpenseTransaction.mockReset();
+    send.mockReset();
+    txs = [];
+    createExpenseTransaction.mockImplementation(async (p: any) => ({
+      id: `tx-${p.at.slice(0, 10)}`,
+      ...p,
+    }));
+    entry = {
+      id: "rent",
+      name: "Rent",
+      type: "EXPENSE",
+      asset: { type: "FIAT", symbol: "VND" },
+      amount: 8000000,
+      rule: "FREQ=MONTHLY;BYMONTHDAY=1",
+      startAt: "2025-01-01T00:00:00.000Z",
+      nextRunAt: "2025-02-01T00:00:00.000Z",
+      lastOccurrenceAt: "2025-01-01T00:00:00.000Z",
+      occurrences: 1,
+      confirm: false,
+      active: true,
+      createdAt: "2025-01-01T00:00:00.000Z",
+    };
+
+    vi.doMock("../src/repositories", () => ({
+      recurringRepository: {
+        findDue: () => [entry],
+        findAll: () => [entry],
+        findById: () => entry,
+        update: (_id: string, u: any) => {
+          updates.push(u);
+          return { ...entry, ...u };
+        },
+      },
+      transactionRepository: {
+        findBySourceRef: () => undefined,
+        findAll: () => txs,
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: { createExpenseTransaction },
+    }));
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send },
+    }));
+    vi.doMock("../src/services/precision.service", () => ({
+      precisionService: { validate: () => undefined },
+    }));
+  });
+
+  it("creates missed occurrences and moves the schedule on", async () => {
+    const { recurringTransactionService } = await import(
+      "../src/services/recurring.service"
+    );
+
+    const [run] = await recurringTransactionService.processDue(
+      new Date("2025-03-15T00:00:00Z"),
+    );
+
+    expect(run.created.map((t) => t.id)).toEqual([
+      "tx-2025-02-01",
+      "tx-2025-03-01",
+    ]);
+    expect(createExpenseTransaction).toHaveBeenCalledWith(
+      expect.objectContaining({
+        note: "Rent",
+        sourceRef: "recurring:rent:2025-02-01",
+      }),
+    );
+    expect(updates[0]).toMatchObject({
+      nextRunAt: "2025-04-01T00:00:00.000Z",
+      lastOccurrenceAt: "2025-03-01T00:00:00.000Z",
+      occurrences: 3,
+    });
+  });
+
+  it("queues occurrences when confirm is set", async () => {
+    entry.confirm = true;
+    const { recurringTransactionService } = await import(
+      "../src/services/recurring.service"
+    );
+
+    const [run] = await recurringTransactionService.processDue(
+      new Date("2025-02-02T00:00:00Z"),
+    );
+
+    expect(createExpenseTransaction).not.toHaveBeenCalled();
+    expect(run.queued).toEqual(["2025-02-01T00:00:00.000Z"]);
+    expect(updates[0].pendingDates).toEqual(["2025-02-01T00:00:00.000Z"]);
+  });
+
+  it("stops at a failed occurrence so it is retried", async () => {
+    createExpenseTransaction.mockRejectedValueOnce(new Error("no FX rate"));
+    const { recurringTransactionService } = await import(
+      "../src/services/recurring.service"
+    );
+
+    const [run] = await recurringTransactionService.processDue(
+      new Date("2025-03-15T00:00:00Z"),
+    );
+
+    expect(run.error).toBe("no FX rate");
+    expect(updates[0]).toMatchObject({
+      nextRunAt: "2025-02-01T00:00:00.000Z",
+      occurrences: 1,
+      lastError: "no FX rate",
+    });
+  });
+
+  it("flags a drifted charge once and applies it", async () => {
+    const VND = { type: "FIAT", symbol: "VND" };
+    const charge = (id: string, at: string, amount: number, extra = {}) => ({
+      id,
+      type: "EXPENSE",
+      asset: VND,
+      amount,
+      createdAt: at,
+      ...extra,
+    });
+    entry.counterparty = "Landlord";
+    entry.lastOccurrenceAt = "2025-03-01T00:00:00.000Z";
+    txs = [
+      charge("t1", "2025-01-01T00:00:00.000Z", 8000000, {
+        sourceRef: "recurring:rent:2025-01-01",
+      }),
+      charge("t2", "2025-02-01T00:00:00.000Z", 8000000, {
+        sourceRef: "recurring:rent:2025-02-01",
+      }),
+      // Imported bank charge two days after the due date
+      charge("t3", "2025-03-03T09:00:00.000Z", 8400000, {
+        counterparty: " landlord",
+      }),
+      charge("t4", "2025-03-10T00:00:00.000Z", 50000, {
+        counterparty: "Landlord",
+      }),
+    ];
+    const { recurringTransactionService } = await import(
+      "../src/services/recurring.service"
+    );
+
+    expect(
+      recurringTransactionService.history(entry).map((c) => c.transactionId),
+    ).toEqual(["t1", "t2", "t3"]);
+
+    const checked = await recurringTransactionService.checkDrift(entry);
+    expect(checked.drift).toMatchObject({
+      amount: 8400000,
+      previousAmount: 8000000,
+      transactionId: "t3",
+    });
+    expect(checked.drift!.changePercent).toBeCloseTo(5);
+    expect(send).toHaveBeenCalledTimes(1);
+    expect(send.mock.calls[0][0].kind).toBe("recurring_drift");
+
+    entry.drift = checked.drift;
+    await recurringTransactionService.checkDrift(entry);
+    expect(send).toHaveBeenCalledTimes(1);
+
+    const applied = recurringTransactionService.applyDrift("rent");
+    expect(applied.amount).toBe(8400000);
+    expect(applied.drift).toBeUndefined();
+  });
+});
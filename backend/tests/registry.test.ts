@@ -0,0 +1,170 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Subscription / Warranty Registry Tests
+ *
+ * - Registering an expense as a subscription
+ * - Rolling renewals forward and expiring warranties
+ * - Upcoming events and one reminder notification per cycle
+ */
+
+type RegistryItem = import("../src/types").RegistryItem;
+type Transaction = import("../src/types").Transaction;
+
+describe("Registry Service", () => {
+  let items: RegistryItem[] = [];
+  let txs: Transaction[] = [];
+  const send = vi.fn(async () => {});
+
+  beforeEach(() => {
+    vi.resetModules();
+    send.mockClear();
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send },
+    }));
+    items = [];
+    txs = [
+      {
+        id: "tx-1",
+        type: "EXPENSE",
+        asset: { type: "FIAT", symbol: "USD" },
+        amount: 15,
+        createdAt: "2025-01-10T00:00:00.000Z",
+        account: "Spend",
+        note: "Netflix",
+        rate: { asset: { type: "FIAT", symbol: "USD" }, rateUSD: 1 } as any,
+        usdAmount: 15,
+      } as Transaction,
+    ];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findById: (id: string) => txs.find((t) => t.id === id),
+      },
+      registryRepository: {
+        findAll: () => items,
+        findById: (id: string) => items.find((i) => i.id === id),
+        findByStatus: (status: string) =>
+          items.filter((i) => i.status === status),
+        findByTransactionId: (id: string) =>
+          items.filter((i) => i.transactionId === id),
+        create: (item: RegistryItem) => {
+          items.push(item);
+          return item;
+        },
+        update: (id: string, updates: Partial<RegistryItem>) => {
+          const idx = items.findIndex((i) => i.id === id);
+          if (idx === -1) return undefined;
+          items[idx] = { ...items[idx], ...updates };
+          return items[idx];
+        },
+        delete: (id: string) => {
+          const len = items.length;
+          items = items.filter((i) => i.id !== id);
+          return items.length < len;
+        },
+      },
+    }));
+  });
+
+  it("defaults subscription fields from the linked expense", async () => {
+    const { registryService } = await import(
+      "../src/services/registry.service"
+    );
+    const item = registryService.createItem({
+      kind: "SUBSCRIPTION",
+      transactionId: "tx-1",
+      cadence: "MONTHLY",
+    });
+
+    expect(item.name).toBe("Netflix");
+    expect(item.amount).toBe(15);
+    expect(item.nextRenewalAt).toBe("2025-02-10T00:00:00.000Z");
+    expect(() =>
+      registryService.createItem({ kind: "WARRANTY", name: "Laptop" }),
+    ).toThrow(/warrantyExpiresAt/);
+  });
+
+  it("rolls renewals forward and expires lapsed warranties", async () => {
+    const { registryService } = await import(
+      "../src/services/registry.service"
+    );
+    const sub = registryService.createItem({
+      kind: "SUBSCRIPTION",
+      name: "Spotify",
+      cadence: "MONTHLY",
+      nextRenewalAt: "2025-01-15T00:00:00.000Z",
+      cancelBy: "2025-01-13T00:00:00.000Z",
+    });
+    const warranty = registryService.createItem({
+      kind: "WARRANTY",
+      name: "Phone",
+      warrantyExpiresAt: "2025-02-01T00:00:00.000Z",
+    });
+
+    registryService.advanceRenewals(new Date("2025-03-20T00:00:00.000Z"));
+
+    expect(registryService.getItem(sub.id)?.nextRenewalAt).toBe(
+      "2025-04-15T00:00:00.000Z",
+    );
+    expect(registryService.getItem(sub.id)?.cancelBy).toBe(
+      "2025-04-13T00:00:00.000Z",
+    );
+    expect(registryService.getItem(warranty.id)?.status).toBe("EXPIRED");
+  });
+
+  it("lists upcoming events and notifies once per cycle", async () => {
+    const { registryService } = await import(
+      "../src/services/registry.service"
+    );
+    registryService.createItem({
+      kind: "SUBSCRIPTION",
+      name: "iCloud",
+      cadence: "YEARLY",
+      nextRenewalAt: "2025-06-05T00:00:00.000Z",
+    });
+
+    const now = new Date("2025-06-01T00:00:00.000Z");
+    const upcoming = registryService.upcoming(30, now);
+    expect(upcoming).toHaveLength(1);
+    expect(upcoming[0].event).toBe("RENEWAL");
+    expect(upcoming[0].daysUntil).toBe(4);
+
+    expect(await registryService.notifyUpcoming(now)).toHaveLength(1);
+    expect(
+      await registryService.notifyUpcoming(
+        new Date("2025-06-02T00:00:00.000Z"),
+      ),
+    ).toHaveLength(0);
+
+    expect(send).toHaveBeenCalledTimes(1);
+    expect(send).toHaveBeenCalledWith(
+      expect.objectContaining({
+        kind: "registry_reminder",
+        title: "iCloud: renews in 4 days",
+        data: expect.objectContaining({ event: "RENEWAL", daysUntil: 4 }),
+      }),
+    );
+  });
+
+  it("still marks the reminder sent when delivery fails", async () => {
+    const { registryService } = await import(
+      "../src/services/registry.service"
+    );
+    const item = registryService.createItem({
+      kind: "WARRANTY",
+      name: "Phone",
+      warrantyExpiresAt: "2025-06-02T00:00:00.000Z",
+    });
+    send.mockRejectedValueOnce(new Error("webhook down"));
+
+    const now = new Date("2025-06-01T00:00:00.000Z");
+    expect(await registryService.notifyUpcoming(now)).toHaveLength(1);
+    expect(send.mock.calls[0][0]).toMatchObject({
+      title: "Phone: warranty expires in 1 day",
+    });
+    expect(registryService.getItem(item.id)?.lastNotifiedAt).toBe(
+      now.toISOString(),
+    );
+  });
+});
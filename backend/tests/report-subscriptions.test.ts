@@ -0,0 +1,159 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Report subscriptions
+ *
+ * - Period modes resolve to whole UTC days relative to the run
+ * - Due subscriptions are rendered, delivered and rescheduled
+ * - A failed delivery is recorded and the schedule still moves on
+ */
+
+type ReportSubscription = import("../src/types").ReportSubscription;
+
+describe("Report Subscription Service", () => {
+  let subs: ReportSubscription[] = [];
+  const mockGet = vi.fn();
+  const mockSend = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    subs = [];
+    mockGet.mockReset();
+    mockSend.mockReset();
+
+    vi.doMock("axios", () => ({ default: { get: mockGet } }));
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send: mockSend },
+    }));
+    vi.doMock("../src/repositories", () => ({
+      reportSubscriptionRepository: {
+        findAll: () => subs,
+        findById: (id: string) => subs.find((s) => s.id === id),
+        findDue: (now: string) =>
+          subs.filter((s) => s.active && s.nextRunAt <= now),
+        create: (s: ReportSubscription) => {
+          subs.push(s);
+          return s;
+        },
+        update: (id: string, updates: Partial<ReportSubscription>) => {
+          const s = subs.find((x) => x.id === id);
+          if (s) Object.assign(s, updates);
+          return s;
+        },
+      },
+      settingsRepository: {
+        getFiscalYear: () => ({ start: "01-01", starts: {} }),
+      },
+    }));
+  });
+
+  it("resolves period modes to whole days", async () => {
+    const { periodRange } = await import(
+      "../src/services/report-subscription.service"
+    );
+    const now = new Date("2025-03-11T15:00:00.000Z");
+
+    expect(periodRange("last_month", now)).toEqual({
+      start: "2025-02-01T00:00:00.000Z",
+      end: "2025-02-28T23:59:59.999Z",
+    });
+    expect(periodRange("last_7_days", now)?.start).toBe(
+      "2025-03-05T00:00:00.000Z",
+    );
+    expect(periodRange("last_year", now)?.end).toBe(
+      "2024-12-31T23:59:59.999Z",
+    );
+    expect(periodRange("none", now)).toBeUndefined();
+  });
+
+  it("follows the fiscal year in year modes", async () => {
+    const { periodRange } = await import(
+      "../src/services/report-subscription.service"
+    );
+    const now = new Date("2025-03-11T15:00:00.000Z");
+    const fiscal = { start: "04-01", starts: {} };
+
+    expect(periodRange("year_to_date", now, fiscal)?.start).toBe(
+      "2024-04-01T00:00:00.000Z",
+    );
+    expect(periodRange("last_year", now, fiscal)).toEqual({
+      start: "2023-04-01T00:00:00.000Z",
+      end: "2024-03-31T23:59:59.999Z",
+    });
+  });
+
+  it("renders, delivers and reschedules due subscriptions", async () => {
+    const { reportSubscriptionService } = await import(
+      "../src/services/report-subscription.service"
+    );
+    mockGet.mockResolvedValue({
+      data: { total_usd: 500, total_vnd: 12500000, by_tag: {} },
+    });
+    const sub = reportSubscriptionService.create({
+      name: "Monthly spending",
+      report: "spending",
+      periodMode: "last_month",
+      filters: { account: "Spend", start: "2020-01-01" },
+      currency: "VND",
+      cadence: "MONTHLY",
+      nextRunAt: "2025-03-01T00:00:00.000Z",
+      active: true,
+    });
+
+    const runs = await reportSubscriptionService.processDue(
+      new Date("2025-03-01T00:30:00.000Z"),
+    );
+
+    expect(runs).toHaveLength(1);
+    expect(mockGet.mock.calls[0][0]).toMatch(/\/api\/reports\/spending$/);
+    expect(mockGet.mock.calls[0][1].params).toEqual({
+      account: "Spend",
+      start: "2025-02-01T00:00:00.000Z",
+      end: "2025-02-28T23:59:59.999Z",
+    });
+    expect(mockSend.mock.calls[0][0].data.result).toEqual({
+      total_vnd: 12500000,
+      by_tag: {},
+    });
+    expect(reportSubscriptionService.get(sub.id)).toMatchObject({
+      lastStatus: "OK",
+      lastRunAt: "2025-03-01T00:30:00.000Z",
+      nextRunAt: "2025-04-01T00:00:00.000Z",
+    });
+  });
+
+  it("records failures and leaves manual runs off the schedule", async () => {
+    const { reportSubscriptionService } = await import(
+      "../src/services/report-subscription.service"
+    );
+    mockGet.mockResolvedValue({ data: { holdings: [] } });
+    mockSend.mockRejectedValue(new Error("webhook down"));
+    const sub = reportSubscriptionService.create({
+      name: "Holdings",
+      report: "holdings",
+      periodMode: "none",
+      currency: "USD",
+      cadence: "WEEKLY",
+      nextRunAt: "2025-03-10T00:00:00.000Z",
+      active: true,
+    });
+
+    const run = await reportSubscriptionService.run(sub.id);
+
+    expect(run).toMatchObject({ status: "FAILED", error: "webhook down" });
+    expect(reportSubscriptionService.get(sub.id)).toMatchObject({
+      lastStatus: "FAILED",
+      nextRunAt: "2025-03-10T00:00:00.000Z",
+    });
+    expect(() =>
+      reportSubscriptionService.create({
+        name: "Typo",
+        report: "holding",
+        periodMode: "none",
+        currency: "USD",
+        cadence: "WEEKLY",
+        active: true,
+      }),
+    ).toThrow(/Unknown report/);
+  });
+});
@@ -0,0 +1,118 @@
+import { describe, it, expect, vi } from "vitest";
+import express from "express";
+import request from "supertest";
+import {
+  displayPrecision,
+  errorHandler,
+  reportingCurrency,
+} from "../src/core/middleware";
+import { DEFAULT_DISPLAY_PRECISION } from "../src/utils/number.util";
+
+/**
+ * Reporting currency (?currency=EUR)
+ *
+ * - Each <name>_usd figure gets a <name>_eur beside it
+ * - Dated rows convert at their day's rate, else the nearest earlier one,
+ *   looked up over the report's range in one call; the rest at today's
+ * - USD and VND pass through; unknown codes are rejected
+ */
+
+describe("Reporting currency", () => {
+  // USD per EUR today, and the days the FX history has a rate for
+  const today = 1.25;
+  const history = [
+    { day: "2025-01-01", rate: 1.0 },
+    { day: "2025-02-01", rate: 0.8 },
+  ];
+  const lookups = vi.fn(async (_currency: string, start: string, end: string) =>
+    history.filter((h) => h.day >= start && h.day <= end),
+  );
+
+  const buildApp = (
+    setting = "VND",
+    rows: unknown = [{ value_usd: 5 }],
+  ) => {
+    lookups.mockClear();
+    const app = express();
+    app.use(displayPrecision(() => DEFAULT_DISPLAY_PRECISION));
+    app.use(
+      reportingCurrency(
+        () => setting,
+        async (currency) => (currency === "EUR" ? today : undefined),
+        lookups,
+      ),
+    );
+    app.get("/report", (_req, res) =>
+      res.json({
+        total_usd: 100,
+        total_vnd: 2_500_000,
+        roi_percent: 5,
+        months: [
+          { month: "2025-02", spending_usd: 40 },
+          { month: "2025-03", spending_usd: 40 },
+        ],
+        series: [{ date: "2025-01-01", net_worth_usd: 10.123 }],
+      }),
+    );
+    app.get("/rows", (_req, res) => res.json(rows));
+    app.use(errorHandler);
+    return app;
+  };
+
+  it("adds figures at each row's rate", async () => {
+    const res = await request(buildApp()).get("/report?currency=eur");
+
+    expect(res.status).toBe(200);
+    expect(res.headers["x-reporting-currency"]).toBe("EUR");
+    expect(Object.keys(res.body).slice(0, 3)).toEqual([
+      "total_usd",
+      "total_eur",
+      "total_vnd",
+    ]);
+    expect(res.body.total_eur).toBe(80);
+    expect(res.body.roi_percent).toBe(5);
+    // February's first day has a rate; March takes February's
+    expect(res.body.months.map((m: any) => m.spending_eur)).toEqual([50, 50]);
+    // Rounded like USD
+    expect(res.body.series[0].net_worth_eur).toBe(10.12);
+    expect(lookups.mock.calls).toEqual([["EUR", "2025-01-01", "2025-03-01"]]);
+  });
+
+  it("looks a daily series up in one call", async () => {
+    const series = Array.from({ length: 90 }, (_, i) => ({
+      date: new Date(Date.UTC(2024, 11, 1 + i)).toISOString().slice(0, 10),
+      value_usd: 10,
+    }));
+    const res = await request(buildApp("EUR", series)).get("/rows");
+
+    expect(lookups).toHaveBeenCalledTimes(1);
+    expect(lookups).toHaveBeenCalledWith("EUR", "2024-12-01", "2025-02-28");
+    const eur = new Map(res.body.map((r: any) => [r.date, r.value_eur]));
+    // Before the first rate: today's
+    expect(eur.get("2024-12-31")).toBe(8);
+    expect(eur.get("2025-01-01")).toBe(10);
+    expect(eur.get("2025-01-31")).toBe(10);
+    expect(eur.get("2025-02-28")).toBe(12.5);
+  });
+
+  it("uses the setting without a query parameter", async () => {
+    const rows = await request(buildApp("EUR")).get("/rows");
+    expect(rows.body).toEqual([{ value_usd: 5, value_eur: 4 }]);
+
+    const plain = await request(buildApp()).get("/rows");
+    expect(plain.headers["x-reporting-currency"]).toBeUndefined();
+    expect(plain.body).toEqual([{ value_usd: 5 }]);
+
+    const vnd = await request(buildApp("EUR")).get("/rows?currency=VND");
+    expect(vnd.body).toEqual([{ value_usd: 5 }]);
+  });
+
+  it("rejects bad codes and currencies without a rate", async () => {
+    const bad = await request(buildApp()).get("/report?currency=EURO");
+    expect(bad.status).toBe(400);
+
+    const unknown = await request(buildApp()).get("/report?currency=XYZ");
+    expect(unknown.status).toBe(400);
+    expect(unknown.body.error).toMatch(/XYZ/);
+  });
+});
@@ -0,0 +1,111 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Snapshot diff
+ *
+ * - Holdings added/removed between two dates
+ * - Net worth change split into contributions, investment gains and FX
+ */
+
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Snapshot diff", () => {
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const entries: VaultEntry[] = [
+    {
+      vault: "Savings",
+      type: "DEPOSIT",
+      asset: VND,
+      amount: 24_000_000,
+      usdValue: 1000,
+      at: "2025-01-01T00:00:00.000Z",
+    },
+    {
+      vault: "Invest",
+      type: "DEPOSIT",
+      asset: BTC,
+      amount: 1,
+      usdValue: 40000,
+      at: "2025-01-01T00:00:00.000Z",
+    },
+    {
+      vault: "Spend",
+      type: "DEPOSIT",
+      asset: USD,
+      amount: 1000,
+      usdValue: 1000,
+      at: "2025-01-01T00:00:00.000Z",
+    },
+    {
+      vault: "Spend",
+      type: "WITHDRAW",
+      asset: USD,
+      amount: 1000,
+      usdValue: 1000,
+      at: "2025-01-15T00:00:00.000Z",
+    },
+    {
+      vault: "Invest",
+      type: "DEPOSIT",
+      asset: ETH,
+      amount: 2,
+      usdValue: 6000,
+      at: "2025-01-20T00:00:00.000Z",
+    },
+  ];
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAllEntriesUntil: (end: string) =>
+          entries.filter((e) => e.at <= end),
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }, at?: string) => {
+          const day = String(at).slice(0, 10);
+          const prices: Record<string, number> = {
+            BTC: day === "2025-01-10" ? 40000 : 50000,
+            ETH: 3000,
+            USD: 1,
+            VND: day === "2025-01-10" ? 1 / 24000 : 1 / 25000,
+          };
+          return { asset, rateUSD: prices[asset.symbol] };
+        },
+      },
+    }));
+  });
+
+  it("reports added and removed holdings", async () => {
+    const { snapshotService } = await import(
+      "../src/services/snapshot.service"
+    );
+    const d = await snapshotService.diff("2025-01-10", "2025-01-31");
+
+    const bySymbol = new Map(d.positions.map((p) => [p.asset.symbol, p]));
+    expect(bySymbol.get("ETH")?.change).toBe("ADDED");
+    expect(bySymbol.get("USD")?.change).toBe("REMOVED");
+    expect(bySymbol.get("BTC")?.change).toBe("UNCHANGED");
+    expect(bySymbol.get("BTC")?.valueChangeUSD).toBe(10000);
+    expect(bySymbol.get("ETH")?.netFlowUSD).toBe(6000);
+  });
+
+  it("splits net worth change into savings, market and FX", async () => {
+    const { snapshotService } = await import(
+      "../src/services/snapshot.service"
+    );
+    const d = await snapshotService.diff("2025-01-10", "2025-01-31");
+
+    // 42000 -> 56960; +6000 deposited, -1000 withdrawn
+    expect(d.netWorthChangeUSD).toBeCloseTo(14960, 6);
+    expect(d.netContributionsUSD).toBe(5000);
+    expect(d.investmentGainsUSD).toBeCloseTo(10000, 6);
+    // VND weakened from 24000 to 25000 per USD
+    expect(d.fxEffectUSD).toBeCloseTo(-40, 6);
+  });
+});
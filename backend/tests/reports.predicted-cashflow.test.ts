@@ -4,6 +4,16 @@ import request from "supertest";
 
 type Asset = import("../src/types").Asset;
 
+/**
+ * Predicted outflows
+ *
+ * - The forecast starts at the month after the as-of date
+ * - Baselines come from recorded expense days of the last 6 months,
+ *   per weekday where there is history
+ * - Figures are rounded for display (USD to cents, VND to the unit);
+ *   ?raw=true returns them unrounded
+ */
+
 describe("reports/predicted-outflows", () => {
   const defaultAccount = "Spending";
   let mockTxs: any[] = [];
@@ -95,14 +105,11 @@ describe("reports/predicted-outflows", () => {
     // Baseline = total expense / total recorded days (unique dates with expenses).
     const expectedBaselineDaily = (300 + 600) / 2;
 
-    expect(res.body.baseline_daily_spend_usd).toBeCloseTo(
-      expectedBaselineDaily,
-      6,
-    );
-    expect(res.body.series[0].predicted_spend_usd).toBeCloseTo(
-      expectedBaselineDaily,
-      6,
+    expect(res.body.baseline_daily_spend_usd).toBe(expectedBaselineDaily);
+    expect(res.body.baseline_daily_spend_vnd).toBe(
+      expectedBaselineDaily * 25000,
     );
+    expect(res.body.series[0].predicted_spend_usd).toBe(expectedBaselineDaily);
   });
 
   it("counts unique recorded days (multiple expenses on one day count as 1)", async () => {
@@ -128,11 +135,8 @@ describe("reports/predicted-outflows", () => {
 
     // Total expense = 60; recorded days = {2025-02-01, 2025-03-02} => 2 days.
     const expectedBaselineDaily = 60 / 2;
-    expect(res.body.baseline_daily_spend_usd).toBeCloseTo(expectedBaselineDaily, 6);
-    expect(res.body.series[0].predicted_spend_usd).toBeCloseTo(
-      expectedBaselineDaily,
-      6,
-    );
+    expect(res.body.baseline_daily_spend_usd).toBe(expectedBaselineDaily);
+    expect(res.body.series[0].predicted_spend_usd).toBe(expectedBaselineDaily);
   });
 
   it("predicts per-day using weekday averages and falls back to overall average when missing weekday data", async () => {
@@ -161,33 +165,18 @@ describe("reports/predicted-outflows", () => {
     const expectedTuesday = (20 + 40) / 2; // 30
     const expectedWednesday = 70; // single sample should be used
 
-    expect(res.body.baseline_daily_spend_usd).toBeCloseTo(
-      expectedOverallBaseline,
-      6,
-    );
+    expect(res.body.baseline_daily_spend_usd).toBe(expectedOverallBaseline);
 
     const byDate = Object.fromEntries(
       (res.body.series || []).map((d: any) => [d.date, d]),
     );
     // Aug 1, 2025 is Fri; there is no Fri history, so fall back to overall baseline.
-    expect(byDate["2025-08-01"].predicted_spend_usd).toBeCloseTo(
-      expectedOverallBaseline,
-      6,
-    );
+    expect(byDate["2025-08-01"].predicted_spend_usd).toBe(expectedOverallBaseline);
     // Aug 4/5, 2025 are Mon/Tue and should use weekday-specific baselines.
-    expect(byDate["2025-08-04"].predicted_spend_usd).toBeCloseTo(
-      expectedMonday,
-      6,
-    );
-    expect(byDate["2025-08-05"].predicted_spend_usd).toBeCloseTo(
-      expectedTuesday,
-      6,
-    );
+    expect(byDate["2025-08-04"].predicted_spend_usd).toBe(expectedMonday);
+    expect(byDate["2025-08-05"].predicted_spend_usd).toBe(expectedTuesday);
     // Aug 6, 2025 is Wed and should use the single-sample baseline (no fallback).
-    expect(byDate["2025-08-06"].predicted_spend_usd).toBeCloseTo(
-      expectedWednesday,
-      6,
-    );
+    expect(byDate["2025-08-06"].predicted_spend_usd).toBe(expectedWednesday);
   });
 
   it("uses at most the last 6 months for baseline calculations", async () => {
@@ -216,7 +205,41 @@ describe("reports/predicted-outflows", () => {
       .expect(200);
 
     const expectedBaselineDaily = (10 + 20 + 30 + 40 + 50 + 60) / 6;
-    expect(res.body.baseline_daily_spend_usd).toBeCloseTo(expectedBaselineDaily, 6);
-    expect(res.body.series[0].predicted_spend_usd).toBeCloseTo(expectedBaselineDaily, 6);
+    expect(res.body.baseline_daily_spend_usd).toBe(expectedBaselineDaily);
+    expect(res.body.series[0].predicted_spend_usd).toBe(expectedBaselineDaily);
+  });
+
+  it("rounds for display unless raw=true", async () => {
+    vi.setSystemTime(new Date("2025-07-18T12:00:00Z"));
+
+    // 50 over 3 recorded days, on weekdays the forecast doesn't start on
+    mockTxs = [
+      { day: "2025-02-03", usd: -10 },
+      { day: "2025-03-03", usd: -20 },
+      { day: "2025-04-08", usd: -20 },
+    ].map(({ day, usd }) => ({
+      type: "EXPENSE",
+      account: defaultAccount,
+      usdAmount: usd,
+      createdAt: `${day}T00:00:00Z`,
+    }));
+
+    const { reportsRouter } = await import("../src/handlers/reports.handler");
+    const app = appFactory(reportsRouter);
+    const url =
+      "/api/reports/predicted-outflows?start_date=2025-07-15" +
+      "&end_date=2025-08-01";
+
+    const rounded = await request(app).get(url).expect(200);
+    expect(rounded.body.baseline_daily_spend_usd).toBe(16.67);
+    expect(rounded.body.baseline_daily_spend_vnd).toBe(416667);
+    expect(rounded.body.series[0].predicted_spend_usd).toBe(16.67);
+
+    const raw = await request(app).get(`${url}&raw=true`).expect(200);
+    expect(raw.body.baseline_daily_spend_usd).toBeCloseTo(50 / 3, 9);
+    expect(raw.body.baseline_daily_spend_vnd).toBeCloseTo(
+      (50 / 3) * 25000,
+      6,
+    );
   });
 });
@@ -0,0 +1,172 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import express from "express";
+import request from "supertest";
+import { computeRiskMetrics } from "../src/services/financial.service";
+
+/**
+ * Risk metrics
+ *
+ * - Volatility, max drawdown and Sharpe ratio of a daily return series
+ * - GET /reports/risk rebuilds daily holdings from vault deposits and
+ *   withdrawals, forward-fills stored prices, and weights returns by the
+ *   previous day's value so new money is not a gain
+ */
+
+type Asset = import("../src/types").Asset;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Risk metrics", () => {
+  it("should return zeros for an empty series", () => {
+    const m = computeRiskMetrics([]);
+    expect(m.observations).toBe(0);
+    expect(m.volatilityPercent).toBe(0);
+    expect(m.sharpeRatio).toBe(0);
+  });
+
+  it("should measure the largest peak-to-trough decline", () => {
+    // 100 -> 110 -> 88 -> 96.8
+    const m = computeRiskMetrics([0.1, -0.2, 0.1]);
+    expect(m.maxDrawdownPercent).toBeCloseTo(-20, 6);
+    expect(m.observations).toBe(3);
+  });
+
+  it("should annualize volatility and net the risk-free rate in Sharpe", () => {
+    const returns = [0.01, -0.01, 0.02, -0.02];
+    const m = computeRiskMetrics(returns, 0.05);
+    const sd = Math.sqrt((0.0001 * 2 + 0.0004 * 2) / 3);
+    expect(m.volatilityPercent).toBeCloseTo(sd * Math.sqrt(365) * 100, 6);
+    expect(m.annualizedReturnPercent).toBeCloseTo(0, 9);
+    expect(m.sharpeRatio).toBeCloseTo(-0.05 / (sd * Math.sqrt(365)), 6);
+  });
+});
+
+describe("GET /reports/risk", () => {
+  const BTC = { type: "CRYPTO" as const, symbol: "BTC" };
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  const entry = (
+    type: "DEPOSIT" | "WITHDRAW",
+    asset: Asset,
+    amount: number,
+    day: string,
+  ): VaultEntry => ({
+    vault: "Main",
+    type,
+    asset,
+    amount,
+    usdValue: amount,
+    at: `${day}T09:00:00.000Z`,
+  });
+  const entries = [
+    entry("DEPOSIT", BTC, 1, "2025-02-20"),
+    entry("DEPOSIT", USD, 1000, "2025-02-20"),
+    entry("DEPOSIT", ETH, 2, "2025-02-21"),
+    entry("WITHDRAW", ETH, 2, "2025-02-25"),
+    // New money mid-window, bought at that day's price
+    entry("DEPOSIT", BTC, 1, "2025-03-03"),
+  ];
+  // None stored for March 4th; the 3rd carries over
+  const btcCloses: Record<string, number> = {
+    "2025-03-01": 100,
+    "2025-03-02": 110,
+    "2025-03-03": 88,
+    "2025-03-05": 96.8,
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date("2025-03-05T12:00:00.000Z"));
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAll: () => [{ name: "Main", status: "ACTIVE" }],
+        findAllEntries: () => entries,
+      },
+      priceCacheRepository: {
+        getRatesInRange: (asset: Asset) =>
+          asset.symbol === "BTC"
+            ? Object.entries(btcCloses).map(([day, rateUSD]) => ({
+                asset,
+                rateUSD,
+                timestamp: `${day}T00:00:00.000Z`,
+              }))
+            : [],
+        getLatestRate: (asset: Asset) =>
+          asset.symbol === "BTC" ? { asset, rateUSD: 100 } : undefined,
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: Asset) => ({
+          asset,
+          rateUSD: 1,
+          timestamp: new Date().toISOString(),
+          source: "FIXED",
+        }),
+      },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  const get = async (query: string) => {
+    const { reportsRouter } = await import("../src/handlers/reports.handler");
+    const app = express();
+    app.use(express.json());
+    app.use("/api", reportsRouter);
+    return request(app).get(`/api/reports/risk?${query}`);
+  };
+
+  it("measures each held asset on its price returns", async () => {
+    const res = await get("days=4&risk_free_rate=5");
+
+    expect(res.status).toBe(200);
+    expect(res.body).toMatchObject({
+      start: "2025-03-01",
+      end: "2025-03-05",
+      days: 4,
+      risk_free_rate_percent: 5,
+    });
+    // ETH was sold before the window; the largest holding comes first
+    expect(Object.keys(res.body.by_asset)).toEqual(["USD", "BTC"]);
+
+    const btc = computeRiskMetrics([0.1, -0.2, 0, 0.1], 0.05);
+    expect(res.body.by_asset.BTC).toMatchObject({
+      observations: 4,
+      current_value_usd: 193.6,
+      max_drawdown_percent: expect.closeTo(-20, 6),
+      volatility_percent: expect.closeTo(btc.volatilityPercent, 6),
+      sharpe_ratio: expect.closeTo(btc.sharpeRatio, 6),
+    });
+    expect(res.body.by_asset.USD).toMatchObject({
+      current_value_usd: 1000,
+      volatility_percent: 0,
+      sharpe_ratio: 0,
+    });
+  });
+
+  it("weights portfolio returns so deposits are not gains", async () => {
+    const res = await get("days=4");
+
+    // Each day's P&L on the units held the day before, over their value
+    const returns = [10 / 1100, -22 / 1110, 0, 17.6 / 1176];
+    const expected = computeRiskMetrics(returns);
+    expect(res.body.portfolio).toMatchObject({
+      observations: 4,
+      annualized_return_percent: expect.closeTo(
+        expected.annualizedReturnPercent,
+        6,
+      ),
+      volatility_percent: expect.closeTo(expected.volatilityPercent, 6),
+      max_drawdown_percent: expect.closeTo(expected.maxDrawdownPercent, 6),
+    });
+  });
+
+  it("clamps the window to at least two days", async () => {
+    const res = await get("days=1");
+    expect(res.body).toMatchObject({ start: "2025-03-03", days: 2 });
+    expect(res.body.by_asset.BTC.observations).toBe(2);
+  });
+});
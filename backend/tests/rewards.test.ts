@@ -0,0 +1,120 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Reward auto-valuation
+ *
+ * - Rewards without a price are valued at the reward date's price
+ * - Older fallback prices are flagged stale and revalued later
+ * - Unpriced assets need an explicit price
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Reward valuation", () => {
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  let txs: Transaction[] = [];
+  let providerUp = true;
+
+  beforeEach(() => {
+    vi.resetModules();
+    txs = [];
+    providerUp = true;
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => txs,
+        create: (t: Transaction) => {
+          txs.push(t);
+          return t;
+        },
+        update: (id: string, updates: Partial<Transaction>) => {
+          const t = txs.find((x) => x.id === id);
+          if (t) Object.assign(t, updates);
+          return t;
+        },
+      },
+      vaultRepository: {},
+      settingsRepository: { getDefaultSpendingVaultName: () => "Spend" },
+      borrowingRepository: {},
+      adminRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({ vaultService: {} }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: any, at: string) => {
+          if (asset.symbol === "NEWTOKEN") {
+            return { asset, rateUSD: 1, timestamp: at, source: "FIXED" };
+          }
+          return providerUp
+            ? { asset, rateUSD: 2000, timestamp: at, source: "COINGECKO" }
+            : {
+                asset,
+                rateUSD: 1800,
+                timestamp: "2025-02-20T00:00:00.000Z",
+                source: "COINGECKO",
+                stale: true,
+              };
+        },
+      },
+    }));
+  });
+
+  it("values a reward at the reward date's price", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const tx = await transactionService.createRewardTransaction({
+      asset: ETH,
+      amount: 0.5,
+      at: "2025-03-01T00:00:00.000Z",
+      account: "Staking",
+    });
+
+    expect(tx).toMatchObject({ type: "INCOME", category: "reward" });
+    expect(tx.usdAmount).toBe(1000);
+    expect(tx.rate.stale).toBeUndefined();
+  });
+
+  it("flags stale prices and revalues them later", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    providerUp = false;
+    const tx = await transactionService.createRewardTransaction({
+      asset: ETH,
+      amount: 0.5,
+      at: "2025-03-01T00:00:00.000Z",
+    });
+    expect(tx.rate.stale).toBe(true);
+    expect(tx.usdAmount).toBe(900);
+
+    providerUp = true;
+    const result = await transactionService.revalueRewards();
+    expect(result.revalued).toHaveLength(1);
+    expect(result.stillStale).toHaveLength(0);
+    expect(txs[0].usdAmount).toBe(1000);
+    expect(txs[0].rate.stale).toBeUndefined();
+  });
+
+  it("requires a price for assets no provider knows", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    const newToken = { type: "CRYPTO" as const, symbol: "NEWTOKEN" };
+    await expect(
+      transactionService.createRewardTransaction({
+        asset: newToken,
+        amount: 10,
+        at: "2025-03-01T00:00:00.000Z",
+      }),
+    ).rejects.toThrow(/price_local/);
+
+    const tx = await transactionService.createRewardTransaction({
+      asset: newToken,
+      amount: 10,
+      at: "2025-03-01T00:00:00.000Z",
+      priceLocal: 0.25,
+    });
+    expect(tx.usdAmount).toBe(2.5);
+  });
+});
@@ -0,0 +1,217 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Transaction rules
+ *
+ * - A rule applies when all its conditions match; text ignores case
+ * - Rules run by priority and see the changes of earlier rules
+ * - Every new transaction goes through the rules, except restores
+ * - Applying to existing transactions reports the changes; a dry run
+ *   writes nothing
+ */
+
+type Transaction = import("../src/types").Transaction;
+type TransactionRule = import("../src/types").TransactionRule;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+
+const tx = (id: string, counterparty: string, amount: number) =>
+  ({
+    id,
+    type: "EXPENSE",
+    asset: usd,
+    amount,
+    account: "Credit Card",
+    counterparty,
+    createdAt: "2025-03-01T00:00:00.000Z",
+    rate: { asset: usd, rateUSD: 1, timestamp: "2025-03-01T00:00:00.000Z" },
+    usdAmount: amount,
+  }) as Transaction;
+
+const rule = (
+  id: string,
+  body: Partial<TransactionRule>,
+): TransactionRule => ({
+  id,
+  name: id,
+  conditions: [],
+  actions: [],
+  priority: 0,
+  enabled: true,
+  createdAt: `2025-01-0${id.length}T00:00:00.000Z`,
+  ...body,
+});
+
+const rules = [
+  rule("coffee", {
+    priority: 10,
+    conditions: [
+      { field: "counterparty", op: "equals", value: "starbucks" },
+      { field: "amount", op: "lt", value: 10 },
+    ],
+    actions: [
+      { type: "add_tag", value: "Coffee" },
+      { type: "set_category", value: "Food & Drink" },
+    ],
+  }),
+  // Runs first and feeds the coffee rule its clean name
+  rule("name", {
+    conditions: [{ field: "counterparty", op: "contains", value: "STARBUCKS" }],
+    actions: [{ type: "set_counterparty", value: "Starbucks" }],
+  }),
+  rule("payoff", {
+    conditions: [{ field: "note", op: "starts_with", value: "card payment" }],
+    actions: [{ type: "set_internal_flow", value: true }],
+  }),
+];
+
+describe("Rule engine", () => {
+  it("chains rules by priority", async () => {
+    const { applyRules } = await import("../src/utils/transaction-rules.util");
+
+    const t = tx("a", "STARBUCKS #1234 HCMC", 4.5);
+    expect(applyRules(t, rules)).toEqual({
+      ruleIds: ["name", "coffee"],
+      changes: {
+        counterparty: "Starbucks",
+        tags: ["Coffee"],
+        category: "Food & Drink",
+      },
+    });
+    // Applying again changes nothing
+    expect(applyRules(t, rules).changes).toEqual({});
+
+    const big = tx("b", "Starbucks Reserve", 25);
+    expect(applyRules(big, rules).ruleIds).toEqual(["name"]);
+    const off = tx("c", "STARBUCKS", 4);
+    expect(
+      applyRules(off, [{ ...rules[0], enabled: false }, rules[1]]).ruleIds,
+    ).toEqual(["name"]);
+  });
+});
+
+describe("Transaction rules on create (JSON repository)", () => {
+  let store: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { transactions: [] };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+  });
+
+  it("applies to new transactions but not restores", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { withTransactionRules } = await import(
+      "../src/repositories/transaction-rules.repository"
+    );
+    const { withoutRules } = await import(
+      "../src/utils/transaction-rules.util"
+    );
+    const repo = withTransactionRules(
+      new TransactionRepositoryJson(),
+      () => rules,
+    );
+
+    const built = tx("a", "Starbucks", 4.5);
+    repo.create(built);
+    // The caller's object carries the result too
+    expect(built.tags).toEqual(["Coffee"]);
+    repo.createMany([
+      { ...tx("b", "Landlord", 900), note: "Card payment March" },
+    ]);
+    withoutRules(() => repo.create(tx("c", "Starbucks", 3)));
+
+    expect(
+      store.transactions.map((t: Transaction) => [
+        t.id,
+        t.tags,
+        t.internalFlow,
+      ]),
+    ).toEqual([
+      ["a", ["Coffee"], undefined],
+      ["b", undefined, true],
+      ["c", undefined, undefined],
+    ]);
+  });
+});
+
+describe("Rule Service", () => {
+  let ledger: Transaction[] = [];
+  let stored: TransactionRule[] = [];
+  const update = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    ledger = [
+      tx("a", "STARBUCKS #1", 4),
+      tx("b", "Starbucks", 4),
+      tx("c", "Grab", 4),
+    ];
+    ledger[1].tags = ["Coffee"];
+    ledger[1].category = "Food & Drink";
+    stored = [];
+    update.mockReset();
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => ledger, update },
+      settingsRepository: {
+        getTransactionRules: () => stored,
+        setTransactionRules: (r: TransactionRule[]) => {
+          stored = r;
+        },
+      },
+    }));
+  });
+
+  it("previews and applies rules to existing transactions", async () => {
+    const { ruleService } = await import("../src/services/rule.service");
+    const created = ruleService.create({
+      name: "Coffee",
+      conditions: rules[0].conditions,
+      actions: rules[0].actions,
+      priority: 10,
+      enabled: true,
+    });
+    ruleService.create({ ...rules[1], enabled: false });
+
+    const dry = ruleService.applyToExisting({ dryRun: true });
+    expect(dry.scanned).toBe(3);
+    expect(dry.changed).toEqual([]);
+    expect(update).not.toHaveBeenCalled();
+
+    // A disabled rule runs when picked
+    const r = ruleService.applyToExisting({
+      dryRun: false,
+      ruleIds: [stored[1].id, created.id],
+    });
+    expect(r.changed).toEqual([
+      {
+        transactionId: "a",
+        ruleIds: [stored[1].id, created.id],
+        before: {
+          counterparty: "STARBUCKS #1",
+          tags: undefined,
+          category: undefined,
+        },
+        changes: {
+          counterparty: "Starbucks",
+          tags: ["Coffee"],
+          category: "Food & Drink",
+        },
+      },
+    ]);
+    expect(update).toHaveBeenCalledWith("a", r.changed[0].changes);
+    // Ledger rows were not touched by the preview
+    expect(ledger[0].counterparty).toBe("STARBUCKS #1");
+
+    expect(() =>
+      ruleService.applyToExisting({ dryRun: true, ruleIds: ["nope"] }),
+    ).toThrow(/not found/);
+  });
+});
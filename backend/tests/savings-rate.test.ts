@@ -0,0 +1,126 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Savings rate report
+ *
+ * - Savings rate is (income - spending) / income per month
+ * - Income is split into spending, investing (cash vault -> other vault),
+ *   debt repayment and the cash left over
+ */
+
+const tx = (type: string, createdAt: string, usdAmount: number, extra = {}) =>
+  ({ id: `${type}-${createdAt}`, type, createdAt, usdAmount, ...extra }) as any;
+
+describe("Savings Rate Service", () => {
+  beforeEach(() => {
+    vi.resetModules();
+    const txs = [
+      tx("INCOME", "2025-01-05T00:00:00Z", 4000),
+      tx("EXPENSE", "2025-01-10T00:00:00Z", 2500),
+      // refund of a work expense: not income, lowers spending
+      tx("INCOME", "2025-01-20T00:00:00Z", 100, { reimbursesId: "x" }),
+      tx("REPAY", "2025-01-25T00:00:00Z", 200, { direction: "BORROW" }),
+      tx("REPAY", "2025-01-26T00:00:00Z", 300, { direction: "LOAN" }),
+      tx("EXPENSE", "2025-02-10T00:00:00Z", 500),
+      tx("EXPENSE", "2024-12-31T23:00:00Z", 999),
+    ];
+    const entries: Record<string, any[]> = {
+      Spend: [
+        {
+          vault: "Spend",
+          type: "WITHDRAW",
+          usdValue: 1000,
+          at: "2025-01-15T00:00:00Z",
+          account: "Stocks",
+        },
+        {
+          vault: "Spend",
+          type: "WITHDRAW",
+          usdValue: 50,
+          at: "2025-01-16T00:00:00Z",
+          account: "Borrowings",
+        },
+        {
+          vault: "Spend",
+          type: "DEPOSIT",
+          usdValue: 300,
+          at: "2025-02-01T00:00:00Z",
+          account: "Stocks",
+        },
+      ],
+      Income: [],
+    };
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => txs },
+      vaultRepository: {
+        findAllEntries: (name: string) => entries[name] || [],
+        findByName: (name: string) => ({ name }),
+      },
+      settingsRepository: {
+        getDefaultSpendingVaultName: () => "Spend",
+        getDefaultIncomeVaultName: () => "Income",
+        getBorrowingSettings: () => ({ name: "Borrowings" }),
+      },
+    }));
+  });
+
+  it("computes the savings rate and allocation per month", async () => {
+    const { savingsRateService } = await import(
+      "../src/services/savings-rate.service"
+    );
+
+    const report = savingsRateService.report(
+      { start: "2025-01", end: "2025-02" },
+      24000,
+    );
+
+    expect(report.months.map((m) => m.month)).toEqual(["2025-01", "2025-02"]);
+    const [jan, feb] = report.months;
+    expect(jan).toMatchObject({
+      income_usd: 4000,
+      spending_usd: 2400,
+      investing_usd: 1000,
+      debt_repayment_usd: 200,
+      cash_buildup_usd: 400,
+      savings_usd: 1600,
+      savings_rate: 40,
+      allocation: {
+        spending_pct: 60,
+        investing_pct: 25,
+        debt_repayment_pct: 5,
+        cash_buildup_pct: 10,
+      },
+    });
+    expect(jan.income_vnd).toBe(96000000);
+    // no income: no rate; money moved back from investments
+    expect(feb).toMatchObject({
+      spending_usd: 500,
+      investing_usd: -300,
+      savings_rate: null,
+    });
+    expect(report.totals).toMatchObject({
+      income_usd: 4000,
+      spending_usd: 2900,
+      savings_usd: 1100,
+    });
+  });
+
+  it("defaults to the last 12 months and validates the range", async () => {
+    const { savingsRateService } = await import(
+      "../src/services/savings-rate.service"
+    );
+
+    const report = savingsRateService.report(
+      {},
+      24000,
+      new Date("2025-03-10T00:00:00Z"),
+    );
+    expect(report.start).toBe("2024-04");
+    expect(report.end).toBe("2025-03");
+    expect(report.months).toHaveLength(12);
+
+    expect(() =>
+      savingsRateService.report({ start: "2025-03", end: "2025-01" }, 1),
+    ).toThrow(/after/);
+  });
+});
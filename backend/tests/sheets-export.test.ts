@@ -0,0 +1,155 @@
+import crypto from "crypto";
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Google Sheets export
+ *
+ * - The service account key is stored encrypted and never in the clear
+ * - A push signs a JWT, then clears and rewrites both tabs
+ * - Scheduled runs move past now and keep the failure on the export
+ */
+
+describe("Sheets Export Service", () => {
+  const { privateKey, publicKey } = crypto.generateKeyPairSync("rsa", {
+    modulusLength: 2048,
+    privateKeyEncoding: { type: "pkcs8", format: "pem" },
+    publicKeyEncoding: { type: "spki", format: "pem" },
+  });
+  const credentials = {
+    type: "service_account",
+    client_email: "export@nami.iam.gserviceaccount.com",
+    private_key: privateKey,
+  };
+  const settings: Record<string, any> = {};
+  const get = vi.fn();
+  const post = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    process.env.SETTINGS_ENCRYPTION_KEY = "test-passphrase";
+    delete settings.sheetsExport;
+    get.mockReset().mockImplementation(async (url: string) => ({
+      data: url.endsWith("/reports/holdings")
+        ? [
+            {
+              asset: "BTC",
+              account: "Cold",
+              institution: null,
+              quantity: 0.5,
+              value_usd: 30000,
+              value_vnd: 750000000,
+              percentage: 100,
+              last_updated: "2025-03-01T00:00:00.000Z",
+            },
+          ]
+        : {
+            months: [
+              {
+                month: "2025-03",
+                income_vnd: 100,
+                spending_vnd: 60,
+                savings_vnd: 40,
+                savings_rate: 40,
+              },
+            ],
+          },
+    }));
+    post.mockReset().mockImplementation(async (url: string) => ({
+      data: url.includes("oauth2") ? { access_token: "tok" } : {},
+    }));
+    vi.doMock("axios", () => ({ default: { get, post } }));
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getSheetsExport: () => settings.sheetsExport,
+        setSheetsExport: (e: any) => (settings.sheetsExport = e),
+      },
+    }));
+  });
+
+  afterEach(() => {
+    delete process.env.SETTINGS_ENCRYPTION_KEY;
+  });
+
+  const configure = async () => {
+    const { sheetsExportService } = await import(
+      "../src/services/sheets-export.service"
+    );
+    sheetsExportService.configure({
+      spreadsheetId: "sheet-1",
+      credentials,
+      holdingsSheet: "Holdings",
+      summarySheet: "Jim's months",
+      months: 3,
+      currency: "VND",
+      intervalHours: 24,
+      active: true,
+    });
+    return sheetsExportService;
+  };
+
+  it("encrypts the key and writes both tabs", async () => {
+    const svc = await configure();
+    expect(settings.sheetsExport.credentials).toMatch(/^enc:v1:/);
+    expect(JSON.stringify(settings.sheetsExport)).not.toContain("PRIVATE");
+
+    const run = await svc.push(new Date("2025-03-15T00:00:00.000Z"));
+
+    expect(run).toMatchObject({
+      status: "OK",
+      holdingsRows: 1,
+      summaryRows: 1,
+    });
+    const [, form] = post.mock.calls[0];
+    const jwt = new URLSearchParams(form).get("assertion")!.split(".");
+    const signed = crypto
+      .createVerify("RSA-SHA256")
+      .update(`${jwt[0]}.${jwt[1]}`)
+      .verify(publicKey, jwt[2], "base64url");
+    expect(signed).toBe(true);
+    expect(get.mock.calls[1][1].params).toEqual({
+      start: "2025-01",
+      end: "2025-03",
+    });
+
+    expect(post.mock.calls[1][1]).toEqual({
+      ranges: ["'Holdings'", "'Jim''s months'"],
+    });
+    const [url, body, opts] = post.mock.calls[2];
+    expect(url).toContain("/spreadsheets/sheet-1/values:batchUpdate");
+    expect(opts.headers.Authorization).toBe("Bearer tok");
+    expect(body.data[0].values[1]).toEqual([
+      "BTC",
+      "Cold",
+      "",
+      0.5,
+      750000000,
+      100,
+      "2025-03-01T00:00:00.000Z",
+    ]);
+    expect(body.data[1].values[1].slice(1)).toEqual([100, 60, 0, 0, 0, 40, 40]);
+  });
+
+  it("records failures and schedules the next run", async () => {
+    const svc = await configure();
+    settings.sheetsExport.nextRunAt = "2025-03-01T09:00:00.000Z";
+    post.mockImplementation(async (url: string) => {
+      if (url.includes("oauth2")) return { data: { access_token: "tok" } };
+      throw Object.assign(new Error("Request failed"), {
+        response: { data: { error: { message: "No edit access" } } },
+      });
+    });
+
+    const run = await svc.processDue(new Date("2025-03-03T10:00:00.000Z"));
+
+    expect(run).toMatchObject({ status: "FAILED", error: "No edit access" });
+    expect(settings.sheetsExport).toMatchObject({
+      lastStatus: "FAILED",
+      lastError: "No edit access",
+      nextRunAt: "2025-03-04T09:00:00.000Z",
+    });
+    // Not due again until then
+    expect(await svc.processDue(new Date("2025-03-03T11:00:00.000Z"))).toBe(
+      null,
+    );
+  });
+});
@@ -0,0 +1,200 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Soft delete of transactions
+ *
+ * - A deleted transaction disappears from every read, reports included
+ * - It can be restored until it is purged
+ * - Purging removes only transactions deleted long enough ago
+ * - A deleted transaction keeps its source ref, so a resync (recurring
+ *   catch-up, exchange or wallet sync) does not create it again
+ */
+
+describe("Transaction soft delete (JSON repository)", () => {
+  const usd = { type: "FIAT", symbol: "USD" };
+  let store: any;
+
+  beforeEach(() => {
+    vi.resetModules();
+    const tx = (id: string, createdAt: string) => ({
+      id,
+      type: "EXPENSE",
+      asset: usd,
+      amount: 10,
+      usdAmount: 10,
+      account: "Spend",
+      createdAt,
+    });
+    store = {
+      transactions: [
+        tx("a", "2025-01-01T00:00:00.000Z"),
+        tx("b", "2025-01-02T00:00:00.000Z"),
+        tx("c", "2025-01-03T00:00:00.000Z"),
+      ],
+    };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+  });
+
+  it("hides a deleted transaction until it is restored", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { ReportingRepositoryJson } = await import(
+      "../src/repositories/reporting.repository"
+    );
+    const repo = new TransactionRepositoryJson();
+    const reporting = new ReportingRepositoryJson();
+
+    expect(repo.softDelete("b", "2025-02-01T00:00:00.000Z")).toBe(true);
+    expect(repo.softDelete("b", "2025-02-02T00:00:00.000Z")).toBe(false);
+    expect(repo.findById("b")).toBeUndefined();
+    expect(repo.findAll().map((t) => t.id)).toEqual(["c", "a"]);
+    expect(repo.count()).toBe(2);
+    expect(repo.findSpending({ account: "Spend" })).toHaveLength(2);
+    expect(repo.update("b", { note: "edit" })).toBeUndefined();
+    expect(
+      reporting.findLedgerUntil("2025-12-31T00:00:00.000Z").map((t) => t.id),
+    ).toEqual(["a", "c"]);
+    expect(repo.findDeleted().map((t) => [t.id, t.deletedAt])).toEqual([
+      ["b", "2025-02-01T00:00:00.000Z"],
+    ]);
+
+    const restored = repo.restore("b");
+    expect(restored?.deletedAt).toBeUndefined();
+    expect(repo.findById("b")).toBeDefined();
+    expect(repo.restore("b")).toBeUndefined();
+  });
+
+  it("purges only transactions deleted before the cutoff", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const repo = new TransactionRepositoryJson();
+    repo.softDelete("a", "2025-01-10T00:00:00.000Z");
+    repo.softDelete("b", "2025-03-10T00:00:00.000Z");
+
+    expect(repo.purgeDeleted("2025-02-01T00:00:00.000Z")).toBe(1);
+    expect(store.transactions.map((t: any) => t.id)).toEqual(["b", "c"]);
+    expect(repo.restore("a")).toBeUndefined();
+    expect(repo.restore("b")?.id).toBe("b");
+  });
+});
+
+describe("Resync after a soft delete", () => {
+  const usd = { type: "FIAT", symbol: "USD" };
+  const ref = "recurring:rent:2025-02-01";
+  const createExpenseTransaction = vi.fn();
+  const entry = {
+    id: "rent",
+    name: "Rent",
+    type: "EXPENSE",
+    asset: usd,
+    amount: 500,
+    rule: "FREQ=MONTHLY;BYMONTHDAY=1",
+    startAt: "2025-01-01T00:00:00.000Z",
+    nextRunAt: "2025-02-01T00:00:00.000Z",
+    lastOccurrenceAt: "2025-01-01T00:00:00.000Z",
+    occurrences: 1,
+    confirm: false,
+    active: true,
+    createdAt: "2025-01-01T00:00:00.000Z",
+  };
+  // The February rent, created by an earlier run and deleted since
+  const deleted = {
+    id: "feb",
+    type: "EXPENSE",
+    asset: usd,
+    amount: 500,
+    usdAmount: 500,
+    account: "Spend",
+    createdAt: "2025-02-01T00:00:00.000Z",
+    sourceRef: ref,
+    deletedAt: "2025-02-03T00:00:00.000Z",
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    createExpenseTransaction.mockReset();
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: { createExpenseTransaction },
+    }));
+    vi.doMock("../src/services/notification.service", () => ({
+      notificationService: { send: vi.fn() },
+    }));
+  });
+
+  // Run the schedule over a repository and check February is not redone
+  const resync = async (repo: string) => {
+    vi.doMock("../src/repositories", async () => {
+      const m = await import("../src/repositories/transaction.repository");
+      return {
+        recurringRepository: {
+          findDue: () => [entry],
+          update: (_id: string, u: any) => ({ ...entry, ...u }),
+        },
+        transactionRepository: new (m as any)[repo](),
+      };
+    });
+    const { recurringTransactionService } = await import(
+      "../src/services/recurring.service"
+    );
+    const { transactionRepository } = await import("../src/repositories");
+
+    expect(transactionRepository.findBySourceRef(ref)).toBeUndefined();
+    expect(
+      transactionRepository.findBySourceRef(ref, { includeDeleted: true })?.id,
+    ).toBe("feb");
+
+    const [run] = await recurringTransactionService.processDue(
+      new Date("2025-02-15T00:00:00Z"),
+    );
+    expect(run.error).toBeUndefined();
+    expect(createExpenseTransaction).not.toHaveBeenCalled();
+    expect(run.recurring.nextRunAt).toBe("2025-03-01T00:00:00.000Z");
+  };
+
+  it("does not recreate a deleted occurrence (JSON)", async () => {
+    const store = { transactions: [deleted] };
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: vi.fn(),
+    }));
+    await resync("TransactionRepositoryJson");
+  });
+
+  it("does not recreate a deleted occurrence (database)", async () => {
+    const row = {
+      id: deleted.id,
+      type: deleted.type,
+      asset_type: usd.type,
+      asset_symbol: usd.symbol,
+      amount: deleted.amount,
+      created_at: deleted.createdAt,
+      account: deleted.account,
+      source_ref: ref,
+      rate: JSON.stringify({ asset: usd, rateUSD: 1 }),
+      usd_amount: deleted.usdAmount,
+      deleted_at: deleted.deletedAt,
+    };
+    // The row only comes back to queries that do not skip deleted rows,
+    // as source_ref is UNIQUE and a second insert would fail
+    vi.doMock("../src/database/connection", () => ({
+      getConnection: () => ({}),
+      prepareCached: (sql: string) => ({
+        get: (sourceRef: string) =>
+          sourceRef === ref && !sql.includes("deleted_at IS NULL")
+            ? row
+            : undefined,
+        run: () => {
+          throw new Error("UNIQUE constraint failed: transactions.source_ref");
+        },
+      }),
+    }));
+    await resync("TransactionRepositoryDb");
+  });
+});
@@ -0,0 +1,114 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Spending exclusion rules
+ *
+ * - Both backends match the same way, case-insensitively beyond ASCII
+ *   (Vietnamese names) and ignoring surrounding whitespace
+ * - A blank value excludes nothing
+ */
+
+type Transaction = import("../src/types").Transaction;
+type SpendingExclusionRule = import("../src/types").SpendingExclusionRule;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+const expense = (id: string, extra: Partial<Transaction> = {}) =>
+  ({
+    id,
+    type: "EXPENSE",
+    asset: usd,
+    amount: 10,
+    usdAmount: 10,
+    account: "Spend",
+    createdAt: `2025-01-0${id.length}T00:00:00.000Z`,
+    rate: { asset: usd, rateUSD: 1, timestamp: "2025-01-01T00:00:00.000Z" },
+    ...extra,
+  }) as Transaction;
+
+const transactions = [
+  expense("a", { counterparty: "Điện lực EVN" }),
+  expense("bb", { tags: ["Hoàn Tiền "] }),
+  expense("ccc", { category: "Food", note: "Trưa với ĐỒNG NGHIỆP" }),
+  expense("dddd", { account: "Other" }),
+];
+
+const rules: Array<[string, SpendingExclusionRule[], string[]]> = [
+  ["counterparty", [{ field: "counterparty", value: "ĐIỆN LỰC EVN" }], ["a"]],
+  ["tag", [{ field: "tag", value: "hoàn tiền" }], ["bb"]],
+  ["note", [{ field: "note", value: "đồng nghiệp" }], ["ccc"]],
+  ["blank", [{ field: "note", value: "   " }], []],
+  ["blank tag", [{ field: "tag", value: " " }], []],
+];
+
+const spendIds = (found: Transaction[]) =>
+  ["a", "bb", "ccc"].filter((id) => !found.some((t) => t.id === id));
+
+describe("Spending exclusions (JSON repository)", () => {
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => ({ transactions }),
+      writeStore: vi.fn(),
+    }));
+  });
+
+  it.each(rules)("excludes by %s", async (_name, exclusions, excluded) => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const found = new TransactionRepositoryJson().findSpending({
+      account: "Spend",
+      exclusions,
+    });
+    expect(spendIds(found)).toEqual(excluded);
+    expect(found.some((t) => t.id === "dddd")).toBe(false);
+  });
+});
+
+describe("Spending exclusions (database repository)", () => {
+  let queried: any[][] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    queried = [];
+    // Rows the query returns: live Spend expenses, exclusions not applied
+    const rows = transactions
+      .filter((t) => t.account === "Spend")
+      .map((t) => ({
+        id: t.id,
+        type: t.type,
+        asset_type: t.asset.type,
+        asset_symbol: t.asset.symbol,
+        amount: t.amount,
+        created_at: t.createdAt,
+        account: t.account,
+        note: t.note,
+        category: t.category,
+        tags: t.tags ? JSON.stringify(t.tags) : null,
+        counterparty: t.counterparty,
+        rate: JSON.stringify(t.rate),
+        usd_amount: t.usdAmount,
+      }));
+    vi.doMock("../src/database/connection", () => ({
+      getConnection: () => ({}),
+      prepareCached: () => ({
+        all: (...params: any[]) => {
+          queried.push(params);
+          return rows;
+        },
+      }),
+    }));
+  });
+
+  it.each(rules)("excludes by %s", async (_name, exclusions, excluded) => {
+    const { TransactionRepositoryDb } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const found = new TransactionRepositoryDb().findSpending({
+      account: "Spend",
+      exclusions,
+    });
+    expect(spendIds(found)).toEqual(excluded);
+    expect(queried).toEqual([["Spend", "Spend"]]);
+  });
+});
@@ -0,0 +1,243 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Date-effective renames
+ *
+ * - Writes record the admin account and asset ids
+ * - Reads report old and new rows under the current name, without
+ *   rewriting them; lookups by account cover every name it went by
+ * - The name in effect at a date follows the rename history
+ * - Renames are rejected onto a taken name, into the future, or before
+ *   the last rename
+ * - Names of a merged entry follow it into the surviving one
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+const usd = { type: "FIAT" as const, symbol: "USD" };
+
+const tx = (id: string, account: string, amount: number, createdAt: string) =>
+  ({
+    id,
+    type: "INCOME",
+    asset: usd,
+    amount,
+    account,
+    createdAt,
+    rate: { asset: usd, rateUSD: 1, timestamp: createdAt },
+    usdAmount: amount,
+  }) as Transaction;
+
+describe("Stable names (JSON repository)", () => {
+  let store: any;
+  const settings = new Map<string, string>();
+  const accounts = [{ id: 3, name: "Techcombank" }];
+  const admin: any = {
+    findAllAccounts: () => accounts,
+    findAllAssets: () => [{ id: 1, symbol: "USD" }],
+  };
+  const deps = () => ({
+    admin,
+    settings: {
+      getSetting: (k: string) => settings.get(k),
+      setSetting: (k: string, v: string) => settings.set(k, v),
+    } as any,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    store = { transactions: [] };
+    settings.clear();
+    accounts[0].name = "Techcombank";
+    vi.doMock("../src/repositories/base.repository", () => ({
+      readStore: () => store,
+      writeStore: (s: any) => {
+        store = s;
+      },
+    }));
+  });
+
+  it("reads renamed rows under the current name", async () => {
+    const { TransactionRepositoryJson } = await import(
+      "../src/repositories/transaction.repository"
+    );
+    const { withStableNames, NAME_HISTORY_KEY, EPOCH } = await import(
+      "../src/repositories/stable-names.repository"
+    );
+    const repo = withStableNames(new TransactionRepositoryJson(), deps);
+
+    repo.create(tx("old", "Techcombank", 100, "2025-01-10T00:00:00.000Z"));
+    expect(store.transactions[0]).toMatchObject({ accountId: 3, assetId: 1 });
+
+    settings.set(
+      NAME_HISTORY_KEY,
+      JSON.stringify([
+        {
+          kind: "account",
+          entityId: 3,
+          name: "Techcombank",
+          effectiveFrom: EPOCH,
+        },
+        {
+          kind: "account",
+          entityId: 3,
+          name: "TCB",
+          effectiveFrom: "2025-03-01T00:00:00.000Z",
+        },
+      ]),
+    );
+    accounts[0].name = "TCB";
+    repo.create(tx("new", "TCB", 50, "2025-03-05T00:00:00.000Z"));
+
+    // Stored as written; reported under the current name
+    expect(store.transactions.map((t: Transaction) => t.account)).toEqual([
+      "Techcombank",
+      "TCB",
+    ]);
+    expect(repo.findAll().map((t) => [t.id, t.account])).toEqual([
+      ["new", "TCB"],
+      ["old", "TCB"],
+    ]);
+    expect(repo.findByAccount("TCB").map((t) => t.id)).toEqual(["new", "old"]);
+    expect(repo.findByAccount("Techcombank").map((t) => t.id)).toEqual([
+      "new",
+      "old",
+    ]);
+  });
+
+  it("resolves the name in effect at a date", async () => {
+    const { NameResolver, EPOCH } = await import(
+      "../src/repositories/stable-names.repository"
+    );
+    const names = new NameResolver(
+      [
+        { id: 3, name: "TCB" },
+        { id: 4, name: "Techcombank" },
+      ],
+      [],
+      [
+        {
+          kind: "account",
+          entityId: 3,
+          name: "Techcombank",
+          effectiveFrom: EPOCH,
+          createdAt: EPOCH,
+        },
+        {
+          kind: "account",
+          entityId: 3,
+          name: "TCB",
+          effectiveFrom: "2025-03-01T00:00:00.000Z",
+          createdAt: EPOCH,
+        },
+      ],
+    );
+
+    expect(names.nameAt("account", 3, "2025-02-28T00:00:00.000Z")).toBe(
+      "Techcombank",
+    );
+    expect(names.nameAt("account", 3, "2025-03-01T00:00:00.000Z")).toBe("TCB");
+    // The name was reused by account 4 after the rename
+    expect(
+      names.idOf("account", "Techcombank", "2025-01-01T00:00:00.000Z"),
+    ).toBe(3);
+    expect(
+      names.idOf("account", "Techcombank", "2025-06-01T00:00:00.000Z"),
+    ).toBe(4);
+  });
+});
+
+describe("Rename Service", () => {
+  const settings = new Map<string, string>();
+  let accounts: Array<{ id: number; name: string }> = [];
+  const updateAccount = vi.fn((id: number, u: { name: string }) => {
+    const a = accounts.find((x) => x.id === id)!;
+    a.name = u.name;
+    return a;
+  });
+  const now = new Date("2025-06-01T00:00:00.000Z");
+
+  beforeEach(() => {
+    vi.resetModules();
+    settings.clear();
+    accounts = [
+      { id: 3, name: "Techcombank" },
+      { id: 5, name: "Cash" },
+    ];
+    updateAccount.mockClear();
+    vi.doMock("../src/repositories", () => ({
+      adminRepository: {
+        findAccountById: (id: number) => accounts.find((a) => a.id === id),
+        findAllAccounts: () => accounts,
+        findAllAssets: () => [],
+        updateAccount,
+      },
+      settingsRepository: {
+        getSetting: (k: string) => settings.get(k),
+        setSetting: (k: string, v: string) => settings.set(k, v),
+      },
+      transactionRepository: { findAll: () => [] },
+      accountBalanceRepository: { replaceAll: vi.fn() },
+    }));
+  });
+
+  it("keeps every name with the date it took effect", async () => {
+    const { renameService } = await import(
+      "../src/services/rename.service"
+    );
+
+    renameService.rename("account", 3, "TCB", "2025-03-01", now);
+    expect(updateAccount).toHaveBeenCalledWith(3, { name: "TCB" });
+    expect(
+      renameService.history("account", 3).map((h) => [h.name, h.effectiveFrom]),
+    ).toEqual([
+      ["Techcombank", "1970-01-01T00:00:00.000Z"],
+      ["TCB", "2025-03-01T00:00:00.000Z"],
+    ]);
+    expect(renameService.nameAt("account", 3, "2025-02-01")).toBe(
+      "Techcombank",
+    );
+    expect(renameService.nameAt("account", 3, "2025-04-01")).toBe("TCB");
+  });
+
+  it("rejects taken names and out-of-order dates", async () => {
+    const { renameService } = await import(
+      "../src/services/rename.service"
+    );
+
+    expect(() => renameService.rename("account", 3, "cash")).toThrow(
+      /merge/,
+    );
+    expect(() =>
+      renameService.rename("account", 3, "TCB", "2030-01-01", now),
+    ).toThrow(/future/);
+    renameService.rename("account", 3, "TCB", "2025-03-01", now);
+    expect(() =>
+      renameService.rename("account", 3, "TCB Bank", "2025-02-01", now),
+    ).toThrow(/after the last rename/);
+    expect(() => renameService.rename("account", 9, "X")).toThrow(
+      /not found/,
+    );
+  });
+
+  it("points the names of a merged account at the surviving one", async () => {
+    const { renameService } = await import(
+      "../src/services/rename.service"
+    );
+    const { NameResolver, readNameHistory } = await import(
+      "../src/repositories/stable-names.repository"
+    );
+
+    renameService.rename("account", 3, "TCB", "2025-03-01", now);
+    renameService.reassign("account", 3, 5);
+    accounts = accounts.filter((a) => a.id !== 3);
+
+    const names = new NameResolver(
+      accounts,
+      [],
+      readNameHistory({ getSetting: (k: string) => settings.get(k) } as any),
+    );
+    expect(names.idOf("account", "Techcombank")).toBe(5);
+    expect(names.namesOf("account", 5)).toEqual(["Cash", "Techcombank", "TCB"]);
+  });
+});
@@ -0,0 +1,75 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Stablecoin peg monitor
+ *
+ * - Stablecoins are valued at $1 while on peg
+ * - Drifting past the threshold flags a depeg and values at market
+ * - Recovery returns valuation to parity
+ */
+
+describe("Stablecoin Service", () => {
+  const USDC = { type: "CRYPTO" as const, symbol: "USDC" };
+  const ETH = { type: "CRYPTO" as const, symbol: "ETH" };
+  let quotes: Record<string, number> = {};
+
+  beforeEach(() => {
+    vi.resetModules();
+    quotes = { USDT: 1.0004, USDC: 0.998 };
+
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: { getDepegThresholdPercent: () => 1 },
+      adminRepository: { findAllAssets: () => [] },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        fetchCurrentCryptoPrices: async (symbols: string[]) =>
+          Object.fromEntries(
+            symbols.filter((s) => s in quotes).map((s) => [s, quotes[s]]),
+          ),
+      },
+    }));
+  });
+
+  it("values stablecoins at parity while on peg", async () => {
+    const { stablecoinService } = await import(
+      "../src/services/stablecoin.service"
+    );
+    expect(stablecoinService.valuationRate(USDC, 0.998)).toBe(1);
+    expect(stablecoinService.valuationRate(ETH, 2000)).toBe(2000);
+
+    const statuses = await stablecoinService.checkPegs();
+    expect(statuses.map((s) => s.symbol).sort()).toEqual(["USDC", "USDT"]);
+    expect(statuses.every((s) => !s.depegged)).toBe(true);
+    expect(stablecoinService.valuationRate(USDC, 0.998)).toBe(1);
+  });
+
+  it("values at market while depegged, at parity after", async () => {
+    const { stablecoinService } = await import(
+      "../src/services/stablecoin.service"
+    );
+    quotes.USDC = 0.95;
+    await stablecoinService.checkPegs(new Date("2025-03-11T07:00:00Z"));
+    await stablecoinService.checkPegs(new Date("2025-03-11T08:00:00Z"));
+
+    const usdc = stablecoinService
+      .getStatuses()
+      .find((s) => s.symbol === "USDC")!;
+    expect(usdc.depegged).toBe(true);
+    expect(usdc.deviationPercent).toBeCloseTo(5, 9);
+    expect(usdc.depeggedSince).toBe("2025-03-11T07:00:00.000Z");
+    // The stored quote wins over the (cached) rate passed in
+    expect(stablecoinService.valuationRate(USDC, 1)).toBe(0.95);
+
+    quotes.USDC = 0.999;
+    await stablecoinService.checkPegs();
+    expect(stablecoinService.valuationRate(USDC, 0.95)).toBe(1);
+  });
+
+  it("uses the market rate when a coin hasn't been checked yet", async () => {
+    const { stablecoinService } = await import(
+      "../src/services/stablecoin.service"
+    );
+    expect(stablecoinService.valuationRate(USDC, 0.9)).toBe(0.9);
+  });
+});
@@ -0,0 +1,113 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Stake/unstake cycles
+ *
+ * - Withdrawals close deposits of the same asset oldest first (FIFO)
+ * - Holding days are weighted by the cost of each matched deposit
+ * - Reward distributions and USD entries are not cycles
+ */
+
+describe("Stake Service", () => {
+  const eth = { type: "CRYPTO", symbol: "ETH" };
+
+  beforeEach(() => {
+    vi.resetModules();
+
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAll: () => [{ name: "Staking" }, { name: "Cash" }],
+        findAllEntries: (name: string) =>
+          name === "Staking"
+            ? [
+                {
+                  vault: "Staking",
+                  type: "DEPOSIT",
+                  asset: eth,
+                  amount: 1,
+                  usdValue: 1500,
+                  at: "2025-01-01T00:00:00.000Z",
+                },
+                {
+                  vault: "Staking",
+                  type: "DEPOSIT",
+                  asset: eth,
+                  amount: 1,
+                  usdValue: 2000,
+                  at: "2025-02-01T00:00:00.000Z",
+                },
+                {
+                  vault: "Staking",
+                  type: "WITHDRAW",
+                  asset: eth,
+                  amount: 0.1,
+                  usdValue: 300,
+                  at: "2025-03-01T00:00:00.000Z",
+                  note: "Reward distribution",
+                },
+                {
+                  vault: "Staking",
+                  type: "WITHDRAW",
+                  asset: eth,
+                  amount: 1.5,
+                  usdValue: 3000,
+                  at: "2025-05-01T00:00:00.000Z",
+                },
+              ]
+            : [
+                {
+                  vault: "Cash",
+                  type: "DEPOSIT",
+                  asset: { type: "FIAT", symbol: "USD" },
+                  amount: 100,
+                  usdValue: 100,
+                  at: "2025-01-01T00:00:00.000Z",
+                },
+                {
+                  vault: "Cash",
+                  type: "WITHDRAW",
+                  asset: { type: "FIAT", symbol: "USD" },
+                  amount: 100,
+                  usdValue: 100,
+                  at: "2025-02-01T00:00:00.000Z",
+                },
+              ],
+      },
+      settingsRepository: {
+        getCostBasisSettings: () => ({ byAsset: {}, byVault: {} }),
+      },
+    }));
+  });
+
+  it("matches an unstake against the oldest stakes first", async () => {
+    const { stakeService } = await import("../src/services/stake.service");
+    const { cycles } = stakeService.getCycles();
+
+    expect(cycles).toHaveLength(1);
+    const c = cycles[0];
+    expect(c.stakes).toEqual([
+      { stakedAt: "2025-01-01T00:00:00.000Z", amount: 1, costUSD: 1500 },
+      { stakedAt: "2025-02-01T00:00:00.000Z", amount: 0.5, costUSD: 1000 },
+    ]);
+    expect(c.costUSD).toBe(2500);
+    expect(c.realizedUSD).toBe(500);
+    expect(c.roiPercent).toBeCloseTo(20);
+    // 120 days for 1500 of cost, 89 days for 1000
+    expect(c.holdingDays).toBeCloseTo(107.6);
+    expect(c.annualizedPercent).toBeCloseTo(67.84, 1);
+  });
+
+  it("aggregates by asset and vault and filters by unstake date", async () => {
+    const { stakeService } = await import("../src/services/stake.service");
+    const r = stakeService.getCycles({ asset: "eth" });
+
+    expect(r.byAsset.map((g) => g.key)).toEqual(["ETH"]);
+    expect(r.byVault[0]).toMatchObject({ key: "Staking", cycles: 1 });
+    expect(r.totals.realizedUSD).toBe(500);
+
+    expect(stakeService.getCycles({ end: "2025-04-30" }).cycles).toEqual([]);
+    expect(stakeService.getCycles({ end: "2025-05-01" }).cycles).toHaveLength(
+      1,
+    );
+  });
+});
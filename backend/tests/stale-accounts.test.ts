@@ -0,0 +1,148 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Stale accounts
+ *
+ * - Accounts with a balance and no transactions since the cutoff are
+ *   flagged, valued at today's prices; archived accounts are skipped
+ * - Dust is archived, anything larger consolidated into the most
+ *   recently used account holding the same asset
+ * - Unpriced or owing accounts are left for review
+ */
+
+describe("Stale accounts", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  const DOGE = { type: "CRYPTO" as const, symbol: "DOGE" };
+  const XYZ = { type: "CRYPTO" as const, symbol: "XYZ" };
+  const now = new Date("2025-07-15T00:00:00.000Z");
+
+  const tx = (account: string, day: string) => ({
+    id: `${account}-${day}`,
+    type: "INCOME",
+    account,
+    createdAt: `${day}T09:00:00.000Z`,
+  });
+  const balance = (account: string, asset: any, quantity: number) => ({
+    account,
+    asset,
+    quantity,
+    transactionCount: 1,
+  });
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => [
+          tx("Old Bank", "2024-03-02"),
+          tx("KuCoin", "2023-11-20"),
+          tx("Vietcombank", "2025-07-01"),
+          tx("Techcombank", "2025-05-01"),
+          tx("Airdrops", "2024-01-01"),
+          tx("Card", "2024-06-01"),
+          tx("Closed", "2023-01-01"),
+          tx("Binance", "2025-02-01"),
+        ],
+      },
+      adminRepository: {
+        findAllAccounts: () => [
+          { id: 4, name: "old bank", institution: "TCB", is_active: true },
+          { id: 9, name: "Closed", is_active: false },
+        ],
+      },
+    }));
+    vi.doMock("../src/services/account-balance.service", () => ({
+      accountBalanceService: {
+        getBalances: () => [
+          balance("Old Bank", VND, 30_000_000),
+          balance("KuCoin", DOGE, 30),
+          balance("Techcombank", VND, 1_000_000),
+          balance("Vietcombank", VND, 5_000_000),
+          balance("Airdrops", XYZ, 1000),
+          balance("Card", VND, -2_500_000),
+          balance("Closed", VND, 10_000),
+          balance("Binance", DOGE, 100),
+        ],
+      },
+    }));
+    const rates: Record<string, number> = { VND: 1 / 25000, DOGE: 0.15 };
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: { symbol: string }) => {
+          const rateUSD = rates[asset.symbol];
+          if (rateUSD === undefined) throw new Error("no price");
+          return { asset, rateUSD };
+        },
+      },
+    }));
+  });
+
+  const load = async () =>
+    (await import("../src/services/stale-account.service"))
+      .staleAccountService;
+
+  it("flags idle accounts that still hold something", async () => {
+    const service = await load();
+    const r = await service.report(6, 10, now);
+
+    expect(r.cutoff).toBe("2025-01-15T00:00:00.000Z");
+    expect(r.accounts.map((a) => a.account)).toEqual([
+      "Old Bank",
+      "KuCoin",
+      "Airdrops",
+      "Card",
+    ]);
+    const [bank, kucoin] = r.accounts;
+    expect(bank).toMatchObject({
+      accountId: 4,
+      institution: "TCB",
+      idleDays: 499,
+      valueUSD: 1200,
+      action: "CONSOLIDATE",
+      // Vietcombank was used more recently than Techcombank
+      consolidateInto: "Vietcombank",
+    });
+    expect(kucoin).toMatchObject({
+      valueUSD: expect.closeTo(4.5, 9),
+      action: "ARCHIVE",
+      reason: "Dust worth $4.50; write it off and archive",
+    });
+    expect(r.totalValueUSD).toBeCloseTo(1200 + 4.5 - 100, 9);
+  });
+
+  it("leaves unpriced and owing accounts for review", async () => {
+    const service = await load();
+    const { accounts } = await service.report(6, 10, now);
+    const airdrops = accounts.find((a) => a.account === "Airdrops")!;
+    const card = accounts.find((a) => a.account === "Card")!;
+
+    expect(airdrops).toMatchObject({
+      valueUSD: 0,
+      unpriced: ["CRYPTO:XYZ"],
+      action: "REVIEW",
+    });
+    expect(airdrops.holdings[0].valueUSD).toBeUndefined();
+    expect(card.action).toBe("REVIEW");
+    expect(card.reason).toMatch(/Owes \$100\.00/);
+  });
+
+  it("takes the idle period and dust threshold from the caller", async () => {
+    const { staleAccountService, staleMonths, dustThreshold } = await import(
+      "../src/services/stale-account.service"
+    );
+
+    // Three months back, Binance (last used in February) is stale too
+    const r = await staleAccountService.report(3, 2, now);
+    const binance = r.accounts.find((a) => a.account === "Binance")!;
+    expect(binance.action).toBe("CONSOLIDATE");
+    expect(binance.consolidateInto).toBeUndefined();
+    // Under the lower threshold KuCoin's DOGE is no longer dust
+    const kucoin = r.accounts.find((a) => a.account === "KuCoin")!;
+    expect(kucoin.action).toBe("CONSOLIDATE");
+
+    expect(staleMonths(undefined)).toBe(6);
+    expect(() => staleMonths("0")).toThrow(/months/);
+    expect(dustThreshold("")).toBe(10);
+    expect(() => dustThreshold("-1")).toThrow(/dust_usd/);
+  });
+});
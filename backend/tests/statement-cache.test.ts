@@ -0,0 +1,73 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Prepared statement cache
+ *
+ * - The same SQL is compiled once and the statement reused
+ * - The least recently used statement goes once the cache is full
+ * - Closing the connection drops its statements
+ */
+
+describe("Statement cache", () => {
+  let prepared: string[] = [];
+
+  beforeEach(() => {
+    vi.resetModules();
+    prepared = [];
+    vi.doMock("fs", () => ({
+      default: { existsSync: () => true, mkdirSync: vi.fn() },
+    }));
+    vi.doMock("better-sqlite3", () => ({
+      default: class {
+        pragma() {}
+        close() {}
+        prepare(sql: string) {
+          prepared.push(sql);
+          return { sql };
+        }
+      },
+    }));
+  });
+
+  it("compiles each SQL string once", async () => {
+    const { prepareCached, statementCacheStats } = await import(
+      "../src/database/connection"
+    );
+    const sql = "SELECT * FROM transactions WHERE id = ?";
+    const first = prepareCached(sql);
+
+    expect(prepareCached(sql)).toBe(first);
+    expect(prepareCached("SELECT 1")).not.toBe(first);
+    expect(prepared).toEqual([sql, "SELECT 1"]);
+    expect(statementCacheStats()).toMatchObject({
+      size: 2,
+      hits: 1,
+      misses: 2,
+    });
+  });
+
+  it("evicts the least recently used statement", async () => {
+    const { prepareCached, statementCacheStats, STATEMENT_CACHE_SIZE } =
+      await import("../src/database/connection");
+    for (let i = 0; i < STATEMENT_CACHE_SIZE; i++) prepareCached(`SELECT ${i}`);
+    prepareCached("SELECT 0"); // now the most recent
+    prepareCached("SELECT 'new'");
+    prepared = [];
+
+    prepareCached("SELECT 0");
+    prepareCached("SELECT 1");
+    expect(prepared).toEqual(["SELECT 1"]);
+    expect(statementCacheStats().size).toBe(STATEMENT_CACHE_SIZE);
+  });
+
+  it("drops statements with the connection", async () => {
+    const { prepareCached, closeConnection, statementCacheStats } =
+      await import("../src/database/connection");
+    prepareCached("SELECT 1");
+    closeConnection();
+    expect(statementCacheStats().size).toBe(0);
+
+    prepareCached("SELECT 1");
+    expect(prepared).toEqual(["SELECT 1", "SELECT 1"]);
+  });
+});
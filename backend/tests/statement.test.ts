@@ -0,0 +1,117 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Vault PDF statements
+ *
+ * - Holdings keep the running quantity and cost basis of each asset
+ * - Share price is AUM over net contributed, carried while nothing is in
+ * - The rendered file is a well-formed PDF with the statement text
+ */
+
+describe("Statement Service", () => {
+  const eth = { type: "CRYPTO", symbol: "ETH" };
+  const btc = { type: "CRYPTO", symbol: "BTC" };
+
+  beforeEach(() => {
+    vi.resetModules();
+
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        vaultStats: async () => ({
+          totalDepositedUSD: 3000,
+          totalWithdrawnUSD: 1000,
+          aumUSD: 2400,
+          aumUSDManual: 0,
+          aumUSDMarket: 2400,
+        }),
+        getVault: (name: string) => ({ name, benchmark: "BTC" }),
+        getVaultTimeline: () => [
+          {
+            type: "DEPOSIT",
+            asset: eth,
+            runningQuantity: 1,
+            runningCostBasisUSD: 2000,
+          },
+          {
+            type: "DEPOSIT",
+            asset: btc,
+            runningQuantity: 0.01,
+            runningCostBasisUSD: 1000,
+          },
+          {
+            type: "WITHDRAW",
+            asset: btc,
+            runningQuantity: 0,
+            runningCostBasisUSD: 0,
+          },
+        ],
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: { getRateUSD: async () => ({ rateUSD: 2400 }) },
+    }));
+    vi.doMock("../src/services/stablecoin.service", () => ({
+      stablecoinService: { valuationRate: (_: unknown, r: number) => r },
+    }));
+    vi.doMock("../src/services/benchmark.service", () => ({
+      benchmarkService: {
+        compare: async () => ({
+          symbol: "BTC",
+          priceReturnPercent: 50,
+          roiPercent: 12.5,
+          alphaPercent: -5.5,
+        }),
+      },
+    }));
+  });
+
+  it("summarizes open holdings and the share price", async () => {
+    const { statementService } = await import(
+      "../src/services/statement.service"
+    );
+    const s = await statementService.vaultStatement(
+      "Family",
+      [
+        { date: "2025-01-01", aumUSD: 0, netContributedUSD: 0 },
+        { date: "2025-01-02", aumUSD: 2200, netContributedUSD: 2000 },
+      ],
+      "Mom",
+      new Date("2025-06-01T00:00:00Z"),
+    );
+
+    expect(s.holdings).toHaveLength(1);
+    expect(s.holdings[0]).toMatchObject({
+      quantity: 1,
+      costBasisUSD: 2000,
+      valueUSD: 2400,
+      pnlUSD: 400,
+    });
+    expect(s.pnlUSD).toBe(400);
+    expect(s.roiPercent).toBe(20);
+    expect(s.priceHistory.map((p) => p.sharePrice)).toEqual([1, 1.1]);
+  });
+
+  it("renders a PDF with a valid cross-reference table", async () => {
+    const { statementService } = await import(
+      "../src/services/statement.service"
+    );
+    const s = await statementService.vaultStatement("Family (A)", [], "Mom");
+    const pdf = statementService.renderPdf(s).toString("latin1");
+
+    expect(pdf.startsWith("%PDF-1.4")).toBe(true);
+    expect(pdf.trimEnd().endsWith("%%EOF")).toBe(true);
+    expect(pdf).toContain("(Vault statement: Family \\(A\\)) Tj");
+    expect(pdf).toContain("(Prepared for Mom) Tj");
+    expect(pdf).toContain("(Alpha vs BTC) Tj");
+    expect(pdf).toContain("(-5.50%) Tj");
+
+    const xref = Number(pdf.match(/startxref\n(\d+)/)![1]);
+    expect(pdf.slice(xref, xref + 4)).toBe("xref");
+    const offsets = [...pdf.matchAll(/^(\d{10}) 00000 n $/gm)].map((m) =>
+      Number(m[1]),
+    );
+    offsets.forEach((o, i) =>
+      expect(pdf.slice(o).startsWith(`${i + 1} 0 obj`)).toBe(true),
+    );
+  });
+});
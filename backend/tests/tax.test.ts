@@ -0,0 +1,195 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Tax report
+ *
+ * - Closed lots are split into short- and long-term by holding period
+ * - Transfers between vaults carry lots instead of realizing them
+ * - Reward distributions and dividend income count as income
+ * - The tax year follows the configured fiscal year start
+ */
+
+describe("Tax Service", () => {
+  const btc = { type: "CRYPTO", symbol: "BTC" };
+  const usd = { type: "FIAT", symbol: "USD" };
+  let fiscalYear: { start: string; starts: Record<string, string> };
+
+  beforeEach(() => {
+    vi.resetModules();
+    fiscalYear = { start: "01-01", starts: {} };
+
+    const entries: Record<string, any[]> = {
+      Cold: [
+        {
+          vault: "Cold",
+          type: "DEPOSIT",
+          asset: btc,
+          amount: 1,
+          usdValue: 15000,
+          at: "2023-06-01T00:00:00.000Z",
+        },
+        {
+          vault: "Cold",
+          type: "WITHDRAW",
+          asset: btc,
+          amount: 0.5,
+          usdValue: 50000,
+          at: "2024-12-01T00:00:00.000Z",
+          account: "Hot",
+        },
+        {
+          vault: "Cold",
+          type: "DEPOSIT",
+          asset: btc,
+          amount: 0.5,
+          usdValue: 40000,
+          at: "2025-01-01T00:00:00.000Z",
+        },
+        {
+          vault: "Cold",
+          type: "WITHDRAW",
+          asset: btc,
+          amount: 0.6,
+          usdValue: 36000,
+          at: "2025-03-01T00:00:00.000Z",
+        },
+      ],
+      Hot: [
+        {
+          vault: "Hot",
+          type: "DEPOSIT",
+          asset: btc,
+          amount: 0.5,
+          usdValue: 50000,
+          at: "2024-12-01T00:00:00.000Z",
+          account: "Cold",
+        },
+        {
+          vault: "Hot",
+          type: "WITHDRAW",
+          asset: btc,
+          amount: 0.1,
+          usdValue: 10000,
+          at: "2025-02-01T00:00:00.000Z",
+        },
+      ],
+      Staking: [
+        {
+          vault: "Staking",
+          type: "WITHDRAW",
+          asset: usd,
+          amount: 120,
+          usdValue: 120,
+          at: "2025-04-01T00:00:00.000Z",
+          note: "Reward distribution",
+        },
+      ],
+    };
+
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findAll: () => Object.keys(entries).map((name) => ({ name })),
+        findAllEntries: (name: string) => entries[name] || [],
+      },
+      transactionRepository: {
+        findAll: () => [
+          {
+            id: "t1",
+            type: "INCOME",
+            category: "dividend",
+            asset: usd,
+            amount: 80,
+            usdAmount: 80,
+            account: "Brokerage",
+            createdAt: "2025-05-01T00:00:00.000Z",
+          },
+          {
+            id: "t2",
+            type: "INCOME",
+            category: "salary",
+            asset: usd,
+            amount: 2000,
+            usdAmount: 2000,
+            createdAt: "2025-05-01T00:00:00.000Z",
+          },
+        ],
+      },
+      settingsRepository: {
+        getCostBasisSettings: () => ({ byAsset: {}, byVault: {} }),
+        getLongTermHoldingDays: () => 365,
+        getFiscalYear: () => fiscalYear,
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        getNetworkFees: () => ({
+          totalUSD: 12,
+          tradingFeesUSD: 30,
+          fxFees: { byMonth: {}, byCard: {}, totalUSD: 4 },
+        }),
+      },
+    }));
+  });
+
+  it("splits gains by term and carries transferred lots", async () => {
+    const { taxService } = await import("../src/services/tax.service");
+    const r = taxService.report(2025);
+
+    // Average cost releases the 2023 and 2025 lots of Cold in proportion
+    const cold = r.disposals.filter((d) => d.vault === "Cold");
+    expect(cold.map((d) => d.term)).toEqual(["LONG", "SHORT"]);
+    expect(cold[0].units).toBeCloseTo(0.3);
+    expect(cold[0].costUSD).toBeCloseTo(4500);
+    expect(cold[1].costUSD).toBeCloseTo(24000);
+
+    // The transfer to Hot kept the 2023 acquisition date and cost
+    const hot = r.disposals.find((d) => d.vault === "Hot")!;
+    expect(hot).toMatchObject({
+      acquiredAt: "2023-06-01T00:00:00.000Z",
+      term: "LONG",
+    });
+    expect(hot.costUSD).toBeCloseTo(1500);
+    expect(hot.gainUSD).toBeCloseTo(8500);
+
+    expect(r.gains.total.proceedsUSD).toBeCloseTo(46000);
+    expect(r.gains.short.gainUSD).toBeCloseTo(18000 - 24000);
+    expect(r.gains.long.gainUSD).toBeCloseTo(18000 - 4500 + 8500);
+    expect(r.unmatched).toEqual([]);
+  });
+
+  it("uses the threshold and collects income and fees", async () => {
+    const { taxService } = await import("../src/services/tax.service");
+    const r = taxService.report(2025, 1000);
+
+    expect(r.gains.long.count).toBe(0);
+    expect(r.incomeByKind).toEqual({ STAKING: 120, DIVIDEND: 80, INTEREST: 0 });
+    expect(r.income.map((i) => i.source)).toEqual(["Staking", "Brokerage"]);
+    expect(r.fees).toEqual({
+      networkUSD: 12,
+      tradingUSD: 30,
+      fxUSD: 4,
+      totalUSD: 46,
+    });
+    // Nothing was disposed of in 2024; the transfer is not a disposal
+    expect(taxService.report(2024).disposals).toEqual([]);
+  });
+
+  it("reports the fiscal year named by its start", async () => {
+    fiscalYear = { start: "04-01", starts: {} };
+    const { taxService } = await import("../src/services/tax.service");
+    const r = taxService.report(2024);
+
+    expect(r.start).toBe("2024-04-01T00:00:00.000Z");
+    expect(r.end).toBe("2025-03-31T23:59:59.999Z");
+    expect(r.gains.total.proceedsUSD).toBeCloseTo(46000);
+    // April rewards and May dividends fall in fiscal 2025
+    expect(r.income).toEqual([]);
+  });
+
+  it("renders CSV with quoted cells", async () => {
+    const { toCsv } = await import("../src/utils/csv.util");
+    expect(toCsv([["Note", "USD"], ['Sold "half", early', 1.5]])).toBe(
+      'Note,USD\r\n"Sold ""half"", early",1.5\r\n',
+    );
+  });
+});
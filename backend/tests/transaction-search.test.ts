@@ -0,0 +1,182 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+
+/**
+ * Transaction search
+ *
+ * - Every word of the query must match, ignoring case and diacritics
+ * - Type, account, institution, amount and internal-flow filters combine
+ * - Presets resolve to a date range relative to today
+ * - Pages follow the cursor and report the total across pages
+ * - Without filters, paging is a keyset seek in the repository
+ */
+
+describe("Transaction Search Service", () => {
+  const vnd = { type: "FIAT", symbol: "VND" };
+  const tx = (
+    id: string,
+    type: string,
+    createdAt: string,
+    extra: Record<string, unknown> = {},
+  ) => ({
+    id,
+    type,
+    asset: vnd,
+    amount: 100000,
+    usdAmount: 4,
+    account: "Spend",
+    createdAt,
+    ...extra,
+  });
+  const findPage = vi.fn();
+  const transactions = [
+    tx("t1", "EXPENSE", "2025-03-01T08:00:00.000Z", {
+      note: "Phở bò tái",
+      counterparty: "Phở Hùng",
+      tags: ["food"],
+    }),
+    tx("t2", "EXPENSE", "2025-03-02T08:00:00.000Z", {
+      note: "Groceries",
+      category: "food",
+      amount: 650000,
+      usdAmount: 26,
+    }),
+    tx("t3", "INCOME", "2025-03-05T02:00:00.000Z", {
+      note: "Salary",
+      account: "Bank",
+      amount: 50000000,
+      usdAmount: 2000,
+    }),
+    tx("t4", "TRANSFER_OUT", "2025-03-06T00:00:00.000Z", {
+      account: "Bank",
+      transferId: "x1",
+    }),
+    tx("t5", "TRANSFER_IN", "2025-03-06T00:00:00.000Z", {
+      transferId: "x1",
+    }),
+    tx("t6", "EXPENSE", "2025-02-10T00:00:00.000Z", {
+      note: "Bún chả",
+      tags: ["Food"],
+    }),
+  ];
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date("2025-03-10T12:00:00.000Z"));
+    findPage
+      .mockReset()
+      .mockReturnValue({ items: [transactions[0]], nextCursor: "c2" });
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: {
+        findAll: () => transactions,
+        findPage,
+        count: () => transactions.length,
+      },
+      settingsRepository: {
+        getFiscalYear: () => ({ start: "01-01", starts: {} }),
+      },
+      reportSubscriptionRepository: {},
+      adminRepository: {
+        findAllAccounts: () => [
+          { id: 1, name: "Bank", institution: "Vietcombank" },
+          { id: 2, name: "Spend" },
+        ],
+      },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  const ids = (items: { id: string }[]) => items.map((t) => t.id);
+
+  it("matches every word of the query without diacritics", async () => {
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    expect(ids(transactionSearchService.search({ q: "pho" }).items)).toEqual(
+      ["t1"],
+    );
+    expect(
+      ids(transactionSearchService.search({ q: "FOOD bun" }).items),
+    ).toEqual(["t6"]);
+    expect(ids(transactionSearchService.search({ q: "food" }).items)).toEqual(
+      ["t2", "t1", "t6"],
+    );
+  });
+
+  it("combines type, account, amount and flow filters", async () => {
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    const search = transactionSearchService.search.bind(
+      transactionSearchService,
+    );
+
+    expect(
+      ids(search({ types: ["EXPENSE", "INCOME"], accounts: ["bank"] }).items),
+    ).toEqual(["t3"]);
+    expect(ids(search({ minAmount: 200000, maxUSD: 100 }).items)).toEqual([
+      "t2",
+    ]);
+    expect(ids(search({ internalFlow: true }).items)).toEqual(["t5", "t4"]);
+    expect(search({ internalFlow: false }).total).toBe(4);
+    expect(ids(search({ institutions: ["VIETCOMBANK"] }).items)).toEqual([
+      "t4",
+      "t3",
+    ]);
+    expect(
+      ids(search({ institutions: ["vietcombank"], types: ["INCOME"] }).items),
+    ).toEqual(["t3"]);
+    expect(search({ institutions: ["Vietcombank"], q: "pho" }).total).toBe(0);
+  });
+
+  it("resolves presets relative to today", async () => {
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    expect(
+      ids(transactionSearchService.search({ preset: "last_month" }).items),
+    ).toEqual(["t6"]);
+    expect(
+      transactionSearchService.search({ preset: "this_month" }).total,
+    ).toBe(5);
+  });
+
+  it("pages matches with a cursor and reports the total", async () => {
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    const first = transactionSearchService.search({ q: "food", limit: 2 });
+    expect(ids(first.items)).toEqual(["t2", "t1"]);
+    expect(first.total).toBe(3);
+
+    const second = transactionSearchService.search({
+      q: "food",
+      limit: 2,
+      cursor: first.nextCursor,
+    });
+    expect(ids(second.items)).toEqual(["t6"]);
+    expect(second.total).toBe(3);
+    expect(second.nextCursor).toBeUndefined();
+
+    expect(() =>
+      transactionSearchService.search({ q: "food", cursor: "not-a-cursor" }),
+    ).toThrow(/cursor is invalid/);
+  });
+
+  it("leaves unfiltered paging to the repository", async () => {
+    const { transactionSearchService } = await import(
+      "../src/services/transaction-search.service"
+    );
+    const page = transactionSearchService.search({ limit: 2, cursor: "c1" });
+    expect(page).toEqual({
+      items: [transactions[0]],
+      nextCursor: "c2",
+      total: 6,
+    });
+    expect(findPage).toHaveBeenCalledWith({ limit: 2, cursor: "c1" });
+  });
+});
@@ -8,7 +8,8 @@ import request from "supertest";
  * Tests for the transaction API endpoints including:
  * - Creating income transactions
  * - Creating expense transactions
- * - Listing transactions
+ * - Listing transactions, with the institution filter combined with others
+ * - Getting a transaction with its linked transactions
  * - Deleting transactions
  * - Unified transaction endpoint (buy/sell)
  */
@@ -40,12 +41,14 @@ describe("Transaction Handler", () => {
   let mockTransactions: Transaction[] = [];
   let mockVaults: Vault[] = [];
   let mockEntries: VaultEntry[] = [];
+  let mockPendingActions: any[] = [];
 
   beforeEach(() => {
     vi.resetModules();
     mockTransactions = [];
     mockVaults = [];
     mockEntries = [];
+    mockPendingActions = [];
 
     // Update the mock implementation to return current mockEntries
     mockGetVaultEntries.mockImplementation((vaultName: string) =>
@@ -62,12 +65,28 @@ describe("Transaction Handler", () => {
           mockTransactions.push(tx);
           return tx;
         },
+        update: (id: string, updates: Partial<Transaction>) => {
+          const idx = mockTransactions.findIndex((t) => t.id === id);
+          if (idx === -1) return undefined;
+          mockTransactions[idx] = {
+            ...mockTransactions[idx],
+            ...updates,
+          } as Transaction;
+          return mockTransactions[idx];
+        },
         delete: (id: string) => {
           const idx = mockTransactions.findIndex((t) => t.id === id);
           if (idx === -1) return false;
           mockTransactions.splice(idx, 1);
           return true;
         },
+        // Soft-deleted transactions drop out of every read
+        softDelete: (id: string) => {
+          const idx = mockTransactions.findIndex((t) => t.id === id);
+          if (idx === -1) return false;
+          mockTransactions.splice(idx, 1);
+          return true;
+        },
       },
       vaultRepository: {
         findByName: (name: string) => mockVaults.find((v) => v.name === name),
@@ -94,6 +113,16 @@ describe("Transaction Handler", () => {
         },
         set: vi.fn(),
       },
+      pendingActionsRepository: {
+        findAll: () => mockPendingActions,
+      },
+      adminRepository: {
+        findAllAccounts: () => [
+          { id: 1, name: "Bank", institution: "Vietcombank" },
+          { id: 2, name: "Card", institution: "Vietcombank" },
+          { id: 3, name: "Spend" },
+        ],
+      },
     }));
 
     // Mock price service
@@ -246,6 +275,46 @@ describe("Transaction Handler", () => {
       expect(res.body[0].type).toBe("deposit");
       expect(res.body[0].investment_id).toBe("TestVault");
     });
+
+    it("should combine the institution with the other filters", async () => {
+      const usd = { type: "FIAT" as const, symbol: "USD" };
+      const tx = (id: string, type: string, account: string, day: string) =>
+        ({
+          id,
+          type,
+          asset: usd,
+          amount: 10,
+          usdAmount: 10,
+          account,
+          createdAt: `2025-01-${day}T00:00:00.000Z`,
+        }) as Transaction;
+      mockTransactions = [
+        tx("1", "INCOME", "Bank", "01"),
+        tx("2", "EXPENSE", "Bank", "02"),
+        tx("3", "EXPENSE", "Card", "03"),
+        tx("4", "EXPENSE", "Spend", "04"),
+      ];
+      const app = await createApp();
+      const ids = async (query: string) => {
+        const res = await request(app)
+          .get(`/api/transactions?${query}`)
+          .expect(200);
+        return res.body.items.map((t: Transaction) => t.id);
+      };
+
+      expect(await ids("institution=vietcombank")).toEqual(["3", "2", "1"]);
+      expect(await ids("institution=Vietcombank&type=EXPENSE")).toEqual([
+        "3",
+        "2",
+      ]);
+      expect(await ids("institution=Vietcombank&account=Card")).toEqual([
+        "3",
+      ]);
+      expect(
+        await ids("institution=Vietcombank&end=2025-01-01T23:59:59.000Z"),
+      ).toEqual(["1"]);
+      expect(await ids("institution=Vietcombank&account=Spend")).toEqual([]);
+    });
   });
 
   describe("DELETE /transactions/:id - Delete transaction", () => {
@@ -275,6 +344,143 @@ describe("Transaction Handler", () => {
     });
   });
 
+  describe("GET /transactions/:id - Linked transactions", () => {
+    const tx = (
+      id: string,
+      type: Transaction["type"],
+      extra: Partial<Transaction> = {},
+    ) =>
+      ({
+        id,
+        type,
+        asset: { type: "FIAT", symbol: "USD" },
+        amount: 100,
+        createdAt: "2025-01-01T00:00:00Z",
+        usdAmount: 100,
+        ...extra,
+      }) as Transaction;
+
+    beforeEach(() => {
+      mockTransactions = [
+        tx("borrow-1", "BORROW", { loanId: "loan-1" }),
+        tx("repay-1", "REPAY", { loanId: "loan-1" }),
+        tx("repay-2", "REPAY", { loanId: "loan-1" }),
+        tx("out-1", "TRANSFER_OUT", { transferId: "t-1" }),
+        tx("in-1", "TRANSFER_IN", { transferId: "t-1" }),
+        tx("other", "EXPENSE"),
+      ];
+      mockPendingActions = [
+        { batch_id: "b-1", created_tx_ids: ["other"] },
+        { batch_id: "b-1", created_tx_ids: ["repay-1"] },
+        { batch_id: "b-1", created_tx_ids: ["out-1"] },
+      ];
+    });
+
+    it("should include direct links with a summary", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .get("/api/transactions/repay-1")
+        .expect(200);
+
+      expect(res.body.id).toBe("repay-1");
+      expect(
+        res.body.links.map((l: any) => [
+          l.type,
+          l.direction,
+          l.transaction.id,
+        ]),
+      ).toEqual([
+        ["loan", "from", "borrow-1"],
+        ["loan", "sibling", "repay-2"],
+        ["action_batch", "sibling", "other"],
+        ["action_batch", "sibling", "out-1"],
+      ]);
+      expect(res.body.links[0].transaction).toMatchObject({
+        type: "BORROW",
+        amount: 100,
+      });
+    });
+
+    it("should traverse every linked transaction", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .get("/api/transactions/borrow-1/related")
+        .expect(200);
+
+      const depth = Object.fromEntries(
+        res.body.transactions.map((t: any) => [t.id, t.depth]),
+      );
+      expect(depth).toEqual({
+        "repay-1": 1,
+        "repay-2": 1,
+        other: 2,
+        "out-1": 2,
+        "in-1": 3,
+      });
+      expect(res.body.links).toContainEqual({
+        type: "transfer",
+        from: "out-1",
+        to: "in-1",
+      });
+
+      await request(app).get("/api/transactions/missing/related").expect(404);
+    });
+  });
+
+  describe("POST /transactions/:id/reimbursement - Match refunds", () => {
+    beforeEach(() => {
+      mockTransactions = [
+        {
+          id: "exp-1",
+          type: "EXPENSE",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 100,
+          createdAt: "2025-01-01T00:00:00Z",
+          account: "Spend",
+          usdAmount: 100,
+        } as Transaction,
+        {
+          id: "inc-1",
+          type: "INCOME",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 100,
+          createdAt: "2025-01-10T00:00:00Z",
+          account: "Spend",
+          usdAmount: 100,
+        } as Transaction,
+      ];
+    });
+
+    it("should link the refund and mark the expense reimbursable", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/transactions/exp-1/reimbursement")
+        .send({ income_id: "inc-1" })
+        .expect(200);
+
+      expect(res.body.expense.reimbursable).toBe(true);
+      expect(res.body.income.reimbursesId).toBe("exp-1");
+    });
+
+    it("should reject matching a non-income transaction", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/transactions/exp-1/reimbursement")
+        .send({ income_id: "exp-1" })
+        .expect(400);
+
+      expect(res.body.error).toBe("Income not found");
+    });
+
+    it("should only allow expenses to be marked reimbursable", async () => {
+      const app = await createApp();
+      await request(app)
+        .post("/api/transactions/inc-1/reimbursable")
+        .send({ reimbursable: true })
+        .expect(400);
+    });
+  });
+
   describe("POST /transactions - Unified endpoint", () => {
     it("should create income transaction via unified endpoint", async () => {
       const app = await createApp();
@@ -442,6 +648,7 @@ describe("TransactionService - Description Validation", () => {
       settingsRepository: {
         getDefaultSpendingVaultName: () => "Spend",
         getDefaultIncomeVaultName: () => "Income",
+        getCardFxMarkupPercent: () => 2.5,
       },
     }));
 
@@ -537,6 +744,80 @@ describe("TransactionService - Description Validation", () => {
         transactionService.createExpenseTransaction(params),
       ).rejects.toThrow("must have either");
     });
+
+    it("computes the FX fee of foreign-currency card spending", async () => {
+      const usd = { type: "FIAT" as const, symbol: "USD" };
+      const tx = await transactionService.createExpenseTransaction({
+        asset: usd,
+        amount: 10000,
+        note: "Hotel",
+        card: "Visa 1234",
+      });
+      expect(tx.feeUSD).toBeCloseTo(tx.usdAmount * 0.025, 10);
+
+      const given = await transactionService.createExpenseTransaction({
+        asset: usd,
+        amount: 10000,
+        note: "Hotel",
+        card: "Visa 1234",
+        feeUSD: 0.3,
+      });
+      expect(given.feeUSD).toBe(0.3);
+
+      const local = await transactionService.createExpenseTransaction({
+        asset: { type: "FIAT" as const, symbol: "VND" },
+        amount: 100000,
+        note: "Coffee",
+        card: "Visa 1234",
+      });
+      expect(local.feeUSD).toBeUndefined();
+    });
+
+    it("geotags expenses and requires coordinates in pairs", async () => {
+      const vnd = { type: "FIAT" as const, symbol: "VND" };
+      const tx = await transactionService.createExpenseTransaction({
+        asset: vnd,
+        amount: 50000,
+        note: "Pho",
+        place: " Pho Hoa ",
+        latitude: 10.7893,
+        longitude: 106.6914,
+      });
+      expect(tx.place).toBe("Pho Hoa");
+      expect([tx.latitude, tx.longitude]).toEqual([10.7893, 106.6914]);
+
+      await expect(
+        transactionService.createExpenseTransaction({
+          asset: vnd,
+          amount: 50000,
+          note: "Pho",
+          latitude: 10.7893,
+        }),
+      ).rejects.toThrow("latitude and longitude");
+    });
+  });
+
+  describe("getSpendingByLocation", () => {
+    it("groups by place, then by ~1 km coordinate cell", () => {
+      const spend = (usdAmount: number, extra: Partial<Transaction>) =>
+        ({ id: String(usdAmount), usdAmount, ...extra }) as Transaction;
+      const r = transactionService.getSpendingByLocation([
+        spend(10, { place: "Ben Thanh", latitude: 10.772, longitude: 106.698 }),
+        spend(20, { place: "ben thanh" }),
+        spend(3, { latitude: 10.8012, longitude: 106.7151 }),
+        spend(4, { latitude: 10.8034, longitude: 106.7163 }),
+        spend(5, {}),
+      ]);
+
+      expect(r.totalUSD).toBe(42);
+      expect(r.unlocated).toEqual({ count: 1, usd: 5 });
+      expect(r.locations.map((l: any) => [l.key, l.count, l.usd])).toEqual([
+        ["ben thanh", 2, 30],
+        ["10.80,106.72", 2, 7],
+      ]);
+      expect(r.locations[0].latitude).toBe(10.772);
+      expect(r.locations[1].latitude).toBeCloseTo(10.8023, 10);
+    });
   });
 
   describe("createIncomeTransaction", () => {
@@ -0,0 +1,102 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Transfer pair consistency
+ *
+ * - Received may fall short of sent by at most the recorded fee
+ * - Missing legs are reported
+ * - Multi-leg groups (DRIP, options) are not treated as transfers
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Transfer consistency", () => {
+  const USDT = { type: "CRYPTO" as const, symbol: "USDT" };
+  const VTI = { type: "CRYPTO" as const, symbol: "VTI" };
+  let txs: Transaction[] = [];
+  let n = 0;
+
+  const tx = (
+    transferId: string,
+    type: Transaction["type"],
+    amount: number,
+    asset: { type: "CRYPTO" | "FIAT"; symbol: string } = USDT,
+    rateUSD = 1,
+  ) =>
+    ({
+      id: `tx-${++n}`,
+      type,
+      asset,
+      amount,
+      createdAt: `2025-01-0${Math.min(9, n)}T00:00:00.000Z`,
+      account: type === "TRANSFER_IN" ? "Ledger" : "Binance",
+      transferId,
+      rate: { asset, rateUSD, timestamp: "2025-01-01T00:00:00.000Z" },
+      usdAmount: amount * rateUSD,
+    }) as Transaction;
+
+  beforeEach(() => {
+    vi.resetModules();
+    n = 0;
+    txs = [];
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => txs },
+      vaultRepository: {},
+      settingsRepository: {},
+      borrowingRepository: {},
+      adminRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({ vaultService: {} }));
+    vi.doMock("../src/services/price.service", () => ({ priceService: {} }));
+  });
+
+  it("accepts shortfalls the fee explains", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    txs.push(
+      // Fee deducted in transit
+      tx("a", "TRANSFER_OUT", 500),
+      tx("a", "TRANSFER_IN", 499),
+      tx("a", "EXPENSE", 1),
+      // Fee paid on top
+      tx("b", "TRANSFER_OUT", 500),
+      tx("b", "TRANSFER_IN", 500),
+      tx("b", "EXPENSE", 1),
+      // DRIP: income plus a cash-for-units conversion at cost
+      tx("c", "INCOME", 42),
+      tx("c", "TRANSFER_OUT", 42),
+      tx("c", "TRANSFER_IN", 0.15, VTI, 280),
+    );
+
+    const r = transactionService.checkTransferPairs();
+    expect(r.checked).toBe(3);
+    expect(r.issues).toEqual([]);
+  });
+
+  it("flags unexplained shortfalls and missing legs", async () => {
+    const { transactionService } = await import(
+      "../src/services/transaction.service"
+    );
+    txs.push(
+      // Withdrawal fee never recorded
+      tx("a", "TRANSFER_OUT", 500),
+      tx("a", "TRANSFER_IN", 499),
+      tx("b", "TRANSFER_OUT", 100),
+    );
+
+    const r = transactionService.checkTransferPairs();
+    expect(r.issues.map((i) => [i.transferId, i.kind])).toEqual([
+      ["b", "MISSING_IN"],
+      ["a", "AMOUNT_MISMATCH"],
+    ]);
+    expect(r.issues[1].discrepancy).toBe(1);
+    expect(r.issues[1].discrepancyUSD).toBe(1);
+
+    // Within tolerance it passes
+    const lenient = transactionService.checkTransferPairs({
+      tolerancePercent: 0.5,
+    });
+    expect(lenient.issues.map((i) => i.kind)).toEqual(["MISSING_IN"]);
+  });
+});
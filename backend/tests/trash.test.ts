@@ -0,0 +1,131 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Trash (undo for destructive operations)
+ *
+ * - A deleted vault comes back with its entries
+ * - Overwritten settings get their previous values back
+ * - Items past their retention are purged
+ */
+
+type Vault = import("../src/types").Vault;
+type VaultEntry = import("../src/types").VaultEntry;
+type TrashItem = import("../src/types").TrashItem;
+
+describe("Trash Service", () => {
+  let vaults: Vault[] = [];
+  let entries: VaultEntry[] = [];
+  let trash: TrashItem[] = [];
+  let settings: Record<string, string> = {};
+
+  beforeEach(() => {
+    vi.resetModules();
+    vaults = [
+      { name: "Savings", status: "ACTIVE", createdAt: "2024-01-01T00:00:00Z" },
+    ];
+    entries = [
+      {
+        vault: "Savings",
+        type: "DEPOSIT",
+        asset: { type: "FIAT", symbol: "USD" },
+        amount: 500,
+        usdValue: 500,
+        at: "2024-02-01T00:00:00Z",
+      },
+    ];
+    trash = [];
+    settings = { defaultSpendingVaultName: "Spend" };
+
+    vi.doMock("../src/repositories", () => ({
+      vaultRepository: {
+        findByName: (name: string) => vaults.find((v) => v.name === name),
+        findAllEntries: (name: string) =>
+          entries.filter((e) => e.vault === name),
+        create: (v: Vault) => {
+          vaults.push(v);
+          return v;
+        },
+        createEntry: (e: VaultEntry) => {
+          entries.push(e);
+          return e;
+        },
+        delete: (name: string) => {
+          vaults = vaults.filter((v) => v.name !== name);
+          entries = entries.filter((e) => e.vault !== name);
+          return true;
+        },
+      },
+      settingsRepository: {
+        getSetting: (key: string) => settings[key],
+        setSetting: (key: string, value: string) => {
+          settings[key] = value;
+        },
+        deleteSetting: (key: string) => {
+          delete settings[key];
+        },
+      },
+      trashRepository: {
+        findAll: () => trash,
+        findById: (id: string) => trash.find((t) => t.id === id),
+        create: (t: TrashItem) => {
+          trash.push(t);
+          return t;
+        },
+        delete: (id: string) => {
+          const len = trash.length;
+          trash = trash.filter((t) => t.id !== id);
+          return trash.length < len;
+        },
+        deleteExpired: (now: string) => {
+          const len = trash.length;
+          trash = trash.filter((t) => t.expiresAt > now);
+          return len - trash.length;
+        },
+      },
+    }));
+  });
+
+  it("restores a deleted vault with its entries", async () => {
+    const { trashService } = await import("../src/services/trash.service");
+    const item = trashService.trashVault("Savings")!;
+
+    expect(vaults).toHaveLength(0);
+    expect(entries).toHaveLength(0);
+
+    trashService.restore(item.id);
+    expect(vaults.map((v) => v.name)).toEqual(["Savings"]);
+    expect(entries).toHaveLength(1);
+    expect(trash).toHaveLength(0);
+  });
+
+  it("refuses to restore over a vault with the same name", async () => {
+    const { trashService } = await import("../src/services/trash.service");
+    const item = trashService.trashVault("Savings")!;
+    vaults.push({ name: "Savings", status: "ACTIVE", createdAt: "" });
+
+    expect(() => trashService.restore(item.id)).toThrow(/exists again/);
+    expect(trash).toHaveLength(1);
+  });
+
+  it("undoes a settings overwrite", async () => {
+    const { trashService } = await import("../src/services/trash.service");
+    const item = trashService.trashSettings("Settings before import", [
+      "defaultSpendingVaultName",
+      "defaultIncomeVaultName",
+    ]);
+    settings.defaultSpendingVaultName = "Imported";
+    settings.defaultIncomeVaultName = "Imported Income";
+
+    trashService.restore(item.id);
+    expect(settings).toEqual({ defaultSpendingVaultName: "Spend" });
+  });
+
+  it("purges items after the retention period", async () => {
+    const { trashService } = await import("../src/services/trash.service");
+    trashService.trashVault("Savings");
+
+    expect(trashService.purgeExpired(new Date())).toBe(0);
+    const later = new Date(Date.now() + 8 * 24 * 60 * 60 * 1000);
+    expect(trashService.purgeExpired(later)).toBe(1);
+  });
+});
@@ -10,7 +10,8 @@ import request from "supertest";
  * - Listing vaults
  * - Getting vault details
  * - Depositing into vaults
- * - Withdrawing from vaults
+ * - Withdrawing from vaults and distributing rewards, capped at the
+ *   remaining quantity unless the overdraw policy warns or allows
  * - Deleting vaults
  */
 
@@ -21,11 +22,15 @@ type VaultEntry = import("../src/types").VaultEntry;
 describe("Vault Handler", () => {
   let mockVaults: Vault[] = [];
   let mockEntries: VaultEntry[] = [];
+  let mockTrash: any[] = [];
+  let overdrawPolicy = "REJECT";
 
   beforeEach(() => {
     vi.resetModules();
     mockVaults = [];
     mockEntries = [];
+    mockTrash = [];
+    overdrawPolicy = "REJECT";
 
     // Mock the repositories index
     vi.doMock("../src/repositories", () => ({
@@ -62,6 +67,15 @@ describe("Vault Handler", () => {
       },
       settingsRepository: {
         getDefaultSpendingVaultName: () => "Spend",
+        getMaxManualPriceChangePercent: () => 50,
+        getCostBasisSettings: () => ({ byAsset: {}, byVault: {} }),
+        getOverdrawPolicy: () => overdrawPolicy,
+      },
+      trashRepository: {
+        create: (item: any) => {
+          mockTrash.push(item);
+          return item;
+        },
       },
     }));
 
@@ -269,6 +283,15 @@ describe("Vault Handler", () => {
   });
 
   describe("POST /vaults/:name/withdraw - Withdraw from vault", () => {
+    const deposit = (vault: string, asset: Asset, amount: number) => ({
+      vault,
+      type: "DEPOSIT" as const,
+      asset,
+      amount,
+      usdValue: asset.symbol === "USD" ? amount : amount * 50000,
+      at: "2025-01-01T00:00:00Z",
+    });
+
     it("should create a withdrawal entry", async () => {
       mockVaults = [
         {
@@ -277,6 +300,9 @@ describe("Vault Handler", () => {
           createdAt: "2025-01-01T00:00:00Z",
         },
       ];
+      mockEntries = [
+        deposit("TestVault", { type: "FIAT", symbol: "USD" }, 500),
+      ];
 
       const app = await createApp();
       const res = await request(app)
@@ -302,6 +328,9 @@ describe("Vault Handler", () => {
           createdAt: "2025-01-01T00:00:00Z",
         },
       ];
+      mockEntries = [
+        deposit("SourceVault", { type: "FIAT", symbol: "USD" }, 1000),
+      ];
 
       const app = await createApp();
       const res = await request(app)
@@ -327,6 +356,171 @@ describe("Vault Handler", () => {
           .length,
       ).toBe(1);
     });
+
+    it("should reject withdrawing more than the remaining quantity", async () => {
+      const btc: Asset = { type: "CRYPTO", symbol: "BTC" };
+      mockVaults = [
+        {
+          name: "TestVault",
+          status: "ACTIVE",
+          createdAt: "2025-01-01T00:00:00Z",
+        },
+      ];
+      mockEntries = [deposit("TestVault", btc, 0.5)];
+
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.6, value: 30000 })
+        .expect(400);
+
+      expect(res.body.error).toMatch(/only 0.5 remaining/);
+      expect(res.body.details).toMatchObject({
+        requested: 0.6,
+        remaining: 0.5,
+      });
+
+      await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.6, value: 30000, to: "Other" })
+        .expect(400);
+      expect(mockEntries).toHaveLength(1);
+
+      await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({
+          asset: "BTC",
+          quantity: 0.6,
+          value: 30000,
+          allow_overdraw: true,
+        })
+        .expect(201);
+      expect(mockEntries).toHaveLength(2);
+    });
+
+    it("should record an over-withdrawal with a warning under WARN", async () => {
+      const btc: Asset = { type: "CRYPTO", symbol: "BTC" };
+      mockVaults = [
+        {
+          name: "TestVault",
+          status: "ACTIVE",
+          createdAt: "2025-01-01T00:00:00Z",
+        },
+      ];
+      mockEntries = [deposit("TestVault", btc, 0.5)];
+      overdrawPolicy = "WARN";
+
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.6, value: 30000 })
+        .expect(201);
+      expect(res.body.entry.amount).toBe(0.6);
+      expect(res.body.warnings).toHaveLength(1);
+      expect(res.body.warnings[0]).toMatch(/only 0.5 remaining/);
+
+      const transfer = await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.1, value: 5000, to: "Other" })
+        .expect(201);
+      expect(transfer.body.warnings[0]).toMatch(/only 0 remaining/);
+      expect(mockEntries).toHaveLength(4);
+
+      // Within what is held there is nothing to warn about
+      mockEntries = [deposit("TestVault", btc, 0.5)];
+      const within = await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.5, value: 25000 })
+        .expect(201);
+      expect(within.body.warnings).toBeUndefined();
+    });
+
+    it("should record an over-withdrawal as is under ALLOW", async () => {
+      const btc: Asset = { type: "CRYPTO", symbol: "BTC" };
+      mockVaults = [
+        {
+          name: "TestVault",
+          status: "ACTIVE",
+          createdAt: "2025-01-01T00:00:00Z",
+        },
+      ];
+      mockEntries = [deposit("TestVault", btc, 0.5)];
+      overdrawPolicy = "ALLOW";
+
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/vaults/TestVault/withdraw")
+        .send({ asset: "BTC", quantity: 0.6, value: 30000 })
+        .expect(201);
+      expect(res.body.entry.amount).toBe(0.6);
+      expect(res.body.warnings).toBeUndefined();
+
+      const transfer = await request(app)
+        .post("/api/vaults/TestVault/transfer")
+        .send({ to: "Other", asset: "BTC", quantity: 0.1, value: 5000 })
+        .expect(201);
+      expect(transfer.body.warnings).toBeUndefined();
+      expect(mockEntries).toHaveLength(4);
+    });
+  });
+
+  describe("POST /vaults/:name/distribute-reward - Overdraw", () => {
+    const usd: Asset = { type: "FIAT", symbol: "USD" };
+    beforeEach(() => {
+      mockVaults = [
+        {
+          name: "Fund",
+          status: "ACTIVE",
+          createdAt: "2025-01-01T00:00:00Z",
+        },
+      ];
+      mockEntries = [
+        {
+          vault: "Fund",
+          type: "DEPOSIT",
+          asset: usd,
+          amount: 1000,
+          usdValue: 1000,
+          at: "2025-01-01T00:00:00Z",
+        },
+      ];
+    });
+
+    it("should reject a reward larger than the vault holds", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/vaults/Fund/distribute-reward")
+        .send({ amount: 1500, destination: "Spend", mark: false })
+        .expect(400);
+      expect(res.body.code).toBe("BUSINESS_ERROR");
+      expect(res.body.error).toMatch(/only 1000 remaining/);
+      expect(mockEntries).toHaveLength(1);
+
+      // Marked to a higher value first, the reward fits
+      const marked = await request(app)
+        .post("/api/vaults/Fund/distribute-reward")
+        .send({ amount: 1500, destination: "Spend", new_total_usd: 2000 })
+        .expect(201);
+      expect(marked.body.marked_to).toBe(2000);
+      expect(marked.body.warnings).toBeUndefined();
+      expect(mockEntries).toHaveLength(4);
+    });
+
+    it("should record it with allow_overdraw or under WARN", async () => {
+      const app = await createApp();
+      await request(app)
+        .post("/api/vaults/Fund/distribute-reward")
+        .send({ amount: 1500, mark: false, allow_overdraw: true })
+        .expect(201);
+
+      overdrawPolicy = "WARN";
+      const warned = await request(app)
+        .post("/api/vaults/Fund/distribute-reward")
+        .send({ amount: 100, mark: false })
+        .expect(201);
+      expect(warned.body.warnings[0]).toMatch(/only 0 remaining/);
+      expect(mockEntries).toHaveLength(5);
+    });
   });
 
   describe("DELETE /vaults/:name - Delete vault", () => {
@@ -344,6 +538,13 @@ describe("Vault Handler", () => {
 
       expect(res.body.ok).toBe(true);
       expect(mockVaults).toHaveLength(0);
+      // Kept in the trash so the deletion can be undone
+      expect(res.body.trash_id).toBe(mockTrash[0].id);
+      expect(mockTrash[0]).toMatchObject({
+        kind: "VAULT",
+        label: "ToDelete",
+        payload: { vault: { name: "ToDelete" }, entries: [] },
+      });
     });
 
     it("should return 404 for non-existent vault", async () => {
@@ -369,6 +570,44 @@ describe("Vault Handler", () => {
       expect(mockVaults[0].status).toBe("CLOSED");
     });
 
+    it("should return holding period and APR or APY annualization", async () => {
+      mockVaults = [
+        { name: "Held", status: "ACTIVE", createdAt: "2025-01-01T00:00:00Z" },
+      ];
+      mockEntries = [
+        {
+          vault: "Held",
+          type: "DEPOSIT",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 1000,
+          usdValue: 1000,
+          at: "2025-01-01T00:00:00Z",
+        },
+        {
+          vault: "Held",
+          type: "VALUATION",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 0,
+          usdValue: 1100,
+          at: "2025-07-01T00:00:00Z",
+        },
+      ];
+
+      const app = await createApp();
+      const apr = await request(app).post("/api/vaults/Held/end").expect(200);
+      const apy = await request(app)
+        .post("/api/vaults/Held/end")
+        .send({ annualization: "apy" })
+        .expect(200);
+
+      expect(apr.body.holding_period_days).toBe(181);
+      expect(apr.body.annualization).toBe("APR");
+      expect(apr.body.roi_percent).toBeCloseTo(10, 6);
+      expect(apr.body.apr_percent).toBeCloseTo((0.1 * 365 * 100) / 181, 6);
+      expect(apy.body.annualization).toBe("APY");
+      expect(apy.body.apr_percent).toBeGreaterThan(apr.body.apr_percent);
+    });
+
     it("should return 404 for non-existent vault", async () => {
       const app = await createApp();
       const res = await request(app)
@@ -417,6 +656,64 @@ describe("Vault Handler", () => {
       expect(res.body[1].type).toBe("WITHDRAW");
     });
 
+    it("should include running quantity and cost basis after each event", async () => {
+      mockVaults = [
+        {
+          name: "BtcVault",
+          status: "ACTIVE",
+          createdAt: "2025-01-01T00:00:00Z",
+        },
+      ];
+      mockEntries = [
+        {
+          vault: "BtcVault",
+          type: "DEPOSIT",
+          asset: { type: "CRYPTO", symbol: "BTC" },
+          amount: 1,
+          usdValue: 40000,
+          at: "2025-01-01T00:00:00Z",
+        },
+        {
+          vault: "BtcVault",
+          type: "DEPOSIT",
+          asset: { type: "CRYPTO", symbol: "BTC" },
+          amount: 1,
+          usdValue: 60000,
+          at: "2025-01-10T00:00:00Z",
+        },
+        {
+          vault: "BtcVault",
+          type: "WITHDRAW",
+          asset: { type: "CRYPTO", symbol: "BTC" },
+          amount: 0.5,
+          usdValue: 30000,
+          at: "2025-01-20T00:00:00Z",
+        },
+        {
+          vault: "BtcVault",
+          type: "WITHDRAW",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 100,
+          usdValue: 100,
+          at: "2025-01-25T00:00:00Z",
+          note: "Reward distribution",
+        },
+      ];
+
+      const app = await createApp();
+      const res = await request(app)
+        .get("/api/vaults/BtcVault/transactions")
+        .expect(200);
+
+      expect(res.body).toHaveLength(4);
+      expect(res.body[1].running_quantity).toBe(2);
+      expect(res.body[1].running_cost_basis_usd).toBe(100000);
+      expect(res.body[2].running_quantity).toBe(1.5);
+      expect(res.body[2].running_cost_basis_usd).toBe(75000);
+      expect(res.body[3].event).toBe("REWARD");
+      expect(res.body[3].total_cost_basis_usd).toBe(75000);
+    });
+
     it("should return 404 for non-existent vault", async () => {
       const app = await createApp();
       const res = await request(app)
@@ -427,6 +724,81 @@ describe("Vault Handler", () => {
     });
   });
 
+  describe("POST /vaults/:id/update-total-value - Outlier guard", () => {
+    beforeEach(() => {
+      mockVaults = [
+        { name: "Priced", status: "ACTIVE", createdAt: "2025-01-01T00:00:00Z" },
+      ];
+      mockEntries = [
+        {
+          vault: "Priced",
+          type: "DEPOSIT",
+          asset: { type: "FIAT", symbol: "USD" },
+          amount: 1000,
+          usdValue: 1000,
+          at: "2025-01-01T00:00:00Z",
+        },
+      ];
+    });
+
+    it("should reject changes beyond the max percentage", async () => {
+      const app = await createApp();
+      const res = await request(app)
+        .post("/api/vaults/Priced/update-total-value")
+        .send({ total_value: 10000 })
+        .expect(400);
+
+      expect(res.body.change_percent).toBe(900);
+      expect(res.body.max_change_percent).toBe(50);
+      expect(mockEntries).toHaveLength(1);
+    });
+
+    it("should apply outlier changes when forced", async () => {
+      const app = await createApp();
+      await request(app)
+        .post("/api/vaults/Priced/update-total-value")
+        .send({ total_value: 10000, force: true })
+        .expect(200);
+
+      expect(mockEntries).toHaveLength(2);
+      expect(mockEntries[1].type).toBe("VALUATION");
+    });
+
+    it("should allow changes within the max percentage", async () => {
+      const app = await createApp();
+      await request(app)
+        .post("/api/vaults/Priced/update-total-value")
+        .send({ total_value: 1200 })
+        .expect(200);
+
+      expect(mockEntries).toHaveLength(2);
+    });
+  });
+
+  describe("Strategy tags", () => {
+    it("should store normalized tags and filter vaults by strategy", async () => {
+      const app = await createApp();
+      await request(app)
+        .post("/api/vaults")
+        .send({ name: "Dca", tags: ["DCA", " dca ", "Long-Term-Hold"] })
+        .expect(201);
+      await request(app).post("/api/vaults").send({ name: "Farm" }).expect(201);
+      await request(app)
+        .put("/api/vaults/Farm")
+        .send({ tags: "yield-farming" })
+        .expect(200);
+
+      expect(mockVaults[0].tags).toEqual(["dca", "long-term-hold"]);
+      expect(mockVaults[1].tags).toEqual(["yield-farming"]);
+
+      const res = await request(app)
+        .get("/api/vaults?strategy=DCA")
+        .expect(200);
+      expect(res.body).toHaveLength(1);
+      expect(res.body[0].name).toBe("Dca");
+    });
+  });
+
   describe("Multi-Asset Vault Calculations", () => {
     it("should calculate USD and non-USD AUM separately without manual valuation", async () => {
       mockVaults = [
@@ -0,0 +1,172 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * RSU / ESPP vesting
+ *
+ * - RSU schedule vests the cliff amount, then equal steps
+ * - A vest posts income and a buy of the units kept after withholding
+ * - ESPP income is the purchase discount
+ */
+
+type VestingGrant = import("../src/types").VestingGrant;
+type VestEvent = import("../src/types").VestEvent;
+type Transaction = import("../src/types").Transaction;
+type VaultEntry = import("../src/types").VaultEntry;
+
+describe("Vesting Service", () => {
+  const USD = { type: "FIAT" as const, symbol: "USD" };
+  let grants: VestingGrant[] = [];
+  let events: VestEvent[] = [];
+  let txs: Transaction[] = [];
+  let entries: VaultEntry[] = [];
+
+  const rsu = {
+    kind: "RSU" as const,
+    employer: "Acme",
+    asset: { type: "CRYPTO" as const, symbol: "ACME" },
+    cashAsset: USD,
+    account: "Brokerage",
+    totalUnits: 400,
+    grantAt: "2024-01-15T00:00:00.000Z",
+    cliffMonths: 12,
+    vestingMonths: 48,
+    frequencyMonths: 3,
+    withholdingRate: 0.22,
+    discount: 0,
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    grants = [];
+    events = [];
+    txs = [];
+    entries = [];
+
+    vi.doMock("../src/repositories", () => ({
+      vestingRepository: {
+        findAll: () => grants,
+        findById: (id: string) => grants.find((g) => g.id === id),
+        create: (g: VestingGrant) => {
+          grants.push(g);
+          return g;
+        },
+        update: (id: string, updates: Partial<VestingGrant>) => {
+          const g = grants.find((x) => x.id === id);
+          if (g) Object.assign(g, updates);
+          return g;
+        },
+        findEvents: (grantId: string) =>
+          events.filter((e) => e.grantId === grantId),
+        findDueEvents: (until: string) =>
+          events.filter((e) => e.status === "SCHEDULED" && e.vestAt <= until),
+        createEvent: (e: VestEvent) => {
+          events.push(e);
+          return e;
+        },
+        updateEvent: (id: string, updates: Partial<VestEvent>) => {
+          const e = events.find((x) => x.id === id);
+          if (e) Object.assign(e, updates);
+          return e;
+        },
+      },
+      transactionRepository: {
+        create: (t: Transaction) => {
+          txs.push(t);
+          return t;
+        },
+      },
+      vaultRepository: {
+        findByName: (name: string) =>
+          name === "Brokerage" ? { name, status: "ACTIVE" } : undefined,
+      },
+      settingsRepository: { getDefaultSpendingVaultName: () => "Spend" },
+      borrowingRepository: {},
+      adminRepository: {},
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: {
+        addVaultEntry: (e: VaultEntry) => {
+          entries.push(e);
+          return e;
+        },
+      },
+    }));
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: {
+        getRateUSD: async (asset: any) => ({
+          asset,
+          rateUSD: asset.symbol === "ACME" ? 100 : 1,
+          source: asset.symbol === "ACME" ? "COINGECKO" : "FIXED",
+        }),
+      },
+    }));
+  });
+
+  it("schedules the cliff and quarterly vests", async () => {
+    const { vestingService } = await import(
+      "../src/services/vesting.service"
+    );
+    const { events: scheduled } = vestingService.createGrant(rsu);
+
+    expect(scheduled).toHaveLength(13);
+    expect(scheduled[0].vestAt.slice(0, 10)).toBe("2025-01-15");
+    expect(scheduled[0].units).toBe(100);
+    expect(scheduled[1].units).toBe(25);
+    expect(scheduled.reduce((s, e) => s + e.units, 0)).toBe(400);
+  });
+
+  it("posts income and a buy net of withholding", async () => {
+    const { vestingService } = await import(
+      "../src/services/vesting.service"
+    );
+    vestingService.createGrant(rsu);
+
+    const vested = await vestingService.processDueVests(
+      new Date("2025-02-01T00:00:00Z"),
+    );
+    expect(vested).toHaveLength(1);
+    expect(vested[0]).toMatchObject({
+      status: "VESTED",
+      fmv: 100,
+      withheldUnits: 22,
+      netUnits: 78,
+      incomeUSD: 7800,
+    });
+
+    expect(txs.map((t) => t.type)).toEqual([
+      "INCOME",
+      "TRANSFER_OUT",
+      "TRANSFER_IN",
+    ]);
+    expect(new Set(txs.map((t) => t.transferId)).size).toBe(1);
+    expect(txs[0]).toMatchObject({ category: "rsu_vest", amount: 7800 });
+    expect(entries[0]).toMatchObject({
+      type: "DEPOSIT",
+      amount: 78,
+      usdValue: 7800,
+    });
+  });
+
+  it("books only the ESPP discount as income", async () => {
+    const { vestingService } = await import(
+      "../src/services/vesting.service"
+    );
+    const { grant } = vestingService.createGrant({
+      ...rsu,
+      kind: "ESPP",
+      totalUnits: 0,
+      withholdingRate: 0,
+      discount: 0.15,
+    });
+
+    const event = await vestingService.addEvent(grant.id, {
+      vestAt: "2025-06-30T00:00:00.000Z",
+      units: 10,
+    });
+    expect(event.purchasePrice).toBe(85);
+    expect(event.incomeUSD).toBeCloseTo(150, 9);
+    expect(txs[0].category).toBe("espp_discount");
+    // Buy leg carries the full market value as cost basis
+    expect(txs[2].usdAmount).toBe(1000);
+  });
+});
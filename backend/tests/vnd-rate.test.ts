@@ -0,0 +1,50 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * VND per USD for the _vnd figures
+ *
+ * - The inverse of the VND rate
+ * - Falls back to 24000 with a warning when the rate is unknown or the
+ *   lookup throws
+ */
+
+describe("usdToVnd", () => {
+  const getRateUSD = vi.fn();
+  const warn = vi.fn();
+
+  beforeEach(() => {
+    vi.resetModules();
+    getRateUSD.mockReset();
+    warn.mockClear();
+    vi.doMock("../src/services/price.service", () => ({
+      priceService: { getRateUSD },
+    }));
+    vi.doMock("../src/utils/logger", () => ({
+      logger: { warn, info: vi.fn(), debug: vi.fn(), error: vi.fn() },
+    }));
+  });
+
+  const load = async () =>
+    (await import("../src/services/vnd-rate.service")).usdToVnd;
+
+  it("inverts the VND rate", async () => {
+    getRateUSD.mockResolvedValue({ rateUSD: 1 / 25000 });
+    const usdToVnd = await load();
+
+    expect(await usdToVnd()).toBeCloseTo(25000);
+    expect(getRateUSD).toHaveBeenCalledWith({ type: "FIAT", symbol: "VND" });
+    expect(warn).not.toHaveBeenCalled();
+  });
+
+  it("warns when it falls back", async () => {
+    const usdToVnd = await load();
+
+    getRateUSD.mockResolvedValueOnce({ rateUSD: 0, missing: true });
+    expect(await usdToVnd()).toBe(24000);
+    getRateUSD.mockRejectedValueOnce(new Error("offline"));
+    expect(await usdToVnd()).toBe(24000);
+
+    expect(warn).toHaveBeenCalledTimes(2);
+    expect(warn.mock.calls[1][0]).toMatchObject({ error: "offline" });
+  });
+});
@@ -0,0 +1,190 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * EVM wallets
+ *
+ * - Native, internal and ERC-20 transfers become transfer_in/transfer_out
+ *   transactions; gas paid becomes a network_fee expense
+ * - Only the listed tokens are synced when the wallet lists any
+ * - A sync resumes after the last block read and records nothing twice
+ */
+
+const me = "0x" + "a".repeat(40);
+const friend = "0x" + "b".repeat(40);
+const usdc = "0x" + "c".repeat(40);
+const spam = "0x" + "d".repeat(40);
+const sec = (iso: string) => String(Date.parse(iso) / 1000);
+
+describe("Wallet Service", () => {
+  let stored: any[] = [];
+  let ledger: any[] = [];
+  const get = vi.fn();
+
+  const explorer = (action: string): any[] => {
+    if (action === "txlist") {
+      return [
+        {
+          blockNumber: "100",
+          timeStamp: sec("2025-03-01T00:00:00.000Z"),
+          hash: "0x01",
+          from: friend.toUpperCase().replace("0X", "0x"),
+          to: me,
+          value: "1500000000000000000", // 1.5 ETH
+          gasUsed: "21000",
+          gasPrice: "1000000000",
+          isError: "0",
+        },
+        {
+          blockNumber: "120",
+          timeStamp: sec("2025-03-02T00:00:00.000Z"),
+          hash: "0x02",
+          from: me,
+          to: friend,
+          value: "250000000000000000",
+          gasUsed: "21000",
+          gasPrice: "2000000000", // 0.000042 ETH of gas
+          isError: "0",
+        },
+      ];
+    }
+    if (action === "tokentx") {
+      return [
+        {
+          blockNumber: "130",
+          timeStamp: sec("2025-03-03T00:00:00.000Z"),
+          hash: "0x03",
+          logIndex: "4",
+          from: friend,
+          to: me,
+          value: "12500000",
+          tokenSymbol: "USDC",
+          tokenDecimal: "6",
+          contractAddress: usdc,
+        },
+        {
+          blockNumber: "131",
+          timeStamp: sec("2025-03-03T01:00:00.000Z"),
+          hash: "0x04",
+          logIndex: "0",
+          from: friend,
+          to: me,
+          value: "1000",
+          tokenSymbol: "CLAIM-REWARDS",
+          tokenDecimal: "0",
+          contractAddress: spam,
+        },
+      ];
+    }
+    return [];
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    stored = [];
+    ledger = [];
+    get.mockReset().mockImplementation(async (_url: string, opts: any) => ({
+      data: {
+        status: "1",
+        message: "OK",
+        result: explorer(opts.params.action),
+      },
+    }));
+    vi.doMock("axios", () => ({ default: { get } }));
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getEvmWallets: () => stored,
+        setEvmWallets: (w: any[]) => (stored = w),
+      },
+      transactionRepository: {
+        findBySourceRef: (ref: string) =>
+          ledger.find((t) => t.sourceRef === ref),
+        create: (t: any) => ledger.push(t),
+      },
+    }));
+    vi.doMock("../src/services/transaction.service", () => ({
+      transactionService: {
+        buildTransactionBase: async (
+          asset: any,
+          amount: number,
+          at: string,
+          account: string,
+        ) => ({ asset, amount, createdAt: at, account }),
+      },
+    }));
+    vi.doMock("../src/services/address-book.service", () => ({
+      addressBookService: {
+        resolve: (a: string) => (a === friend ? { name: "Alice" } : undefined),
+      },
+    }));
+  });
+
+  it("records transfers, gas and listed tokens once", async () => {
+    const { walletService } = await import("../src/services/wallet.service");
+    const w = walletService.create({
+      chain: "ethereum",
+      address: me,
+      name: "Ledger",
+      tokens: [usdc],
+      intervalHours: 6,
+      active: true,
+    });
+
+    const run = await walletService.sync(w.id);
+    expect(run).toMatchObject({
+      status: "OK",
+      created: { received: 2, sent: 1, fees: 1 },
+      skipped: 0,
+    });
+    expect(
+      ledger.map((t) => [t.sourceRef, t.type, t.asset.symbol, t.amount]),
+    ).toEqual([
+      ["evm:ethereum:0x01:in", "TRANSFER_IN", "ETH", 1.5],
+      ["evm:ethereum:0x02:out", "TRANSFER_OUT", "ETH", 0.25],
+      ["evm:ethereum:0x02:fee", "EXPENSE", "ETH", 0.000042],
+      ["evm:ethereum:0x03:token:4:in", "TRANSFER_IN", "USDC", 12.5],
+    ]);
+    expect(ledger[0]).toMatchObject({
+      account: "Ledger",
+      chain: "ethereum",
+      counterparty: "Alice",
+      createdAt: "2025-03-01T00:00:00.000Z",
+    });
+    expect(ledger[2].category).toBe("network_fee");
+    expect(walletService.addressesByAccount().get("Ledger")).toEqual([me]);
+
+    get.mockClear();
+    const again = await walletService.sync(w.id);
+    expect(again.created).toEqual({ received: 0, sent: 0, fees: 0 });
+    expect(again.skipped).toBe(3);
+    expect(ledger).toHaveLength(4);
+
+    const start = (action: string) =>
+      get.mock.calls.find(([, o]) => o.params.action === action)![1].params
+        .startblock;
+    expect(start("txlist")).toBe(121);
+    expect(start("tokentx")).toBe(132);
+    expect(start("txlistinternal")).toBe(0); // nothing there yet
+  });
+
+  it("refuses a wallet tracked twice and reports explorer errors", async () => {
+    const { walletService } = await import("../src/services/wallet.service");
+    const req = {
+      chain: "bsc" as const,
+      address: me,
+      name: "Hot",
+      tokens: [],
+      intervalHours: 6,
+      active: true,
+    };
+    const w = walletService.create(req);
+    expect(() => walletService.create(req)).toThrow(/already tracked/);
+
+    get.mockResolvedValueOnce({
+      data: { status: "0", message: "NOTOK", result: "Invalid API Key" },
+    });
+    const run = await walletService.sync(w.id);
+    expect(run).toMatchObject({ status: "FAILED", error: "Invalid API Key" });
+    expect(walletService.get(w.id)!.lastStatus).toBe("FAILED");
+    expect(get.mock.calls[0][1].params.chainid).toBe(56);
+  });
+});
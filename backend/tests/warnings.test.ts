@@ -0,0 +1,129 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Soft create warnings
+ *
+ * - Missing rates and rates days away from the transaction are flagged
+ * - A counterparty seen on no other transaction is flagged as new
+ * - Outflows that overdraw a vault are flagged; nothing is rejected
+ * - Writes dated in a closed fiscal year are flagged
+ */
+
+type Transaction = import("../src/types").Transaction;
+
+describe("Warning Service", () => {
+  const VND = { type: "FIAT" as const, symbol: "VND" };
+  let txs: Transaction[] = [];
+  let remaining = 0;
+  let closes: { year: number; start: string; end: string }[] = [];
+
+  const tx = (id: string, extra: Partial<Transaction> = {}) =>
+    ({
+      id,
+      type: "EXPENSE",
+      asset: VND,
+      amount: 100000,
+      createdAt: "2025-01-10T10:00:00.000Z",
+      account: "Spend",
+      rate: {
+        asset: VND,
+        rateUSD: 0.00004,
+        timestamp: "2025-01-10T00:00:00.000Z",
+        source: "ER_API",
+      },
+      usdAmount: 4,
+      ...extra,
+    }) as Transaction;
+
+  beforeEach(() => {
+    vi.resetModules();
+    remaining = 500000;
+    closes = [];
+    txs = [tx("old", { counterparty: "Pho Hung" })];
+
+    vi.doMock("../src/repositories", () => ({
+      transactionRepository: { findAll: () => txs },
+      vaultRepository: {
+        findByName: (name: string) =>
+          name === "Spend" ? { name, status: "ACTIVE" } : undefined,
+      },
+      yearCloseRepository: { findAll: () => closes },
+    }));
+    vi.doMock("../src/services/vault.service", () => ({
+      vaultService: { remainingQuantity: async () => remaining },
+    }));
+  });
+
+  it("flags missing and far-off rates", async () => {
+    const { warningService } = await import(
+      "../src/services/warning.service"
+    );
+    const missing = tx("a", {
+      rate: { ...tx("a").rate, rateUSD: 0, source: "FIXED", missing: true },
+    });
+    const old = tx("b", {
+      rate: { ...tx("b").rate, timestamp: "2025-01-06T00:00:00.000Z" },
+    });
+
+    expect(await warningService.forTransaction(tx("c"))).toEqual([]);
+    expect((await warningService.forTransaction(missing))[0]).toMatch(
+      /No FX rate for VND/,
+    );
+    expect(await warningService.forTransaction(old)).toEqual([
+      "FX rate for VND is 4 days off the transaction date",
+    ]);
+  });
+
+  it("flags counterparties not seen on other transactions", async () => {
+    const { warningService } = await import(
+      "../src/services/warning.service"
+    );
+    const known = tx("a", { counterparty: " pho hung" });
+    const fresh = tx("b", { counterparty: "Bun Cha 34" });
+    txs.push(known, fresh);
+
+    expect(await warningService.forTransaction(known)).toEqual([]);
+    expect(await warningService.forTransaction(fresh)).toEqual([
+      '"Bun Cha 34" is a new counterparty',
+    ]);
+  });
+
+  it("flags outflows that take a vault below zero", async () => {
+    const { warningService } = await import(
+      "../src/services/warning.service"
+    );
+    remaining = -20000;
+
+    expect(await warningService.forTransaction(tx("a"))).toEqual([
+      'VND balance of "Spend" goes negative (-20000)',
+    ]);
+    expect(
+      await warningService.forTransaction(tx("b", { type: "INCOME" })),
+    ).toEqual([]);
+    expect(
+      await warningService.forTransaction(tx("c", { account: "Wallet" })),
+    ).toEqual([]);
+  });
+
+  it("flags writes dated in a closed fiscal year", async () => {
+    const { warningService } = await import(
+      "../src/services/warning.service"
+    );
+    closes = [
+      {
+        year: 2024,
+        start: "2024-01-01T00:00:00.000Z",
+        end: "2024-12-31T23:59:59.999Z",
+      },
+    ];
+
+    expect(await warningService.forTransaction(tx("a"))).toEqual([]);
+    const backdated = tx("b", {
+      createdAt: "2024-12-31T10:00:00.000Z",
+      rate: { ...tx("b").rate, timestamp: "2024-12-31T00:00:00.000Z" },
+    });
+    expect((await warningService.forTransaction(backdated))[0]).toMatch(
+      /fiscal year 2024, which is closed/,
+    );
+  });
+});
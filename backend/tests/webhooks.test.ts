@@ -0,0 +1,138 @@
+import crypto from "crypto";
+import { describe, it, expect, vi, beforeEach, afterEach } from "vitest";
+import {
+  EventBus,
+  withTransactionEvents,
+} from "../src/services/event-bus.service";
+
+/**
+ * Domain events and webhooks
+ *
+ * - Transaction writes through the repository publish events
+ * - Deliveries are signed over "<timestamp>.<body>" and retried on 5xx
+ */
+
+describe("Event bus", () => {
+  it("publishes transaction writes and isolates failing handlers", () => {
+    const bus = new EventBus();
+    const seen: string[] = [];
+    bus.subscribe(() => {
+      throw new Error("handler bug");
+    });
+    const stop = bus.subscribe((e) =>
+      seen.push(`${e.type}:${(e.data as any).id}`),
+    );
+    const rows = new Map<string, any>();
+    const repo = withTransactionEvents(
+      {
+        create: (t: any) => (rows.set(t.id, t), t),
+        createMany: (ts: any[]) => ts,
+        update: (id: string, u: any) =>
+          rows.has(id) ? { ...rows.get(id), ...u } : undefined,
+        delete: (id: string) => rows.delete(id),
+        findById: (id: string) => rows.get(id),
+        findAll: () => Array.from(rows.values()),
+      } as any,
+      bus,
+    );
+
+    repo.create({ id: "a" } as any);
+    repo.update("a", { note: "x" });
+    repo.update("missing", { note: "x" });
+    repo.delete("a");
+    expect(repo.findAll()).toEqual([]);
+    stop();
+    repo.create({ id: "b" } as any);
+
+    expect(seen).toEqual([
+      "transaction.created:a",
+      "transaction.updated:a",
+      "transaction.deleted:a",
+    ]);
+  });
+});
+
+describe("Webhook Service", () => {
+  const post = vi.fn();
+  const updates: any[] = [];
+  const hook = {
+    id: "h1",
+    url: "https://hooks.example.com/nami",
+    events: ["transaction.created"],
+    secret: "0123456789abcdef",
+    active: true,
+    createdAt: "2025-01-01T00:00:00.000Z",
+  };
+
+  beforeEach(() => {
+    vi.resetModules();
+    vi.useFakeTimers();
+    post.mockReset();
+    updates.length = 0;
+    vi.doMock("axios", () => ({ default: { post } }));
+    vi.doMock("../src/repositories", () => ({
+      webhookRepository: {
+        findAll: () => [hook],
+        findById: () => hook,
+        update: (_id: string, u: any) => (updates.push(u), { ...hook, ...u }),
+      },
+    }));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it("signs the body and retries server errors", async () => {
+    post
+      .mockResolvedValueOnce({ status: 503 })
+      .mockResolvedValueOnce({ status: 204 });
+    const { webhookService } = await import(
+      "../src/services/webhook.service"
+    );
+
+    const pending = webhookService.deliver(hook, {
+      id: "evt-1",
+      type: "transaction.created",
+      at: "2025-01-02T00:00:00.000Z",
+      data: { id: "tx-1" },
+    });
+    await vi.advanceTimersByTimeAsync(1000);
+    const result = await pending;
+
+    expect(result).toEqual({ status: 204, attempts: 2, error: undefined });
+    const [url, body, opts] = post.mock.calls[1];
+    expect(url).toBe(hook.url);
+    const ts = opts.headers["X-Nami-Timestamp"];
+    const expected = crypto
+      .createHmac("sha256", hook.secret)
+      .update(`${ts}.${body}`)
+      .digest("hex");
+    expect(opts.headers["X-Nami-Signature"]).toBe(`sha256=${expected}`);
+    expect(opts.headers["X-Nami-Delivery"]).toBe("evt-1");
+    expect(updates[0]).toMatchObject({ lastStatus: 204 });
+  });
+
+  it("keeps client errors without retrying", async () => {
+    post.mockResolvedValue({ status: 410 });
+    const { webhookService } = await import(
+      "../src/services/webhook.service"
+    );
+
+    const result = await webhookService.test("h1");
+
+    expect(result).toEqual({ status: 410, attempts: 1, error: "HTTP 410" });
+    expect(updates[0]).toMatchObject({
+      lastStatus: 410,
+      lastError: "HTTP 410",
+    });
+    // Event types are checked when registering
+    expect(() =>
+      webhookService.create({
+        url: hook.url,
+        events: ["expense.created"],
+        active: true,
+      }),
+    ).toThrow(/Unknown event types: expense.created/);
+  });
+});
@@ -0,0 +1,169 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+/**
+ * Year-end close
+ *
+ * - Closing archives net worth, holdings, balances and PnL once
+ * - A year closes only after it ends, and only once
+ * - The scheduler closes the previous year a month after it ends
+ */
+
+type YearClose = import("../src/types").YearClose;
+
+describe("Year Close Service", () => {
+  const btc = { type: "CRYPTO", symbol: "BTC" };
+  let closes: YearClose[] = [];
+  const diff = vi.fn();
+
+  const entry = (
+    type: string,
+    vault: string,
+    amount: number,
+    at: string,
+  ) => ({
+    vault,
+    type,
+    asset: btc,
+    amount,
+    usdValue: amount * 50000,
+    at,
+  });
+  const entries = [
+    entry("DEPOSIT", "Cold", 1, "2024-03-01T00:00:00.000Z"),
+    entry("WITHDRAW", "Cold", 0.25, "2024-09-01T00:00:00.000Z"),
+    entry("DEPOSIT", "Hot", 0.25, "2024-09-01T00:00:00.000Z"),
+  ];
+
+  beforeEach(() => {
+    vi.resetModules();
+    closes = [];
+    diff.mockReset().mockResolvedValue({
+      from: { date: "2023-12-31", positions: [], totalUSD: 0 },
+      to: {
+        date: "2024-12-31",
+        positions: [
+          { asset: btc, quantity: 1, rateUSD: 90000, valueUSD: 90000 },
+        ],
+        totalUSD: 90000,
+      },
+      netContributionsUSD: 50000,
+      marketMovementUSD: 40000,
+      investmentGainsUSD: 40000,
+      fxEffectUSD: 0,
+    });
+
+    vi.doMock("../src/repositories", () => ({
+      settingsRepository: {
+        getFiscalYear: () => ({ start: "01-01", starts: {} }),
+      },
+      vaultRepository: {
+        findAllEntriesUntil: (end: string) =>
+          entries.filter((e) => e.at <= end),
+      },
+      yearCloseRepository: {
+        findAll: () => closes,
+        findByYear: (year: number) => closes.find((c) => c.year === year),
+        create: (c: YearClose) => {
+          closes.push(c);
+          return c;
+        },
+      },
+    }));
+    vi.doMock("../src/services/snapshot.service", () => ({
+      snapshotService: { diff },
+    }));
+    vi.doMock("../src/services/networth.service", () => ({
+      netWorthService: {
+        series: () => ({
+          points: [
+            {
+              date: "2024-12-31",
+              assets_usd: 90000,
+              liabilities_usd: 5000,
+              net_worth_usd: 85000,
+              net_worth_vnd: 85000 * 25000,
+            },
+          ],
+          unpriced: [],
+        }),
+      },
+    }));
+    vi.doMock("../src/services/tax.service", () => ({
+      taxService: {
+        report: () => ({
+          gains: { total: { gainUSD: 1000 } },
+          incomeUSD: 200,
+          fees: { totalUSD: 15 },
+        }),
+      },
+    }));
+  });
+
+  it("archives the year-end figures once", async () => {
+    const { yearCloseService } = await import(
+      "../src/services/year-close.service"
+    );
+    const now = new Date("2025-01-05T00:00:00.000Z");
+    const c = await yearCloseService.close(2024, { note: "Filed", now });
+
+    expect(diff).toHaveBeenCalledWith("2023-12-31", "2024-12-31");
+    expect(c).toMatchObject({
+      year: 2024,
+      start: "2024-01-01T00:00:00.000Z",
+      end: "2024-12-31T23:59:59.999Z",
+      auto: false,
+      note: "Filed",
+    });
+    expect(c.snapshot).toMatchObject({
+      netWorthUSD: 85000,
+      liabilitiesUSD: 5000,
+      holdingsUSD: 90000,
+      pnl: {
+        openingUSD: 0,
+        netContributionsUSD: 50000,
+        marketMovementUSD: 40000,
+        realizedGainUSD: 1000,
+        incomeUSD: 200,
+        feesUSD: 15,
+      },
+    });
+    expect(c.snapshot.balances.map((b) => [b.vault, b.quantity])).toEqual([
+      ["Cold", 0.75],
+      ["Hot", 0.25],
+    ]);
+    expect(yearCloseService.get(2024)).toBe(c);
+
+    await expect(yearCloseService.close(2024, { now })).rejects.toThrow(
+      /already closed/,
+    );
+  });
+
+  it("refuses to close a year that has not ended", async () => {
+    const { yearCloseService } = await import(
+      "../src/services/year-close.service"
+    );
+    await expect(
+      yearCloseService.close(2024, {
+        now: new Date("2024-12-31T12:00:00.000Z"),
+      }),
+    ).rejects.toThrow(/runs until 2024-12-31/);
+    expect(closes).toEqual([]);
+  });
+
+  it("closes the previous year a month after it ends", async () => {
+    const { yearCloseService } = await import(
+      "../src/services/year-close.service"
+    );
+    expect(
+      await yearCloseService.processDue(new Date("2025-01-20T00:00:00.000Z")),
+    ).toBeUndefined();
+
+    const c = await yearCloseService.processDue(
+      new Date("2025-02-01T00:00:00.000Z"),
+    );
+    expect(c).toMatchObject({ year: 2024, auto: true });
+    expect(
+      await yearCloseService.processDue(new Date("2025-02-02T00:00:00.000Z")),
+    ).toBeUndefined();
+  });
+});